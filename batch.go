@@ -0,0 +1,225 @@
+// Package ytt downloads YouTube transcripts in bulk. It is the library
+// behind the ytt command, for services that embed transcript downloading
+// rather than shell out to the CLI.
+package ytt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/n2p5/ytt/internal/batch"
+	"github.com/n2p5/ytt/internal/clock"
+	"github.com/n2p5/ytt/internal/transcript"
+	ytclient "github.com/n2p5/ytt/internal/youtube"
+	"google.golang.org/api/youtube/v3"
+)
+
+// BatchDownloader downloads the transcripts of every video its sources list
+// and saves them to its sink. Videos are downloaded concurrently, failures
+// are retried, and a failure or panic on one video doesn't stop the others.
+type BatchDownloader struct {
+	// YouTube is an authorized YouTube Data API service. Downloading
+	// captions requires OAuth with the youtube.force-ssl scope.
+	YouTube *youtube.Service
+	// Sources list the videos to download. A video listed by several
+	// sources is downloaded once.
+	Sources []Source
+	// Sink saves each transcript.
+	Sink Sink
+
+	// Concurrency is the number of videos downloaded at once. Values
+	// below 1 mean 1.
+	Concurrency int
+	// Retries is how many more times a video is tried after a transient
+	// failure, such as a server error, rate limiting, or a network error.
+	// Other failures, such as a video without captions or an exhausted
+	// quota, aren't retried.
+	Retries int
+	// RetryDelay is the wait before the first retry, doubling for each
+	// one after. Defaults to one second.
+	RetryDelay time.Duration
+	// Clock, if set, is used instead of the system clock to wait between
+	// retries, so tests don't have to wait in real time.
+	Clock Clock
+
+	// Format converts captions to "vtt", "srt", "sbv", "json", "txt", "html", "pdf", "epub", or "docx".
+	// Empty keeps the format YouTube provides.
+	Format string
+	// Process is a processing pipeline applied to each transcript, such as
+	// "dedupe,paragraphs", as accepted by the CLI's --process flag.
+	Process string
+
+	// OnStart, if set, is called as each video starts downloading.
+	OnStart func(videoID string)
+	// OnDone, if set, is called with each video's result as it finishes.
+	OnDone func(ItemResult)
+	// CrashDir, if set, is where crash reports for panics are written.
+	CrashDir string
+}
+
+// Clock tells the time and waits for it to pass. Setting one on a
+// BatchDownloader makes its retries deterministic in tests.
+type Clock = clock.Clock
+
+// Result is the outcome of a BatchDownloader run.
+type Result struct {
+	// Items has one result per video, in the order the sources listed them.
+	Items []ItemResult
+	// Succeeded and Failed count the items.
+	Succeeded int
+	Failed    int
+}
+
+// ItemResult is the outcome for one video.
+type ItemResult struct {
+	VideoID string
+	// Title is the video's title, if it was found.
+	Title string
+	// Attempts is how many times the video was tried.
+	Attempts int
+	// Err is why the video's last attempt failed, or nil.
+	Err error
+}
+
+// Err returns an error summarizing the failed items, or nil if there were
+// none.
+func (r *Result) Err() error {
+	if r.Failed == 0 {
+		return nil
+	}
+	errs := make([]error, 0, r.Failed)
+	for _, item := range r.Items {
+		if item.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.VideoID, item.Err))
+		}
+	}
+	return fmt.Errorf("%d of %d transcripts failed: %w", r.Failed, len(r.Items), errors.Join(errs...))
+}
+
+// Run lists the videos from every source and downloads their transcripts.
+// It returns an error only if the run couldn't start; per-video failures
+// are reported in the Result.
+func (d *BatchDownloader) Run(ctx context.Context) (*Result, error) {
+	if d.YouTube == nil {
+		return nil, errors.New("BatchDownloader has no YouTube service")
+	}
+	if d.Sink == nil {
+		return nil, errors.New("BatchDownloader has no sink")
+	}
+	opts, err := d.downloadOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	seen := map[string]bool{}
+	for _, src := range d.Sources {
+		listed, err := src.VideoIDs(ctx, d.YouTube)
+		if err != nil {
+			return nil, fmt.Errorf("error listing videos: %w", err)
+		}
+		for _, id := range listed {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	client := &ytclient.Client{Service: d.YouTube}
+	items := make(map[string]*ItemResult, len(ids))
+	for _, id := range ids {
+		items[id] = &ItemResult{VideoID: id}
+	}
+
+	results := batch.Run(ctx, ids, batch.Options{
+		Workers:  d.Concurrency,
+		CrashDir: d.CrashDir,
+		OnStart:  d.OnStart,
+		OnDone: func(r batch.Result) {
+			if d.OnDone != nil {
+				item := *items[r.ID]
+				item.Err = r.Err
+				d.OnDone(item)
+			}
+		},
+	}, func(ctx context.Context, id string) error {
+		return d.download(ctx, client, items[id], opts)
+	})
+
+	res := &Result{Items: make([]ItemResult, len(ids))}
+	for i, r := range results {
+		item := *items[r.ID]
+		if item.Err = r.Err; item.Err != nil {
+			res.Failed++
+		} else {
+			res.Succeeded++
+		}
+		res.Items[i] = item
+	}
+	return res, nil
+}
+
+// download tries to download and save a video's transcript, retrying
+// transient failures with backoff.
+func (d *BatchDownloader) download(ctx context.Context, client *ytclient.Client, item *ItemResult, opts ytclient.DownloadOptions) error {
+	delay := d.RetryDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for {
+		item.Attempts++
+		err := d.save(ctx, client, item, opts)
+		if err == nil || item.Attempts > d.Retries || !ytclient.IsTransient(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-clock.Or(d.Clock).After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (d *BatchDownloader) save(ctx context.Context, client *ytclient.Client, item *ItemResult, opts ytclient.DownloadOptions) error {
+	src, data, err := client.FetchCaptions(ctx, item.VideoID, false)
+	if err != nil {
+		return err
+	}
+	item.Title = src.Title
+	if data, err = ytclient.RenderTranscript(data, src, opts); err != nil {
+		return err
+	}
+	return d.Sink.Save(ctx, Transcript{
+		VideoID:     src.VideoID,
+		Title:       src.Title,
+		ChannelID:   src.ChannelID,
+		PublishedAt: src.PublishedAt,
+		Language:    src.Language,
+		Format:      string(opts.Format),
+		Process:     d.Process,
+		Filename:    ytclient.TranscriptFilename(src.VideoID, src.Title, opts.Format),
+		Data:        data,
+	})
+}
+
+func (d *BatchDownloader) downloadOptions() (ytclient.DownloadOptions, error) {
+	var opts ytclient.DownloadOptions
+	switch f := transcript.Format(d.Format); f {
+	case "", transcript.FormatVTT, transcript.FormatSRT, transcript.FormatSBV, transcript.FormatJSON, transcript.FormatPlain, transcript.FormatMD, transcript.FormatHTML, transcript.FormatPDF, transcript.FormatEPUB, transcript.FormatDOCX:
+		opts.Format = f
+	default:
+		return opts, fmt.Errorf("unsupported format %q (want vtt, srt, sbv, json, txt, md, html, pdf, epub, or docx)", f)
+	}
+	if d.Process != "" {
+		p, err := transcript.ParsePipeline(d.Process)
+		if err != nil {
+			return opts, err
+		}
+		opts.Process = p
+	}
+	return opts, nil
+}
@@ -0,0 +1,221 @@
+package ytt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/clock"
+	"github.com/n2p5/ytt/internal/manifest"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+// fakeYouTube serves videos v1 to v3 and down, each with one English
+// caption track. Downloading v2's captions fails the first time, and
+// down's every time.
+func fakeYouTube(t *testing.T) *youtube.Service {
+	t.Helper()
+	var mu sync.Mutex
+	downloads := map[string]int{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/youtube/v3/videos", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		if id == "missing" {
+			fmt.Fprint(w, `{"items":[]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"items":[{"id":%q,"snippet":{"title":"Video %s","channelId":"UC1"}}]}`, id, id)
+	})
+	mux.HandleFunc("/youtube/v3/captions", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("videoId")
+		fmt.Fprintf(w, `{"items":[{"id":"cap-%s","snippet":{"language":"en"}}]}`, id)
+	})
+	mux.HandleFunc("/youtube/v3/captions/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/youtube/v3/captions/cap-")
+		mu.Lock()
+		downloads[id]++
+		n := downloads[id]
+		mu.Unlock()
+		if id == "v2" && n == 1 || id == "down" {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "1\n00:00:01,000 --> 00:00:02,000\nhello from %s\n", id)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	service, err := youtube.NewService(context.Background(),
+		option.WithEndpoint(server.URL),
+		option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+func TestBatchDownloader(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewDirSink(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var done []string
+	d := &BatchDownloader{
+		YouTube:     fakeYouTube(t),
+		Sources:     []Source{Videos("v1", "v2", "missing"), Videos("v3", "v1")},
+		Sink:        sink,
+		Concurrency: 2,
+		Retries:     1,
+		RetryDelay:  1,
+		Format:      "txt",
+		OnDone: func(item ItemResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			done = append(done, item.VideoID)
+		},
+	}
+	res, err := d.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	type item struct {
+		id       string
+		attempts int
+		failed   bool
+	}
+	want := []item{{"v1", 1, false}, {"v2", 2, false}, {"missing", 1, true}, {"v3", 1, false}}
+	if len(res.Items) != len(want) {
+		t.Fatalf("Run() returned %d items, want %d", len(res.Items), len(want))
+	}
+	for i, w := range want {
+		got := res.Items[i]
+		if got.VideoID != w.id || got.Attempts != w.attempts || (got.Err != nil) != w.failed {
+			t.Errorf("item %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if res.Succeeded != 3 || res.Failed != 1 || res.Err() == nil {
+		t.Errorf("Run() succeeded %d, failed %d, err %v; want 3, 1, and an error", res.Succeeded, res.Failed, res.Err())
+	}
+	if len(done) != 4 {
+		t.Errorf("OnDone called %d times, want 4", len(done))
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "v2-Video v2.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello from v2\n" {
+		t.Errorf("v2 transcript = %q", data)
+	}
+	m, err := manifest.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := m.Entries["v3"]; e.File != "v3-Video v3.txt" || e.Format != "txt" || e.Status != manifest.StatusOK {
+		t.Errorf("manifest entry for v3 = %+v", e)
+	}
+}
+
+// recordingClock is a fake clock that records the delays it is asked to
+// wait.
+type recordingClock struct {
+	*clock.Fake
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func (c *recordingClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.delays = append(c.delays, d)
+	c.mu.Unlock()
+	return c.Fake.After(d)
+}
+
+func TestBatchDownloaderBackoff(t *testing.T) {
+	clk := &recordingClock{Fake: clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))}
+	d := &BatchDownloader{
+		YouTube:    fakeYouTube(t),
+		Sources:    []Source{Videos("down")},
+		Sink:       SinkFunc(func(context.Context, Transcript) error { return nil }),
+		Retries:    3,
+		RetryDelay: 10 * time.Second,
+		Clock:      clk,
+	}
+
+	done := make(chan *Result)
+	go func() {
+		res, err := d.Run(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		done <- res
+	}()
+	for _, delay := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second} {
+		clk.BlockUntil(1)
+		clk.Advance(delay)
+	}
+	res := <-done
+
+	if got := res.Items[0].Attempts; got != 4 {
+		t.Errorf("Attempts = %d, want 4", got)
+	}
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second}
+	if !reflect.DeepEqual(clk.delays, want) {
+		t.Errorf("retry delays = %v, want %v", clk.delays, want)
+	}
+}
+
+func TestBatchDownloaderCancel(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	service, err := youtube.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &BatchDownloader{
+		YouTube: service,
+		Sources: []Source{Videos("v1")},
+		Sink:    SinkFunc(func(context.Context, Transcript) error { return nil }),
+		Retries: 3,
+	}
+	go func() {
+		<-started
+		cancel()
+	}()
+	done := make(chan *Result)
+	go func() {
+		res, _ := d.Run(ctx)
+		done <- res
+	}()
+	select {
+	case res := <-done:
+		if item := res.Items[0]; item.Err == nil || item.Attempts != 1 {
+			t.Errorf("canceled item = %+v, want one failed attempt", item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() didn't stop when its context was canceled")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// A token belongs to the one channel picked when signing in, so each
+// Brand Account channel a Google account manages is signed in separately,
+// with "ytt auth login --add-account". Those accounts' tokens are kept
+// beside the default token, named for their channel, and listed in
+// accountsFile so --account can pick one by ID or handle.
+
+// accountsFile lists the added accounts, beside the default token.
+const accountsFile = "accounts.json"
+
+// defaultToken is the token path before --account picked another.
+var defaultToken string
+
+// accountsPath returns where the added accounts are listed.
+func accountsPath() string {
+	return filepath.Join(filepath.Dir(defaultToken), accountsFile)
+}
+
+// accountTokenPath returns the token path of the account for channelID.
+func accountTokenPath(channelID string) string {
+	ext := filepath.Ext(defaultToken)
+	return strings.TrimSuffix(defaultToken, ext) + "-" + channelID + ext
+}
+
+// loadAccounts returns the added accounts. There are none if the file
+// doesn't exist.
+func loadAccounts() ([]youtube.ChannelSummary, error) {
+	data, err := os.ReadFile(accountsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading accounts: %w", err)
+	}
+	var accounts []youtube.ChannelSummary
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", accountsPath(), err)
+	}
+	return accounts, nil
+}
+
+// addAccount records ch as an added account, replacing any earlier record
+// of the channel.
+func addAccount(ch youtube.ChannelSummary) error {
+	accounts, err := loadAccounts()
+	if err != nil {
+		return err
+	}
+	accounts = slices.DeleteFunc(accounts, func(a youtube.ChannelSummary) bool { return a.ChannelID == ch.ChannelID })
+	accounts = append(accounts, ch)
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(accountsPath()), 0700); err != nil {
+		return fmt.Errorf("error creating accounts directory: %w", err)
+	}
+	if err := os.WriteFile(accountsPath(), append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing accounts: %w", err)
+	}
+	return nil
+}
+
+// findAccount returns the added account for a channel ID or handle.
+func findAccount(name string) (youtube.ChannelSummary, error) {
+	accounts, err := loadAccounts()
+	if err != nil {
+		return youtube.ChannelSummary{}, err
+	}
+	for _, a := range accounts {
+		if a.ChannelID == name || (a.Handle != "" && strings.EqualFold(strings.TrimPrefix(a.Handle, "@"), strings.TrimPrefix(name, "@"))) {
+			return a, nil
+		}
+	}
+	return youtube.ChannelSummary{}, fmt.Errorf("no account for %s; add it with \"ytt auth login --add-account\"", name)
+}
+
+// selectAccount switches the token to the account --account names, if any.
+// Adding an account signs in afresh, so the configured account isn't
+// selected for it, and needn't exist.
+func selectAccount(cmd *cobra.Command) error {
+	defaultToken = viper.GetString("token")
+	name := viper.GetString("account")
+	if add, _ := cmd.Flags().GetBool("add-account"); name == "" || add {
+		return nil
+	}
+	a, err := findAccount(name)
+	if err != nil {
+		return err
+	}
+	viper.Set("token", accountTokenPath(a.ChannelID))
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/n2p5/ytt/internal/alert"
+	"github.com/n2p5/ytt/internal/notify"
+	"github.com/n2p5/ytt/internal/stats"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/viper"
+)
+
+// alertRules reads and validates the "alerts" list from the config file.
+func alertRules() ([]alert.Rule, error) {
+	var rules []alert.Rule
+	if err := viper.UnmarshalKey("alerts", &rules); err != nil {
+		return nil, fmt.Errorf("invalid alerts config: %w", err)
+	}
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// notifier returns the configured notifiers. Messages always go to stderr,
+// and also to notify.webhook when it is set.
+func notifier() notify.Notifier {
+	n := notify.Multi{notify.Writer{W: stderr}}
+	if url := viper.GetString("notify.webhook"); url != "" {
+		n = append(n, notify.Webhook{URL: url})
+	}
+	return n
+}
+
+// runAlerts evaluates the configured alert rules for a channel's videos and
+// sends any that fire, skipping ones that already fired within their cooldown.
+func runAlerts(channelID string, videos []youtube.VideoInfo, now time.Time) error {
+	rules, err := alertRules()
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+
+	dataDir := viper.GetString("data_dir")
+	samples, err := stats.LoadChannel(filepath.Join(dataDir, "stats"), channelID)
+	if err != nil {
+		return err
+	}
+
+	statePath := filepath.Join(dataDir, "alerts.json")
+	state, err := alert.LoadState(statePath)
+	if err != nil {
+		return err
+	}
+
+	alerts := state.Filter(alert.Evaluate(rules, videos, samples, now), rules, now)
+	n := notifier()
+	for _, a := range alerts {
+		msg := notify.Message{Title: "ytt alert: " + a.Rule, Text: fmt.Sprintf("%s (https://youtu.be/%s)", a.Message, a.VideoID)}
+		if err := n.Notify(context.Background(), msg); err != nil {
+			warn(err)
+		}
+	}
+	return state.Save(statePath)
+}
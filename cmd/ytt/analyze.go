@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze <video_id|file>...",
+	Short: "Report word counts, speaking rate, and filler words for transcripts",
+	Long: `Report statistics on how a transcript was delivered: its word count, the
+speaking rate in words per minute while captions are on screen, vocabulary
+richness, how often filler words such as "um", "you know", and "like" are
+said, and how long the transcript takes to read.
+
+Vocabulary richness is the average share of distinct words in each run of
+100 words, from 0 to 1, so episodes of different lengths can be compared.
+"Like" is counted wherever it's said, including as a verb. Auto-generated
+captions leave out many "um"s and "uh"s, so uploaded captions give truer
+filler counts.
+
+Each argument is a caption file, or "-" for stdin, or otherwise a video ID,
+whose transcript is read from the output directory if it was downloaded
+there, and fetched from YouTube otherwise. Given several, one row is
+printed for each, to compare episodes.`,
+	Example: `  ytt analyze abc123
+  ytt analyze outputs/*.vtt`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAnalyze,
+}
+
+func init() {
+	analyzeCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+
+	rootCmd.AddCommand(analyzeCmd)
+}
+
+func runAnalyze(cmd *cobra.Command, args []string) error {
+	var results []analyzeResult
+	for _, in := range args {
+		cues, err := inputCues(in, viper.GetString("output"))
+		if err != nil {
+			if len(args) == 1 {
+				return err
+			}
+			warn(err)
+			continue
+		}
+		// Rolling auto-generated captions repeat each line, which would
+		// count every word twice.
+		if cues, err = transcript.Dedupe(transcript.Source{}, cues); err != nil {
+			return err
+		}
+		results = append(results, newAnalyzeResult(in, transcript.Analyze(cues)))
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no transcripts could be analyzed")
+	}
+
+	if jsonOutput() {
+		setResult(results)
+		return nil
+	}
+	if len(results) == 1 {
+		r := results[0]
+		fmt.Printf("Words:               %d (%d distinct)\n", r.Words, r.UniqueWords)
+		if r.WordsPerMinute > 0 {
+			fmt.Printf("Speaking rate:       %.0f words per minute over %s\n", r.WordsPerMinute, transcript.FormatTimestamp(r.stats.SpeakingTime))
+		}
+		fmt.Printf("Vocabulary richness: %.2f\n", r.Richness)
+		fmt.Printf("Filler words:        %.1f per 100 words", r.FillersPer100)
+		var fillers []string
+		for _, f := range r.stats.TopFillers() {
+			fillers = append(fillers, fmt.Sprintf("%s %d", f, r.Fillers[f]))
+		}
+		if len(fillers) > 0 {
+			fmt.Printf(" (%s)", strings.Join(fillers, ", "))
+		}
+		fmt.Println()
+		fmt.Printf("Reading time:        %s\n", transcript.FormatTimestamp(r.stats.ReadingTime))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TRANSCRIPT\tWORDS\tWPM\tRICHNESS\tFILLERS/100\tREADING")
+	for _, r := range results {
+		wpm := "-"
+		if r.WordsPerMinute > 0 {
+			wpm = fmt.Sprintf("%.0f", r.WordsPerMinute)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%.2f\t%.1f\t%s\n", r.Input, r.Words, wpm, r.Richness, r.FillersPer100, transcript.FormatTimestamp(r.stats.ReadingTime))
+	}
+	return w.Flush()
+}
+
+// inputCues reads the cues of a caption file, stdin for "-", or otherwise
+// the video in names, from the output directory or YouTube.
+func inputCues(in, outputDir string) ([]transcript.Cue, error) {
+	if !isCaptionFile(in) {
+		return loadCues(in, outputDir)
+	}
+	_, data, err := readCaptions(in)
+	if err != nil {
+		return nil, err
+	}
+	cues, err := transcript.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", in, err)
+	}
+	return cues, nil
+}
+
+// analyzeResult is a transcript's statistics under --json.
+type analyzeResult struct {
+	Input          string         `json:"input"`
+	Words          int            `json:"words"`
+	UniqueWords    int            `json:"unique_words"`
+	Richness       float64        `json:"richness"`
+	SpeakingTime   float64        `json:"speaking_time"`
+	WordsPerMinute float64        `json:"words_per_minute"`
+	Fillers        map[string]int `json:"fillers"`
+	FillersPer100  float64        `json:"fillers_per_100_words"`
+	ReadingTime    float64        `json:"reading_time"`
+
+	stats transcript.Stats
+}
+
+func newAnalyzeResult(in string, s transcript.Stats) analyzeResult {
+	return analyzeResult{
+		Input:          in,
+		Words:          s.Words,
+		UniqueWords:    s.UniqueWords,
+		Richness:       s.Richness,
+		SpeakingTime:   s.SpeakingTime.Seconds(),
+		WordsPerMinute: s.WordsPerMinute,
+		Fillers:        s.Fillers,
+		FillersPer100:  s.FillersPer100,
+		ReadingTime:    s.ReadingTime.Seconds(),
+		stats:          s,
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/embed"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var askCmd = &cobra.Command{
+	Use:   "ask <question>",
+	Short: "Find the transcript passages that best answer a question",
+	Long: `Search the transcripts in the output directory by meaning rather than by
+words, and print the passages closest to the question, best first, each
+with a link to that moment of the video.
+
+Passages are embedded with the provider set in the config file, either an
+OpenAI-compatible endpoint (embed.url and embed.model, with the API key in
+YTT_EMBED_API_KEY) or a command (embed.command) that reads a JSON array of
+texts on stdin and prints a JSON array of vectors, such as a script running
+a local ONNX model. The vectors are kept in .ytt/embeddings.json in the
+output directory; each run embeds only the transcripts added or changed
+since the last, unless --no-update is given. Changing the provider embeds
+everything again.`,
+	Example: `  ytt ask "what did they say about interest rates?"
+  ytt ask --channel @GoogleDevelopers --top 10 "how do I migrate to the new API?"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAsk,
+}
+
+func init() {
+	askCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	askCmd.Flags().String("channel", "", "only search this channel's videos")
+	askCmd.RegisterFlagCompletionFunc("channel", completeChannelIDs)
+	askCmd.Flags().Int("top", 5, "number of passages to print")
+	askCmd.Flags().Bool("no-update", false, "search the index as it is, without embedding new or changed transcripts")
+
+	rootCmd.AddCommand(askCmd)
+}
+
+func runAsk(cmd *cobra.Command, args []string) error {
+	provider, err := embedProvider()
+	if err != nil {
+		return err
+	}
+	a, err := archive.Open(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	var channelID string
+	if channel := viper.GetString("channel"); channel != "" {
+		if channelID, err = resolveChannelOffline(channel); err != nil {
+			return err
+		}
+	}
+	ix, err := embed.Load(a.Dir)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer onInterrupt(cancel)()
+
+	if !viper.GetBool("no-update") {
+		summary, err := ix.Update(ctx, a, provider, func(videoID string) {
+			fmt.Fprintf(stderr, "Embedding %s\n", videoID)
+		})
+		if summary != nil && summary.Embedded+summary.Removed > 0 {
+			if err := ix.Save(a.Dir); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+		for _, err := range summary.Skipped {
+			warn(err)
+		}
+	} else if ix.Len() > 0 && ix.Provider != provider.Name() {
+		return fmt.Errorf("the embedding index in %s was built with another provider; run without --no-update to rebuild it", a.Dir)
+	}
+	if ix.Len() == 0 {
+		return fmt.Errorf("no transcripts in %s", a.Dir)
+	}
+
+	vectors, err := provider.Embed(ctx, args)
+	if err != nil {
+		return fmt.Errorf("error embedding the question: %w", err)
+	}
+	hits := ix.Search(vectors[0], viper.GetInt("top"), channelID)
+
+	if jsonOutput() {
+		results := make([]askResult, len(hits))
+		for i, h := range hits {
+			results[i] = askResult{
+				VideoID:   h.VideoID,
+				Title:     h.Title,
+				Timestamp: transcript.FormatTimestamp(h.Start),
+				Start:     h.Start.Seconds(),
+				End:       h.End.Seconds(),
+				URL:       transcript.WatchURL(h.VideoID, h.Start),
+				Score:     h.Score,
+				Text:      h.Text,
+			}
+		}
+		setResult(results)
+		return nil
+	}
+	for i, h := range hits {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s  %s [%s]  (%.2f)\n", transcript.WatchURL(h.VideoID, h.Start), h.Title, transcript.FormatTimestamp(h.Start), h.Score)
+		fmt.Println(h.Text)
+	}
+	return nil
+}
+
+// askResult is a passage under --json.
+type askResult struct {
+	VideoID   string  `json:"video_id"`
+	Title     string  `json:"title"`
+	Timestamp string  `json:"timestamp"`
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+	URL       string  `json:"url"`
+	Score     float64 `json:"score"`
+	Text      string  `json:"text"`
+}
+
+// embedTimeout is how long an embeddings request may take.
+const embedTimeout = 2 * time.Minute
+
+// embedProvider returns the embedding provider set in the config file.
+func embedProvider() (embed.Provider, error) {
+	if command := viper.GetString("embed.command"); command != "" {
+		return embed.Command{Command: command, Stderr: stderr}, nil
+	}
+	url, model := viper.GetString("embed.url"), viper.GetString("embed.model")
+	if url == "" || model == "" {
+		return nil, fmt.Errorf("no embedding provider: set embed.url and embed.model, or embed.command, in the config file")
+	}
+	key := viper.GetString("embed.api_key")
+	if key == "" {
+		key = os.Getenv("YTT_EMBED_API_KEY")
+	}
+	return embed.OpenAI{URL: url, Model: model, APIKey: key, Client: &http.Client{Timeout: embedTimeout}}, nil
+}
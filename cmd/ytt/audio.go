@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/n2p5/ytt/internal/audio"
+	"github.com/spf13/viper"
+)
+
+// audioBase returns where a video's audio is saved, without the extension
+// the downloader picks.
+func audioBase(outputDir, videoID string) string {
+	return filepath.Join(outputDir, "audio", videoID)
+}
+
+// audioDownloader returns the downloader set by the audio_downloader and
+// audio_args settings.
+func audioDownloader() audio.Command {
+	return audio.Command{Program: viper.GetString("audio_downloader"), Args: viper.GetStringSlice("audio_args")}
+}
+
+// checkAudioDownloader reports an error if --with-audio is given but the
+// configured downloader isn't installed.
+func checkAudioDownloader() error {
+	if !viper.GetBool("with-audio") {
+		return nil
+	}
+	program := viper.GetString("audio_downloader")
+	if _, err := exec.LookPath(program); err != nil {
+		return fmt.Errorf("--with-audio needs %s (set audio_downloader in the config file to use another program): %w", program, err)
+	}
+	return nil
+}
+
+// saveAudio downloads a video's audio track into the output directory for
+// --with-audio. Audio saved by an earlier run is kept. It returns the
+// audio's path, or "" if the video has none.
+func saveAudio(ctx context.Context, outputDir, videoID string) (string, error) {
+	base := audioBase(outputDir, videoID)
+	path, err := audio.Find(base)
+	if err != nil || path != "" || !viper.GetBool("with-audio") {
+		return path, err
+	}
+	fmt.Fprintf(stderr, "Downloading audio for %s\n", videoID)
+	return audioDownloader().Download(ctx, videoID, base)
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Inspect and manage the saved OAuth token",
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the saved token works, and for which channel",
+	Long: `Show the saved token's file, when its access token expires, whether it can
+be refreshed, the scopes it grants, and the channel it belongs to. Looking up
+the channel costs 1 quota unit and is skipped while the access token is
+expired. Nothing is refreshed or changed.
+
+Exits with status 4 if there is no token or it can't be refreshed.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthStatus,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Sign in again and replace the saved token",
+	Long: `Sign in again and replace the saved token. Only read access to YouTube is
+asked for; permission to manage captions is asked for the first time a
+command lists, downloads, or uploads captions. Pass --captions to grant it
+now, such as before running unattended.
+
+A sign-in is for the one channel picked in Google's account chooser. To
+work with another channel of the same Google account, such as a Brand
+Account, sign in with --add-account and pick that channel; its token is
+kept beside the default one, and --account selects it for later commands.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthLogin,
+}
+
+var authRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Exchange the refresh token for a new access token now",
+	Args:  cobra.NoArgs,
+	RunE:  runAuthRefresh,
+}
+
+var authExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the credentials to run ytt elsewhere from the environment",
+	Long: `Print the OAuth client and the saved refresh token as a YTT_CREDENTIALS
+line, base64-encoded, for a container or CI job to run ytt without the
+client secret file or token. Anyone with the line can use your account
+until the token is revoked, so keep it in a secret store.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthExport,
+}
+
+var authRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke the saved token at Google and delete it",
+	Long: `Revoke the saved token at Google, so it stops working everywhere, and delete
+the token file. The next command that calls the API signs in again.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthRevoke,
+}
+
+func init() {
+	authLoginCmd.Flags().Bool("captions", false, "also grant permission to list, download, and upload captions")
+	authLoginCmd.Flags().Bool("add-account", false, "sign in as another channel, kept beside the default token and selected with --account")
+	authCmd.AddCommand(authStatusCmd, authLoginCmd, authRefreshCmd, authExportCmd, authRevokeCmd)
+	rootCmd.AddCommand(authCmd)
+}
+
+// authOptions returns the client options that apply to OAuth requests.
+func authOptions() ([]youtube.Option, error) {
+	var opts []youtube.Option
+	if proxy := viper.GetString("proxy"); proxy != "" {
+		transport, err := youtube.ProxyTransport(proxy)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, youtube.WithTransport(transport))
+	}
+	if viper.GetBool("no-browser") {
+		opts = append(opts, youtube.WithoutBrowser())
+	}
+	if viper.GetBool("non-interactive") {
+		opts = append(opts, youtube.WithoutSignIn())
+	}
+	storage := youtube.TokenStorage(viper.GetString("token-storage"))
+	opts = append(opts, youtube.WithTokenStorage(storage, os.Getenv("YTT_TOKEN_PASSPHRASE")))
+	creds, err := envCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if creds != nil {
+		opts = append(opts, youtube.WithCredentials(creds))
+	}
+	return opts, nil
+}
+
+// envCredentials returns the credentials in YTT_CREDENTIALS, or in
+// YTT_CLIENT_ID, YTT_CLIENT_SECRET, and YTT_REFRESH_TOKEN, or nil if none
+// are set.
+func envCredentials() (*youtube.Credentials, error) {
+	if blob := os.Getenv("YTT_CREDENTIALS"); blob != "" {
+		creds, err := youtube.ParseCredentials(blob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YTT_CREDENTIALS: %w", err)
+		}
+		return creds, nil
+	}
+	creds := &youtube.Credentials{
+		ClientID:     os.Getenv("YTT_CLIENT_ID"),
+		ClientSecret: os.Getenv("YTT_CLIENT_SECRET"),
+		RefreshToken: os.Getenv("YTT_REFRESH_TOKEN"),
+	}
+	switch {
+	case *creds == youtube.Credentials{}:
+		return nil, nil
+	case creds.ClientID == "" || creds.ClientSecret == "":
+		return nil, fmt.Errorf("YTT_CLIENT_ID and YTT_CLIENT_SECRET must both be set to use credentials from the environment")
+	}
+	return creds, nil
+}
+
+// signInHint adds to err, if it says signing in is required under
+// --non-interactive, how to sign in elsewhere and pass the token in.
+func signInHint(err error) error {
+	if !errors.Is(err, youtube.ErrAuthorizationRequired) {
+		return err
+	}
+	return fmt.Errorf("%w; run \"ytt auth login\" where a browser can be opened, then pass the token in with YTT_CREDENTIALS from \"ytt auth export\", or copy the token file", err)
+}
+
+// authStatus is the result of ytt auth status.
+type authStatus struct {
+	*youtube.TokenStatus
+	ChannelID string `json:"channel_id,omitempty"`
+}
+
+func runAuthStatus(cmd *cobra.Command, args []string) error {
+	opts, err := authOptions()
+	if err != nil {
+		return err
+	}
+	tokenPath := viper.GetString("token")
+	ts, err := youtube.InspectToken(tokenPath, opts...)
+	if err != nil {
+		return &authError{err}
+	}
+	s := authStatus{TokenStatus: ts}
+	if ts.Present && !ts.Expired {
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		if s.ChannelID, err = client.AuthenticatedChannelID(); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput() {
+		setResult(s)
+	} else {
+		printAuthStatus(s)
+	}
+	switch {
+	case !ts.Present:
+		return &authError{fmt.Errorf("no token at %s; run \"ytt auth login\"", tokenPath)}
+	case ts.Expired && !ts.Refreshable:
+		return &authError{fmt.Errorf("the token has expired and can't be refreshed; run \"ytt auth login\"")}
+	}
+	return nil
+}
+
+func printAuthStatus(s authStatus) {
+	state := "missing"
+	switch {
+	case !s.Present:
+	case !s.Expired:
+		state = fmt.Sprintf("valid until %s (%s left)", s.Expiry.Local().Format("Mon Jan 2 15:04 MST"), time.Until(s.Expiry).Round(time.Minute))
+	case s.Refreshable:
+		state = "access token expired; it is refreshed on the next API call"
+	default:
+		state = "expired"
+	}
+	refresh := "no"
+	if s.Refreshable {
+		refresh = "yes"
+	}
+	scopes := make([]string, len(s.Scopes))
+	for i, scope := range s.Scopes {
+		scopes[i] = strings.TrimPrefix(scope, "https://www.googleapis.com/auth/")
+	}
+	rows := [][2]string{
+		{"Token", s.Storage},
+		{"Status", state},
+	}
+	if s.Present {
+		rows = append(rows, [2]string{"Refresh", refresh})
+	}
+	rows = append(rows, [2]string{"Scopes", strings.Join(scopes, ", ")}, [2]string{"Channel", s.ChannelID})
+	for _, r := range rows {
+		if r[1] != "" {
+			fmt.Printf("%-8s %s\n", r[0]+":", r[1])
+		}
+	}
+}
+
+func runAuthLogin(cmd *cobra.Command, args []string) error {
+	opts, err := authOptions()
+	if err != nil {
+		return err
+	}
+	if captions, _ := cmd.Flags().GetBool("captions"); captions {
+		opts = append(opts, youtube.WithCaptionAccess())
+	}
+	if add, _ := cmd.Flags().GetBool("add-account"); add {
+		if cmd.Flags().Changed("account") {
+			return fmt.Errorf("--add-account can't be used with --account; the account is the channel picked when signing in")
+		}
+		return addAccountLogin(opts)
+	}
+	if err := youtube.Authenticate(viper.GetString("oauth"), viper.GetString("token"), opts...); err != nil {
+		return &authError{signInHint(err)}
+	}
+	return nil
+}
+
+// addAccountLogin signs in, looks up the channel picked, and keeps the
+// token as that channel's account. An account set in the config file or
+// environment is ignored, as selectAccount ignores it, since signing in
+// picks the account.
+func addAccountLogin(opts []youtube.Option) error {
+	pending := accountTokenPath("pending")
+	if err := youtube.Authenticate(viper.GetString("oauth"), pending, opts...); err != nil {
+		return &authError{signInHint(err)}
+	}
+	// Don't leave a token for an unknown channel behind. Revoking it needs
+	// Google, which may be why the channel couldn't be looked up.
+	discard := func() {
+		if err := youtube.RevokeToken(pending, opts...); err != nil {
+			if err := youtube.DeleteToken(pending, opts...); err != nil {
+				warn(err)
+			}
+		}
+	}
+	viper.Set("token", pending)
+	client, err := newClient()
+	if err != nil {
+		discard()
+		return err
+	}
+	channels, err := client.MyChannels()
+	if err != nil {
+		discard()
+		return err
+	}
+	if len(channels) == 0 {
+		discard()
+		return fmt.Errorf("the account signed in has no YouTube channel")
+	}
+	ch := channels[0]
+	if err := youtube.MoveToken(pending, accountTokenPath(ch.ChannelID), opts...); err != nil {
+		return err
+	}
+	if err := addAccount(ch); err != nil {
+		return err
+	}
+	if jsonOutput() {
+		setResult(ch)
+		return nil
+	}
+	fmt.Fprintf(stderr, "Added %s (%s); pass --account %s to act as it\n", ch.Title, ch.ChannelID, ch.ChannelID)
+	return nil
+}
+
+func runAuthRefresh(cmd *cobra.Command, args []string) error {
+	opts, err := authOptions()
+	if err != nil {
+		return err
+	}
+	tok, err := youtube.RefreshToken(viper.GetString("oauth"), viper.GetString("token"), opts...)
+	if err != nil {
+		return &authError{err}
+	}
+	fmt.Fprintf(stderr, "Refreshed the access token; it expires %s\n", tok.Expiry.Local().Format("Mon Jan 2 15:04 MST"))
+	return nil
+}
+
+func runAuthExport(cmd *cobra.Command, args []string) error {
+	opts, err := authOptions()
+	if err != nil {
+		return err
+	}
+	creds, err := youtube.ExportCredentials(viper.GetString("oauth"), viper.GetString("token"), opts...)
+	if err != nil {
+		return &authError{err}
+	}
+	fmt.Printf("YTT_CREDENTIALS=%s\n", creds.Encode())
+	return nil
+}
+
+func runAuthRevoke(cmd *cobra.Command, args []string) error {
+	opts, err := authOptions()
+	if err != nil {
+		return err
+	}
+	tokenPath := viper.GetString("token")
+	if dryRun() {
+		p := &plan{action: "revoke and delete"}
+		p.add(nil, "%s", tokenPath)
+		p.print()
+		return nil
+	}
+	if err := youtube.RevokeToken(tokenPath, opts...); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Revoked and deleted the token at %s\n", tokenPath)
+	return nil
+}
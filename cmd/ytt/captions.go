@@ -0,0 +1,456 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/n2p5/ytt/internal/batch"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var captionsCmd = &cobra.Command{
+	Use:   "captions",
+	Short: "Manage caption tracks on videos you own",
+}
+
+var captionsListCmd = &cobra.Command{
+	Use:   "list <video_id>",
+	Short: "List a video's caption tracks",
+	Long: `List a video's caption tracks with their language, kind, name, and last
+update, marking with * the track ytt downloads given --lang, --lang-match, and --prefer.
+Kind is standard or forced for captions uploaded by a person, and asr for
+YouTube's automatic speech recognition. Listing costs 50 quota units.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCaptionsList,
+}
+
+var captionsDeleteCmd = &cobra.Command{
+	Use:   "delete [caption_id]",
+	Short: "Delete a caption track",
+	Long: `Delete a caption track by ID, or look it up with --video and --lang.
+
+The lookup fails if several tracks share the language; pass the caption ID
+of the one to remove instead.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCaptionsDelete,
+}
+
+var captionsBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Download every caption track on a channel in its original format",
+	Long: `Download every caption track on a channel's videos into the output
+directory, one subdirectory per video, with a manifest.json recording the
+video, language, track kind, and last update time of each track.
+
+With --content-owner and no --channel, every channel the content owner
+manages is backed up, each into a subdirectory named for its channel ID.`,
+	Args: cobra.NoArgs,
+	RunE: runCaptionsBackup,
+}
+
+var captionsRestoreCmd = &cobra.Command{
+	Use:   "restore <backup_dir>",
+	Short: "Re-upload caption tracks from a backup",
+	Long: `Upload each caption track listed in a backup's manifest.json to its video
+as a new track. Auto-generated (ASR) tracks are skipped unless --include-asr
+is set, since YouTube regenerates them itself.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCaptionsRestore,
+}
+
+var captionsLintCmd = &cobra.Command{
+	Use:   "lint <file>...",
+	Short: "Check caption files for problems before uploading them",
+	Long: `Check SRT, VTT, and SBV caption files for the problems YouTube rejects
+uploads for or shows wrongly: another encoding than UTF-8, unparseable
+blocks and timings, cues that end before they start or come out of order,
+and a ytt header block.
+
+Cues that overlap, last too long or too briefly to read, or have too many
+or too long lines are reported as warnings, as is markup YouTube shows as
+text. The command fails if any file has errors; warnings alone don't fail
+it. Linting makes no API calls.`,
+	Example: `  ytt captions lint captions.srt
+  ytt captions lint --max-line-chars 32 *.vtt
+  ytt captions lint captions.srt --json | jq '.[].findings[] | select(.severity == "error")'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runCaptionsLint,
+}
+
+func init() {
+	captionsBackupCmd.Flags().String("channel", "", "channel ID, @handle, or URL to back up (default: authenticated user's channel)")
+	captionsBackupCmd.RegisterFlagCompletionFunc("channel", completeChannelIDs)
+	captionsBackupCmd.Flags().String("out", "backup", "directory to write the backup to")
+	captionsBackupCmd.Flags().IntP("workers", "w", 1, "number of videos to back up concurrently")
+
+	captionsRestoreCmd.Flags().Bool("include-asr", false, "also upload auto-generated tracks")
+	captionsRestoreCmd.Flags().BoolP("yes", "y", false, "skip the confirmation prompt")
+
+	captionsListCmd.Flags().String("lang", "", "caption language to mark the track of (default: English, or the first track)")
+	captionsListCmd.Flags().String("prefer", "manual", "which track to mark when a language has several: manual, asr, or any")
+	captionsListCmd.Flags().String("lang-match", "base", "how --lang matches track languages: base (en matches en-US and en-GB) or exact")
+	captionsListCmd.RegisterFlagCompletionFunc("lang-match", cobra.FixedCompletions([]string{"base", "exact"}, cobra.ShellCompDirectiveNoFileComp))
+	captionsListCmd.RegisterFlagCompletionFunc("prefer", cobra.FixedCompletions([]string{"manual", "asr", "any"}, cobra.ShellCompDirectiveNoFileComp))
+
+	captionsDeleteCmd.Flags().String("video", "", "video ID to look up the caption track on")
+	captionsDeleteCmd.Flags().String("lang", "", "caption language to delete (requires --video)")
+	captionsDeleteCmd.Flags().BoolP("yes", "y", false, "skip the confirmation prompt")
+
+	captionsLintCmd.Flags().Int("max-line-chars", transcript.DefaultLintOptions.MaxLineChars, "warn about lines longer than this many characters (0 to disable)")
+	captionsLintCmd.Flags().Int("max-lines", transcript.DefaultLintOptions.MaxLines, "warn about cues with more lines than this (0 to disable)")
+	captionsLintCmd.Flags().Duration("min-cue-duration", transcript.DefaultLintOptions.MinDuration, "warn about cues shorter than this (0 to disable)")
+	captionsLintCmd.Flags().Duration("max-cue-duration", transcript.DefaultLintOptions.MaxDuration, "warn about cues longer than this (0 to disable)")
+
+	captionsCmd.AddCommand(captionsListCmd, captionsDeleteCmd, captionsBackupCmd, captionsRestoreCmd, captionsLintCmd)
+	rootCmd.AddCommand(captionsCmd)
+}
+
+// captionListing is a track listed by ytt captions list.
+type captionListing struct {
+	youtube.CaptionTrack
+	// Selected marks the track ytt downloads.
+	Selected bool `json:"selected"`
+}
+
+func runCaptionsList(cmd *cobra.Command, args []string) error {
+	lang, _ := cmd.Flags().GetString("lang")
+	prefer, err := youtube.ParseTrackPreference(viper.GetString("prefer"))
+	if err != nil {
+		return err
+	}
+	match, err := youtube.ParseLangMatch(viper.GetString("lang-match"))
+	if err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	tracks, err := client.ListCaptions(args[0])
+	if err != nil {
+		return err
+	}
+	selected, _ := youtube.SelectCaption(tracks, youtube.CaptionChoice{Lang: lang, Match: match, Prefer: prefer})
+	listing := make([]captionListing, len(tracks))
+	for i, t := range tracks {
+		listing[i] = captionListing{CaptionTrack: t, Selected: t.CaptionID == selected.CaptionID}
+	}
+
+	if jsonOutput() {
+		setResult(listing)
+		return nil
+	}
+	if len(tracks) == 0 {
+		fmt.Fprintf(stderr, "Video %s has no caption tracks.\n", args[0])
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tID\tLANGUAGE\tKIND\tNAME\tUPDATED")
+	for _, l := range listing {
+		mark := ""
+		if l.Selected {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", mark, l.CaptionID, cmp.Or(l.Language, "-"), l.TrackKind, l.Name, l.LastUpdated)
+	}
+	return w.Flush()
+}
+
+func runCaptionsDelete(cmd *cobra.Command, args []string) error {
+	videoID, _ := cmd.Flags().GetString("video")
+	lang, _ := cmd.Flags().GetString("lang")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	if len(args) == 1 && videoID != "" {
+		return fmt.Errorf("pass either a caption ID or --video, not both")
+	}
+	if len(args) == 0 && (videoID == "" || lang == "") {
+		return fmt.Errorf("a caption ID or both --video and --lang are required")
+	}
+
+	if dryRun() {
+		p := &plan{action: "delete"}
+		if len(args) == 1 {
+			p.add([]youtube.Method{youtube.MethodCaptionsDelete}, "caption track %s", args[0])
+		} else {
+			p.add([]youtube.Method{youtube.MethodCaptionsList, youtube.MethodCaptionsDelete}, "the %s caption track on video %s", lang, videoID)
+		}
+		p.print()
+		return nil
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	var track youtube.CaptionTrack
+	if len(args) == 1 {
+		track = youtube.CaptionTrack{CaptionID: args[0]}
+	} else {
+		tracks, err := client.ListCaptions(videoID)
+		if err != nil {
+			return err
+		}
+		track, err = youtube.FindCaption(tracks, lang)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !yes {
+		question := fmt.Sprintf("Delete caption track %s?", track.CaptionID)
+		if track.VideoID != "" {
+			question = fmt.Sprintf("Delete caption track %s (%s, kind=%s, name=%q) on video %s?",
+				track.CaptionID, track.Language, track.TrackKind, track.Name, track.VideoID)
+		}
+		if !confirm(question) {
+			fmt.Fprintln(stderr, "Aborted.")
+			return nil
+		}
+	}
+
+	if err := client.DeleteCaption(track.CaptionID); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Deleted caption track %s\n", track.CaptionID)
+	setResult(map[string]string{"deleted": track.CaptionID})
+	return nil
+}
+
+func runCaptionsBackup(cmd *cobra.Command, args []string) error {
+	channel, _ := cmd.Flags().GetString("channel")
+	outDir, _ := cmd.Flags().GetString("out")
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	owner := viper.GetString("content-owner")
+	if channel != "" || owner == "" {
+		channelID, err := resolveChannel(client, channel)
+		if err != nil {
+			return err
+		}
+		manifest, err := backupChannel(client, channelID, outDir)
+		if manifest != nil {
+			setResult(manifest)
+		}
+		return err
+	}
+
+	// The content owner's whole catalog, a channel at a time.
+	channels, err := client.ManagedChannels(owner)
+	if err != nil {
+		return err
+	}
+	var manifests []*youtube.BackupManifest
+	var failed []error
+	for _, ch := range channels {
+		fmt.Fprintf(stderr, "Backing up %s (%s)\n", ch.Title, ch.ChannelID)
+		manifest, err := backupChannel(client, ch.ChannelID, filepath.Join(outDir, ch.ChannelID))
+		if manifest != nil {
+			manifests = append(manifests, manifest)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", ch.ChannelID, err))
+		}
+	}
+	setResult(manifests)
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d channels failed: %w", len(failed), len(channels), errors.Join(failed...))
+	}
+	return nil
+}
+
+// backupChannel backs up the caption tracks of a channel's videos into
+// outDir. It returns the backup's manifest, which lists the tracks backed
+// up even if some videos failed.
+func backupChannel(client *youtube.Client, channelID, outDir string) (*youtube.BackupManifest, error) {
+	videos, err := client.ListVideos(channelID, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun() {
+		// Track counts aren't known without listing captions, which is
+		// itself the expensive part, so assume one track per video.
+		p := &plan{action: "back up captions for"}
+		for _, v := range videos {
+			p.add([]youtube.Method{youtube.MethodCaptionsList, youtube.MethodCaptionsDownload}, "%s  %s", v.VideoID, filepath.Join(outDir, v.VideoID))
+		}
+		p.print()
+		fmt.Fprintln(stderr, "Quota estimate assumes one caption track per video; each additional track costs", youtube.CostCaptionsDownload, "units.")
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating backup directory: %w", err)
+	}
+
+	ids := make([]string, len(videos))
+	for i, v := range videos {
+		ids[i] = v.VideoID
+	}
+
+	var mu sync.Mutex
+	manifest := &youtube.BackupManifest{
+		ChannelID: channelID,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	results := runBatch("backup", ids, func(ctx context.Context, videoID string) error {
+		entries, err := client.BackupVideoCaptions(videoID, outDir)
+		mu.Lock()
+		manifest.Entries = append(manifest.Entries, entries...)
+		mu.Unlock()
+		if err == nil {
+			fmt.Fprintf(stderr, "Backed up %d caption tracks for %s\n", len(entries), videoID)
+		}
+		return err
+	})
+
+	if err := youtube.WriteBackupManifest(outDir, manifest); err != nil {
+		return nil, err
+	}
+
+	failed := batch.Failed(results)
+	for _, r := range failed {
+		fmt.Fprintf(stderr, "Failed %s: %v\n", r.ID, r.Err)
+	}
+	fmt.Fprintf(stderr, "Backed up %d caption tracks from %d videos to %s\n", len(manifest.Entries), len(videos), outDir)
+	if len(failed) > 0 {
+		return manifest, fmt.Errorf("%d of %d videos failed", len(failed), len(results))
+	}
+	return manifest, nil
+}
+
+func runCaptionsRestore(cmd *cobra.Command, args []string) error {
+	dir := args[0]
+	includeASR, _ := cmd.Flags().GetBool("include-asr")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	manifest, err := youtube.ReadBackupManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	var entries []youtube.BackupEntry
+	for _, e := range manifest.Entries {
+		if e.TrackKind == "asr" && !includeASR {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(stderr, "Nothing to restore.")
+		return nil
+	}
+
+	if dryRun() {
+		p := &plan{action: "upload"}
+		for _, e := range entries {
+			p.add([]youtube.Method{youtube.MethodCaptionsInsert}, "%s track %s to video %s  from %s", e.Language, e.CaptionID, e.VideoID, filepath.Join(dir, e.File))
+		}
+		p.print()
+		return nil
+	}
+
+	if !yes && !confirm(fmt.Sprintf("Upload %d caption tracks from %s?", len(entries), dir)) {
+		fmt.Fprintln(stderr, "Aborted.")
+		return nil
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	restored := []restoredCaption{}
+	setResult(&restored)
+	for _, e := range entries {
+		newID, err := client.RestoreCaption(dir, e)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed %s (%s): %v\n", e.CaptionID, e.VideoID, err)
+			restored = append(restored, restoredCaption{BackupEntry: e, Error: err.Error()})
+			failed++
+			continue
+		}
+		fmt.Fprintf(stderr, "Restored %s track for %s as %s\n", e.Language, e.VideoID, newID)
+		restored = append(restored, restoredCaption{BackupEntry: e, NewCaptionID: newID})
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d caption tracks failed to restore", failed, len(entries))
+	}
+	return nil
+}
+
+// restoredCaption is a backed-up track's outcome under --json.
+type restoredCaption struct {
+	youtube.BackupEntry
+	NewCaptionID string `json:"new_caption_id,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// lintResult is the findings ytt captions lint reports for one file.
+type lintResult struct {
+	File     string               `json:"file"`
+	Findings []transcript.Finding `json:"findings"`
+}
+
+func runCaptionsLint(cmd *cobra.Command, args []string) error {
+	opts := transcript.LintOptions{}
+	opts.MaxLineChars, _ = cmd.Flags().GetInt("max-line-chars")
+	opts.MaxLines, _ = cmd.Flags().GetInt("max-lines")
+	opts.MinDuration, _ = cmd.Flags().GetDuration("min-cue-duration")
+	opts.MaxDuration, _ = cmd.Flags().GetDuration("max-cue-duration")
+
+	results := make([]lintResult, len(args))
+	errs, failedFiles := 0, 0
+	for i, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", path, err)
+		}
+		results[i] = lintResult{File: path, Findings: transcript.Lint(data, opts)}
+		n := 0
+		for _, f := range results[i].Findings {
+			if f.Severity == transcript.SeverityError {
+				n++
+			}
+		}
+		if n > 0 {
+			errs += n
+			failedFiles++
+		}
+	}
+
+	if jsonOutput() {
+		setResult(results)
+	} else {
+		for _, r := range results {
+			for _, f := range r.Findings {
+				loc := r.File
+				if f.Line > 0 {
+					loc = fmt.Sprintf("%s:%d", r.File, f.Line)
+				}
+				fmt.Printf("%s: %s: %s (%s)\n", loc, f.Severity, f.Message, f.Rule)
+			}
+		}
+	}
+	if errs > 0 {
+		return fmt.Errorf("%d error(s) in %d of %d files", errs, failedFiles, len(args))
+	}
+	return nil
+}
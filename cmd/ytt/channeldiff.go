@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/n2p5/ytt/internal/snapshot"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var channelDiffCmd = &cobra.Command{
+	Use:   "channel-diff",
+	Short: "Snapshot a channel's videos and show what changed since the last snapshot",
+	Long: `Fetch the channel's current video list, save it as a snapshot, and
+compare it with the previous snapshot, listing new videos, videos that were
+removed (deleted or made private), and videos that were retitled.
+
+Run it periodically (e.g. from cron) to track a channel over time. With
+--offline, the two most recent stored snapshots are compared without any API
+calls.`,
+	Args: cobra.NoArgs,
+	RunE: runChannelDiff,
+}
+
+func init() {
+	channelDiffCmd.Flags().String("channel", "", "channel ID, @handle, or URL (default: authenticated user's channel)")
+	channelDiffCmd.RegisterFlagCompletionFunc("channel", completeChannelIDs)
+	channelDiffCmd.Flags().Bool("offline", false, "compare the two most recent stored snapshots without fetching")
+
+	rootCmd.AddCommand(channelDiffCmd)
+}
+
+func runChannelDiff(cmd *cobra.Command, args []string) error {
+	channelID, _ := cmd.Flags().GetString("channel")
+	offline, _ := cmd.Flags().GetBool("offline")
+	dir := filepath.Join(viper.GetString("data_dir"), "snapshots")
+
+	if offline {
+		if channelID == "" {
+			return fmt.Errorf("--offline requires --channel")
+		}
+		channelID, err := resolveChannelOffline(channelID)
+		if err != nil {
+			return err
+		}
+		paths, err := snapshot.List(dir, channelID)
+		if err != nil {
+			return err
+		}
+		if len(paths) < 2 {
+			return fmt.Errorf("need at least two snapshots of %s, found %d", channelID, len(paths))
+		}
+		old, err := snapshot.Load(paths[len(paths)-2])
+		if err != nil {
+			return err
+		}
+		cur, err := snapshot.Load(paths[len(paths)-1])
+		if err != nil {
+			return err
+		}
+		showDiff(old, cur, "")
+		return nil
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	if channelID, err = resolveChannel(client, channelID); err != nil {
+		return err
+	}
+
+	paths, err := snapshot.List(dir, channelID)
+	if err != nil {
+		return err
+	}
+
+	videos, err := client.ListVideos(channelID, 0, false)
+	if err != nil {
+		return err
+	}
+	cur := &snapshot.Snapshot{ChannelID: channelID, TakenAt: time.Now().UTC(), Videos: videos}
+
+	var saved string
+	if dryRun() {
+		if !jsonOutput() {
+			fmt.Printf("Dry run: would save a snapshot of %d videos for %s\n", len(videos), channelID)
+		}
+	} else {
+		if saved, err = snapshot.Save(dir, cur); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "Saved snapshot: %s\n", saved)
+	}
+
+	if len(paths) == 0 {
+		fmt.Fprintf(stderr, "First snapshot of %s (%d videos); nothing to compare yet.\n", channelID, len(videos))
+		setResult(channelDiffResult{ChannelID: channelID, Snapshot: saved, DryRun: dryRun(), To: cur.TakenAt})
+		return nil
+	}
+	old, err := snapshot.Load(paths[len(paths)-1])
+	if err != nil {
+		return err
+	}
+	showDiff(old, cur, saved)
+	return nil
+}
+
+// showDiff prints the changes between two snapshots, or makes them the
+// result under --json. saved is the path cur was saved to, if it was.
+func showDiff(old, cur *snapshot.Snapshot, saved string) {
+	if jsonOutput() {
+		d := snapshot.Compare(old, cur)
+		setResult(channelDiffResult{ChannelID: cur.ChannelID, Snapshot: saved, DryRun: dryRun(), From: old.TakenAt, To: cur.TakenAt, Diff: &d})
+		return
+	}
+	printDiff(old, cur)
+}
+
+// channelDiffResult is channel-diff's result under --json. Diff is nil for
+// a channel's first snapshot, when there is nothing to compare.
+type channelDiffResult struct {
+	ChannelID string `json:"channel_id"`
+	// Snapshot is the path of the snapshot saved, if any.
+	Snapshot string    `json:"snapshot,omitempty"`
+	DryRun   bool      `json:"dry_run,omitempty"`
+	From     time.Time `json:"from,omitzero"`
+	To       time.Time `json:"to"`
+	*snapshot.Diff
+}
+
+func printDiff(old, cur *snapshot.Snapshot) {
+	fmt.Printf("Channel %s: %s -> %s\n", cur.ChannelID,
+		old.TakenAt.Local().Format(time.DateTime), cur.TakenAt.Local().Format(time.DateTime))
+
+	d := snapshot.Compare(old, cur)
+	if d.Empty() {
+		fmt.Println("No changes.")
+		return
+	}
+	if len(d.Added) > 0 {
+		fmt.Printf("New (%d):\n", len(d.Added))
+		for _, v := range d.Added {
+			fmt.Printf("  + %s  %s\n", v.VideoID, v.Title)
+		}
+	}
+	if len(d.Removed) > 0 {
+		fmt.Printf("Removed (%d):\n", len(d.Removed))
+		for _, v := range d.Removed {
+			fmt.Printf("  - %s  %s\n", v.VideoID, v.Title)
+		}
+	}
+	if len(d.Retitled) > 0 {
+		fmt.Printf("Retitled (%d):\n", len(d.Retitled))
+		for _, r := range d.Retitled {
+			fmt.Printf("  ~ %s  %q -> %q\n", r.VideoID, r.OldTitle, r.NewTitle)
+		}
+	}
+}
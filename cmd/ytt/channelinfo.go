@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var channelInfoCmd = &cobra.Command{
+	Use:   "channel-info [channel]",
+	Short: "Show a channel's title, description, statistics, topics, and branding",
+	Long: `Show a channel's metadata: title, handle, description, creation date,
+country, subscriber, view, and video counts, topic categories, keywords, and
+branding images. The channel is given by ID, @handle, or URL, and defaults
+to the authenticated user's channel. Use --json for machine-readable output.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeChannelIDs,
+	RunE:              runChannelInfo,
+}
+
+func init() {
+	rootCmd.AddCommand(channelInfoCmd)
+}
+
+func runChannelInfo(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	var channel string
+	if len(args) > 0 {
+		channel = args[0]
+	}
+	channelID, err := resolveChannel(client, channel)
+	if err != nil {
+		return err
+	}
+
+	d, err := client.GetChannelDetails(channelID)
+	if err != nil {
+		return err
+	}
+	if jsonOutput() {
+		setResult(d)
+		return nil
+	}
+
+	subscribers := fmt.Sprint(d.SubscriberCount)
+	if d.SubscribersHidden {
+		subscribers = "hidden"
+	}
+	topics := make([]string, len(d.TopicCategories))
+	for i, t := range d.TopicCategories {
+		topics[i] = topicName(t)
+	}
+	rows := [][2]string{
+		{"Channel", d.ChannelID},
+		{"Title", d.Title},
+		{"Handle", d.Handle},
+		{"Created", d.PublishedAt},
+		{"Country", d.Country},
+		{"Subscribers", subscribers},
+		{"Views", fmt.Sprint(d.ViewCount)},
+		{"Videos", fmt.Sprint(d.VideoCount)},
+		{"Topics", strings.Join(topics, ", ")},
+		{"Keywords", d.Keywords},
+		{"Trailer", d.TrailerVideoID},
+		{"Thumbnail", d.ThumbnailURL},
+		{"Banner", d.BannerURL},
+		{"Uploads", d.UploadsPlaylist},
+	}
+	for _, r := range rows {
+		if r[1] != "" {
+			fmt.Printf("%-12s %s\n", r[0]+":", r[1])
+		}
+	}
+	if d.Description != "" {
+		fmt.Printf("\n%s\n", d.Description)
+	}
+	return nil
+}
+
+// topicName returns the article name of a topic category's Wikipedia URL,
+// such as "Video game culture" for .../wiki/Video_game_culture.
+func topicName(topic string) string {
+	u, err := url.Parse(topic)
+	if err != nil {
+		return topic
+	}
+	return strings.ReplaceAll(path.Base(u.Path), "_", " ")
+}
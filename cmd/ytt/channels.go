@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var channelsCmd = &cobra.Command{
+	Use:   "channels --mine",
+	Short: "List the channels you can act as",
+	Long: `List the channels ytt can act as: the channel of the default token, each
+channel added with "ytt auth login --add-account", and with --content-owner,
+the channels a YouTube content owner (CMS) manages. The channel commands
+act as is marked with *; pass --account to act as another added channel,
+or set account in the config file to make it the default.
+
+A Google account can manage several Brand Account channels, but a sign-in
+is for one of them, so each needs adding to be listed. Looking up the
+default token's channel costs 1 quota unit, and listing a content owner's
+channels 1 unit per 50 channels.`,
+	Example: `  ytt auth login --add-account      # pick the Brand Account channel in Google's chooser
+  ytt channels --mine
+  ytt channel-info --account @mybrand`,
+	Args: cobra.NoArgs,
+	RunE: runChannels,
+}
+
+func init() {
+	channelsCmd.Flags().Bool("mine", false, "list the channels you can act as")
+	channelsCmd.MarkFlagRequired("mine")
+
+	rootCmd.AddCommand(channelsCmd)
+}
+
+// channelListing is a channel listed by ytt channels.
+type channelListing struct {
+	youtube.ChannelSummary
+	// Source is how ytt acts as the channel: "token" for the default token,
+	// "account" for an added account, or "content_owner".
+	Source string `json:"source"`
+	// Selected marks the channel commands act as.
+	Selected bool `json:"selected"`
+}
+
+func runChannels(cmd *cobra.Command, args []string) error {
+	current := viper.GetString("token")
+	viper.Set("token", defaultToken)
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	mine, err := client.MyChannels()
+	if err != nil {
+		return err
+	}
+	var listing []channelListing
+	for _, ch := range mine {
+		listing = append(listing, channelListing{ChannelSummary: ch, Source: "token", Selected: viper.GetString("account") == ""})
+	}
+
+	accounts, err := loadAccounts()
+	if err != nil {
+		return err
+	}
+	var selected string
+	if name := viper.GetString("account"); name != "" {
+		a, err := findAccount(name)
+		if err != nil {
+			return err
+		}
+		selected = a.ChannelID
+	}
+	for _, a := range accounts {
+		listing = append(listing, channelListing{ChannelSummary: a, Source: "account", Selected: a.ChannelID == selected})
+	}
+
+	if owner := viper.GetString("content-owner"); owner != "" {
+		viper.Set("token", current)
+		if client, err = newClient(); err != nil {
+			return err
+		}
+		managed, err := client.ManagedChannels(owner)
+		if err != nil {
+			return err
+		}
+		for _, ch := range managed {
+			listing = append(listing, channelListing{ChannelSummary: ch, Source: "content_owner"})
+		}
+	}
+
+	if jsonOutput() {
+		setResult(listing)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tID\tTITLE\tHANDLE\tSELECT WITH")
+	for _, l := range listing {
+		mark, how := "", ""
+		if l.Selected {
+			mark = "*"
+		}
+		switch l.Source {
+		case "token":
+			how = "(default)"
+		case "account":
+			how = "--account " + l.ChannelID
+		case "content_owner":
+			how = "(content owner)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", mark, l.ChannelID, l.Title, l.Handle, how)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var chaptersCmd = &cobra.Command{
+	Use:   "chapters <video_id>",
+	Short: "Outline a transcript by the chapters in the video's description",
+	Long: `Join the chapters listed in a video's description with its transcript, and
+print an outline: each chapter's title, start and end, and the text spoken
+within it, as Markdown show notes, or with --json, as JSON.
+
+Chapters are read from the description by the rules YouTube shows them by:
+one timestamp per line, the first at 0:00, and at least three of them. The
+description is fetched from YouTube, costing 1 quota unit, unless
+--description-file is given. The transcript is read from the output
+directory if it was downloaded there, and fetched from YouTube otherwise.`,
+	Example: `  ytt chapters abc123 > show-notes.md
+  ytt chapters abc123 --description-file description.txt --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runChapters,
+}
+
+func init() {
+	chaptersCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	chaptersCmd.Flags().String("description-file", "", "read the video description from this file instead of YouTube")
+
+	rootCmd.AddCommand(chaptersCmd)
+}
+
+func runChapters(cmd *cobra.Command, args []string) error {
+	videoID := args[0]
+	src, description, err := describeVideo(videoID)
+	if err != nil {
+		return err
+	}
+	chapters := transcript.ParseChapters(description)
+	if len(chapters) == 0 {
+		return fmt.Errorf("the description of %s lists no chapters", videoID)
+	}
+
+	cues, err := loadCues(videoID, viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	sections := transcript.Outline(chapters, cues)
+
+	if jsonOutput() {
+		res := chaptersResult{VideoID: videoID, Title: src.Title}
+		for _, s := range sections {
+			res.Chapters = append(res.Chapters, chapterResult{
+				Title:     s.Title,
+				Timestamp: transcript.FormatTimestamp(s.Start),
+				Start:     s.Start.Seconds(),
+				End:       s.End.Seconds(),
+				URL:       transcript.WatchURL(videoID, s.Start),
+				Text:      s.Text,
+			})
+		}
+		setResult(res)
+		return nil
+	}
+	_, err = os.Stdout.Write(transcript.OutlineMarkdown(src.Metadata(), sections))
+	return err
+}
+
+// chaptersResult is the outline under --json, with times in seconds.
+type chaptersResult struct {
+	VideoID  string          `json:"video_id"`
+	Title    string          `json:"title,omitempty"`
+	Chapters []chapterResult `json:"chapters"`
+}
+
+type chapterResult struct {
+	Title     string  `json:"title"`
+	Timestamp string  `json:"timestamp"`
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+	URL       string  `json:"url"`
+	Text      string  `json:"text"`
+}
+
+// describeVideo returns a video's source, from the archive in the output
+// directory if the video is there, and its description, read from
+// --description-file or fetched from YouTube along with any details the
+// archive lacks.
+func describeVideo(videoID string) (transcript.Source, string, error) {
+	src := transcript.Source{VideoID: videoID}
+	a, err := archive.Open(viper.GetString("output"))
+	if err != nil {
+		return src, "", err
+	}
+	if e, ok, err := a.Video(videoID); err != nil {
+		return src, "", err
+	} else if ok {
+		src = transcript.Source{VideoID: videoID, Title: e.Title, ChannelID: e.ChannelID, PublishedAt: e.PublishedAt, Language: e.Language}
+	}
+
+	if path := viper.GetString("description-file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return src, "", fmt.Errorf("error reading description: %w", err)
+		}
+		return src, string(data), nil
+	}
+	client, err := newClient()
+	if err != nil {
+		return src, "", err
+	}
+	details, err := client.GetVideoDetails(videoID)
+	if err != nil {
+		return src, "", err
+	}
+	src.Title = cmp.Or(src.Title, details.Title)
+	src.ChannelID = cmp.Or(src.ChannelID, details.ChannelID)
+	if published, err := time.Parse(time.RFC3339, details.PublishedAt); err == nil && src.PublishedAt.IsZero() {
+		src.PublishedAt = published
+	}
+	return src, details.Description, nil
+}
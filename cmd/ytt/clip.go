@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/n2p5/ytt/internal/audio"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var clipCmd = &cobra.Command{
+	Use:   "clip <video_id>",
+	Short: "Find a quote in a transcript and link to or download that clip",
+	Long: `Find the phrase given with --match in a video's transcript and print the
+clip it falls in: the captions from the cue the phrase starts in to the cue
+it ends in, widened by --padding on each side, with links to that moment
+on YouTube. Matching ignores case and line breaks, as with grep. If the
+phrase occurs more than once, the first occurrence is used unless --nth
+picks another.
+
+With --media, the clip itself is also downloaded, by yt-dlp unless the
+config file names another program, and saved with its captions, timed
+from the start of the clip, as an SRT file beside it. --out names the
+saved files and is only accepted with --media.
+
+The transcript is read from the output directory if it was downloaded
+there, and fetched from YouTube otherwise.`,
+	Example: `  ytt clip abc123 --match "the exact phrase"
+  ytt clip abc123 --match "the exact phrase" --padding 5s --media --out quote`,
+	Args: cobra.ExactArgs(1),
+	RunE: runClip,
+}
+
+func init() {
+	clipCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	clipCmd.Flags().String("match", "", "phrase to find in the transcript")
+	clipCmd.Flags().Duration("padding", 2*time.Second, "time to add before and after the phrase's captions")
+	clipCmd.Flags().Int("nth", 1, "which occurrence of the phrase to clip")
+	clipCmd.Flags().Bool("media", false, "also download the clip, with the downloader set in the config file (default: yt-dlp)")
+	clipCmd.Flags().String("out", "", "file to save the clip to with --media, without an extension (default: {video_id}-{start}-{end})")
+	clipCmd.MarkFlagRequired("match")
+
+	rootCmd.AddCommand(clipCmd)
+}
+
+func runClip(cmd *cobra.Command, args []string) error {
+	videoID, phrase := args[0], viper.GetString("match")
+	nth := viper.GetInt("nth")
+	if nth < 1 {
+		return fmt.Errorf("--nth must be at least 1")
+	}
+	padding := viper.GetDuration("padding")
+	if padding < 0 {
+		return fmt.Errorf("--padding can't be negative")
+	}
+	media := viper.GetBool("media")
+	if cmd.Flags().Changed("out") && !media {
+		return fmt.Errorf("--out names the downloaded clip, so it needs --media")
+	}
+	program := viper.GetString("clip_downloader")
+	if media {
+		if _, err := exec.LookPath(program); err != nil {
+			return fmt.Errorf("--media needs %s (set clip_downloader in the config file to use another program): %w", program, err)
+		}
+	}
+
+	cues, err := loadCues(videoID, viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	// Rolling auto-generated captions repeat each line, which would find
+	// every phrase twice.
+	if cues, err = transcript.Dedupe(transcript.Source{VideoID: videoID}, cues); err != nil {
+		return err
+	}
+
+	matches := transcript.Find(cues, phrase, 0)
+	switch {
+	case len(matches) == 0:
+		return fmt.Errorf("%q not found", phrase)
+	case nth > len(matches):
+		return fmt.Errorf("--nth %d is past the last of %d occurrences of %q", nth, len(matches), phrase)
+	case len(matches) > 1 && !cmd.Flags().Changed("nth"):
+		fmt.Fprintf(stderr, "%q occurs %d times; clipping the first (pick another with --nth)\n", phrase, len(matches))
+	}
+	m := matches[nth-1]
+	start, end := max(m.Start-padding, 0), m.End+padding
+	snippet := transcript.Between(cues, start, end)
+
+	res := clipResult{
+		VideoID:  videoID,
+		Start:    start.Seconds(),
+		End:      end.Seconds(),
+		URL:      transcript.WatchURL(videoID, start),
+		EmbedURL: embedURL(videoID, start, end),
+		Text:     transcript.Text(snippet),
+	}
+	if media {
+		base := viper.GetString("out")
+		if base == "" {
+			base = fmt.Sprintf("%s-%d-%d", videoID, int(start.Seconds()), int(math.Ceil(end.Seconds())))
+		}
+		if dryRun() {
+			p := &plan{action: "download"}
+			p.add(nil, "clip %s  %s to %s", videoID, transcript.FormatTimestamp(start), transcript.FormatTimestamp(end))
+			p.print()
+			return nil
+		}
+		fmt.Fprintf(stderr, "Downloading clip %s to %s\n", transcript.FormatTimestamp(start), transcript.FormatTimestamp(end))
+		clipper := audio.Command{Program: program, Args: viper.GetStringSlice("clip_args")}
+		if res.Clip, err = clipper.DownloadSection(context.Background(), videoID, base, start, end); err != nil {
+			return err
+		}
+		if res.Captions, err = writeClipCaptions(base, snippet, start); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput() {
+		setResult(res)
+		return nil
+	}
+	fmt.Print(res.Text)
+	fmt.Printf("\n%s\n%s\n", res.URL, res.EmbedURL)
+	if res.Clip != "" {
+		fmt.Fprintf(stderr, "Saved %s and %s\n", res.Clip, res.Captions)
+	}
+	return nil
+}
+
+// clipResult is the clip under --json, with times in seconds.
+type clipResult struct {
+	VideoID  string  `json:"video_id"`
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+	URL      string  `json:"url"`
+	EmbedURL string  `json:"embed_url"`
+	Text     string  `json:"text"`
+	Clip     string  `json:"clip,omitempty"`
+	Captions string  `json:"captions,omitempty"`
+}
+
+// embedURL returns a link to the embedded player that plays only the part
+// of a video between start and end.
+func embedURL(videoID string, start, end time.Duration) string {
+	return fmt.Sprintf("https://www.youtube.com/embed/%s?start=%d&end=%d", videoID, int(start.Seconds()), int(math.Ceil(end.Seconds())))
+}
+
+// writeClipCaptions saves the captions of a clip starting at start as an SRT
+// file named for the clip, timed from the start of the clip, and returns
+// its path.
+func writeClipCaptions(base string, cues []transcript.Cue, start time.Duration) (string, error) {
+	shifted := make([]transcript.Cue, len(cues))
+	for i, c := range cues {
+		c.Start, c.End = max(c.Start-start, 0), max(c.End-start, 0)
+		c.Words = nil
+		shifted[i] = c
+	}
+	data, err := transcript.FormatSRT.Format(shifted)
+	if err != nil {
+		return "", err
+	}
+	path := base + "-captions.srt"
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("error writing clip captions: %w", err)
+	}
+	return path, nil
+}
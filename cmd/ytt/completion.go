@@ -0,0 +1,102 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/trash"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Completion functions for flag values and arguments, used by the scripts
+// "ytt completion" generates. Subcommands and flag names are completed by
+// cobra itself.
+
+// completeChannelIDs completes the channels seen in recent API responses.
+func completeChannelIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var ids []string
+	for _, id := range youtube.RecentChannelIDs(viper.GetString("cache_dir")) {
+		if strings.HasPrefix(id, toComplete) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAccounts completes --account with the channel IDs of the added
+// accounts, described by their titles.
+func completeAccounts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if defaultToken == "" {
+		// Completion runs without resolvePaths.
+		defaultToken = cmp.Or(viper.GetString("token"), defaultSecretPath("token.json"))
+	}
+	accounts, _ := loadAccounts()
+	var ids []string
+	for _, a := range accounts {
+		if strings.HasPrefix(a.ChannelID, toComplete) {
+			ids = append(ids, a.ChannelID+"\t"+a.Title)
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeGroups completes the channel groups defined in the config file.
+func completeGroups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var groups []string
+	for name := range viper.GetStringMap("groups") {
+		if strings.HasPrefix(name, toComplete) {
+			groups = append(groups, name)
+		}
+	}
+	slices.Sort(groups)
+	return groups, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFormats completes --format.
+func completeFormats(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = string(f)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProcessors completes the last step of a comma-separated --process.
+func completeProcessors(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	done, last := "", toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		done, last = toComplete[:i+1], toComplete[i+1:]
+	}
+	var steps []string
+	for _, name := range transcript.ProcessorNames() {
+		if strings.HasPrefix(name, last) && !strings.Contains(","+done, ","+name+",") {
+			steps = append(steps, done+name)
+		}
+	}
+	return steps, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeTrashed completes the files in the output directory's trash.
+func completeTrashed(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	outputDir, _ := cmd.Flags().GetString("output")
+	entries, err := trash.List(outputDir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var paths []string
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Path, toComplete) && !seen[e.Path] {
+			seen[e.Path] = true
+			paths = append(paths, e.Path)
+		}
+	}
+	return paths, cobra.ShellCompDirectiveNoFileComp
+}
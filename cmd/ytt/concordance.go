@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var concordanceCmd = &cobra.Command{
+	Use:   "concordance",
+	Short: "Print every occurrence of a term across archived transcripts as CSV",
+	Long: `Scan the transcripts in the output directory for a term and print one CSV
+row per occurrence: the video, when it was published, the timestamp and a
+link to it, and the sentence the term appears in. Rows are ordered by publish
+date, to follow how a topic is discussed over time.
+
+With --channel, only that channel's videos are scanned. Transcripts
+downloaded by earlier versions of ytt have no channel or publish date
+recorded, and are only included without --channel.`,
+	Args: cobra.NoArgs,
+	RunE: runConcordance,
+}
+
+func init() {
+	concordanceCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	concordanceCmd.Flags().String("channel", "", "only scan this channel's videos")
+	concordanceCmd.RegisterFlagCompletionFunc("channel", completeChannelIDs)
+	concordanceCmd.Flags().String("term", "", "word or phrase to find")
+	concordanceCmd.MarkFlagRequired("term")
+
+	rootCmd.AddCommand(concordanceCmd)
+}
+
+func runConcordance(cmd *cobra.Command, args []string) error {
+	a, err := archive.Open(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	channelID := viper.GetString("channel")
+	if channelID != "" {
+		if channelID, err = resolveChannelOffline(channelID); err != nil {
+			return err
+		}
+	}
+	occurrences, err := a.Concordance(channelID, viper.GetString("term"))
+	if err != nil {
+		return err
+	}
+	if jsonOutput() {
+		rows := make([]concordanceRow, len(occurrences))
+		for i, o := range occurrences {
+			rows[i] = concordanceRow{
+				VideoID:     o.Video.VideoID,
+				Title:       o.Video.Title,
+				PublishedAt: o.Video.PublishedAt,
+				Timestamp:   transcript.FormatTimestamp(o.Start),
+				Seconds:     o.Start.Seconds(),
+				URL:         transcript.WatchURL(o.Video.VideoID, o.Start),
+				Sentence:    o.Sentence,
+			}
+		}
+		setResult(rows)
+		return nil
+	}
+	return archive.WriteConcordanceCSV(os.Stdout, occurrences)
+}
+
+// concordanceRow is an occurrence under --json, with the CSV's columns.
+type concordanceRow struct {
+	VideoID     string    `json:"video_id"`
+	Title       string    `json:"title"`
+	PublishedAt time.Time `json:"published_at,omitzero"`
+	Timestamp   string    `json:"timestamp"`
+	Seconds     float64   `json:"seconds"`
+	URL         string    `json:"url"`
+	Sentence    string    `json:"sentence"`
+}
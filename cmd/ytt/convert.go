@@ -0,0 +1,210 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert <file>...",
+	Short: "Convert local caption files between formats, without the API",
+	Long: `Convert caption files to another format with the same parsing, formatting,
+and processing ytt applies to downloads, for transcripts obtained elsewhere.
+Each file's format is detected from its content unless --from is given.
+No API calls are made.
+
+A single file is written to stdout, or to --out if given, as it must be
+with --json. Several files are each written beside their input with the
+new extension, or into the --out directory. A file named "-" is read from
+stdin.
+
+--video and --title describe the video the captions belong to, for the
+formats that embed metadata or link timestamps to the video.`,
+	Example: `  ytt convert talk.vtt --to srt > talk.srt
+  ytt convert captions/*.srt --to md --process dedupe,paragraphs
+  ytt convert whisper.srt --to html --video abc123 --title "My Talk" --out talk.html`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runConvert,
+}
+
+func init() {
+	convertCmd.Flags().String("to", "", "format to convert to: "+formatList)
+	convertCmd.Flags().String("from", "", "format of the input files (default: detected from their content)")
+	convertCmd.Flags().String("process", "", "comma-separated processing steps to apply ("+strings.Join(transcript.ProcessorNames(), ", ")+")")
+	convertCmd.Flags().String("redact", "", "replace personal information with placeholders: pii for all of it, or a comma-separated list of email, phone, and address")
+	convertCmd.Flags().String("mask-words", "", "mask or replace the words listed in this file, one per line, or \"builtin\" for common profanities")
+	addLayoutFlags(convertCmd)
+	convertCmd.Flags().String("newline", "lf", "line endings to write: lf or crlf")
+	convertCmd.Flags().Bool("raw-text", false, "keep HTML entities and inline tags in caption text instead of decoding and removing them")
+	convertCmd.Flags().String("out", "", "file to write to, or directory with several input files")
+	convertCmd.Flags().String("video", "", "ID of the video the captions belong to")
+	convertCmd.Flags().String("title", "", "title of the video the captions belong to")
+	convertCmd.MarkFlagRequired("to")
+	convertCmd.RegisterFlagCompletionFunc("to", completeFormats)
+	convertCmd.RegisterFlagCompletionFunc("from", completeFormats)
+	convertCmd.RegisterFlagCompletionFunc("process", completeProcessors)
+	convertCmd.RegisterFlagCompletionFunc("newline", completeNewlines)
+
+	rootCmd.AddCommand(convertCmd)
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	to := transcript.Format(viper.GetString("to"))
+	if !knownFormat(to) {
+		return fmt.Errorf("unsupported format %q (want %s)", to, formatList)
+	}
+	from := transcript.Format(viper.GetString("from"))
+	if from != "" && !knownFormat(from) {
+		return fmt.Errorf("unsupported format %q (want %s)", from, formatList)
+	}
+	if err := checkLayout(to); err != nil {
+		return err
+	}
+	newline, err := transcript.ParseNewline(viper.GetString("newline"))
+	if err != nil {
+		return err
+	}
+	var process transcript.Pipeline
+	if spec := processSpec(); spec != "" {
+		p, err := transcript.ParsePipeline(spec)
+		if err != nil {
+			return err
+		}
+		process = p
+	}
+	src := transcript.Source{VideoID: viper.GetString("video"), Title: viper.GetString("title")}
+
+	// Work out where each file goes.
+	out := viper.GetString("out")
+	dests := make([]string, len(args))
+	for i, in := range args {
+		switch {
+		case len(args) == 1:
+			dests[i] = out
+		case in == "-":
+			return fmt.Errorf("stdin can only be converted on its own")
+		case out != "":
+			dests[i] = filepath.Join(out, convertedName(in, to))
+		default:
+			dests[i] = filepath.Join(filepath.Dir(in), convertedName(in, to))
+		}
+		if dests[i] != "" && in != "-" && sameFile(in, dests[i]) {
+			return fmt.Errorf("converting %s would overwrite it", in)
+		}
+	}
+	if dests[0] == "" && jsonOutput() {
+		return fmt.Errorf("--json needs --out, as the converted file would also be written to stdout")
+	}
+	if info, err := os.Stdout.Stat(); dests[0] == "" && to.Binary() && err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return fmt.Errorf("--to %s writes a binary file; give --out or redirect stdout", to)
+	}
+
+	if dryRun() {
+		p := &plan{action: "convert"}
+		for i, in := range args {
+			p.add(nil, "%s -> %s", in, cmp.Or(dests[i], "stdout"))
+		}
+		p.print()
+		return nil
+	}
+
+	if len(args) > 1 && out != "" {
+		if err := os.MkdirAll(out, 0755); err != nil {
+			return fmt.Errorf("error creating output directory: %w", err)
+		}
+	}
+	var failed int
+	var converted []convertResult
+	for i, in := range args {
+		if err := convertFile(in, dests[i], from, to, src, process, newline); err != nil {
+			if len(args) == 1 {
+				return err
+			}
+			warn(err)
+			failed++
+			continue
+		}
+		converted = append(converted, convertResult{Input: in, Output: dests[i]})
+	}
+	if jsonOutput() {
+		setResult(converted)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed to convert", failed, len(args))
+	}
+	return nil
+}
+
+// convertResult is a file converted, under --json.
+type convertResult struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// convertFile converts the caption file in to format to and writes it to
+// dest, or stdout if dest is empty, ending text formats' lines with newline.
+func convertFile(in, dest string, from, to transcript.Format, src transcript.Source, process transcript.Pipeline, newline transcript.Newline) error {
+	var data []byte
+	var err error
+	if in == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(in)
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", in, err)
+	}
+
+	if from == "" {
+		from = transcript.DetectFormat(data)
+	}
+	cues, err := transcript.ParseFormatOptions(data, from, transcript.ParseOptions{RawText: viper.GetBool("raw-text")})
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %w", in, err)
+	}
+	if cues, err = process.Process(src, cues); err != nil {
+		return fmt.Errorf("error processing %s: %w", in, err)
+	}
+	converted, err := to.FormatSource(src, cues)
+	if err != nil {
+		return fmt.Errorf("error converting %s: %w", in, err)
+	}
+	if !to.Binary() {
+		converted = transcript.Normalize(converted, newline)
+	}
+
+	if dest == "" {
+		_, err = os.Stdout.Write(converted)
+		return err
+	}
+	if err := os.WriteFile(dest, converted, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", dest, err)
+	}
+	fmt.Fprintf(stderr, "Converted %s to %s\n", in, dest)
+	return nil
+}
+
+// convertedName returns the name of a file converted to format: its base
+// name with the format's extension.
+func convertedName(path string, format transcript.Format) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "." + string(format)
+}
+
+// sameFile reports whether a and b name the same existing file.
+func sameFile(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
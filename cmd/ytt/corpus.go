@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var corpusCmd = &cobra.Command{
+	Use:   "corpus",
+	Short: "Merge archived transcripts into one JSON Lines file",
+	Long: `Write the transcripts in the output directory to a single JSON Lines file,
+for feeding to NLP and embedding pipelines. Each line is a record with the
+video's ID, title, channel, publish date, language, and a link, and either
+the video's whole transcript (--per video) or one cue with its start and
+end in seconds (--per cue). Videos are ordered by publish date.
+
+With --chunks, each record is instead a chunk of consecutive cues of up to
+--chunk-tokens tokens, sized for vector-database ingestion, with its start
+and end in seconds. Each chunk repeats up to --chunk-overlap tokens from the
+end of the one before, so text split between chunks is found whole in one.
+Tokens are estimated from the text, erring high, so chunks fit the model.
+
+With --channel, only that channel's videos are included. Transcripts
+downloaded by earlier versions of ytt have no channel recorded, and are
+only included without --channel. Pass --out - to write to stdout.`,
+	Args: cobra.NoArgs,
+	RunE: runCorpus,
+}
+
+func init() {
+	corpusCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	corpusCmd.Flags().String("channel", "", "only include this channel's videos")
+	corpusCmd.RegisterFlagCompletionFunc("channel", completeChannelIDs)
+	corpusCmd.Flags().String("out", "corpus.jsonl", "file to write the corpus to, or - for stdout")
+	corpusCmd.Flags().String("per", "video", "write one record per video or per cue")
+	corpusCmd.Flags().Bool("chunks", false, "write one record per chunk of cues sized for embedding")
+	corpusCmd.Flags().Int("chunk-tokens", 500, "most tokens in a chunk, with --chunks")
+	corpusCmd.Flags().Int("chunk-overlap", 50, "most tokens a chunk repeats from the one before, with --chunks")
+	corpusCmd.MarkFlagsMutuallyExclusive("per", "chunks")
+	corpusCmd.RegisterFlagCompletionFunc("per", cobra.FixedCompletions([]string{"video", "cue"}, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.AddCommand(corpusCmd)
+}
+
+func runCorpus(cmd *cobra.Command, args []string) error {
+	opts := archive.CorpusOptions{
+		ChunkTokens:  viper.GetInt("chunk-tokens"),
+		ChunkOverlap: viper.GetInt("chunk-overlap"),
+	}
+	var err error
+	if opts.Unit, err = archive.ParseCorpusUnit(viper.GetString("per")); err != nil {
+		return err
+	}
+	if viper.GetBool("chunks") {
+		opts.Unit = archive.CorpusChunk
+	}
+	if opts.ChunkTokens <= 0 {
+		return fmt.Errorf("--chunk-tokens must be positive")
+	}
+	if opts.ChunkOverlap < 0 || opts.ChunkOverlap >= opts.ChunkTokens {
+		return fmt.Errorf("--chunk-overlap must be at least 0 and less than --chunk-tokens")
+	}
+	a, err := archive.Open(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	if channel := viper.GetString("channel"); channel != "" {
+		if opts.ChannelID, err = resolveChannelOffline(channel); err != nil {
+			return err
+		}
+	}
+	out := viper.GetString("out")
+	if out == "-" && jsonOutput() {
+		return fmt.Errorf("--out - can't be used with --json, which also writes to stdout")
+	}
+
+	if dryRun() {
+		videos, err := a.Videos()
+		if err != nil {
+			return err
+		}
+		p := &plan{action: "write one record per " + string(opts.Unit) + " to " + out + " for"}
+		for _, v := range videos {
+			if opts.ChannelID == "" || v.ChannelID == opts.ChannelID {
+				p.add(nil, "%s  %s", v.VideoID, v.Title)
+			}
+		}
+		p.print()
+		return nil
+	}
+
+	var f *os.File
+	w := io.Writer(os.Stdout)
+	if out != "-" {
+		if f, err = os.Create(out); err != nil {
+			return fmt.Errorf("error creating corpus file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	summary, err := a.WriteCorpus(bw, opts)
+	if err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("error writing corpus: %w", err)
+	}
+	if f != nil {
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("error writing corpus: %w", err)
+		}
+	}
+	for _, err := range summary.Skipped {
+		warn(err)
+	}
+	if summary.Videos == 0 {
+		return fmt.Errorf("no transcripts in %s", a.Dir)
+	}
+
+	if jsonOutput() {
+		setResult(corpusResult{File: out, Videos: summary.Videos, Records: summary.Records, Skipped: len(summary.Skipped)})
+		return nil
+	}
+	if out == "-" {
+		out = "stdout"
+	}
+	fmt.Fprintf(stderr, "Wrote %d records from %d videos to %s\n", summary.Records, summary.Videos, out)
+	return nil
+}
+
+type corpusResult struct {
+	File    string `json:"file"`
+	Videos  int    `json:"videos"`
+	Records int    `json:"records"`
+	Skipped int    `json:"skipped"`
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/n2p5/ytt/internal/batch"
+	"github.com/n2p5/ytt/internal/coverage"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Report which of a channel's videos have human-made or only automatic captions",
+	Long: `List the caption tracks of every video on the channel and report, per
+video, whether it has captions written by people ("manual"), only YouTube's
+automatic speech recognition ("asr_only"), or none, with the languages of
+each, followed by the percentage of videos in each group.
+
+With --csv, the per-video report is written as CSV and the summary goes to
+stderr. Listing captions costs quota for every video, so check the estimate
+with --dry-run on a large channel.`,
+	Args: cobra.NoArgs,
+	RunE: runCoverage,
+}
+
+func init() {
+	coverageCmd.Flags().String("channel", "", "channel ID, @handle, or URL (default: authenticated user's channel)")
+	coverageCmd.RegisterFlagCompletionFunc("channel", completeChannelIDs)
+	coverageCmd.Flags().IntP("workers", "w", 1, "number of videos to check concurrently")
+	coverageCmd.Flags().Bool("csv", false, "write the per-video report as CSV")
+	addContentFilterFlags(coverageCmd)
+
+	rootCmd.AddCommand(coverageCmd)
+}
+
+func runCoverage(cmd *cobra.Command, args []string) error {
+	channelID, _ := cmd.Flags().GetString("channel")
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	if channelID, err = resolveChannel(client, channelID); err != nil {
+		return err
+	}
+
+	var videos []youtube.VideoInfo
+	for v, err := range client.Videos(context.Background(), channelID, listOptions()) {
+		if err != nil {
+			return err
+		}
+		videos = append(videos, v)
+	}
+
+	if dryRun() {
+		p := &plan{action: "list captions for"}
+		for _, v := range videos {
+			p.add([]youtube.Method{youtube.MethodCaptionsList}, "%s  %s", v.VideoID, v.Title)
+		}
+		p.print()
+		return nil
+	}
+
+	ids := make([]string, len(videos))
+	titles := map[string]string{}
+	for i, v := range videos {
+		ids[i] = v.VideoID
+		titles[v.VideoID] = v.Title
+	}
+	var mu sync.Mutex
+	found := map[string]coverage.Video{}
+	results := runBatch("coverage", ids, func(ctx context.Context, videoID string) error {
+		tracks, err := client.ListCaptions(videoID)
+		v := coverage.FromTracks(videoID, titles[videoID], tracks)
+		if err != nil {
+			v.Error = err.Error()
+		}
+		mu.Lock()
+		found[videoID] = v
+		mu.Unlock()
+		return err
+	})
+
+	// Report in the channel's order rather than the order checks finished,
+	// leaving out the videos skipped once the quota ran out or the run was
+	// interrupted, which were never checked.
+	report := make([]coverage.Video, 0, len(ids))
+	for _, id := range ids {
+		if v, ok := found[id]; ok {
+			report = append(report, v)
+		}
+	}
+	summary := coverage.Summarize(report)
+
+	switch {
+	case jsonOutput():
+		setResult(coverageResult{ChannelID: channelID, Videos: report, Summary: summary})
+	case viper.GetBool("csv"):
+		if err := coverage.WriteCSV(os.Stdout, report); err != nil {
+			return err
+		}
+		printCoverageSummary(stderr, summary)
+	default:
+		for _, v := range report {
+			langs := strings.Join(v.Manual, ",")
+			if v.Status() == coverage.StatusASR {
+				langs = strings.Join(v.ASR, ",")
+			}
+			fmt.Printf("%-8s  %s  %-8s  %s\n", v.Status(), v.VideoID, langs, v.Title)
+		}
+		fmt.Println()
+		printCoverageSummary(os.Stdout, summary)
+	}
+
+	failed := batch.Failed(results)
+	for _, r := range failed {
+		fmt.Fprintf(stderr, "Failed %s: %v\n", r.ID, r.Err)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d videos failed", len(failed), len(results))
+	}
+	return nil
+}
+
+// coverageResult is coverage's result under --json.
+type coverageResult struct {
+	ChannelID string           `json:"channel_id"`
+	Videos    []coverage.Video `json:"videos"`
+	Summary   coverage.Summary `json:"summary"`
+}
+
+func printCoverageSummary(w io.Writer, s coverage.Summary) {
+	fmt.Fprintf(w, "%d videos: %.1f%% with human captions, %.1f%% with only automatic captions, %.1f%% without captions\n",
+		s.Videos, s.Percent(s.Manual), s.Percent(s.ASROnly), s.Percent(s.None))
+	if s.Unknown > 0 {
+		fmt.Fprintf(w, "%d videos couldn't be checked\n", s.Unknown)
+	}
+}
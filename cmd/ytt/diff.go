@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/textdiff"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <video_id>",
+	Short: "Show how a video's published captions differ from the archived copy",
+	Long: `Compare the caption track currently published on YouTube with the copy
+in the output directory and print a unified diff of their text, one cue per
+line, to audit what an editor or YouTube's auto-sync changed. Raw captions
+kept with --keep-raw are compared when there are any; otherwise the
+published captions are given the same processing as the archived
+transcript, so only changes on YouTube show.
+
+The published captions are those of the track the transcript was made
+from, which the manifest records, or for older transcripts the track in
+the transcript's language. It is an error if that track is gone.
+
+If the track's last update time on YouTube matches the one recorded when
+the transcript was downloaded, the captions are known to be unchanged and
+aren't downloaded again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	diffCmd.Flags().IntP("unified", "U", 3, "lines of context around each change")
+	diffCmd.Flags().Bool("timings", false, "include each cue's timing, to show retimed cues as changes")
+	diffCmd.Flags().Bool("force", false, "download and compare the captions even if YouTube reports them unchanged")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	videoID := args[0]
+
+	a, err := archive.Open(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	e, ok, err := a.Video(videoID)
+	if err != nil {
+		return err
+	}
+	if !ok || e.File == "" {
+		return fmt.Errorf("video %s is not in %s; download it first", videoID, a.Dir)
+	}
+
+	// Raw captions are compared as downloaded. A processed transcript is
+	// compared with the published captions processed the same way.
+	label := e.File
+	var process transcript.Pipeline
+	var archived []transcript.Cue
+	if e.Raw != "" {
+		if data, err := os.ReadFile(filepath.Join(a.Dir, filepath.FromSlash(e.Raw))); err == nil {
+			if archived, err = transcript.Parse(data); err != nil {
+				return fmt.Errorf("error parsing %s: %w", e.Raw, err)
+			}
+			label = e.Raw
+		}
+	}
+	if archived == nil {
+		if archived, err = a.Cues(videoID); err != nil {
+			return err
+		}
+		if e.Process != "" {
+			if process, err = transcript.ParsePipeline(e.Process); err != nil {
+				return fmt.Errorf("error reading the processing of %s: %w", e.File, err)
+			}
+		}
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	// Compare with the track the transcript was made from, not whichever
+	// the client would pick today.
+	var src transcript.Source
+	var track youtube.CaptionTrack
+	if e.CaptionID == "" && e.Language == "" {
+		src, track, err = client.FindCaptions(videoID)
+	} else {
+		src, track, err = client.FindCaptionTrack(videoID, e.CaptionID, e.Language)
+	}
+	if errors.Is(err, youtube.ErrNoCaptions) {
+		return fmt.Errorf("the captions %s was made from are gone: %w", e.File, err)
+	}
+	if err != nil {
+		return err
+	}
+	updatedAt := track.UpdatedAt()
+	res := diffResult{VideoID: videoID, File: label, ArchivedUpdatedAt: e.CaptionUpdatedAt, UpdatedAt: updatedAt}
+	if !updatedAt.IsZero() && updatedAt.Equal(e.CaptionUpdatedAt) && !viper.GetBool("force") {
+		if jsonOutput() {
+			setResult(res)
+			return nil
+		}
+		fmt.Fprintf(stderr, "Captions for %s are unchanged since %s\n", videoID, updatedAt.Local().Format(time.DateTime))
+		return nil
+	}
+
+	data, err := client.DownloadCaptions(track, false)
+	if err != nil {
+		return err
+	}
+	current, err := transcript.Parse(data)
+	if err != nil {
+		return fmt.Errorf("error parsing captions: %w", err)
+	}
+	if current, err = process.Process(src, current); err != nil {
+		return err
+	}
+
+	timings := viper.GetBool("timings")
+	edits := textdiff.Lines(diffLines(archived, timings), diffLines(current, timings))
+	res.Removed, res.Added = textdiff.Changed(edits)
+	res.Diff = textdiff.Unified(edits, viper.GetInt("unified"))
+	if jsonOutput() {
+		setResult(res)
+		return nil
+	}
+	if res.Diff == "" {
+		fmt.Fprintf(stderr, "Captions for %s have no changes in their text\n", videoID)
+		return nil
+	}
+	fmt.Printf("--- %s\t%s\n", label, diffTime(e.CaptionUpdatedAt))
+	fmt.Printf("+++ youtube/%s\t%s\n", videoID, diffTime(updatedAt))
+	fmt.Print(res.Diff)
+	return nil
+}
+
+// diffResult is the comparison under --json. Diff is empty if the text is
+// unchanged or the captions weren't downloaded because YouTube reports them
+// unchanged.
+type diffResult struct {
+	VideoID           string    `json:"video_id"`
+	File              string    `json:"file"`
+	ArchivedUpdatedAt time.Time `json:"archived_updated_at,omitzero"`
+	UpdatedAt         time.Time `json:"updated_at,omitzero"`
+	Removed           int       `json:"removed"`
+	Added             int       `json:"added"`
+	Diff              string    `json:"diff"`
+}
+
+// diffLines returns the lines of cues to compare: each cue's text on one
+// line, after its timing if timings is set.
+func diffLines(cues []transcript.Cue, timings bool) []string {
+	lines := make([]string, len(cues))
+	for i, c := range cues {
+		lines[i] = strings.ReplaceAll(c.Text, "\n", " ")
+		if timings {
+			lines[i] = fmt.Sprintf("[%s --> %s] %s", diffTimestamp(c.Start), diffTimestamp(c.End), lines[i])
+		}
+	}
+	return lines
+}
+
+// diffTimestamp formats a cue time to the millisecond, so small retimings
+// show.
+func diffTimestamp(d time.Duration) string {
+	return fmt.Sprintf("%s.%03d", transcript.FormatTimestamp(d), d.Milliseconds()%1000)
+}
+
+// diffTime formats a caption track's update time for a diff header.
+func diffTime(t time.Time) string {
+	if t.IsZero() {
+		return "(update time unknown)"
+	}
+	return t.Local().Format(time.DateTime)
+}
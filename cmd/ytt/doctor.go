@@ -0,0 +1,357 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/filename"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the setup and show how to fix what's wrong",
+	Long: `Show the config file and every path ytt uses, resolved to an absolute path,
+with where it was set (flag, environment, config file, or default) and
+whether it exists. Relative paths in the config file are relative to the
+config file's directory.
+
+Then check that the config file parses, the OAuth client and token are
+usable, the YouTube Data API can be reached and is enabled for the client's
+project, and the output directory is writable; estimate the quota left
+today; and look for the optional programs some commands run. Each check is
+printed as OK, WARN, FAIL, or SKIP, with how to fix it.
+
+Checking the API costs 1 quota unit. doctor never signs in, so it is safe
+to run where no one can. The command fails if any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().StringP("output", "o", "outputs", "directory, or s3:// or gs:// URL, transcripts are saved in")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorPath is a path ytt uses, as listed under --json.
+type doctorPath struct {
+	Name   string `json:"name"`
+	Key    string `json:"key"`
+	Path   string `json:"path"`
+	Source string `json:"source"`
+	Exists bool   `json:"exists"`
+}
+
+// doctorNames are the names doctor shows pathKeys under.
+var doctorNames = map[string]string{
+	"oauth":     "OAuth client",
+	"token":     "Token",
+	"output":    "Output",
+	"data_dir":  "Data",
+	"cache_dir": "Cache",
+	"crash_dir": "Crash reports",
+}
+
+// Statuses of a doctor check.
+const (
+	checkOK   = "OK"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+	checkSkip = "SKIP"
+)
+
+// doctorCheck is the result of one check, as listed under --json.
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Fix says how to fix a failed or warned check.
+	Fix string `json:"fix,omitempty"`
+}
+
+// doctorReport is the result of ytt doctor.
+type doctorReport struct {
+	Paths  []doctorPath  `json:"paths"`
+	Checks []doctorCheck `json:"checks"`
+}
+
+// check records a check's result and prints it, unless under --json.
+func (r *doctorReport) check(name, status, detail, fix string) {
+	c := doctorCheck{Name: name, Status: status, Detail: detail, Fix: fix}
+	r.Checks = append(r.Checks, c)
+	if jsonOutput() {
+		return
+	}
+	fmt.Printf("%-4s  %-12s %s\n", c.Status, c.Name, c.Detail)
+	if c.Fix != "" {
+		fmt.Printf("      %-12s Fix: %s\n", "", c.Fix)
+	}
+}
+
+// failed returns how many checks failed.
+func (r *doctorReport) failed() int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == checkFail {
+			n++
+		}
+	}
+	return n
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	// Checking the token must never start the OAuth flow.
+	viper.Set("non-interactive", true)
+
+	r := &doctorReport{Paths: doctorPaths()}
+	if !jsonOutput() {
+		for _, p := range r.Paths {
+			state := ""
+			if !p.Exists {
+				state = ", missing"
+			}
+			fmt.Printf("%-14s %s (%s%s)\n", p.Name+":", p.Path, p.Source, state)
+		}
+		fmt.Println()
+	}
+
+	checkConfig(r)
+	credsOK := checkCredentials(r)
+	tokenOK := checkToken(r, credsOK)
+	checkAPI(r, tokenOK)
+	checkQuota(r)
+	checkOutput(r)
+	checkTools(r)
+
+	if jsonOutput() {
+		setResult(r)
+	}
+	if n := r.failed(); n > 0 {
+		return fmt.Errorf("%d check(s) failed", n)
+	}
+	return nil
+}
+
+// doctorPaths returns the config file and the paths in pathKeys, resolved.
+func doctorPaths() []doctorPath {
+	config := doctorPath{Name: "Config", Key: "config", Path: viper.ConfigFileUsed(), Source: fromDefault}
+	if cfgFile != "" {
+		config.Source = fromFlag
+	}
+	if config.Path == "" {
+		config.Path = filepath.Join(configDir(), "config.yaml")
+	}
+	paths := []doctorPath{config}
+	for _, key := range pathKeys {
+		paths = append(paths, doctorPath{Name: doctorNames[key], Key: key, Path: viper.GetString(key), Source: pathSources[key]})
+	}
+	for i := range paths {
+		p := &paths[i]
+		if strings.Contains(p.Path, "://") {
+			p.Exists = true
+			continue
+		}
+		if abs, err := filepath.Abs(p.Path); err == nil {
+			p.Path = abs
+		}
+		_, err := os.Stat(p.Path)
+		p.Exists = err == nil
+	}
+	return paths
+}
+
+// checkConfig checks that the config file, if there is one, parses.
+func checkConfig(r *doctorReport) {
+	path := viper.ConfigFileUsed()
+	if _, err := os.Stat(path); path == "" || errors.Is(err, os.ErrNotExist) {
+		if cfgFile != "" {
+			r.check("config", checkFail, fmt.Sprintf("%s doesn't exist", cfgFile), "create it, or drop --config to use the defaults")
+			return
+		}
+		r.check("config", checkOK, "no config file; using the defaults", "")
+		return
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		r.check("config", checkFail, err.Error(), fmt.Sprintf("fix the YAML in %s", path))
+		return
+	}
+	r.check("config", checkOK, path, "")
+}
+
+// checkCredentials checks that the OAuth client can be read, and reports
+// whether it can.
+func checkCredentials(r *doctorReport) bool {
+	opts, err := authOptions()
+	if err != nil {
+		r.check("credentials", checkFail, err.Error(), "fix or unset the YTT_CREDENTIALS or YTT_CLIENT_* variables")
+		return false
+	}
+	oauthPath := viper.GetString("oauth")
+	id, err := youtube.ClientID(oauthPath, opts...)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		r.check("credentials", checkFail, fmt.Sprintf("no OAuth client secret at %s", oauthPath),
+			fmt.Sprintf("download a Desktop app OAuth client from the Google Cloud console and save it as %s, or pass --oauth", oauthPath))
+		return false
+	case err != nil:
+		r.check("credentials", checkFail, err.Error(), "download the OAuth client's JSON again from the Google Cloud console")
+		return false
+	}
+	source := oauthPath
+	if creds, _ := envCredentials(); creds != nil {
+		source = "the environment"
+	}
+	r.check("credentials", checkOK, fmt.Sprintf("client %s from %s", id, source), "")
+	return true
+}
+
+// checkToken checks that there is a token that works or can be refreshed,
+// and reports whether there is.
+func checkToken(r *doctorReport, credsOK bool) bool {
+	opts, err := authOptions()
+	if err != nil {
+		r.check("token", checkSkip, "", "")
+		return false
+	}
+	s, err := youtube.InspectToken(viper.GetString("token"), opts...)
+	switch {
+	case err != nil:
+		r.check("token", checkFail, err.Error(), `run "ytt auth login" to replace it`)
+		return false
+	case !s.Present:
+		r.check("token", checkFail, fmt.Sprintf("no token in %s", s.Storage), `run "ytt auth login", or pass one in with YTT_CREDENTIALS`)
+		return false
+	case s.Expired && !s.Refreshable:
+		r.check("token", checkFail, fmt.Sprintf("the token in %s has expired and can't be refreshed", s.Storage), `run "ytt auth login"`)
+		return false
+	case s.Expired:
+		r.check("token", checkOK, fmt.Sprintf("%s; the access token is refreshed on the next call", s.Storage), "")
+	default:
+		r.check("token", checkOK, fmt.Sprintf("%s; valid for %s", s.Storage, time.Until(s.Expiry).Round(time.Minute)), "")
+	}
+	if s.Scopes != nil && !slices.Contains(s.Scopes, youtube.CaptionScope) {
+		r.check("scopes", checkWarn, "the token can't list or download captions yet",
+			`run "ytt auth login --captions", or let the first caption download ask`)
+	}
+	return credsOK
+}
+
+// checkAPI checks that the API can be reached with the token and is
+// enabled for the client's project.
+func checkAPI(r *doctorReport, tokenOK bool) {
+	if !tokenOK {
+		r.check("api", checkSkip, "needs working credentials and a token", "")
+		return
+	}
+	var channelID string
+	client, err := newClient()
+	if err == nil {
+		channelID, err = client.AuthenticatedChannelID()
+	}
+	switch {
+	case err == nil:
+		r.check("api", checkOK, "reached YouTube as channel "+channelID, "")
+	case youtube.IsAPIDisabled(err):
+		r.check("api", checkFail, "the YouTube Data API v3 isn't enabled for the OAuth client's project",
+			"enable it at https://console.cloud.google.com/apis/library/youtube.googleapis.com for the project the OAuth client belongs to")
+	case youtube.IsQuotaExceeded(err):
+		r.check("api", checkFail, "the project's daily quota is used up",
+			fmt.Sprintf("wait until it resets at %s, or request more quota in the Google Cloud console", youtube.QuotaReset(time.Now()).Local().Format("15:04 MST")))
+	case youtube.IsAuthError(err):
+		r.check("api", checkFail, err.Error(), `run "ytt auth login"`)
+	default:
+		r.check("api", checkFail, err.Error(), "check the network connection, and --proxy or HTTPS_PROXY if you use a proxy")
+	}
+}
+
+// checkQuota estimates the quota left today from the quota logged by runs
+// on this machine.
+func checkQuota(r *doctorReport) {
+	now := time.Now()
+	log, err := youtube.LoadQuotaLog(quotaLogPath(), now)
+	if err != nil {
+		r.check("quota", checkWarn, err.Error(), fmt.Sprintf("delete %s", quotaLogPath()))
+		return
+	}
+	daily := viper.GetInt("daily_quota")
+	// The doctor's own API check hasn't been logged yet.
+	used := log.Units + apiUsage.Total()
+	left := daily - used
+	detail := fmt.Sprintf("about %d of %d units left today, counting ytt runs on this machine; resets in %s",
+		max(left, 0), daily, youtube.QuotaReset(now).Sub(now).Round(time.Minute))
+	if left < youtube.CostDownloadTranscript {
+		r.check("quota", checkWarn, detail, "wait for the reset, or set daily_quota in the config file if the project has more")
+		return
+	}
+	r.check("quota", checkOK, detail, "")
+}
+
+// checkOutput checks that transcripts can be written to the output
+// directory.
+func checkOutput(r *doctorReport) {
+	dir := viper.GetString("output")
+	if strings.Contains(dir, "://") {
+		r.check("output", checkSkip, dir+" is object storage; not checked", "")
+		return
+	}
+	target := dir
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		// The directory is created on the first download.
+		target = filepath.Dir(filepath.Clean(dir))
+		for {
+			if _, err := os.Stat(target); err == nil || target == filepath.Dir(target) {
+				break
+			}
+			target = filepath.Dir(target)
+		}
+	}
+	f, err := os.CreateTemp(target, ".ytt-doctor-*")
+	if err != nil {
+		r.check("output", checkFail, fmt.Sprintf("can't write to %s: %v", target, err), "pass --output with a writable directory, or fix its permissions")
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	if abs, err := filepath.Abs(dir); err == nil && filename.Current.PathTooLong(filepath.Join(abs, strings.Repeat("x", youtube.MaxTranscriptFilename))) {
+		r.check("output", checkWarn, fmt.Sprintf("transcript paths in %s can be longer than Windows' %d-character limit, which some programs can't open", abs, filename.Current.MaxPath),
+			"use a shorter --output path, or enable long paths in Windows (LongPathsEnabled)")
+		return
+	}
+	if target != dir {
+		r.check("output", checkOK, fmt.Sprintf("%s will be created", dir), "")
+		return
+	}
+	r.check("output", checkOK, fmt.Sprintf("%s is writable", dir), "")
+}
+
+// doctorTool is an optional program some commands run.
+type doctorTool struct {
+	name, program, usedBy, install string
+}
+
+// checkTools looks for the optional programs some commands run.
+func checkTools(r *doctorReport) {
+	tools := []doctorTool{
+		{"downloader", viper.GetString("audio_downloader"), "--with-audio and clip --media", "install yt-dlp from https://github.com/yt-dlp/yt-dlp, or set audio_downloader and clip_downloader"},
+		{"ffmpeg", "ffmpeg", "yt-dlp to extract audio and cut clips", "install ffmpeg from https://ffmpeg.org"},
+		{"whisper", "whisper", "transcribing audio to import with ytt import", "install openai-whisper with pip"},
+	}
+	for _, t := range tools {
+		path, err := exec.LookPath(t.program)
+		if err != nil {
+			r.check(t.name, checkWarn, fmt.Sprintf("%s not found; needed for %s", t.program, t.usedBy), t.install)
+			continue
+		}
+		r.check(t.name, checkOK, path, "")
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// mask-words reads files, so it's registered here rather than in the
+// transcript package: while package variables are initialized, before the
+// init functions list the processors in flag help.
+var _ = func() bool {
+	transcript.RegisterProcessor("mask-words", maskWordsFactory)
+	return true
+}()
+
+// formats are the formats transcripts can be converted to.
+var formats = []transcript.Format{
+	transcript.FormatVTT, transcript.FormatSRT, transcript.FormatSBV, transcript.FormatJSON, transcript.FormatPlain,
+	transcript.FormatMD, transcript.FormatHTML, transcript.FormatPDF, transcript.FormatEPUB, transcript.FormatDOCX,
+}
+
+// formatList names the formats for help and errors.
+const formatList = "vtt, srt, sbv, json, txt, md, html, pdf, epub, or docx"
+
+// knownFormat reports whether transcripts can be converted to f.
+func knownFormat(f transcript.Format) bool {
+	return slices.Contains(formats, f)
+}
+
+// addDownloadFlags adds the flags that control how downloaded transcripts
+// are converted and processed before they are saved, and what is done with
+// them after.
+func addDownloadFlags(cmd *cobra.Command) {
+	cmd.Flags().String("format", "", "convert transcripts to "+formatList+" (default: keep YouTube's format)")
+	cmd.Flags().String("process", "", "comma-separated processing steps to apply to each transcript ("+strings.Join(transcript.ProcessorNames(), ", ")+")")
+	cmd.Flags().String("redact", "", "replace personal information with placeholders: pii for all of it, or a comma-separated list of email, phone, and address")
+	cmd.Flags().String("mask-words", "", "mask or replace the words listed in this file, one per line, or \"builtin\" for common profanities")
+	addLayoutFlags(cmd)
+	cmd.Flags().String("lang", "", "language of the captions to download; captions that read as another language are saved with a warning (default: English, or the first track)")
+	cmd.Flags().String("lang-match", "base", "how --lang matches track languages: base (en matches en-US and en-GB, zh-Hans matches zh-CN, closest first) or exact")
+	cmd.Flags().String("prefer", "manual", "when a language has both uploaded and auto-generated captions, download: manual, asr, or any (the first listed)")
+	cmd.Flags().Bool("keep-raw", false, "also keep captions exactly as downloaded, under raw/ in the output directory")
+	cmd.Flags().Bool("keep-styles", false, "keep speaker labels and styling from VTT captions when converting or processing")
+	cmd.Flags().Bool("raw-text", false, "keep HTML entities such as &#39; and inline tags such as <font> in caption text when converting or processing, instead of decoding and removing them")
+	cmd.Flags().Bool("header", false, "begin each transcript with a header block describing the video, caption track, and download, as a comment suited to the format (not srt or sbv)")
+	cmd.Flags().String("newline", "lf", "line endings to save transcripts with: lf or crlf; transcripts are always saved as UTF-8 without a byte order mark")
+	cmd.Flags().Bool("word-timings", false, "keep per-word timings from auto-generated captions (requires --format json)")
+	cmd.Flags().String("summarize-cmd", "", "shell command to pipe each transcript's text through, saving its output as {video_id}-summary.md")
+	cmd.Flags().Bool("keep-history", false, "also keep each downloaded revision of a transcript under .ytt/history/ in the output directory, listed by \"ytt history --transcripts\"")
+	cmd.Flags().Bool("with-audio", false, "also download each video's audio track under audio/ in the output directory, with the downloader set in the config file (default: yt-dlp)")
+	cmd.Flags().Int("priority", 0, "priority of these videos in the job queue; higher-priority jobs are retried first by \"ytt jobs retry\"")
+	cmd.Flags().String("post-hook", "", "shell command to run after each transcript is saved, with YTT_VIDEO_ID, YTT_TITLE, YTT_FILE, YTT_LANG, and YTT_CHANNEL set")
+	cmd.RegisterFlagCompletionFunc("format", completeFormats)
+	cmd.RegisterFlagCompletionFunc("process", completeProcessors)
+	cmd.RegisterFlagCompletionFunc("lang-match", cobra.FixedCompletions([]string{"base", "exact"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("newline", completeNewlines)
+	cmd.RegisterFlagCompletionFunc("prefer", cobra.FixedCompletions([]string{"manual", "asr", "any"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// downloadOptions builds download options from the download flags.
+func downloadOptions() (youtube.DownloadOptions, error) {
+	var opts youtube.DownloadOptions
+
+	opts.Format = transcript.Format(viper.GetString("format"))
+	if opts.Format != "" && !knownFormat(opts.Format) {
+		return opts, fmt.Errorf("unsupported format %q (want %s)", opts.Format, formatList)
+	}
+
+	if err := checkAudioDownloader(); err != nil {
+		return opts, err
+	}
+
+	opts.Lang = viper.GetString("lang")
+	if _, err := youtube.ParseLangMatch(viper.GetString("lang-match")); err != nil {
+		return opts, err
+	}
+	if _, err := youtube.ParseTrackPreference(viper.GetString("prefer")); err != nil {
+		return opts, err
+	}
+	// Under --progress json, the events take the place of these lines.
+	if viper.GetString("progress") == "text" {
+		opts.Log = stderr
+	}
+	opts.KeepRaw = viper.GetBool("keep-raw")
+	opts.KeepStyles = viper.GetBool("keep-styles")
+	opts.RawText = viper.GetBool("raw-text")
+	if opts.Header = viper.GetBool("header"); opts.Header {
+		if opts.Format == transcript.FormatSRT || opts.Format == transcript.FormatSBV {
+			return opts, fmt.Errorf("--header can't be used with --format %s, which has no comments to hold it", opts.Format)
+		}
+		opts.Generator = "ytt " + version()
+	}
+	var err error
+	if opts.Newline, err = transcript.ParseNewline(viper.GetString("newline")); err != nil {
+		return opts, err
+	}
+
+	if opts.WordTimings = viper.GetBool("word-timings"); opts.WordTimings && opts.Format != transcript.FormatJSON {
+		return opts, fmt.Errorf("--word-timings requires --format json")
+	}
+
+	if err := checkLayout(opts.Format); err != nil {
+		return opts, err
+	}
+	if spec := processSpec(); spec != "" {
+		p, err := transcript.ParsePipeline(spec)
+		if err != nil {
+			return opts, err
+		}
+		opts.Process = p
+	}
+	return opts, nil
+}
+
+// completeNewlines completes --newline.
+var completeNewlines = cobra.FixedCompletions([]string{string(transcript.NewlineLF), string(transcript.NewlineCRLF)}, cobra.ShellCompDirectiveNoFileComp)
+
+// addLayoutFlags adds the flags that lay captions out for players and
+// re-upload, which add steps to the end of the --process pipeline.
+func addLayoutFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("wrap", 0, "wrap caption lines at this many characters (vtt, srt, and sbv only)")
+	cmd.Flags().Int("max-cue-chars", 0, "split captions longer than this many characters into consecutive captions (vtt, srt, and sbv only)")
+	cmd.Flags().Duration("max-cue-duration", 0, "split captions lasting longer than this, such as 7s, into consecutive captions (vtt, srt, and sbv only)")
+}
+
+// processSpec returns the --process pipeline with the steps --redact,
+// --mask-words, and the layout flags add, as recorded in the manifest:
+// redact, mask-words, max-chars, max-duration, then wrap, so captions are
+// split once their words are final, and before their lines are wrapped.
+func processSpec() string {
+	var steps []string
+	if spec := viper.GetString("process"); spec != "" {
+		steps = append(steps, spec)
+	}
+	switch kinds := viper.GetString("redact"); kinds {
+	case "":
+	case "pii":
+		steps = append(steps, "redact")
+	default:
+		steps = append(steps, "redact="+strings.Join(strings.Split(strings.ReplaceAll(kinds, " ", ""), ","), "+"))
+	}
+	switch list := viper.GetString("mask-words"); list {
+	case "":
+	case "builtin":
+		steps = append(steps, "profanity-mask")
+	default:
+		if abs, err := filepath.Abs(list); err == nil {
+			list = abs
+		}
+		steps = append(steps, "mask-words="+list)
+	}
+	if n := viper.GetInt("max-cue-chars"); n > 0 {
+		steps = append(steps, fmt.Sprintf("max-chars=%d", n))
+	}
+	if d := viper.GetDuration("max-cue-duration"); d > 0 {
+		steps = append(steps, "max-duration="+d.String())
+	}
+	if n := viper.GetInt("wrap"); n > 0 {
+		steps = append(steps, fmt.Sprintf("wrap=%d", n))
+	}
+	return strings.Join(steps, ",")
+}
+
+// maskWordsFactory builds the mask-words processor from the word list
+// file it names.
+func maskWordsFactory(arg string) (transcript.Processor, error) {
+	if arg == "" {
+		return nil, fmt.Errorf("needs a word list file")
+	}
+	if strings.Contains(arg, ",") {
+		return nil, fmt.Errorf("word list file names can't contain commas")
+	}
+	data, err := os.ReadFile(arg)
+	if err != nil {
+		return nil, fmt.Errorf("error reading word list: %w", err)
+	}
+	rules, err := transcript.ParseWordList(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing word list %s: %w", arg, err)
+	}
+	return transcript.MaskWords(rules), nil
+}
+
+// checkLayout checks the layout flags are only given for caption formats;
+// an empty format keeps YouTube's, which is always one.
+func checkLayout(format transcript.Format) error {
+	if viper.GetInt("wrap") < 0 || viper.GetInt("max-cue-chars") < 0 || viper.GetDuration("max-cue-duration") < 0 {
+		return fmt.Errorf("--wrap, --max-cue-chars, and --max-cue-duration must not be negative")
+	}
+	set := viper.GetInt("wrap") > 0 || viper.GetInt("max-cue-chars") > 0 || viper.GetDuration("max-cue-duration") > 0
+	if set && !slices.Contains([]transcript.Format{"", transcript.FormatVTT, transcript.FormatSRT, transcript.FormatSBV}, format) {
+		return fmt.Errorf("--wrap, --max-cue-chars, and --max-cue-duration apply to vtt, srt, and sbv captions, not %s", format)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/viper"
+)
+
+func dryRun() bool {
+	return viper.GetBool("dry-run")
+}
+
+// plan collects what a command would do under --dry-run.
+type plan struct {
+	action   string
+	items    []string
+	estimate youtube.CostEstimator
+}
+
+// add records an item of the plan and the API calls it would make.
+func (p *plan) add(calls []youtube.Method, format string, args ...any) {
+	p.items = append(p.items, fmt.Sprintf(format, args...))
+	p.estimate.Add(1, calls...)
+}
+
+// planResult is a plan as the command's result under --json.
+type planResult struct {
+	DryRun         bool     `json:"dry_run"`
+	Action         string   `json:"action"`
+	Items          []string `json:"items"`
+	EstimatedQuota int      `json:"estimated_quota"`
+}
+
+// print writes the plan to stdout.
+func (p *plan) print() {
+	if jsonOutput() {
+		setResult(planResult{DryRun: true, Action: p.action, Items: p.items, EstimatedQuota: p.estimate.Total()})
+		return
+	}
+	fmt.Printf("Dry run: would %s %d item(s), estimated %d quota units\n", p.action, len(p.items), p.estimate.Total())
+	for _, item := range p.items {
+		fmt.Printf("  %s\n", item)
+	}
+}
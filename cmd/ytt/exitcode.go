@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/n2p5/ytt/internal/youtube"
+)
+
+// Exit statuses, documented in the README for scripts to branch on.
+const (
+	exitFailure       = 1 // any other error
+	exitPartial       = 2 // some videos in a batch failed
+	exitNoCaptions    = 3
+	exitAuth          = 4
+	exitQuotaExceeded = 5
+	exitNotFound      = 6
+	exitInterrupted   = 130 // stopped by SIGINT or SIGTERM, as shells report
+)
+
+// authError marks a failure to authenticate, such as a missing client
+// secret or a token that can't be refreshed.
+type authError struct {
+	err error
+}
+
+func (e *authError) Error() string { return e.err.Error() }
+func (e *authError) Unwrap() error { return e.err }
+
+// batchError reports the videos that failed in a batch.
+type batchError struct {
+	total int
+	errs  []error
+}
+
+func (e *batchError) Error() string {
+	return fmt.Sprintf("%d of %d transcripts failed", len(e.errs), e.total)
+}
+
+func (e *batchError) Unwrap() []error { return e.errs }
+
+// exitCode returns the exit status for a command that failed with err. A
+// batch in which some videos succeeded exits with exitPartial. One in which
+// every video failed for the same reason exits as that reason would for a
+// single video, and otherwise with exitFailure.
+func exitCode(err error) int {
+	var qe *quotaError
+	if errors.As(err, &qe) {
+		return exitQuotaExceeded
+	}
+	var ie *interruptError
+	if errors.As(err, &ie) {
+		return exitInterrupted
+	}
+	var be *batchError
+	if !errors.As(err, &be) {
+		return errorCode(err)
+	}
+	if len(be.errs) < be.total {
+		return exitPartial
+	}
+	code := errorCode(be.errs[0])
+	for _, e := range be.errs[1:] {
+		if errorCode(e) != code {
+			return exitFailure
+		}
+	}
+	return code
+}
+
+// errorCode classifies a single error.
+func errorCode(err error) int {
+	var ae *authError
+	switch {
+	case errors.As(err, &ae), youtube.IsAuthError(err):
+		return exitAuth
+	case youtube.IsQuotaExceeded(err):
+		return exitQuotaExceeded
+	case errors.Is(err, youtube.ErrNoCaptions):
+		return exitNoCaptions
+	case youtube.IsNotFound(err):
+		return exitNotFound
+	}
+	return exitFailure
+}
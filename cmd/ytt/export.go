@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/book"
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/site"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the transcript archive in other forms",
+}
+
+var exportSiteCmd = &cobra.Command{
+	Use:   "site",
+	Short: "Render the transcript archive as a static website",
+	Long: `Render every transcript in the output directory as a static website: an
+index of channels with a search box, a page listing each channel's videos,
+and a page for each transcript whose timestamps link to that moment of the
+video. The search index is built ahead of time and searched in the browser,
+so the site needs no server and works opened straight from disk.
+
+Running it again rebuilds the site in place.`,
+	Args: cobra.NoArgs,
+	RunE: runExportSite,
+}
+
+var exportBookCmd = &cobra.Command{
+	Use:   "book [video_id]...",
+	Short: "Stitch transcripts from the archive into one PDF, EPUB, or DOCX book",
+	Long: `Stitch transcripts from the output directory into a single PDF, EPUB, or
+DOCX book, with a title page and a chapter for each video, for reading a
+series offline or on an e-reader, or editing it in a word processor.
+Chapters follow the order the videos are given in, or with no videos
+given, include every transcript in the output directory, oldest first.
+
+The format is taken from the --out file's extension unless --format is
+given. To save each video as its own book instead, download it with
+--format pdf, epub, or docx.`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              runExportBook,
+}
+
+func init() {
+	exportBookCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	exportBookCmd.Flags().String("out", "transcripts.epub", "file to write the book to")
+	exportBookCmd.Flags().String("format", "", "book format, pdf, epub, or docx (default: from the --out extension)")
+	exportBookCmd.Flags().String("title", "Transcripts", "title for the book's title page")
+	exportBookCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{string(transcript.FormatPDF), string(transcript.FormatEPUB), string(transcript.FormatDOCX)}, cobra.ShellCompDirectiveNoFileComp))
+
+	exportSiteCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	exportSiteCmd.Flags().String("out", "site", "directory to write the site to")
+
+	exportCmd.AddCommand(exportSiteCmd, exportBookCmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExportSite(cmd *cobra.Command, args []string) error {
+	a, err := archive.Open(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	out := viper.GetString("out")
+
+	if dryRun() {
+		videos, err := a.Videos()
+		if err != nil {
+			return err
+		}
+		p := &plan{action: "write site pages for"}
+		for _, v := range videos {
+			p.add(nil, "%s  %s", v.VideoID, filepath.Join(out, "videos", v.VideoID+".html"))
+		}
+		p.print()
+		return nil
+	}
+
+	summary, err := site.Build(a, out)
+	if err != nil {
+		return err
+	}
+	for _, err := range summary.Skipped {
+		warn(err)
+	}
+	if jsonOutput() {
+		setResult(exportSiteResult{Dir: out, Channels: summary.Channels, Videos: summary.Videos, Skipped: len(summary.Skipped)})
+		return nil
+	}
+	fmt.Fprintf(stderr, "Wrote %d videos from %d channels to %s\n", summary.Videos, summary.Channels, out)
+	fmt.Println(filepath.Join(out, "index.html"))
+	return nil
+}
+
+type exportSiteResult struct {
+	Dir      string `json:"dir"`
+	Channels int    `json:"channels"`
+	Videos   int    `json:"videos"`
+	Skipped  int    `json:"skipped"`
+}
+
+func runExportBook(cmd *cobra.Command, args []string) error {
+	out := viper.GetString("out")
+	format := transcript.Format(viper.GetString("format"))
+	if format == "" {
+		format = transcript.Format(strings.TrimPrefix(filepath.Ext(out), "."))
+	}
+	switch format {
+	case transcript.FormatPDF, transcript.FormatEPUB, transcript.FormatDOCX:
+	default:
+		return fmt.Errorf("unsupported book format %q (want pdf, epub, or docx)", format)
+	}
+
+	a, err := archive.Open(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	entries, err := bookEntries(a, args)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no transcripts in %s", a.Dir)
+	}
+
+	if dryRun() {
+		p := &plan{action: "write a chapter to " + out + " for"}
+		for _, e := range entries {
+			p.add(nil, "%s  %s", e.VideoID, e.Title)
+		}
+		p.print()
+		return nil
+	}
+
+	b := &book.Book{
+		ID:    "export-" + entries[0].VideoID,
+		Title: viper.GetString("title"),
+		Fields: []book.Field{
+			{Key: "videos", Value: fmt.Sprint(len(entries))},
+		},
+	}
+	channels := map[string]bool{}
+	for _, e := range entries {
+		cues, err := a.Cues(e.VideoID)
+		if err != nil {
+			return fmt.Errorf("error reading transcript for %s: %w", e.VideoID, err)
+		}
+		src := transcript.Source{VideoID: e.VideoID, Title: e.Title, ChannelID: e.ChannelID, PublishedAt: e.PublishedAt, Language: e.Language}
+		b.Chapters = append(b.Chapters, transcript.BookChapter(src.Metadata(), cues))
+		channels[e.ChannelID] = true
+		if b.Language == "" {
+			b.Language = e.Language
+		}
+	}
+	if len(channels) == 1 && entries[0].ChannelID != "" {
+		b.Fields = append(b.Fields, book.Field{Key: "channel_id", Value: entries[0].ChannelID})
+	}
+
+	var data []byte
+	switch format {
+	case transcript.FormatPDF:
+		data, err = b.PDF()
+	case transcript.FormatDOCX:
+		data, err = b.DOCX()
+	default:
+		data, err = b.EPUB()
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		return fmt.Errorf("error writing book: %w", err)
+	}
+	if jsonOutput() {
+		setResult(exportBookResult{File: out, Format: string(format), Chapters: len(b.Chapters)})
+		return nil
+	}
+	fmt.Fprintf(stderr, "Wrote %d chapters to %s\n", len(b.Chapters), out)
+	return nil
+}
+
+// bookEntries returns the archive entries for the given videos, in order,
+// or every entry oldest first if none are given.
+func bookEntries(a *archive.Archive, videoIDs []string) ([]manifest.Entry, error) {
+	if len(videoIDs) == 0 {
+		entries, err := a.Videos()
+		if err != nil {
+			return nil, err
+		}
+		slices.SortStableFunc(entries, func(a, b manifest.Entry) int {
+			return a.PublishedAt.Compare(b.PublishedAt)
+		})
+		return entries, nil
+	}
+	var entries []manifest.Entry
+	for _, id := range videoIDs {
+		e, ok, err := a.Video(id)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("video %s is not in %s", id, a.Dir)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+type exportBookResult struct {
+	File     string `json:"file"`
+	Format   string `json:"format"`
+	Chapters int    `json:"chapters"`
+}
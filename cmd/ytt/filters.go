@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addContentFilterFlags adds the flags that choose which kinds of videos a
+// channel listing returns.
+func addContentFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("min-duration", 60, "videos shorter than this many seconds count as shorts")
+	cmd.Flags().Bool("include-shorts", false, "include shorts (skipped by default)")
+	cmd.Flags().Bool("only-shorts", false, "only shorts")
+	cmd.Flags().Bool("exclude-live", false, "skip live streams, current or past")
+	cmd.Flags().Bool("only-live", false, "only live streams, current or past")
+
+	cmd.MarkFlagsMutuallyExclusive("include-shorts", "only-shorts")
+	cmd.MarkFlagsMutuallyExclusive("exclude-live", "only-live")
+}
+
+// listOptions builds listing options from the content filter flags.
+func listOptions() youtube.ListOptions {
+	opts := youtube.ListOptions{MinDurationSeconds: viper.GetInt("min-duration")}
+
+	switch {
+	case viper.GetBool("include-shorts"):
+		opts.Shorts = youtube.ShortsInclude
+	case viper.GetBool("only-shorts"):
+		opts.Shorts = youtube.ShortsOnly
+	}
+
+	switch {
+	case viper.GetBool("exclude-live"):
+		opts.Live = youtube.LiveExclude
+	case viper.GetBool("only-live"):
+		opts.Live = youtube.LiveOnly
+	}
+	return opts
+}
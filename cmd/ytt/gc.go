@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/trash"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Find and fix files and manifest entries that have fallen out of sync",
+	Long: `Compare the output directory with its manifest and list orphans, files no
+entry refers to, and manifest entries whose files are missing. Where an
+orphan looks like an entry's missing transcript, such as one renamed by hand,
+it is shown as the likely match.
+
+Without a flag nothing is changed. Flags fix what was found, in this order:
+
+  --relink      point entries at their likely matches
+  --redownload  download the remaining missing transcripts again, with
+                --format and --process
+  --delete      move orphans to the trash, and forget entries whose
+                transcripts are still missing and references to missing
+                raw captions, summaries, or audio`,
+	Args: cobra.NoArgs,
+	RunE: runGC,
+}
+
+func init() {
+	gcCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	gcCmd.Flags().IntP("workers", "w", 1, "number of videos to download concurrently with --redownload")
+	gcCmd.Flags().Bool("relink", false, "point entries with missing transcripts at their likely matches")
+	gcCmd.Flags().Bool("redownload", false, "download missing transcripts again")
+	gcCmd.Flags().Bool("delete", false, "trash orphans and forget missing files")
+	addDownloadFlags(gcCmd)
+
+	rootCmd.AddCommand(gcCmd)
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	outputDir := viper.GetString("output")
+	relink, redownload, del := viper.GetBool("relink"), viper.GetBool("redownload"), viper.GetBool("delete")
+
+	report, err := archive.Check(outputDir)
+	if err != nil {
+		return err
+	}
+	if len(report.Orphans) == 0 && len(report.Dangling) == 0 {
+		fmt.Fprintln(stderr, "Everything is consistent.")
+		setResult(newGCResult(report))
+		return nil
+	}
+	if !relink && !redownload && !del {
+		printReport(report)
+		return nil
+	}
+
+	opts, err := downloadOptions()
+	if err != nil {
+		return err
+	}
+
+	// Work out every change first, so --dry-run can show them all.
+	p := &plan{action: "fix"}
+	claimed := map[string]bool{}
+	var updates []manifest.Entry
+	var removes, download []string
+	for _, d := range report.Dangling {
+		e, missing := d.Entry, d.Missing
+		if d.MissingFile() {
+			switch {
+			case relink && d.Candidate != "":
+				p.add(nil, "relink %s  %s -> %s", e.VideoID, e.File, d.Candidate)
+				missing = without(missing, e.File)
+				e.File = d.Candidate
+				claimed[d.Candidate] = true
+			case redownload:
+				p.add(youtube.DownloadTranscriptCalls, "redownload %s  %s", e.VideoID, youtube.TranscriptFilename(e.VideoID, e.Title, opts.Format))
+				download = append(download, e.VideoID)
+				continue
+			case del:
+				p.add(nil, "forget %s  (missing %s)", e.VideoID, e.File)
+				removes = append(removes, e.VideoID)
+				continue
+			default:
+				continue
+			}
+		}
+		if del {
+			for _, path := range missing {
+				p.add(nil, "forget %s  %s", e.VideoID, path)
+				switch path {
+				case e.Raw:
+					e.Raw = ""
+				case e.Summary:
+					e.Summary = ""
+				case e.Audio:
+					e.Audio = ""
+				}
+			}
+		}
+		if e != d.Entry {
+			updates = append(updates, e)
+		}
+	}
+	var trashed []string
+	if del {
+		for _, o := range report.Orphans {
+			if !claimed[o] {
+				p.add(nil, "trash %s", o)
+				trashed = append(trashed, o)
+			}
+		}
+	}
+
+	if len(p.items) == 0 {
+		printReport(report)
+		fmt.Fprintln(stderr, "Nothing to fix with the given flags.")
+		return nil
+	}
+	if dryRun() {
+		p.print()
+		return nil
+	}
+
+	mw, err := manifest.OpenWriter(outputDir, manifest.WriterOptions{})
+	if err != nil {
+		return err
+	}
+	for _, e := range updates {
+		e.UpdatedAt = time.Time{}
+		mw.Record(e)
+	}
+	for _, id := range removes {
+		mw.Remove(id)
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	for _, o := range trashed {
+		if _, err := trash.Move(outputDir, filepath.Join(outputDir, filepath.FromSlash(o))); err != nil {
+			warn(err)
+		}
+	}
+	fmt.Fprintf(stderr, "Updated %d entries, forgot %d, and trashed %d orphans\n", len(updates), len(removes), len(trashed))
+	res := newGCResult(report)
+	res.Fixed = p.items
+	setResult(res)
+
+	if len(download) == 0 {
+		return nil
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Downloading %d missing transcripts\n", len(download))
+	if jsonOutput() {
+		currentRun = &runRecorder{}
+	}
+	err = downloadTranscripts(client, download, outputDir, opts)
+	res.Downloaded = currentRun.results()
+	return err
+}
+
+// gcResult is what gc found, and fixed, under --json.
+type gcResult struct {
+	Orphans []string    `json:"orphans"`
+	Missing []gcMissing `json:"missing"`
+	// Fixed lists the changes made, as --dry-run would show them.
+	Fixed      []string         `json:"fixed,omitempty"`
+	Downloaded []manifest.Entry `json:"downloaded,omitempty"`
+}
+
+// gcMissing is a file a manifest entry refers to that doesn't exist.
+type gcMissing struct {
+	VideoID   string `json:"video_id"`
+	Path      string `json:"path"`
+	Candidate string `json:"candidate,omitempty"`
+}
+
+func newGCResult(report *archive.Report) *gcResult {
+	res := &gcResult{Orphans: append([]string{}, report.Orphans...), Missing: []gcMissing{}}
+	for _, d := range report.Dangling {
+		for _, p := range d.Missing {
+			m := gcMissing{VideoID: d.Entry.VideoID, Path: p}
+			if p == d.Entry.File {
+				m.Candidate = d.Candidate
+			}
+			res.Missing = append(res.Missing, m)
+		}
+	}
+	return res
+}
+
+// printReport lists what archive.Check found on stdout, or makes it the
+// result under --json.
+func printReport(report *archive.Report) {
+	if jsonOutput() {
+		setResult(newGCResult(report))
+		return
+	}
+	for _, o := range report.Orphans {
+		fmt.Printf("orphan   %s\n", o)
+	}
+	for _, d := range report.Dangling {
+		for _, p := range d.Missing {
+			if p == d.Entry.File && d.Candidate != "" {
+				fmt.Printf("missing  %s  %s  (likely %s)\n", d.Entry.VideoID, p, d.Candidate)
+			} else {
+				fmt.Printf("missing  %s  %s\n", d.Entry.VideoID, p)
+			}
+		}
+	}
+}
+
+// without returns list without the elements equal to s.
+func without(list []string, s string) []string {
+	var out []string
+	for _, l := range list {
+		if l != s {
+			out = append(out, l)
+		}
+	}
+	return out
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/n2p5/ytt/internal/gitcommit"
+	"github.com/spf13/viper"
+)
+
+// commitOutput commits the changes in outputDir under --git-commit, with a
+// message made of summary and today's date.
+func commitOutput(outputDir, summary string) {
+	if !viper.GetBool("git-commit") || dryRun() {
+		return
+	}
+	message := fmt.Sprintf("%s on %s", summary, time.Now().Format(time.DateOnly))
+	committed, err := gitcommit.Commit(outputDir, message)
+	if err != nil {
+		warn(err)
+		return
+	}
+	if committed {
+		fmt.Fprintf(stderr, "Committed %q\n", message)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <video_id> <phrase>",
+	Short: "Find a phrase in a video's transcript",
+	Long: `Print each occurrence of a phrase in a video's transcript with the text
+around it and a link to that moment on YouTube. Matching ignores case and
+line breaks, so phrases split across captions are found.
+
+The transcript is read from the output directory if it was downloaded
+there, and fetched from YouTube otherwise.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runGrep,
+}
+
+func init() {
+	grepCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	grepCmd.Flags().IntP("context", "C", 60, "characters of context to show on each side of a match")
+
+	rootCmd.AddCommand(grepCmd)
+}
+
+func runGrep(cmd *cobra.Command, args []string) error {
+	videoID, phrase := args[0], args[1]
+
+	cues, err := loadCues(videoID, viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	matches := transcript.Find(cues, phrase, viper.GetInt("context"))
+	if jsonOutput() {
+		rows := make([]grepMatch, len(matches))
+		for i, m := range matches {
+			rows[i] = grepMatch{
+				Timestamp: transcript.FormatTimestamp(m.Start),
+				Seconds:   m.Start.Seconds(),
+				URL:       transcript.WatchURL(videoID, m.Start),
+				Before:    m.Before,
+				Text:      m.Text,
+				After:     m.After,
+			}
+		}
+		setResult(rows)
+	} else {
+		for _, m := range matches {
+			fmt.Printf("%s  %s\n    %s[%s]%s\n", transcript.FormatTimestamp(m.Start), transcript.WatchURL(videoID, m.Start), m.Before, m.Text, m.After)
+		}
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("%q not found", phrase)
+	}
+	return nil
+}
+
+// grepMatch is a match under --json.
+type grepMatch struct {
+	Timestamp string  `json:"timestamp"`
+	Seconds   float64 `json:"seconds"`
+	URL       string  `json:"url"`
+	Before    string  `json:"before"`
+	Text      string  `json:"text"`
+	After     string  `json:"after"`
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/n2p5/ytt/internal/stats"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var growthCmd = &cobra.Command{
+	Use:   "growth <video_id|channel>",
+	Short: "Print recorded view, like, and comment counts over time as CSV",
+	Long: `Print the counts recorded by "ytt sync --record-stats" as CSV.
+
+For a video, each row is one sync. For a channel, given by ID, @handle, or
+URL, each row sums all of the channel's videos at one sync.`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeChannelIDs(cmd, args, toComplete)
+	},
+	RunE: runGrowth,
+}
+
+func init() {
+	rootCmd.AddCommand(growthCmd)
+}
+
+func runGrowth(cmd *cobra.Command, args []string) error {
+	id, err := resolveChannelOffline(args[0])
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(viper.GetString("data_dir"), "stats")
+
+	if stats.HasChannel(dir, id) {
+		samples, err := stats.LoadChannel(dir, id)
+		if err != nil {
+			return err
+		}
+		totals := stats.Totals(samples)
+		if jsonOutput() {
+			setResult(totals)
+			return nil
+		}
+		return stats.WriteTotalsCSV(os.Stdout, totals)
+	}
+
+	samples, err := stats.LoadVideo(dir, id)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("no stats recorded for %s; run ytt sync --record-stats first", id)
+	}
+	if jsonOutput() {
+		setResult(samples)
+		return nil
+	}
+	return stats.WriteVideoCSV(os.Stdout, samples)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/history"
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/revision"
+	"github.com/n2p5/ytt/internal/trash"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <video_id>",
+	Short: "Show metadata changes or transcript revisions recorded for a video",
+	Long: `Show the metadata history "ytt sync" has recorded for a video: the
+metadata as first seen, followed by each change.
+
+With --transcripts, list instead the revisions of the video's transcript
+kept in the output directory by --keep-history, oldest first. Restore one
+with --restore, giving its number in the list or its ID; the current
+transcript is moved to the trash in its place.`,
+	Example: `  ytt history abc123
+  ytt history abc123 --transcripts
+  ytt history abc123 --restore 2`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().Bool("full", false, "print full descriptions instead of only noting that they changed")
+	historyCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	historyCmd.Flags().Bool("transcripts", false, "list the transcript revisions kept with --keep-history")
+	historyCmd.Flags().String("restore", "", "restore the transcript revision with this number or ID")
+
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	videoID := args[0]
+	full, _ := cmd.Flags().GetBool("full")
+	if id := viper.GetString("restore"); id != "" {
+		return restoreRevision(viper.GetString("output"), videoID, id)
+	}
+	if viper.GetBool("transcripts") {
+		return listRevisions(viper.GetString("output"), videoID)
+	}
+
+	entries, err := history.Load(filepath.Join(viper.GetString("data_dir"), "history"), videoID)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no history recorded for %s; run ytt sync first", videoID)
+	}
+	if jsonOutput() {
+		setResult(entries)
+		return nil
+	}
+
+	for i, e := range entries {
+		when := e.Time.Local().Format(time.DateTime)
+		if i == 0 {
+			fmt.Printf("%s  first seen\n", when)
+			printMetadata(e, []string{history.FieldTitle, history.FieldDescription, history.FieldTags}, full)
+			continue
+		}
+		fmt.Printf("%s  changed: %s\n", when, strings.Join(e.Changed, ", "))
+		printMetadata(e, e.Changed, full)
+	}
+	return nil
+}
+
+func printMetadata(e history.Entry, fields []string, full bool) {
+	for _, field := range fields {
+		switch field {
+		case history.FieldTitle:
+			fmt.Printf("    title: %s\n", e.Title)
+		case history.FieldDescription:
+			if full {
+				fmt.Printf("    description:\n%s\n", indent(e.Description, "      "))
+			} else {
+				fmt.Printf("    description: %d characters\n", len(e.Description))
+			}
+		case history.FieldTags:
+			fmt.Printf("    tags: %s\n", strings.Join(e.Tags, ", "))
+		}
+	}
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+// saveRevision keeps a copy of a newly saved transcript for --keep-history.
+func saveRevision(outputDir string, e manifest.Entry) error {
+	if !viper.GetBool("keep-history") {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(e.File)))
+	if err != nil {
+		return fmt.Errorf("error reading transcript: %w", err)
+	}
+	rev := revision.Revision{
+		VideoID:          e.VideoID,
+		File:             e.File,
+		Format:           e.Format,
+		Process:          e.Process,
+		Hash:             manifest.Hash(data),
+		CaptionUpdatedAt: e.CaptionUpdatedAt,
+	}
+	_, _, err = revision.Save(outputDir, rev, data, time.Now())
+	return err
+}
+
+func listRevisions(outputDir, videoID string) error {
+	revs, err := revision.List(outputDir, videoID)
+	if err != nil {
+		return err
+	}
+	if len(revs) == 0 {
+		return fmt.Errorf("no transcript revisions of %s in %s; download it with --keep-history first", videoID, outputDir)
+	}
+	if jsonOutput() {
+		setResult(revs)
+		return nil
+	}
+
+	var current string
+	if e, ok, err := loadEntry(outputDir, videoID); err == nil && ok {
+		current = e.Hash
+	}
+	for i, rev := range revs {
+		note := ""
+		if rev.Hash == current {
+			note = "  (current)"
+		}
+		fmt.Printf("%3d  %s  %s  %d bytes  %s%s\n", i+1, rev.ID, rev.SavedAt.Local().Format(time.DateTime), rev.Size, rev.File, note)
+	}
+	return nil
+}
+
+// restoreRevision puts a revision of a video's transcript back in the
+// output directory and records it in the manifest as ytt's own output, so
+// later syncs treat it as unedited.
+func restoreRevision(outputDir, videoID, id string) error {
+	rev, err := revision.Find(outputDir, videoID, id)
+	if err != nil {
+		return err
+	}
+	e, ok, err := loadEntry(outputDir, videoID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		e = manifest.Entry{VideoID: videoID, Status: manifest.StatusOK}
+	}
+	if dryRun() {
+		p := &plan{action: "restore"}
+		p.add(nil, "%s  revision %s saved %s", rev.File, rev.ID, rev.SavedAt.Local().Format(time.DateTime))
+		p.print()
+		return nil
+	}
+
+	data, err := revision.Read(outputDir, rev)
+	if err != nil {
+		return err
+	}
+	for _, file := range []string{e.File, rev.File} {
+		if file == "" {
+			continue
+		}
+		if _, err := trash.Move(outputDir, filepath.Join(outputDir, filepath.FromSlash(file))); err != nil {
+			return err
+		}
+	}
+	dest := filepath.Join(outputDir, filepath.FromSlash(rev.File))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+	if err := os.WriteFile(dest, data, 0644); err != nil {
+		return fmt.Errorf("error restoring %s: %w", rev.File, err)
+	}
+
+	e.File, e.Format, e.Process, e.Hash = rev.File, rev.Format, rev.Process, rev.Hash
+	e.Status, e.Error, e.UpdatedAt = manifest.StatusOK, "", time.Time{}
+	mw, err := manifest.OpenWriter(outputDir, manifest.WriterOptions{})
+	if err != nil {
+		return err
+	}
+	mw.Record(e)
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Restored %s from revision %s saved %s\n", rev.File, rev.ID, rev.SavedAt.Local().Format(time.DateTime))
+	setResult(rev)
+	return nil
+}
+
+// loadEntry returns a video's manifest entry in outputDir.
+func loadEntry(outputDir, videoID string) (manifest.Entry, bool, error) {
+	m, err := manifest.Load(outputDir)
+	if err != nil {
+		return manifest.Entry{}, false, err
+	}
+	e, ok := m.Entries[videoID]
+	return e, ok, nil
+}
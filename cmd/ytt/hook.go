@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/n2p5/ytt/internal/objstore"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/viper"
+)
+
+// runPostHook runs --post-hook for a saved transcript, describing it in
+// YTT_* environment variables.
+func runPostHook(res *youtube.DownloadResult) error {
+	command := viper.GetString("post-hook")
+	if command == "" {
+		return nil
+	}
+
+	// Transcripts uploaded to object storage are given by URL.
+	file := res.Path
+	if !objstore.IsRemote(file) {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+	}
+	cmd := shellCommand(command)
+	cmd.Env = append(os.Environ(),
+		"YTT_VIDEO_ID="+res.VideoID,
+		"YTT_TITLE="+res.Title,
+		"YTT_FILE="+file,
+		"YTT_LANG="+res.Language,
+		"YTT_CHANNEL="+res.ChannelID,
+	)
+	cmd.Stdout = stderr
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running post-hook for %s: %w", res.VideoID, err)
+	}
+	return nil
+}
+
+// shellCommand runs command through the system shell, so it can carry its
+// own arguments, pipes, and quoting.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/trash"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Add a transcript from elsewhere to the output directory",
+	Long: `Add a transcript made outside YouTube, such as by Whisper or a human
+transcriber, to the output directory as the transcript of the video given
+with --video, so it is searched, served, and exported with the rest. The
+video's title, channel, and publish date are looked up on YouTube, and the
+file is converted and processed with the download flags like a downloaded
+transcript. It keeps its own format unless --format is given.
+
+The file's format is detected from its content unless --from is given. An
+existing transcript of the video is moved to the trash. Imported
+transcripts are not replaced by YouTube's captions when "ytt sync --update"
+runs.`,
+	Example: `  ytt import talk.srt --video abc123
+  ytt import whisper.vtt --video abc123 --format txt --process dedupe,paragraphs`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	importCmd.Flags().StringP("output", "o", "outputs", "directory to add the transcript to")
+	importCmd.Flags().String("video", "", "ID of the video the transcript belongs to")
+	importCmd.Flags().String("from", "", "format of the file (default: detected from its content)")
+	importCmd.Flags().Bool("git-commit", false, "commit the imported transcript when the output directory is in a Git repository")
+	importCmd.MarkFlagRequired("video")
+	importCmd.RegisterFlagCompletionFunc("from", completeFormats)
+	addDownloadFlags(importCmd)
+
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	file, videoID := args[0], viper.GetString("video")
+	opts, err := downloadOptions()
+	if err != nil {
+		return err
+	}
+	from := transcript.Format(viper.GetString("from"))
+	if from != "" && !knownFormat(from) {
+		return fmt.Errorf("unsupported format %q (want %s)", from, formatList)
+	}
+	outputDir := viper.GetString("output")
+	if !localOutput(outputDir) {
+		return fmt.Errorf("ytt import needs a local output directory")
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", file, err)
+	}
+	detected := transcript.DetectFormat(data)
+	cues, err := transcript.ParseFormat(data, cmp.Or(from, detected))
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %w", file, err)
+	}
+	if len(cues) == 0 {
+		return fmt.Errorf("%s holds no captions", file)
+	}
+	// The transcript keeps the file's format unless asked otherwise.
+	if opts.Format == "" {
+		opts.Format = cmp.Or(from, detected)
+	}
+
+	if dryRun() {
+		p := &plan{action: "import"}
+		p.add([]youtube.Method{youtube.MethodVideosList}, "%s  %s  (%s, %d cues)", videoID, file, cmp.Or(from, detected), len(cues))
+		p.print()
+		return nil
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	details, err := client.GetVideoDetails(videoID)
+	if err != nil {
+		return err
+	}
+	src := transcript.Source{VideoID: videoID, Title: details.Title, ChannelID: details.ChannelID, Language: opts.Lang}
+	if published, err := time.Parse(time.RFC3339, details.PublishedAt); err == nil {
+		src.PublishedAt = published
+	}
+	// Captions given with --from are saved in that format, so they are
+	// read back the same way whatever their content looks like.
+	if from != "" && from != detected {
+		if data, err = from.FormatSource(src, cues); err != nil {
+			return fmt.Errorf("error reading %s as %s: %w", file, from, err)
+		}
+	}
+	imported, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+
+	m, err := manifest.Load(outputDir)
+	if err != nil {
+		return err
+	}
+	prev, hadPrev := m.Entries[videoID]
+
+	beginRun("import "+file, outputDir)
+	defer endRun()
+	fmt.Fprintf(stderr, "Importing %s as the transcript of %s\n", file, src.Title)
+	err = saveTranscripts([]string{videoID}, outputDir, opts, "import", "imported", func(videoID string) (*youtube.DownloadResult, error) {
+		// Don't leave a transcript under another name behind unreferenced.
+		// It is moved aside first: on a case-insensitive file system, a
+		// name differing only in case is the file about to be written.
+		var prevPath, trashed string
+		if hadPrev && prev.File != "" && prev.File != youtube.TranscriptFilename(videoID, src.Title, opts.Format) {
+			prevPath = filepath.Join(outputDir, filepath.FromSlash(prev.File))
+			var err error
+			if trashed, err = trash.Move(outputDir, prevPath); err != nil {
+				return nil, err
+			}
+		}
+		res, err := youtube.SaveCaptions(outputDir, src, data, opts)
+		if err != nil {
+			if trashed != "" {
+				if rerr := os.Rename(trashed, prevPath); rerr != nil {
+					warn(fmt.Errorf("error restoring %s from the trash: %w", prev.File, rerr))
+				}
+			}
+			return nil, err
+		}
+		res.Imported = imported
+		return res, nil
+	})
+	commitOutput(outputDir, fmt.Sprintf("import: %s from %s", videoID, filepath.Base(file)))
+	return err
+}
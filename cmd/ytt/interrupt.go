@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// interrupted is set once SIGINT or SIGTERM has stopped a batch.
+var interrupted atomic.Bool
+
+// interruptError reports that a batch stopped on SIGINT or SIGTERM, with
+// its remaining videos, if any, saved for resume.
+type interruptError struct {
+	remaining int
+}
+
+func (e *interruptError) Error() string {
+	if e.remaining == 0 {
+		return "interrupted"
+	}
+	return fmt.Sprintf("interrupted with %d video(s) left; run \"ytt resume\" to finish them", e.remaining)
+}
+
+// onInterrupt calls stop on the first SIGINT or SIGTERM, so a batch can
+// stop starting videos, let the ones in progress finish, and save what's
+// left. A second signal exits at once. The returned function stops
+// listening.
+func onInterrupt(stop func()) func() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-c:
+		case <-done:
+			return
+		}
+		interrupted.Store(true)
+		fmt.Fprintln(stderr, "Interrupted; finishing the videos in progress (interrupt again to quit now)")
+		stop()
+		select {
+		case <-c:
+			os.Exit(exitInterrupted)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
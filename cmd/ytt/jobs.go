@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/n2p5/ytt/internal/jobs"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "List, retry, and cancel queued video downloads",
+	Long: `Each video that transcript, sync, refresh, resume, or gc --redownload
+saves is a job in a queue kept in jobs.json in the data directory, with its
+priority, status, attempts, and the settings of the run that queued it.
+A batch runs its videos highest priority first; set its priority with
+--priority.
+
+A video that fails is left pending for another attempt, after a delay that
+doubles each time, until it has failed job_max_attempts times (default 3);
+then it is failed. "ytt jobs retry" runs the attempts that are due, and
+requeues failed and canceled jobs, highest priority first. A job left
+running by a ytt process that crashed or was killed counts as a failed
+attempt once the process has exited, or after an hour if it ran on another
+machine.
+
+Finished and canceled jobs are dropped after 30 days.`,
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the jobs in the queue",
+	Args:  cobra.NoArgs,
+	RunE:  runJobsList,
+}
+
+var jobsRetryCmd = &cobra.Command{
+	Use:   "retry [job_id]...",
+	Short: "Run failed jobs again",
+	Long: `Requeue the given failed or canceled jobs, or every failed job, and run
+them along with the pending jobs whose retry delay has passed, highest
+priority first. Each job runs with the settings of the run that queued it.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runJobsRetry,
+}
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel [job_id]...",
+	Short: "Stop jobs from running",
+	Long: `Cancel the given pending or failed jobs, or with --all every one, so they
+aren't retried. A job that is running finishes its attempt, but isn't
+retried. Canceled jobs can be run again with "ytt jobs retry".`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runJobsCancel,
+}
+
+func init() {
+	jobsListCmd.Flags().StringSlice("status", nil, "only list jobs with this status: pending, running, done, failed, or canceled (repeatable)")
+	jobsListCmd.RegisterFlagCompletionFunc("status", cobra.FixedCompletions([]string{"pending", "running", "done", "failed", "canceled"}, cobra.ShellCompDirectiveNoFileComp))
+	jobsCancelCmd.Flags().Bool("all", false, "cancel every pending and failed job")
+
+	jobsCmd.AddCommand(jobsListCmd, jobsRetryCmd, jobsCancelCmd)
+	rootCmd.AddCommand(jobsCmd)
+}
+
+func runJobsList(cmd *cobra.Command, args []string) error {
+	statuses, _ := cmd.Flags().GetStringSlice("status")
+	for _, s := range statuses {
+		if !slices.Contains(jobs.Statuses, jobs.Status(s)) {
+			return fmt.Errorf("unknown job status %q (want pending, running, done, failed, or canceled)", s)
+		}
+	}
+	q, err := loadJobs(viper.GetString("data_dir"))
+	if err != nil {
+		return err
+	}
+	list := []*jobs.Job{}
+	for _, j := range q.Jobs {
+		if len(statuses) == 0 || slices.Contains(statuses, string(j.Status)) {
+			list = append(list, j)
+		}
+	}
+	if jsonOutput() {
+		setResult(list)
+		return nil
+	}
+	if len(list) == 0 {
+		fmt.Fprintln(stderr, "No jobs.")
+		return nil
+	}
+	fmt.Printf("%-6s %-9s %8s %8s %-10s %-12s %s\n", "ID", "STATUS", "PRIORITY", "ATTEMPTS", "KIND", "VIDEO", "UPDATED")
+	for _, j := range list {
+		fmt.Printf("%-6d %-9s %8d %8s %-10s %-12s %s\n", j.ID, j.Status, j.Priority, fmt.Sprintf("%d/%d", j.Attempts, j.MaxAttempts), j.Kind, j.VideoID, j.UpdatedAt.Local().Format(time.DateTime))
+		if j.Status == jobs.StatusPending && !j.RetryAt.IsZero() {
+			fmt.Printf("       retry after %s\n", j.RetryAt.Local().Format(time.DateTime))
+		}
+		if j.Error != "" && j.Status != jobs.StatusDone {
+			fmt.Printf("       %s\n", j.Error)
+		}
+	}
+	return nil
+}
+
+func runJobsRetry(cmd *cobra.Command, args []string) error {
+	ids, err := jobIDs(args)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var due []jobs.Job
+	batches := map[int]jobs.Batch{}
+	selectDue := func(q *jobs.Queue) error {
+		requeued := map[int]bool{}
+		for _, id := range ids {
+			j := q.Get(id)
+			if j == nil {
+				return fmt.Errorf("no job %d", id)
+			}
+			if j.Status == jobs.StatusPending {
+				requeued[id] = true
+				continue
+			}
+			if err := j.Retry(now); err != nil {
+				return err
+			}
+			requeued[id] = true
+		}
+		if len(ids) == 0 {
+			for _, j := range q.Jobs {
+				if j.Status == jobs.StatusFailed {
+					j.Retry(now)
+					requeued[j.ID] = true
+				}
+			}
+		}
+		// Pending jobs that haven't been tried yet belong to a run still
+		// going, or to "ytt resume"; only those waiting out a retry delay
+		// are taken.
+		for _, j := range q.Due(now) {
+			if requeued[j.ID] || (len(ids) == 0 && j.Attempts > 0) {
+				due = append(due, *j)
+				if b := q.Batch(j.Batch); b != nil {
+					batches[b.ID] = *b
+				}
+			}
+		}
+		return nil
+	}
+
+	dataDir := viper.GetString("data_dir")
+	if dryRun() {
+		q, err := loadJobs(dataDir)
+		if err != nil {
+			return err
+		}
+		if err := selectDue(q); err != nil {
+			return err
+		}
+		p := &plan{action: "retry"}
+		for _, j := range due {
+			p.add(youtube.DownloadTranscriptCalls, "job %d  %s  %s  (priority %d)", j.ID, j.Kind, j.VideoID, j.Priority)
+		}
+		p.print()
+		return nil
+	}
+	if err := jobs.Update(dataDir, nil, selectDue); err != nil {
+		return err
+	}
+	if len(due) == 0 {
+		fmt.Fprintln(stderr, "No jobs to retry.")
+		return nil
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, group := range groupJobs(due) {
+		if interrupted.Load() {
+			break
+		}
+		if err := retryJobs(client, batches[group[0].Batch], group); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// groupJobs splits jobs, in the order given, into groups by the batch
+// that queued them, whose settings they run with.
+func groupJobs(list []jobs.Job) [][]jobs.Job {
+	var order []int
+	groups := map[int][]jobs.Job{}
+	for _, j := range list {
+		if _, ok := groups[j.Batch]; !ok {
+			order = append(order, j.Batch)
+		}
+		groups[j.Batch] = append(groups[j.Batch], j)
+	}
+	out := make([][]jobs.Job, len(order))
+	for i, b := range order {
+		out[i] = groups[b]
+	}
+	return out
+}
+
+// retryJobs runs a group of jobs from batch b with b's settings. Settings
+// b's run didn't have are cleared, so none carry over from the group
+// before.
+func retryJobs(client *youtube.Client, b jobs.Batch, group []jobs.Job) error {
+	for _, key := range resumeSettings {
+		viper.Set(key, nil)
+	}
+	restoreSettings(b.Settings)
+	location := outputLocation()
+	if !localOutput(location) {
+		return fmt.Errorf("error retrying %d %s job(s): %s is not a local directory", len(group), group[0].Kind, location)
+	}
+	opts, err := downloadOptions()
+	if err != nil {
+		return err
+	}
+	save, err := savedRunSave(client, location, opts)
+	if err != nil {
+		return err
+	}
+	videoIDs := make([]string, len(group))
+	for i, j := range group {
+		videoIDs[i] = j.VideoID
+	}
+	fmt.Fprintf(stderr, "Retrying %d %s job(s) into %s\n", len(group), group[0].Kind, location)
+	err = saveTranscripts(videoIDs, location, opts, group[0].Kind, "downloaded", save)
+	commitOutput(location, fmt.Sprintf("jobs retry: %d videos from %s", len(videoIDs), group[0].Kind))
+	return err
+}
+
+func runJobsCancel(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	if len(args) == 0 && !all {
+		return fmt.Errorf("give job IDs or --all")
+	}
+	ids, err := jobIDs(args)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var canceled []int
+	cancel := func(q *jobs.Queue) error {
+		for _, id := range ids {
+			j := q.Get(id)
+			if j == nil {
+				return fmt.Errorf("no job %d", id)
+			}
+			if err := j.Cancel(now); err != nil {
+				return err
+			}
+			canceled = append(canceled, id)
+		}
+		if all {
+			for _, j := range q.Jobs {
+				if j.Status == jobs.StatusPending || j.Status == jobs.StatusFailed {
+					j.Cancel(now)
+					canceled = append(canceled, j.ID)
+				}
+			}
+		}
+		return nil
+	}
+
+	dataDir := viper.GetString("data_dir")
+	if dryRun() {
+		q, err := loadJobs(dataDir)
+		if err != nil {
+			return err
+		}
+		if err := cancel(q); err != nil {
+			return err
+		}
+		p := &plan{action: "cancel"}
+		for _, id := range canceled {
+			j := q.Get(id)
+			p.add(nil, "job %d  %s  %s", j.ID, j.Kind, j.VideoID)
+		}
+		p.print()
+		return nil
+	}
+	if err := jobs.Update(dataDir, nil, cancel); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Canceled %d job(s)\n", len(canceled))
+	setResult(map[string]any{"canceled": canceled})
+	return nil
+}
+
+// loadJobs loads the job queue in dataDir to show or plan changes to,
+// with the jobs left running by processes that have exited recovered as
+// Update would.
+func loadJobs(dataDir string) (*jobs.Queue, error) {
+	q, err := jobs.Load(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	q.Recover(time.Now())
+	return q, nil
+}
+
+// jobIDs parses job IDs given as arguments.
+func jobIDs(args []string) ([]int, error) {
+	ids := make([]int, len(args))
+	for i, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("invalid job ID %q", arg)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// jobTracker records the videos of a batch in the job queue as they are
+// saved. A nil tracker records nothing.
+type jobTracker struct {
+	w *jobs.Writer
+}
+
+// trackJobs queues a job for each of videoIDs under kind, saved into
+// location at the --priority of the run, and returns a tracker for
+// recording them. Failures to update the queue are warned about rather than
+// stopping the batch.
+func trackJobs(kind, location string, videoIDs []string) *jobTracker {
+	// An imported file is read from disk, so there is nothing to retry.
+	if kind == "import" || dryRun() {
+		return nil
+	}
+	b := &jobs.Batch{
+		Kind:        kind,
+		MaxAttempts: viper.GetInt("job_max_attempts"),
+		Backoff:     viper.GetDuration("job_retry_backoff"),
+		Settings:    runSettings(location),
+		CreatedAt:   time.Now(),
+	}
+	w, err := jobs.OpenWriter(viper.GetString("data_dir"), b, videoIDs, viper.GetInt("priority"), jobs.WriterOptions{})
+	if err != nil {
+		warn(err)
+		return nil
+	}
+	return &jobTracker{w: w}
+}
+
+// order returns videoIDs in the order the queue runs them: highest priority
+// first, then oldest.
+func (t *jobTracker) order(videoIDs []string) []string {
+	if t == nil {
+		return videoIDs
+	}
+	return t.w.Order()
+}
+
+// start marks videoID's job as running. It reports false if the job was
+// canceled, in which case the video should be skipped.
+func (t *jobTracker) start(videoID string) bool {
+	return t == nil || t.w.Start(videoID)
+}
+
+// finish records the outcome of videoID's job. A video stopped by the quota
+// or an interrupt is left pending without counting the attempt.
+func (t *jobTracker) finish(videoID string, err error) {
+	switch {
+	case t == nil:
+	case youtube.IsQuotaExceeded(err) || errors.Is(err, context.Canceled):
+		t.w.Release(videoID)
+	default:
+		t.w.Finish(videoID, err)
+	}
+}
+
+// close writes the last of the batch's progress to the queue.
+func (t *jobTracker) close() {
+	if t == nil {
+		return
+	}
+	if err := t.w.Close(); err != nil {
+		warn(err)
+	}
+}
+
+// jobCounts returns the number of jobs in the queue by status, for
+// /metrics.
+func jobCounts(dataDir string) (map[string]int, error) {
+	q, err := jobs.Load(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	for _, s := range jobs.Statuses {
+		counts[string(s)] = 0
+	}
+	for _, j := range q.Jobs {
+		counts[string(j.Status)]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/spf13/viper"
+)
+
+// jsonResult is the running command's result, written to stdout under
+// --json once the command finishes.
+var jsonResult any
+
+// commandRan is set once a command starts running, so --json output isn't
+// written after --help or --version.
+var commandRan bool
+
+// jsonOutput reports whether --json asked for machine-readable output. Under
+// --json, commands set their result with setResult instead of printing
+// text, and status output stays on stderr.
+func jsonOutput() bool {
+	return viper.GetBool("json")
+}
+
+// setResult records v as the command's result for --json.
+func setResult(v any) {
+	jsonResult = v
+}
+
+// jsonOutcome is the single JSON document written to stdout under --json.
+// A failed command still includes whatever result it got to, such as the
+// videos of a batch that succeeded.
+type jsonOutcome struct {
+	OK     bool       `json:"ok"`
+	Result any        `json:"result,omitempty"`
+	Error  *jsonError `json:"error,omitempty"`
+}
+
+type jsonError struct {
+	Message string `json:"message"`
+	// Code is the exit code ytt exits with; see exitcode.go.
+	Code int `json:"code"`
+	// Reason classifies the error, as errorReason does, unless it is a
+	// batch of errors or unclassified.
+	Reason string `json:"reason,omitempty"`
+}
+
+// writeJSON writes the command's outcome, with err if it failed.
+func writeJSON(w io.Writer, err error) error {
+	out := jsonOutcome{OK: err == nil, Result: jsonResult}
+	if err != nil {
+		out.Error = &jsonError{Message: err.Error(), Code: exitCode(err)}
+		var be *batchError
+		if reason := errorReason(err); reason != "other" && !errors.As(err, &be) {
+			out.Error.Reason = reason
+		}
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
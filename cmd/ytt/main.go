@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	err := rootCmd.Execute()
+	stopTelemetry(err)
+	recordMetrics()
+	sendNotification(err)
+	if jsonOutput() && (commandRan || err != nil) {
+		if werr := writeJSON(os.Stdout, err); werr != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON output: %v\n", werr)
+		}
+	}
+	if err != nil {
+		os.Exit(exitCode(err))
+	}
+}
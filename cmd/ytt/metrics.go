@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/metrics"
+	"github.com/n2p5/ytt/internal/resume"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// The counts a run adds to the totals "ytt serve" exposes at /metrics.
+// apiUsage tallies the quota every client of the run uses, and
+// metricsCommand names the command, such as "sync".
+var (
+	runCountsMu    sync.Mutex
+	runCounts      = map[string]float64{}
+	apiUsage       = &youtube.CostEstimator{}
+	metricsCommand string
+)
+
+// metricsHelp describes the metrics served at /metrics.
+var metricsHelp = map[string]string{
+	"ytt_transcripts_downloaded_total": "Transcripts downloaded, by job.",
+	"ytt_api_errors_total":             "Videos that failed, by reason.",
+	"ytt_quota_units_used_total":       "YouTube Data API quota units used.",
+	"ytt_last_run_timestamp_seconds":   "When a run of each command last finished.",
+	"ytt_last_sync_timestamp_seconds":  "When ytt sync last finished.",
+	"ytt_queue_length":                 "Videos waiting for ytt resume.",
+	"ytt_jobs":                         "Jobs in the download queue, by status.",
+	"ytt_transcripts":                  "Videos in the served archive, by status.",
+}
+
+// startMetrics notes which command is running.
+func startMetrics(cmd *cobra.Command) {
+	metricsCommand = strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// countMetric adds n to the series for this run.
+func countMetric(series string, n float64) {
+	runCountsMu.Lock()
+	defer runCountsMu.Unlock()
+	runCounts[series] += n
+}
+
+// errorReason classifies a video's error for ytt_api_errors_total and
+// --json output.
+func errorReason(err error) string {
+	switch {
+	case youtube.IsQuotaExceeded(err):
+		return "quota_exceeded"
+	case errors.Is(err, youtube.ErrAuthorizationRequired):
+		return "authorization_required"
+	case youtube.IsAuthError(err):
+		return "auth"
+	case errors.Is(err, youtube.ErrNoCaptions):
+		return "no_captions"
+	case youtube.IsNotFound(err):
+		return "not_found"
+	case errors.Is(err, youtube.ErrTooLarge):
+		return "too_large"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return "other"
+}
+
+// recordMetrics adds the run's counts to the totals in the data directory.
+// Runs that made no API calls and counted nothing leave them alone.
+func recordMetrics() {
+	if !commandRan || dryRun() {
+		return
+	}
+	if quota := apiUsage.Total(); quota > 0 {
+		countMetric("ytt_quota_units_used_total", float64(quota))
+		if err := youtube.LogQuota(quotaLogPath(), quota, time.Now()); err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+	}
+	runCountsMu.Lock()
+	defer runCountsMu.Unlock()
+	if len(runCounts) == 0 {
+		return
+	}
+	if err := metrics.Add(viper.GetString("data_dir"), metricsCommand, runCounts, time.Now()); err != nil {
+		fmt.Fprintf(stderr, "Warning: %v\n", err)
+	}
+}
+
+// quotaLogPath returns the file the quota used today is logged in.
+func quotaLogPath() string {
+	return filepath.Join(viper.GetString("data_dir"), "quota.json")
+}
+
+// collectMetrics returns the totals of past runs along with gauges of the
+// resume queue, the job queue, and the archive in outputDir.
+func collectMetrics(outputDir string) func() ([]metrics.Sample, error) {
+	return func() ([]metrics.Sample, error) {
+		dataDir := viper.GetString("data_dir")
+		totals, err := metrics.Load(dataDir)
+		if err != nil {
+			return nil, err
+		}
+		samples := totals.Samples()
+		if at, ok := totals.LastRun["sync"]; ok {
+			samples = append(samples, metrics.Sample{Series: "ytt_last_sync_timestamp_seconds", Value: float64(at.Unix())})
+		}
+
+		queue := 0
+		s, err := resume.Load(dataDir)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			queue = len(s.VideoIDs)
+		}
+		samples = append(samples, metrics.Sample{Series: "ytt_queue_length", Value: float64(queue)})
+
+		counts, err := jobCounts(dataDir)
+		if err != nil {
+			return nil, err
+		}
+		for st, n := range counts {
+			samples = append(samples, metrics.Sample{Series: metrics.Series("ytt_jobs", "status", st), Value: float64(n)})
+		}
+
+		m, err := manifest.Load(outputDir)
+		if err != nil {
+			return nil, err
+		}
+		status := map[string]int{manifest.StatusOK: 0, manifest.StatusFailed: 0}
+		for _, e := range m.Entries {
+			status[e.Status]++
+		}
+		for st, n := range status {
+			samples = append(samples, metrics.Sample{Series: metrics.Series("ytt_transcripts", "status", st), Value: float64(n)})
+		}
+		return samples, nil
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/n2p5/ytt/internal/notify"
+	"github.com/spf13/viper"
+)
+
+// defaultNotifyTemplate is the text of a run notification unless
+// --notify-template replaces it.
+const defaultNotifyTemplate = `{{if .Total}}{{.Succeeded}} of {{.Total}} transcripts {{.Verb}}` +
+	`{{if .Failed}}, {{.Failed}} failed{{end}}{{if .Remaining}}, {{.Remaining}} left for ytt resume{{end}} in {{.Duration}}{{end}}` +
+	`{{if .Error}}{{if .Total}}: {{end}}{{.Error}}{{end}}`
+
+// runSummary is what a run notification reports, and the data its template
+// is executed with.
+type runSummary struct {
+	// Command is the command that ran, such as "sync".
+	Command string
+	// Job names the batch, and Verb describes a success in it. Total is
+	// zero when no batch ran.
+	Job       string
+	Verb      string
+	Total     int
+	Succeeded int
+	Failed    int
+	// Skipped are the videos whose jobs were canceled.
+	Skipped int
+	// Remaining are the videos saved for ytt resume.
+	Remaining int
+	// Failures lists each failed video as "id: error".
+	Failures []string
+	Duration time.Duration
+	// Error is the error the command failed with, or empty.
+	Error string
+}
+
+// The start of the running command, and the summary of its batch once
+// reportBatch has run.
+var (
+	runStarted   time.Time
+	batchMu      sync.Mutex
+	batchSummary *runSummary
+)
+
+// summarizeBatch adds a batch's counts to the run notification. A command
+// can run several batches, as sync does for each channel of a group; the
+// notification describes them all, named for the first.
+func summarizeBatch(s runSummary) {
+	batchMu.Lock()
+	defer batchMu.Unlock()
+	if batchSummary == nil {
+		batchSummary = &s
+		return
+	}
+	b := batchSummary
+	b.Total += s.Total
+	b.Succeeded += s.Succeeded
+	b.Failed += s.Failed
+	b.Skipped += s.Skipped
+	b.Remaining += s.Remaining
+	b.Failures = append(b.Failures, s.Failures...)
+}
+
+// runNotifier returns the notifiers given by --notify-url, notify.webhook,
+// which alerts are also sent to, and --notify-desktop, or nil if there are
+// none.
+func runNotifier() notify.Notifier {
+	var n notify.Multi
+	urls := viper.GetStringSlice("notify-url")
+	if url := viper.GetString("notify.webhook"); url != "" && !slices.Contains(urls, url) {
+		urls = append(urls, url)
+	}
+	for _, url := range urls {
+		n = append(n, notify.ForURL(url))
+	}
+	if viper.GetBool("notify-desktop") {
+		n = append(n, notify.Desktop{})
+	}
+	if len(n) == 0 {
+		return nil
+	}
+	return n
+}
+
+// sendNotification notifies that the command finished, with err if it
+// failed, when notifications are set up and a batch ran or the command
+// failed.
+func sendNotification(err error) {
+	n := runNotifier()
+	if n == nil || !commandRan || dryRun() {
+		return
+	}
+	batchMu.Lock()
+	s := batchSummary
+	batchMu.Unlock()
+	if s == nil {
+		if err == nil {
+			return
+		}
+		s = &runSummary{}
+	}
+	s.Command = metricsCommand
+	s.Duration = time.Since(runStarted).Round(time.Second)
+	msg := notify.Message{Title: "ytt " + s.Command + " finished"}
+	if err != nil {
+		s.Error = err.Error()
+		msg.Title = "ytt " + s.Command + " failed"
+	}
+
+	text, terr := notificationText(viper.GetString("notify-template"), s)
+	if terr != nil {
+		fmt.Fprintf(stderr, "Warning: %v\n", terr)
+		text, _ = notificationText("", s)
+	}
+	msg.Text = text
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if nerr := n.Notify(ctx, msg); nerr != nil {
+		fmt.Fprintf(stderr, "Warning: %v\n", nerr)
+	}
+}
+
+// notificationText executes tmpl, or the default template if it is empty,
+// with s.
+func notificationText(tmpl string, s *runSummary) (string, error) {
+	if tmpl == "" {
+		tmpl = defaultNotifyTemplate
+	}
+	t, err := template.New("notify").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid --notify-template: %w", err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, s); err != nil {
+		return "", fmt.Errorf("invalid --notify-template: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/n2p5/ytt/internal/bundle"
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/objstore"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/viper"
+)
+
+// archiveIndex is the name of the manifest inside an --archive file.
+const archiveIndex = "index.json"
+
+// outputLocation returns where transcripts are saved: the --archive file if
+// one is given, otherwise the --output directory or object storage URL.
+func outputLocation() string {
+	if archive := viper.GetString("archive"); archive != "" {
+		return archive
+	}
+	return viper.GetString("output")
+}
+
+// isArchive reports whether an output location is an --archive file.
+func isArchive(location string) bool {
+	return location == viper.GetString("archive") && location != ""
+}
+
+// localOutput reports whether an output location is a local directory.
+func localOutput(location string) bool {
+	return !objstore.IsRemote(location) && !isArchive(location)
+}
+
+// outputPath returns where a file named name goes in an output location.
+func outputPath(location, name string) string {
+	switch {
+	case isArchive(location):
+		return location + ":" + name
+	case objstore.IsRemote(location):
+		return strings.TrimSuffix(location, "/") + "/" + name
+	}
+	return filepath.Join(location, name)
+}
+
+// openStore opens an output location that isn't a local directory, and the
+// manifest kept in it, rejecting flags that only work with a local
+// directory. finish must be called when done, with commit false to discard
+// what was written where that is possible.
+func openStore(ctx context.Context, location string) (store objstore.Store, index string, m *manifest.Manifest, finish func(commit bool) error, err error) {
+	switch {
+	case viper.GetBool("keep-raw"):
+		err = fmt.Errorf("--keep-raw needs a local output directory")
+	case viper.GetString("summarize-cmd") != "":
+		err = fmt.Errorf("--summarize-cmd needs a local output directory")
+	case viper.GetBool("keep-history"):
+		err = fmt.Errorf("--keep-history needs a local output directory")
+	case viper.GetBool("with-audio"):
+		err = fmt.Errorf("--with-audio needs a local output directory")
+	case viper.GetBool("update"):
+		err = fmt.Errorf("--update needs a local output directory")
+	case viper.GetBool("git-commit"):
+		err = fmt.Errorf("--git-commit needs a local output directory")
+	case isArchive(location) && viper.GetString("post-hook") != "":
+		err = fmt.Errorf("--post-hook can't be used with --archive")
+	}
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+
+	if isArchive(location) {
+		w, err := bundle.Create(location, archiveIndex)
+		if err != nil {
+			return nil, "", nil, nil, err
+		}
+		store, index = w, archiveIndex
+		finish = func(commit bool) error {
+			if !commit {
+				w.Abort()
+				return nil
+			}
+			return w.Close()
+		}
+	} else {
+		if store, err = objstore.Open(ctx, location); err != nil {
+			return nil, "", nil, nil, err
+		}
+		index = manifest.File
+		finish = func(bool) error { return nil }
+	}
+
+	data, err := store.Get(ctx, index)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		m = &manifest.Manifest{Entries: map[string]manifest.Entry{}}
+	case err != nil:
+		finish(false)
+		return nil, "", nil, nil, err
+	default:
+		if m, err = manifest.Decode(data); err != nil {
+			finish(false)
+			return nil, "", nil, nil, err
+		}
+	}
+	return store, index, m, finish, nil
+}
+
+// uploadTranscripts downloads each video's transcript through the batch
+// worker pool and saves it to store, the one at location, recording the
+// results in m and saving m alongside them as index.
+func uploadTranscripts(client *youtube.Client, videoIDs []string, location string, store objstore.Store, index string, m *manifest.Manifest, opts youtube.DownloadOptions) error {
+	var mu sync.Mutex
+	record := func(e manifest.Entry) {
+		mu.Lock()
+		defer mu.Unlock()
+		m.Add(e)
+		currentRun.record(e)
+	}
+
+	results := runBatch("upload", videoIDs, func(ctx context.Context, videoID string) error {
+		e, err := uploadTranscript(ctx, client, store, videoID, opts)
+		if err != nil {
+			record(manifest.Entry{VideoID: videoID, Status: manifest.StatusFailed, Error: err.Error()})
+			return err
+		}
+		record(e)
+		return nil
+	})
+
+	data, err := m.Encode()
+	if err == nil {
+		err = store.Put(context.Background(), index, data)
+	}
+	if err != nil {
+		warn(err)
+	}
+	return reportBatch("upload", location, results, "saved")
+}
+
+// uploadTranscript downloads a video's transcript and saves it to store,
+// returning its manifest entry.
+func uploadTranscript(ctx context.Context, client *youtube.Client, store objstore.Store, videoID string, opts youtube.DownloadOptions) (manifest.Entry, error) {
+	src, data, err := client.FetchCaptions(ctx, videoID, opts.WordTimings)
+	if err != nil {
+		return manifest.Entry{}, err
+	}
+	detected, warning := youtube.CheckLanguage(&src, data, opts.Lang)
+	if warning != "" {
+		warn(errors.New(warning))
+	}
+	rendered, err := youtube.RenderTranscript(data, src, opts)
+	if err != nil {
+		return manifest.Entry{}, err
+	}
+
+	name := youtube.TranscriptFilename(videoID, src.Title, opts.Format)
+	fmt.Fprintf(stderr, "Saving to: %s\n", store.URL(name))
+	if err := store.Put(ctx, name, rendered); err != nil {
+		return manifest.Entry{}, err
+	}
+
+	res := &youtube.DownloadResult{
+		VideoID:     videoID,
+		Title:       src.Title,
+		ChannelID:   src.ChannelID,
+		PublishedAt: src.PublishedAt,
+		Language:    src.Language,
+		Path:        store.URL(name),
+	}
+	if err := runPostHook(res); err != nil {
+		warn(err)
+	}
+	return manifest.Entry{
+		VideoID:          videoID,
+		Title:            src.Title,
+		ChannelID:        src.ChannelID,
+		PublishedAt:      src.PublishedAt,
+		Language:         src.Language,
+		File:             name,
+		Format:           string(opts.Format),
+		Process:          processSpec(),
+		Hash:             manifest.Hash(rendered),
+		SourceHash:       manifest.Hash(data),
+		DetectedLanguage: detected,
+		Status:           manifest.StatusOK,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/n2p5/ytt/internal/objstore"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// pathKeys are the settings that hold paths. Relative paths in the config
+// file are relative to the file's directory, so the same files are found
+// from any working directory; relative paths in flags and the environment
+// are relative to the working directory, as usual.
+var pathKeys = []string{"oauth", "token", "output", "data_dir", "cache_dir", "crash_dir"}
+
+// Where a path setting came from, for ytt doctor.
+const (
+	fromFlag    = "flag"
+	fromEnv     = "environment"
+	fromConfig  = "config file"
+	fromDefault = "default"
+)
+
+// pathSources records where each of pathKeys was set by resolvePaths.
+var pathSources = map[string]string{}
+
+// legacySecretsDir is where the client secret and token are found when ytt
+// runs from a checkout of this repository, as the setup steps once said.
+const legacySecretsDir = "secrets"
+
+// resolvePaths settles the path settings for the running command: paths
+// from the config file are made relative to its directory, with ~ expanded,
+// and the client secret and token default to the config directory unless
+// the working directory has a secrets directory.
+func resolvePaths(cmd *cobra.Command) {
+	for _, key := range pathKeys {
+		switch {
+		case cmd.Flags().Changed(key):
+			pathSources[key] = fromFlag
+		case os.Getenv(envName(key)) != "":
+			pathSources[key] = fromEnv
+		case viper.InConfig(key):
+			pathSources[key] = fromConfig
+			viper.Set(key, configRelative(viper.GetString(key)))
+		default:
+			pathSources[key] = fromDefault
+		}
+	}
+	if viper.GetString("oauth") == "" {
+		viper.Set("oauth", defaultSecretPath("oauth.json"))
+	}
+	if viper.GetString("token") == "" {
+		viper.Set("token", defaultSecretPath("token.json"))
+	}
+}
+
+// envName returns the environment variable viper reads key from.
+func envName(key string) string {
+	return "YTT_" + strings.ToUpper(key)
+}
+
+// configRelative expands a leading ~ in path and makes it relative to the
+// config file's directory. Storage URLs, such as an s3:// output, are
+// returned unchanged, as is anything else with a scheme, so that it fails
+// as an unsupported URL rather than as a missing directory.
+func configRelative(path string) string {
+	if objstore.IsRemote(path) || strings.Contains(path, "://") {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, "~"); ok && (rest == "" || os.IsPathSeparator(rest[0])) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(viper.ConfigFileUsed()), path)
+}
+
+// configDir returns the directory of the config file in use, or the one ytt
+// looks for it in.
+func configDir() string {
+	if f := viper.ConfigFileUsed(); f != "" {
+		if _, err := os.Stat(f); err == nil {
+			return filepath.Dir(f)
+		}
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "ytt")
+	}
+	return legacySecretsDir
+}
+
+// defaultSecretPath returns the default path of the secret file name: in
+// the working directory's secrets directory if there is one, and otherwise
+// in the config directory.
+func defaultSecretPath(name string) string {
+	if info, err := os.Stat(legacySecretsDir); err == nil && info.IsDir() {
+		return filepath.Join(legacySecretsDir, name)
+	}
+	return filepath.Join(configDir(), name)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	// promptMu keeps prompts from concurrent batch workers from
+	// interleaving.
+	promptMu sync.Mutex
+	// stdin is shared so input buffered by one prompt isn't lost to the next.
+	stdin = bufio.NewReader(os.Stdin)
+)
+
+// confirm asks a yes/no question on stderr and reads the answer from stdin.
+// Anything other than "y" or "yes" is treated as no, as is every question
+// under --non-interactive.
+func confirm(question string) bool {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	if viper.GetBool("non-interactive") {
+		fmt.Fprintln(os.Stderr, "no (--non-interactive)")
+		return false
+	}
+	answer, err := stdin.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// choose asks question on stderr and returns one of choices, which can be
+// answered in full or by first letter. Unrecognized answers ask again. If
+// stdin is closed, or under --non-interactive, the first choice is returned.
+func choose(question string, choices ...string) string {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	labels := make([]string, len(choices))
+	for i, c := range choices {
+		labels[i] = "[" + c[:1] + "]" + c[1:]
+	}
+	if viper.GetBool("non-interactive") {
+		fmt.Fprintf(os.Stderr, "%s %s: %s (--non-interactive)\n", question, strings.Join(labels, ", "), choices[0])
+		return choices[0]
+	}
+	for {
+		fmt.Fprintf(os.Stderr, "%s %s: ", question, strings.Join(labels, ", "))
+		answer, err := stdin.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		for _, c := range choices {
+			if answer == c || answer == c[:1] {
+				return c
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr)
+			return choices[0]
+		}
+	}
+}
+
+// interactive reports whether stdin is a terminal someone can answer
+// prompts from, and --non-interactive isn't set.
+func interactive() bool {
+	if viper.GetBool("non-interactive") {
+		return false
+	}
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var quoteCmd = &cobra.Command{
+	Use:   "quote <video_id>",
+	Short: "Print the part of a transcript between two times",
+	Long: `Print the transcript cues shown between --from and --to, for citing an
+exact segment. Times are given as seconds, M:SS, or H:MM:SS.
+
+The transcript is read from the output directory if it was downloaded
+there, and fetched from YouTube otherwise.`,
+	Example: `  ytt quote abc123 --from 12:34 --to 15:02 --link`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runQuote,
+}
+
+func init() {
+	quoteCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	quoteCmd.Flags().String("from", "0", "start of the segment")
+	quoteCmd.Flags().String("to", "", "end of the segment (default: end of the video)")
+	quoteCmd.Flags().Bool("timestamps", false, "prefix each cue with its start time")
+	quoteCmd.Flags().Bool("link", false, "end with a link to the segment on YouTube")
+
+	rootCmd.AddCommand(quoteCmd)
+}
+
+func runQuote(cmd *cobra.Command, args []string) error {
+	videoID := args[0]
+
+	from, err := parseOffset(viper.GetString("from"))
+	if err != nil {
+		return err
+	}
+	var to time.Duration
+	if s := viper.GetString("to"); s != "" {
+		if to, err = parseOffset(s); err != nil {
+			return err
+		}
+		if to <= from {
+			return fmt.Errorf("--to must be after --from")
+		}
+	}
+
+	cues, err := loadCues(videoID, viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	quoted := transcript.Between(cues, from, to)
+	if len(quoted) == 0 {
+		if to == 0 {
+			return fmt.Errorf("no captions after %s", transcript.FormatTimestamp(from))
+		}
+		return fmt.Errorf("no captions between %s and %s", transcript.FormatTimestamp(from), transcript.FormatTimestamp(to))
+	}
+	if jsonOutput() {
+		res := quoteResult{VideoID: videoID, URL: transcript.WatchURL(videoID, max(from, quoted[0].Start))}
+		for _, c := range quoted {
+			res.Cues = append(res.Cues, quoteCue{Start: c.Start.Seconds(), End: c.End.Seconds(), Text: c.Text})
+		}
+		setResult(res)
+		return nil
+	}
+	for _, c := range quoted {
+		text := strings.ReplaceAll(c.Text, "\n", " ")
+		if viper.GetBool("timestamps") {
+			text = "[" + transcript.FormatTimestamp(c.Start) + "] " + text
+		}
+		fmt.Println(text)
+	}
+	if viper.GetBool("link") {
+		fmt.Printf("\n%s\n", transcript.WatchURL(videoID, max(from, quoted[0].Start)))
+	}
+	return nil
+}
+
+// quoteResult is the quoted segment under --json, with times in seconds.
+type quoteResult struct {
+	VideoID string     `json:"video_id"`
+	Cues    []quoteCue `json:"cues"`
+	URL     string     `json:"url"`
+}
+
+type quoteCue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// loadCues returns a video's transcript from the archive in outputDir, or
+// fetches it from YouTube if it isn't there.
+func loadCues(videoID, outputDir string) ([]transcript.Cue, error) {
+	a, err := archive.Open(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok, err := a.Video(videoID); err != nil {
+		return nil, err
+	} else if ok {
+		return a.Cues(videoID)
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	_, data, err := client.FetchCaptions(context.Background(), videoID, false)
+	if err != nil {
+		return nil, err
+	}
+	return transcript.Parse(data)
+}
+
+// parseOffset parses a position in a video given as seconds or a timestamp
+// such as 12:34 or 1:02:03.
+func parseOffset(s string) (time.Duration, error) {
+	if !strings.Contains(s, ":") {
+		secs, err := strconv.ParseFloat(s, 64)
+		if err != nil || secs < 0 {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return transcript.ParseTimestamp(s)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/trash"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var refreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Regenerate downloaded transcripts with a new format or processing",
+	Long: `Bring every transcript in the output directory's manifest up to date with
+--format and --process.
+
+Transcripts are regenerated from raw captions kept with --keep-raw, or from
+the saved files where they still hold the full captions: unprocessed, and
+with timings if the new format needs them. Others are downloaded again. Replaced files are moved to the trash.`,
+	Args: cobra.NoArgs,
+	RunE: runRefresh,
+}
+
+func init() {
+	refreshCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	refreshCmd.Flags().IntP("workers", "w", 1, "number of videos to refresh concurrently")
+	refreshCmd.Flags().String("manifest", "", "write a run manifest of this batch, with each video's result and file hash, to this path")
+	addDownloadFlags(refreshCmd)
+
+	rootCmd.AddCommand(refreshCmd)
+}
+
+func runRefresh(cmd *cobra.Command, args []string) error {
+	opts, err := downloadOptions()
+	if err != nil {
+		return err
+	}
+	outputDir := viper.GetString("output")
+	spec := processSpec()
+	beginRun("refresh "+outputDir, outputDir)
+	defer endRun()
+
+	m, err := manifest.Load(outputDir)
+	if err != nil {
+		return err
+	}
+
+	entries := map[string]manifest.Entry{}
+	var ids []string
+	p := &plan{action: "refresh transcripts for"}
+	for _, e := range m.Sorted() {
+		if e.Status != manifest.StatusOK || e.File == "" {
+			warn(fmt.Errorf("skipping %s: its last download failed", e.VideoID))
+			continue
+		}
+		if e.Format == string(opts.Format) && e.Process == spec {
+			continue
+		}
+		newFile := youtube.TranscriptFilename(e.VideoID, e.Title, opts.Format)
+		fromFile := refreshSource(outputDir, e, opts.Format) != nil
+		if !fromFile && e.Imported != "" {
+			warn(fmt.Errorf("skipping %s: it was imported, and can't be regenerated from %s; import %s again instead", e.VideoID, e.File, e.Imported))
+			continue
+		}
+		entries[e.VideoID] = e
+		ids = append(ids, e.VideoID)
+
+		if fromFile {
+			p.add(nil, "%s  %s  (from %s)", e.VideoID, newFile, e.File)
+		} else {
+			p.add(youtube.DownloadTranscriptCalls, "%s  %s  (download)", e.VideoID, newFile)
+		}
+	}
+
+	if len(ids) == 0 {
+		fmt.Fprintln(stderr, "Everything is up to date.")
+		return nil
+	}
+	if dryRun() {
+		p.print()
+		return nil
+	}
+
+	var client *youtube.Client
+	if p.estimate.Total() > 0 {
+		if client, err = newClient(); err != nil {
+			return err
+		}
+	}
+
+	return saveTranscripts(ids, outputDir, opts, "refresh", "refreshed", func(videoID string) (*youtube.DownloadResult, error) {
+		e := entries[videoID]
+
+		var res *youtube.DownloadResult
+		var err error
+		if data := refreshSource(outputDir, e, opts.Format); data != nil {
+			src := transcript.Source{VideoID: videoID, Title: e.Title, ChannelID: e.ChannelID, PublishedAt: e.PublishedAt, Language: e.Language}
+			res, err = youtube.SaveTranscript(outputDir, src, data, opts)
+			if err == nil {
+				res.SourceHash, res.Imported, res.DetectedLanguage = e.SourceHash, e.Imported, e.DetectedLanguage
+				if e.Raw != "" {
+					res.RawPath = filepath.Join(outputDir, filepath.FromSlash(e.Raw))
+				}
+			}
+		} else {
+			res, err = client.DownloadTranscript(videoID, outputDir, opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// A new format means a new file name; don't leave the old file
+		// behind unreferenced.
+		if relOutputPath(outputDir, res.Path) != e.File {
+			if _, err := trash.Move(outputDir, filepath.Join(outputDir, filepath.FromSlash(e.File))); err != nil {
+				warn(err)
+			}
+		}
+		return res, nil
+	})
+}
+
+// refreshSource returns the saved captions for e if the transcript can be
+// regenerated from them in format, or nil if it has to be downloaded again.
+// Raw captions kept with --keep-raw are preferred.
+func refreshSource(outputDir string, e manifest.Entry, format transcript.Format) []byte {
+	if e.Raw != "" {
+		if data, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(e.Raw))); err == nil {
+			return data
+		}
+	}
+	// Processing is lossy, and converting loses the original format.
+	if e.Process != "" || (e.Format != "" && format == "") {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(e.File)))
+	if err != nil {
+		return nil
+	}
+	if transcript.DetectFormat(data) == transcript.FormatPlain && format != "" && format != transcript.FormatPlain {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/report"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Write a channel-wide report on uploads, captions, and topics",
+	Long: `Write a report on a channel as Markdown or a standalone HTML page: how
+often it uploads, its videos' average duration, how many have captions,
+how views relate to transcript length, and the words and phrases said
+most.
+
+The channel's videos are listed from YouTube, costing about 1 quota unit
+per 50 videos; the transcripts are read from the output directory, so sync
+the channel first for the transcript figures. Views are compared with
+transcript lengths by rank correlation, from -1 to 1, so a few viral
+videos don't dominate it.`,
+	Example: `  ytt report --channel @example > report.md
+  ytt report --channel @example --out report.html`,
+	Args: cobra.NoArgs,
+	RunE: runReport,
+}
+
+func init() {
+	reportCmd.Flags().String("channel", "", "channel ID, @handle, or URL (default: authenticated user's channel)")
+	reportCmd.RegisterFlagCompletionFunc("channel", completeChannelIDs)
+	reportCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	reportCmd.Flags().String("format", "", "report format: md or html (default: from --out's extension, else md)")
+	reportCmd.Flags().String("out", "", "file to write the report to (default: stdout)")
+	reportCmd.Flags().Int("top", 20, "number of top words and phrases to list")
+	reportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"md", "html"}, cobra.ShellCompDirectiveNoFileComp))
+	addContentFilterFlags(reportCmd)
+
+	rootCmd.AddCommand(reportCmd)
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	out := viper.GetString("out")
+	format := viper.GetString("format")
+	if format == "" {
+		format = "md"
+		if ext := strings.ToLower(filepath.Ext(out)); ext == ".html" || ext == ".htm" {
+			format = "html"
+		}
+	}
+	if format != "md" && format != "html" {
+		return fmt.Errorf("unsupported report format %q (want md or html)", format)
+	}
+	channelID, _ := cmd.Flags().GetString("channel")
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	if channelID, err = resolveChannel(client, channelID); err != nil {
+		return err
+	}
+	a, err := archive.Open(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	var videos []report.Video
+	for v, err := range client.Videos(context.Background(), channelID, listOptions()) {
+		if err != nil {
+			return err
+		}
+		published, _ := time.Parse(time.RFC3339, v.Date)
+		rv := report.Video{
+			VideoID:     v.VideoID,
+			Title:       v.Title,
+			PublishedAt: published,
+			Duration:    time.Duration(v.DurationSeconds) * time.Second,
+			Views:       v.ViewCount,
+			HasCaptions: v.HasCaptions,
+		}
+		if e, ok, err := a.Video(v.VideoID); err != nil {
+			return err
+		} else if ok && e.Status == manifest.StatusOK {
+			if rv.Transcript, err = archivedText(a, v.VideoID); err != nil {
+				warn(err)
+			}
+		}
+		videos = append(videos, rv)
+	}
+	r := report.Build(channelID, videos, viper.GetInt("top"), time.Now())
+
+	if jsonOutput() {
+		setResult(r)
+		return nil
+	}
+	var data []byte
+	if format == "html" {
+		if data, err = r.HTML(); err != nil {
+			return err
+		}
+	} else {
+		data = r.Markdown()
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		return fmt.Errorf("error writing report: %w", err)
+	}
+	fmt.Fprintf(stderr, "Wrote report on %d videos to %s\n", r.Videos, out)
+	return nil
+}
+
+// archivedText returns the text of a video's archived transcript, without
+// the repeated lines of auto-generated captions.
+func archivedText(a *archive.Archive, videoID string) (string, error) {
+	cues, err := a.Cues(videoID)
+	if err != nil {
+		return "", err
+	}
+	if cues, err = transcript.Dedupe(transcript.Source{VideoID: videoID}, cues); err != nil {
+		return "", err
+	}
+	return transcript.Text(cues), nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/resume"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Finish a batch that stopped when the daily API quota ran out",
+	Long: `When the daily API quota runs out partway through transcript, sync,
+refresh, or gc --redownload, the videos left to do are saved along with the
+run's output directory, format, and other download settings, and ytt exits
+with status 5. Run resume once the quota resets, at midnight Pacific time, to
+download the rest with the same settings.
+
+A batch interrupted with Ctrl-C or SIGTERM finishes the videos in progress
+and saves the rest the same way, exiting with status 130; those can be
+resumed right away.
+
+If the quota runs out again, the queue is saved again for the next resume.
+Use --discard to drop a saved queue.`,
+	Args: cobra.NoArgs,
+	RunE: runResume,
+}
+
+func init() {
+	resumeCmd.Flags().Bool("discard", false, "drop the saved queue instead of resuming it")
+
+	rootCmd.AddCommand(resumeCmd)
+}
+
+// resumeSettings are the flags saved with a queue so resume downloads the
+// rest of a batch the way it started.
+var resumeSettings = []string{
+	"output", "archive", "workers",
+	"format", "process", "lang", "lang-match", "prefer", "keep-raw", "keep-history", "keep-styles", "raw-text", "header", "newline", "redact", "mask-words", "wrap", "max-cue-chars", "max-cue-duration", "word-timings", "summarize-cmd", "with-audio", "post-hook",
+	"update", "on-conflict", "git-commit", "priority",
+}
+
+// runSettings returns the current values of resumeSettings for a batch
+// saving into location. location is saved as the output in place of
+// --output, which for a group sync is the parent of the channel's own
+// subdirectory.
+func runSettings(location string) map[string]any {
+	settings := map[string]any{}
+	for _, key := range resumeSettings {
+		if v := viper.Get(key); v != nil {
+			settings[key] = v
+		}
+	}
+	if !isArchive(location) {
+		settings["output"] = location
+	}
+	return settings
+}
+
+// restoreSettings sets the flags saved with a batch by runSettings.
+func restoreSettings(settings map[string]any) {
+	for key, v := range settings {
+		viper.Set(key, v)
+	}
+}
+
+// quotaError reports that a batch stopped because the daily quota ran out,
+// with its remaining videos saved for resume.
+type quotaError struct {
+	remaining int
+	reset     time.Time
+}
+
+func (e *quotaError) Error() string {
+	return fmt.Sprintf("daily API quota exhausted with %d video(s) left; the quota resets at %s, then run \"ytt resume\"",
+		e.remaining, e.reset.Local().Format("Mon Jan 2 15:04 MST"))
+}
+
+// saveQueue saves the videos a batch saving into location didn't finish
+// for resume once reset has passed.
+func saveQueue(job, location string, videoIDs []string, reset time.Time) error {
+	now := time.Now()
+	s := &resume.State{
+		Job:      job,
+		Settings: runSettings(location),
+		VideoIDs: videoIDs,
+		SavedAt:  now.UTC(),
+		ResetAt:  reset.UTC(),
+	}
+	return resume.Save(viper.GetString("data_dir"), s)
+}
+
+func runResume(cmd *cobra.Command, args []string) error {
+	dataDir := viper.GetString("data_dir")
+	s, err := resume.Load(dataDir)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		fmt.Fprintln(stderr, "Nothing to resume.")
+		return nil
+	}
+	if viper.GetBool("discard") {
+		fmt.Fprintf(stderr, "Discarded %d queued videos from %s\n", len(s.VideoIDs), s.Job)
+		return resume.Clear(dataDir)
+	}
+
+	if time.Now().Before(s.ResetAt) {
+		warn(fmt.Errorf("the quota resets at %s; resuming earlier may fail", s.ResetAt.Local().Format("Mon Jan 2 15:04 MST")))
+	}
+	restoreSettings(s.Settings)
+	opts, err := downloadOptions()
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	location := outputLocation()
+	beginRun("resume "+s.Job, location)
+	defer endRun()
+	if dryRun() {
+		return planTranscripts(client, s.VideoIDs, location, opts.Format)
+	}
+	fmt.Fprintf(stderr, "Resuming %d videos from %s, saved %s\n", len(s.VideoIDs), s.Job, s.SavedAt.Local().Format(time.DateTime))
+
+	if !localOutput(location) {
+		store, index, m, finish, err := openStore(context.Background(), location)
+		if err != nil {
+			return err
+		}
+		err = uploadTranscripts(client, s.VideoIDs, location, store, index, m, opts)
+		if ferr := finish(true); err == nil {
+			err = ferr
+		}
+		return finishResume(dataDir, err)
+	}
+
+	save, err := savedRunSave(client, location, opts)
+	if err != nil {
+		return err
+	}
+	err = saveTranscripts(s.VideoIDs, location, opts, s.Job, "downloaded", save)
+	commitOutput(location, fmt.Sprintf("resume: %d videos from %s", len(s.VideoIDs), s.Job))
+	return finishResume(dataDir, err)
+}
+
+// savedRunSave returns the function that downloads a video left over from
+// an earlier run into location, with that run's settings already applied.
+func savedRunSave(client *youtube.Client, location string, opts youtube.DownloadOptions) (func(videoID string) (*youtube.DownloadResult, error), error) {
+	policy := viper.GetString("on-conflict")
+	if policy == conflictPrompt && !interactive() {
+		policy = conflictKeep
+	}
+	m, err := manifest.Load(location)
+	if err != nil {
+		return nil, err
+	}
+	update := viper.GetBool("update")
+	return func(videoID string) (*youtube.DownloadResult, error) {
+		// Videos a sync --update was checking are checked again rather
+		// than downloaded over local edits.
+		e, ok := m.Entries[videoID]
+		if ok && update && e.Status == manifest.StatusOK && e.Format == string(opts.Format) && e.Process == processSpec() {
+			return updateTranscript(client, location, e, opts, policy)
+		}
+		return client.DownloadTranscript(videoID, location, opts)
+	}, nil
+}
+
+// finishResume clears the resumed queue unless the quota ran out again or
+// the run was interrupted, in which case the queue now holds what's left.
+func finishResume(dataDir string, err error) error {
+	var qe *quotaError
+	var ie *interruptError
+	if errors.As(err, &qe) || errors.As(err, &ie) {
+		return err
+	}
+	if cerr := resume.Clear(dataDir); cerr != nil {
+		warn(cerr)
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/n2p5/ytt/internal/batch"
+	"github.com/n2p5/ytt/internal/jobs"
+	"github.com/n2p5/ytt/internal/resume"
+	"github.com/spf13/viper"
+)
+
+// TestResumeAfterGroupSync checks that videos left over from a group
+// channel's batch are resumed and retried into the channel's subdirectory,
+// not the --output directory holding it.
+func TestResumeAfterGroupSync(t *testing.T) {
+	for _, key := range []string{"data_dir", "output", "archive"} {
+		old := viper.Get(key)
+		t.Cleanup(func() { viper.Set(key, old) })
+	}
+	dataDir, parent := t.TempDir(), t.TempDir()
+	viper.Set("data_dir", dataDir)
+	viper.Set("output", parent)
+	viper.Set("archive", "")
+	channelDir := outputPath(parent, "somechannel")
+
+	tracker := trackJobs("sync", channelDir, []string{"abc"})
+	tracker.close()
+	results := []batch.Result{{ID: "abc", Err: context.Canceled}}
+	var qe *quotaError
+	if err := reportBatch("sync", channelDir, results, "synced"); !errors.As(err, &qe) {
+		t.Fatalf("reportBatch() = %v, want the queue saved for resume", err)
+	}
+
+	s, err := resume.Load(dataDir)
+	if err != nil || s == nil {
+		t.Fatalf("resume.Load() = %v, %v", s, err)
+	}
+	viper.Set("output", parent)
+	restoreSettings(s.Settings)
+	if got := outputLocation(); got != channelDir {
+		t.Errorf("resume saves into %s, want %s", got, channelDir)
+	}
+
+	q, err := jobs.Load(dataDir)
+	if err != nil || len(q.Batches) != 1 {
+		t.Fatalf("jobs.Load() = %+v, %v; want one batch", q, err)
+	}
+	viper.Set("output", parent)
+	restoreSettings(q.Batches[0].Settings)
+	if got := outputLocation(); got != channelDir {
+		t.Errorf("jobs retry saves into %s, want %s", got, channelDir)
+	}
+}
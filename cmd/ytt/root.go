@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/n2p5/ytt/internal/audio"
+	"github.com/n2p5/ytt/internal/crash"
+	"github.com/n2p5/ytt/internal/jobs"
+	"github.com/n2p5/ytt/internal/trash"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cfgFile string
+
+// stderr mirrors status output into the crash log ring so crash reports show
+// what led up to a panic.
+var stderr io.Writer = io.MultiWriter(os.Stderr, crash.Log)
+
+var rootCmd = &cobra.Command{
+	Use:          "ytt",
+	Short:        "Work with transcripts and captions for YouTube videos",
+	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Bind only the running command's flags so that commands sharing a
+		// flag name don't clobber each other's viper binding.
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+		resolvePaths(cmd)
+		if err := selectAccount(cmd); err != nil {
+			return err
+		}
+		if p := viper.GetString("progress"); p != "text" && p != "json" {
+			return fmt.Errorf("invalid --progress %q (want text or json)", p)
+		}
+		if err := startTelemetry(cmd); err != nil {
+			return err
+		}
+		startMetrics(cmd)
+		runStarted = time.Now()
+		if _, err := notificationText(viper.GetString("notify-template"), &runSummary{}); err != nil {
+			return err
+		}
+		commandRan = true
+		if jsonOutput() {
+			// The error is written as part of the JSON output instead.
+			cmd.Root().SilenceErrors = true
+		}
+		return nil
+	},
+	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+		return checkStrict()
+	},
+}
+
+func init() {
+	cobra.OnInitialize(initConfig)
+
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default $XDG_CONFIG_HOME/ytt/config.yaml)")
+	rootCmd.PersistentFlags().String("oauth", "", "path to the OAuth client secret JSON file (default: secrets/oauth.json if there is a secrets directory, else oauth.json in the config directory)")
+	rootCmd.PersistentFlags().String("token", "", "path to the cached OAuth token (default: secrets/token.json if there is a secrets directory, else token.json in the config directory)")
+	rootCmd.PersistentFlags().String("account", "", "act as this channel, by ID or @handle, signed in with \"ytt auth login --add-account\" (default: the channel of --token)")
+	rootCmd.RegisterFlagCompletionFunc("account", completeAccounts)
+	rootCmd.PersistentFlags().String("content-owner", "", "make API requests on behalf of this YouTube content owner (CMS) ID, to work with every channel it manages (env: YTT_CONTENT_OWNER)")
+	rootCmd.PersistentFlags().String("content-owner-channel", "", "with --content-owner, the channel ID to act for where the API asks for one, such as listing playlists (env: YTT_CONTENT_OWNER_CHANNEL)")
+	rootCmd.PersistentFlags().String("token-storage", "file", "where to keep the OAuth token: file, keyring, encrypted (with YTT_TOKEN_PASSPHRASE), or auto")
+	rootCmd.RegisterFlagCompletionFunc("token-storage", cobra.FixedCompletions([]string{"file", "keyring", "encrypted", "auto"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.PersistentFlags().Bool("no-browser", false, "don't open a browser for authorization; print the URL to open by hand")
+	rootCmd.PersistentFlags().Bool("non-interactive", false, "never sign in or wait for input; fail with exit status 4 when signing in is needed (env: YTT_NON_INTERACTIVE)")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "don't use or update the on-disk API response cache")
+	rootCmd.PersistentFlags().String("progress", "text", "progress output: text, or json for line-delimited JSON events on stderr")
+	rootCmd.RegisterFlagCompletionFunc("progress", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.PersistentFlags().Bool("strict", false, "exit with an error if anything produced a warning, such as a truncated file name")
+	rootCmd.PersistentFlags().String("user-agent", "", "User-Agent to send with API requests")
+	rootCmd.PersistentFlags().String("quota-user", "", "quotaUser to tag API requests with, to attribute quota usage to a user or team")
+	rootCmd.PersistentFlags().Float64("qps", 0, "maximum API requests per second, slowing down further if YouTube reports rateLimitExceeded (0 for no limit)")
+	rootCmd.PersistentFlags().Int("burst", 10, "API requests allowed at once before --qps applies")
+	rootCmd.PersistentFlags().String("proxy", "", "send API requests through this http://, https://, or socks5:// proxy (default: from HTTPS_PROXY, HTTP_PROXY, and NO_PROXY)")
+	rootCmd.PersistentFlags().String("otel-endpoint", "", "send OpenTelemetry traces and metrics to this OTLP/HTTP collector, such as http://localhost:4318 (default: OTEL_EXPORTER_OTLP_ENDPOINT)")
+	rootCmd.PersistentFlags().StringSlice("notify-url", nil, "when a batch finishes or the command fails, post a summary to this webhook, Slack, or Discord URL (repeatable)")
+	rootCmd.PersistentFlags().Bool("notify-desktop", false, "when a batch finishes or the command fails, show a desktop notification")
+	rootCmd.PersistentFlags().String("notify-template", "", "Go template for the notification text, given the run summary (see the README)")
+	rootCmd.PersistentFlags().Duration("timeout", 2*time.Minute, "give up on an API request, including downloading its response, after this long (0 for no limit)")
+	rootCmd.PersistentFlags().Int("max-caption-mb", 50, "fail caption downloads larger than this many megabytes (0 for no limit)")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "show what would be downloaded, uploaded, or written, and the estimated quota cost, without doing it")
+	rootCmd.PersistentFlags().Bool("json", false, "write the command's result, or its error, as a single JSON document on stdout")
+	// Bound here as well as in PersistentPreRunE so that errors from parsing
+	// the command line are reported as JSON too.
+	viper.BindPFlag("json", rootCmd.PersistentFlags().Lookup("json"))
+
+	rootCmd.Version = version()
+
+	viper.SetDefault("crash_dir", defaultCacheDir("crash"))
+	viper.SetDefault("cache_dir", defaultCacheDir("http"))
+	viper.SetDefault("data_dir", defaultDataDir())
+	viper.SetDefault("trash_retention", trash.DefaultRetention)
+	viper.SetDefault("job_max_attempts", jobs.DefaultPolicy.MaxAttempts)
+	viper.SetDefault("job_retry_backoff", jobs.DefaultPolicy.Backoff)
+	viper.SetDefault("daily_quota", youtube.DefaultDailyQuota)
+	viper.SetDefault("audio_downloader", audio.DefaultProgram)
+	viper.SetDefault("audio_args", audio.DefaultArgs)
+	viper.SetDefault("clip_downloader", audio.DefaultProgram)
+	viper.SetDefault("clip_args", audio.DefaultClipArgs)
+	log.SetOutput(stderr)
+}
+
+func initConfig() {
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+	} else {
+		if dir, err := os.UserConfigDir(); err == nil {
+			viper.AddConfigPath(filepath.Join(dir, "ytt"))
+		}
+		viper.SetConfigName("config")
+		viper.SetConfigType("yaml")
+	}
+
+	viper.SetEnvPrefix("YTT")
+	viper.AutomaticEnv()
+	// AutomaticEnv can't map the hyphen, and containers set these.
+	viper.BindEnv("non-interactive", "YTT_NON_INTERACTIVE")
+	viper.BindEnv("content-owner", "YTT_CONTENT_OWNER")
+	viper.BindEnv("content-owner-channel", "YTT_CONTENT_OWNER_CHANNEL")
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok || cfgFile != "" {
+			fmt.Fprintf(os.Stderr, "Error reading config file: %v\n", err)
+		}
+	}
+}
+
+func defaultCacheDir(name string) string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "ytt", name)
+	}
+	return filepath.Join(os.TempDir(), "ytt-"+name)
+}
+
+// defaultDataDir follows the XDG base directory spec for persistent state
+// such as channel snapshots.
+func defaultDataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "ytt")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "share", "ytt")
+	}
+	return "ytt-data"
+}
+
+func newClient() (*youtube.Client, error) {
+	opts, err := authOptions()
+	if err != nil {
+		return nil, err
+	}
+	if !viper.GetBool("no-cache") {
+		opts = append(opts, youtube.WithCache(viper.GetString("cache_dir")))
+	}
+	if ua := viper.GetString("user-agent"); ua != "" {
+		opts = append(opts, youtube.WithUserAgent(ua))
+	}
+	if user := viper.GetString("quota-user"); user != "" {
+		opts = append(opts, youtube.WithQuotaUser(user))
+	}
+	if owner := viper.GetString("content-owner"); owner != "" {
+		opts = append(opts, youtube.WithContentOwner(owner))
+	}
+	if channel := viper.GetString("content-owner-channel"); channel != "" {
+		if viper.GetString("content-owner") == "" {
+			return nil, fmt.Errorf("--content-owner-channel needs --content-owner")
+		}
+		opts = append(opts, youtube.WithContentOwnerChannel(channel))
+	}
+	if qps := viper.GetFloat64("qps"); qps > 0 {
+		opts = append(opts, youtube.WithRateLimit(qps, viper.GetInt("burst")))
+	}
+	if timeout := viper.GetDuration("timeout"); timeout > 0 {
+		opts = append(opts, youtube.WithTimeout(timeout))
+	}
+	if mb := viper.GetInt64("max-caption-mb"); mb > 0 {
+		opts = append(opts, youtube.WithMaxCaptionSize(mb<<20))
+	}
+	if lang := viper.GetString("lang"); lang != "" {
+		opts = append(opts, youtube.WithLanguage(lang))
+	}
+	if match, err := youtube.ParseLangMatch(viper.GetString("lang-match")); err == nil {
+		opts = append(opts, youtube.WithLangMatch(match))
+	}
+	if prefer, err := youtube.ParseTrackPreference(viper.GetString("prefer")); err == nil {
+		opts = append(opts, youtube.WithTrackPreference(prefer))
+	}
+	if tel != nil {
+		opts = append(opts, youtube.WithTelemetry(tel, commandSpan))
+	}
+	opts = append(opts, youtube.WithUsage(apiUsage))
+	opts = append(opts, youtube.WithHandleCache(filepath.Join(viper.GetString("data_dir"), "handles.json")))
+	client, err := youtube.NewClient(viper.GetString("oauth"), viper.GetString("token"), opts...)
+	if youtube.IsAuthError(err) {
+		return nil, &authError{signInHint(err)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// resolveChannel returns the ID of a channel given by ID, @handle, or URL,
+// or of the authenticated user's channel if channel is empty.
+func resolveChannel(client *youtube.Client, channel string) (string, error) {
+	if channel == "" {
+		return client.AuthenticatedChannelID()
+	}
+	return client.ResolveChannel(channel)
+}
+
+// resolveChannelOffline is resolveChannel for commands that otherwise work
+// offline: an API client is only created if channel isn't already an ID.
+func resolveChannelOffline(channel string) (string, error) {
+	ref, err := youtube.ParseChannelRef(channel)
+	if err != nil {
+		return "", err
+	}
+	if ref.ID != "" {
+		return ref.ID, nil
+	}
+	client, err := newClient()
+	if err != nil {
+		return "", err
+	}
+	return client.ResolveChannel(channel)
+}
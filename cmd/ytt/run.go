@@ -0,0 +1,106 @@
+package main
+
+import (
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/spf13/viper"
+)
+
+// currentRun collects the results of the running command's batch for
+// --manifest and --json, or is nil if neither was asked for.
+var currentRun *runRecorder
+
+type runRecorder struct {
+	path string
+
+	mu  sync.Mutex
+	run manifest.Run
+}
+
+// beginRun starts collecting a run manifest if --manifest or --json is set.
+// source describes where the batch's videos come from, and output where they
+// are saved.
+func beginRun(source, output string) {
+	path := viper.GetString("manifest")
+	if (path == "" && !jsonOutput()) || dryRun() {
+		return
+	}
+	currentRun = &runRecorder{
+		path: path,
+		run: manifest.Run{
+			Version:   version(),
+			Source:    source,
+			Output:    output,
+			StartedAt: time.Now().UTC(),
+			Results:   []manifest.Entry{},
+		},
+	}
+}
+
+// record adds a video's entry to the run manifest, if one is being
+// collected.
+func (r *runRecorder) record(e manifest.Entry) {
+	if r == nil {
+		return
+	}
+	if e.UpdatedAt.IsZero() {
+		e.UpdatedAt = time.Now().UTC()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.run.Results = append(r.run.Results, e)
+}
+
+// results returns the entries recorded so far, or nil if none are being
+// collected.
+func (r *runRecorder) results() []manifest.Entry {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.run.Results)
+}
+
+// endRun writes the run manifest, if one is being collected, and makes it
+// the command's result under --json.
+func endRun() {
+	r := currentRun
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.run.FinishedAt = time.Now().UTC()
+	if jsonOutput() {
+		setResult(&r.run)
+	}
+	if r.path == "" {
+		return
+	}
+	if err := manifest.WriteRun(r.path, &r.run); err != nil {
+		warn(err)
+	}
+}
+
+// version returns ytt's module version, or the VCS revision it was built
+// from for a development build.
+func version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return "devel " + s.Value
+		}
+	}
+	return "devel"
+}
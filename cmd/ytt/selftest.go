@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var selftestCmd = &cobra.Command{
+	Use:   "selftest [video_id]",
+	Short: "Run a quick end-to-end check against a test video",
+	Long: `Check that ytt works end to end with the current credentials: authenticate,
+fetch the test video's metadata, list its caption tracks, download its
+captions, and render them in every format. Each step is printed as PASS,
+FAIL, or SKIP (when an earlier step it needs failed).
+
+The test video is the argument, or selftest_video in the config file. Use a
+video you own, or a public video whose owner allows caption downloads.
+Nothing is written to disk. The command fails if any step fails.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSelftest,
+}
+
+func init() {
+	rootCmd.AddCommand(selftestCmd)
+}
+
+// errSkipped marks a selftest step that didn't run because one it depends
+// on failed.
+var errSkipped = errors.New("skipped")
+
+// selftest runs the steps of a selftest and prints each one's result.
+type selftest struct {
+	failed int
+	steps  []selftestStep
+}
+
+// selftestStep is a step's result, as listed under --json.
+type selftestStep struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	ElapsedMS int64  `json:"elapsed_ms"`
+}
+
+// step runs fn and prints its result under name. fn returns a detail to
+// show on success. A step whose dependency failed is skipped.
+func (s *selftest) step(name string, dep error, fn func() (string, error)) error {
+	if dep != nil {
+		s.report(selftestStep{Name: name, Status: "SKIP"})
+		return errSkipped
+	}
+	start := time.Now()
+	detail, err := fn()
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		s.failed++
+		s.report(selftestStep{Name: name, Status: "FAIL", Error: err.Error(), ElapsedMS: elapsed.Milliseconds()})
+		return err
+	}
+	s.report(selftestStep{Name: name, Status: "PASS", Detail: detail, ElapsedMS: elapsed.Milliseconds()})
+	return nil
+}
+
+// report prints a step's result, or collects it for --json.
+func (s *selftest) report(st selftestStep) {
+	if jsonOutput() {
+		s.steps = append(s.steps, st)
+		setResult(s.steps)
+		return
+	}
+	switch st.Status {
+	case "SKIP":
+		fmt.Printf("SKIP  %-12s\n", st.Name)
+	case "FAIL":
+		fmt.Printf("FAIL  %-12s %s\n", st.Name, st.Error)
+	default:
+		fmt.Printf("PASS  %-12s %s (%s)\n", st.Name, st.Detail, time.Duration(st.ElapsedMS)*time.Millisecond)
+	}
+}
+
+func runSelftest(cmd *cobra.Command, args []string) error {
+	videoID := viper.GetString("selftest_video")
+	if len(args) > 0 {
+		videoID = args[0]
+	}
+	if videoID == "" {
+		return fmt.Errorf("no test video: give a video ID, or set selftest_video in the config file")
+	}
+
+	s := &selftest{}
+	var client *youtube.Client
+	authErr := s.step("auth", nil, func() (string, error) {
+		var err error
+		if client, err = newClient(); err != nil {
+			return "", err
+		}
+		channelID, err := client.AuthenticatedChannelID()
+		if err != nil {
+			return "", err
+		}
+		return "channel " + channelID, nil
+	})
+
+	var src transcript.Source
+	metaErr := s.step("metadata", authErr, func() (string, error) {
+		details, err := client.GetVideoDetails(videoID)
+		if err != nil {
+			return "", err
+		}
+		src = transcript.Source{VideoID: videoID, Title: details.Title, ChannelID: details.ChannelID}
+		if published, err := time.Parse(time.RFC3339, details.PublishedAt); err == nil {
+			src.PublishedAt = published
+		}
+		return fmt.Sprintf("%q", details.Title), nil
+	})
+
+	var track youtube.CaptionTrack
+	listErr := s.step("captions", metaErr, func() (string, error) {
+		tracks, err := client.ListCaptions(videoID)
+		if err != nil {
+			return "", err
+		}
+		if len(tracks) == 0 {
+			return "", fmt.Errorf("%w for video %s", youtube.ErrNoCaptions, videoID)
+		}
+		track = youtube.PreferredCaption(tracks)
+		src.Language = track.Language
+		return fmt.Sprintf("%d track(s), using %s (%s)", len(tracks), track.Language, track.TrackKind), nil
+	})
+
+	var data []byte
+	downloadErr := s.step("download", listErr, func() (string, error) {
+		var err error
+		if data, err = client.DownloadCaptions(track, true); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d bytes", len(data)), nil
+	})
+
+	for _, f := range formats {
+		s.step("render "+string(f), downloadErr, func() (string, error) {
+			out, err := youtube.RenderTranscript(data, src, youtube.DownloadOptions{Format: f})
+			if err != nil {
+				return "", err
+			}
+			if len(out) == 0 {
+				return "", fmt.Errorf("rendered an empty transcript")
+			}
+			return fmt.Sprintf("%d bytes", len(out)), nil
+		})
+	}
+
+	if s.failed > 0 {
+		return fmt.Errorf("%d selftest step(s) failed", s.failed)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/metrics"
+	"github.com/n2p5/ytt/internal/server"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve downloaded transcripts over HTTP",
+	Long: `Serve the transcripts in the output directory as a read-only JSON API.
+
+Endpoints:
+  GET /api/videos
+  GET /api/videos/{id}
+  GET /api/videos/{id}/transcript
+  GET /api/videos/{id}/segments
+  GET /api/search?q=...&limit=...
+  GET /metrics
+
+With --graphql, the same data is also available at /graphql.
+
+/metrics serves Prometheus metrics: transcripts downloaded, failed videos
+by reason, and quota used, totaled across every ytt run that used the same
+data directory, along with the time of the last sync, the length of the
+resume queue, and the number of videos in the archive.`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+// serveShutdownTimeout is how long requests in progress get to finish once
+// the server is asked to stop.
+const serveShutdownTimeout = 10 * time.Second
+
+func init() {
+	serveCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	serveCmd.Flags().String("addr", "localhost:8090", "address to listen on")
+	serveCmd.Flags().Bool("graphql", false, "enable the GraphQL endpoint at /graphql")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	a, err := archive.Open(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	addr := viper.GetString("addr")
+	handler := server.New(a, server.Options{
+		GraphQL: viper.GetBool("graphql"),
+		Metrics: metrics.Handler(collectMetrics(a.Dir), metricsHelp),
+	})
+
+	srv := &http.Server{Addr: addr, Handler: handler}
+
+	// On SIGINT or SIGTERM, stop accepting connections and let requests in
+	// progress finish.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdown := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		fmt.Fprintln(stderr, "Shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+		defer cancel()
+		shutdown <- srv.Shutdown(ctx)
+	}()
+
+	fmt.Fprintf(stderr, "Serving %s on http://%s\n", a.Dir, addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error serving: %w", err)
+	}
+	if err := <-shutdown; err != nil {
+		return fmt.Errorf("error shutting down: %w", err)
+	}
+	return nil
+}
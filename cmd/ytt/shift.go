@@ -0,0 +1,140 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var shiftCmd = &cobra.Command{
+	Use:   "shift <file|video_id>",
+	Short: "Move or stretch caption timings to re-sync them with a video",
+	Long: `Adjust every cue's timing, to re-sync captions with a re-edited or
+re-encoded version of the video. Each time becomes time*scale + offset:
+--offset moves every cue earlier (negative) or later, and --scale stretches
+captions that drift further out of sync as the video goes on, such as 1.001
+for captions timed against 29.97 fps video shown at 30. Cues moved wholly
+before the start of the video are dropped.
+
+The argument is a caption file, or "-" for stdin, or otherwise a video ID,
+whose captions are downloaded fresh from YouTube. The shifted captions are
+written in the same format unless --format is given, to --out or stdout.
+--out may name the input file to shift it in place, and is needed with
+--json.`,
+	Example: `  ytt shift talk.srt --offset -1.5s --out talk.srt
+  ytt shift abc123 --offset 2s --scale 1.001 --format vtt --out abc123.vtt`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShift,
+}
+
+func init() {
+	shiftCmd.Flags().Duration("offset", 0, "time to move every cue by, such as -1.5s to show captions earlier")
+	shiftCmd.Flags().Float64("scale", 1, "factor to stretch cue times by before offsetting them, such as 1.001")
+	shiftCmd.Flags().String("format", "", "format to write: "+formatList+" (default: the input's)")
+	shiftCmd.Flags().String("out", "", "file to write to (default: stdout)")
+	shiftCmd.RegisterFlagCompletionFunc("format", completeFormats)
+
+	rootCmd.AddCommand(shiftCmd)
+}
+
+func runShift(cmd *cobra.Command, args []string) error {
+	in := args[0]
+	offset, scale := viper.GetDuration("offset"), viper.GetFloat64("scale")
+	if scale <= 0 {
+		return fmt.Errorf("--scale must be more than 0")
+	}
+	format := transcript.Format(viper.GetString("format"))
+	if format != "" && !knownFormat(format) {
+		return fmt.Errorf("unsupported format %q (want %s)", format, formatList)
+	}
+	out := viper.GetString("out")
+	if out == "" && jsonOutput() {
+		return fmt.Errorf("--json needs --out, as the shifted captions would also be written to stdout")
+	}
+
+	if dryRun() {
+		p := &plan{action: "shift"}
+		var calls []youtube.Method
+		if !isCaptionFile(in) {
+			calls = youtube.DownloadTranscriptCalls
+		}
+		p.add(calls, "%s by %v, scaled by %g -> %s", in, offset, scale, cmp.Or(out, "stdout"))
+		p.print()
+		return nil
+	}
+
+	src, data, err := readCaptions(in)
+	if err != nil {
+		return err
+	}
+	from := transcript.DetectFormat(data)
+	cues, err := transcript.ParseFormat(data, from)
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %w", in, err)
+	}
+	shiftedCues := transcript.Shift(cues, offset, scale)
+	shifted, err := cmp.Or(format, from).FormatSource(src, shiftedCues)
+	if err != nil {
+		return fmt.Errorf("error formatting %s: %w", in, err)
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(shifted)
+		return err
+	}
+	if err := os.WriteFile(out, shifted, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", out, err)
+	}
+	if jsonOutput() {
+		setResult(shiftResult{Input: in, Output: out, Format: string(cmp.Or(format, from)), Cues: len(shiftedCues)})
+		return nil
+	}
+	fmt.Fprintf(stderr, "Shifted %s to %s\n", in, out)
+	return nil
+}
+
+// shiftResult is the shifted captions under --json.
+type shiftResult struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+	Format string `json:"format"`
+	Cues   int    `json:"cues"`
+}
+
+// readCaptions reads caption data from a file, stdin for "-", or YouTube
+// when no file is named in, treating it as a video ID.
+func readCaptions(in string) (transcript.Source, []byte, error) {
+	if in == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return transcript.Source{}, nil, fmt.Errorf("error reading stdin: %w", err)
+		}
+		return transcript.Source{}, data, nil
+	}
+	if isCaptionFile(in) {
+		data, err := os.ReadFile(in)
+		if err != nil {
+			return transcript.Source{}, nil, fmt.Errorf("error reading %s: %w", in, err)
+		}
+		return transcript.Source{}, data, nil
+	}
+	client, err := newClient()
+	if err != nil {
+		return transcript.Source{}, nil, err
+	}
+	return client.FetchCaptions(context.Background(), in, false)
+}
+
+// isCaptionFile reports whether in names stdin or a file, rather than a
+// video.
+func isCaptionFile(in string) bool {
+	_, err := os.Stat(in)
+	return in == "-" || err == nil
+}
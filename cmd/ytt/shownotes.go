@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var shownotesCmd = &cobra.Command{
+	Use:   "shownotes <video_id>",
+	Short: "Write Markdown show notes for a video",
+	Long: `Write a Markdown show-notes page for a video, ready to paste into an
+episode page: the title, a link to the video, the chapters from its
+description with their timestamps, the links from its description, and
+the cleaned-up transcript below, each paragraph linked to its moment of the
+video.
+
+The description is fetched from YouTube, costing 1 quota unit, unless
+--description-file is given. The transcript is read from the output
+directory if it was downloaded there, and fetched from YouTube otherwise,
+then cleaned up with the --process steps.`,
+	Example: `  ytt shownotes abc123 > episode-42.md
+  ytt shownotes abc123 --description-file description.txt --out episode-42.md`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShownotes,
+}
+
+func init() {
+	shownotesCmd.Flags().StringP("output", "o", "outputs", "directory containing downloaded transcripts")
+	shownotesCmd.Flags().String("description-file", "", "read the video description from this file instead of YouTube")
+	shownotesCmd.Flags().String("process", "dedupe,paragraphs", "comma-separated processing steps to clean up the transcript with ("+strings.Join(transcript.ProcessorNames(), ", ")+")")
+	shownotesCmd.Flags().String("out", "", "file to write the show notes to (default: stdout)")
+	shownotesCmd.RegisterFlagCompletionFunc("process", completeProcessors)
+
+	rootCmd.AddCommand(shownotesCmd)
+}
+
+func runShownotes(cmd *cobra.Command, args []string) error {
+	videoID := args[0]
+	pipeline, err := transcript.ParsePipeline(viper.GetString("process"))
+	if err != nil {
+		return err
+	}
+	src, description, err := describeVideo(videoID)
+	if err != nil {
+		return err
+	}
+	cues, err := loadCues(videoID, viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	if cues, err = pipeline.Process(src, cues); err != nil {
+		return err
+	}
+
+	notes := transcript.ShowNotes{
+		Meta:     src.Metadata(),
+		Chapters: transcript.ParseChapters(description),
+		Links:    transcript.ExtractLinks(description),
+		Cues:     cues,
+	}
+	md := notes.Markdown()
+
+	out := viper.GetString("out")
+	if out != "" {
+		if err := os.WriteFile(out, md, 0644); err != nil {
+			return fmt.Errorf("error writing show notes: %w", err)
+		}
+	}
+	if jsonOutput() {
+		res := shownotesResult{VideoID: videoID, Title: src.Title, File: out, Links: notes.Links}
+		for _, ch := range notes.Chapters {
+			res.Chapters = append(res.Chapters, shownotesChapter{
+				Title:     ch.Title,
+				Timestamp: transcript.FormatTimestamp(ch.Start),
+				Start:     ch.Start.Seconds(),
+				URL:       transcript.WatchURL(videoID, ch.Start),
+			})
+		}
+		if out == "" {
+			res.Markdown = string(md)
+		}
+		setResult(res)
+		return nil
+	}
+	if out != "" {
+		fmt.Fprintf(stderr, "Wrote show notes for %s to %s\n", videoID, out)
+		return nil
+	}
+	_, err = os.Stdout.Write(md)
+	return err
+}
+
+// shownotesResult is the show notes under --json. Markdown holds the
+// document unless it was written to --out.
+type shownotesResult struct {
+	VideoID  string             `json:"video_id"`
+	Title    string             `json:"title,omitempty"`
+	Chapters []shownotesChapter `json:"chapters"`
+	Links    []string           `json:"links"`
+	File     string             `json:"file,omitempty"`
+	Markdown string             `json:"markdown,omitempty"`
+}
+
+type shownotesChapter struct {
+	Title     string  `json:"title"`
+	Timestamp string  `json:"timestamp"`
+	Start     float64 `json:"start"`
+	URL       string  `json:"url"`
+}
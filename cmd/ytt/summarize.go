@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/trash"
+	"github.com/spf13/viper"
+)
+
+// summaryPath returns where a video's summary is saved.
+func summaryPath(outputDir, videoID string) string {
+	return filepath.Join(outputDir, videoID+"-summary.md")
+}
+
+// summarize pipes the text of the transcript at path through
+// --summarize-cmd and saves the command's output as the video's summary.
+// A summary at least as new as the transcript is left alone. It returns
+// the summary's path, or "" if the video has none.
+func summarize(outputDir string, src transcript.Source, path string) (string, error) {
+	out := summaryPath(outputDir, src.VideoID)
+	command := viper.GetString("summarize-cmd")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading transcript: %w", err)
+	}
+	if summary, err := os.Stat(out); err == nil && (command == "" || !summary.ModTime().Before(info.ModTime())) {
+		return out, nil
+	}
+	if command == "" {
+		return "", nil
+	}
+
+	text, err := summaryInput(src, path)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(stderr, "Summarizing %s\n", src.VideoID)
+	var summary bytes.Buffer
+	cmd := shellCommand(command)
+	cmd.Stdin = bytes.NewReader(text)
+	cmd.Stdout = &summary
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error summarizing %s: %w", src.VideoID, err)
+	}
+	if len(bytes.TrimSpace(summary.Bytes())) == 0 {
+		return "", fmt.Errorf("error summarizing %s: --summarize-cmd printed nothing", src.VideoID)
+	}
+
+	if _, err := trash.Move(outputDir, out); err != nil {
+		return "", err
+	}
+	data := append(src.Metadata().FrontMatter(), '\n')
+	data = append(data, summary.Bytes()...)
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		return "", fmt.Errorf("error writing summary: %w", err)
+	}
+	return out, nil
+}
+
+// summaryInput returns a transcript's text as it is given to the summary
+// command: without timings, repeated lines from rolling captions, or line
+// breaks within paragraphs.
+func summaryInput(src transcript.Source, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading transcript: %w", err)
+	}
+	cues, err := transcript.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing transcript: %w", err)
+	}
+	clean := transcript.Pipeline{transcript.ProcessorFunc(transcript.Dedupe), transcript.Paragraphs(transcript.DefaultParagraphGap)}
+	if cues, err = clean.Process(src, cues); err != nil {
+		return nil, err
+	}
+	return transcript.FormatPlain.Format(cues)
+}
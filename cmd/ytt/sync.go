@@ -0,0 +1,568 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/n2p5/ytt/internal/history"
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/objstore"
+	"github.com/n2p5/ytt/internal/stats"
+	"github.com/n2p5/ytt/internal/textdiff"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/trash"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Download transcripts for every channel video not yet in the output directory",
+	Long: `List the channel's videos and download transcripts for those the output
+directory's manifest doesn't have yet. Shorts are skipped unless
+--include-shorts or --only-shorts is given; live streams are included unless
+--exclude-live is given.
+
+Each run also compares every video's title, description, and tags with the
+previous run and appends changes to the video's history, shown by
+"ytt history <video_id>", and evaluates any alert rules from the config file.
+
+With --update, transcripts already downloaded are checked against YouTube
+too, and replaced if their captions changed. A transcript edited locally
+since ytt saved it is a conflict, resolved as --on-conflict says: prompt for
+each one, keep the local file, replace it, or merge the two with
+Git-style conflict markers around the lines that differ. Without a terminal
+to prompt on, local files are kept. Replaced files are moved to the trash.
+Transcripts saved with a different --format or --process than the one given
+are not checked; run "ytt refresh" first.
+
+With --group, every channel of a group defined under "groups" in the config
+file is synced, each into a subdirectory of the output directory named after
+its handle or channel ID. With --subscriptions, every channel the
+authenticated user subscribes to is synced the same way, into
+subdirectories named after their channel IDs; list channels under
+subscriptions.include in the config file to sync only those, or under
+subscriptions.exclude to leave them out.
+
+With --feed, or a channel given as its feed URL
+(https://www.youtube.com/feeds/videos.xml?channel_id=...), only the
+channel's 15 latest uploads are listed, from its feed, which costs no
+quota; the API is only asked about videos not yet downloaded. Metadata
+history and --record-stats then cover just those videos. Sync without
+--feed now and then to catch anything older the feed has moved past.`,
+	Args: cobra.NoArgs,
+	RunE: runSync,
+}
+
+func init() {
+	syncCmd.Flags().String("channel", "", "channel ID, @handle, or URL (default: authenticated user's channel)")
+	syncCmd.RegisterFlagCompletionFunc("channel", completeChannelIDs)
+	syncCmd.Flags().String("group", "", "sync every channel of this group from the config file, each into its own subdirectory of the output directory")
+	syncCmd.RegisterFlagCompletionFunc("group", completeGroups)
+	syncCmd.Flags().Bool("subscriptions", false, "sync every channel you subscribe to, each into its own subdirectory of the output directory")
+	syncCmd.MarkFlagsMutuallyExclusive("channel", "group", "subscriptions")
+	syncCmd.Flags().StringP("output", "o", "outputs", "directory, or s3:// or gs:// URL, to save transcripts in")
+	syncCmd.Flags().IntP("workers", "w", 1, "number of videos to download concurrently")
+	syncCmd.Flags().String("archive", "", "save transcripts, with an index.json manifest, in this .tar.gz or .zip file instead of the output directory")
+	addContentFilterFlags(syncCmd)
+	addDownloadFlags(syncCmd)
+	syncCmd.Flags().Bool("feed", false, "list only the channel's latest uploads, from its feed, which costs no quota")
+	syncCmd.Flags().Bool("update", false, "also re-download transcripts whose captions changed on YouTube")
+	syncCmd.Flags().String("on-conflict", conflictPrompt, "what --update does with locally edited transcripts: prompt, keep, replace, or merge")
+	syncCmd.RegisterFlagCompletionFunc("on-conflict", cobra.FixedCompletions([]string{conflictPrompt, conflictKeep, conflictReplace, conflictMerge}, cobra.ShellCompDirectiveNoFileComp))
+	syncCmd.Flags().Bool("git-commit", false, "commit the changed transcripts when the output directory is in a Git repository")
+	syncCmd.Flags().String("manifest", "", "write a run manifest of this batch, with each video's result and file hash, to this path")
+	syncCmd.Flags().Bool("record-stats", false, "record view, like, and comment counts for \"ytt growth\"")
+
+	rootCmd.AddCommand(syncCmd)
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	channelID, _ := cmd.Flags().GetString("channel")
+	outputDir := outputLocation()
+
+	dlOpts, err := downloadOptions()
+	if err != nil {
+		return err
+	}
+	update := viper.GetBool("update")
+	policy := viper.GetString("on-conflict")
+	switch policy {
+	case conflictPrompt, conflictKeep, conflictReplace, conflictMerge:
+	default:
+		return fmt.Errorf("invalid --on-conflict %q (want prompt, keep, replace, or merge)", policy)
+	}
+	if policy == conflictPrompt && update && !dryRun() && !interactive() {
+		fmt.Fprintln(stderr, "Not running in a terminal; locally edited transcripts will be kept.")
+		policy = conflictKeep
+	}
+
+	group, _ := cmd.Flags().GetString("group")
+	subscriptions, _ := cmd.Flags().GetBool("subscriptions")
+	if (group != "" || subscriptions) && isArchive(outputDir) {
+		return fmt.Errorf("--group and --subscriptions can't be used with --archive")
+	}
+	var channels []string
+	if group != "" {
+		channels = viper.GetStringSlice("groups." + group)
+		if len(channels) == 0 {
+			return fmt.Errorf("no channels in group %q; define it under \"groups\" in the config file", group)
+		}
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	if group != "" {
+		return syncChannels(client, "group "+group, channels, outputDir, dlOpts, update, policy)
+	}
+	if subscriptions {
+		if channels, err = subscribedChannels(client); err != nil {
+			return err
+		}
+		if len(channels) == 0 {
+			fmt.Fprintln(stderr, "No subscriptions to sync.")
+			return nil
+		}
+		return syncChannels(client, "subscriptions", channels, outputDir, dlOpts, update, policy)
+	}
+	feed := viper.GetBool("feed") || youtube.IsFeedURL(channelID)
+	if channelID, err = resolveChannel(client, channelID); err != nil {
+		return err
+	}
+	beginRun("channel "+channelID, outputDir)
+	defer endRun()
+	return syncChannel(client, channelID, outputDir, dlOpts, update, policy, feed)
+}
+
+// syncChannels syncs each of several channels, such as a group's, into its
+// own subdirectory of outputDir, carrying on past channels that fail, but
+// stopping once the quota runs out or the run is interrupted. name says what
+// the channels are, for the run manifest and errors.
+func syncChannels(client *youtube.Client, name string, channels []string, outputDir string, dlOpts youtube.DownloadOptions, update bool, policy string) error {
+	beginRun(name, outputDir)
+	defer endRun()
+
+	var failed int
+	for i, channel := range channels {
+		if interrupted.Load() {
+			return fmt.Errorf("%w before syncing %d of %d channels in %s", &interruptError{}, len(channels)-i, len(channels), name)
+		}
+		fmt.Fprintf(stderr, "Syncing %s\n", channel)
+		if err := syncGroupChannel(client, channel, outputDir, dlOpts, update, policy); err != nil {
+			// The channel's unfinished videos are saved for resume, and
+			// syncing the next would replace them.
+			if code := exitCode(err); code == exitQuotaExceeded || code == exitInterrupted {
+				return err
+			}
+			warn(fmt.Errorf("error syncing %s: %w", channel, err))
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d channels in %s failed", failed, len(channels), name)
+	}
+	return nil
+}
+
+func syncGroupChannel(client *youtube.Client, channel, outputDir string, dlOpts youtube.DownloadOptions, update bool, policy string) error {
+	channelID, err := client.ResolveChannel(channel)
+	if err != nil {
+		return err
+	}
+	dir, err := groupSubdir(channel, channelID)
+	if err != nil {
+		return err
+	}
+	feed := viper.GetBool("feed") || youtube.IsFeedURL(channel)
+	return syncChannel(client, channelID, outputPath(outputDir, dir), dlOpts, update, policy, feed)
+}
+
+// subscribedChannels returns the IDs of the channels the authenticated user
+// subscribes to, narrowed by subscriptions.include and
+// subscriptions.exclude in the config file.
+func subscribedChannels(client *youtube.Client) ([]string, error) {
+	subs, err := client.Subscriptions()
+	if err != nil {
+		return nil, err
+	}
+	resolve := func(key string) (map[string]bool, error) {
+		ids := map[string]bool{}
+		for _, channel := range viper.GetStringSlice(key) {
+			id, err := client.ResolveChannel(channel)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			ids[id] = true
+		}
+		return ids, nil
+	}
+	include, err := resolve("subscriptions.include")
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := resolve("subscriptions.exclude")
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []string
+	for _, s := range subs {
+		if (len(include) > 0 && !include[s.ChannelID]) || exclude[s.ChannelID] {
+			continue
+		}
+		channels = append(channels, s.ChannelID)
+	}
+	fmt.Fprintf(stderr, "Syncing %d of %d subscriptions\n", len(channels), len(subs))
+	return channels, nil
+}
+
+// groupSubdir names the subdirectory a group's channel is synced into: its
+// handle or username as written in the config, or else its channel ID.
+func groupSubdir(channel, channelID string) (string, error) {
+	ref, err := youtube.ParseChannelRef(channel)
+	if err != nil {
+		return "", err
+	}
+	name := channelID
+	switch {
+	case ref.Handle != "":
+		name = strings.TrimPrefix(ref.Handle, "@")
+	case ref.Username != "":
+		name = ref.Username
+	}
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("can't name a directory after channel %q", channel)
+	}
+	return name, nil
+}
+
+// syncChannel syncs one channel's videos into outputDir, listing only its
+// latest uploads, from its feed, if feed is set.
+func syncChannel(client *youtube.Client, channelID, outputDir string, dlOpts youtube.DownloadOptions, update bool, policy string, feed bool) error {
+	historyDir := filepath.Join(viper.GetString("data_dir"), "history")
+
+	var err error
+	var store objstore.Store
+	var index string
+	var m *manifest.Manifest
+	finish := func(bool) error { return nil }
+	if localOutput(outputDir) {
+		m, err = manifest.Load(outputDir)
+	} else {
+		store, index, m, finish, err = openStore(context.Background(), outputDir)
+	}
+	if err != nil {
+		return err
+	}
+	finished := false
+	defer func() {
+		if !finished {
+			finish(false)
+		}
+	}()
+
+	opts := listOptions()
+	opts.IncludeDescription = true
+	opts.IncludeTags = true
+	now := time.Now()
+
+	recordStats := viper.GetBool("record-stats") && !dryRun()
+	var samples []stats.Sample
+
+	videos := client.Videos(context.Background(), channelID, opts)
+	if feed {
+		// Videos already downloaded, and not to be checked for updates,
+		// aren't looked up.
+		done := func(videoID string) bool {
+			e, ok := m.Entries[videoID]
+			return ok && e.Status == manifest.StatusOK && (!update || e.Imported != "")
+		}
+		videos = client.FeedVideos(context.Background(), channelID, done, opts)
+	}
+
+	var listed, pending []youtube.VideoInfo
+	existing := map[string]manifest.Entry{}
+	var mismatched int
+	for v, err := range videos {
+		if err != nil {
+			return err
+		}
+		listed = append(listed, v)
+
+		if !dryRun() {
+			meta := history.Metadata{Title: v.Title, Description: v.Description, Tags: v.Tags}
+			changed, err := history.Record(historyDir, v.VideoID, meta, now)
+			if err != nil {
+				warn(err)
+			} else if len(changed) > 0 {
+				fmt.Fprintf(stderr, "Metadata changed for %s: %s\n", v.VideoID, strings.Join(changed, ", "))
+			}
+		}
+
+		if recordStats {
+			samples = append(samples, stats.Sample{
+				Time:         now.UTC(),
+				VideoID:      v.VideoID,
+				ViewCount:    v.ViewCount,
+				LikeCount:    v.LikeCount,
+				CommentCount: v.CommentCount,
+			})
+		}
+
+		if e, ok := m.Entries[v.VideoID]; ok && e.Status == manifest.StatusOK {
+			// Imported transcripts stand in for YouTube's captions.
+			if !update || e.Imported != "" {
+				continue
+			}
+			if e.Format != string(dlOpts.Format) || e.Process != processSpec() {
+				mismatched++
+				continue
+			}
+			existing[v.VideoID] = e
+		}
+		pending = append(pending, v)
+	}
+	if mismatched > 0 {
+		warn(fmt.Errorf("not checking %d transcripts saved with a different --format or --process; run \"ytt refresh\" first", mismatched))
+	}
+
+	if err := stats.Append(filepath.Join(viper.GetString("data_dir"), "stats"), channelID, samples); err != nil {
+		warn(err)
+	}
+	if !dryRun() {
+		if err := runAlerts(channelID, listed, now); err != nil {
+			warn(err)
+		}
+	}
+
+	if len(pending) == 0 {
+		fmt.Fprintln(stderr, "Everything is up to date.")
+		return nil
+	}
+
+	if dryRun() {
+		p := &plan{action: "download transcripts for"}
+		for _, v := range pending {
+			path := outputPath(outputDir, youtube.TranscriptFilename(v.VideoID, v.Title, dlOpts.Format))
+			if _, ok := existing[v.VideoID]; ok {
+				p.add(youtube.DownloadTranscriptCalls, "%s  %s  (update if changed)", v.VideoID, path)
+			} else {
+				p.add(youtube.DownloadTranscriptCalls, "%s  %s", v.VideoID, path)
+			}
+		}
+		p.print()
+		return nil
+	}
+
+	ids := make([]string, len(pending))
+	for i, v := range pending {
+		ids[i] = v.VideoID
+	}
+	if store != nil {
+		fmt.Fprintf(stderr, "Saving %d new transcripts to %s\n", len(ids), outputDir)
+		err := uploadTranscripts(client, ids, outputDir, store, index, m, dlOpts)
+		finished = true
+		if ferr := finish(true); err == nil {
+			err = ferr
+		}
+		return err
+	}
+	if update {
+		fmt.Fprintf(stderr, "Checking %d existing and downloading %d new transcripts\n", len(existing), len(ids)-len(existing))
+	} else {
+		fmt.Fprintf(stderr, "Downloading %d new transcripts\n", len(ids))
+	}
+	var added, updated, unchanged atomic.Int64
+	err = saveTranscripts(ids, outputDir, dlOpts, "sync", "synced", func(videoID string) (*youtube.DownloadResult, error) {
+		e, ok := existing[videoID]
+		if !ok {
+			res, err := client.DownloadTranscript(videoID, outputDir, dlOpts)
+			if err == nil {
+				added.Add(1)
+			}
+			return res, err
+		}
+		res, err := updateTranscript(client, outputDir, e, dlOpts, policy)
+		switch {
+		case err != nil:
+		case res == nil || res.Unchanged:
+			unchanged.Add(1)
+		default:
+			updated.Add(1)
+		}
+		return res, err
+	})
+	if update {
+		fmt.Fprintf(stderr, "%d updated, %d unchanged\n", updated.Load(), unchanged.Load())
+	}
+	commitOutput(outputDir, fmt.Sprintf("sync: %d new, %d updated from channel %s", added.Load(), updated.Load(), channelID))
+	return err
+}
+
+// Values for --on-conflict.
+const (
+	conflictPrompt  = "prompt"
+	conflictKeep    = "keep"
+	conflictReplace = "replace"
+	conflictMerge   = "merge"
+)
+
+// updateTranscript saves the captions for a transcript already in the
+// manifest if they changed since e was recorded, resolving local edits to
+// the transcript by policy. Captions are only downloaded if the track's
+// lastUpdated time changed, and only saved if their content did. It returns
+// nil if the track is unchanged, and a result marked Unchanged if only the
+// manifest entry needs updating.
+func updateTranscript(client *youtube.Client, outputDir string, e manifest.Entry, opts youtube.DownloadOptions, policy string) (*youtube.DownloadResult, error) {
+	src, track, err := client.FindCaptions(e.VideoID)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(outputDir, filepath.FromSlash(e.File))
+	updatedAt := track.UpdatedAt()
+	if !updatedAt.IsZero() && updatedAt.Equal(e.CaptionUpdatedAt) {
+		if _, err := os.Stat(path); err == nil {
+			return nil, nil
+		}
+	}
+
+	data, err := client.DownloadCaptions(track, opts.CaptionsAsVTT())
+	if err != nil {
+		return nil, err
+	}
+	sourceHash := manifest.Hash(data)
+	if sourceHash == e.SourceHash {
+		if updatedAt.Equal(e.CaptionUpdatedAt) {
+			return nil, nil
+		}
+		// Remember the new lastUpdated time so the next sync can skip
+		// the download.
+		e.CaptionID, e.CaptionUpdatedAt = track.CaptionID, updatedAt
+		return entryResult(outputDir, e), nil
+	}
+	e.CaptionID, e.CaptionUpdatedAt = track.CaptionID, updatedAt
+
+	rendered, err := youtube.RenderTranscript(data, src, opts)
+	if err != nil {
+		return nil, err
+	}
+	local, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error reading transcript: %w", err)
+	}
+
+	// Entries recorded before hashes were kept can't tell an edit from an
+	// older caption track, so any difference counts as an edit.
+	edited := local != nil && !bytes.Equal(local, rendered) &&
+		(e.Hash == "" || manifest.Hash(local) != e.Hash)
+	if !edited {
+		if local != nil && bytes.Equal(local, rendered) {
+			// The captions changed in a way that doesn't show in the
+			// transcript, such as styling dropped by conversion.
+			e.Hash, e.SourceHash = manifest.Hash(local), sourceHash
+			return entryResult(outputDir, e), nil
+		}
+		return saveUpdate(outputDir, e, src, data, opts)
+	}
+
+	if policy == conflictPrompt {
+		policy = promptConflict(e, local, rendered, opts.Format.Binary())
+	}
+	if policy == conflictMerge && opts.Format.Binary() {
+		warn(fmt.Errorf("can't merge %s files; keeping local edits to %s", opts.Format, path))
+		policy = conflictKeep
+	}
+	switch policy {
+	case conflictReplace:
+		return saveUpdate(outputDir, e, src, data, opts)
+	case conflictMerge:
+		res, err := saveUpdate(outputDir, e, src, data, opts)
+		if err != nil {
+			return nil, err
+		}
+		merged, conflicts := textdiff.Merge(textdiff.SplitLines(string(local)), textdiff.SplitLines(string(rendered)), "local", "youtube")
+		// The merged lines, conflict markers included, end as --newline
+		// says, like the rest of the transcript.
+		out := transcript.Normalize([]byte(strings.Join(merged, "\n")+"\n"), opts.Newline)
+		if err := os.WriteFile(res.Path, out, 0644); err != nil {
+			return nil, fmt.Errorf("error writing transcript: %w", err)
+		}
+		// res.Hash stays that of the downloaded transcript, so the merged
+		// file counts as locally edited from now on.
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%d conflicts to resolve in %s", conflicts, res.Path))
+		return res, nil
+	default:
+		fmt.Fprintf(stderr, "Kept local edits to %s\n", path)
+		e.SourceHash = sourceHash
+		return entryResult(outputDir, e), nil
+	}
+}
+
+// saveUpdate saves updated captions for e, trashing e's file if the
+// transcript's name changed with its title.
+func saveUpdate(outputDir string, e manifest.Entry, src transcript.Source, data []byte, opts youtube.DownloadOptions) (*youtube.DownloadResult, error) {
+	res, err := youtube.SaveCaptions(outputDir, src, data, opts)
+	if err != nil {
+		return nil, err
+	}
+	res.CaptionID, res.CaptionUpdatedAt = e.CaptionID, e.CaptionUpdatedAt
+	if relOutputPath(outputDir, res.Path) != e.File {
+		if _, err := trash.Move(outputDir, filepath.Join(outputDir, filepath.FromSlash(e.File))); err != nil {
+			warn(err)
+		}
+	}
+	return res, nil
+}
+
+// entryResult describes e's existing files as a download result, for
+// recording it again unchanged but for its hashes and caption track time.
+func entryResult(outputDir string, e manifest.Entry) *youtube.DownloadResult {
+	res := &youtube.DownloadResult{
+		VideoID:          e.VideoID,
+		Title:            e.Title,
+		ChannelID:        e.ChannelID,
+		PublishedAt:      e.PublishedAt,
+		Language:         e.Language,
+		Path:             filepath.Join(outputDir, filepath.FromSlash(e.File)),
+		Hash:             e.Hash,
+		SourceHash:       e.SourceHash,
+		CaptionID:        e.CaptionID,
+		CaptionUpdatedAt: e.CaptionUpdatedAt,
+		Imported:         e.Imported,
+		DetectedLanguage: e.DetectedLanguage,
+		Unchanged:        true,
+	}
+	if e.Raw != "" {
+		res.RawPath = filepath.Join(outputDir, filepath.FromSlash(e.Raw))
+	}
+	return res
+}
+
+// promptConflict shows how a locally edited transcript differs from the
+// updated captions and asks what to do with it. Binary files can only be
+// kept or replaced.
+func promptConflict(e manifest.Entry, local, updated []byte, binary bool) string {
+	if binary {
+		return choose(fmt.Sprintf("\n%s was edited locally and its captions changed on YouTube. Keep or replace?", e.File), conflictKeep, conflictReplace)
+	}
+	diff := textdiff.Unified(textdiff.Lines(textdiff.SplitLines(string(local)), textdiff.SplitLines(string(updated))), 1)
+	lines := strings.SplitAfter(diff, "\n")
+	if len(lines) > maxConflictPreview {
+		diff = strings.Join(lines[:maxConflictPreview], "") + fmt.Sprintf("... %d more lines\n", len(lines)-maxConflictPreview)
+	}
+	question := fmt.Sprintf("\n%s was edited locally and its captions changed on YouTube (- local, + YouTube):\n%s\nKeep, replace, or merge?", e.File, diff)
+	return choose(question, conflictKeep, conflictReplace, conflictMerge)
+}
+
+// maxConflictPreview is how many diff lines promptConflict shows.
+const maxConflictPreview = 20
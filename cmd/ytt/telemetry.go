@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/n2p5/ytt/internal/metrics"
+	"github.com/n2p5/ytt/internal/telemetry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// tel exports traces and metrics to the collector given by --otel-endpoint
+// or OTEL_EXPORTER_OTLP_ENDPOINT, or is nil if there is none. commandSpan
+// covers the running command; API calls and videos are traced under it.
+var (
+	tel         *telemetry.Exporter
+	commandSpan *telemetry.Span
+)
+
+// startTelemetry starts exporting telemetry for cmd if a collector is
+// configured.
+func startTelemetry(cmd *cobra.Command) error {
+	cfg, err := telemetry.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if endpoint := viper.GetString("otel-endpoint"); endpoint != "" {
+		cfg.Endpoint = endpoint
+	}
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	cfg.ServiceVersion = version()
+	if tel, err = telemetry.New(cfg); err != nil {
+		return err
+	}
+	commandSpan = tel.Start(nil, cmd.CommandPath(), telemetry.Bool("ytt.dry_run", dryRun()))
+	return nil
+}
+
+// stopTelemetry ends the command's span, failed with err if it isn't nil,
+// and sends what was recorded to the collector.
+func stopTelemetry(err error) {
+	if tel == nil {
+		return
+	}
+	commandSpan.End(err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tel.Flush(ctx); err != nil {
+		warn(err)
+	}
+}
+
+// traceVideo starts the span for one video of a batch job.
+func traceVideo(job, videoID string) *telemetry.Span {
+	return tel.Start(commandSpan, job+" video", telemetry.String("ytt.job", job), telemetry.String("ytt.video_id", videoID))
+}
+
+// countVideo counts a video of a batch job as done or failed.
+func countVideo(job string, err error) {
+	status := "ok"
+	if errors.Is(err, errJobCanceled) {
+		status = "skipped"
+	} else if err != nil {
+		status = "failed"
+		countMetric(metrics.Series("ytt_api_errors_total", "reason", errorReason(err)), 1)
+	}
+	tel.Count("ytt.videos", "{video}", 1, telemetry.String("ytt.job", job), telemetry.String("ytt.status", status))
+}
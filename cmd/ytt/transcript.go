@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/batch"
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/metrics"
+	"github.com/n2p5/ytt/internal/progress"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/trash"
+	"github.com/n2p5/ytt/internal/youtube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var transcriptCmd = &cobra.Command{
+	Use:   "transcript [video_id]...",
+	Short: "Download transcripts for one or more videos",
+	Long: `Download the transcript for each video into the output directory.
+
+Files are named {video_id}-{title}.txt, or take the extension of the format
+given with --format. A failure or crash on one video is
+reported at the end and does not stop the others.
+
+Besides videos given by ID, --liked downloads the videos you've liked, and
+--playlist a playlist's videos, including your private playlists. Listing
+them costs 1 quota unit per 50 videos. --watch-later is accepted, but the
+YouTube API has listed the Watch Later playlist as empty since 2016; save
+the videos to another playlist and use --playlist instead.`,
+	Example: `  ytt transcript abc123 def456
+  ytt transcript --liked --output liked/
+  ytt transcript --playlist PLxxxxxxxx`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runTranscript,
+}
+
+func init() {
+	transcriptCmd.Flags().StringP("output", "o", "outputs", "directory, or s3:// or gs:// URL, to save transcripts in")
+	transcriptCmd.Flags().IntP("workers", "w", 1, "number of videos to download concurrently")
+	transcriptCmd.Flags().String("archive", "", "save transcripts, with an index.json manifest, in this .tar.gz or .zip file instead of the output directory")
+	transcriptCmd.Flags().Bool("git-commit", false, "commit the changed transcripts when the output directory is in a Git repository")
+	transcriptCmd.Flags().String("manifest", "", "write a run manifest of this batch, with each video's result and file hash, to this path")
+	transcriptCmd.Flags().Bool("liked", false, "also download the videos you've liked")
+	transcriptCmd.Flags().Bool("watch-later", false, "also download your Watch Later playlist, if the API lists it")
+	transcriptCmd.Flags().StringSlice("playlist", nil, "also download this playlist's videos (repeatable)")
+	// Playlist IDs aren't cached anywhere to offer, but they're never files.
+	transcriptCmd.RegisterFlagCompletionFunc("playlist", cobra.NoFileCompletions)
+	addDownloadFlags(transcriptCmd)
+
+	rootCmd.AddCommand(transcriptCmd)
+}
+
+func runTranscript(cmd *cobra.Command, args []string) error {
+	liked, watchLater, playlists := viper.GetBool("liked"), viper.GetBool("watch-later"), viper.GetStringSlice("playlist")
+	if len(args) == 0 && !liked && !watchLater && len(playlists) == 0 {
+		return fmt.Errorf("give video IDs, --liked, --watch-later, or --playlist")
+	}
+	opts, err := downloadOptions()
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	// What the batch is, for the run manifest and commit message.
+	var sources []string
+	if len(args) > 0 {
+		sources = append(sources, "videos "+strings.Join(args, ","))
+	}
+	if watchLater {
+		playlists = append(playlists, youtube.WatchLaterPlaylist)
+	}
+	for _, p := range playlists {
+		ids, err := client.PlaylistVideoIDs(context.Background(), p)
+		if errors.Is(err, youtube.ErrWatchLater) {
+			warn(err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		args = append(args, ids...)
+		sources = append(sources, "playlist "+p)
+	}
+	if liked {
+		ids, err := client.LikedVideoIDs(context.Background())
+		if err != nil {
+			return err
+		}
+		args = append(args, ids...)
+		sources = append(sources, "liked videos")
+	}
+	args = uniqueIDs(args)
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "No videos to download.")
+		return nil
+	}
+
+	location := outputLocation()
+	beginRun(strings.Join(sources, ", "), location)
+	defer endRun()
+	if dryRun() {
+		return planTranscripts(client, args, location, opts.Format)
+	}
+
+	if !localOutput(location) {
+		store, index, m, finish, err := openStore(context.Background(), location)
+		if err != nil {
+			return err
+		}
+		err = uploadTranscripts(client, args, location, store, index, m, opts)
+		if ferr := finish(true); err == nil {
+			err = ferr
+		}
+		return err
+	}
+	err = downloadTranscripts(client, args, location, opts)
+	commitOutput(location, "transcript: "+strings.Join(args, ", "))
+	return err
+}
+
+// uniqueIDs returns ids without repeats, in order.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	var out []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// downloadTranscripts downloads each video's transcript into outputDir
+// through the batch worker pool and records the results in the manifest.
+func downloadTranscripts(client *youtube.Client, videoIDs []string, outputDir string, opts youtube.DownloadOptions) error {
+	return saveTranscripts(videoIDs, outputDir, opts, "download", "downloaded", func(videoID string) (*youtube.DownloadResult, error) {
+		return client.DownloadTranscript(videoID, outputDir, opts)
+	})
+}
+
+// saveTranscripts runs save for each video through the batch worker pool and
+// records the results, saved with opts, in the manifest. save may return a
+// nil result to leave a video's entry as it is. job names the work in
+// progress events and the job queue, and verb describes a success in the
+// summary. Videos run in the job queue's order, and those whose jobs were
+// canceled with "ytt jobs cancel" are skipped.
+func saveTranscripts(videoIDs []string, outputDir string, opts youtube.DownloadOptions, job, verb string, save func(videoID string) (*youtube.DownloadResult, error)) error {
+	if _, err := trash.Purge(outputDir, viper.GetDuration("trash_retention")); err != nil {
+		warn(err)
+	}
+
+	mw, err := manifest.OpenWriter(outputDir, manifest.WriterOptions{})
+	if err != nil {
+		return err
+	}
+
+	tracker := trackJobs(job, outputDir, videoIDs)
+	results := runBatch(job, tracker.order(videoIDs), func(ctx context.Context, videoID string) (err error) {
+		if !tracker.start(videoID) {
+			return errJobCanceled
+		}
+		defer func() { tracker.finish(videoID, err) }()
+		res, err := save(videoID)
+		if err != nil {
+			e := manifest.Entry{VideoID: videoID, Status: manifest.StatusFailed, Error: err.Error()}
+			mw.Record(e)
+			currentRun.record(e)
+			return err
+		}
+		if res == nil {
+			// Nothing needed saving.
+			return nil
+		}
+		if res.Imported == "" {
+			countMetric(metrics.Series("ytt_transcripts_downloaded_total", "job", job), 1)
+		}
+		for _, w := range res.Warnings {
+			warn(errors.New(w))
+		}
+		e := manifest.Entry{
+			VideoID:          videoID,
+			Title:            res.Title,
+			ChannelID:        res.ChannelID,
+			PublishedAt:      res.PublishedAt,
+			Language:         res.Language,
+			DetectedLanguage: res.DetectedLanguage,
+			File:             relOutputPath(outputDir, res.Path),
+			Format:           string(opts.Format),
+			Process:          processSpec(),
+			Hash:             res.Hash,
+			SourceHash:       res.SourceHash,
+			CaptionID:        res.CaptionID,
+			CaptionUpdatedAt: res.CaptionUpdatedAt,
+			Imported:         res.Imported,
+			Status:           manifest.StatusOK,
+		}
+		if res.RawPath != "" {
+			e.Raw = relOutputPath(outputDir, res.RawPath)
+		}
+		src := transcript.Source{VideoID: videoID, Title: res.Title, ChannelID: res.ChannelID, PublishedAt: res.PublishedAt, Language: res.Language}
+		if summary, err := summarize(outputDir, src, res.Path); err != nil {
+			warn(err)
+		} else if summary != "" {
+			e.Summary = relOutputPath(outputDir, summary)
+		}
+		if audio, err := saveAudio(ctx, outputDir, videoID); err != nil {
+			warn(err)
+		} else if audio != "" {
+			e.Audio = relOutputPath(outputDir, audio)
+		}
+		if !res.Unchanged {
+			if err := saveRevision(outputDir, e); err != nil {
+				warn(err)
+			}
+			if err := runPostHook(res); err != nil {
+				warn(err)
+			}
+		}
+		mw.Record(e)
+		currentRun.record(e)
+		return nil
+	})
+
+	tracker.close()
+	if err := mw.Close(); err != nil {
+		warn(err)
+	}
+	return reportBatch(job, outputDir, results, verb)
+}
+
+// errJobCanceled is the result of a video skipped because its job was
+// canceled. It counts as neither a success nor a failure.
+var errJobCanceled = errors.New("job canceled")
+
+// reportBatch prints the failures in a batch of transcripts and a summary
+// of the whole batch, in which verb describes a success. It returns an error
+// if any transcript failed. If the batch stopped because the daily quota ran
+// out or it was interrupted, the videos it didn't finish are saved for
+// "ytt resume" under job, to be saved into location.
+func reportBatch(job, location string, results []batch.Result, verb string) error {
+	var failed []batch.Result
+	var remaining []string
+	skipped := 0
+	quota := false
+	for _, r := range batch.Failed(results) {
+		if errors.Is(r.Err, errJobCanceled) {
+			skipped++
+			continue
+		}
+		if youtube.IsQuotaExceeded(r.Err) || errors.Is(r.Err, context.Canceled) {
+			quota = quota || youtube.IsQuotaExceeded(r.Err)
+			remaining = append(remaining, r.ID)
+			continue
+		}
+		failed = append(failed, r)
+		fmt.Fprintf(stderr, "Failed %s: %v\n", r.ID, r.Err)
+	}
+	succeeded := len(results) - len(failed) - len(remaining) - skipped
+	s := runSummary{Job: job, Verb: verb, Total: len(results), Succeeded: succeeded, Failed: len(failed), Skipped: skipped, Remaining: len(remaining)}
+	for _, r := range failed {
+		s.Failures = append(s.Failures, fmt.Sprintf("%s: %v", r.ID, r.Err))
+	}
+	summarizeBatch(s)
+	if len(results) > 1 {
+		fmt.Fprintf(stderr, "%d of %d transcripts %s\n", succeeded, len(results), verb)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(stderr, "Skipped %d canceled job(s)\n", skipped)
+	}
+	if len(remaining) > 0 {
+		// An interrupted batch can be resumed right away.
+		if !quota && interrupted.Load() {
+			if err := saveQueue(job, location, remaining, time.Now()); err != nil {
+				return err
+			}
+			return &interruptError{remaining: len(remaining)}
+		}
+		reset := youtube.QuotaReset(time.Now())
+		if err := saveQueue(job, location, remaining, reset); err != nil {
+			return err
+		}
+		return &quotaError{remaining: len(remaining), reset: reset}
+	}
+	if len(failed) > 0 {
+		be := &batchError{total: len(results)}
+		for _, r := range failed {
+			be.errs = append(be.errs, r.Err)
+		}
+		return be
+	}
+	return nil
+}
+
+// runBatch runs fn for each ID through the batch worker pool, emitting
+// progress events for job under --progress json. Once the daily quota runs
+// out or SIGINT or SIGTERM arrives, the IDs not yet started are skipped with
+// context.Canceled.
+func runBatch(job string, ids []string, fn func(ctx context.Context, id string) error) []batch.Result {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// An interrupt stops videos from starting but lets those in progress
+	// finish, so their files and manifest entries are complete.
+	schedule, stop := context.WithCancel(ctx)
+	defer stop()
+	defer onInterrupt(stop)()
+
+	opts := batchOptions()
+	if viper.GetString("progress") == "json" {
+		r := progress.New(stderr, job, len(ids))
+		defer r.Finish()
+		opts.OnStart = r.Begin
+		opts.OnDone = func(res batch.Result) { r.End(res.ID, res.Err) }
+	}
+	return batch.Run(schedule, ids, opts, func(_ context.Context, id string) error {
+		span := traceVideo(job, id)
+		err := fn(ctx, id)
+		span.End(err)
+		countVideo(job, err)
+		if youtube.IsQuotaExceeded(err) {
+			cancel()
+		}
+		return err
+	})
+}
+
+func batchOptions() batch.Options {
+	return batch.Options{
+		Workers:  viper.GetInt("workers"),
+		CrashDir: viper.GetString("crash_dir"),
+		Config:   viper.AllSettings(),
+	}
+}
+
+// relOutputPath returns path relative to the output directory, with forward
+// slashes, as stored in the manifest.
+func relOutputPath(outputDir, path string) string {
+	rel, err := filepath.Rel(outputDir, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func planTranscripts(client *youtube.Client, videoIDs []string, outputDir string, format transcript.Format) error {
+	titles, err := client.VideoTitles(videoIDs)
+	if err != nil {
+		return err
+	}
+
+	p := &plan{action: "download transcripts for"}
+	for _, videoID := range videoIDs {
+		title, ok := titles[videoID]
+		if !ok {
+			p.add(nil, "%s  (video not found)", videoID)
+			continue
+		}
+		p.add(youtube.DownloadTranscriptCalls, "%s  %s", videoID, outputPath(outputDir, youtube.TranscriptFilename(videoID, title, format)))
+	}
+	p.print()
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/n2p5/ytt/internal/trash"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Inspect and restore previous versions of replaced transcripts",
+	Long: `When a transcript is re-downloaded over an existing file, the previous
+version is moved to .trash/ inside the output directory and kept for the
+configured trash_retention (default 30 days).`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trashed files",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := trash.List(viper.GetString("output"))
+		if err != nil {
+			return err
+		}
+		if jsonOutput() {
+			setResult(append([]trash.Entry{}, entries...))
+			return nil
+		}
+		if len(entries) == 0 {
+			fmt.Fprintln(stderr, "Trash is empty.")
+			return nil
+		}
+		for _, e := range entries {
+			fmt.Printf("%s  %s\n", e.TrashedAt.Local().Format(time.DateTime), e.Path)
+		}
+		return nil
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Restore the most recently trashed version of a file",
+	Long: `Restore the most recently trashed version of a file, given by its path
+relative to the output directory. The current file, if any, is moved to the
+trash in its place.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTrashed,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputDir := viper.GetString("output")
+		if dryRun() {
+			e, err := trash.Latest(outputDir, args[0])
+			if err != nil {
+				return err
+			}
+			p := &plan{action: "restore"}
+			p.add(nil, "%s  from %s", e.Path, e.TrashedAt.Local().Format(time.DateTime))
+			p.print()
+			return nil
+		}
+
+		e, err := trash.Restore(outputDir, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "Restored %s from %s\n", e.Path, e.TrashedAt.Local().Format(time.DateTime))
+		setResult(e)
+		return nil
+	},
+}
+
+func init() {
+	trashCmd.PersistentFlags().StringP("output", "o", "outputs", "output directory whose trash to use")
+
+	trashCmd.AddCommand(trashListCmd, trashRestoreCmd)
+	rootCmd.AddCommand(trashCmd)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse a channel's videos in the terminal and pick ones to download",
+	Long: `List the channel's videos in a terminal browser, marking those whose
+transcripts are already in the output directory as "saved". Search with /,
+change the order between date, views, and title with s, select videos with
+space (or every listed video with a), and press enter to download the
+selected transcripts, or the one under the cursor if none are selected.
+
+Shorts and live streams are filtered as for sync.`,
+	Args: cobra.NoArgs,
+	RunE: runTUI,
+}
+
+func init() {
+	tuiCmd.Flags().String("channel", "", "channel ID, @handle, or URL (default: authenticated user's channel)")
+	tuiCmd.RegisterFlagCompletionFunc("channel", completeChannelIDs)
+	tuiCmd.Flags().StringP("output", "o", "outputs", "directory to save transcripts in")
+	tuiCmd.Flags().IntP("workers", "w", 1, "number of videos to download concurrently")
+	addContentFilterFlags(tuiCmd)
+	addDownloadFlags(tuiCmd)
+
+	rootCmd.AddCommand(tuiCmd)
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	channelID, _ := cmd.Flags().GetString("channel")
+	outputDir := viper.GetString("output")
+	if !interactive() {
+		return fmt.Errorf("ytt tui needs a terminal")
+	}
+
+	opts, err := downloadOptions()
+	if err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	if channelID, err = resolveChannel(client, channelID); err != nil {
+		return err
+	}
+
+	m, err := manifest.Load(outputDir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Listing videos for %s...\n", channelID)
+	var items []tui.Item
+	for v, err := range client.Videos(context.Background(), channelID, listOptions()) {
+		if err != nil {
+			return err
+		}
+		item := tui.Item{VideoID: v.VideoID, Title: v.Title, Views: v.ViewCount}
+		if published, err := time.Parse(time.RFC3339, v.Date); err == nil {
+			item.Published = published
+		}
+		if e, ok := m.Entries[v.VideoID]; ok && e.Status == manifest.StatusOK {
+			item.Local = true
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		fmt.Fprintf(stderr, "No videos found for %s.\n", channelID)
+		return nil
+	}
+
+	chosen, err := tui.Run(os.Stdin, os.Stderr, tui.NewModel(items))
+	if err != nil {
+		return err
+	}
+	if len(chosen) == 0 {
+		return nil
+	}
+	if dryRun() {
+		return planTranscripts(client, chosen, outputDir, opts.Format)
+	}
+	beginRun("channel "+channelID, outputDir)
+	defer endRun()
+	return downloadTranscripts(client, chosen, outputDir, opts)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <dir>",
+	Short: "Check downloaded transcripts against their recorded SHA-256 hashes",
+	Long: `Re-hash every transcript listed in the directory's manifest and report
+those that were modified or are missing. With --manifest, check the files
+listed in a run manifest written by transcript, sync, or refresh instead.
+
+Transcripts saved before ytt recorded hashes are counted but can't be
+checked. The command fails if any file was modified or is missing.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVerify,
+}
+
+func init() {
+	verifyCmd.Flags().String("manifest", "", "run manifest to check instead of the directory's manifest.json")
+
+	rootCmd.AddCommand(verifyCmd)
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	dir := args[0]
+
+	var entries []manifest.Entry
+	if path := viper.GetString("manifest"); path != "" {
+		run, err := manifest.LoadRun(path)
+		if err != nil {
+			return err
+		}
+		entries = run.Results
+	} else {
+		m, err := manifest.Load(dir)
+		if err != nil {
+			return err
+		}
+		entries = m.Sorted()
+	}
+
+	v, err := manifest.Verify(dir, entries)
+	if err != nil {
+		return err
+	}
+	if jsonOutput() {
+		setResult(verifyResult{
+			Verified: len(v.OK),
+			Modified: append([]manifest.Entry{}, v.Modified...),
+			Missing:  append([]manifest.Entry{}, v.Missing...),
+			Unhashed: len(v.Unhashed),
+		})
+	} else {
+		for _, e := range v.Modified {
+			fmt.Printf("modified  %s  %s\n", e.VideoID, e.File)
+		}
+		for _, e := range v.Missing {
+			fmt.Printf("missing   %s  %s\n", e.VideoID, e.File)
+		}
+	}
+	fmt.Fprintf(stderr, "%d verified, %d modified, %d missing, %d without a hash\n", len(v.OK), len(v.Modified), len(v.Missing), len(v.Unhashed))
+	if v.Failed() {
+		return fmt.Errorf("%d of %d transcripts failed verification", len(v.Modified)+len(v.Missing), len(v.OK)+len(v.Modified)+len(v.Missing))
+	}
+	return nil
+}
+
+// verifyResult is verify's result under --json.
+type verifyResult struct {
+	Verified int              `json:"verified"`
+	Modified []manifest.Entry `json:"modified"`
+	Missing  []manifest.Entry `json:"missing"`
+	Unhashed int              `json:"unhashed"`
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/spf13/viper"
+)
+
+// warnings counts the warnings printed by warn, for --strict.
+var warnings atomic.Int64
+
+// warn reports a problem that doesn't stop the command. Under --strict the
+// command still fails once it finishes.
+func warn(err error) {
+	warnings.Add(1)
+	fmt.Fprintf(stderr, "Warning: %v\n", err)
+}
+
+// checkStrict fails the command if it printed any warnings under --strict.
+func checkStrict() error {
+	if n := warnings.Load(); n > 0 && viper.GetBool("strict") {
+		return fmt.Errorf("%d warning(s) in strict mode", n)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Package alert evaluates user-defined rules against the video metadata and
+// stats collected during a sync.
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/n2p5/ytt/internal/stats"
+	"github.com/n2p5/ytt/internal/youtube"
+)
+
+// Rule types.
+const (
+	// TypeViewsGain fires when a video gains more than Threshold views
+	// within Window.
+	TypeViewsGain = "views_gain"
+	// TypeMissingCaptions fires when a video published more than OlderThan
+	// ago has no captions.
+	TypeMissingCaptions = "missing_captions"
+)
+
+// DefaultCooldown is how long a rule stays quiet for a video after firing,
+// unless the rule sets its own.
+const DefaultCooldown = 24 * time.Hour
+
+// Rule is one alerting rule as written in the config file.
+type Rule struct {
+	Name      string        `mapstructure:"name"`
+	Type      string        `mapstructure:"type"`
+	Threshold uint64        `mapstructure:"threshold"`
+	Window    time.Duration `mapstructure:"window"`
+	OlderThan time.Duration `mapstructure:"older_than"`
+	Cooldown  time.Duration `mapstructure:"cooldown"`
+}
+
+// Validate reports a rule that is missing settings its type needs.
+func (r Rule) Validate() error {
+	if r.Name == "" {
+		return errors.New("alert rule is missing a name")
+	}
+	switch r.Type {
+	case TypeViewsGain:
+		if r.Threshold == 0 || r.Window <= 0 {
+			return fmt.Errorf("alert %q: %s needs threshold and window", r.Name, r.Type)
+		}
+	case TypeMissingCaptions:
+		if r.OlderThan <= 0 {
+			return fmt.Errorf("alert %q: %s needs older_than", r.Name, r.Type)
+		}
+	default:
+		return fmt.Errorf("alert %q: unknown type %q", r.Name, r.Type)
+	}
+	return nil
+}
+
+// Alert is a rule that fired for a video.
+type Alert struct {
+	Rule    string
+	VideoID string
+	Title   string
+	Message string
+}
+
+// Evaluate checks every rule against the current videos and the stats
+// samples recorded so far, and returns the alerts that fire.
+func Evaluate(rules []Rule, videos []youtube.VideoInfo, samples []stats.Sample, now time.Time) []Alert {
+	byVideo := map[string][]stats.Sample{}
+	for _, s := range samples {
+		byVideo[s.VideoID] = append(byVideo[s.VideoID], s)
+	}
+
+	var alerts []Alert
+	for _, rule := range rules {
+		for _, v := range videos {
+			if msg, ok := check(rule, v, byVideo[v.VideoID], now); ok {
+				alerts = append(alerts, Alert{Rule: rule.Name, VideoID: v.VideoID, Title: v.Title, Message: msg})
+			}
+		}
+	}
+	return alerts
+}
+
+func check(rule Rule, v youtube.VideoInfo, samples []stats.Sample, now time.Time) (string, bool) {
+	switch rule.Type {
+	case TypeViewsGain:
+		gain, ok := viewGain(samples, rule.Window)
+		if !ok || gain <= rule.Threshold {
+			return "", false
+		}
+		return fmt.Sprintf("%q gained %d views in the last %s", v.Title, gain, rule.Window), true
+
+	case TypeMissingCaptions:
+		published, err := time.Parse(time.RFC3339, v.Date)
+		if err != nil || v.HasCaptions || now.Sub(published) <= rule.OlderThan {
+			return "", false
+		}
+		return fmt.Sprintf("%q has no captions %s after publishing", v.Title, now.Sub(published).Round(time.Hour)), true
+	}
+	return "", false
+}
+
+// viewGain returns how many views a video gained over roughly window: from
+// the latest sample at least window older than the newest one (or the
+// oldest sample, if none is that old) to the newest. Samples must be in
+// time order.
+func viewGain(samples []stats.Sample, window time.Duration) (uint64, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	latest := samples[len(samples)-1]
+	base := samples[0]
+	for _, s := range samples {
+		if latest.Time.Sub(s.Time) < window {
+			break
+		}
+		base = s
+	}
+	if latest.ViewCount <= base.ViewCount {
+		return 0, true
+	}
+	return latest.ViewCount - base.ViewCount, true
+}
+
+// State remembers when each rule last fired for each video, so alerts don't
+// repeat on every run.
+type State struct {
+	Fired map[string]time.Time `json:"fired"`
+}
+
+// LoadState reads the state file at path. A missing file yields empty state.
+func LoadState(path string) (*State, error) {
+	st := &State{Fired: map[string]time.Time{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading alert state: %w", err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("error parsing alert state: %w", err)
+	}
+	if st.Fired == nil {
+		st.Fired = map[string]time.Time{}
+	}
+	return st, nil
+}
+
+// Save writes the state file to path, replacing it whole so an
+// interrupted save leaves the previous state.
+func (st *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating alert state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding alert state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing alert state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error writing alert state: %w", err)
+	}
+	return nil
+}
+
+// Filter drops alerts still within their rule's cooldown and records the
+// rest as fired at now.
+func (st *State) Filter(alerts []Alert, rules []Rule, now time.Time) []Alert {
+	cooldowns := map[string]time.Duration{}
+	for _, r := range rules {
+		cooldowns[r.Name] = r.Cooldown
+		if r.Cooldown <= 0 {
+			cooldowns[r.Name] = DefaultCooldown
+		}
+	}
+
+	var out []Alert
+	for _, a := range alerts {
+		key := a.Rule + "/" + a.VideoID
+		if last, ok := st.Fired[key]; ok && now.Sub(last) < cooldowns[a.Rule] {
+			continue
+		}
+		st.Fired[key] = now
+		out = append(out, a)
+	}
+	return out
+}
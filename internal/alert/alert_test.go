@@ -0,0 +1,105 @@
+package alert
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/stats"
+	"github.com/n2p5/ytt/internal/youtube"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	rules := []Rule{
+		{Name: "viral", Type: TypeViewsGain, Threshold: 10000, Window: 24 * time.Hour},
+		{Name: "captions", Type: TypeMissingCaptions, OlderThan: 48 * time.Hour},
+	}
+	videos := []youtube.VideoInfo{
+		{VideoID: "hot", Title: "Hot", HasCaptions: true, Date: "2025-01-01T00:00:00Z"},
+		{VideoID: "slow", Title: "Slow", HasCaptions: true, Date: "2025-01-01T00:00:00Z"},
+		{VideoID: "nocap", Title: "No captions", Date: "2025-01-01T00:00:00Z"},
+		{VideoID: "fresh", Title: "Fresh", Date: "2025-01-09T12:00:00Z"},
+	}
+	samples := []stats.Sample{
+		{Time: now.Add(-72 * time.Hour), VideoID: "hot", ViewCount: 0},
+		{Time: now.Add(-24 * time.Hour), VideoID: "hot", ViewCount: 1000},
+		{Time: now, VideoID: "hot", ViewCount: 20000},
+		{Time: now.Add(-72 * time.Hour), VideoID: "slow", ViewCount: 0},
+		{Time: now.Add(-24 * time.Hour), VideoID: "slow", ViewCount: 15000},
+		{Time: now, VideoID: "slow", ViewCount: 16000},
+	}
+
+	alerts := Evaluate(rules, videos, samples, now)
+	got := map[string]bool{}
+	for _, a := range alerts {
+		got[a.Rule+"/"+a.VideoID] = true
+	}
+	want := map[string]bool{"viral/hot": true, "captions/nocap": true}
+	if len(got) != len(want) {
+		t.Fatalf("Evaluate() fired %v, want %v", got, want)
+	}
+	for k := range want {
+		if !got[k] {
+			t.Errorf("Evaluate() did not fire %s", k)
+		}
+	}
+}
+
+func TestStateFilter(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	rules := []Rule{{Name: "captions", Type: TypeMissingCaptions, OlderThan: time.Hour}}
+	alerts := []Alert{{Rule: "captions", VideoID: "a"}}
+	st := &State{Fired: map[string]time.Time{}}
+
+	if got := st.Filter(alerts, rules, now); len(got) != 1 {
+		t.Errorf("first Filter() returned %d alerts, want 1", len(got))
+	}
+	if got := st.Filter(alerts, rules, now.Add(time.Hour)); len(got) != 0 {
+		t.Errorf("Filter() within cooldown returned %d alerts, want 0", len(got))
+	}
+	if got := st.Filter(alerts, rules, now.Add(25*time.Hour)); len(got) != 1 {
+		t.Errorf("Filter() after cooldown returned %d alerts, want 1", len(got))
+	}
+}
+
+func TestStateSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "alerts.json")
+	fired := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	st := &State{Fired: map[string]time.Time{"captions/a": fired}}
+	if err := st.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Save() left its temporary file behind: %v", err)
+	}
+	got, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Fired["captions/a"].Equal(fired) || len(got.Fired) != 1 {
+		t.Errorf("LoadState() after Save = %v", got.Fired)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{"views gain", Rule{Name: "a", Type: TypeViewsGain, Threshold: 1, Window: time.Hour}, false},
+		{"views gain missing window", Rule{Name: "a", Type: TypeViewsGain, Threshold: 1}, true},
+		{"missing captions", Rule{Name: "a", Type: TypeMissingCaptions, OlderThan: time.Hour}, false},
+		{"unknown type", Rule{Name: "a", Type: "nope"}, true},
+		{"no name", Rule{Type: TypeMissingCaptions, OlderThan: time.Hour}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
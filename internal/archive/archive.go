@@ -0,0 +1,146 @@
+// Package archive reads a transcript output directory: its manifest and the
+// transcripts it lists.
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/transcript"
+)
+
+// Archive is a read-only view of an output directory. It reloads the
+// manifest when the manifest file changes, so it can back a long-running
+// server while syncs add to the directory.
+type Archive struct {
+	Dir string
+
+	mu       sync.Mutex
+	manifest *manifest.Manifest
+	modTime  time.Time
+}
+
+// Open loads the archive in dir.
+func Open(dir string) (*Archive, error) {
+	a := &Archive{Dir: dir}
+	if _, err := a.load(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// load returns the current manifest, rereading it if the file changed.
+func (a *Archive) load() (*manifest.Manifest, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var modTime time.Time
+	if info, err := os.Stat(filepath.Join(a.Dir, manifest.File)); err == nil {
+		modTime = info.ModTime()
+	}
+	if a.manifest != nil && modTime.Equal(a.modTime) {
+		return a.manifest, nil
+	}
+
+	m, err := manifest.Load(a.Dir)
+	if err != nil {
+		return nil, err
+	}
+	a.manifest, a.modTime = m, modTime
+	return m, nil
+}
+
+// Videos returns the successfully downloaded videos, ordered by video ID.
+func (a *Archive) Videos() ([]manifest.Entry, error) {
+	m, err := a.load()
+	if err != nil {
+		return nil, err
+	}
+	var videos []manifest.Entry
+	for _, e := range m.Sorted() {
+		if e.Status == manifest.StatusOK && e.File != "" {
+			videos = append(videos, e)
+		}
+	}
+	return videos, nil
+}
+
+// Video returns the manifest entry for a downloaded video.
+func (a *Archive) Video(videoID string) (manifest.Entry, bool, error) {
+	m, err := a.load()
+	if err != nil {
+		return manifest.Entry{}, false, err
+	}
+	e, ok := m.Entries[videoID]
+	if !ok || e.Status != manifest.StatusOK || e.File == "" {
+		return manifest.Entry{}, false, nil
+	}
+	return e, true, nil
+}
+
+// Transcript returns the raw transcript file for a video.
+func (a *Archive) Transcript(videoID string) ([]byte, error) {
+	e, ok, err := a.Video(videoID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("video %s is not in the archive", videoID)
+	}
+	data, err := os.ReadFile(filepath.Join(a.Dir, filepath.FromSlash(e.File)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading transcript: %w", err)
+	}
+	return data, nil
+}
+
+// Cues returns a video's transcript parsed into cues.
+func (a *Archive) Cues(videoID string) ([]transcript.Cue, error) {
+	data, err := a.Transcript(videoID)
+	if err != nil {
+		return nil, err
+	}
+	return transcript.Parse(data)
+}
+
+// Match is a cue containing a search query.
+type Match struct {
+	VideoID string
+	Title   string
+	Cue     transcript.Cue
+}
+
+// Search returns cues, across every video, whose text contains query,
+// ignoring case. At most limit matches are returned if limit is positive.
+func (a *Archive) Search(query string, limit int) ([]Match, error) {
+	videos, err := a.Videos()
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	var matches []Match
+	for _, v := range videos {
+		cues, err := a.Cues(v.VideoID)
+		if err != nil {
+			// A missing or unreadable file shouldn't hide matches
+			// in the rest of the archive.
+			continue
+		}
+		for _, c := range cues {
+			if !strings.Contains(strings.ToLower(c.Text), query) {
+				continue
+			}
+			matches = append(matches, Match{VideoID: v.VideoID, Title: v.Title, Cue: c})
+			if limit > 0 && len(matches) >= limit {
+				return matches, nil
+			}
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,71 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+)
+
+// newTestArchive writes transcripts and a manifest to a temp directory.
+func newTestArchive(t *testing.T, files map[string]string) *Archive {
+	t.Helper()
+	dir := t.TempDir()
+	w, err := manifest.OpenWriter(dir, manifest.WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, content := range files {
+		name := id + "-Title.txt"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		w.Record(manifest.Entry{VideoID: id, Title: "Title " + id, File: name, Status: manifest.StatusOK})
+	}
+	w.Record(manifest.Entry{VideoID: "failed", Status: manifest.StatusFailed})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestSearch(t *testing.T) {
+	a := newTestArchive(t, map[string]string{
+		"a": "1\n00:00:01,000 --> 00:00:02,000\nInflation is rising\n\n2\n00:00:03,000 --> 00:00:04,000\nsomething else\n",
+		"b": "1\n00:01:00,000 --> 00:01:02,000\nwe talk about INFLATION\n",
+	})
+
+	videos, err := a.Videos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(videos) != 2 {
+		t.Errorf("Videos() returned %d videos, want 2 (failed entries excluded)", len(videos))
+	}
+
+	matches, err := a.Search("inflation", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("Search() returned %d matches, want 2", len(matches))
+	}
+	if matches[1].VideoID != "b" || matches[1].Cue.Start != time.Minute {
+		t.Errorf("second match = %+v, want video b at 1m", matches[1])
+	}
+
+	limited, err := a.Search("inflation", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limited) != 1 {
+		t.Errorf("Search() with limit 1 returned %d matches", len(limited))
+	}
+}
@@ -0,0 +1,150 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/n2p5/ytt/internal/filelock"
+	"github.com/n2p5/ytt/internal/manifest"
+)
+
+// Report lists the inconsistencies between an output directory and its
+// manifest. Paths are relative to the directory, with forward slashes, as
+// in the manifest.
+type Report struct {
+	// Orphans are files that no manifest entry refers to.
+	Orphans []string
+	// Dangling are entries that refer to files that don't exist.
+	Dangling []Dangling
+}
+
+// Dangling is a manifest entry with missing files.
+type Dangling struct {
+	Entry manifest.Entry
+	// Missing lists the entry's File, Raw, and Summary paths that don't
+	// exist.
+	Missing []string
+	// Candidate is an orphan that is probably the entry's missing
+	// transcript, such as one renamed by hand, or empty if there is none.
+	Candidate string
+}
+
+// MissingFile reports whether the entry's transcript itself is missing.
+func (d Dangling) MissingFile() bool {
+	for _, p := range d.Missing {
+		if p == d.Entry.File {
+			return true
+		}
+	}
+	return false
+}
+
+// Check compares the files in the output directory dir with its manifest.
+// The manifest's own files, the trash, and hidden files are ignored, as are
+// subdirectories with a manifest of their own, such as a channel archived
+// inside another's, whose files that manifest accounts for.
+func Check(dir string) (*Report, error) {
+	m, err := manifest.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]bool{}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if _, err := os.Stat(filepath.Join(p, manifest.File)); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if manifestFile(rel) {
+			return nil
+		}
+		files[rel] = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading output directory: %w", err)
+	}
+
+	referenced := map[string]bool{}
+	report := &Report{}
+	for _, e := range m.Sorted() {
+		var missing []string
+		for _, p := range []string{e.File, e.Raw, e.Summary, e.Audio} {
+			if p == "" {
+				continue
+			}
+			referenced[p] = true
+			if !files[p] && !exists(dir, p) {
+				missing = append(missing, p)
+			}
+		}
+		if missing != nil {
+			report.Dangling = append(report.Dangling, Dangling{Entry: e, Missing: missing})
+		}
+	}
+	for f := range files {
+		if !referenced[f] {
+			report.Orphans = append(report.Orphans, f)
+		}
+	}
+	sort.Strings(report.Orphans)
+
+	// An orphan named for the video, in the same directory and format as
+	// its missing transcript, is likely that transcript under another name.
+	claimed := map[string]bool{}
+	for i, d := range report.Dangling {
+		if !d.MissingFile() {
+			continue
+		}
+		for _, o := range report.Orphans {
+			if !claimed[o] && path.Dir(o) == path.Dir(d.Entry.File) && path.Ext(o) == path.Ext(d.Entry.File) &&
+				strings.HasPrefix(path.Base(o), d.Entry.VideoID+"-") {
+				report.Dangling[i].Candidate = o
+				claimed[o] = true
+				break
+			}
+		}
+	}
+	return report, nil
+}
+
+// manifestFile reports whether name is one of the files a manifest is kept
+// in: the manifest, its journal, a temporary file from saving it, or its
+// lock, including one being broken as stale. Another process may be using
+// any of them.
+func manifestFile(name string) bool {
+	return name == manifest.File || name == manifest.JournalFile ||
+		strings.HasPrefix(name, manifest.File+".tmp") || strings.HasPrefix(name, manifest.File+filelock.Suffix)
+}
+
+// exists reports whether the manifest path p exists in dir. Entries can
+// refer to files Check skips, such as hidden ones.
+func exists(dir, p string) bool {
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(p)))
+	return err == nil
+}
@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/n2p5/ytt/internal/manifest"
+)
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"a-Title.txt",
+		"b-Renamed by hand.txt",
+		"c-Stray.txt",
+		"raw/a.vtt",
+		"raw/z.vtt",
+		".trash/20250101T000000.000000000/old.txt",
+		".DS_Store",
+		// Another archive inside this one.
+		"nested/manifest.json",
+		"nested/e-Other.txt",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := manifest.OpenWriter(dir, manifest.WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Record(manifest.Entry{VideoID: "a", File: "a-Title.txt", Raw: "raw/a.vtt", Summary: "a-summary.md", Status: manifest.StatusOK})
+	w.Record(manifest.Entry{VideoID: "b", File: "b-Title.txt", Status: manifest.StatusOK})
+	w.Record(manifest.Entry{VideoID: "d", File: "d-Gone.srt", Status: manifest.StatusOK})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Another process's manifest lock, and one being broken as stale.
+	for _, name := range []string{"manifest.json.lock", "manifest.json.lock.stale1a2b"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("1 host"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := Check(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantOrphans := []string{"b-Renamed by hand.txt", "c-Stray.txt", "raw/z.vtt"}
+	if !reflect.DeepEqual(report.Orphans, wantOrphans) {
+		t.Errorf("Orphans = %q, want %q", report.Orphans, wantOrphans)
+	}
+
+	type dangling struct {
+		id        string
+		missing   []string
+		candidate string
+		file      bool
+	}
+	var got []dangling
+	for _, d := range report.Dangling {
+		got = append(got, dangling{d.Entry.VideoID, d.Missing, d.Candidate, d.MissingFile()})
+	}
+	want := []dangling{
+		{"a", []string{"a-summary.md"}, "", false},
+		{"b", []string{"b-Title.txt"}, "b-Renamed by hand.txt", true},
+		{"d", []string{"d-Gone.srt"}, "", true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Dangling = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/transcript"
+)
+
+// concordanceContext is how much text around a match Concordance searches
+// for the sentence containing it.
+const concordanceContext = 300
+
+// Occurrence is one use of a term in an archived transcript.
+type Occurrence struct {
+	Video    manifest.Entry
+	Start    time.Duration
+	Sentence string
+}
+
+// Concordance finds every occurrence of term in the archive's transcripts,
+// restricted to one channel's videos if channelID is set. Occurrences are
+// ordered by publish date, then by position in the video.
+func (a *Archive) Concordance(channelID, term string) ([]Occurrence, error) {
+	videos, err := a.Videos()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(videos, func(i, j int) bool {
+		return videos[i].PublishedAt.Before(videos[j].PublishedAt)
+	})
+
+	var out []Occurrence
+	for _, v := range videos {
+		if channelID != "" && v.ChannelID != channelID {
+			continue
+		}
+		cues, err := a.Cues(v.VideoID)
+		if err != nil {
+			// As in Search, one unreadable file shouldn't hide the rest.
+			continue
+		}
+		for _, m := range transcript.Find(cues, term, concordanceContext) {
+			out = append(out, Occurrence{Video: v, Start: m.Start, Sentence: m.Sentence()})
+		}
+	}
+	return out, nil
+}
+
+// WriteConcordanceCSV writes occurrences as CSV with a header row.
+func WriteConcordanceCSV(w io.Writer, occurrences []Occurrence) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"video_id", "title", "published_at", "timestamp", "seconds", "url", "sentence"})
+	for _, o := range occurrences {
+		var published string
+		if !o.Video.PublishedAt.IsZero() {
+			published = o.Video.PublishedAt.UTC().Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			o.Video.VideoID,
+			o.Video.Title,
+			published,
+			transcript.FormatTimestamp(o.Start),
+			strconv.Itoa(int(o.Start.Seconds())),
+			transcript.WatchURL(o.Video.VideoID, o.Start),
+			o.Sentence,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
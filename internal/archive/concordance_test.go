@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+)
+
+func TestConcordance(t *testing.T) {
+	dir := t.TempDir()
+	videos := []struct {
+		id, channel string
+		published   time.Time
+		content     string
+	}{
+		{"new", "UC1", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), "1\n00:01:00,000 --> 00:01:05,000\nWe covered rates. Inflation is cooling now.\n"},
+		{"old", "UC1", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "1\n00:00:10,000 --> 00:00:12,000\nso inflation\n\n2\n00:00:12,000 --> 00:00:14,000\nis rising\n"},
+		{"other", "UC2", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "1\n00:00:01,000 --> 00:00:02,000\ninflation\n"},
+	}
+	w, err := manifest.OpenWriter(dir, manifest.WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range videos {
+		name := v.id + ".srt"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(v.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		w.Record(manifest.Entry{VideoID: v.id, Title: "Title, " + v.id, ChannelID: v.channel, PublishedAt: v.published, File: name, Status: manifest.StatusOK})
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	a, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	occ, err := a.Concordance("UC1", "Inflation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteConcordanceCSV(&buf, occ); err != nil {
+		t.Fatal(err)
+	}
+	want := `video_id,title,published_at,timestamp,seconds,url,sentence
+old,"Title, old",2024-01-01T00:00:00Z,0:10,10,https://youtu.be/old?t=10,so inflation is rising
+new,"Title, new",2025-06-01T00:00:00Z,1:00,60,https://youtu.be/new?t=60,Inflation is cooling now.
+`
+	if got := buf.String(); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+
+	all, err := a.Concordance("", "inflation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("Concordance() across channels returned %d occurrences, want 3", len(all))
+	}
+}
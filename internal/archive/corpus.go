@@ -0,0 +1,192 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/transcript"
+)
+
+// CorpusUnit is what each record of a corpus holds.
+type CorpusUnit string
+
+const (
+	// CorpusVideo writes one record per video, with its whole transcript.
+	CorpusVideo CorpusUnit = "video"
+	// CorpusCue writes one record per cue, with its timing.
+	CorpusCue CorpusUnit = "cue"
+	// CorpusChunk writes one record per chunk of cues sized for an
+	// embedding model, with its timing.
+	CorpusChunk CorpusUnit = "chunk"
+)
+
+// ParseCorpusUnit parses a unit as named on the command line. Empty is
+// CorpusVideo.
+func ParseCorpusUnit(s string) (CorpusUnit, error) {
+	switch u := CorpusUnit(s); u {
+	case "":
+		return CorpusVideo, nil
+	case CorpusVideo, CorpusCue, CorpusChunk:
+		return u, nil
+	}
+	return "", fmt.Errorf("invalid corpus unit %q (want video, cue, or chunk)", s)
+}
+
+// CorpusOptions configures WriteCorpus.
+type CorpusOptions struct {
+	// ChannelID restricts the corpus to one channel's videos if set.
+	ChannelID string
+	Unit      CorpusUnit
+	// ChunkTokens and ChunkOverlap size CorpusChunk records, as for
+	// transcript.Chunks.
+	ChunkTokens  int
+	ChunkOverlap int
+}
+
+// CorpusSummary reports what WriteCorpus wrote.
+type CorpusSummary struct {
+	Videos  int
+	Records int
+	// Skipped explains, for each video that contributed no records, why its
+	// transcript couldn't be read.
+	Skipped []error
+}
+
+// corpusVideo is the video metadata every corpus record carries.
+type corpusVideo struct {
+	VideoID     string    `json:"video_id"`
+	Title       string    `json:"title,omitempty"`
+	ChannelID   string    `json:"channel_id,omitempty"`
+	PublishedAt time.Time `json:"published_at,omitzero"`
+	Language    string    `json:"language,omitempty"`
+}
+
+// videoRecord is a CorpusVideo record.
+type videoRecord struct {
+	corpusVideo
+	URL string `json:"url"`
+	// Cues is the number of cues in the transcript.
+	Cues int `json:"cues"`
+	// Duration is when the last cue ends, in seconds.
+	Duration float64 `json:"duration"`
+	Text     string  `json:"text"`
+}
+
+// chunkRecord is a CorpusChunk record.
+type chunkRecord struct {
+	corpusVideo
+	URL string `json:"url"`
+	// Chunk is the chunk's position in the transcript, from 1.
+	Chunk int     `json:"chunk"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	// Tokens is the estimated number of tokens in Text.
+	Tokens int    `json:"tokens"`
+	Text   string `json:"text"`
+}
+
+// cueRecord is a CorpusCue record.
+type cueRecord struct {
+	corpusVideo
+	URL string `json:"url"`
+	// Cue is the cue's position in the transcript, from 1.
+	Cue   int     `json:"cue"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// WriteCorpus writes the archive's transcripts to w as JSON Lines, one
+// record per video, cue, or chunk as opts.Unit says. Videos are ordered by
+// publish date, and each record carries the video's metadata, so the lines
+// can be fed to a pipeline one at a time.
+func (a *Archive) WriteCorpus(w io.Writer, opts CorpusOptions) (*CorpusSummary, error) {
+	videos, err := a.Videos()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(videos, func(i, j int) bool {
+		return videos[i].PublishedAt.Before(videos[j].PublishedAt)
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	summary := &CorpusSummary{}
+	for _, v := range videos {
+		if opts.ChannelID != "" && v.ChannelID != opts.ChannelID {
+			continue
+		}
+		cues, err := a.Cues(v.VideoID)
+		if err != nil {
+			summary.Skipped = append(summary.Skipped, fmt.Errorf("error reading transcript for %s: %w", v.VideoID, err))
+			continue
+		}
+		for _, r := range corpusRecords(v, cues, opts) {
+			if err := enc.Encode(r); err != nil {
+				return nil, fmt.Errorf("error writing corpus: %w", err)
+			}
+			summary.Records++
+		}
+		summary.Videos++
+	}
+	return summary, nil
+}
+
+// corpusRecords returns the records for a video's cues.
+func corpusRecords(e manifest.Entry, cues []transcript.Cue, opts CorpusOptions) []any {
+	video := corpusVideo{VideoID: e.VideoID, Title: e.Title, ChannelID: e.ChannelID, PublishedAt: e.PublishedAt, Language: e.Language}
+	switch opts.Unit {
+	case CorpusChunk:
+		chunks := transcript.Chunks(cues, opts.ChunkTokens, opts.ChunkOverlap)
+		records := make([]any, len(chunks))
+		for i, c := range chunks {
+			records[i] = chunkRecord{
+				corpusVideo: video,
+				URL:         transcript.WatchURL(e.VideoID, c.Start),
+				Chunk:       i + 1,
+				Start:       c.Start.Seconds(),
+				End:         c.End.Seconds(),
+				Tokens:      c.Tokens,
+				Text:        c.Text,
+			}
+		}
+		return records
+	case CorpusCue:
+		records := make([]any, len(cues))
+		for i, c := range cues {
+			records[i] = cueRecord{
+				corpusVideo: video,
+				URL:         transcript.WatchURL(e.VideoID, c.Start),
+				Cue:         i + 1,
+				Start:       c.Start.Seconds(),
+				End:         c.End.Seconds(),
+				Text:        cueText(c),
+			}
+		}
+		return records
+	}
+
+	texts := make([]string, len(cues))
+	var end time.Duration
+	for i, c := range cues {
+		texts[i] = cueText(c)
+		end = max(end, c.End)
+	}
+	return []any{videoRecord{
+		corpusVideo: video,
+		URL:         "https://youtu.be/" + e.VideoID,
+		Cues:        len(cues),
+		Duration:    end.Seconds(),
+		Text:        strings.Join(texts, " "),
+	}}
+}
+
+// cueText returns a cue's text on one line.
+func cueText(c transcript.Cue) string {
+	return strings.Join(strings.Fields(c.Text), " ")
+}
@@ -0,0 +1,96 @@
+package archive
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+)
+
+func TestWriteCorpus(t *testing.T) {
+	dir := t.TempDir()
+	videos := []struct {
+		id, channel string
+		published   time.Time
+		content     string
+	}{
+		{"new", "UC1", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), "1\n00:00:00,000 --> 00:00:02,500\n<b>Hello</b> & welcome\n"},
+		{"old", "UC1", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "1\n00:00:10,000 --> 00:00:12,000\nso inflation\n\n2\n00:00:12,000 --> 00:00:14,000\nis\nrising\n"},
+		{"other", "UC2", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "1\n00:00:01,000 --> 00:00:02,000\nhi\n"},
+		{"gone", "UC1", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), ""},
+	}
+	w, err := manifest.OpenWriter(dir, manifest.WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range videos {
+		name := v.id + ".srt"
+		if v.content != "" {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(v.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w.Record(manifest.Entry{VideoID: v.id, Title: "Title " + v.id, ChannelID: v.channel, PublishedAt: v.published, Language: "en", File: name, Status: manifest.StatusOK})
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	a, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opts        CorpusOptions
+		want        string
+		wantRecords int
+	}{
+		{CorpusOptions{ChannelID: "UC1", Unit: CorpusVideo}, `{"video_id":"old","title":"Title old","channel_id":"UC1","published_at":"2024-01-01T00:00:00Z","language":"en","url":"https://youtu.be/old","cues":2,"duration":14,"text":"so inflation is rising"}
+{"video_id":"new","title":"Title new","channel_id":"UC1","published_at":"2025-06-01T00:00:00Z","language":"en","url":"https://youtu.be/new","cues":1,"duration":2.5,"text":"Hello & welcome"}
+`, 2},
+		{CorpusOptions{ChannelID: "UC1", Unit: CorpusCue}, `{"video_id":"old","title":"Title old","channel_id":"UC1","published_at":"2024-01-01T00:00:00Z","language":"en","url":"https://youtu.be/old?t=10","cue":1,"start":10,"end":12,"text":"so inflation"}
+{"video_id":"old","title":"Title old","channel_id":"UC1","published_at":"2024-01-01T00:00:00Z","language":"en","url":"https://youtu.be/old?t=12","cue":2,"start":12,"end":14,"text":"is rising"}
+{"video_id":"new","title":"Title new","channel_id":"UC1","published_at":"2025-06-01T00:00:00Z","language":"en","url":"https://youtu.be/new?t=0","cue":1,"start":0,"end":2.5,"text":"Hello & welcome"}
+`, 3},
+		{CorpusOptions{ChannelID: "UC1", Unit: CorpusChunk, ChunkTokens: 5, ChunkOverlap: 1}, `{"video_id":"old","title":"Title old","channel_id":"UC1","published_at":"2024-01-01T00:00:00Z","language":"en","url":"https://youtu.be/old?t=10","chunk":1,"start":10,"end":12,"tokens":4,"text":"so inflation"}
+{"video_id":"old","title":"Title old","channel_id":"UC1","published_at":"2024-01-01T00:00:00Z","language":"en","url":"https://youtu.be/old?t=12","chunk":2,"start":12,"end":14,"tokens":3,"text":"is rising"}
+{"video_id":"new","title":"Title new","channel_id":"UC1","published_at":"2025-06-01T00:00:00Z","language":"en","url":"https://youtu.be/new?t=0","chunk":1,"start":0,"end":2.5,"tokens":5,"text":"Hello & welcome"}
+`, 3},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		summary, err := a.WriteCorpus(&buf, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("WriteCorpus(%s) =\n%s\nwant\n%s", tt.opts.Unit, got, tt.want)
+		}
+		if summary.Videos != 2 || summary.Records != tt.wantRecords || len(summary.Skipped) != 1 {
+			t.Errorf("WriteCorpus(%s) summary = %+v, want 2 videos, %d records, 1 skipped", tt.opts.Unit, summary, tt.wantRecords)
+		}
+	}
+}
+
+func TestParseCorpusUnit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    CorpusUnit
+		wantErr bool
+	}{
+		{"", CorpusVideo, false},
+		{"video", CorpusVideo, false},
+		{"cue", CorpusCue, false},
+		{"chunk", CorpusChunk, false},
+		{"sentence", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCorpusUnit(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseCorpusUnit(%q) = %q, %v, want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package audio downloads the audio track of a YouTube video alongside its
+// transcript, or a clip of the video, through an external downloader such
+// as yt-dlp.
+package audio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Downloader saves a video's audio track.
+type Downloader interface {
+	// Download saves the audio of videoID to base, a path without an
+	// extension; the downloader adds one for the format it saves. It
+	// returns the path of the saved file.
+	Download(ctx context.Context, videoID, base string) (string, error)
+}
+
+// DefaultProgram is the downloader used unless another is configured.
+const DefaultProgram = "yt-dlp"
+
+// DefaultArgs are the arguments given to yt-dlp: the best audio-only
+// format, saved under the extension yt-dlp picks for it.
+var DefaultArgs = []string{"--quiet", "--no-playlist", "--format", "bestaudio", "--output", "{output}.%(ext)s", "{url}"}
+
+// DefaultClipArgs are the arguments given to yt-dlp to download a clip:
+// the section between {start} and {end}, cut exactly at those times.
+var DefaultClipArgs = []string{"--quiet", "--no-playlist", "--download-sections", "*{start}-{end}", "--force-keyframes-at-cuts", "--output", "{output}.%(ext)s", "{url}"}
+
+// Command is a Downloader that runs an external program. In its arguments,
+// {url} is replaced by the video's URL, {video_id} by its ID, and {output}
+// by the path to save to, without an extension. When downloading a section,
+// {start} and {end} are replaced by its times in seconds.
+type Command struct {
+	Program string
+	Args    []string
+}
+
+// Download runs the program and returns the file it saved.
+func (c Command) Download(ctx context.Context, videoID, base string) (string, error) {
+	return c.run(ctx, "audio", videoID, base)
+}
+
+// DownloadSection runs the program to save the part of the video between
+// start and end, and returns the file it saved.
+func (c Command) DownloadSection(ctx context.Context, videoID, base string, start, end time.Duration) (string, error) {
+	return c.run(ctx, "clip", videoID, base, "{start}", seconds(start), "{end}", seconds(end))
+}
+
+// run runs the program with its arguments expanded, including the extra
+// old, new replacement pairs, and returns the file it saved. what names
+// the download in errors.
+func (c Command) run(ctx context.Context, what, videoID, base string, extra ...string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		return "", fmt.Errorf("error creating %s directory: %w", what, err)
+	}
+	r := strings.NewReplacer(append([]string{"{url}", "https://www.youtube.com/watch?v=" + videoID, "{video_id}", videoID, "{output}", base}, extra...)...)
+	args := make([]string, len(c.Args))
+	for i, a := range c.Args {
+		args[i] = r.Replace(a)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Program, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("error downloading %s for %s: %w: %s", what, videoID, err, msg)
+		}
+		return "", fmt.Errorf("error downloading %s for %s: %w", what, videoID, err)
+	}
+
+	path, err := Find(base)
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", fmt.Errorf("error downloading %s for %s: %s saved no file at %s.*", what, videoID, c.Program, base)
+	}
+	return path, nil
+}
+
+// Find returns the file a downloader saved to base, or "" if there is none.
+// Partial files left by an interrupted download are ignored.
+func Find(base string) (string, error) {
+	entries, err := os.ReadDir(filepath.Dir(base))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error finding audio: %w", err)
+	}
+	prefix := filepath.Base(base) + "."
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) || !e.Type().IsRegular() {
+			continue
+		}
+		if ext := filepath.Ext(name); ext == ".part" || ext == ".ytdl" || ext == ".tmp" {
+			continue
+		}
+		return filepath.Join(filepath.Dir(base), name), nil
+	}
+	return "", nil
+}
+
+// seconds formats d in seconds, to the millisecond.
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Round(time.Millisecond).Seconds(), 'f', -1, 64)
+}
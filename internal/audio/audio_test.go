@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "abc123")
+	for _, name := range []string{"abc123.webm.part", "abc1234.m4a", "other.m4a"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Find(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("Find with only a partial download = %q, want none", got)
+	}
+
+	want := base + ".opus"
+	if err := os.WriteFile(want, []byte("audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = Find(base); err != nil || got != want {
+		t.Errorf("Find = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestCommandDownloadSection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	base := filepath.Join(t.TempDir(), "abc123-clip")
+	c := Command{Program: "sh", Args: []string{"-c", `printf '%s' "$1" > "$2.mp4"`, "sh", "{video_id} {start}-{end}", "{output}"}}
+	path, err := c.DownloadSection(context.Background(), "abc123", base, 1500*time.Millisecond, 75*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "abc123 1.5-75"; string(data) != want {
+		t.Errorf("downloader was given %q, want %q", data, want)
+	}
+}
+
+func TestCommandDownload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	base := filepath.Join(t.TempDir(), "audio", "abc123")
+
+	tests := []struct {
+		name    string
+		script  string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "saves a file",
+			script: `printf '%s' "$1" > "$2.m4a"`,
+			want:   "https://www.youtube.com/watch?v=abc123",
+		},
+		{
+			name:    "fails",
+			script:  `echo "video unavailable" >&2; exit 1`,
+			wantErr: "video unavailable",
+		},
+		{
+			name:    "saves nothing",
+			script:  `true`,
+			wantErr: "saved no file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.RemoveAll(filepath.Dir(base))
+			c := Command{Program: "sh", Args: []string{"-c", tt.script, "sh", "{url}", "{output}"}}
+			path, err := c.Download(context.Background(), "abc123", base)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Download error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path != base+".m4a" {
+				t.Errorf("Download path = %q, want %q", path, base+".m4a")
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("downloader was given %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
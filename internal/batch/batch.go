@@ -0,0 +1,101 @@
+// Package batch runs per-video work across a bounded pool of workers.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+
+	"github.com/n2p5/ytt/internal/crash"
+)
+
+// Result records the outcome of processing a single item.
+type Result struct {
+	ID  string
+	Err error
+}
+
+// Options configures a batch run.
+type Options struct {
+	// Workers is the number of items processed concurrently. Values below 1 mean 1.
+	Workers int
+	// CrashDir is where crash reports for panicking items are written.
+	CrashDir string
+	// Config is included, sanitized, in crash reports.
+	Config map[string]any
+	// OnStart, if set, is called as each item starts.
+	OnStart func(id string)
+	// OnDone, if set, is called with each item's result as it finishes.
+	OnDone func(Result)
+}
+
+// Run calls fn for every ID and returns the results in input order. A panic
+// in fn is recovered, written to a crash report, and recorded as that item's
+// error so the remaining items still run.
+func Run(ctx context.Context, ids []string, opts Options, fn func(ctx context.Context, id string) error) []Result {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]Result, len(ids))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if opts.OnStart != nil {
+					opts.OnStart(ids[i])
+				}
+				results[i] = Result{ID: ids[i], Err: call(ctx, ids[i], opts, fn)}
+				if opts.OnDone != nil {
+					opts.OnDone(results[i])
+				}
+			}
+		}()
+	}
+
+	for i := range ids {
+		if ctx.Err() != nil {
+			results[i] = Result{ID: ids[i], Err: ctx.Err()}
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func call(ctx context.Context, id string, opts Options, fn func(ctx context.Context, id string) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			path, werr := crash.Write(opts.CrashDir, id, r, stack, opts.Config)
+			if werr != nil {
+				fmt.Fprintf(os.Stderr, "Unable to write crash report: %v\n", werr)
+				err = fmt.Errorf("panic processing %s: %v", id, r)
+				return
+			}
+			err = fmt.Errorf("panic processing %s: %v (crash report: %s)", id, r, path)
+		}
+	}()
+	return fn(ctx, id)
+}
+
+// Failed returns the results that have an error.
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
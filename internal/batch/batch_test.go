@@ -0,0 +1,90 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRunRecoversPanics(t *testing.T) {
+	dir := t.TempDir()
+	ids := []string{"ok", "boom", "fail", "ok2"}
+
+	results := Run(context.Background(), ids, Options{Workers: 2, CrashDir: dir}, func(ctx context.Context, id string) error {
+		switch id {
+		case "boom":
+			panic("malformed caption")
+		case "fail":
+			return errors.New("no captions")
+		}
+		return nil
+	})
+
+	if len(results) != len(ids) {
+		t.Fatalf("got %d results, want %d", len(results), len(ids))
+	}
+	for i, r := range results {
+		if r.ID != ids[i] {
+			t.Errorf("results[%d].ID = %q, want %q", i, r.ID, ids[i])
+		}
+	}
+	if results[0].Err != nil || results[3].Err != nil {
+		t.Errorf("unexpected errors for successful items: %v, %v", results[0].Err, results[3].Err)
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "malformed caption") {
+		t.Errorf("panic not recorded as error: %v", results[1].Err)
+	}
+	if got := len(Failed(results)); got != 2 {
+		t.Errorf("Failed() returned %d results, want 2", got)
+	}
+
+	reports, _ := filepath.Glob(filepath.Join(dir, "crash-*-boom.json"))
+	if len(reports) != 1 {
+		t.Fatalf("found %d crash reports, want 1", len(reports))
+	}
+	data, err := os.ReadFile(reports[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "malformed caption") {
+		t.Errorf("crash report missing panic value:\n%s", data)
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	var mu sync.Mutex
+	started := map[string]bool{}
+	done := map[string]error{}
+
+	opts := Options{
+		Workers:  3,
+		CrashDir: t.TempDir(),
+		OnStart: func(id string) {
+			mu.Lock()
+			started[id] = true
+			mu.Unlock()
+		},
+		OnDone: func(r Result) {
+			mu.Lock()
+			done[r.ID] = r.Err
+			mu.Unlock()
+		},
+	}
+	Run(context.Background(), []string{"a", "b", "boom"}, opts, func(ctx context.Context, id string) error {
+		if id == "boom" {
+			panic("boom")
+		}
+		return nil
+	})
+
+	if len(started) != 3 || len(done) != 3 {
+		t.Fatalf("hooks saw %d starts and %d results, want 3 each", len(started), len(done))
+	}
+	if done["a"] != nil || done["boom"] == nil {
+		t.Errorf("OnDone results = %v, want only boom to fail", done)
+	}
+}
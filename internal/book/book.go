@@ -0,0 +1,134 @@
+// Package book lays out transcripts as books for offline reading and
+// editing: PDF for printing and any reader, EPUB for e-readers, and DOCX for
+// word processors. A book opens with a title page listing its metadata,
+// followed by a chapter for each transcript.
+//
+// Every format carries an attachment the caller chooses, stored unchanged,
+// so a program that wrote a book can read its data back with
+// ReadAttachment.
+package book
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// Book is a title page and a sequence of chapters.
+type Book struct {
+	// ID identifies the book, such as the video ID of a single transcript.
+	ID       string
+	Title    string
+	Language string
+	// Date is the book's publication time in RFC 3339 format, if known.
+	Date string
+	// Fields are listed on the title page.
+	Fields   []Field
+	Chapters []Chapter
+	// Attachment is embedded in the book, to be read back with
+	// ReadAttachment. It isn't shown to readers.
+	Attachment []byte
+}
+
+// Field is an item of metadata shown as "Key: Value".
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Chapter is one transcript.
+type Chapter struct {
+	Title string
+	// Subtitle is a line shown under the title, such as the video's date
+	// and URL.
+	Subtitle   string
+	Paragraphs []Paragraph
+}
+
+// Paragraph is a cue or a run of cues, with the timestamp it starts at.
+type Paragraph struct {
+	// Time is the paragraph's timestamp as shown, such as "1:05", or empty
+	// to leave it out.
+	Time string
+	// URL links the timestamp to that moment of the video, where the
+	// format supports links.
+	URL     string
+	Speaker string
+	Text    string
+}
+
+// attachmentName is the attachment's file name inside a book.
+const attachmentName = "ytt-transcript.json"
+
+// IsPDF reports whether data looks like a PDF file.
+func IsPDF(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("%PDF-"))
+}
+
+// IsEPUB reports whether data looks like an EPUB file: a zip file whose
+// first entry is the uncompressed mimetype.
+func IsEPUB(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04")) && bytes.Contains(data[:min(len(data), 128)], []byte(epubMimeType))
+}
+
+// IsDOCX reports whether data looks like a Word document: a zip file
+// holding word/document.xml.
+func IsDOCX(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04")) && !IsEPUB(data) && bytes.Contains(data, []byte("word/document.xml"))
+}
+
+// pdfAttachment matches the header of the attachment's stream in a PDF
+// written by this package.
+var pdfAttachment = regexp.MustCompile(`/Type /EmbeddedFile /Subtype /application#2Fjson /Filter /FlateDecode /Length (\d+) >>\nstream\n`)
+
+// ReadAttachment returns the attachment embedded in a PDF, EPUB, or DOCX
+// book written by this package.
+func ReadAttachment(data []byte) ([]byte, error) {
+	switch {
+	case IsPDF(data):
+		loc := pdfAttachment.FindSubmatchIndex(data)
+		if loc == nil {
+			return nil, fmt.Errorf("PDF file has no embedded ytt transcript")
+		}
+		n, err := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		if err != nil || loc[1]+n > len(data) {
+			return nil, fmt.Errorf("PDF file has a damaged ytt transcript")
+		}
+		r, err := zlib.NewReader(bytes.NewReader(data[loc[1] : loc[1]+n]))
+		if err != nil {
+			return nil, fmt.Errorf("error reading PDF attachment: %w", err)
+		}
+		defer r.Close()
+		attachment, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("error reading PDF attachment: %w", err)
+		}
+		return attachment, nil
+	case IsEPUB(data):
+		return zipAttachment(data, "EPUB", "META-INF/"+attachmentName)
+	case IsDOCX(data):
+		return zipAttachment(data, "DOCX", docxAttachment)
+	}
+	return nil, fmt.Errorf("not a PDF, EPUB, or DOCX file")
+}
+
+func zipAttachment(data []byte, kind, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s file: %w", kind, err)
+	}
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s file has no embedded ytt transcript", kind)
+	}
+	defer f.Close()
+	attachment, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s attachment: %w", kind, err)
+	}
+	return attachment, nil
+}
@@ -0,0 +1,208 @@
+package book
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func testBook() *Book {
+	return &Book{
+		ID:     "abc123",
+		Title:  "Café (live) & more",
+		Fields: []Field{{"video_id", "abc123"}},
+		Chapters: []Chapter{{
+			Title:    "Part one",
+			Subtitle: "2024-03-01 · https://youtu.be/abc123",
+			Paragraphs: []Paragraph{
+				{Time: "0:01", URL: "https://youtu.be/abc123?t=1", Speaker: "Ann", Text: "so <today>"},
+				{Time: "0:05", Text: strings.Repeat("a long paragraph ", 200)},
+			},
+		}},
+		Attachment: []byte(`{"cues":[]}`),
+	}
+}
+
+func TestPDF(t *testing.T) {
+	data, err := testBook().PDF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsPDF(data) || IsEPUB(data) {
+		t.Fatalf("PDF output isn't recognized as a PDF")
+	}
+
+	// Every cross-reference entry points at the object it numbers.
+	xref := bytes.LastIndex(data, []byte("\nxref\n"))
+	if xref < 0 {
+		t.Fatal("no cross-reference table")
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	if len(entries) == 0 {
+		t.Fatal("empty cross-reference table")
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(data[off:], []byte(want)) {
+			t.Errorf("object %d is not at offset %d", i+1, off)
+		}
+	}
+	if n := bytes.Count(data, []byte("/Type /Page ")); n < 3 {
+		t.Errorf("got %d pages, want the long chapter to run past its first page", n)
+	}
+	if !bytes.Contains(data, []byte("/Outlines")) {
+		t.Error("no bookmarks")
+	}
+
+	got, err := ReadAttachment(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"cues":[]}` {
+		t.Errorf("ReadAttachment = %q", got)
+	}
+}
+
+func TestEPUB(t *testing.T) {
+	data, err := testBook().EPUB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEPUB(data) || IsPDF(data) {
+		t.Fatalf("EPUB output isn't recognized as an EPUB")
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := zr.File[0]; f.Name != "mimetype" || f.Method != zip.Store {
+		t.Errorf("first entry is %s (method %d), want mimetype stored uncompressed", f.Name, f.Method)
+	}
+	read := func(name string) string {
+		t.Helper()
+		f, err := zr.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		b, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if opf := read("OEBPS/content.opf"); !strings.Contains(opf, "<dc:title>Café (live) &amp; more</dc:title>") || !strings.Contains(opf, `<itemref idref="chapter-1"/>`) {
+		t.Errorf("content.opf is missing the title or chapter:\n%s", opf)
+	}
+	want := `<p><a class="time" href="https://youtu.be/abc123?t=1">0:01</a> <span class="speaker">Ann:</span> so &lt;today&gt;</p>`
+	if ch := read("OEBPS/chapter-1.xhtml"); !strings.Contains(ch, want) {
+		t.Errorf("chapter is missing %q:\n%s", want, ch)
+	}
+
+	got, err := ReadAttachment(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"cues":[]}` {
+		t.Errorf("ReadAttachment = %q", got)
+	}
+
+	// The same book always makes the same file.
+	again, _ := testBook().EPUB()
+	if !bytes.Equal(data, again) {
+		t.Error("EPUB output is not deterministic")
+	}
+}
+
+func TestDOCX(t *testing.T) {
+	data, err := testBook().DOCX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsDOCX(data) || IsEPUB(data) || IsPDF(data) {
+		t.Fatalf("DOCX output isn't recognized as a DOCX")
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(b)
+		if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".rels") {
+			if err := xml.Unmarshal(b, new(any)); err != nil {
+				t.Errorf("%s is not well-formed XML: %v", f.Name, err)
+			}
+		}
+	}
+
+	doc := parts["word/document.xml"]
+	for _, want := range []string{
+		`<w:pStyle w:val="Title"/></w:pPr><w:r><w:t xml:space="preserve">Café (live) &amp; more</w:t></w:r>`,
+		`<w:pStyle w:val="Heading1"/><w:pageBreakBefore/>`,
+		`<w:hyperlink r:id="rId2"><w:r><w:rPr><w:rStyle w:val="Timestamp"/></w:rPr><w:t xml:space="preserve">0:01</w:t></w:r></w:hyperlink>`,
+		`<w:t xml:space="preserve">so &lt;today&gt;</w:t>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document.xml is missing %q:\n%s", want, doc)
+		}
+	}
+	if rels := parts["word/_rels/document.xml.rels"]; !strings.Contains(rels, `Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://youtu.be/abc123?t=1"`) {
+		t.Errorf("document.xml.rels is missing the timestamp link:\n%s", rels)
+	}
+
+	got, err := ReadAttachment(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"cues":[]}` {
+		t.Errorf("ReadAttachment = %q", got)
+	}
+}
+
+func TestWinAnsi(t *testing.T) {
+	got := winAnsi("café “quoted”\n日本")
+	want := []byte("caf\xe9 \x93quoted\x94 ??")
+	if !bytes.Equal(got, want) {
+		t.Errorf("winAnsi = %q, want %q", got, want)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	// "iiii" is 8.88 points wide at size 10, and a space 2.78.
+	tests := []struct {
+		text  string
+		width float64
+		want  []string
+	}{
+		{"iiii iiii iiii", 21, []string{"iiii iiii", "iiii"}},
+		{"iiii iiii iiii", 20, []string{"iiii", "iiii", "iiii"}},
+		{"iiiiiiiiiiii", 10, []string{"iiii", "iiii", "iiii"}},
+		{"", 10, []string{""}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, line := range wrap([]byte(tt.text), 10, tt.width) {
+			got = append(got, string(line))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("wrap(%q, %g) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package book
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// docxAttachment is where a DOCX file keeps the attachment. The package
+// relationship pointing at it has a type Word doesn't know, so Word
+// ignores it.
+const docxAttachment = "ytt/" + attachmentName
+
+// DOCX renders the book as a Word document: the title as a heading over a
+// block of metadata, then each chapter under its own heading, starting on a
+// new page. Timestamps are set in a muted character style, linked to the
+// video, so editors can restyle or remove them at once. A book of a single
+// chapter with the book's title goes straight into its text.
+func (b *Book) DOCX() ([]byte, error) {
+	var doc, rels strings.Builder
+	rels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+`)
+	links := 1
+	link := func(url string) string {
+		links++
+		id := fmt.Sprintf("rId%d", links)
+		fmt.Fprintf(&rels, "<Relationship Id=\"%s\" Type=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink\" Target=\"%s\" TargetMode=\"External\"/>\n", id, escape(url))
+		return id
+	}
+
+	doc.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<w:body>
+`)
+	docxParagraph(&doc, "Title", false, docxRun(b.Title, ""))
+	for _, f := range b.Fields {
+		docxParagraph(&doc, "Metadata", false, docxRun(f.Key+": ", "<w:b/>")+docxRun(f.Value, ""))
+	}
+	if len(b.Fields) > 0 {
+		docxParagraph(&doc, "", false, "")
+	}
+
+	single := len(b.Chapters) == 1 && b.Chapters[0].Title == b.Title
+	for _, ch := range b.Chapters {
+		if !single {
+			docxParagraph(&doc, "Heading1", true, docxRun(ch.Title, ""))
+			if ch.Subtitle != "" {
+				docxParagraph(&doc, "Metadata", false, docxRun(ch.Subtitle, ""))
+			}
+		}
+		for _, p := range ch.Paragraphs {
+			var runs string
+			if p.Time != "" {
+				stamp := docxRun(p.Time, `<w:rStyle w:val="Timestamp"/>`)
+				if p.URL != "" {
+					stamp = fmt.Sprintf(`<w:hyperlink r:id="%s">%s</w:hyperlink>`, link(p.URL), stamp)
+				}
+				runs += stamp + docxRun(" ", "")
+			}
+			if p.Speaker != "" {
+				runs += docxRun(p.Speaker+": ", "<w:b/>")
+			}
+			runs += docxRun(p.Text, "")
+			docxParagraph(&doc, "", false, runs)
+		}
+	}
+	doc.WriteString(`<w:sectPr><w:pgSz w:w="12240" w:h="15840"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>
+</w:body>
+</w:document>
+`)
+	rels.WriteString("</Relationships>\n")
+
+	files := []struct{ name, data string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", b.docxPackageRels()},
+		{"docProps/core.xml", b.docxCore()},
+		{"word/document.xml", doc.String()},
+		{"word/_rels/document.xml.rels", rels.String()},
+		{"word/styles.xml", docxStyles},
+	}
+	if b.Attachment != nil {
+		files = append(files, struct{ name, data string }{docxAttachment, string(b.Attachment)})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate})
+		if err != nil {
+			return nil, fmt.Errorf("error writing DOCX: %w", err)
+		}
+		if _, err := w.Write([]byte(f.data)); err != nil {
+			return nil, fmt.Errorf("error writing DOCX: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("error writing DOCX: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// docxParagraph writes a paragraph of runs in a paragraph style, or the
+// default style if style is empty.
+func docxParagraph(w *strings.Builder, style string, pageBreak bool, runs string) {
+	w.WriteString("<w:p>")
+	if style != "" || pageBreak {
+		w.WriteString("<w:pPr>")
+		if style != "" {
+			fmt.Fprintf(w, `<w:pStyle w:val="%s"/>`, style)
+		}
+		if pageBreak {
+			w.WriteString("<w:pageBreakBefore/>")
+		}
+		w.WriteString("</w:pPr>")
+	}
+	w.WriteString(runs + "</w:p>\n")
+}
+
+// docxRun returns a run of text with the given run properties, turning
+// line breaks into breaks within the paragraph.
+func docxRun(text, props string) string {
+	var b strings.Builder
+	b.WriteString("<w:r>")
+	if props != "" {
+		b.WriteString("<w:rPr>" + props + "</w:rPr>")
+	}
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.WriteString("<w:br/>")
+		}
+		fmt.Fprintf(&b, `<w:t xml:space="preserve">%s</w:t>`, escape(line))
+	}
+	b.WriteString("</w:r>")
+	return b.String()
+}
+
+func (b *Book) docxCore() string {
+	var s strings.Builder
+	s.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&s, "<dc:title>%s</dc:title>\n", escape(b.Title))
+	fmt.Fprintf(&s, "<dc:identifier>%s</dc:identifier>\n", escape(b.ID))
+	if b.Language != "" {
+		fmt.Fprintf(&s, "<dc:language>%s</dc:language>\n", escape(b.Language))
+	}
+	s.WriteString("</cp:coreProperties>\n")
+	return s.String()
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Default Extension="json" ContentType="application/json"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>
+`
+
+func (b *Book) docxPackageRels() string {
+	var s strings.Builder
+	s.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+`)
+	if b.Attachment != nil {
+		s.WriteString(`<Relationship Id="rId3" Type="https://github.com/n2p5/ytt/relationships/transcript" Target="` + docxAttachment + `"/>` + "\n")
+	}
+	s.WriteString("</Relationships>\n")
+	return s.String()
+}
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:spacing w:after="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="48"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Metadata"><w:name w:val="Metadata"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0"/></w:pPr><w:rPr><w:color w:val="666666"/><w:sz w:val="20"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Timestamp"><w:name w:val="Timestamp"/><w:rPr><w:color w:val="999999"/><w:sz w:val="18"/></w:rPr></w:style>
+</w:styles>
+`
@@ -0,0 +1,178 @@
+package book
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const epubMimeType = "application/epub+zip"
+
+// epubModified stands in for the modification time EPUB requires when the
+// book has no date, so the same transcript always makes the same file.
+const epubModified = "2000-01-01T00:00:00Z"
+
+// EPUB renders the book as an EPUB 3 file, with the title page and each
+// chapter as separate documents and a table of contents linking them.
+func (b *Book) EPUB() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name string, method uint16, data string) error {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(data))
+		return err
+	}
+
+	// The mimetype comes first and uncompressed, so readers can identify
+	// the file from its first bytes.
+	files := []struct{ name, data string }{
+		{"META-INF/container.xml", epubContainer},
+		{"OEBPS/content.opf", b.epubPackage()},
+		{"OEBPS/nav.xhtml", b.epubNav()},
+		{"OEBPS/style.css", epubStyle},
+		{"OEBPS/title.xhtml", b.epubTitlePage()},
+	}
+	for i, ch := range b.Chapters {
+		files = append(files, struct{ name, data string }{fmt.Sprintf("OEBPS/chapter-%d.xhtml", i+1), b.epubChapter(ch)})
+	}
+	if b.Attachment != nil {
+		files = append(files, struct{ name, data string }{"META-INF/" + attachmentName, string(b.Attachment)})
+	}
+
+	if err := add("mimetype", zip.Store, epubMimeType); err != nil {
+		return nil, fmt.Errorf("error writing EPUB: %w", err)
+	}
+	for _, f := range files {
+		if err := add(f.name, zip.Deflate, f.data); err != nil {
+			return nil, fmt.Errorf("error writing EPUB: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("error writing EPUB: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+const epubStyle = `body { font-family: serif; line-height: 1.5; }
+h1, h2 { font-family: sans-serif; }
+.subtitle, .time { color: #666; font-size: 0.85em; }
+.time { font-family: sans-serif; text-decoration: none; }
+.speaker { font-weight: bold; }
+dt { font-weight: bold; }
+`
+
+func (b *Book) epubPackage() string {
+	var s strings.Builder
+	s.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&s, "<dc:identifier id=\"id\">urn:ytt:%s</dc:identifier>\n", escape(b.ID))
+	fmt.Fprintf(&s, "<dc:title>%s</dc:title>\n", escape(b.Title))
+	fmt.Fprintf(&s, "<dc:language>%s</dc:language>\n", escape(cmp.Or(b.Language, "und")))
+	if b.Date != "" {
+		fmt.Fprintf(&s, "<dc:date>%s</dc:date>\n", escape(b.Date))
+	}
+	fmt.Fprintf(&s, "<meta property=\"dcterms:modified\">%s</meta>\n", escape(cmp.Or(b.Date, epubModified)))
+	s.WriteString(`</metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="style" href="style.css" media-type="text/css"/>
+<item id="title" href="title.xhtml" media-type="application/xhtml+xml"/>
+`)
+	for i := range b.Chapters {
+		fmt.Fprintf(&s, "<item id=\"chapter-%d\" href=\"chapter-%d.xhtml\" media-type=\"application/xhtml+xml\"/>\n", i+1, i+1)
+	}
+	s.WriteString("</manifest>\n<spine>\n<itemref idref=\"title\"/>\n")
+	for i := range b.Chapters {
+		fmt.Fprintf(&s, "<itemref idref=\"chapter-%d\"/>\n", i+1)
+	}
+	s.WriteString("</spine>\n</package>\n")
+	return s.String()
+}
+
+func (b *Book) epubNav() string {
+	var s strings.Builder
+	s.WriteString(b.xhtmlHead("Contents"))
+	s.WriteString("<nav epub:type=\"toc\">\n<h1>Contents</h1>\n<ol>\n")
+	for i, ch := range b.Chapters {
+		fmt.Fprintf(&s, "<li><a href=\"chapter-%d.xhtml\">%s</a></li>\n", i+1, escape(ch.Title))
+	}
+	s.WriteString("</ol>\n</nav>\n</body>\n</html>\n")
+	return s.String()
+}
+
+func (b *Book) epubTitlePage() string {
+	var s strings.Builder
+	s.WriteString(b.xhtmlHead(b.Title))
+	fmt.Fprintf(&s, "<h1>%s</h1>\n", escape(b.Title))
+	if len(b.Fields) > 0 {
+		s.WriteString("<dl>\n")
+		for _, f := range b.Fields {
+			fmt.Fprintf(&s, "<dt>%s</dt><dd>%s</dd>\n", escape(f.Key), escape(f.Value))
+		}
+		s.WriteString("</dl>\n")
+	}
+	s.WriteString("</body>\n</html>\n")
+	return s.String()
+}
+
+func (b *Book) epubChapter(ch Chapter) string {
+	var s strings.Builder
+	s.WriteString(b.xhtmlHead(ch.Title))
+	fmt.Fprintf(&s, "<h2>%s</h2>\n", escape(ch.Title))
+	if ch.Subtitle != "" {
+		fmt.Fprintf(&s, "<p class=\"subtitle\">%s</p>\n", escape(ch.Subtitle))
+	}
+	for _, p := range ch.Paragraphs {
+		s.WriteString("<p>")
+		switch {
+		case p.Time != "" && p.URL != "":
+			fmt.Fprintf(&s, "<a class=\"time\" href=\"%s\">%s</a> ", escape(p.URL), escape(p.Time))
+		case p.Time != "":
+			fmt.Fprintf(&s, "<span class=\"time\">%s</span> ", escape(p.Time))
+		}
+		if p.Speaker != "" {
+			fmt.Fprintf(&s, "<span class=\"speaker\">%s:</span> ", escape(p.Speaker))
+		}
+		s.WriteString(strings.ReplaceAll(escape(p.Text), "\n", "<br/>"))
+		s.WriteString("</p>\n")
+	}
+	s.WriteString("</body>\n</html>\n")
+	return s.String()
+}
+
+func (b *Book) xhtmlHead(title string) string {
+	lang := escape(cmp.Or(b.Language, "und"))
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="%s" xml:lang="%s">
+<head>
+<meta charset="utf-8"/>
+<title>%s</title>
+<link rel="stylesheet" href="style.css"/>
+</head>
+<body>
+`, lang, lang, escape(title))
+}
+
+// escape escapes s for XML text and attribute values.
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,332 @@
+package book
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// PDF pages are US Letter, set in the Helvetica fonts every PDF reader
+// has built in. Those fonts only cover the Windows-1252 character set, so
+// other characters are shown as "?"; EPUB has no such limit.
+const (
+	pageWidth  = 612
+	pageHeight = 792
+	margin     = 72
+	textWidth  = pageWidth - 2*margin
+	// timeColumn is the width of the timestamp column in chapters.
+	timeColumn = 48
+)
+
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+const (
+	black = 0.0
+	gray  = 0.45
+)
+
+// PDF renders the book as a PDF file, with a bookmark for each chapter.
+func (b *Book) PDF() ([]byte, error) {
+	l := &pdfLayout{}
+	l.newPage()
+	l.y -= 2 * margin
+	l.paragraph(fontBold, 24, 30, black, margin, textWidth, b.Title)
+	l.y -= 12
+	for _, f := range b.Fields {
+		l.paragraph(fontRegular, 11, 15, black, margin, textWidth, f.Key+": "+f.Value)
+	}
+
+	chapterPages := make([]int, len(b.Chapters))
+	for i, ch := range b.Chapters {
+		l.newPage()
+		chapterPages[i] = len(l.pages) - 1
+		l.paragraph(fontBold, 16, 20, black, margin, textWidth, ch.Title)
+		if ch.Subtitle != "" {
+			l.paragraph(fontRegular, 10, 13, gray, margin, textWidth, ch.Subtitle)
+		}
+		l.y -= 10
+		for _, p := range ch.Paragraphs {
+			text := p.Text
+			if p.Speaker != "" {
+				text = p.Speaker + ": " + text
+			}
+			x, width := float64(margin), float64(textWidth)
+			if p.Time != "" {
+				x, width = margin+timeColumn, textWidth-timeColumn
+			}
+			for i, line := range wrap(winAnsi(text), 11, width) {
+				l.next(15)
+				if i == 0 && p.Time != "" {
+					l.show(fontRegular, 10, gray, margin, winAnsi(p.Time))
+				}
+				l.show(fontRegular, 11, black, x, line)
+			}
+			l.y -= 4
+		}
+	}
+	return l.render(b, chapterPages)
+}
+
+// pdfLayout places lines of text on pages, top to bottom.
+type pdfLayout struct {
+	pages []*bytes.Buffer
+	// y is the baseline of the last line placed on the current page.
+	y float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, &bytes.Buffer{})
+	l.y = pageHeight - margin
+}
+
+// next moves down a line, starting a new page if the line doesn't fit.
+func (l *pdfLayout) next(leading float64) {
+	if l.y-leading < margin {
+		l.newPage()
+	}
+	l.y -= leading
+}
+
+// show draws text, encoded with winAnsi, on the current line.
+func (l *pdfLayout) show(font string, size, color, x float64, text []byte) {
+	fmt.Fprintf(l.pages[len(l.pages)-1], "BT /%s %g Tf %g g %g %g Td (%s) Tj ET\n", font, size, color, x, l.y, pdfEscape(text))
+}
+
+// paragraph draws text wrapped to width.
+func (l *pdfLayout) paragraph(font string, size, leading, color, x, width float64, text string) {
+	if font == fontBold {
+		// The bold face is wider than the widths table, which is for
+		// the regular one.
+		width /= 1.1
+	}
+	for _, line := range wrap(winAnsi(text), size, width) {
+		l.next(leading)
+		l.show(font, size, color, x, line)
+	}
+}
+
+// render writes the pages out as a PDF file, numbering every page after
+// the title page.
+func (l *pdfLayout) render(b *Book, chapterPages []int) ([]byte, error) {
+	w := &pdfWriter{}
+	catalog, pages, regular, bold, info := w.reserve(), w.reserve(), w.reserve(), w.reserve(), w.reserve()
+	w.buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+
+	var kids []string
+	pageObjects := make([]int, len(l.pages))
+	for i, content := range l.pages {
+		if i > 0 {
+			n := winAnsi(fmt.Sprint(i))
+			fmt.Fprintf(content, "BT /%s 9 Tf %g g %g %g Td (%s) Tj ET\n", fontRegular, gray, (pageWidth-textLength(n, 9))/2, margin/2.0, n)
+		}
+		page, stream := w.reserve(), w.reserve()
+		pageObjects[i] = page
+		kids = append(kids, ref(page))
+		if err := w.stream(stream, "", content.Bytes()); err != nil {
+			return nil, err
+		}
+		w.object(page, fmt.Sprintf("<< /Type /Page /Parent %s /MediaBox [0 0 %d %d] /Resources << /Font << /%s %s /%s %s >> >> /Contents %s >>",
+			ref(pages), pageWidth, pageHeight, fontRegular, ref(regular), fontBold, ref(bold), ref(stream)))
+	}
+	w.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.object(regular, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	w.object(bold, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	w.object(info, fmt.Sprintf("<< /Title %s /Producer (ytt) >>", pdfText(b.Title)))
+
+	extra := ""
+	if len(b.Chapters) > 0 {
+		outlines := w.reserve()
+		items := make([]int, len(b.Chapters))
+		for i := range items {
+			items[i] = w.reserve()
+		}
+		for i, ch := range b.Chapters {
+			item := fmt.Sprintf("<< /Title %s /Parent %s /Dest [%s /XYZ null null null]", pdfText(ch.Title), ref(outlines), ref(pageObjects[chapterPages[i]]))
+			if i > 0 {
+				item += " /Prev " + ref(items[i-1])
+			}
+			if i < len(items)-1 {
+				item += " /Next " + ref(items[i+1])
+			}
+			w.object(items[i], item+" >>")
+		}
+		w.object(outlines, fmt.Sprintf("<< /Type /Outlines /First %s /Last %s /Count %d >>", ref(items[0]), ref(items[len(items)-1]), len(items)))
+		extra += " /Outlines " + ref(outlines) + " /PageMode /UseOutlines"
+	}
+	if b.Attachment != nil {
+		file, spec := w.reserve(), w.reserve()
+		if err := w.stream(file, "/Type /EmbeddedFile /Subtype /application#2Fjson", b.Attachment); err != nil {
+			return nil, err
+		}
+		w.object(spec, fmt.Sprintf("<< /Type /Filespec /F (%s) /UF (%s) /EF << /F %s >> >>", attachmentName, attachmentName, ref(file)))
+		extra += fmt.Sprintf(" /Names << /EmbeddedFiles << /Names [(%s) %s] >> >>", attachmentName, ref(spec))
+	}
+	w.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %s%s >>", ref(pages), extra))
+	return w.finish(catalog, info), nil
+}
+
+// pdfWriter writes numbered objects and the cross-reference table that
+// locates them.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+// reserve allocates an object number, so objects can refer to objects
+// written after them.
+func (w *pdfWriter) reserve() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func (w *pdfWriter) object(n int, body string) {
+	w.offsets[n-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", n, body)
+}
+
+// stream writes a compressed stream object whose dictionary has the given
+// entries besides its filter and length.
+func (w *pdfWriter) stream(n int, entries string, data []byte) error {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(data); err != nil {
+		return fmt.Errorf("error compressing PDF stream: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("error compressing PDF stream: %w", err)
+	}
+	if entries != "" {
+		entries += " "
+	}
+	w.object(n, fmt.Sprintf("<< %s/Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", entries, z.Len(), z.Bytes()))
+	return nil
+}
+
+func (w *pdfWriter) finish(root, info int) []byte {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, off := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %s /Info %s >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, ref(root), ref(info), xref)
+	return w.buf.Bytes()
+}
+
+func ref(n int) string {
+	return fmt.Sprintf("%d 0 R", n)
+}
+
+// pdfText encodes s as a PDF text string, in UTF-16 so any character
+// survives in bookmarks and document properties.
+func pdfText(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfEscape escapes text for a PDF literal string.
+func pdfEscape(text []byte) []byte {
+	var b bytes.Buffer
+	for _, c := range text {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.Bytes()
+}
+
+// winAnsiExtra maps the characters Windows-1252 puts in 0x80-0x9F.
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// winAnsi encodes s in Windows-1252, the encoding of the built-in fonts.
+// Line breaks and other control characters become spaces, and characters
+// the encoding lacks become "?".
+func winAnsi(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		switch {
+		case r < 0x20:
+			b = append(b, ' ')
+		case r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			b = append(b, byte(r))
+		case winAnsiExtra[r] != 0:
+			b = append(b, winAnsiExtra[r])
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+// helveticaWidths are the widths of Helvetica's printable ASCII characters,
+// from space to tilde, in thousandths of the font size.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textLength returns the width of Windows-1252 text in Helvetica at size.
+// Characters outside ASCII are taken to be as wide as a digit.
+func textLength(text []byte, size float64) float64 {
+	var units int
+	for _, c := range text {
+		if c >= 0x20 && c < 0x7F {
+			units += helveticaWidths[c-0x20]
+		} else {
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// wrap breaks text into lines no wider than width at size, breaking at
+// spaces where it can and inside words longer than a line.
+func wrap(text []byte, size, width float64) [][]byte {
+	var lines [][]byte
+	var line []byte
+	for _, word := range bytes.Fields(text) {
+		candidate := word
+		if len(line) > 0 {
+			candidate = append(append(append([]byte{}, line...), ' '), word...)
+		}
+		if textLength(candidate, size) <= width {
+			line = candidate
+			continue
+		}
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		for textLength(word, size) > width {
+			n := 1
+			for n < len(word) && textLength(word[:n+1], size) <= width {
+				n++
+			}
+			lines = append(lines, word[:n])
+			word = word[n:]
+		}
+		line = word
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,234 @@
+// Package bundle writes files into a single .tar.gz or .zip archive.
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Writer writes files into a new archive that replaces the one at its path
+// when it is closed. Files in an archive already at the path are carried
+// over unless they are written again. A Writer is safe for concurrent use.
+type Writer struct {
+	path  string
+	index string
+	zip   bool
+
+	mu      sync.Mutex
+	tmp     *os.File
+	tw      *tar.Writer
+	gz      *gzip.Writer
+	zw      *zip.Writer
+	written map[string]bool
+	prev    []byte // the previous archive's index file
+}
+
+// Create starts a new archive at path, which must end in .tar.gz, .tgz, or
+// .zip. index names a file, such as an index of the archive's contents, that
+// is kept aside from the previous archive for Get rather than carried over.
+func Create(path, index string) (*Writer, error) {
+	w := &Writer{path: path, index: index, written: map[string]bool{}}
+	switch {
+	case strings.HasSuffix(path, ".zip"):
+		w.zip = true
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+	default:
+		return nil, fmt.Errorf("unsupported archive %q (want .tar.gz, .tgz, or .zip)", path)
+	}
+
+	err := w.walkPrevious(func(name string, r io.Reader) error {
+		if name != index {
+			return nil
+		}
+		var err error
+		w.prev, err = io.ReadAll(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating archive directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating archive: %w", err)
+	}
+	w.tmp = tmp
+	if w.zip {
+		w.zw = zip.NewWriter(tmp)
+	} else {
+		w.gz = gzip.NewWriter(tmp)
+		w.tw = tar.NewWriter(w.gz)
+	}
+	return w, nil
+}
+
+// Put adds a file to the archive.
+func (w *Writer) Put(_ context.Context, name string, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.written[name] {
+		return fmt.Errorf("%s is already in the archive", name)
+	}
+	if err := w.add(name, data); err != nil {
+		return fmt.Errorf("error writing %s to archive: %w", name, err)
+	}
+	// Marked only once written, so a failed file can be retried and the
+	// previous archive's copy is still carried over if it isn't.
+	w.written[name] = true
+	return nil
+}
+
+func (w *Writer) add(name string, data []byte) error {
+	now := time.Now()
+	if w.zip {
+		f, err := w.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+// Get returns the index file from the archive being replaced, or an error
+// wrapping fs.ErrNotExist if there was none.
+func (w *Writer) Get(_ context.Context, name string) ([]byte, error) {
+	if name != w.index || w.prev == nil {
+		return nil, fmt.Errorf("%s: %w", w.URL(name), fs.ErrNotExist)
+	}
+	return w.prev, nil
+}
+
+// URL returns how a file in the archive is shown to the user.
+func (w *Writer) URL(name string) string {
+	return w.path + ":" + name
+}
+
+// Close carries over the previous archive's files, finishes the new
+// archive, and moves it into place.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer os.Remove(w.tmp.Name())
+
+	err := w.walkPrevious(func(name string, r io.Reader) error {
+		if w.written[name] || name == w.index {
+			return nil
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return w.add(name, data)
+	})
+	if err == nil {
+		err = w.finish()
+	}
+	if cerr := w.tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing archive: %w", err)
+	}
+	if err := os.Rename(w.tmp.Name(), w.path); err != nil {
+		return fmt.Errorf("error replacing archive: %w", err)
+	}
+	return nil
+}
+
+// Abort discards the new archive, leaving any previous one in place.
+func (w *Writer) Abort() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tmp.Close()
+	os.Remove(w.tmp.Name())
+}
+
+func (w *Writer) finish() error {
+	if w.zip {
+		return w.zw.Close()
+	}
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// walkPrevious calls fn for each regular file in the archive at w.path, if
+// there is one.
+func (w *Writer) walkPrevious(fn func(name string, r io.Reader) error) error {
+	if w.zip {
+		zr, err := zip.OpenReader(w.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading archive: %w", err)
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("error reading archive: %w", err)
+			}
+			err = fn(f.Name, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	f, err := os.Open(w.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading archive: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("error reading archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
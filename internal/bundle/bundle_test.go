@@ -0,0 +1,112 @@
+package bundle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// contents reads every file in the archive at path.
+func contents(t *testing.T, path string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	w := &Writer{path: path, zip: filepath.Ext(path) == ".zip"}
+	err := w.walkPrevious(func(name string, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		files[name] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestWriter(t *testing.T) {
+	for _, name := range []string{"out.tar.gz", "out.zip"} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), name)
+
+			w, err := Create(path, "index.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Get(ctx, "index.json"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Get() on a new archive error = %v, want fs.ErrNotExist", err)
+			}
+			for file, data := range map[string]string{"a.txt": "old a", "b.txt": "b", "index.json": "v1"} {
+				if err := w.Put(ctx, file, []byte(data)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Put(ctx, "b.txt", nil); err == nil {
+				t.Error("Put() of a duplicate name succeeded")
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// A second run replaces a.txt and the index and keeps b.txt.
+			w, err = Create(path, "index.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			index, err := w.Get(ctx, "index.json")
+			if err != nil || string(index) != "v1" {
+				t.Errorf("Get(index.json) = %q, %v, want v1", index, err)
+			}
+			if err := w.Put(ctx, "a.txt", []byte("new a")); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Put(ctx, "index.json", []byte("v2")); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]string{"a.txt": "new a", "b.txt": "b", "index.json": "v2"}
+			if got := contents(t, path); !reflect.DeepEqual(got, want) {
+				t.Errorf("archive = %v, want %v", got, want)
+			}
+
+			// An aborted run leaves the archive alone.
+			w, err = Create(path, "index.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Put(ctx, "c.txt", []byte("c"))
+			w.Abort()
+			if got := contents(t, path); !reflect.DeepEqual(got, want) {
+				t.Errorf("archive after Abort = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCreateUnsupported(t *testing.T) {
+	if _, err := Create(filepath.Join(t.TempDir(), "out.rar"), "index.json"); err == nil {
+		t.Error("Create(out.rar) succeeded")
+	}
+}
+
+func TestPutFailed(t *testing.T) {
+	w, err := Create(filepath.Join(t.TempDir(), "out.tar.gz"), "index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	// tar can't store a name with a NUL in it.
+	for range 2 {
+		err := w.Put(context.Background(), "bad\x00name", []byte("x"))
+		if err == nil || strings.Contains(err.Error(), "already in the archive") {
+			t.Errorf("Put() of an unwritable name = %v, want the tar error", err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fired(ch <-chan time.Time) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestFakeAfter(t *testing.T) {
+	f := NewFake(epoch)
+	ch := f.After(time.Minute)
+
+	f.Advance(59 * time.Second)
+	if fired(ch) {
+		t.Fatal("After fired before its duration passed")
+	}
+	f.Advance(time.Second)
+	select {
+	case got := <-ch:
+		if want := epoch.Add(time.Minute); !got.Equal(want) {
+			t.Errorf("After sent %v, want %v", got, want)
+		}
+	default:
+		t.Fatal("After didn't fire once its duration passed")
+	}
+	if !fired(f.After(0)) {
+		t.Error("After(0) didn't fire immediately")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(epoch)
+	tk := f.NewTicker(10 * time.Second)
+
+	tests := []struct {
+		advance time.Duration
+		want    bool
+	}{
+		{5 * time.Second, false},
+		{5 * time.Second, true},
+		{9 * time.Second, false},
+		{time.Second, true},
+		// Ticks missed while nobody receives are dropped.
+		{35 * time.Second, true},
+		{4 * time.Second, false},
+		{time.Second, true},
+	}
+	for i, tt := range tests {
+		f.Advance(tt.advance)
+		if got := fired(tk.C()); got != tt.want {
+			t.Errorf("step %d: ticked = %v, want %v", i, got, tt.want)
+		}
+	}
+
+	tk.Stop()
+	f.Advance(time.Minute)
+	if fired(tk.C()) {
+		t.Error("stopped ticker ticked")
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	f := NewFake(epoch)
+	done := make(chan struct{})
+	go func() {
+		<-f.After(time.Hour)
+		close(done)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Hour)
+	<-done
+}
@@ -0,0 +1,125 @@
+// Package coverage reports which of a channel's videos have captions, and
+// whether they were written by people or generated by YouTube's automatic
+// speech recognition (ASR).
+package coverage
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/n2p5/ytt/internal/youtube"
+)
+
+// Video statuses, from best to worst covered.
+const (
+	StatusManual = "manual"
+	StatusASR    = "asr_only"
+	StatusNone   = "none"
+	// StatusUnknown is a video whose captions couldn't be listed.
+	StatusUnknown = "unknown"
+)
+
+// Video is one video's caption coverage.
+type Video struct {
+	VideoID string `json:"video_id"`
+	Title   string `json:"title"`
+	// Manual and ASR list the languages of the video's human-made and
+	// auto-generated tracks.
+	Manual []string `json:"manual"`
+	ASR    []string `json:"asr"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// Status summarizes the video's coverage.
+func (v Video) Status() string {
+	switch {
+	case v.Error != "":
+		return StatusUnknown
+	case len(v.Manual) > 0:
+		return StatusManual
+	case len(v.ASR) > 0:
+		return StatusASR
+	default:
+		return StatusNone
+	}
+}
+
+// FromTracks returns a video's coverage from its caption tracks.
+func FromTracks(videoID, title string, tracks []youtube.CaptionTrack) Video {
+	v := Video{VideoID: videoID, Title: title, Manual: []string{}, ASR: []string{}}
+	for _, t := range tracks {
+		if t.TrackKind == "asr" {
+			v.ASR = appendLang(v.ASR, t.Language)
+		} else {
+			v.Manual = appendLang(v.Manual, t.Language)
+		}
+	}
+	slices.Sort(v.Manual)
+	slices.Sort(v.ASR)
+	return v
+}
+
+func appendLang(langs []string, lang string) []string {
+	if slices.Contains(langs, lang) {
+		return langs
+	}
+	return append(langs, lang)
+}
+
+// Summary counts videos by status.
+type Summary struct {
+	Videos  int `json:"videos"`
+	Manual  int `json:"manual"`
+	ASROnly int `json:"asr_only"`
+	None    int `json:"none"`
+	Unknown int `json:"unknown"`
+}
+
+// Summarize counts videos by status.
+func Summarize(videos []Video) Summary {
+	s := Summary{Videos: len(videos)}
+	for _, v := range videos {
+		switch v.Status() {
+		case StatusManual:
+			s.Manual++
+		case StatusASR:
+			s.ASROnly++
+		case StatusNone:
+			s.None++
+		default:
+			s.Unknown++
+		}
+	}
+	return s
+}
+
+// Percent returns n as a percentage of the videos whose captions could be
+// listed.
+func (s Summary) Percent(n int) float64 {
+	known := s.Videos - s.Unknown
+	if known == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(known)
+}
+
+// WriteCSV writes videos as CSV with a header row. Languages are separated
+// by spaces.
+func WriteCSV(w io.Writer, videos []Video) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"video_id", "title", "status", "manual_languages", "asr_languages", "error"})
+	for _, v := range videos {
+		cw.Write([]string{
+			v.VideoID,
+			v.Title,
+			v.Status(),
+			strings.Join(v.Manual, " "),
+			strings.Join(v.ASR, " "),
+			v.Error,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,94 @@
+package coverage
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/n2p5/ytt/internal/youtube"
+)
+
+func TestFromTracks(t *testing.T) {
+	tests := []struct {
+		name       string
+		tracks     []youtube.CaptionTrack
+		wantManual []string
+		wantASR    []string
+		wantStatus string
+	}{
+		{
+			name:       "no tracks",
+			wantStatus: StatusNone,
+		},
+		{
+			name:       "asr only",
+			tracks:     []youtube.CaptionTrack{{Language: "en", TrackKind: "asr"}},
+			wantASR:    []string{"en"},
+			wantStatus: StatusASR,
+		},
+		{
+			name: "manual and asr",
+			tracks: []youtube.CaptionTrack{
+				{Language: "fr", TrackKind: "standard"},
+				{Language: "en", TrackKind: "asr"},
+				{Language: "de", TrackKind: "forced"},
+				{Language: "fr", TrackKind: "standard", Name: "Formal"},
+			},
+			wantManual: []string{"de", "fr"},
+			wantASR:    []string{"en"},
+			wantStatus: StatusManual,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := FromTracks("abc", "Title", tt.tracks)
+			if !slices.Equal(v.Manual, tt.wantManual) {
+				t.Errorf("Manual = %v, want %v", v.Manual, tt.wantManual)
+			}
+			if !slices.Equal(v.ASR, tt.wantASR) {
+				t.Errorf("ASR = %v, want %v", v.ASR, tt.wantASR)
+			}
+			if got := v.Status(); got != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	videos := []Video{
+		{VideoID: "a", Manual: []string{"en"}},
+		{VideoID: "b", ASR: []string{"en"}},
+		{VideoID: "c", ASR: []string{"en"}},
+		{VideoID: "d"},
+		{VideoID: "e", Error: "forbidden"},
+	}
+	s := Summarize(videos)
+	want := Summary{Videos: 5, Manual: 1, ASROnly: 2, None: 1, Unknown: 1}
+	if s != want {
+		t.Errorf("Summarize = %+v, want %+v", s, want)
+	}
+	if got := s.Percent(s.Manual); got != 25 {
+		t.Errorf("Percent(Manual) = %v, want 25", got)
+	}
+	if got := (Summary{}).Percent(0); got != 0 {
+		t.Errorf("Percent of no videos = %v, want 0", got)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	videos := []Video{
+		{VideoID: "a", Title: "One, two", Manual: []string{"de", "en"}, ASR: []string{"en"}},
+		{VideoID: "b", Title: "Three", Error: "forbidden"},
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, videos); err != nil {
+		t.Fatal(err)
+	}
+	want := "video_id,title,status,manual_languages,asr_languages,error\n" +
+		"a,\"One, two\",manual,de en,en,\n" +
+		"b,Three,unknown,,,forbidden\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,104 @@
+// Package crash records panics from batch workers as crash report bundles
+// so a single bad item can be investigated without aborting the run.
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Log holds the most recent log lines for inclusion in crash reports.
+var Log = NewRing(200)
+
+// Report is the content of a crash bundle.
+type Report struct {
+	Time      string         `json:"time"`
+	Job       string         `json:"job"`
+	Panic     string         `json:"panic"`
+	Stack     string         `json:"stack"`
+	Log       []string       `json:"log"`
+	Config    map[string]any `json:"config,omitempty"`
+	BuildInfo string         `json:"build_info,omitempty"`
+}
+
+// Write saves a crash report for a panic recovered while processing job and
+// returns the path of the bundle. Config is sanitized before it is written.
+func Write(dir, job string, recovered any, stack []byte, config map[string]any) (string, error) {
+	now := time.Now()
+	report := Report{
+		Time:   now.Format(time.RFC3339),
+		Job:    job,
+		Panic:  fmt.Sprint(recovered),
+		Stack:  string(stack),
+		Log:    Log.Lines(),
+		Config: Sanitize(config),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		report.BuildInfo = info.GoVersion + " " + info.Main.Version
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("unable to create crash directory: %w", err)
+	}
+
+	name := fmt.Sprintf("crash-%s-%s.json", now.Format("20060102-150405.000"), sanitizeJob(job))
+	path := filepath.Join(dir, name)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("unable to encode crash report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("unable to write crash report: %w", err)
+	}
+	return path, nil
+}
+
+var sensitiveKeys = []string{"secret", "password", "passphrase", "api_key", "apikey", "access_token", "refresh_token", "credential"}
+
+// Sanitize returns a copy of config with values of sensitive-looking keys redacted.
+func Sanitize(config map[string]any) map[string]any {
+	if config == nil {
+		return nil
+	}
+	out := make(map[string]any, len(config))
+	for k, v := range config {
+		switch {
+		case isSensitive(k):
+			out[k] = "[REDACTED]"
+		case isMap(v):
+			out[k] = Sanitize(v.(map[string]any))
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func isMap(v any) bool {
+	_, ok := v.(map[string]any)
+	return ok
+}
+
+func sanitizeJob(job string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, job)
+}
@@ -0,0 +1,43 @@
+package crash
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := NewRing(3)
+	for i := 1; i <= 4; i++ {
+		fmt.Fprintf(r, "line %d\n", i)
+	}
+	fmt.Fprint(r, "partial")
+
+	want := []string{"line 2", "line 3", "line 4", "partial"}
+	if got := r.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	config := map[string]any{
+		"oauth":         "secrets/oauth.json",
+		"client_secret": "hunter2",
+		"profile": map[string]any{
+			"refresh_token": "abc",
+			"output":        "outputs",
+		},
+	}
+
+	want := map[string]any{
+		"oauth":         "secrets/oauth.json",
+		"client_secret": "[REDACTED]",
+		"profile": map[string]any{
+			"refresh_token": "[REDACTED]",
+			"output":        "outputs",
+		},
+	}
+	if got := Sanitize(config); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sanitize() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package crash
+
+import (
+	"strings"
+	"sync"
+)
+
+// Ring is an io.Writer that keeps the last n lines written to it.
+type Ring struct {
+	mu      sync.Mutex
+	lines   []string
+	next    int
+	full    bool
+	partial string
+}
+
+// NewRing creates a Ring holding up to n lines.
+func NewRing(n int) *Ring {
+	return &Ring{lines: make([]string, n)}
+}
+
+// Write records p, splitting it into lines. Incomplete trailing lines are
+// buffered until the rest of the line arrives.
+func (r *Ring) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.partial + string(p)
+	parts := strings.Split(s, "\n")
+	r.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		r.lines[r.next] = line
+		r.next = (r.next + 1) % len(r.lines)
+		if r.next == 0 {
+			r.full = true
+		}
+	}
+	return len(p), nil
+}
+
+// Lines returns the buffered lines, oldest first.
+func (r *Ring) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []string
+	if r.full {
+		out = append(out, r.lines[r.next:]...)
+	}
+	out = append(out, r.lines[:r.next]...)
+	if r.partial != "" {
+		out = append(out, r.partial)
+	}
+	return out
+}
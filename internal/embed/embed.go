@@ -0,0 +1,147 @@
+// Package embed turns transcript passages into embedding vectors, keeps
+// them in an index beside the transcripts, and finds the passages closest
+// to a question.
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Provider computes embedding vectors.
+type Provider interface {
+	// Name identifies the provider and model, so vectors from different
+	// models are never compared.
+	Name() string
+	// Embed returns a vector for each text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// OpenAI calls an OpenAI-compatible embeddings endpoint, as served by
+// OpenAI and by local servers such as Ollama, llama.cpp, and vLLM.
+type OpenAI struct {
+	// URL is the API's base URL, such as https://api.openai.com/v1;
+	// requests go to URL/embeddings.
+	URL    string
+	Model  string
+	APIKey string
+	Client *http.Client
+}
+
+// Name implements Provider.
+func (p OpenAI) Name() string {
+	return "openai:" + p.Model + "@" + p.URL
+}
+
+// Embed implements Provider.
+func (p OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": p.Model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimSuffix(p.URL, "/") + "/embeddings"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating embeddings request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling embeddings endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embeddings endpoint returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("error decoding embeddings: %w", err)
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings endpoint returned index %d for %d texts", d.Index, len(texts))
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return checkVectors(vectors)
+}
+
+// Command runs a shell command for each batch of texts, such as a script
+// running a local ONNX model. The command reads the texts from stdin as a
+// JSON array of strings and prints a JSON array of vectors, one for each.
+type Command struct {
+	Command string
+	Stderr  io.Writer
+}
+
+// Name implements Provider.
+func (p Command) Name() string {
+	return "command:" + p.Command
+}
+
+// Embed implements Provider.
+func (p Command) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	in, err := json.Marshal(texts)
+	if err != nil {
+		return nil, err
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", p.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.Command)
+	}
+	var out bytes.Buffer
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &out
+	cmd.Stderr = p.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error running embedding command: %w", err)
+	}
+
+	var vectors [][]float32
+	if err := json.Unmarshal(out.Bytes(), &vectors); err != nil {
+		return nil, fmt.Errorf("error decoding embedding command output: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding command printed %d vectors for %d texts", len(vectors), len(texts))
+	}
+	return checkVectors(vectors)
+}
+
+// checkVectors makes sure every vector is there and they are all the same
+// length.
+func checkVectors(vectors [][]float32) ([][]float32, error) {
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("no embedding for text %d", i)
+		}
+		if len(v) != len(vectors[0]) {
+			return nil, fmt.Errorf("embeddings have %d and %d dimensions", len(vectors[0]), len(v))
+		}
+	}
+	return vectors, nil
+}
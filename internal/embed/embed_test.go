@@ -0,0 +1,68 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestOpenAI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "small" || len(req.Input) != 2 {
+			http.Error(w, "bad body", http.StatusBadRequest)
+			return
+		}
+		// Out of order, as the API allows.
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	p := OpenAI{URL: srv.URL + "/v1/", Model: "small", APIKey: "key"}
+	got, err := p.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{1, 0}, {0, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Embed() = %v, want %v", got, want)
+	}
+
+	p.APIKey = "wrong"
+	if _, err := p.Embed(context.Background(), []string{"a", "b"}); err == nil {
+		t.Error("Embed() with a rejected key succeeded")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	tests := []struct {
+		command string
+		want    [][]float32
+		wantErr bool
+	}{
+		{`cat >/dev/null; echo '[[1,2],[3,4]]'`, [][]float32{{1, 2}, {3, 4}}, false},
+		{`echo '[[1,2]]'`, nil, true},
+		{`echo '[[1,2],[3]]'`, nil, true},
+		{`echo nope`, nil, true},
+		{`exit 1`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := Command{Command: tt.command}.Embed(context.Background(), []string{"a", "b"})
+		if !reflect.DeepEqual(got, tt.want) || (err != nil) != tt.wantErr {
+			t.Errorf("Embed() with %q = %v, %v, want %v, error %v", tt.command, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package embed
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/filelock"
+	"github.com/n2p5/ytt/internal/transcript"
+)
+
+// File is where the index is kept, relative to the output directory.
+const File = ".ytt/embeddings.json"
+
+// The passage size the index is built with, as for transcript.Chunks.
+// Passages are kept short so each one answers a question on its own.
+const (
+	ChunkTokens  = 200
+	ChunkOverlap = 20
+)
+
+// batchSize is how many passages are embedded at once.
+const batchSize = 64
+
+// Index holds the embedded passages of an archive's transcripts.
+type Index struct {
+	// Provider is the Name of the provider the vectors came from. Vectors
+	// from another provider are thrown away, as they can't be compared.
+	Provider     string           `json:"provider"`
+	ChunkTokens  int              `json:"chunk_tokens"`
+	ChunkOverlap int              `json:"chunk_overlap"`
+	Videos       map[string]Video `json:"videos"`
+}
+
+// Video is a video's embedded passages.
+type Video struct {
+	Title     string `json:"title,omitempty"`
+	ChannelID string `json:"channel_id,omitempty"`
+	// Version identifies the transcript the passages were taken from, so
+	// they are embedded again when it changes.
+	Version  string    `json:"version"`
+	Passages []Passage `json:"passages"`
+}
+
+// Passage is a chunk of a transcript and its vector, scaled to unit length.
+type Passage struct {
+	Start  time.Duration `json:"start"`
+	End    time.Duration `json:"end"`
+	Text   string        `json:"text"`
+	Vector []float32     `json:"vector"`
+}
+
+// Load reads the index of the archive in dir. A missing index is empty.
+func Load(dir string) (*Index, error) {
+	ix := &Index{Videos: map[string]Video{}}
+	data, err := os.ReadFile(filepath.Join(dir, File))
+	if errors.Is(err, os.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading embedding index: %w", err)
+	}
+	if err := json.Unmarshal(data, ix); err != nil {
+		return nil, fmt.Errorf("error parsing embedding index: %w", err)
+	}
+	if ix.Videos == nil {
+		ix.Videos = map[string]Video{}
+	}
+	return ix, nil
+}
+
+// Save writes the index into the archive in dir, replacing it atomically
+// while holding its lock.
+func (ix *Index) Save(dir string) error {
+	path := filepath.Join(dir, File)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating embedding index directory: %w", err)
+	}
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return err
+	}
+	return filelock.With(path, func() error { return writeIndex(path, data) })
+}
+
+// writeIndex atomically replaces the index file at path with data.
+func writeIndex(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("error writing embedding index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing embedding index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing embedding index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error replacing embedding index: %w", err)
+	}
+	return nil
+}
+
+// UpdateSummary reports what Update did.
+type UpdateSummary struct {
+	// Embedded is the number of videos embedded, and Removed the number
+	// dropped because they left the archive.
+	Embedded int
+	Removed  int
+	// Skipped are the read errors of transcripts that weren't embedded. A
+	// video already in the index keeps its earlier embeddings, and each is
+	// tried again by the next Update.
+	Skipped []error
+}
+
+// Update brings the index up to date with the archive: it embeds the
+// passages of videos that are new or whose transcript changed, and drops
+// videos no longer in the archive. before, if set, is called before each
+// video is embedded. If Update fails partway, the videos embedded so far
+// are kept, so saving the index doesn't lose them.
+func (ix *Index) Update(ctx context.Context, a *archive.Archive, p Provider, before func(videoID string)) (*UpdateSummary, error) {
+	if ix.Provider != p.Name() || ix.ChunkTokens != ChunkTokens || ix.ChunkOverlap != ChunkOverlap {
+		ix.Provider, ix.ChunkTokens, ix.ChunkOverlap = p.Name(), ChunkTokens, ChunkOverlap
+		clear(ix.Videos)
+	}
+	videos, err := a.Videos()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &UpdateSummary{}
+	inArchive := map[string]bool{}
+	for _, v := range videos {
+		inArchive[v.VideoID] = true
+	}
+	for id := range ix.Videos {
+		if !inArchive[id] {
+			delete(ix.Videos, id)
+			summary.Removed++
+		}
+	}
+
+	for _, v := range videos {
+		version := cmp.Or(v.Hash, v.UpdatedAt.UTC().Format(time.RFC3339Nano))
+		if old, ok := ix.Videos[v.VideoID]; ok && old.Version == version {
+			continue
+		}
+		cues, err := a.Cues(v.VideoID)
+		if err != nil {
+			summary.Skipped = append(summary.Skipped, fmt.Errorf("error reading transcript for %s: %w", v.VideoID, err))
+			continue
+		}
+		if before != nil {
+			before(v.VideoID)
+		}
+		passages, err := embedChunks(ctx, p, transcript.Chunks(cues, ChunkTokens, ChunkOverlap))
+		if err != nil {
+			return summary, fmt.Errorf("error embedding %s: %w", v.VideoID, err)
+		}
+		ix.Videos[v.VideoID] = Video{Title: v.Title, ChannelID: v.ChannelID, Version: version, Passages: passages}
+		summary.Embedded++
+	}
+	return summary, nil
+}
+
+// embedChunks embeds chunks in batches.
+func embedChunks(ctx context.Context, p Provider, chunks []transcript.Chunk) ([]Passage, error) {
+	passages := make([]Passage, 0, len(chunks))
+	for batch := range slices.Chunk(chunks, batchSize) {
+		texts := make([]string, len(batch))
+		for i, c := range batch {
+			texts[i] = c.Text
+		}
+		vectors, err := p.Embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		for i, c := range batch {
+			passages = append(passages, Passage{Start: c.Start, End: c.End, Text: c.Text, Vector: normalize(vectors[i])})
+		}
+	}
+	return passages, nil
+}
+
+// Hit is a passage found by Search.
+type Hit struct {
+	VideoID string
+	Title   string
+	Start   time.Duration
+	End     time.Duration
+	Text    string
+	// Score is the cosine similarity of the passage to the query, from -1
+	// to 1.
+	Score float64
+}
+
+// Search returns the limit passages closest to query, best first,
+// restricted to one channel's videos if channelID is set.
+func (ix *Index) Search(query []float32, limit int, channelID string) []Hit {
+	query = normalize(query)
+	var hits []Hit
+	for id, v := range ix.Videos {
+		if channelID != "" && v.ChannelID != channelID {
+			continue
+		}
+		for _, p := range v.Passages {
+			if len(p.Vector) != len(query) {
+				continue
+			}
+			hits = append(hits, Hit{VideoID: id, Title: v.Title, Start: p.Start, End: p.End, Text: p.Text, Score: dot(query, p.Vector)})
+		}
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.VideoID, b.VideoID), cmp.Compare(a.Start, b.Start))
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// Len returns the number of passages in the index.
+func (ix *Index) Len() int {
+	n := 0
+	for _, v := range ix.Videos {
+		n += len(v.Passages)
+	}
+	return n
+}
+
+// normalize returns v scaled to unit length, so the dot product of two
+// vectors is their cosine similarity.
+func normalize(v []float32) []float32 {
+	norm := math.Sqrt(dot(v, v))
+	if norm == 0 {
+		return v
+	}
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,132 @@
+package embed
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/manifest"
+)
+
+// wordProvider embeds texts by whether they mention each of its words.
+type wordProvider struct {
+	words []string
+	calls int
+}
+
+func (p *wordProvider) Name() string { return "words:" + strings.Join(p.words, ",") }
+
+func (p *wordProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	p.calls++
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = p.vector(text)
+	}
+	return vectors, nil
+}
+
+func (p *wordProvider) vector(text string) []float32 {
+	v := make([]float32, len(p.words)+1)
+	v[len(p.words)] = 0.1
+	for j, w := range p.words {
+		if strings.Contains(text, w) {
+			v[j] = 1
+		}
+	}
+	return v
+}
+
+func writeArchive(t *testing.T, dir string, videos map[string]string) {
+	t.Helper()
+	w, err := manifest.OpenWriter(dir, manifest.WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, content := range videos {
+		name := id + ".srt"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		w.Record(manifest.Entry{VideoID: id, Title: "Title " + id, ChannelID: "UC" + id, File: name, Hash: content, Status: manifest.StatusOK})
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, dir, map[string]string{
+		"cats":  "1\n00:00:05,000 --> 00:00:07,000\nall about cats\n",
+		"dogs":  "1\n00:00:01,000 --> 00:00:02,000\ndogs bark\n",
+		"mixed": "1\n00:01:00,000 --> 00:01:02,000\ncats and dogs\n",
+	})
+	a, err := archive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &wordProvider{words: []string{"cats", "dogs"}}
+
+	ix, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := ix.Update(context.Background(), a, p, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Embedded != 3 || ix.Len() != 3 {
+		t.Errorf("first Update embedded %d videos, %d passages, want 3 and 3", summary.Embedded, ix.Len())
+	}
+	if err := ix.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	ix, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := p.calls
+	if summary, err = ix.Update(context.Background(), a, p, nil); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Embedded != 0 || p.calls != calls {
+		t.Errorf("Update of an unchanged archive embedded %d videos in %d calls, want none", summary.Embedded, p.calls-calls)
+	}
+
+	hits := ix.Search(p.vector("cats"), 2, "")
+	if len(hits) != 2 || hits[0].VideoID != "cats" || hits[1].VideoID != "mixed" {
+		t.Fatalf("Search(cats) = %+v, want cats then mixed", hits)
+	}
+	if hits[0].Start.Seconds() != 5 || hits[0].Text != "all about cats" || hits[0].Score < 0.99 {
+		t.Errorf("Search(cats) first hit = %+v", hits[0])
+	}
+	if hits := ix.Search(p.vector("cats"), 0, "UCdogs"); len(hits) != 1 || hits[0].VideoID != "dogs" {
+		t.Errorf("Search(cats) in UCdogs = %+v, want only dogs", hits)
+	}
+
+	// A changed transcript is embedded again, and a removed one dropped.
+	os.Remove(filepath.Join(dir, manifest.File))
+	writeArchive(t, dir, map[string]string{"cats": "1\n00:00:05,000 --> 00:00:07,000\nmore about cats\n"})
+	if a, err = archive.Open(dir); err != nil {
+		t.Fatal(err)
+	}
+	if summary, err = ix.Update(context.Background(), a, p, nil); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Embedded != 1 || summary.Removed != 2 || ix.Len() != 1 {
+		t.Errorf("Update after changes = %+v with %d passages, want 1 embedded, 2 removed, 1 passage", summary, ix.Len())
+	}
+
+	// Another provider's vectors are thrown away.
+	other := &wordProvider{words: []string{"birds"}}
+	if summary, err = ix.Update(context.Background(), a, other, nil); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Embedded != 1 || ix.Provider != other.Name() {
+		t.Errorf("Update with another provider = %+v, provider %q", summary, ix.Provider)
+	}
+}
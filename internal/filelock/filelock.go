@@ -0,0 +1,132 @@
+// Package filelock serializes updates to files that several ytt processes
+// share, such as a cron job and a manual run writing the same manifest or
+// refreshing the same token.
+//
+// A file's lock is a lock file beside it, created exclusively and removed
+// when released. A lock left behind by a process that crashed is taken
+// over once it is older than StaleAfter, so locks must only be held for
+// short updates.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+)
+
+// Suffix is added to a file's path to name its lock file.
+const Suffix = ".lock"
+
+var (
+	// Timeout is how long Acquire waits for another process to release a
+	// lock before giving up.
+	Timeout = 2 * time.Minute
+	// StaleAfter is the age past which a lock is taken to belong to a
+	// process that exited without releasing it.
+	StaleAfter = time.Minute
+)
+
+// ErrTimeout is returned when a lock isn't released within Timeout.
+var ErrTimeout = errors.New("timed out waiting for lock")
+
+// A Lock is a held lock on a file.
+type Lock struct {
+	path string
+	// content identifies this lock among any that replace it in the file.
+	content string
+}
+
+// Acquire locks the file at path, waiting for any other process holding it.
+// The file itself needn't exist, but its directory must.
+func Acquire(path string) (*Lock, error) {
+	lockPath := path + Suffix
+	deadline := time.Now().Add(Timeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			host, _ := os.Hostname()
+			content := fmt.Sprintf("%d %s %s %x\n", os.Getpid(), host, time.Now().UTC().Format(time.RFC3339), rand.Uint64())
+			_, err := f.WriteString(content)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return nil, fmt.Errorf("error creating lock %s: %w", lockPath, err)
+			}
+			return &Lock{path: lockPath, content: content}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("error creating lock %s: %w", lockPath, err)
+		}
+
+		info, err := os.Stat(lockPath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			// Released since we tried; try again at once.
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("error checking lock %s: %w", lockPath, err)
+		case time.Since(info.ModTime()) > StaleAfter:
+			breakStale(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w %s, held by %s", ErrTimeout, lockPath, owner(lockPath))
+		}
+		time.Sleep(10*time.Millisecond + rand.N(40*time.Millisecond))
+	}
+}
+
+// Release unlocks the file. It leaves alone a lock that has since been
+// taken over as stale by another process.
+func (l *Lock) Release() error {
+	if data, err := os.ReadFile(l.path); err != nil || string(data) != l.content {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error releasing lock %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// With runs fn while holding the lock on the file at path.
+func With(path string, fn func() error) error {
+	l, err := Acquire(path)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+	return fn()
+}
+
+// breakStale removes the stale lock file at path. The lock is first moved
+// aside, so that of several processes finding it stale only one removes
+// it, and moved back if another process had replaced it with a fresh lock
+// in the meantime.
+func breakStale(path string) {
+	aside := fmt.Sprintf("%s.stale%x", path, rand.Uint64())
+	if err := os.Rename(path, aside); err != nil {
+		return
+	}
+	if info, err := os.Stat(aside); err == nil && time.Since(info.ModTime()) <= StaleAfter {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			os.Rename(aside, path)
+			return
+		}
+	}
+	os.Remove(aside)
+}
+
+// owner describes the process holding the lock file at path.
+func owner(path string) string {
+	data, err := os.ReadFile(path)
+	fields := strings.Fields(string(data))
+	if err != nil || len(fields) < 2 {
+		return "another process"
+	}
+	return fmt.Sprintf("process %s on %s", fields[0], fields[1])
+}
@@ -0,0 +1,96 @@
+package filelock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithSerializes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter")
+	if err := os.WriteFile(path, []byte("0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unlocked, these read-modify-write cycles would lose increments.
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := With(path, func() error {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				n, _ := strconv.Atoi(string(data))
+				time.Sleep(time.Millisecond)
+				return os.WriteFile(path, []byte(strconv.Itoa(n+1)), 0644)
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "20" {
+		t.Errorf("counter = %s, want 20", data)
+	}
+	if _, err := os.Stat(path + Suffix); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestAcquireTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { Timeout = timeout }(Timeout)
+	Timeout = 50 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "file")
+	l, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Acquire(path); !errors.Is(err, ErrTimeout) {
+		t.Errorf("second Acquire() error = %v, want ErrTimeout", err)
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	l, err = Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire() after Release = %v", err)
+	}
+	l.Release()
+}
+
+func TestStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	stale, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * StaleAfter)
+	if err := os.Chtimes(path+Suffix, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire() over stale lock = %v", err)
+	}
+	// The crashed holder's late release leaves the new lock alone.
+	stale.Release()
+	if _, err := os.Stat(path + Suffix); err != nil {
+		t.Errorf("new lock removed by stale holder: %v", err)
+	}
+	l.Release()
+	if matches, _ := filepath.Glob(path + Suffix + "*"); len(matches) != 0 {
+		t.Errorf("lock files left behind: %v", matches)
+	}
+}
@@ -0,0 +1,161 @@
+// Package filename holds the rules operating systems set for file names
+// and paths, so the names ytt makes from video titles are valid wherever
+// its output ends up. Each platform's rules are a plain value, so tests on
+// any system can check all of them.
+package filename
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Rules are the limits a platform puts on file names and paths.
+type Rules struct {
+	// OS is the GOOS the rules are for, or "" for Portable.
+	OS string
+	// Invalid are the characters a name can't contain, including the path
+	// separators.
+	Invalid string
+	// NoControl forbids the control characters U+0001 to U+001F.
+	NoControl bool
+	// Reserved are device names that can't name files, whatever their
+	// case and extension.
+	Reserved []string
+	// NoTrailing are the characters a name can't end with.
+	NoTrailing string
+	// MaxName is the longest name, in bytes, a directory can hold.
+	MaxName int
+	// MaxPath is the longest path, in UTF-16 code units, most programs can
+	// open, or 0 if there is no practical limit.
+	MaxPath int
+}
+
+// windowsReserved are the device names Windows reserves in every
+// directory.
+var windowsReserved = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// Rules for each platform ytt runs on. Windows paths are limited to
+// MAX_PATH, 260 characters with the terminating NUL, unless long paths are
+// enabled on the system and in the program opening them.
+var (
+	Windows = Rules{OS: "windows", Invalid: `<>:"/\|?*`, NoControl: true, Reserved: windowsReserved, NoTrailing: " .", MaxName: 255, MaxPath: 259}
+	Darwin  = Rules{OS: "darwin", Invalid: "/:", MaxName: 255}
+	Unix    = Rules{OS: "linux", Invalid: "/", MaxName: 255}
+
+	// Portable combines the rules of every platform, for names that must be
+	// valid on all of them, such as those in an archive copied or synced
+	// between machines.
+	Portable = Rules{Invalid: `<>:"/\|?*`, NoControl: true, Reserved: windowsReserved, NoTrailing: " .", MaxName: 255, MaxPath: 259}
+
+	// Current are the rules of the system ytt is running on.
+	Current = For(runtime.GOOS)
+)
+
+// For returns the rules for goos, taking any system other than Windows and
+// macOS to be a Unix.
+func For(goos string) Rules {
+	switch goos {
+	case "windows":
+		return Windows
+	case "darwin", "ios":
+		return Darwin
+	}
+	r := Unix
+	r.OS = goos
+	return r
+}
+
+// Sanitize makes name valid under r: it replaces forbidden characters
+// with "_", shortens it to max bytes (MaxName if max is 0) without
+// splitting a character, trims spaces and dots from both ends, so it can't
+// end in one or start a hidden file, and adds "_" to a reserved name
+// before its extension. It reports whether the name was shortened.
+func (r Rules) Sanitize(name string, max int) (string, bool) {
+	if max <= 0 {
+		max = r.MaxName
+	}
+	name = strings.Map(func(c rune) rune {
+		if r.forbidden(c) {
+			return '_'
+		}
+		return c
+	}, name)
+
+	truncated := len(name) > max
+	if truncated {
+		cut := max
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = name[:cut]
+	}
+	name = strings.Trim(name, " .")
+	if r.reserved(name) {
+		base, ext, _ := strings.Cut(name, ".")
+		name = base + "_"
+		if ext != "" {
+			name += "." + ext
+		}
+	}
+	return name, truncated
+}
+
+// Check returns an error saying why name isn't a valid file name under r,
+// or nil if it is.
+func (r Rules) Check(name string) error {
+	switch {
+	case name == "" || name == "." || name == "..":
+		return fmt.Errorf("%q is not a file name", name)
+	case len(name) > r.MaxName:
+		return fmt.Errorf("file name %q is %d bytes, over %d", name, len(name), r.MaxName)
+	case r.reserved(name):
+		return fmt.Errorf("file name %q is reserved for a device", name)
+	case r.NoTrailing != "" && strings.ContainsAny(name[len(name)-1:], r.NoTrailing):
+		return fmt.Errorf("file name %q ends with %q", name, name[len(name)-1:])
+	}
+	for _, c := range name {
+		if r.forbidden(c) {
+			return fmt.Errorf("file name %q contains %q", name, c)
+		}
+	}
+	return nil
+}
+
+// PathTooLong reports whether path is longer than r's MaxPath.
+func (r Rules) PathTooLong(path string) bool {
+	return r.MaxPath > 0 && PathLength(path) > r.MaxPath
+}
+
+// PathLength returns the length of path as Windows counts it, in UTF-16
+// code units.
+func PathLength(path string) int {
+	n := 0
+	for _, c := range path {
+		n += utf16.RuneLen(c)
+	}
+	return n
+}
+
+func (r Rules) forbidden(c rune) bool {
+	return c == 0 || (r.NoControl && c < 0x20) || strings.ContainsRune(r.Invalid, c)
+}
+
+// reserved reports whether name, less any extension, is a reserved device
+// name.
+func (r Rules) reserved(name string) bool {
+	base, _, _ := strings.Cut(name, ".")
+	base = strings.TrimRight(base, " ")
+	for _, res := range r.Reserved {
+		if strings.EqualFold(base, res) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package filename
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+		in    string
+		max   int
+		want  string
+		short bool
+	}{
+		{"windows characters", Windows, `a<b>c:d"e/f\g|h?i*j`, 0, "a_b_c_d_e_f_g_h_i_j", false},
+		{"unix keeps windows characters", Unix, `a<b>:c\d`, 0, `a<b>:c\d`, false},
+		{"unix separator", Unix, "a/b", 0, "a_b", false},
+		{"darwin colon", Darwin, "a:b", 0, "a_b", false},
+		{"control characters", Windows, "a\tb\x01c", 0, "a_b_c", false},
+		{"nul everywhere", Unix, "a\x00b", 0, "a_b", false},
+		{"trailing dots and spaces", Portable, " .name. ", 0, "name", false},
+		{"reserved", Windows, "NUL", 0, "NUL_", false},
+		{"reserved with extension", Windows, "com1.txt", 0, "com1_.txt", false},
+		{"not reserved", Windows, "console", 0, "console", false},
+		{"unix allows reserved", Unix, "nul", 0, "nul", false},
+		{"truncated", Portable, strings.Repeat("a", 10), 4, "aaaa", true},
+		{"truncated between characters", Portable, "aéé", 4, "aé", true},
+		{"max name", Unix, strings.Repeat("x", 300), 0, strings.Repeat("x", 255), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, short := tt.rules.Sanitize(tt.in, tt.max)
+			if got != tt.want || short != tt.short {
+				t.Errorf("Sanitize(%q) = %q, %v; want %q, %v", tt.in, got, short, tt.want, tt.short)
+			}
+			if err := tt.rules.Check(got); got != "" && err != nil {
+				t.Errorf("Check(Sanitize(%q)) = %v", tt.in, err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		rules Rules
+		name  string
+		ok    bool
+	}{
+		{Windows, "abc-Title.txt", true},
+		{Windows, "a:b.txt", false},
+		{Windows, "aux.srt", false},
+		{Windows, "name.", false},
+		{Unix, "name.", true},
+		{Unix, "a:b", true},
+		{Darwin, "a:b", false},
+		{Unix, "..", false},
+		{Unix, strings.Repeat("x", 256), false},
+	}
+	for _, tt := range tests {
+		if err := tt.rules.Check(tt.name); (err == nil) != tt.ok {
+			t.Errorf("%s Check(%q) = %v, want ok %v", tt.rules.OS, tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestFor(t *testing.T) {
+	for goos, want := range map[string]string{"windows": "windows", "darwin": "darwin", "linux": "linux", "freebsd": "freebsd"} {
+		if got := For(goos); got.OS != want {
+			t.Errorf("For(%q).OS = %q, want %q", goos, got.OS, want)
+		}
+	}
+	if For("freebsd").Invalid != Unix.Invalid {
+		t.Errorf("For(freebsd) = %+v, want Unix rules", For("freebsd"))
+	}
+}
+
+func TestPathTooLong(t *testing.T) {
+	long := `C:\Users\me\` + strings.Repeat("a", 250)
+	if !Windows.PathTooLong(long) {
+		t.Errorf("Windows.PathTooLong(%d chars) = false", len(long))
+	}
+	if Unix.PathTooLong(long) {
+		t.Error("Unix.PathTooLong() = true, want no limit")
+	}
+	// Characters outside the BMP count twice, as surrogate pairs.
+	if n := PathLength("a😀"); n != 3 {
+		t.Errorf("PathLength() = %d, want 3", n)
+	}
+}
@@ -0,0 +1,60 @@
+// Package gitcommit versions an output directory that lives in a Git
+// repository by committing its changes with the git command.
+package gitcommit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNotRepo is returned by Commit for a directory outside a Git work tree.
+var ErrNotRepo = errors.New("not inside a Git repository")
+
+// Commit stages every change under dir, except the trash, and commits it
+// with message. Changes elsewhere in the repository are left alone. It
+// reports whether a commit was made; nothing is committed if dir has no
+// changes.
+func Commit(dir, message string) (bool, error) {
+	if out, err := git(dir, "rev-parse", "--is-inside-work-tree"); err != nil || strings.TrimSpace(out) != "true" {
+		return false, fmt.Errorf("error committing %s: %w", dir, ErrNotRepo)
+	}
+
+	pathspec := []string{"--", ".", ":(exclude).trash"}
+	if _, err := git(dir, append([]string{"add", "--all"}, pathspec...)...); err != nil {
+		return false, fmt.Errorf("error staging changes: %w", err)
+	}
+	// diff --quiet exits 1 when there are staged changes, and with another
+	// status when it fails.
+	_, err := git(dir, append([]string{"diff", "--cached", "--quiet"}, pathspec...)...)
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case !errors.As(err, &exitErr) || exitErr.ExitCode() != 1:
+		return false, fmt.Errorf("error checking for staged changes: %w", err)
+	}
+	if _, err := git(dir, append([]string{"commit", "--quiet", "--message", message}, pathspec...)...); err != nil {
+		return false, fmt.Errorf("error committing changes: %w", err)
+	}
+	return true, nil
+}
+
+// git runs a git command in dir and returns its output. A failing command's
+// error includes what it printed on stderr.
+func git(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
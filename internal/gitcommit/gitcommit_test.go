@@ -0,0 +1,113 @@
+package gitcommit
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	repo := t.TempDir()
+	if _, err := git(repo, "init", "--quiet"); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	repo := setupRepo(t)
+	dir := filepath.Join(repo, "outputs")
+	writeFile(t, filepath.Join(dir, "a-One.txt"), "one\n")
+	writeFile(t, filepath.Join(dir, ".trash", "old.txt"), "old\n")
+	writeFile(t, filepath.Join(repo, "notes.txt"), "not a transcript\n")
+
+	committed, err := Commit(dir, "sync: 1 new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !committed {
+		t.Fatal("Commit() = false, want a commit")
+	}
+
+	files, err := git(repo, "ls-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(files); len(got) != 1 || got[0] != "outputs/a-One.txt" {
+		t.Errorf("committed files = %v, want [outputs/a-One.txt]", got)
+	}
+	msg, err := git(repo, "log", "-1", "--format=%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(msg); got != "sync: 1 new" {
+		t.Errorf("commit message = %q, want %q", got, "sync: 1 new")
+	}
+
+	committed, err = Commit(dir, "sync: nothing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if committed {
+		t.Error("Commit() with no changes = true, want false")
+	}
+}
+
+func TestCommitNotRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	dir := t.TempDir()
+	if _, err := Commit(dir, "sync"); !errors.Is(err, ErrNotRepo) {
+		t.Errorf("Commit() error = %v, want ErrNotRepo", err)
+	}
+}
+
+func TestCommitDiffFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake git is a shell script")
+	}
+	// A git whose diff fails rather than reporting changes.
+	bin := t.TempDir()
+	writeFile(t, filepath.Join(bin, "git"), `#!/bin/sh
+case $1 in
+rev-parse) echo true ;;
+diff) echo "fatal: unable to read index" >&2; exit 128 ;;
+commit) echo "committed" >&2; exit 1 ;;
+esac
+`)
+	if err := os.Chmod(filepath.Join(bin, "git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	committed, err := Commit(t.TempDir(), "sync")
+	if committed || err == nil || !strings.Contains(err.Error(), "unable to read index") {
+		t.Errorf("Commit() = %v, %v; want the diff error", committed, err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package graphql executes read-only GraphQL queries against a schema of Go
+// resolvers.
+//
+// It implements the subset of GraphQL that dashboards querying the archive
+// need: query operations with fields, aliases, arguments, variables (with
+// defaults), nested selections, and __typename. Fragments, directives,
+// mutations, subscriptions, and introspection are not supported, and
+// argument types are not validated beyond what resolvers check themselves.
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// ResolveFunc computes a field's value from its parent object and arguments.
+// The parent is nil for fields of the query root.
+type ResolveFunc func(parent any, args map[string]any) (any, error)
+
+// Field is a field on an Object.
+type Field struct {
+	// Type is the object type the field returns, or nil for scalars. A
+	// resolver for an object-typed field may return a single value or a
+	// slice of them.
+	Type    *Object
+	Resolve ResolveFunc
+}
+
+// Object is a GraphQL object type.
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+// Schema is a set of types reachable from the query root.
+type Schema struct {
+	Query *Object
+}
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Error is a GraphQL error.
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Response is a GraphQL response.
+type Response struct {
+	Data   any     `json:"data,omitempty"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Execute runs a query against the schema. Errors in individual fields are
+// reported in Response.Errors with the field set to null, as GraphQL requires.
+func (s *Schema) Execute(req Request) Response {
+	ops, err := parse(req.Query)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	op, err := selectOperation(ops, req.OperationName)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	vars := map[string]any{}
+	for k, v := range op.defaults {
+		vars[k] = v
+	}
+	for k, v := range req.Variables {
+		vars[k] = v
+	}
+
+	e := &executor{vars: vars}
+	data := e.object(s.Query, nil, op.selection, nil)
+	return Response{Data: data, Errors: e.errors}
+}
+
+// ServeHTTP implements http.Handler. It accepts a JSON Request in a POST body,
+// or query, operationName, and variables as URL parameters on GET.
+func (s *Schema) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeJSON(w, http.StatusBadRequest, Response{Errors: []Error{{Message: "invalid variables: " + err.Error()}}})
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, Response{Errors: []Error{{Message: "invalid request body: " + err.Error()}}})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.Execute(req))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func selectOperation(ops []operation, name string) (operation, error) {
+	if name == "" {
+		if len(ops) > 1 {
+			return operation{}, fmt.Errorf("operationName is required when the query has several operations")
+		}
+		return ops[0], nil
+	}
+	for _, op := range ops {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return operation{}, fmt.Errorf("unknown operation %q", name)
+}
+
+type executor struct {
+	vars   map[string]any
+	errors []Error
+}
+
+func (e *executor) object(typ *Object, parent any, sels []selection, path []any) *orderedMap {
+	out := &orderedMap{}
+	for _, sel := range sels {
+		fieldPath := append(append([]any{}, path...), sel.alias)
+
+		if sel.name == "__typename" {
+			out.set(sel.alias, typ.Name)
+			continue
+		}
+		field, ok := typ.Fields[sel.name]
+		if !ok {
+			e.fail(fieldPath, "cannot query field %q on type %q", sel.name, typ.Name)
+			out.set(sel.alias, nil)
+			continue
+		}
+
+		args := make(map[string]any, len(sel.args))
+		for name, v := range sel.args {
+			args[name] = v.resolve(e.vars)
+		}
+		result, err := field.Resolve(parent, args)
+		if err != nil {
+			e.fail(fieldPath, "%v", err)
+			out.set(sel.alias, nil)
+			continue
+		}
+		out.set(sel.alias, e.complete(field, sel, result, fieldPath))
+	}
+	return out
+}
+
+// complete applies a field's sub-selection to its resolved value.
+func (e *executor) complete(field *Field, sel selection, result any, path []any) any {
+	if field.Type == nil {
+		if len(sel.children) > 0 {
+			e.fail(path, "field %q is a scalar and cannot have a selection", sel.name)
+			return nil
+		}
+		return result
+	}
+	if len(sel.children) == 0 {
+		e.fail(path, "field %q of type %q must have a selection", sel.name, field.Type.Name)
+		return nil
+	}
+
+	rv := reflect.ValueOf(result)
+	if !rv.IsValid() || (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Slice) && rv.IsNil() {
+		return nil
+	}
+	if rv.Kind() == reflect.Slice {
+		items := make([]any, rv.Len())
+		for i := range items {
+			items[i] = e.object(field.Type, rv.Index(i).Interface(), sel.children, append(path, i))
+		}
+		return items
+	}
+	return e.object(field.Type, result, sel.children, path)
+}
+
+func (e *executor) fail(path []any, format string, args ...any) {
+	e.errors = append(e.errors, Error{Message: fmt.Sprintf(format, args...), Path: path})
+}
+
+// orderedMap is a JSON object that keeps fields in query order, as the
+// GraphQL spec requires for responses.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func (m *orderedMap) set(key string, v any) {
+	if m.values == nil {
+		m.values = map[string]any{}
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// StringArg returns a string argument, or "" if it is missing or not a string.
+func StringArg(args map[string]any, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+// IntArg returns an integer argument, or def if it is missing. Numbers from
+// JSON variables arrive as float64 and are truncated.
+func IntArg(args map[string]any, name string, def int) int {
+	switch v := args[name].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return def
+}
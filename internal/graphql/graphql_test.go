@@ -0,0 +1,123 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type book struct {
+	ID    string
+	Title string
+}
+
+func testSchema() *Schema {
+	books := []book{{"1", "Go"}, {"2", "GraphQL"}, {"3", "YouTube"}}
+
+	bookType := &Object{Name: "Book", Fields: map[string]*Field{
+		"id":    {Resolve: func(p any, _ map[string]any) (any, error) { return p.(book).ID, nil }},
+		"title": {Resolve: func(p any, _ map[string]any) (any, error) { return p.(book).Title, nil }},
+	}}
+	return &Schema{Query: &Object{Name: "Query", Fields: map[string]*Field{
+		"books": {Type: bookType, Resolve: func(_ any, args map[string]any) (any, error) {
+			return books[:min(IntArg(args, "first", len(books)), len(books))], nil
+		}},
+		"book": {Type: bookType, Resolve: func(_ any, args map[string]any) (any, error) {
+			for _, b := range books {
+				if b.ID == StringArg(args, "id") {
+					return b, nil
+				}
+			}
+			return nil, fmt.Errorf("book %q not found", StringArg(args, "id"))
+		}},
+	}}}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name string
+		req  Request
+		want string
+	}{
+		{
+			"fields and arguments",
+			Request{Query: `{ books(first: 2) { id title } }`},
+			`{"data":{"books":[{"id":"1","title":"Go"},{"id":"2","title":"GraphQL"}]}}`,
+		},
+		{
+			"aliases, variables, and typename",
+			Request{Query: `query Q($id: ID!) { b: book(id: $id) { __typename name: title } }`, Variables: map[string]any{"id": "3"}},
+			`{"data":{"b":{"__typename":"Book","name":"YouTube"}}}`,
+		},
+		{
+			"variable defaults",
+			Request{Query: `query ($n: Int = 1) { books(first: $n) { id } }`},
+			`{"data":{"books":[{"id":"1"}]}}`,
+		},
+		{
+			"resolver error nulls the field",
+			Request{Query: `{ book(id: "9") { id } books(first: 1) { id } }`},
+			`{"data":{"book":null,"books":[{"id":"1"}]},"errors":[{"message":"book \"9\" not found","path":["book"]}]}`,
+		},
+		{
+			"unknown field",
+			Request{Query: `{ books { nope } }`},
+			`{"data":{"books":[{"nope":null},{"nope":null},{"nope":null}]},"errors":[{"message":"cannot query field \"nope\" on type \"Book\"","path":["books",0,"nope"]},{"message":"cannot query field \"nope\" on type \"Book\"","path":["books",1,"nope"]},{"message":"cannot query field \"nope\" on type \"Book\"","path":["books",2,"nope"]}]}`,
+		},
+		{
+			"syntax error",
+			Request{Query: `{ books { id }`},
+			`{"errors":[{"message":"syntax error at offset 14: unterminated selection set"}]}`,
+		},
+		{
+			"fragments unsupported",
+			Request{Query: `{ books { ...F } }`},
+			`{"errors":[{"message":"syntax error at offset 10: fragments are not supported"}]}`,
+		},
+	}
+
+	schema := testSchema()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(schema.Execute(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Execute() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	srv := httptest.NewServer(testSchema())
+	defer srv.Close()
+
+	want := `{"data":{"book":{"title":"GraphQL"}}}` + "\n"
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"query":"query($id: ID){book(id:$id){title}}","variables":{"id":"2"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != want {
+		t.Errorf("POST = %s, want %s", body, want)
+	}
+
+	resp, err = http.Get(srv.URL + "?query=" + url.QueryEscape(`{book(id:"2"){title}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != want {
+		t.Errorf("GET = %s, want %s", body, want)
+	}
+}
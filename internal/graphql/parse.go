@@ -0,0 +1,435 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// selection is a field in a query, with its arguments and sub-selections.
+type selection struct {
+	alias    string
+	name     string
+	args     map[string]value
+	children []selection
+}
+
+// value is an argument value as written in the query.
+type value struct {
+	literal  any
+	variable string
+	list     []value
+	isList   bool
+}
+
+type operation struct {
+	name      string
+	defaults  map[string]any
+	selection []selection
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+func parse(src string) ([]operation, error) {
+	p := &parser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	var ops []operation
+	for p.tok.kind != tokEOF {
+		op, err := p.operation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("query contains no operations")
+	}
+	return ops, nil
+}
+
+func (p *parser) operation() (operation, error) {
+	op := operation{defaults: map[string]any{}}
+
+	if p.tok.kind == tokName {
+		switch p.tok.text {
+		case "query":
+		case "mutation", "subscription":
+			return op, p.errorf("%s operations are not supported", p.tok.text)
+		case "fragment":
+			return op, p.errorf("fragments are not supported")
+		default:
+			return op, p.errorf("unexpected %q", p.tok.text)
+		}
+		if err := p.next(); err != nil {
+			return op, err
+		}
+		if p.tok.kind == tokName {
+			op.name = p.tok.text
+			if err := p.next(); err != nil {
+				return op, err
+			}
+		}
+		if p.is("(") {
+			if err := p.variableDefinitions(op.defaults); err != nil {
+				return op, err
+			}
+		}
+	}
+
+	sel, err := p.selectionSet()
+	if err != nil {
+		return op, err
+	}
+	op.selection = sel
+	return op, nil
+}
+
+// variableDefinitions parses "($a: Type = default, ...)", keeping only the
+// defaults; types are not checked.
+func (p *parser) variableDefinitions(defaults map[string]any) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.is(")") {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if p.is("=") {
+			if err := p.next(); err != nil {
+				return err
+			}
+			v, err := p.value()
+			if err != nil {
+				return err
+			}
+			defaults[name] = v.resolve(nil)
+		}
+	}
+	return p.expect(")")
+}
+
+func (p *parser) skipType() error {
+	if p.is("[") {
+		if err := p.next(); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.is("!") {
+		return p.next()
+	}
+	return nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []selection
+	for !p.is("}") {
+		if p.tok.kind == tokEOF {
+			return nil, p.errorf("unterminated selection set")
+		}
+		if p.is("...") {
+			return nil, p.errorf("fragments are not supported")
+		}
+		sel, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	return sels, p.next()
+}
+
+func (p *parser) field() (selection, error) {
+	sel := selection{args: map[string]value{}}
+	name, err := p.name()
+	if err != nil {
+		return sel, err
+	}
+	if p.is(":") {
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+		sel.alias = name
+		if name, err = p.name(); err != nil {
+			return sel, err
+		}
+	}
+	sel.name = name
+	if sel.alias == "" {
+		sel.alias = name
+	}
+
+	if p.is("(") {
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+		for !p.is(")") {
+			argName, err := p.name()
+			if err != nil {
+				return sel, err
+			}
+			if err := p.expect(":"); err != nil {
+				return sel, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return sel, err
+			}
+			sel.args[argName] = v
+		}
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+	}
+	if p.is("@") {
+		return sel, p.errorf("directives are not supported")
+	}
+	if p.is("{") {
+		children, err := p.selectionSet()
+		if err != nil {
+			return sel, err
+		}
+		sel.children = children
+	}
+	return sel, nil
+}
+
+func (p *parser) value() (value, error) {
+	tok := p.tok
+	switch {
+	case p.is("$"):
+		if err := p.next(); err != nil {
+			return value{}, err
+		}
+		name, err := p.name()
+		return value{variable: name}, err
+
+	case p.is("["):
+		if err := p.next(); err != nil {
+			return value{}, err
+		}
+		v := value{isList: true}
+		for !p.is("]") {
+			item, err := p.value()
+			if err != nil {
+				return value{}, err
+			}
+			v.list = append(v.list, item)
+		}
+		return v, p.next()
+
+	case tok.kind == tokInt:
+		n, err := strconv.Atoi(tok.text)
+		if err != nil {
+			return value{}, p.errorf("invalid integer %q", tok.text)
+		}
+		return value{literal: n}, p.next()
+
+	case tok.kind == tokFloat:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return value{}, p.errorf("invalid number %q", tok.text)
+		}
+		return value{literal: f}, p.next()
+
+	case tok.kind == tokString:
+		return value{literal: tok.text}, p.next()
+
+	case tok.kind == tokName:
+		var lit any
+		switch tok.text {
+		case "true":
+			lit = true
+		case "false":
+			lit = false
+		case "null":
+			lit = nil
+		default:
+			lit = tok.text // enum value
+		}
+		return value{literal: lit}, p.next()
+	}
+	return value{}, p.errorf("unexpected %q in value", tok.text)
+}
+
+// resolve returns the Go value of v, looking variables up in vars.
+func (v value) resolve(vars map[string]any) any {
+	switch {
+	case v.variable != "":
+		return vars[v.variable]
+	case v.isList:
+		out := make([]any, len(v.list))
+		for i, item := range v.list {
+			out[i] = item.resolve(vars)
+		}
+		return out
+	}
+	return v.literal
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.errorf("expected name, found %q", p.tok.text)
+	}
+	name := p.tok.text
+	return name, p.next()
+}
+
+func (p *parser) is(punct string) bool {
+	return p.tok.kind == tokPunct && p.tok.text == punct
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.is(punct) {
+		return p.errorf("expected %q, found %q", punct, p.tok.text)
+	}
+	return p.next()
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("syntax error at offset %d: %s", p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+// next advances to the next token, skipping whitespace, commas, and comments.
+func (p *parser) next() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+			continue
+		}
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		break
+	}
+
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokPunct, text: "...", pos: start}
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokPunct, text: string(c), pos: start}
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for p.pos < len(p.src) && isNameChar(p.src[p.pos]) {
+			p.pos++
+		}
+		p.tok = token{kind: tokName, text: p.src[start:p.pos], pos: start}
+	case c == '-' || c >= '0' && c <= '9':
+		p.pos++
+		kind := tokInt
+		for p.pos < len(p.src) {
+			d := p.src[p.pos]
+			if d == '.' || d == 'e' || d == 'E' || d == '+' || d == '-' {
+				kind = tokFloat
+			} else if d < '0' || d > '9' {
+				break
+			}
+			p.pos++
+		}
+		p.tok = token{kind: kind, text: p.src[start:p.pos], pos: start}
+	case c == '"':
+		s, err := p.string()
+		if err != nil {
+			return err
+		}
+		p.tok = token{kind: tokString, text: s, pos: start}
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		return fmt.Errorf("syntax error at offset %d: unexpected character %q", start, r)
+	}
+	return nil
+}
+
+func (p *parser) string() (string, error) {
+	start := p.pos
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			return "", fmt.Errorf("syntax error at offset %d: unterminated string", start)
+		}
+		s := p.src[p.pos+3 : p.pos+3+end]
+		p.pos += end + 6
+		return s, nil
+	}
+
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '\n':
+			return "", fmt.Errorf("syntax error at offset %d: unterminated string", start)
+		case '"':
+			p.pos++
+			s, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return "", fmt.Errorf("syntax error at offset %d: invalid string", start)
+			}
+			return s, nil
+		}
+		p.pos++
+	}
+	return "", fmt.Errorf("syntax error at offset %d: unterminated string", start)
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
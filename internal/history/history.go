@@ -0,0 +1,121 @@
+// Package history keeps a per-video log of title, description, and tag
+// changes observed across sync runs.
+//
+// Each video has an append-only JSON Lines file, <dir>/<videoID>.jsonl. The
+// first line is the metadata as first seen; each later line is written only
+// when something changed.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Fields that can change between observations.
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldTags        = "tags"
+)
+
+// Metadata is the tracked subset of a video's metadata.
+type Metadata struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Entry is one observation in a video's history.
+type Entry struct {
+	Time time.Time `json:"time"`
+	Metadata
+	// Changed lists the fields that differ from the previous entry. It is
+	// empty for the first entry.
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Record compares meta with the last entry for videoID and appends a new
+// entry if this is the first observation or something changed. It returns
+// the changed fields, which is nil for a first observation or no change.
+func Record(dir, videoID string, meta Metadata, at time.Time) ([]string, error) {
+	entries, err := Load(dir, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	if len(entries) > 0 {
+		changed = Changes(entries[len(entries)-1].Metadata, meta)
+		if len(changed) == 0 {
+			return nil, nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating history directory: %w", err)
+	}
+	f, err := os.OpenFile(path(dir, videoID), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening history for %s: %w", videoID, err)
+	}
+	defer f.Close()
+
+	entry := Entry{Time: at.UTC(), Metadata: meta, Changed: changed}
+	if err := json.NewEncoder(f).Encode(entry); err != nil {
+		return nil, fmt.Errorf("error writing history for %s: %w", videoID, err)
+	}
+	return changed, nil
+}
+
+// Load returns a video's history, oldest first. A video with no history
+// yields no entries and no error.
+func Load(dir, videoID string) ([]Entry, error) {
+	f, err := os.Open(path(dir, videoID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history for %s: %w", videoID, err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("error parsing history for %s: %w", videoID, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history for %s: %w", videoID, err)
+	}
+	return entries, nil
+}
+
+// Changes returns the fields that differ between old and cur.
+func Changes(old, cur Metadata) []string {
+	var changed []string
+	if old.Title != cur.Title {
+		changed = append(changed, FieldTitle)
+	}
+	if old.Description != cur.Description {
+		changed = append(changed, FieldDescription)
+	}
+	if !slices.Equal(old.Tags, cur.Tags) {
+		changed = append(changed, FieldTags)
+	}
+	return changed
+}
+
+func path(dir, videoID string) string {
+	return filepath.Join(dir, videoID+".jsonl")
+}
@@ -0,0 +1,47 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		meta        Metadata
+		wantChanged []string
+		wantEntries int
+	}{
+		{Metadata{Title: "First", Tags: []string{"go"}}, nil, 1},
+		{Metadata{Title: "First", Tags: []string{"go"}}, nil, 1},
+		{Metadata{Title: "Second", Tags: []string{"go"}}, []string{FieldTitle}, 2},
+		{Metadata{Title: "Second", Description: "new", Tags: []string{"go", "cli"}}, []string{FieldDescription, FieldTags}, 3},
+	}
+
+	for i, step := range steps {
+		changed, err := Record(dir, "abc", step.meta, at.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(changed, step.wantChanged) {
+			t.Errorf("step %d: changed = %v, want %v", i, changed, step.wantChanged)
+		}
+		entries, err := Load(dir, "abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != step.wantEntries {
+			t.Errorf("step %d: %d entries, want %d", i, len(entries), step.wantEntries)
+		}
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	entries, err := Load(t.TempDir(), "none")
+	if err != nil || entries != nil {
+		t.Errorf("Load() of missing history = %v, %v; want nil, nil", entries, err)
+	}
+}
@@ -0,0 +1,413 @@
+// Package jobs keeps a queue of per-video download jobs with priorities,
+// retries, and their status, so a large batch can be inspected, retried,
+// and canceled after the run that started it has exited.
+//
+// The queue lives in jobs.json in ytt's data directory, with the changes
+// made since it was last written appended to jobs.journal. A run holds its
+// batch's jobs in memory through a Writer, which journals their progress in
+// batches; commands that change the queue as a whole, such as canceling
+// jobs, go through Update. Both hold the queue's file lock while writing,
+// so several ytt processes can share it.
+package jobs
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/n2p5/ytt/internal/clock"
+	"github.com/n2p5/ytt/internal/filelock"
+)
+
+// File is the queue's name inside the data directory, and JournalFile the
+// name of its journal of changes.
+const (
+	File        = "jobs.json"
+	JournalFile = "jobs.journal"
+)
+
+// Status is where a job is in its life.
+type Status string
+
+const (
+	// StatusPending jobs are waiting to run, or to run again once their
+	// retry delay has passed.
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	// StatusFailed jobs failed on their last allowed attempt.
+	StatusFailed   Status = "failed"
+	StatusCanceled Status = "canceled"
+)
+
+// Statuses lists every status, in the order a job passes through them.
+var Statuses = []Status{StatusPending, StatusRunning, StatusDone, StatusFailed, StatusCanceled}
+
+var (
+	// Retention is how long finished and canceled jobs are kept.
+	Retention = 30 * 24 * time.Hour
+	// RunningTimeout is how long a job may be running on another host
+	// before it is taken to have been abandoned. Jobs running on this host
+	// are recovered as soon as their process has exited.
+	RunningTimeout = time.Hour
+)
+
+// Batch is a run that queued jobs.
+type Batch struct {
+	ID int `json:"id"`
+	// Kind names the work, such as "download" or "sync".
+	Kind        string        `json:"kind"`
+	MaxAttempts int           `json:"max_attempts"`
+	Backoff     time.Duration `json:"backoff"`
+	// Settings are the flag values the run was started with, which a
+	// retry of its jobs runs with again.
+	Settings  map[string]any `json:"settings,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Policy returns how b's failed jobs are retried.
+func (b *Batch) Policy() Policy {
+	return Policy{MaxAttempts: b.MaxAttempts, Backoff: b.Backoff}
+}
+
+// Owner identifies the process running a job.
+type Owner struct {
+	PID  int    `json:"pid"`
+	Host string `json:"host"`
+}
+
+// Self is the running process as a job owner.
+var Self = func() Owner {
+	host, _ := os.Hostname()
+	return Owner{PID: os.Getpid(), Host: host}
+}()
+
+// Job is one video's transcript to download.
+type Job struct {
+	ID      int    `json:"id"`
+	VideoID string `json:"video_id"`
+	Kind    string `json:"kind"`
+	// Batch is the ID of the batch that last queued the job.
+	Batch int `json:"batch"`
+	// Priority orders pending jobs: higher runs first, and jobs of equal
+	// priority run oldest first.
+	Priority    int    `json:"priority"`
+	Status      Status `json:"status"`
+	Attempts    int    `json:"attempts"`
+	MaxAttempts int    `json:"max_attempts"`
+	Error       string `json:"error,omitempty"`
+	// Owner and StartedAt describe the attempt of a running job.
+	Owner     Owner     `json:"owner,omitzero"`
+	StartedAt time.Time `json:"started_at,omitzero"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// RetryAt is when a pending job that failed may run again.
+	RetryAt time.Time `json:"retry_at,omitzero"`
+}
+
+// Due reports whether j is pending and past any retry delay at now.
+func (j *Job) Due(now time.Time) bool {
+	return j.Status == StatusPending && !now.Before(j.RetryAt)
+}
+
+// Policy is how failed jobs are retried.
+type Policy struct {
+	// MaxAttempts is how many times a job runs before it is failed. Values
+	// below 1 mean 1.
+	MaxAttempts int
+	// Backoff is the delay before the second attempt, doubled for each
+	// attempt after.
+	Backoff time.Duration
+}
+
+// DefaultPolicy tries a job three times, a minute and then two minutes
+// apart.
+var DefaultPolicy = Policy{MaxAttempts: 3, Backoff: time.Minute}
+
+// Delay returns how long to wait after a job's attempt'th failure.
+func (p Policy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		return 0
+	}
+	return p.Backoff << min(attempt-1, 16)
+}
+
+// Queue is the set of jobs and the batches that queued them.
+type Queue struct {
+	// NextID and NextBatch are the IDs the next new job and batch get.
+	NextID    int      `json:"next_id"`
+	NextBatch int      `json:"next_batch"`
+	Batches   []*Batch `json:"batches"`
+	Jobs      []*Job   `json:"jobs"`
+}
+
+// Get returns the job with id, or nil.
+func (q *Queue) Get(id int) *Job {
+	for _, j := range q.Jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// Batch returns the batch with id, or nil.
+func (q *Queue) Batch(id int) *Batch {
+	for _, b := range q.Batches {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+// AddBatch adds b to the queue, giving it the next batch ID.
+func (q *Queue) AddBatch(b *Batch) {
+	q.NextBatch = max(q.NextBatch, 1)
+	b.ID = q.NextBatch
+	b.MaxAttempts = max(b.MaxAttempts, 1)
+	q.NextBatch++
+	q.Batches = append(q.Batches, b)
+}
+
+// Enqueue adds a pending job for videoID to batch b and returns it. A job
+// for the same video and kind that is pending or failed is moved to b
+// instead, keeping its ID, and may run at once.
+func (q *Queue) Enqueue(b *Batch, videoID string, priority int, now time.Time) *Job {
+	for _, j := range q.Jobs {
+		if j.Kind == b.Kind && j.VideoID == videoID && (j.Status == StatusPending || j.Status == StatusFailed) {
+			if j.Status == StatusFailed {
+				j.Status, j.Attempts = StatusPending, 0
+			}
+			j.Batch, j.Priority, j.MaxAttempts, j.RetryAt, j.UpdatedAt = b.ID, priority, b.MaxAttempts, time.Time{}, now
+			return j
+		}
+	}
+	q.NextID = max(q.NextID, 1)
+	j := &Job{
+		ID:          q.NextID,
+		VideoID:     videoID,
+		Kind:        b.Kind,
+		Batch:       b.ID,
+		Priority:    priority,
+		Status:      StatusPending,
+		MaxAttempts: b.MaxAttempts,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	q.NextID++
+	q.Jobs = append(q.Jobs, j)
+	return j
+}
+
+// Due returns the pending jobs that may run at now, in the order they
+// should: highest priority first, then oldest.
+func (q *Queue) Due(now time.Time) []*Job {
+	var due []*Job
+	for _, j := range q.Jobs {
+		if j.Due(now) {
+			due = append(due, j)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b *Job) int {
+		if c := cmp.Compare(b.Priority, a.Priority); c != 0 {
+			return c
+		}
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return due
+}
+
+// Policy returns how j is retried, from the batch that queued it.
+func (q *Queue) Policy(j *Job) Policy {
+	if b := q.Batch(j.Batch); b != nil {
+		return b.Policy()
+	}
+	return Policy{MaxAttempts: j.MaxAttempts, Backoff: DefaultPolicy.Backoff}
+}
+
+// Start marks j as running another attempt in the process owner. It
+// reports false, leaving j alone, if j isn't pending, as when it has been
+// canceled.
+func (j *Job) Start(owner Owner, now time.Time) bool {
+	if j.Status != StatusPending {
+		return false
+	}
+	j.Status = StatusRunning
+	j.Attempts++
+	j.Owner, j.StartedAt = owner, now
+	j.UpdatedAt = now
+	return true
+}
+
+// Finish records the outcome of j's attempt. A failed job is left pending
+// for another attempt after p's delay, or failed once it has used its
+// attempts.
+func (j *Job) Finish(err error, p Policy, now time.Time) {
+	j.UpdatedAt = now
+	j.Owner, j.StartedAt = Owner{}, time.Time{}
+	if j.Status == StatusCanceled {
+		return
+	}
+	j.RetryAt = time.Time{}
+	if err == nil {
+		j.Status, j.Error = StatusDone, ""
+		return
+	}
+	j.Error = err.Error()
+	if j.Attempts >= j.MaxAttempts {
+		j.Status = StatusFailed
+		return
+	}
+	j.Status = StatusPending
+	j.RetryAt = now.Add(p.Delay(j.Attempts))
+}
+
+// Release returns a running job to pending without counting its attempt,
+// for work that stopped for reasons of its own, such as the quota running
+// out.
+func (j *Job) Release(now time.Time) {
+	j.Attempts = max(j.Attempts-1, 0)
+	j.UpdatedAt = now
+	j.Owner, j.StartedAt = Owner{}, time.Time{}
+	if j.Status != StatusCanceled {
+		j.Status = StatusPending
+	}
+}
+
+// Retry returns a failed or canceled job to pending with its attempts
+// reset. It returns an error if the job is still active or done.
+func (j *Job) Retry(now time.Time) error {
+	if j.Status != StatusFailed && j.Status != StatusCanceled {
+		return fmt.Errorf("job %d is %s, not failed or canceled", j.ID, j.Status)
+	}
+	j.Status = StatusPending
+	j.Attempts = 0
+	j.RetryAt = time.Time{}
+	j.UpdatedAt = now
+	return nil
+}
+
+// Cancel stops a pending or failed job from running. A running job's
+// attempt is left to finish, but its outcome isn't recorded and it isn't
+// retried. It returns an error if the job is already done or canceled.
+func (j *Job) Cancel(now time.Time) error {
+	if j.Status == StatusDone || j.Status == StatusCanceled {
+		return fmt.Errorf("job %d is already %s", j.ID, j.Status)
+	}
+	j.Status = StatusCanceled
+	j.UpdatedAt = now
+	return nil
+}
+
+// Recover finishes, as failed attempts, the running jobs whose process has
+// exited without finishing them, such as one that crashed or was killed.
+// It returns the number recovered.
+func (q *Queue) Recover(now time.Time) int {
+	n := 0
+	for _, j := range q.Jobs {
+		if j.Status != StatusRunning || ownerAlive(j.Owner, j.StartedAt, now) {
+			continue
+		}
+		owner := j.Owner
+		j.Finish(fmt.Errorf("interrupted: process %d on %s exited while running it", owner.PID, owner.Host), q.Policy(j), now)
+		n++
+	}
+	return n
+}
+
+// ownerAlive reports whether the process that started a job at started may
+// still be running it.
+func ownerAlive(o Owner, started, now time.Time) bool {
+	if o.Host != Self.Host || o.PID == 0 {
+		return now.Sub(started) < RunningTimeout
+	}
+	return o.PID == Self.PID || processAlive(o.PID)
+}
+
+// Prune removes the done and canceled jobs last updated before cutoff, and
+// the batches no job belongs to any longer.
+func (q *Queue) Prune(cutoff time.Time) {
+	q.Jobs = slices.DeleteFunc(q.Jobs, func(j *Job) bool {
+		return (j.Status == StatusDone || j.Status == StatusCanceled) && j.UpdatedAt.Before(cutoff)
+	})
+	used := map[int]bool{}
+	for _, j := range q.Jobs {
+		used[j.Batch] = true
+	}
+	q.Batches = slices.DeleteFunc(q.Batches, func(b *Batch) bool { return !used[b.ID] })
+}
+
+// Load reads the queue saved in dir, replaying its journal, or returns an
+// empty queue if there is none.
+func Load(dir string) (*Queue, error) {
+	q := &Queue{NextID: 1, NextBatch: 1}
+	data, err := os.ReadFile(filepath.Join(dir, File))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("error reading job queue: %w", err)
+	default:
+		if err := json.Unmarshal(data, q); err != nil {
+			return nil, fmt.Errorf("error parsing job queue: %w", err)
+		}
+	}
+	if err := replayJournal(filepath.Join(dir, JournalFile), q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Update loads the queue saved in dir, recovers jobs left running by
+// processes that have exited, calls fn with it, and saves it unless fn
+// returns an error, all while holding the queue's lock. Done and canceled
+// jobs older than Retention are dropped as it is saved. The time is read
+// from clk, or the system clock if clk is nil.
+func Update(dir string, clk clock.Clock, fn func(q *Queue) error) error {
+	clk = clock.Or(clk)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	return filelock.With(filepath.Join(dir, File), func() error {
+		q, err := Load(dir)
+		if err != nil {
+			return err
+		}
+		q.Recover(clk.Now())
+		if err := fn(q); err != nil {
+			return err
+		}
+		return save(dir, q, clk.Now())
+	})
+}
+
+// save writes q as the queue file and discards the journal, whose changes
+// q includes, pruning jobs older than Retention at now. The caller holds the
+// queue's lock.
+func save(dir string, q *Queue, now time.Time) error {
+	q.Prune(now.Add(-Retention))
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding job queue: %w", err)
+	}
+	path := filepath.Join(dir, File)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing job queue: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error writing job queue: %w", err)
+	}
+	if err := os.Remove(filepath.Join(dir, JournalFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing job journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/clock"
+)
+
+var t0 = time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+
+func newBatch(q *Queue, kind string) *Batch {
+	b := &Batch{Kind: kind, MaxAttempts: DefaultPolicy.MaxAttempts, Backoff: DefaultPolicy.Backoff, CreatedAt: t0}
+	q.AddBatch(b)
+	return b
+}
+
+func TestDueOrder(t *testing.T) {
+	q := &Queue{}
+	b := newBatch(q, "download")
+	q.Enqueue(b, "low", 0, t0)
+	q.Enqueue(b, "high", 5, t0.Add(time.Second))
+	q.Enqueue(b, "older", 0, t0.Add(-time.Second))
+	q.Enqueue(b, "waiting", 9, t0).RetryAt = t0.Add(time.Hour)
+
+	var got []string
+	for _, j := range q.Due(t0.Add(time.Minute)) {
+		got = append(got, j.VideoID)
+	}
+	if want := "[high older low]"; fmt.Sprint(got) != want {
+		t.Errorf("Due() = %v, want %v", got, want)
+	}
+}
+
+func TestFinish(t *testing.T) {
+	boom := errors.New("boom")
+	p := Policy{MaxAttempts: 3, Backoff: time.Minute}
+	tests := []struct {
+		name      string
+		attempts  int
+		err       error
+		status    Status
+		retryWait time.Duration
+	}{
+		{"success", 1, nil, StatusDone, 0},
+		{"first failure", 1, boom, StatusPending, time.Minute},
+		{"second failure", 2, boom, StatusPending, 2 * time.Minute},
+		{"last failure", 3, boom, StatusFailed, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &Job{Status: StatusRunning, Attempts: tt.attempts, MaxAttempts: p.MaxAttempts}
+			j.Finish(tt.err, p, t0)
+			if j.Status != tt.status {
+				t.Errorf("status = %s, want %s", j.Status, tt.status)
+			}
+			var wait time.Duration
+			if !j.RetryAt.IsZero() {
+				wait = j.RetryAt.Sub(t0)
+			}
+			if wait != tt.retryWait {
+				t.Errorf("retry after %v, want %v", wait, tt.retryWait)
+			}
+		})
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	q := &Queue{}
+	b := &Batch{Kind: "sync", MaxAttempts: 1}
+	q.AddBatch(b)
+	j := q.Enqueue(b, "a", 0, t0)
+	p := b.Policy()
+
+	j.Start(Self, t0)
+	j.Finish(errors.New("boom"), p, t0)
+	if j.Status != StatusFailed || j.Error != "boom" {
+		t.Fatalf("after failure: status %s, error %q", j.Status, j.Error)
+	}
+	if err := j.Cancel(t0); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Cancel(t0); err == nil {
+		t.Error("Cancel() of a canceled job succeeded")
+	}
+	if j.Start(Self, t0) {
+		t.Error("Start() of a canceled job succeeded")
+	}
+	if err := j.Retry(t0); err != nil {
+		t.Fatal(err)
+	}
+	if j.Status != StatusPending || j.Attempts != 0 {
+		t.Errorf("after Retry: status %s, attempts %d", j.Status, j.Attempts)
+	}
+
+	// Canceling a running job keeps its outcome from being recorded.
+	j.Start(Self, t0)
+	j.Cancel(t0)
+	j.Finish(nil, p, t0)
+	if j.Status != StatusCanceled {
+		t.Errorf("status = %s after finishing a canceled job, want canceled", j.Status)
+	}
+	if err := j.Retry(t0); err != nil {
+		t.Fatal(err)
+	}
+
+	// A job stopped by the quota doesn't use an attempt.
+	j.Start(Self, t0)
+	j.Release(t0)
+	if j.Status != StatusPending || j.Attempts != 0 {
+		t.Errorf("after Release: status %s, attempts %d", j.Status, j.Attempts)
+	}
+	if err := j.Retry(t0); err == nil {
+		t.Error("Retry() of a pending job succeeded")
+	}
+}
+
+func TestEnqueueRequeues(t *testing.T) {
+	q := &Queue{}
+	first := newBatch(q, "download")
+	a := q.Enqueue(first, "a", 0, t0)
+	a.Status, a.Attempts = StatusFailed, 3
+
+	second := newBatch(q, "download")
+	if got := q.Enqueue(second, "a", 2, t0); got.ID != a.ID || got.Status != StatusPending || got.Attempts != 0 || got.Priority != 2 || got.Batch != second.ID {
+		t.Errorf("requeued failed job = %+v", got)
+	}
+	a.Status = StatusDone
+	if got := q.Enqueue(second, "a", 0, t0); got.ID == a.ID {
+		t.Error("done job was reused")
+	}
+	if got := q.Enqueue(newBatch(q, "sync"), "a", 0, t0); got.ID == a.ID {
+		t.Error("job of another kind was reused")
+	}
+	if len(q.Jobs) != 3 {
+		t.Errorf("queue has %d jobs, want 3", len(q.Jobs))
+	}
+}
+
+func TestRecover(t *testing.T) {
+	// A process that has exited stands in for one that crashed.
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	exited := cmd.ProcessState.Pid()
+
+	now := time.Now()
+	q := &Queue{}
+	b := newBatch(q, "download")
+	tests := []struct {
+		name    string
+		owner   Owner
+		started time.Time
+		want    Status
+	}{
+		{"exited process", Owner{PID: exited, Host: Self.Host}, now, StatusPending},
+		{"this process", Self, now, StatusRunning},
+		{"other host", Owner{PID: 1, Host: "elsewhere"}, now, StatusRunning},
+		{"other host, abandoned", Owner{PID: 1, Host: "elsewhere"}, now.Add(-2 * RunningTimeout), StatusPending},
+	}
+	for _, tt := range tests {
+		j := q.Enqueue(b, tt.name, 0, now)
+		j.Start(tt.owner, tt.started)
+	}
+	if n := q.Recover(now); n != 2 {
+		t.Errorf("Recover() = %d, want 2", n)
+	}
+	for i, tt := range tests {
+		j := q.Jobs[i]
+		if j.Status != tt.want {
+			t.Errorf("%s: status = %s, want %s", tt.name, j.Status, tt.want)
+		}
+		if tt.want == StatusPending && (j.Attempts != 1 || j.Error == "") {
+			t.Errorf("%s: recovered with %d attempts and error %q", tt.name, j.Attempts, j.Error)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	dir := t.TempDir()
+	ids := make([]string, 50)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("vid%02d", i)
+	}
+	b := &Batch{Kind: "download", MaxAttempts: 2, Backoff: time.Minute, Settings: map[string]any{"format": "srt"}}
+	w, err := OpenWriter(dir, b, ids, 0, WriterOptions{MaxBatch: 7, CompactEvery: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Order(); len(got) != len(ids) || got[0] != "vid00" {
+		t.Fatalf("Order() = %v", got)
+	}
+
+	// Canceled by another process partway through.
+	err = Update(dir, nil, func(q *Queue) error {
+		return q.Jobs[len(q.Jobs)-1].Cancel(time.Now())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	last := ids[len(ids)-1]
+	for i, id := range ids[:len(ids)-1] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !w.Start(id) {
+				t.Errorf("Start(%s) = false", id)
+				return
+			}
+			var err error
+			if i%10 == 0 {
+				err = errors.New("boom")
+			}
+			w.Finish(id, err)
+		}()
+	}
+	wg.Wait()
+	// The writer has flushed since, and so has seen the cancellation.
+	if w.Start(last) {
+		t.Errorf("Start(%s) of a canceled job = true", last)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[Status]int{}
+	for _, j := range q.Jobs {
+		counts[j.Status]++
+	}
+	if counts[StatusDone] != 44 || counts[StatusPending] != 5 || counts[StatusCanceled] != 1 {
+		t.Errorf("statuses = %v", counts)
+	}
+	if len(q.Batches) != 1 || q.Batches[0].Settings["format"] != "srt" {
+		t.Errorf("batches = %+v", q.Batches)
+	}
+	if _, err := os.Stat(filepath.Join(dir, JournalFile)); !os.IsNotExist(err) {
+		t.Errorf("journal left behind after Close: %v", err)
+	}
+}
+
+func TestWriterClock(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewFake(t0)
+	b := &Batch{Kind: "download", MaxAttempts: 3, Backoff: time.Minute}
+	w, err := OpenWriter(dir, b, []string{"a", "b"}, 0, WriterOptions{Clock: clk})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start("a")
+	w.Finish("a", errors.New("boom"))
+	if got := w.Order(); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("Order() before the retry delay = %v, want [b]", got)
+	}
+	clk.Advance(time.Minute)
+	if got := w.Order(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Order() after the retry delay = %v, want [a b]", got)
+	}
+
+	// A job another host started is recovered once it has run too long.
+	w.Start("b")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	err = Update(dir, clk, func(q *Queue) error {
+		q.Jobs[1].Owner = Owner{PID: 1, Host: "elsewhere"}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := func() Status {
+		var s Status
+		err := Update(dir, clk, func(q *Queue) error {
+			s = q.Jobs[1].Status
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	if s := status(); s != StatusRunning {
+		t.Errorf("status before RunningTimeout = %s, want %s", s, StatusRunning)
+	}
+	clk.Advance(RunningTimeout)
+	if s := status(); s != StatusPending {
+		t.Errorf("status after RunningTimeout = %s, want %s", s, StatusPending)
+	}
+}
+
+func TestWriterScales(t *testing.T) {
+	if testing.Short() {
+		t.Skip("slow")
+	}
+	dir := t.TempDir()
+	settings := map[string]any{}
+	for i := range 25 {
+		settings[fmt.Sprintf("setting-%d", i)] = "value"
+	}
+	ids := make([]string, 3000)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("vid%04d", i)
+	}
+	start := time.Now()
+	w, err := OpenWriter(dir, &Batch{Kind: "download", Settings: settings}, ids, 0, WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range w.Order() {
+		w.Start(id)
+		w.Finish(id, nil)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Rewriting the queue for every change took minutes for this many.
+	if d := time.Since(start); d > 30*time.Second {
+		t.Errorf("3000 jobs took %v", d)
+	}
+}
+
+func TestUpdatePrunes(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * Retention)
+	err := Update(dir, nil, func(q *Queue) error {
+		oldBatch := newBatch(q, "download")
+		q.Enqueue(oldBatch, "done", 0, old).Status = StatusDone
+		recent := newBatch(q, "download")
+		q.Enqueue(recent, "failed", 0, old).Status = StatusFailed
+		q.Enqueue(recent, "recent", 0, time.Now()).Status = StatusDone
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, j := range q.Jobs {
+		got = append(got, j.VideoID)
+	}
+	if fmt.Sprint(got) != "[failed recent]" {
+		t.Errorf("jobs after prune = %v, want [failed recent]", got)
+	}
+	if len(q.Batches) != 1 {
+		t.Errorf("%d batches left, want 1", len(q.Batches))
+	}
+}
@@ -0,0 +1,285 @@
+package jobs
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/n2p5/ytt/internal/clock"
+	"github.com/n2p5/ytt/internal/filelock"
+)
+
+// WriterOptions tunes how a Writer batches its journal writes.
+type WriterOptions struct {
+	// FlushInterval is the longest a change waits in memory before it is
+	// journaled. Defaults to one second.
+	FlushInterval time.Duration
+	// MaxBatch journals pending changes as soon as this many accumulate.
+	// Defaults to 100.
+	MaxBatch int
+	// CompactEvery folds the journal into the queue file after this many
+	// journaled changes. Defaults to 1000.
+	CompactEvery int
+	// Clock, if set, is used instead of the system clock to time jobs,
+	// their retries, and flushes.
+	Clock clock.Clock
+}
+
+// op is a change to a job, as journaled.
+type op struct {
+	Op    string    `json:"op"`
+	ID    int       `json:"id"`
+	Error string    `json:"error,omitempty"`
+	Owner Owner     `json:"owner,omitzero"`
+	At    time.Time `json:"at"`
+}
+
+const (
+	opStart   = "start"
+	opFinish  = "finish"
+	opRelease = "release"
+)
+
+// apply makes the change o describes. Changes to jobs that have since been
+// pruned are ignored.
+func (q *Queue) apply(o op) {
+	j := q.Get(o.ID)
+	if j == nil {
+		return
+	}
+	switch o.Op {
+	case opStart:
+		j.Start(o.Owner, o.At)
+	case opFinish:
+		var err error
+		if o.Error != "" {
+			err = errors.New(o.Error)
+		}
+		j.Finish(err, q.Policy(j), o.At)
+	case opRelease:
+		j.Release(o.At)
+	}
+}
+
+func replayJournal(path string, q *Queue) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading job journal: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var o op
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			// A torn final line from a crash mid-append.
+			break
+		}
+		q.apply(o)
+	}
+	return scanner.Err()
+}
+
+// Writer runs one batch's jobs. It keeps the queue in memory, journals the
+// batch's progress every FlushInterval or MaxBatch changes, and rereads the
+// queue as it does, so it sees jobs canceled by other processes. Its methods
+// may be called from any number of goroutines.
+type Writer struct {
+	dir   string
+	opts  WriterOptions
+	batch *Batch
+	// ids maps each of the batch's videos to its job.
+	ids map[string]int
+
+	mu        sync.Mutex
+	q         *Queue
+	pending   []op
+	flushed   time.Time
+	journaled int
+	err       error
+}
+
+// OpenWriter adds batch b, with a job for each of videoIDs at priority, to
+// the queue in dir, and returns a Writer for running them. Jobs left
+// running by processes that have exited are recovered first. Close must be
+// called to journal the last changes and compact.
+func OpenWriter(dir string, b *Batch, videoIDs []string, priority int, opts WriterOptions) (*Writer, error) {
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.MaxBatch <= 0 {
+		opts.MaxBatch = 100
+	}
+	if opts.CompactEvery <= 0 {
+		opts.CompactEvery = 1000
+	}
+	opts.Clock = clock.Or(opts.Clock)
+	w := &Writer{dir: dir, opts: opts, batch: b, ids: make(map[string]int, len(videoIDs)), flushed: opts.Clock.Now()}
+	err := Update(dir, opts.Clock, func(q *Queue) error {
+		now := opts.Clock.Now()
+		q.AddBatch(b)
+		for _, id := range videoIDs {
+			w.ids[id] = q.Enqueue(b, id, priority, now).ID
+		}
+		w.q = q
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Order returns the batch's videos that are due to run, highest priority
+// first.
+func (w *Writer) Order() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var ids []string
+	for _, j := range w.q.Due(w.opts.Clock.Now()) {
+		if j.Batch == w.batch.ID && w.ids[j.VideoID] == j.ID {
+			ids = append(ids, j.VideoID)
+		}
+	}
+	return ids
+}
+
+// Start marks videoID's job as running in this process. It reports false if
+// the job is no longer pending, as when it was canceled, in which case the
+// video should be skipped.
+func (w *Writer) Start(videoID string) bool {
+	started := false
+	w.change(videoID, func(j *Job, now time.Time) *op {
+		if started = j.Start(Self, now); !started {
+			return nil
+		}
+		return &op{Op: opStart, Owner: Self}
+	})
+	return started
+}
+
+// Finish records the outcome of videoID's attempt.
+func (w *Writer) Finish(videoID string, err error) {
+	w.change(videoID, func(j *Job, now time.Time) *op {
+		j.Finish(err, w.batch.Policy(), now)
+		o := &op{Op: opFinish}
+		if err != nil {
+			o.Error = err.Error()
+		}
+		return o
+	})
+}
+
+// Release returns videoID's job to pending without counting its attempt.
+func (w *Writer) Release(videoID string) {
+	w.change(videoID, func(j *Job, now time.Time) *op {
+		j.Release(now)
+		return &op{Op: opRelease}
+	})
+}
+
+// change applies fn to videoID's job in memory and queues the op it
+// returns, if any, for the journal.
+func (w *Writer) change(videoID string, fn func(j *Job, now time.Time) *op) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	j := w.q.Get(w.ids[videoID])
+	if j == nil {
+		return
+	}
+	now := w.opts.Clock.Now()
+	o := fn(j, now)
+	if o == nil {
+		return
+	}
+	o.ID, o.At = j.ID, now
+	w.pending = append(w.pending, *o)
+	if len(w.pending) >= w.opts.MaxBatch || now.Sub(w.flushed) >= w.opts.FlushInterval {
+		w.flush()
+	}
+}
+
+// flush appends the pending ops to the journal with a single write and
+// fsync, and rereads the queue, which then includes them along with any
+// other process's changes. The caller holds w.mu.
+func (w *Writer) flush() {
+	w.flushed = w.opts.Clock.Now()
+	if len(w.pending) == 0 {
+		return
+	}
+	var data []byte
+	for _, o := range w.pending {
+		line, err := json.Marshal(o)
+		if err != nil {
+			w.setErr(fmt.Errorf("error encoding job journal: %w", err))
+			return
+		}
+		data = append(append(data, line...), '\n')
+	}
+	err := filelock.With(filepath.Join(w.dir, File), func() error {
+		f, err := os.OpenFile(filepath.Join(w.dir, JournalFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("error opening job journal: %w", err)
+		}
+		_, err = f.Write(data)
+		if err == nil {
+			err = f.Sync()
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("error writing job journal: %w", err)
+		}
+		q, err := Load(w.dir)
+		if err != nil {
+			return err
+		}
+		w.q = q
+		return nil
+	})
+	if err != nil {
+		w.setErr(err)
+		return
+	}
+	w.journaled += len(w.pending)
+	w.pending = nil
+	if w.journaled >= w.opts.CompactEvery {
+		w.setErr(w.compact())
+	}
+}
+
+// compact folds the journal into the queue file. The caller holds w.mu.
+func (w *Writer) compact() error {
+	w.journaled = 0
+	return Update(w.dir, w.opts.Clock, func(q *Queue) error {
+		w.q = q
+		return nil
+	})
+}
+
+// Close journals the pending changes and compacts the journal into the
+// queue file. It returns the first error the Writer encountered.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flush()
+	if w.err == nil {
+		w.setErr(w.compact())
+	}
+	return w.err
+}
+
+func (w *Writer) setErr(err error) {
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+}
@@ -0,0 +1,39 @@
+// Package keyring stores secrets in the operating system's credential
+// store: the macOS Keychain, the Windows Credential Manager, or a Secret
+// Service such as GNOME Keyring or KWallet elsewhere.
+//
+// It has no dependencies. On macOS and under Secret Service it runs the
+// security and secret-tool commands; on Windows it calls the Credential
+// Manager API directly.
+package keyring
+
+import "errors"
+
+// ErrNotFound is returned by Get for a secret that isn't stored.
+var ErrNotFound = errors.New("secret not found in keyring")
+
+// ErrUnavailable is returned when the system has no usable keyring, such
+// as a Linux server without secret-tool or a session bus.
+var ErrUnavailable = errors.New("no keyring available")
+
+// Get returns the secret stored for service and account.
+func Get(service, account string) (string, error) {
+	return get(service, account)
+}
+
+// Set stores secret for service and account, replacing any stored before.
+func Set(service, account, secret string) error {
+	return set(service, account, secret)
+}
+
+// Delete removes the secret stored for service and account. Deleting a
+// secret that isn't stored is not an error.
+func Delete(service, account string) error {
+	return del(service, account)
+}
+
+// Available reports whether the system has a keyring Get, Set, and Delete
+// can use.
+func Available() bool {
+	return available()
+}
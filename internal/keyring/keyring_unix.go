@@ -0,0 +1,126 @@
+//go:build !windows
+
+package keyring
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// command is the program talking to the keyring: security on macOS and
+// secret-tool elsewhere. Tests replace it.
+var command = func() string {
+	if runtime.GOOS == "darwin" {
+		return "security"
+	}
+	return "secret-tool"
+}()
+
+// macOS reports a missing item with exit status 44.
+const securityNotFound = 44
+
+func available() bool {
+	if _, err := exec.LookPath(command); err != nil {
+		return false
+	}
+	if command == "security" {
+		return true
+	}
+	// Secret Service is reached over the session bus, but a bus doesn't
+	// mean a Secret Service is running on it. Looking up a secret that
+	// doesn't exist fails quietly if one is, and with an error if not.
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return false
+	}
+	_, errOut, err := run(exec.Command(command, "lookup", "service", "ytt-keyring-probe"))
+	var exitErr *exec.ExitError
+	return err == nil || errors.As(err, &exitErr) && errOut == ""
+}
+
+func get(service, account string) (string, error) {
+	var cmd *exec.Cmd
+	if command == "security" {
+		cmd = exec.Command(command, "find-generic-password", "-s", service, "-a", account, "-w")
+	} else {
+		cmd = exec.Command(command, "lookup", "service", service, "account", account)
+	}
+	out, errOut, err := run(cmd)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// secret-tool exits with 1 and prints nothing for a missing secret.
+		if command == "security" && exitErr.ExitCode() == securityNotFound || command != "security" && errOut == "" {
+			return "", ErrNotFound
+		}
+	}
+	if err != nil {
+		return "", commandError(cmd, errOut, err)
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func set(service, account, secret string) error {
+	if command == "security" {
+		// Passed through security's own prompt rather than its arguments,
+		// so the secret doesn't show up in the process list.
+		cmd := exec.Command(command, "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+			strconv.Quote(service), strconv.Quote(account), hex.EncodeToString([]byte(secret))))
+		return check(cmd)
+	}
+	cmd := exec.Command(command, "store", "--label", service+" ("+account+")", "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	return check(cmd)
+}
+
+func del(service, account string) error {
+	var cmd *exec.Cmd
+	if command == "security" {
+		cmd = exec.Command(command, "delete-generic-password", "-s", service, "-a", account)
+	} else {
+		cmd = exec.Command(command, "clear", "service", service, "account", account)
+	}
+	_, errOut, err := run(cmd)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && command == "security" && exitErr.ExitCode() == securityNotFound {
+		return nil
+	}
+	if err != nil {
+		return commandError(cmd, errOut, err)
+	}
+	return nil
+}
+
+// run runs cmd and returns what it printed on stdout and stderr.
+func run(cmd *exec.Cmd) (stdout, stderr string, err error) {
+	if _, err := exec.LookPath(cmd.Path); err != nil {
+		return "", "", fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err = cmd.Run()
+	return out.String(), strings.TrimSpace(errOut.String()), err
+}
+
+// check runs cmd and returns an error if it fails.
+func check(cmd *exec.Cmd) error {
+	_, errOut, err := run(cmd)
+	if err != nil {
+		return commandError(cmd, errOut, err)
+	}
+	return nil
+}
+
+// commandError describes cmd failing with err after printing errOut.
+func commandError(cmd *exec.Cmd, errOut string, err error) error {
+	if errOut != "" {
+		return fmt.Errorf("error running %s: %w: %s", cmd.Args[0], err, errOut)
+	}
+	return fmt.Errorf("error running %s: %w", cmd.Args[0], err)
+}
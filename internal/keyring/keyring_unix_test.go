@@ -0,0 +1,97 @@
+//go:build !windows
+
+package keyring
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSecretTool is a secret-tool keeping secrets in files named after
+// their service and account.
+const fakeSecretTool = `#!/bin/sh
+op=$1; shift
+[ "$1" = --label ] && shift 2
+file="$STORE/$2-$4"
+[ -n "$NO_SERVICE" ] && { echo "Cannot autolaunch D-Bus without X11 \$DISPLAY" >&2; exit 1; }
+case $op in
+store) cat > "$file" ;;
+lookup) [ -f "$file" ] || exit 1; cat "$file" ;;
+clear) rm -f "$file" ;;
+*) echo "unknown command $op" >&2; exit 2 ;;
+esac
+`
+
+func TestSecretTool(t *testing.T) {
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "secret-tool")
+	if err := os.WriteFile(tool, []byte(fakeSecretTool), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("STORE", dir)
+	old := command
+	command = tool
+	t.Cleanup(func() { command = old })
+
+	if _, err := Get("ytt", "token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() before Set = %v, want ErrNotFound", err)
+	}
+	secret := `{"access_token": "a", "refresh_token": "r"}`
+	if err := Set("ytt", "token", secret); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Get("ytt", "token"); err != nil || got != secret {
+		t.Errorf("Get() = %q, %v; want %q", got, err, secret)
+	}
+	if err := Delete("ytt", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get("ytt", "token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSecretToolAvailable(t *testing.T) {
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "secret-tool")
+	if err := os.WriteFile(tool, []byte(fakeSecretTool), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("STORE", dir)
+	old := command
+	command = tool
+	t.Cleanup(func() { command = old })
+
+	tests := []struct {
+		name      string
+		bus       string
+		noService string
+		want      bool
+	}{
+		{"service running", "unix:path=/run/bus", "", true},
+		{"no session bus", "", "", false},
+		{"no service on the bus", "unix:path=/run/bus", "1", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("DBUS_SESSION_BUS_ADDRESS", tt.bus)
+		t.Setenv("NO_SERVICE", tt.noService)
+		if got := Available(); got != tt.want {
+			t.Errorf("%s: Available() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMissingTool(t *testing.T) {
+	old := command
+	command = filepath.Join(t.TempDir(), "no-such-tool")
+	t.Cleanup(func() { command = old })
+
+	if Available() {
+		t.Error("Available() = true without the keyring tool")
+	}
+	if err := Set("ytt", "token", "x"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Set() = %v, want ErrUnavailable", err)
+	}
+}
@@ -0,0 +1,278 @@
+// Package manifest tracks the transcripts downloaded into an output directory.
+//
+// The manifest lives in manifest.json at the root of the output directory.
+// Updates are first appended to manifest.journal and fsynced, then folded
+// into manifest.json when the writer compacts, so an interrupted run loses at
+// most the updates that were still being batched in memory.
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// File is the manifest's name inside the output directory.
+	File = "manifest.json"
+	// JournalFile holds updates not yet compacted into File.
+	JournalFile = "manifest.journal"
+)
+
+// Status values for an Entry.
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// statusRemoved marks a journaled update that removes the video's entry.
+const statusRemoved = "removed"
+
+// Entry records the latest result for one video.
+type Entry struct {
+	VideoID     string    `json:"video_id"`
+	Title       string    `json:"title,omitempty"`
+	ChannelID   string    `json:"channel_id,omitempty"`
+	PublishedAt time.Time `json:"published_at,omitzero"`
+	// Language is the caption track's language code, or the detected
+	// language if the track has none.
+	Language string `json:"language,omitempty"`
+	// DetectedLanguage is the language the transcript's text reads as, if
+	// it could be told.
+	DetectedLanguage string `json:"detected_language,omitempty"`
+	File             string `json:"file,omitempty"`
+	// Format is the caption format File was converted to, or empty if it
+	// holds captions as YouTube provided them.
+	Format string `json:"format,omitempty"`
+	// Process is the processing pipeline applied to File, if any.
+	Process string `json:"process,omitempty"`
+	// Raw is the captions as downloaded, if they were kept.
+	Raw string `json:"raw,omitempty"`
+	// Summary is the summary written by --summarize-cmd, if any.
+	Summary string `json:"summary,omitempty"`
+	// Audio is the video's audio track, if it was downloaded with
+	// --with-audio.
+	Audio string `json:"audio,omitempty"`
+	// Hash is the SHA-256 of File as ytt wrote it, so local edits can be
+	// told apart from ytt's own output.
+	Hash string `json:"hash,omitempty"`
+	// SourceHash is the SHA-256 of the captions as last downloaded, so an
+	// updated caption track can be detected.
+	SourceHash string `json:"source_hash,omitempty"`
+	// CaptionID is the caption track File was made from, so the same
+	// track can be compared with later.
+	CaptionID string `json:"caption_id,omitempty"`
+	// CaptionUpdatedAt is the caption track's lastUpdated time when it was
+	// last downloaded, so an unchanged track can be skipped without
+	// downloading it again.
+	CaptionUpdatedAt time.Time `json:"caption_updated_at,omitzero"`
+	// Imported is the file File was imported from by "ytt import", if the
+	// transcript didn't come from YouTube. Imported transcripts aren't
+	// replaced by YouTube's captions.
+	Imported  string    `json:"imported,omitempty"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Manifest is the set of entries for an output directory, keyed by video ID.
+type Manifest struct {
+	UpdatedAt time.Time        `json:"updated_at"`
+	Entries   map[string]Entry `json:"-"`
+}
+
+type manifestJSON struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// MarshalJSON encodes the entries as a list sorted by video ID so the file
+// diffs cleanly between runs.
+func (m *Manifest) MarshalJSON() ([]byte, error) {
+	out := manifestJSON{UpdatedAt: m.UpdatedAt, Entries: m.Sorted()}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes the list form written by MarshalJSON.
+func (m *Manifest) UnmarshalJSON(data []byte) error {
+	var in manifestJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	m.UpdatedAt = in.UpdatedAt
+	m.Entries = make(map[string]Entry, len(in.Entries))
+	for _, e := range in.Entries {
+		m.Entries[e.VideoID] = e
+	}
+	return nil
+}
+
+// Sorted returns the entries ordered by video ID.
+func (m *Manifest) Sorted() []Entry {
+	entries := make([]Entry, 0, len(m.Entries))
+	for _, e := range m.Entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].VideoID < entries[j].VideoID
+	})
+	return entries
+}
+
+// Hash returns the hex SHA-256 of data, as stored in Entry.Hash and
+// Entry.SourceHash.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *Manifest) apply(e Entry) {
+	if e.Status == statusRemoved {
+		delete(m.Entries, e.VideoID)
+		if e.UpdatedAt.After(m.UpdatedAt) {
+			m.UpdatedAt = e.UpdatedAt
+		}
+		return
+	}
+	// A failed retry doesn't remove the file an earlier run saved.
+	if prev, ok := m.Entries[e.VideoID]; ok && e.Status == StatusFailed {
+		if e.File == "" {
+			e.File, e.Format, e.Process, e.Raw = prev.File, prev.Format, prev.Process, prev.Raw
+			e.Summary, e.Audio, e.Hash, e.SourceHash, e.CaptionUpdatedAt = prev.Summary, prev.Audio, prev.Hash, prev.SourceHash, prev.CaptionUpdatedAt
+			e.CaptionID, e.Imported = prev.CaptionID, prev.Imported
+		}
+		if e.Title == "" {
+			e.Title, e.ChannelID, e.PublishedAt, e.Language = prev.Title, prev.ChannelID, prev.PublishedAt, prev.Language
+			e.DetectedLanguage = prev.DetectedLanguage
+		}
+	}
+	m.Entries[e.VideoID] = e
+	if e.UpdatedAt.After(m.UpdatedAt) {
+		m.UpdatedAt = e.UpdatedAt
+	}
+}
+
+// Load reads the manifest in dir, including any journaled updates that were
+// not compacted. A missing manifest yields an empty one.
+func Load(dir string) (*Manifest, error) {
+	m := &Manifest{Entries: map[string]Entry{}}
+
+	data, err := os.ReadFile(filepath.Join(dir, File))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	default:
+		if m, err = Decode(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := replayJournal(filepath.Join(dir, JournalFile), m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func replayJournal(path string, m *Manifest) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading manifest journal: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// A torn final line from a crash mid-append; everything
+			// before it was fsynced and is kept.
+			break
+		}
+		m.apply(e)
+	}
+	return scanner.Err()
+}
+
+// Decode parses the contents of a manifest file, for manifests kept
+// somewhere other than a local output directory.
+func Decode(data []byte) (*Manifest, error) {
+	m := &Manifest{Entries: map[string]Entry{}}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Encode returns the contents of the manifest file for m.
+func (m *Manifest) Encode() ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding manifest: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Add records an update in m, as Writer.Record does for a manifest on disk.
+func (m *Manifest) Add(e Entry) {
+	if e.UpdatedAt.IsZero() {
+		e.UpdatedAt = time.Now().UTC()
+	}
+	m.apply(e)
+}
+
+// save atomically replaces the manifest file in dir with m.
+func save(dir string, m *Manifest) error {
+	data, err := m.Encode()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, File), data)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error syncing manifest: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error replacing manifest: %w", err)
+	}
+	return nil
+}
+
+func encodeBatch(batch []Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range batch {
+		if err := enc.Encode(e); err != nil {
+			return nil, fmt.Errorf("error encoding manifest entry: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
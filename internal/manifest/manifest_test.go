@@ -0,0 +1,174 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/clock"
+)
+
+func TestWriterConcurrentRecords(t *testing.T) {
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, WriterOptions{MaxBatch: 7, CompactEvery: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Record(Entry{VideoID: fmt.Sprintf("vid%02d", i), Status: StatusOK})
+		}()
+	}
+	wg.Wait()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, JournalFile)); !os.IsNotExist(err) {
+		t.Errorf("journal left behind after Close: %v", err)
+	}
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 50 {
+		t.Errorf("manifest has %d entries, want 50", len(m.Entries))
+	}
+}
+
+func TestWritersShareManifest(t *testing.T) {
+	// Two writers on one directory stand in for two ytt processes.
+	dir := t.TempDir()
+	var writers []*Writer
+	for range 2 {
+		w, err := OpenWriter(dir, WriterOptions{MaxBatch: 3, CompactEvery: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		writers = append(writers, w)
+	}
+
+	var wg sync.WaitGroup
+	for n, w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 20 {
+				w.Record(Entry{VideoID: fmt.Sprintf("w%d-vid%02d", n, i), Status: StatusOK})
+			}
+		}()
+	}
+	wg.Wait()
+	for _, w := range writers {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 40 {
+		t.Errorf("manifest has %d entries, want 40", len(m.Entries))
+	}
+}
+
+func TestLoadReplaysJournal(t *testing.T) {
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Record(Entry{VideoID: "a", Status: StatusOK, File: "a-Old.txt"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash after journaling two updates, the last one torn.
+	journal := `{"video_id":"a","status":"ok","file":"a-New.txt","updated_at":"2025-01-02T00:00:00Z"}
+{"video_id":"b","status":"ok","updated_at":"2025-01-02T00:00:00Z"}
+{"video_id":"c","sta`
+	if err := os.WriteFile(filepath.Join(dir, JournalFile), []byte(journal), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Entries["a"].File; got != "a-New.txt" {
+		t.Errorf("entry a file = %q, want a-New.txt", got)
+	}
+	if _, ok := m.Entries["b"]; !ok {
+		t.Error("entry b missing after replay")
+	}
+	if _, ok := m.Entries["c"]; ok {
+		t.Error("torn entry c should not be applied")
+	}
+}
+
+func TestFailedRetryKeepsFile(t *testing.T) {
+	updated := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	m := &Manifest{Entries: map[string]Entry{}}
+	m.apply(Entry{VideoID: "a", Title: "T", File: "a-T.vtt", Format: "vtt", Process: "dedupe", Raw: "raw/a.vtt", Summary: "a-summary.md", Hash: "h", SourceHash: "s", CaptionID: "c1", CaptionUpdatedAt: updated, Imported: "whisper/a.srt", DetectedLanguage: "en", Status: StatusOK})
+	m.apply(Entry{VideoID: "a", Status: StatusFailed, Error: "boom"})
+
+	want := Entry{VideoID: "a", Title: "T", File: "a-T.vtt", Format: "vtt", Process: "dedupe", Raw: "raw/a.vtt", Summary: "a-summary.md", Hash: "h", SourceHash: "s", CaptionID: "c1", CaptionUpdatedAt: updated, Imported: "whisper/a.srt", DetectedLanguage: "en", Status: StatusFailed, Error: "boom"}
+	if got := m.Entries["a"]; got != want {
+		t.Errorf("entry = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriterRemove(t *testing.T) {
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Record(Entry{VideoID: "a", Status: StatusOK})
+	w.Record(Entry{VideoID: "b", Status: StatusOK})
+	w.Remove("a")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Entries["a"]; ok {
+		t.Error("removed entry a still in manifest")
+	}
+	if _, ok := m.Entries["b"]; !ok {
+		t.Error("entry b missing")
+	}
+}
+
+func TestWriterStampsWithClock(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 6, 1, 9, 30, 0, 0, time.UTC)
+	w, err := OpenWriter(dir, WriterOptions{Clock: clock.NewFake(now)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Record(Entry{VideoID: "a", Status: StatusOK})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Entries["a"].UpdatedAt; !got.Equal(now) {
+		t.Errorf("UpdatedAt = %v, want %v", got, now)
+	}
+}
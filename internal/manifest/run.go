@@ -0,0 +1,98 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Run describes one batch run: what it downloaded from, when, with which
+// version of ytt, and the entry each video ended up with. Unlike the output
+// directory's manifest, which keeps the latest entry for every video, a Run
+// covers only the videos that one run touched.
+type Run struct {
+	Version string `json:"ytt_version"`
+	// Source is what the videos came from, such as "channel UCxxxx" or
+	// "videos abc123,def456".
+	Source     string    `json:"source"`
+	Output     string    `json:"output"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Results    []Entry   `json:"results"`
+}
+
+// WriteRun writes r to path as indented JSON, with its results ordered by
+// video ID.
+func WriteRun(path string, r *Run) error {
+	sort.Slice(r.Results, func(i, j int) bool {
+		return r.Results[i].VideoID < r.Results[j].VideoID
+	})
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding run manifest: %w", err)
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// LoadRun reads a run manifest written by WriteRun.
+func LoadRun(path string) (*Run, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading run manifest: %w", err)
+	}
+	var r Run
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("error parsing run manifest: %w", err)
+	}
+	return &r, nil
+}
+
+// Verification is the result of checking saved files against their
+// recorded hashes.
+type Verification struct {
+	// OK lists the entries whose file matches its hash.
+	OK []Entry
+	// Modified lists the entries whose file no longer matches its hash.
+	Modified []Entry
+	// Missing lists the entries whose file is gone.
+	Missing []Entry
+	// Unhashed lists the entries saved before hashes were recorded, which
+	// can't be checked.
+	Unhashed []Entry
+}
+
+// Failed reports whether any file was modified or missing.
+func (v *Verification) Failed() bool {
+	return len(v.Modified) > 0 || len(v.Missing) > 0
+}
+
+// Verify checks the file of each entry, relative to dir, against the entry's
+// hash. Entries without a file, such as failed downloads, are skipped.
+func Verify(dir string, entries []Entry) (*Verification, error) {
+	v := &Verification{}
+	for _, e := range entries {
+		if e.File == "" {
+			continue
+		}
+		if e.Hash == "" {
+			v.Unhashed = append(v.Unhashed, e)
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(e.File)))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			v.Missing = append(v.Missing, e)
+		case err != nil:
+			return nil, fmt.Errorf("error reading %s: %w", e.File, err)
+		case Hash(data) != e.Hash:
+			v.Modified = append(v.Modified, e)
+		default:
+			v.OK = append(v.OK, e)
+		}
+	}
+	return v, nil
+}
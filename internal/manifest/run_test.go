@@ -0,0 +1,88 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteRunRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := &Run{
+		Version:    "v1.2.3",
+		Source:     "channel UCxxxx",
+		Output:     "outputs",
+		StartedAt:  start,
+		FinishedAt: start.Add(time.Minute),
+		Results: []Entry{
+			{VideoID: "b", File: "b-Two.txt", Hash: "bbb", Status: StatusOK, UpdatedAt: start},
+			{VideoID: "a", Status: StatusFailed, Error: "no captions", UpdatedAt: start},
+		},
+	}
+	if err := WriteRun(path, r); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadRun(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, r) {
+		t.Errorf("LoadRun() = %+v, want %+v", got, r)
+	}
+	if got.Results[0].VideoID != "a" {
+		t.Errorf("results not sorted by video ID: %+v", got.Results)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("ok.txt", "hello")
+	write("edited.txt", "hello, edited")
+	write("legacy.txt", "old")
+
+	entries := []Entry{
+		{VideoID: "ok", File: "ok.txt", Hash: Hash([]byte("hello"))},
+		{VideoID: "edited", File: "edited.txt", Hash: Hash([]byte("hello"))},
+		{VideoID: "gone", File: "gone.txt", Hash: Hash([]byte("hello"))},
+		{VideoID: "legacy", File: "legacy.txt"},
+		{VideoID: "failed", Status: StatusFailed},
+	}
+	v, err := Verify(dir, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(entries []Entry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.VideoID)
+		}
+		return out
+	}
+	tests := []struct {
+		name string
+		got  []Entry
+		want []string
+	}{
+		{"OK", v.OK, []string{"ok"}},
+		{"Modified", v.Modified, []string{"edited"}},
+		{"Missing", v.Missing, []string{"gone"}},
+		{"Unhashed", v.Unhashed, []string{"legacy"}},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.got); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if !v.Failed() {
+		t.Error("Failed() = false, want true")
+	}
+}
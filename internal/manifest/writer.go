@@ -0,0 +1,203 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/n2p5/ytt/internal/clock"
+	"github.com/n2p5/ytt/internal/filelock"
+)
+
+// WriterOptions tunes how a Writer batches updates.
+type WriterOptions struct {
+	// FlushInterval is the longest an update waits in memory before it is
+	// journaled and fsynced. Defaults to one second.
+	FlushInterval time.Duration
+	// MaxBatch journals pending updates as soon as this many accumulate.
+	// Defaults to 100.
+	MaxBatch int
+	// CompactEvery folds the journal into the manifest file after this many
+	// journaled updates. Defaults to 1000.
+	CompactEvery int
+	// Clock, if set, is used instead of the system clock to time flushes
+	// and stamp updates.
+	Clock clock.Clock
+}
+
+// Writer owns the manifest for an output directory. Any number of goroutines
+// may call Record; a single background goroutine batches the updates,
+// appends them to the journal, and periodically compacts.
+//
+// Other processes may write the same manifest at once, such as a cron job
+// and a manual run: journal appends and compactions hold the manifest's
+// file lock, and compaction folds in every process's journaled updates.
+type Writer struct {
+	dir      string
+	opts     WriterOptions
+	updates  chan Entry
+	done     chan struct{}
+	closeOne sync.Once
+
+	// Owned by the run goroutine.
+	manifest  *Manifest
+	journaled int
+	err       error
+}
+
+// OpenWriter loads the manifest in dir, recovering any journaled updates, and
+// starts the writer goroutine. Close must be called to flush and compact.
+func OpenWriter(dir string, opts WriterOptions) (*Writer, error) {
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.MaxBatch <= 0 {
+		opts.MaxBatch = 100
+	}
+	if opts.CompactEvery <= 0 {
+		opts.CompactEvery = 1000
+	}
+	opts.Clock = clock.Or(opts.Clock)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating output directory: %w", err)
+	}
+	m, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{
+		dir:      dir,
+		opts:     opts,
+		updates:  make(chan Entry, opts.MaxBatch),
+		done:     make(chan struct{}),
+		manifest: m,
+	}
+	// Fold recovered journal entries in before appending new ones.
+	if err := w.compact(); err != nil {
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Record queues an update. It does not wait for the update to be written.
+func (w *Writer) Record(e Entry) {
+	if e.UpdatedAt.IsZero() {
+		e.UpdatedAt = w.opts.Clock.Now().UTC()
+	}
+	w.updates <- e
+}
+
+// Remove queues the removal of a video's entry.
+func (w *Writer) Remove(videoID string) {
+	w.Record(Entry{VideoID: videoID, Status: statusRemoved})
+}
+
+// Close flushes pending updates, compacts the journal into the manifest file,
+// and stops the writer. It returns the first error the writer encountered.
+func (w *Writer) Close() error {
+	w.closeOne.Do(func() { close(w.updates) })
+	<-w.done
+	return w.err
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := w.opts.Clock.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var pending []Entry
+	for {
+		select {
+		case e, ok := <-w.updates:
+			if !ok {
+				w.flush(pending)
+				w.setErr(w.compact())
+				return
+			}
+			pending = append(pending, e)
+			if len(pending) >= w.opts.MaxBatch {
+				w.flush(pending)
+				pending = nil
+			}
+		case <-ticker.C():
+			if len(pending) > 0 {
+				w.flush(pending)
+				pending = nil
+			}
+		}
+	}
+}
+
+// flush appends batch to the journal with a single write and fsync. The
+// journal is reopened for each batch, since another process may have
+// compacted it away since the last.
+func (w *Writer) flush(batch []Entry) {
+	if len(batch) == 0 {
+		return
+	}
+	for _, e := range batch {
+		w.manifest.apply(e)
+	}
+
+	data, err := encodeBatch(batch)
+	if err != nil {
+		w.setErr(err)
+		return
+	}
+	err = filelock.With(filepath.Join(w.dir, File), func() error {
+		f, err := os.OpenFile(filepath.Join(w.dir, JournalFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("error opening manifest journal: %w", err)
+		}
+		defer f.Close()
+		if _, err := f.Write(data); err != nil {
+			return fmt.Errorf("error writing manifest journal: %w", err)
+		}
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("error syncing manifest journal: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		w.setErr(err)
+		return
+	}
+
+	w.journaled += len(batch)
+	if w.journaled >= w.opts.CompactEvery {
+		w.setErr(w.compact())
+	}
+}
+
+// compact folds the journal into the manifest file and then discards it.
+// The manifest is reloaded first, so updates journaled by other processes
+// are kept along with this writer's, which are all journaled already.
+func (w *Writer) compact() error {
+	return filelock.With(filepath.Join(w.dir, File), func() error {
+		m, err := Load(w.dir)
+		if err != nil {
+			return err
+		}
+		if err := save(w.dir, m); err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(w.dir, JournalFile)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing manifest journal: %w", err)
+		}
+		w.manifest, w.journaled = m, 0
+		return nil
+	})
+}
+
+func (w *Writer) setErr(err error) {
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+}
@@ -0,0 +1,180 @@
+// Package metrics keeps running totals of the work ytt commands have done,
+// and writes them in the Prometheus text exposition format.
+//
+// Each command adds its counts to metrics.json in ytt's data directory when
+// it finishes, so a long-running "ytt serve" can expose totals across
+// separate runs of sync and the other commands.
+package metrics
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File is the totals file's name inside the data directory.
+const File = "metrics.json"
+
+// Totals are the counters accumulated across runs.
+type Totals struct {
+	// Counters maps each series, a metric name followed by its labels such
+	// as `ytt_api_errors_total{reason="not_found"}`, to its value.
+	Counters map[string]float64 `json:"counters"`
+	// LastRun maps each command, such as "sync", to when a run of it last
+	// finished.
+	LastRun map[string]time.Time `json:"last_run"`
+}
+
+// labelEscaper escapes label values as the exposition format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Series returns the series name for metric name with the given label
+// name and value pairs.
+func Series(name string, labels ...string) string {
+	if len(labels) < 2 {
+		return name
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteByte('=')
+		b.WriteByte('"')
+		labelEscaper.WriteString(&b, labels[i+1])
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Load reads the totals saved in dir. It returns empty totals if there are
+// none.
+func Load(dir string) (*Totals, error) {
+	t := &Totals{Counters: map[string]float64{}, LastRun: map[string]time.Time{}}
+	data, err := os.ReadFile(filepath.Join(dir, File))
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading metrics: %w", err)
+	}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("error parsing metrics: %w", err)
+	}
+	if t.Counters == nil {
+		t.Counters = map[string]float64{}
+	}
+	if t.LastRun == nil {
+		t.LastRun = map[string]time.Time{}
+	}
+	return t, nil
+}
+
+// Add adds counts to the totals saved in dir and records that a run of
+// command finished at at.
+func Add(dir, command string, counts map[string]float64, at time.Time) error {
+	t, err := Load(dir)
+	if err != nil {
+		return err
+	}
+	for series, n := range counts {
+		t.Counters[series] += n
+	}
+	t.LastRun[command] = at.UTC()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding metrics: %w", err)
+	}
+	tmp := filepath.Join(dir, File+".tmp")
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing metrics: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, File)); err != nil {
+		return fmt.Errorf("error writing metrics: %w", err)
+	}
+	return nil
+}
+
+// Sample is the value of one series.
+type Sample struct {
+	Series string
+	Value  float64
+}
+
+// name returns the metric name of the sample's series.
+func (s Sample) name() string {
+	name, _, _ := strings.Cut(s.Series, "{")
+	return name
+}
+
+// Samples returns the totals as samples: the counters as they are, and
+// LastRun as the gauge ytt_last_run_timestamp_seconds labeled by command.
+func (t *Totals) Samples() []Sample {
+	var samples []Sample
+	for series, n := range t.Counters {
+		samples = append(samples, Sample{series, n})
+	}
+	for command, at := range t.LastRun {
+		samples = append(samples, Sample{Series("ytt_last_run_timestamp_seconds", "command", command), float64(at.Unix())})
+	}
+	return samples
+}
+
+// Write writes samples in the Prometheus text exposition format, grouped
+// by metric. Metrics whose names end in _total are counters and the rest
+// are gauges; help gives metrics a description.
+func Write(w io.Writer, samples []Sample, help map[string]string) error {
+	samples = slices.Clone(samples)
+	slices.SortFunc(samples, func(a, b Sample) int {
+		return cmp.Or(strings.Compare(a.name(), b.name()), strings.Compare(a.Series, b.Series))
+	})
+	var b strings.Builder
+	last := ""
+	for _, s := range samples {
+		if name := s.name(); name != last {
+			if h, ok := help[name]; ok {
+				fmt.Fprintf(&b, "# HELP %s %s\n", name, h)
+			}
+			kind := "gauge"
+			if strings.HasSuffix(name, "_total") {
+				kind = "counter"
+			}
+			fmt.Fprintf(&b, "# TYPE %s %s\n", name, kind)
+			last = name
+		}
+		fmt.Fprintf(&b, "%s %s\n", s.Series, strconv.FormatFloat(s.Value, 'f', -1, 64))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the samples returned by collect in the Prometheus text
+// exposition format.
+func Handler(collect func() ([]Sample, error), help map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		samples, err := collect()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w, samples, help)
+	})
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSeries(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels []string
+		want   string
+	}{
+		{"ytt_quota_units_used_total", nil, "ytt_quota_units_used_total"},
+		{"ytt_api_errors_total", []string{"reason", "not_found"}, `ytt_api_errors_total{reason="not_found"}`},
+		{"m", []string{"a", "1", "b", "2"}, `m{a="1",b="2"}`},
+		{"m", []string{"a", "say \"hi\"\\\n"}, `m{a="say \"hi\"\\\n"}`},
+	}
+	for _, tt := range tests {
+		if got := Series(tt.name, tt.labels...); got != tt.want {
+			t.Errorf("Series(%q, %q) = %s, want %s", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	dir := t.TempDir()
+
+	tot, err := Load(dir)
+	if err != nil || len(tot.Counters) != 0 || len(tot.LastRun) != 0 {
+		t.Fatalf("Load() with nothing saved = %+v, %v; want empty totals", tot, err)
+	}
+
+	first := time.Date(2025, 3, 1, 15, 0, 0, 0, time.UTC)
+	downloaded := Series("ytt_transcripts_downloaded_total", "job", "sync")
+	if err := Add(dir, "sync", map[string]float64{downloaded: 3, "ytt_quota_units_used_total": 250}, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := Add(dir, "download", map[string]float64{downloaded: 2}, first.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	tot, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tot.Counters[downloaded]; got != 5 {
+		t.Errorf("%s = %v, want 5", downloaded, got)
+	}
+	if got := tot.Counters["ytt_quota_units_used_total"]; got != 250 {
+		t.Errorf("ytt_quota_units_used_total = %v, want 250", got)
+	}
+	if got := tot.LastRun["sync"]; !got.Equal(first) {
+		t.Errorf("LastRun[sync] = %v, want %v", got, first)
+	}
+	if got := tot.LastRun["download"]; !got.Equal(first.Add(time.Hour)) {
+		t.Errorf("LastRun[download] = %v, want %v", got, first.Add(time.Hour))
+	}
+}
+
+func TestWrite(t *testing.T) {
+	samples := []Sample{
+		{`ytt_transcripts{status="ok"}`, 12},
+		{`ytt_api_errors_total{reason="quota_exceeded"}`, 1},
+		{"ytt_transcripts_downloaded_total", 40},
+		{`ytt_api_errors_total{reason="not_found"}`, 2},
+		{`ytt_transcripts{status="failed"}`, 3},
+	}
+	help := map[string]string{"ytt_api_errors_total": "Videos that failed with an API error."}
+
+	var b strings.Builder
+	if err := Write(&b, samples, help); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP ytt_api_errors_total Videos that failed with an API error.
+# TYPE ytt_api_errors_total counter
+ytt_api_errors_total{reason="not_found"} 2
+ytt_api_errors_total{reason="quota_exceeded"} 1
+# TYPE ytt_transcripts gauge
+ytt_transcripts{status="failed"} 3
+ytt_transcripts{status="ok"} 12
+# TYPE ytt_transcripts_downloaded_total counter
+ytt_transcripts_downloaded_total 40
+`
+	if got := b.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tot := &Totals{
+		Counters: map[string]float64{"ytt_quota_units_used_total": 7},
+		LastRun:  map[string]time.Time{"sync": time.Unix(1700000000, 0)},
+	}
+	h := Handler(func() ([]Sample, error) { return tot.Samples(), nil }, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", w.Header().Get("Content-Type"))
+	}
+	for _, line := range []string{
+		"ytt_quota_units_used_total 7\n",
+		`ytt_last_run_timestamp_seconds{command="sync"} 1700000000` + "\n",
+	} {
+		if !strings.Contains(w.Body.String(), line) {
+			t.Errorf("body missing %q:\n%s", line, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package notify delivers short messages about ytt runs to people.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Message is a notification.
+type Message struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// Notifier delivers messages.
+type Notifier interface {
+	Notify(ctx context.Context, m Message) error
+}
+
+// Writer prints messages to W, one per line.
+type Writer struct {
+	W io.Writer
+}
+
+// Notify implements Notifier.
+func (n Writer) Notify(ctx context.Context, m Message) error {
+	_, err := fmt.Fprintf(n.W, "[%s] %s\n", m.Title, m.Text)
+	return err
+}
+
+// Webhook POSTs each message as JSON to URL.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify implements Notifier.
+func (n Webhook) Notify(ctx context.Context, m Message) error {
+	return postJSON(ctx, n.Client, n.URL, m)
+}
+
+// Slack posts each message to a Slack incoming webhook URL.
+type Slack struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify implements Notifier.
+func (n Slack) Notify(ctx context.Context, m Message) error {
+	return postJSON(ctx, n.Client, n.URL, map[string]string{"text": "*" + m.Title + "*\n" + m.Text})
+}
+
+// Discord posts each message to a Discord webhook URL.
+type Discord struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify implements Notifier.
+func (n Discord) Notify(ctx context.Context, m Message) error {
+	return postJSON(ctx, n.Client, n.URL, map[string]string{"content": "**" + m.Title + "**\n" + m.Text})
+}
+
+// ForURL returns the notifier for a webhook URL: Slack and Discord webhooks
+// get messages in their own formats, and any other URL gets Webhook's JSON.
+func ForURL(rawURL string) Notifier {
+	if u, err := url.Parse(rawURL); err == nil {
+		switch host := strings.ToLower(u.Hostname()); {
+		case host == "hooks.slack.com":
+			return Slack{URL: rawURL}
+		case (host == "discord.com" || host == "discordapp.com") && strings.HasPrefix(u.Path, "/api/webhooks/"):
+			return Discord{URL: rawURL}
+		}
+	}
+	return Webhook{URL: rawURL}
+}
+
+// postJSON POSTs v as JSON to endpoint.
+func postJSON(ctx context.Context, client *http.Client, endpoint string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Desktop shows messages as native desktop notifications, with notify-send
+// on Linux and the BSDs, osascript on macOS, and PowerShell on Windows.
+type Desktop struct{}
+
+// Notify implements Notifier.
+func (Desktop) Notify(ctx context.Context, m Message) error {
+	cmd := desktopCommand(ctx, runtime.GOOS, m)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error showing desktop notification: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// windowsNotify shows a notification from a tray icon, which needs nothing
+// beyond what ships with Windows.
+const windowsNotify = `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, $env:YTT_NOTIFY_TITLE, $env:YTT_NOTIFY_TEXT, 'Info')
+Start-Sleep -Seconds 5
+$n.Dispose()`
+
+// desktopCommand returns the command showing m on goos. The scripts read
+// the message from the environment so it needs no quoting.
+func desktopCommand(ctx context.Context, goos string, m Message) *exec.Cmd {
+	var cmd *exec.Cmd
+	switch goos {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "osascript", "-e", `display notification (system attribute "YTT_NOTIFY_TEXT") with title (system attribute "YTT_NOTIFY_TITLE")`)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsNotify)
+	default:
+		return exec.CommandContext(ctx, "notify-send", "--app-name=ytt", m.Title, m.Text)
+	}
+	cmd.Env = append(os.Environ(), "YTT_NOTIFY_TITLE="+m.Title, "YTT_NOTIFY_TEXT="+m.Text)
+	return cmd
+}
+
+// Multi sends each message to every notifier and joins their errors.
+type Multi []Notifier
+
+// Notify implements Notifier.
+func (n Multi) Notify(ctx context.Context, m Message) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(ctx, m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWebhook(t *testing.T) {
+	var got Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	want := Message{Title: "ytt", Text: "done"}
+	if err := (Webhook{URL: server.URL}).Notify(context.Background(), want); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("webhook received %+v, want %+v", got, want)
+	}
+}
+
+func TestMultiJoinsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var out strings.Builder
+	n := Multi{Writer{W: &out}, Webhook{URL: server.URL}}
+	err := n.Notify(context.Background(), Message{Title: "ytt", Text: "done"})
+	if err == nil {
+		t.Error("Notify() succeeded despite failing webhook")
+	}
+	if out.String() != "[ytt] done\n" {
+		t.Errorf("writer got %q; a failing notifier should not stop the others", out.String())
+	}
+}
+
+func TestChatWebhooks(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	m := Message{Title: "ytt sync finished", Text: "3 of 3 transcripts synced"}
+	tests := []struct {
+		notifier Notifier
+		want     map[string]string
+	}{
+		{Slack{URL: server.URL}, map[string]string{"text": "*ytt sync finished*\n3 of 3 transcripts synced"}},
+		{Discord{URL: server.URL}, map[string]string{"content": "**ytt sync finished**\n3 of 3 transcripts synced"}},
+	}
+	for _, tt := range tests {
+		if err := tt.notifier.Notify(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%T posted %v, want %v", tt.notifier, got, tt.want)
+		}
+	}
+}
+
+func TestForURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Notifier
+	}{
+		{"https://hooks.slack.com/services/T0/B0/x", Slack{URL: "https://hooks.slack.com/services/T0/B0/x"}},
+		{"https://discord.com/api/webhooks/1/abc", Discord{URL: "https://discord.com/api/webhooks/1/abc"}},
+		{"https://discordapp.com/api/webhooks/1/abc", Discord{URL: "https://discordapp.com/api/webhooks/1/abc"}},
+		{"https://discord.com/channels/1", Webhook{URL: "https://discord.com/channels/1"}},
+		{"https://example.com/hooks/ytt", Webhook{URL: "https://example.com/hooks/ytt"}},
+	}
+	for _, tt := range tests {
+		if got := ForURL(tt.url); got != tt.want {
+			t.Errorf("ForURL(%q) = %#v, want %#v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestDesktopCommand(t *testing.T) {
+	m := Message{Title: "ytt", Text: `it's "done"`}
+	tests := []struct {
+		goos     string
+		wantName string
+		wantEnv  bool
+	}{
+		{"linux", "notify-send", false},
+		{"darwin", "osascript", true},
+		{"windows", "powershell", true},
+	}
+	for _, tt := range tests {
+		cmd := desktopCommand(context.Background(), tt.goos, m)
+		if cmd.Args[0] != tt.wantName {
+			t.Errorf("%s: command = %q, want %q", tt.goos, cmd.Args[0], tt.wantName)
+		}
+		if tt.wantEnv {
+			if !slices.Contains(cmd.Env, `YTT_NOTIFY_TEXT=it's "done"`) {
+				t.Errorf("%s: message not passed in the environment: %q", tt.goos, cmd.Env)
+			}
+		} else if !slices.Contains(cmd.Args, m.Text) {
+			t.Errorf("%s: message not passed as an argument: %q", tt.goos, cmd.Args)
+		}
+	}
+}
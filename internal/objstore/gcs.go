@@ -0,0 +1,61 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// gcsStore stores objects in Google Cloud Storage.
+type gcsStore struct {
+	bucket, prefix string
+	service        *storage.Service
+}
+
+// newGCS connects to Cloud Storage with Application Default Credentials.
+func newGCS(ctx context.Context, bucket, prefix string, opts ...option.ClientOption) (*gcsStore, error) {
+	opts = append([]option.ClientOption{option.WithScopes(storage.DevstorageReadWriteScope)}, opts...)
+	service, err := storage.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Cloud Storage service: %w", err)
+	}
+	return &gcsStore{bucket: bucket, prefix: prefix, service: service}, nil
+}
+
+func (s *gcsStore) URL(name string) string {
+	return "gs://" + s.bucket + "/" + s.prefix + name
+}
+
+func (s *gcsStore) Put(ctx context.Context, name string, data []byte) error {
+	obj := &storage.Object{Name: s.prefix + name, ContentType: contentType(name)}
+	_, err := s.service.Objects.Insert(s.bucket, obj).Media(bytes.NewReader(data)).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("error uploading %s: %w", s.URL(name), err)
+	}
+	return nil
+}
+
+func (s *gcsStore) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := s.service.Objects.Get(s.bucket, s.prefix+name).Context(ctx).Download()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			err = fmt.Errorf("%w: %w", err, fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("error downloading %s: %w", s.URL(name), err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s: %w", s.URL(name), err)
+	}
+	return data, nil
+}
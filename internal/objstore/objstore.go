@@ -0,0 +1,48 @@
+// Package objstore reads and writes objects in remote object storage, so
+// transcripts can be saved to a bucket instead of the local filesystem.
+package objstore
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Store is a bucket, or a prefix within one, holding objects by name.
+type Store interface {
+	// Put creates or replaces the named object.
+	Put(ctx context.Context, name string, data []byte) error
+	// Get returns the named object's contents, or an error wrapping
+	// fs.ErrNotExist if there is no such object.
+	Get(ctx context.Context, name string) ([]byte, error)
+	// URL returns the location of the named object, as given to Open.
+	URL(name string) string
+}
+
+// IsRemote reports whether an output location is an object storage URL
+// rather than a local directory.
+func IsRemote(location string) bool {
+	return strings.HasPrefix(location, "s3://") || strings.HasPrefix(location, "gs://")
+}
+
+// Open returns the store for an s3://bucket/prefix or gs://bucket/prefix
+// URL. Credentials come from the environment, as the providers' own tools
+// find them.
+func Open(ctx context.Context, location string) (Store, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid storage URL %q", location)
+	}
+	prefix := strings.Trim(u.Path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	switch u.Scheme {
+	case "s3":
+		return newS3(u.Host, prefix)
+	case "gs":
+		return newGCS(ctx, u.Host, prefix)
+	}
+	return nil, fmt.Errorf("unsupported storage URL %q (want s3:// or gs://)", location)
+}
@@ -0,0 +1,104 @@
+// Package progress reports the progress of batch jobs as line-delimited
+// JSON events, for GUIs and wrappers that draw their own progress bars.
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	EventStart     = "start"
+	EventItemStart = "item_start"
+	EventItemDone  = "item_done"
+	EventFinish    = "finish"
+)
+
+// Event is one line of progress output. Counts are as of the event.
+type Event struct {
+	Event  string    `json:"event"`
+	Time   time.Time `json:"time"`
+	Job    string    `json:"job"`
+	ID     string    `json:"id,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Done   int       `json:"done"`
+	Failed int       `json:"failed"`
+	Total  int       `json:"total"`
+}
+
+// Reporter writes events for one job. Its methods are safe for concurrent
+// use, and do nothing on a nil Reporter so callers needn't check whether
+// progress reporting is enabled.
+type Reporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	job    string
+	total  int
+	done   int
+	failed int
+	now    func() time.Time
+}
+
+// New returns a reporter for a job of total items and emits its start event.
+func New(w io.Writer, job string, total int) *Reporter {
+	r := &Reporter{w: w, job: job, total: total, now: time.Now}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emit(EventStart, "", nil)
+	return r
+}
+
+// Begin reports that an item started.
+func (r *Reporter) Begin(id string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emit(EventItemStart, id, nil)
+}
+
+// End reports that an item finished, failing if err is non-nil.
+func (r *Reporter) End(id string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done++
+	if err != nil {
+		r.failed++
+	}
+	r.emit(EventItemDone, id, err)
+}
+
+// Finish reports that the job finished.
+func (r *Reporter) Finish() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emit(EventFinish, "", nil)
+}
+
+func (r *Reporter) emit(event, id string, err error) {
+	e := Event{
+		Event:  event,
+		Time:   r.now().UTC(),
+		Job:    r.job,
+		ID:     id,
+		Done:   r.done,
+		Failed: r.failed,
+		Total:  r.total,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	// Progress is best effort; a closed pipe shouldn't fail the job.
+	data, _ := json.Marshal(e)
+	r.w.Write(append(data, '\n'))
+}
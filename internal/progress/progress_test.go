@@ -0,0 +1,36 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, "download", 2)
+	r.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+	buf.Reset()
+
+	r.Begin("a")
+	r.End("a", nil)
+	r.End("b", errors.New("no captions"))
+	r.Finish()
+
+	want := `{"event":"item_start","time":"2025-01-02T03:04:05Z","job":"download","id":"a","done":0,"failed":0,"total":2}
+{"event":"item_done","time":"2025-01-02T03:04:05Z","job":"download","id":"a","done":1,"failed":0,"total":2}
+{"event":"item_done","time":"2025-01-02T03:04:05Z","job":"download","id":"b","error":"no captions","done":2,"failed":1,"total":2}
+{"event":"finish","time":"2025-01-02T03:04:05Z","job":"download","done":2,"failed":1,"total":2}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("events =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNilReporter(t *testing.T) {
+	var r *Reporter
+	r.Begin("a")
+	r.End("a", nil)
+	r.Finish()
+}
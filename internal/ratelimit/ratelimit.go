@@ -0,0 +1,98 @@
+// Package ratelimit paces requests with a token bucket that slows down when
+// the server reports it is being called too fast.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/n2p5/ytt/internal/clock"
+)
+
+const (
+	// maxSlowdown is how far below the configured rate SlowDown can go.
+	maxSlowdown = 16
+	// recoverAfter is how long the rate must go without a SlowDown before
+	// it doubles back toward the configured rate.
+	recoverAfter = time.Minute
+)
+
+// Limiter is a token bucket that allows qps requests per second on average,
+// and up to burst at once. It is safe for concurrent use.
+type Limiter struct {
+	clock clock.Clock
+
+	mu       sync.Mutex
+	max      float64 // configured rate
+	rate     float64 // current rate, lowered by SlowDown
+	burst    float64
+	tokens   float64
+	last     time.Time // when tokens was last refilled
+	slowedAt time.Time // when rate last changed
+}
+
+// New returns a Limiter allowing qps requests per second with bursts of up
+// to burst, reading the time from clk. A burst below 1 means 1.
+func New(qps float64, burst int, clk clock.Clock) *Limiter {
+	clk = clock.Or(clk)
+	b := float64(max(burst, 1))
+	now := clk.Now()
+	return &Limiter{clock: clk, max: qps, rate: qps, burst: b, tokens: b, last: now, slowedAt: now}
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := l.clock.Now()
+		l.refill(now)
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.clock.After(wait):
+		}
+	}
+}
+
+// SlowDown halves the rate, down to a sixteenth of the configured rate,
+// and empties the bucket. The rate doubles back each minute that passes
+// without another SlowDown.
+func (l *Limiter) SlowDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	l.refill(now)
+	l.rate = max(l.rate/2, l.max/maxSlowdown)
+	l.tokens = 0
+	l.slowedAt = now
+}
+
+// Rate returns the current rate in requests per second.
+func (l *Limiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(l.clock.Now())
+	return l.rate
+}
+
+// refill adds the tokens earned since the last refill and recovers the rate
+// after a slowdown.
+func (l *Limiter) refill(now time.Time) {
+	for l.rate < l.max && now.Sub(l.slowedAt) >= recoverAfter {
+		l.slowedAt = l.slowedAt.Add(recoverAfter)
+		l.rate = min(l.rate*2, l.max)
+	}
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+		l.last = now
+	}
+}
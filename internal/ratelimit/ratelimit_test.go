@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/clock"
+)
+
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestLimiterBurstThenRate(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	l := New(2, 3, clk)
+	ctx := context.Background()
+
+	for i := range 3 {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("Wait %d: %v", i, err)
+		}
+	}
+
+	done := make(chan error)
+	go func() { done <- l.Wait(ctx) }()
+	clk.BlockUntil(1)
+	select {
+	case <-done:
+		t.Fatal("Wait returned with an empty bucket")
+	default:
+	}
+	clk.Advance(500 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimiterWaitCanceled(t *testing.T) {
+	l := New(1, 1, clock.NewFake(epoch))
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); err != context.Canceled {
+		t.Errorf("Wait() = %v, want context.Canceled", err)
+	}
+}
+
+func TestLimiterSlowDown(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	l := New(8, 1, clk)
+
+	tests := []struct {
+		slowDown bool
+		advance  time.Duration
+		want     float64
+	}{
+		{true, 0, 4},
+		{true, 0, 2},
+		{true, 0, 1},
+		{true, 0, 0.5},
+		// Never below a sixteenth of the configured rate.
+		{true, 0, 0.5},
+		{false, 59 * time.Second, 0.5},
+		{false, time.Second, 1},
+		{false, 2 * time.Minute, 4},
+		{false, 10 * time.Minute, 8},
+	}
+	for i, tt := range tests {
+		if tt.slowDown {
+			l.SlowDown()
+		}
+		clk.Advance(tt.advance)
+		if got := l.Rate(); got != tt.want {
+			t.Errorf("step %d: Rate() = %v, want %v", i, got, tt.want)
+		}
+	}
+}
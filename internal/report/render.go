@@ -0,0 +1,177 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// commonWords are words too common to say what a channel talks about,
+// including the filler words of speech.
+var commonWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		a about above after again against all also am an and any are aren't as at
+		be because been before being below between both but by can can't could
+		couldn't did didn't do does doesn't doing don't down during each even
+		every few for from further get gets getting go goes going gonna got had
+		hadn't has hasn't have haven't having he he'd he'll he's her here here's
+		hers herself him himself his how how's i i'd i'll i'm i've if in into is
+		isn't it it's its itself just know let's like lot make me more most much
+		mustn't my myself need no nor not now of off oh okay ok on once one only
+		or other ought our ours ourselves out over own really right said say says
+		see so some something such than that that's the their theirs them
+		themselves then there there's these they they'd they'll they're they've
+		thing things think this those through to too um uh up us very want wanna
+		was wasn't way we we'd we'll we're we've well were weren't what what's
+		when when's where where's which while who who's whom why why's will with
+		won't would wouldn't yeah yes you you'd you'll you're you've your yours
+		yourself yourselves er ah hmm actually basically literally mean kind sort
+		going also back two three first time`) {
+		commonWords[w] = true
+	}
+}
+
+// commonWord reports whether w is too common to be a topic.
+func commonWord(w string) bool {
+	return commonWords[w] || len([]rune(w)) < 3
+}
+
+// Markdown renders the report as a Markdown document.
+func (r *Report) Markdown() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Channel report: %s\n\n", r.ChannelID)
+	fmt.Fprintf(&b, "Generated %s from %d videos.\n\n", r.Generated.Format("2006-01-02"), r.Videos)
+	b.WriteString("## Overview\n\n")
+	for _, row := range r.Overview() {
+		fmt.Fprintf(&b, "- **%s:** %s\n", row[0], row[1])
+	}
+	if len(r.Months) > 0 {
+		b.WriteString("\n## Uploads by month\n\n| Month | Uploads |\n|-------|---------|\n")
+		for _, m := range r.Months {
+			fmt.Fprintf(&b, "| %s | %d |\n", m.Month, m.Uploads)
+		}
+	}
+	for _, section := range []struct {
+		title string
+		terms []Term
+	}{{"Top words", r.TopWords}, {"Top phrases", r.TopPhrases}} {
+		if len(section.terms) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n| Term | Times said | Videos |\n|------|------------|--------|\n", section.title)
+		for _, t := range section.terms {
+			fmt.Fprintf(&b, "| %s | %d | %d |\n", t.Text, t.Count, t.Videos)
+		}
+	}
+	return b.Bytes()
+}
+
+// HTML renders the report as a standalone web page.
+func (r *Report) HTML() ([]byte, error) {
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, r); err != nil {
+		return nil, fmt.Errorf("error rendering report: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// Overview returns the report's headline figures as label and value pairs.
+func (r *Report) Overview() [][2]string {
+	rows := [][2]string{
+		{"Videos", fmt.Sprint(r.Videos)},
+		{"Captioned", fmt.Sprintf("%d (%.0f%%); %d uncaptioned", r.Captioned, r.CaptionedShare()*100, r.Videos-r.Captioned)},
+		{"Transcripts archived", fmt.Sprint(r.Archived)},
+	}
+	if !r.FirstUpload.IsZero() {
+		rows = append(rows, [2]string{"Uploads", fmt.Sprintf("%s to %s", r.FirstUpload.Format("2006-01-02"), r.LastUpload.Format("2006-01-02"))})
+	}
+	if r.UploadsPerMonth > 0 {
+		rows = append(rows,
+			[2]string{"Upload cadence", fmt.Sprintf("%.1f per month", r.UploadsPerMonth)},
+			[2]string{"Typical gap between uploads", formatDuration(r.MedianGap)})
+	}
+	rows = append(rows, [2]string{"Average duration", formatDuration(r.AverageDuration)})
+	if r.Archived > 0 {
+		rows = append(rows, [2]string{"Average transcript length", fmt.Sprintf("%d words", r.AverageWords)})
+	}
+	if r.ViewsWordsCorrelation != nil {
+		rows = append(rows, [2]string{"Views vs. transcript length", fmt.Sprintf("%+.2f rank correlation (%s)", *r.ViewsWordsCorrelation, strength(*r.ViewsWordsCorrelation))})
+	}
+	return rows
+}
+
+// strength describes a correlation in words.
+func strength(rho float64) string {
+	a := rho
+	if a < 0 {
+		a = -a
+	}
+	switch {
+	case a < 0.1:
+		return "none"
+	case a < 0.3:
+		return "weak"
+	case a < 0.5:
+		return "moderate"
+	default:
+		return "strong"
+	}
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Channel report: {{.ChannelID}}</title>
+<style>
+body { font: 16px/1.5 system-ui, sans-serif; max-width: 50em; margin: 2em auto; padding: 0 1em; color: #222; }
+h1 { font-size: 1.5em; }
+.meta { color: #555; font-size: 0.9em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { text-align: left; padding: 0.25em 1em 0.25em 0; border-bottom: 1px solid #ddd; }
+td.n { text-align: right; font-variant-numeric: tabular-nums; }
+.bar { display: inline-block; height: 0.8em; background: #4a7bd0; }
+</style>
+</head>
+<body>
+<h1>Channel report: {{.ChannelID}}</h1>
+<p class="meta">Generated {{.Generated.Format "2006-01-02"}} from {{.Videos}} videos.</p>
+<h2>Overview</h2>
+<table>
+{{- range .Overview}}
+<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{- end}}
+</table>
+{{- if .Months}}
+<h2>Uploads by month</h2>
+<table>
+{{- range .Months}}
+<tr><th>{{.Month}}</th><td class="n">{{.Uploads}}</td><td><span class="bar" style="width: {{.Uploads}}em"></span></td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .TopWords}}
+<h2>Top words</h2>
+<table>
+<tr><th>Term</th><th>Times said</th><th>Videos</th></tr>
+{{- range .TopWords}}
+<tr><td>{{.Text}}</td><td class="n">{{.Count}}</td><td class="n">{{.Videos}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .TopPhrases}}
+<h2>Top phrases</h2>
+<table>
+<tr><th>Term</th><th>Times said</th><th>Videos</th></tr>
+{{- range .TopPhrases}}
+<tr><td>{{.Text}}</td><td class="n">{{.Count}}</td><td class="n">{{.Videos}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
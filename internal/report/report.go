@@ -0,0 +1,280 @@
+// Package report builds a channel-wide report from the channel's video
+// listing and the transcripts archived for it: upload cadence, video
+// lengths, how views relate to how much is said, the topics talked about
+// most, and how many videos have captions.
+package report
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/transcript"
+)
+
+// Video is one of the channel's videos, with its transcript if archived.
+type Video struct {
+	VideoID     string
+	Title       string
+	PublishedAt time.Time
+	Duration    time.Duration
+	Views       uint64
+	HasCaptions bool
+	// Transcript is the video's transcript text, empty if it isn't
+	// archived.
+	Transcript string
+}
+
+// Report is a channel's report.
+type Report struct {
+	ChannelID string    `json:"channel_id"`
+	Generated time.Time `json:"generated_at"`
+	Videos    int       `json:"videos"`
+	// Captioned counts the videos with captions, and Archived those with
+	// a transcript in the archive.
+	Captioned int `json:"captioned"`
+	Archived  int `json:"archived"`
+
+	FirstUpload time.Time `json:"first_upload,omitzero"`
+	LastUpload  time.Time `json:"last_upload,omitzero"`
+	// UploadsPerMonth is the average over the time between the first and
+	// last upload, and MedianGap the typical time between uploads.
+	UploadsPerMonth float64       `json:"uploads_per_month"`
+	MedianGap       time.Duration `json:"median_gap"`
+	// Months counts uploads in each of the last 12 months up to the last
+	// upload, oldest first.
+	Months []Month `json:"months"`
+
+	AverageDuration time.Duration `json:"average_duration"`
+	AverageWords    int           `json:"average_words"`
+	// ViewsWordsCorrelation is the rank correlation between archived
+	// videos' views and transcript lengths, from -1 to 1, or nil with
+	// fewer than three to compare.
+	ViewsWordsCorrelation *float64 `json:"views_words_correlation"`
+
+	// TopWords and TopPhrases are the words and two- and three-word
+	// phrases said most across the archived transcripts, leaving out
+	// common words.
+	TopWords   []Term `json:"top_words"`
+	TopPhrases []Term `json:"top_phrases"`
+}
+
+// Month is the number of uploads in a month, such as "2025-01".
+type Month struct {
+	Month   string `json:"month"`
+	Uploads int    `json:"uploads"`
+}
+
+// Term is a word or phrase, how often it's said, and in how many videos.
+type Term struct {
+	Text   string `json:"text"`
+	Count  int    `json:"count"`
+	Videos int    `json:"videos"`
+}
+
+// CaptionedShare returns the share of videos with captions, from 0 to 1.
+func (r *Report) CaptionedShare() float64 {
+	if r.Videos == 0 {
+		return 0
+	}
+	return float64(r.Captioned) / float64(r.Videos)
+}
+
+// Build works out a channel's report from its videos, keeping the top
+// words and phrases.
+func Build(channelID string, videos []Video, top int, now time.Time) *Report {
+	r := &Report{ChannelID: channelID, Generated: now.UTC(), Videos: len(videos), Months: []Month{}, TopWords: []Term{}, TopPhrases: []Term{}}
+	if len(videos) == 0 {
+		return r
+	}
+
+	var dates []time.Time
+	var duration time.Duration
+	var views, words []float64
+	for _, v := range videos {
+		if v.HasCaptions {
+			r.Captioned++
+		}
+		if !v.PublishedAt.IsZero() {
+			dates = append(dates, v.PublishedAt)
+		}
+		duration += v.Duration
+		if v.Transcript != "" {
+			r.Archived++
+			views = append(views, float64(v.Views))
+			words = append(words, float64(len(transcript.Tokens(v.Transcript))))
+		}
+	}
+	r.AverageDuration = (duration / time.Duration(len(videos))).Round(time.Second)
+	if len(words) > 0 {
+		var sum float64
+		for _, n := range words {
+			sum += n
+		}
+		r.AverageWords = int(math.Round(sum / float64(len(words))))
+	}
+	if len(words) >= 3 {
+		rho := spearman(views, words)
+		r.ViewsWordsCorrelation = &rho
+	}
+	r.cadence(dates)
+	r.TopWords, r.TopPhrases = topTerms(videos, top)
+	return r
+}
+
+// cadence works out how often the channel uploads.
+func (r *Report) cadence(dates []time.Time) {
+	if len(dates) == 0 {
+		return
+	}
+	slices.SortFunc(dates, time.Time.Compare)
+	r.FirstUpload, r.LastUpload = dates[0].UTC(), dates[len(dates)-1].UTC()
+	if len(dates) > 1 {
+		// Videos published at the same moment, as premieres scheduled
+		// together can be, span no time to average over.
+		if months := r.LastUpload.Sub(r.FirstUpload).Hours() / 24 / (365.25 / 12); months > 0 {
+			r.UploadsPerMonth = float64(len(dates)-1) / months
+		}
+		gaps := make([]time.Duration, len(dates)-1)
+		for i := range gaps {
+			gaps[i] = dates[i+1].Sub(dates[i])
+		}
+		slices.Sort(gaps)
+		r.MedianGap = gaps[len(gaps)/2].Round(time.Hour)
+	}
+
+	last := time.Date(r.LastUpload.Year(), r.LastUpload.Month(), 1, 0, 0, 0, 0, time.UTC)
+	counts := map[string]int{}
+	for _, d := range dates {
+		counts[d.UTC().Format("2006-01")]++
+	}
+	for i := 11; i >= 0; i-- {
+		m := last.AddDate(0, -i, 0).Format("2006-01")
+		r.Months = append(r.Months, Month{Month: m, Uploads: counts[m]})
+	}
+}
+
+// topTerms counts the words and phrases said in the videos' transcripts
+// and returns the top ones. Phrases may not start or end with a common
+// word, and with several transcripts, terms said in only one are left out
+// as likely one-off names.
+func topTerms(videos []Video, top int) (wordTerms, phraseTerms []Term) {
+	counts := map[string]*Term{}
+	transcripts := 0
+	for _, v := range videos {
+		if v.Transcript == "" {
+			continue
+		}
+		transcripts++
+		inVideo := map[string]bool{}
+		add := func(text string) {
+			t, ok := counts[text]
+			if !ok {
+				t = &Term{Text: text}
+				counts[text] = t
+			}
+			t.Count++
+			if !inVideo[text] {
+				inVideo[text] = true
+				t.Videos++
+			}
+		}
+		tokens := transcript.Tokens(v.Transcript)
+		for i, w := range tokens {
+			if !commonWord(w) {
+				add(w)
+			}
+			for n := 2; n <= 3 && i+n <= len(tokens); n++ {
+				if !commonWord(w) && !commonWord(tokens[i+n-1]) {
+					add(strings.Join(tokens[i:i+n], " "))
+				}
+			}
+		}
+	}
+
+	for _, t := range counts {
+		if transcripts > 1 && t.Videos < 2 {
+			continue
+		}
+		if strings.Contains(t.Text, " ") {
+			if t.Count > 1 {
+				phraseTerms = append(phraseTerms, *t)
+			}
+		} else {
+			wordTerms = append(wordTerms, *t)
+		}
+	}
+	byCount := func(a, b Term) int {
+		return cmp.Or(b.Count-a.Count, b.Videos-a.Videos, strings.Compare(a.Text, b.Text))
+	}
+	slices.SortFunc(wordTerms, byCount)
+	slices.SortFunc(phraseTerms, byCount)
+	return clip(wordTerms, top), clip(phraseTerms, top)
+}
+
+func clip(terms []Term, n int) []Term {
+	if terms == nil {
+		return []Term{}
+	}
+	return terms[:min(n, len(terms))]
+}
+
+// spearman returns the Spearman rank correlation of x and y.
+func spearman(x, y []float64) float64 {
+	return pearson(ranks(x), ranks(y))
+}
+
+// ranks returns the rank of each value, averaging tied ranks.
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(values[a], values[b]) })
+	out := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		for k := i; k <= j; k++ {
+			out[order[k]] = float64(i+j)/2 + 1
+		}
+		i = j + 1
+	}
+	return out
+}
+
+// pearson returns the Pearson correlation of x and y, or 0 if either
+// doesn't vary.
+func pearson(x, y []float64) float64 {
+	var mx, my float64
+	for i := range x {
+		mx += x[i]
+		my += y[i]
+	}
+	mx /= float64(len(x))
+	my /= float64(len(y))
+	var sxy, sxx, syy float64
+	for i := range x {
+		sxy += (x[i] - mx) * (y[i] - my)
+		sxx += (x[i] - mx) * (x[i] - mx)
+		syy += (y[i] - my) * (y[i] - my)
+	}
+	if sxx == 0 || syy == 0 {
+		return 0
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+// formatDuration formats a duration as days for long gaps and as a
+// timestamp otherwise.
+func formatDuration(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%.0f days", d.Hours()/24)
+	}
+	return transcript.FormatTimestamp(d)
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestBuild(t *testing.T) {
+	videos := []Video{
+		{VideoID: "a", PublishedAt: day("2025-01-01"), Duration: 10 * time.Minute, Views: 100, HasCaptions: true, Transcript: "Machine learning is fun. Machine learning models."},
+		{VideoID: "b", PublishedAt: day("2025-01-15"), Duration: 20 * time.Minute, Views: 300, HasCaptions: true, Transcript: "Today: machine learning and compilers, more machine learning"},
+		{VideoID: "c", PublishedAt: day("2025-03-01"), Duration: 30 * time.Minute, Views: 200, HasCaptions: true, Transcript: "Compilers compilers, [Music] um machine learning again and again and again"},
+		{VideoID: "d", PublishedAt: day("2025-03-15"), Duration: 0, Views: 5},
+	}
+	now := day("2025-04-01")
+	r := Build("UC1", videos, 3, now)
+
+	if r.Videos != 4 || r.Captioned != 3 || r.Archived != 3 {
+		t.Errorf("counts = %d videos, %d captioned, %d archived", r.Videos, r.Captioned, r.Archived)
+	}
+	if r.CaptionedShare() != 0.75 {
+		t.Errorf("CaptionedShare() = %v, want 0.75", r.CaptionedShare())
+	}
+	if r.AverageDuration != 15*time.Minute {
+		t.Errorf("AverageDuration = %v, want 15m", r.AverageDuration)
+	}
+	if r.AverageWords != 8 {
+		t.Errorf("AverageWords = %d, want 8", r.AverageWords)
+	}
+	if !r.FirstUpload.Equal(day("2025-01-01")) || !r.LastUpload.Equal(day("2025-03-15")) {
+		t.Errorf("uploads = %v to %v", r.FirstUpload, r.LastUpload)
+	}
+	if r.MedianGap != 14*24*time.Hour {
+		t.Errorf("MedianGap = %v, want 14 days", r.MedianGap)
+	}
+	if len(r.Months) != 12 || r.Months[11] != (Month{"2025-03", 2}) || r.Months[10] != (Month{"2025-02", 0}) || r.Months[9] != (Month{"2025-01", 2}) {
+		t.Errorf("Months = %v", r.Months)
+	}
+	// Views rank a < c < b; words rank a < b < c.
+	if r.ViewsWordsCorrelation == nil || math.Abs(*r.ViewsWordsCorrelation-0.5) > 1e-9 {
+		t.Errorf("ViewsWordsCorrelation = %v, want 0.5", r.ViewsWordsCorrelation)
+	}
+	wantWords := []Term{{"learning", 5, 3}, {"machine", 5, 3}, {"compilers", 3, 2}}
+	if !reflect.DeepEqual(r.TopWords, wantWords) {
+		t.Errorf("TopWords = %v, want %v", r.TopWords, wantWords)
+	}
+	if len(r.TopPhrases) == 0 || r.TopPhrases[0] != (Term{"machine learning", 5, 3}) {
+		t.Errorf("TopPhrases = %v", r.TopPhrases)
+	}
+
+	md := string(r.Markdown())
+	for _, want := range []string{"# Channel report: UC1\n", "- **Captioned:** 3 (75%); 1 uncaptioned\n", "| 2025-03 | 2 |\n", "| machine learning | 5 | 3 |\n"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() = %s\nwant it to contain %q", md, want)
+		}
+	}
+	html, err := r.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), "<td>machine learning</td>") {
+		t.Errorf("HTML() = %s", html)
+	}
+}
+
+func TestBuildEmpty(t *testing.T) {
+	r := Build("UC1", nil, 10, day("2025-04-01"))
+	if r.Videos != 0 || r.ViewsWordsCorrelation != nil || len(r.TopWords) != 0 {
+		t.Errorf("Build(nil) = %+v", r)
+	}
+	if md := string(r.Markdown()); !strings.Contains(md, "- **Videos:** 0\n") {
+		t.Errorf("Markdown() = %s", md)
+	}
+}
+
+func TestBuildSameMoment(t *testing.T) {
+	at := day("2025-03-01")
+	r := Build("UC1", []Video{{VideoID: "a", PublishedAt: at}, {VideoID: "b", PublishedAt: at}}, 10, day("2025-04-01"))
+	if r.UploadsPerMonth != 0 || r.MedianGap != 0 {
+		t.Errorf("cadence = %v per month, median gap %v", r.UploadsPerMonth, r.MedianGap)
+	}
+	if _, err := json.Marshal(r); err != nil {
+		t.Errorf("json.Marshal() = %v", err)
+	}
+}
+
+func TestRanks(t *testing.T) {
+	got := ranks([]float64{30, 10, 20, 10})
+	want := []float64{4, 1.5, 3, 1.5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ranks() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+// Package resume keeps the work a run left undone when the daily API quota
+// ran out, so it can be picked up once the quota resets.
+//
+// The state lives in resume.json in ytt's data directory. There is one
+// queue at a time; saving a new one replaces the old.
+package resume
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File is the state file's name inside the data directory.
+const File = "resume.json"
+
+// State is the queue of videos a run didn't get to.
+type State struct {
+	// Job names the interrupted work, such as "sync" or "download".
+	Job string `json:"job"`
+	// Settings holds the configuration the run used, such as its output
+	// directory and format, keyed by flag name.
+	Settings map[string]any `json:"settings"`
+	VideoIDs []string       `json:"video_ids"`
+	SavedAt  time.Time      `json:"saved_at"`
+	// ResetAt is when the quota resets and the queue can be resumed.
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// Save writes s to dir, replacing any saved state.
+func Save(dir string, s *State) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding resume state: %w", err)
+	}
+	tmp := filepath.Join(dir, File+".tmp")
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing resume state: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, File)); err != nil {
+		return fmt.Errorf("error writing resume state: %w", err)
+	}
+	return nil
+}
+
+// Load reads the state saved in dir, or returns nil if there is none.
+func Load(dir string) (*State, error) {
+	data, err := os.ReadFile(filepath.Join(dir, File))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading resume state: %w", err)
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error parsing resume state: %w", err)
+	}
+	return &s, nil
+}
+
+// Clear removes the state saved in dir, if any.
+func Clear(dir string) error {
+	if err := os.Remove(filepath.Join(dir, File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing resume state: %w", err)
+	}
+	return nil
+}
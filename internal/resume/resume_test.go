@@ -0,0 +1,45 @@
+package resume
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSaveLoadClear(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Load(dir)
+	if err != nil || s != nil {
+		t.Fatalf("Load() with nothing saved = %v, %v; want nil, nil", s, err)
+	}
+
+	saved := time.Date(2025, 3, 1, 15, 0, 0, 0, time.UTC)
+	want := &State{
+		Job:      "sync",
+		Settings: map[string]any{"output": "outputs", "format": "srt", "keep-raw": true},
+		VideoIDs: []string{"a", "b"},
+		SavedAt:  saved,
+		ResetAt:  saved.Add(17 * time.Hour),
+	}
+	if err := Save(dir, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	if err := Clear(dir); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := Load(dir); err != nil || s != nil {
+		t.Errorf("Load() after Clear = %v, %v; want nil, nil", s, err)
+	}
+	if err := Clear(dir); err != nil {
+		t.Errorf("Clear() with nothing saved: %v", err)
+	}
+}
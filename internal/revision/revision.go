@@ -0,0 +1,141 @@
+// Package revision keeps every revision of a video's transcript that was
+// downloaded, so earlier captions can be listed and restored after YouTube
+// replaces them.
+//
+// Revisions live in the output directory under
+// .ytt/history/<videoID>/<id><ext>, where the ID is the time the revision
+// was saved, each beside an <id>.revision.json file describing it.
+package revision
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dir is the directory inside an output root holding revisions.
+const Dir = ".ytt/history"
+
+const idLayout = "20060102T150405.000000000Z"
+
+// metaSuffix ends the name of the file describing a revision.
+const metaSuffix = ".revision.json"
+
+// Revision describes one saved revision of a transcript.
+type Revision struct {
+	ID      string    `json:"id"`
+	VideoID string    `json:"video_id"`
+	SavedAt time.Time `json:"saved_at"`
+	// File is the transcript's path relative to the root when it was saved.
+	File string `json:"file"`
+	// Format and Process are how the transcript was converted and
+	// processed, as recorded in the manifest.
+	Format  string `json:"format,omitempty"`
+	Process string `json:"process,omitempty"`
+	Hash    string `json:"hash"`
+	// CaptionUpdatedAt is the caption track's lastUpdated time when the
+	// revision was downloaded.
+	CaptionUpdatedAt time.Time `json:"caption_updated_at,omitzero"`
+	Size             int       `json:"size"`
+}
+
+// Save stores data as a new revision of rev.VideoID's transcript, unless the
+// latest revision has the same hash. It fills in the revision's ID, time,
+// and size, and reports whether a revision was stored.
+func Save(root string, rev Revision, data []byte, now time.Time) (Revision, bool, error) {
+	revs, err := List(root, rev.VideoID)
+	if err != nil {
+		return Revision{}, false, err
+	}
+	if len(revs) > 0 && revs[len(revs)-1].Hash == rev.Hash {
+		return revs[len(revs)-1], false, nil
+	}
+
+	rev.SavedAt = now.UTC()
+	rev.ID = rev.SavedAt.Format(idLayout)
+	rev.Size = len(data)
+	dir := filepath.Join(root, filepath.FromSlash(Dir), rev.VideoID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Revision{}, false, fmt.Errorf("error creating history directory: %w", err)
+	}
+	meta, err := json.MarshalIndent(rev, "", "  ")
+	if err != nil {
+		return Revision{}, false, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, rev.ID+path.Ext(rev.File)), data, 0644); err != nil {
+		return Revision{}, false, fmt.Errorf("error saving revision of %s: %w", rev.VideoID, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, rev.ID+metaSuffix), append(meta, '\n'), 0644); err != nil {
+		return Revision{}, false, fmt.Errorf("error saving revision of %s: %w", rev.VideoID, err)
+	}
+	return rev, true, nil
+}
+
+// List returns the revisions of a video's transcript, oldest first.
+func List(root, videoID string) ([]Revision, error) {
+	dir := filepath.Join(root, filepath.FromSlash(Dir), videoID)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history of %s: %w", videoID, err)
+	}
+
+	var revs []Revision
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), metaSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading history of %s: %w", videoID, err)
+		}
+		var rev Revision
+		if err := json.Unmarshal(data, &rev); err != nil {
+			return nil, fmt.Errorf("error reading history of %s: %s: %w", videoID, e.Name(), err)
+		}
+		revs = append(revs, rev)
+	}
+	sort.Slice(revs, func(i, j int) bool {
+		return revs[i].SavedAt.Before(revs[j].SavedAt)
+	})
+	return revs, nil
+}
+
+// Find returns the revision of a video's transcript with the given ID, or
+// the nth revision, counting from 1 for the oldest, if id is a number.
+func Find(root, videoID, id string) (Revision, error) {
+	revs, err := List(root, videoID)
+	if err != nil {
+		return Revision{}, err
+	}
+	if n, err := strconv.Atoi(id); err == nil {
+		if n < 1 || n > len(revs) {
+			return Revision{}, fmt.Errorf("%s has %d revisions, so there is no revision %d", videoID, len(revs), n)
+		}
+		return revs[n-1], nil
+	}
+	for _, rev := range revs {
+		if rev.ID == id {
+			return rev, nil
+		}
+	}
+	return Revision{}, fmt.Errorf("no revision %s of %s", id, videoID)
+}
+
+// Read returns the content of a revision.
+func Read(root string, rev Revision) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(Dir), rev.VideoID, rev.ID+path.Ext(rev.File)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading revision %s of %s: %w", rev.ID, rev.VideoID, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,74 @@
+package revision
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveListFind(t *testing.T) {
+	root := t.TempDir()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	saves := []struct {
+		hash   string
+		data   string
+		stored bool
+	}{
+		{"h1", "first", true},
+		{"h1", "first", false}, // unchanged
+		{"h2", "second", true},
+		{"h1", "first", true}, // reverted on YouTube
+	}
+	for i, s := range saves {
+		rev := Revision{VideoID: "abc123", File: "abc123-Title.json", Format: "json", Hash: s.hash}
+		got, stored, err := Save(root, rev, []byte(s.data), start.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored != s.stored {
+			t.Errorf("save %d stored = %v, want %v", i, stored, s.stored)
+		}
+		if got.Hash != s.hash {
+			t.Errorf("save %d returned revision with hash %q, want %q", i, got.Hash, s.hash)
+		}
+	}
+
+	revs, err := List(root, "abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashes []string
+	for _, r := range revs {
+		hashes = append(hashes, r.Hash)
+	}
+	if got := strings.Join(hashes, ","); got != "h1,h2,h1" {
+		t.Fatalf("List hashes = %s, want h1,h2,h1", got)
+	}
+	if !revs[1].SavedAt.Equal(start.Add(2*time.Hour)) || revs[1].Size != len("second") || revs[1].Format != "json" {
+		t.Errorf("List()[1] = %+v", revs[1])
+	}
+
+	for _, id := range []string{"2", revs[1].ID} {
+		rev, err := Find(root, "abc123", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := Read(root, rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "second" {
+			t.Errorf("Read(Find(%q)) = %q, want second", id, data)
+		}
+	}
+	for _, id := range []string{"0", "4", "20260101T000000.000000000Z"} {
+		if _, err := Find(root, "abc123", id); err == nil {
+			t.Errorf("Find(%q) succeeded, want an error", id)
+		}
+	}
+
+	if revs, err := List(root, "other"); err != nil || revs != nil {
+		t.Errorf("List of a video without history = %v, %v", revs, err)
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/n2p5/ytt/internal/graphql"
+)
+
+// schema exposes the REST data over GraphQL:
+//
+//	type Query {
+//	  videos: [Video!]!
+//	  video(id: ID!): Video
+//	  search(query: String!, limit: Int): [Match!]!
+//	}
+//	type Video { id title file updatedAt transcript segments: [Segment!]! }
+//	type Segment { start end text }
+//	type Match { video: Video! start text url }
+func (s *server) schema() *graphql.Schema {
+	segment := &graphql.Object{Name: "Segment", Fields: map[string]*graphql.Field{
+		"start": {Resolve: func(p any, _ map[string]any) (any, error) { return p.(Segment).Start, nil }},
+		"end":   {Resolve: func(p any, _ map[string]any) (any, error) { return p.(Segment).End, nil }},
+		"text":  {Resolve: func(p any, _ map[string]any) (any, error) { return p.(Segment).Text, nil }},
+	}}
+
+	video := &graphql.Object{Name: "Video", Fields: map[string]*graphql.Field{
+		"id":        {Resolve: func(p any, _ map[string]any) (any, error) { return p.(Video).ID, nil }},
+		"title":     {Resolve: func(p any, _ map[string]any) (any, error) { return p.(Video).Title, nil }},
+		"file":      {Resolve: func(p any, _ map[string]any) (any, error) { return p.(Video).File, nil }},
+		"updatedAt": {Resolve: func(p any, _ map[string]any) (any, error) { return p.(Video).UpdatedAt, nil }},
+		"transcript": {Resolve: func(p any, _ map[string]any) (any, error) {
+			data, err := s.archive.Transcript(p.(Video).ID)
+			return string(data), err
+		}},
+		"segments": {Type: segment, Resolve: func(p any, _ map[string]any) (any, error) {
+			return s.segments(p.(Video).ID)
+		}},
+	}}
+
+	match := &graphql.Object{Name: "Match", Fields: map[string]*graphql.Field{
+		"video": {Type: video, Resolve: func(p any, _ map[string]any) (any, error) {
+			v, _, err := s.video(p.(Match).VideoID)
+			return v, err
+		}},
+		"start": {Resolve: func(p any, _ map[string]any) (any, error) { return p.(Match).Start, nil }},
+		"text":  {Resolve: func(p any, _ map[string]any) (any, error) { return p.(Match).Text, nil }},
+		"url":   {Resolve: func(p any, _ map[string]any) (any, error) { return p.(Match).URL, nil }},
+	}}
+
+	query := &graphql.Object{Name: "Query", Fields: map[string]*graphql.Field{
+		"videos": {Type: video, Resolve: func(any, map[string]any) (any, error) {
+			return s.videos()
+		}},
+		"video": {Type: video, Resolve: func(_ any, args map[string]any) (any, error) {
+			v, ok, err := s.video(graphql.StringArg(args, "id"))
+			if err != nil || !ok {
+				return nil, err
+			}
+			return v, nil
+		}},
+		"search": {Type: match, Resolve: func(_ any, args map[string]any) (any, error) {
+			q := graphql.StringArg(args, "query")
+			if q == "" {
+				return nil, fmt.Errorf("argument query is required")
+			}
+			return s.search(q, graphql.IntArg(args, "limit", DefaultSearchLimit))
+		}},
+	}}
+
+	return &graphql.Schema{Query: query}
+}
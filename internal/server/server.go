@@ -0,0 +1,237 @@
+// Package server serves a transcript archive over HTTP.
+//
+// REST endpoints:
+//
+//	GET /api/videos                     downloaded videos
+//	GET /api/videos/{id}                one video
+//	GET /api/videos/{id}/transcript     the raw transcript file
+//	GET /api/videos/{id}/segments       the transcript as timed segments
+//	GET /api/search?q=...&limit=...     segments containing q, across videos
+//
+// With Options.GraphQL set, the same data is also available at /graphql.
+// With Options.Metrics set, /metrics serves that handler.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/transcript"
+)
+
+// DefaultSearchLimit caps search results when the request doesn't set a limit.
+const DefaultSearchLimit = 100
+
+// Options configures the server.
+type Options struct {
+	// GraphQL enables the /graphql endpoint.
+	GraphQL bool
+	// Metrics, if set, is served at /metrics.
+	Metrics http.Handler
+}
+
+// Video is the API representation of a downloaded video.
+type Video struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	File      string    `json:"file"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Segment is a timed piece of a transcript. Times are in seconds.
+type Segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Match is a search result.
+type Match struct {
+	VideoID string  `json:"video_id"`
+	Title   string  `json:"title"`
+	Start   float64 `json:"start"`
+	Text    string  `json:"text"`
+	URL     string  `json:"url"`
+}
+
+// New returns a handler serving the archive.
+func New(a *archive.Archive, opts Options) http.Handler {
+	s := &server{archive: a}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/videos", s.handleVideos)
+	mux.HandleFunc("GET /api/videos/{id}", s.handleVideo)
+	mux.HandleFunc("GET /api/videos/{id}/transcript", s.handleTranscript)
+	mux.HandleFunc("GET /api/videos/{id}/segments", s.handleSegments)
+	mux.HandleFunc("GET /api/search", s.handleSearch)
+	if opts.GraphQL {
+		mux.Handle("/graphql", s.schema())
+	}
+	if opts.Metrics != nil {
+		mux.Handle("GET /metrics", opts.Metrics)
+	}
+	return mux
+}
+
+type server struct {
+	archive *archive.Archive
+}
+
+func (s *server) handleVideos(w http.ResponseWriter, r *http.Request) {
+	videos, err := s.videos()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, videos)
+}
+
+func (s *server) handleVideo(w http.ResponseWriter, r *http.Request) {
+	v, ok, err := s.video(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("video %s is not in the archive", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, v)
+}
+
+func (s *server) handleTranscript(w http.ResponseWriter, r *http.Request) {
+	if !s.exists(w, r.PathValue("id")) {
+		return
+	}
+	data, err := s.archive.Transcript(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(data)
+}
+
+func (s *server) handleSegments(w http.ResponseWriter, r *http.Request) {
+	if !s.exists(w, r.PathValue("id")) {
+		return
+	}
+	segments, err := s.segments(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, segments)
+}
+
+func (s *server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing q parameter"))
+		return
+	}
+	limit := DefaultSearchLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", l))
+			return
+		}
+		limit = n
+	}
+
+	matches, err := s.search(q, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, matches)
+}
+
+// exists writes a 404 and returns false if the video isn't in the archive.
+func (s *server) exists(w http.ResponseWriter, videoID string) bool {
+	_, ok, err := s.archive.Video(videoID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("video %s is not in the archive", videoID))
+		return false
+	}
+	return true
+}
+
+func (s *server) videos() ([]Video, error) {
+	entries, err := s.archive.Videos()
+	if err != nil {
+		return nil, err
+	}
+	videos := make([]Video, 0, len(entries))
+	for _, e := range entries {
+		videos = append(videos, newVideo(e))
+	}
+	return videos, nil
+}
+
+func (s *server) video(videoID string) (Video, bool, error) {
+	e, ok, err := s.archive.Video(videoID)
+	if err != nil || !ok {
+		return Video{}, ok, err
+	}
+	return newVideo(e), true, nil
+}
+
+func (s *server) segments(videoID string) ([]Segment, error) {
+	cues, err := s.archive.Cues(videoID)
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]Segment, 0, len(cues))
+	for _, c := range cues {
+		segments = append(segments, newSegment(c))
+	}
+	return segments, nil
+}
+
+func (s *server) search(query string, limit int) ([]Match, error) {
+	found, err := s.archive.Search(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]Match, 0, len(found))
+	for _, m := range found {
+		matches = append(matches, Match{
+			VideoID: m.VideoID,
+			Title:   m.Title,
+			Start:   m.Cue.Start.Seconds(),
+			Text:    m.Cue.Text,
+			URL:     transcript.WatchURL(m.VideoID, m.Cue.Start),
+		})
+	}
+	return matches, nil
+}
+
+func newVideo(e manifest.Entry) Video {
+	return Video{ID: e.VideoID, Title: e.Title, File: e.File, UpdatedAt: e.UpdatedAt}
+}
+
+func newSegment(c transcript.Cue) Segment {
+	return Segment{Start: c.Start.Seconds(), End: c.End.Seconds(), Text: c.Text}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/manifest"
+)
+
+func newTestServer(t *testing.T, opts Options) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	const name = "abc-Talk.srt"
+	content := "1\n00:00:01,500 --> 00:00:03,000\nHello world\n\n2\n00:01:05,000 --> 00:01:07,000\nGoodbye world\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := manifest.OpenWriter(dir, manifest.WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Record(manifest.Entry{VideoID: "abc", Title: "Talk", File: name, Status: manifest.StatusOK})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := archive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(New(a, opts))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestREST(t *testing.T) {
+	srv := newTestServer(t, Options{})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/api/videos/abc/segments", 200, `[{"start":1.5,"end":3,"text":"Hello world"},{"start":65,"end":67,"text":"Goodbye world"}]`},
+		{"/api/search?q=goodbye", 200, `[{"video_id":"abc","title":"Talk","start":65,"text":"Goodbye world","url":"https://youtu.be/abc?t=65"}]`},
+		{"/api/search?q=world&limit=1", 200, `"text":"Hello world"`},
+		{"/api/search", 400, `missing q parameter`},
+		{"/api/search?q=x&limit=0", 400, `invalid limit`},
+		{"/api/videos/abc/transcript", 200, "00:00:01,500 --> 00:00:03,000\nHello world"},
+		{"/api/videos/nope", 404, `not in the archive`},
+		{"/api/videos/nope/segments", 404, `not in the archive`},
+		{"/api/videos", 200, `"id":"abc","title":"Talk","file":"abc-Talk.srt"`},
+		{"/graphql", 404, ""},
+		{"/metrics", 404, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			status, body := get(t, srv.URL+tt.path)
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", status, tt.wantStatus, body)
+			}
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	srv := newTestServer(t, Options{GraphQL: true})
+
+	query := `{"query":"{ video(id: \"abc\") { title segments { start text } } search(query: \"goodbye\") { url video { id } } missing: video(id: \"nope\") { id } }"}`
+	resp, err := http.Post(srv.URL+"/graphql", "application/json", strings.NewReader(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	want := `{"data":{"video":{"title":"Talk","segments":[{"start":1.5,"text":"Hello world"},{"start":65,"text":"Goodbye world"}]},"search":[{"url":"https://youtu.be/abc?t=65","video":{"id":"abc"}}],"missing":null}}` + "\n"
+	if string(body) != want {
+		t.Errorf("body =\n%s\nwant\n%s", body, want)
+	}
+}
@@ -0,0 +1,232 @@
+// Package site renders a transcript archive as a static website: an index of
+// channels, a page listing each channel's videos, a page for each video's
+// transcript, and a prebuilt search index that the index page searches in
+// the browser. The site needs no server; it works opened straight from
+// disk.
+package site
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/transcript"
+)
+
+// unknownChannel groups videos whose channel the manifest doesn't record.
+const unknownChannel = "unknown"
+
+// Summary reports what Build wrote.
+type Summary struct {
+	Channels int
+	Videos   int
+	// Skipped holds an error for each video whose transcript couldn't be
+	// read, which is left out of the site.
+	Skipped []error
+}
+
+// Build writes the site for the archive into dir, creating it if needed.
+// Files from an earlier build are overwritten.
+func Build(a *archive.Archive, dir string) (*Summary, error) {
+	entries, err := a.Videos()
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range []string{"channels", "videos"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("error creating site directory: %w", err)
+		}
+	}
+
+	summary := &Summary{}
+	idx := newIndex()
+	channels := map[string][]video{}
+	for _, e := range entries {
+		cues, err := a.Cues(e.VideoID)
+		if err != nil {
+			summary.Skipped = append(summary.Skipped, fmt.Errorf("error reading transcript for %s: %w", e.VideoID, err))
+			continue
+		}
+		v := newVideo(e, cues)
+		if err := writePage(filepath.Join(dir, "videos", v.ID+".html"), videoTemplate, v); err != nil {
+			return nil, err
+		}
+		idx.add(v)
+		channels[v.Channel] = append(channels[v.Channel], v)
+		summary.Videos++
+	}
+
+	var list []channel
+	for id, videos := range channels {
+		// Newest first; videos without a date last.
+		slices.SortStableFunc(videos, func(a, b video) int {
+			return b.Published.Compare(a.Published)
+		})
+		ch := channel{ID: id, Videos: videos}
+		if err := writePage(filepath.Join(dir, "channels", id+".html"), channelTemplate, ch); err != nil {
+			return nil, err
+		}
+		list = append(list, ch)
+	}
+	slices.SortFunc(list, func(a, b channel) int {
+		return cmp.Or(cmp.Compare(len(b.Videos), len(a.Videos)), cmp.Compare(a.ID, b.ID))
+	})
+	summary.Channels = len(list)
+
+	if err := writePage(filepath.Join(dir, "index.html"), indexTemplate, list); err != nil {
+		return nil, err
+	}
+	if err := idx.write(filepath.Join(dir, "search-index.js")); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+type channel struct {
+	ID     string
+	Videos []video
+}
+
+// Unknown reports whether the channel groups videos of unknown channels.
+func (c channel) Unknown() bool { return c.ID == unknownChannel }
+
+type video struct {
+	ID        string
+	Title     string
+	Channel   string
+	Published time.Time
+	Language  string
+	Cues      []cue
+}
+
+type cue struct {
+	// Second is the second the cue starts in. The first cue starting in
+	// each second is marked Anchor, and gets the fragment ID "t" followed
+	// by the second, which search results link to.
+	Second  int
+	Anchor  bool
+	Time    string
+	URL     string
+	Speaker string
+	Text    string
+}
+
+func newVideo(e manifest.Entry, cues []transcript.Cue) video {
+	v := video{
+		ID:        e.VideoID,
+		Title:     cmp.Or(e.Title, e.VideoID),
+		Channel:   cmp.Or(e.ChannelID, unknownChannel),
+		Published: e.PublishedAt,
+		Language:  e.Language,
+	}
+	seen := map[int]bool{}
+	for _, c := range cues {
+		second := int(c.Start.Seconds())
+		v.Cues = append(v.Cues, cue{
+			Second:  second,
+			Anchor:  !seen[second],
+			Time:    transcript.FormatTimestamp(c.Start),
+			URL:     transcript.WatchURL(e.VideoID, c.Start),
+			Speaker: c.Speaker,
+			Text:    c.Text,
+		})
+		seen[second] = true
+	}
+	return v
+}
+
+// URL links to the video on YouTube.
+func (v video) URL() string { return "https://youtu.be/" + v.ID }
+
+// Date formats the publication date, or returns "" if it is unknown.
+func (v video) Date() string {
+	if v.Published.IsZero() {
+		return ""
+	}
+	return v.Published.UTC().Format(time.DateOnly)
+}
+
+func writePage(path string, tmpl *template.Template, data any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error writing site: %w", err)
+	}
+	if err := tmpl.Execute(f, data); err != nil {
+		f.Close()
+		return fmt.Errorf("error rendering %s: %w", filepath.Base(path), err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing site: %w", err)
+	}
+	return nil
+}
+
+// index is the search index the index page loads. It is written as a script
+// assigning a global rather than as JSON, since browsers don't let pages
+// opened from disk fetch files:
+//
+//	window.yttSearchIndex = {
+//	  "videos": [{"id": "abc123", "title": "..."}],
+//	  "docs": [[0, 65, "text of the cue at 1:05 in video 0"]],
+//	  "terms": {"inflation": [0, 7]}
+//	};
+//
+// Each doc is a cue: its video's position in videos, its start second, and
+// its text. Terms map each lowercased word to the docs containing it.
+type index struct {
+	Videos []indexVideo     `json:"videos"`
+	Docs   [][3]any         `json:"docs"`
+	Terms  map[string][]int `json:"terms"`
+}
+
+type indexVideo struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+func newIndex() *index {
+	return &index{Videos: []indexVideo{}, Docs: [][3]any{}, Terms: map[string][]int{}}
+}
+
+func (idx *index) add(v video) {
+	vi := len(idx.Videos)
+	idx.Videos = append(idx.Videos, indexVideo{ID: v.ID, Title: v.Title})
+	for _, c := range v.Cues {
+		doc := len(idx.Docs)
+		idx.Docs = append(idx.Docs, [3]any{vi, c.Second, c.Text})
+		for _, term := range terms(c.Text) {
+			if postings := idx.Terms[term]; len(postings) == 0 || postings[len(postings)-1] != doc {
+				idx.Terms[term] = append(postings, doc)
+			}
+		}
+	}
+}
+
+func (idx *index) write(path string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("error encoding search index: %w", err)
+	}
+	script := "window.yttSearchIndex = " + string(data) + ";\n"
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		return fmt.Errorf("error writing search index: %w", err)
+	}
+	return nil
+}
+
+// terms splits text into the lowercased words the search index is keyed by.
+// The index page splits queries the same way.
+func terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
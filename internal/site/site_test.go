@@ -0,0 +1,105 @@
+package site
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/archive"
+	"github.com/n2p5/ytt/internal/manifest"
+)
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a": "1\n00:00:01,000 --> 00:00:02,000\nInflation <is> rising\n\n2\n00:01:05,000 --> 00:01:06,000\nrates too\n",
+		"b": "1\n00:00:03,000 --> 00:00:04,000\ninflation again\n",
+	}
+	w, err := manifest.OpenWriter(dir, manifest.WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, content := range files {
+		name := id + ".srt"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		w.Record(manifest.Entry{
+			VideoID:     id,
+			Title:       "Title " + id,
+			ChannelID:   "UC1",
+			PublishedAt: time.Date(2024, 1, len(id), 12, 0, 0, 0, time.UTC),
+			File:        name,
+			Status:      manifest.StatusOK,
+		})
+	}
+	w.Record(manifest.Entry{VideoID: "gone", File: "gone.srt", Status: manifest.StatusOK})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	a, err := archive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "site")
+	summary, err := Build(a, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Videos != 2 || summary.Channels != 1 || len(summary.Skipped) != 1 {
+		t.Errorf("summary = %+v, want 2 videos from 1 channel and the missing file skipped", summary)
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	for name, want := range map[string]string{
+		"index.html":        `<a href="channels/UC1.html">UC1</a>`,
+		"channels/UC1.html": `<a href="../videos/a.html">Title a</a>`,
+		"videos/a.html":     `<li id="t65"><span class="time"><a href="https://youtu.be/a?t=65">1:05</a></span> rates too</li>`,
+	} {
+		if page := read(name); !strings.Contains(page, want) {
+			t.Errorf("%s is missing %q:\n%s", name, want, page)
+		}
+	}
+	if page := read("videos/a.html"); !strings.Contains(page, "Inflation &lt;is&gt; rising") {
+		t.Errorf("cue text is not escaped:\n%s", page)
+	}
+
+	script := read("search-index.js")
+	data, ok := strings.CutPrefix(strings.TrimSpace(script), "window.yttSearchIndex = ")
+	if !ok {
+		t.Fatalf("search-index.js = %q, want a global assignment", script)
+	}
+	var idx index
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(data, ";")), &idx); err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Videos) != 2 || len(idx.Docs) != 3 {
+		t.Fatalf("index has %d videos and %d docs, want 2 and 3", len(idx.Videos), len(idx.Docs))
+	}
+	if got := idx.Terms["inflation"]; len(got) != 2 {
+		t.Errorf("inflation is in docs %v, want one in each video", got)
+	}
+	if got := idx.Terms["rates"]; len(got) != 1 || idx.Docs[got[0]][1] != float64(65) {
+		t.Errorf("rates is in docs %v, want the cue at 65s", got)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := terms("Don't PANIC: 42 cafés!")
+	want := []string{"don", "t", "panic", "42", "cafés"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("terms = %q, want %q", got, want)
+	}
+}
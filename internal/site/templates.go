@@ -0,0 +1,127 @@
+package site
+
+import "html/template"
+
+// The pages share a stylesheet, inlined into each so any page can be opened
+// or copied on its own.
+const layout = `{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<style>
+body { font: 16px/1.5 system-ui, sans-serif; max-width: 50em; margin: 2em auto; padding: 0 1em; color: #222; }
+h1 { font-size: 1.5em; }
+nav, .meta, .count { color: #555; font-size: 0.9em; }
+ol, ul { padding: 0; list-style: none; }
+li { margin: 0.25em 0; }
+.time { display: inline-block; min-width: 4.5em; font-variant-numeric: tabular-nums; color: #555; }
+.time a { color: inherit; }
+.speaker { font-weight: 600; }
+:target { background: #fff3bf; }
+#search { width: 24em; max-width: 90%; font: inherit; padding: 0.25em 0.5em; }
+</style>
+{{end}}`
+
+var indexTemplate = template.Must(template.New("index").Parse(layout + `{{template "head"}}<title>Transcripts</title>
+</head>
+<body>
+<h1>Transcripts</h1>
+<input type="search" id="search" placeholder="Search every transcript" autofocus>
+<p class="count" id="count"></p>
+<ol id="results"></ol>
+<h2>Channels</h2>
+<ul>
+{{- range .}}
+<li><a href="channels/{{.ID}}.html">{{if .Unknown}}Unknown channel{{else}}{{.ID}}{{end}}</a> <span class="count">{{len .Videos}} videos</span></li>
+{{- end}}
+</ul>
+<script src="search-index.js"></script>
+<script>
+const maxResults = 100;
+const index = window.yttSearchIndex;
+// A Map, so that words such as "constructor" aren't looked up on the
+// object's prototype.
+const postings = new Map(Object.entries(index.terms));
+const search = document.getElementById("search");
+const count = document.getElementById("count");
+const results = document.getElementById("results");
+
+// docsFor returns the docs containing term, or any word starting with it
+// if it's the word still being typed.
+function docsFor(term, prefix) {
+  if (!prefix) return new Set(postings.get(term) || []);
+  const docs = new Set();
+  for (const [key, ds] of postings) {
+    if (key.startsWith(term)) for (const d of ds) docs.add(d);
+  }
+  return docs;
+}
+
+function timestamp(s) {
+  const h = Math.floor(s / 3600), m = Math.floor(s / 60) % 60, sec = String(s % 60).padStart(2, "0");
+  return h > 0 ? h + ":" + String(m).padStart(2, "0") + ":" + sec : m + ":" + sec;
+}
+
+search.addEventListener("input", () => {
+  results.replaceChildren();
+  const terms = search.value.toLowerCase().match(/[\p{L}\p{N}]+/gu) || [];
+  if (terms.length === 0) {
+    count.textContent = "";
+    return;
+  }
+  let docs = null;
+  terms.forEach((term, i) => {
+    const found = docsFor(term, i === terms.length - 1);
+    docs = docs === null ? found : new Set([...docs].filter(d => found.has(d)));
+  });
+  const matches = [...docs].sort((a, b) => a - b);
+  count.textContent = matches.length === 1 ? "1 match" : matches.length + " matches";
+  if (matches.length > maxResults) count.textContent += ", showing the first " + maxResults;
+  for (const d of matches.slice(0, maxResults)) {
+    const [v, start, text] = index.docs[d];
+    const video = index.videos[v];
+    const li = document.createElement("li");
+    const link = document.createElement("a");
+    link.href = "videos/" + video.id + ".html#t" + start;
+    link.textContent = video.title + " " + timestamp(start);
+    li.append(link, document.createElement("br"), text);
+    results.append(li);
+  }
+});
+</script>
+</body>
+</html>
+`))
+
+var channelTemplate = template.Must(template.New("channel").Parse(layout + `{{template "head"}}<title>{{if .Unknown}}Unknown channel{{else}}{{.ID}}{{end}}</title>
+</head>
+<body>
+<nav><a href="../index.html">All channels</a></nav>
+<h1>{{if .Unknown}}Unknown channel{{else}}{{.ID}}{{end}}</h1>
+{{- if not .Unknown}}
+<p class="meta"><a href="https://www.youtube.com/channel/{{.ID}}">On YouTube</a></p>
+{{- end}}
+<ul>
+{{- range .Videos}}
+<li>{{with .Date}}<span class="time">{{.}}</span> {{end}}<a href="../videos/{{.ID}}.html">{{.Title}}</a></li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
+var videoTemplate = template.Must(template.New("video").Parse(layout + `{{template "head"}}<title>{{.Title}}</title>
+</head>
+<body>
+<nav><a href="../index.html">All channels</a> / <a href="../channels/{{.Channel}}.html">{{.Channel}}</a></nav>
+<h1>{{.Title}}</h1>
+<p class="meta">{{with .Date}}{{.}} · {{end}}{{with .Language}}{{.}} · {{end}}<a href="{{.URL}}">{{.URL}}</a></p>
+<ol>
+{{- range .Cues}}
+<li{{if .Anchor}} id="t{{.Second}}"{{end}}><span class="time"><a href="{{.URL}}">{{.Time}}</a></span> {{with .Speaker}}<span class="speaker">{{.}}:</span> {{end}}{{.Text}}</li>
+{{- end}}
+</ol>
+</body>
+</html>
+`))
@@ -0,0 +1,111 @@
+// Package snapshot stores point-in-time copies of a channel's video list and
+// compares them to find videos that were added, removed, or retitled.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/n2p5/ytt/internal/youtube"
+)
+
+const nameLayout = "20060102T150405Z"
+
+// Snapshot is a channel's video list at a point in time.
+type Snapshot struct {
+	ChannelID string              `json:"channel_id"`
+	TakenAt   time.Time           `json:"taken_at"`
+	Videos    []youtube.VideoInfo `json:"videos"`
+}
+
+// Save writes s under dir/<channelID>/ and returns the file's path.
+func Save(dir string, s *Snapshot) (string, error) {
+	channelDir := filepath.Join(dir, s.ChannelID)
+	if err := os.MkdirAll(channelDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding snapshot: %w", err)
+	}
+	path := filepath.Join(channelDir, s.TakenAt.UTC().Format(nameLayout)+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("error writing snapshot: %w", err)
+	}
+	return path, nil
+}
+
+// List returns the paths of a channel's snapshots in dir, oldest first.
+func List(dir, channelID string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, channelID, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Load reads a snapshot file.
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot: %w", err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error parsing snapshot %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Retitle is a video whose title changed between snapshots.
+type Retitle struct {
+	VideoID  string `json:"video_id"`
+	OldTitle string `json:"old_title"`
+	NewTitle string `json:"new_title"`
+}
+
+// Diff is the difference between two snapshots of the same channel.
+type Diff struct {
+	Added    []youtube.VideoInfo `json:"added"`
+	Removed  []youtube.VideoInfo `json:"removed"`
+	Retitled []Retitle           `json:"retitled"`
+}
+
+// Empty reports whether the snapshots were identical in the compared fields.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Retitled) == 0
+}
+
+// Compare returns what changed going from old to cur. Removed videos are
+// ones that were deleted or made private since old was taken.
+func Compare(old, cur *Snapshot) Diff {
+	oldByID := make(map[string]youtube.VideoInfo, len(old.Videos))
+	for _, v := range old.Videos {
+		oldByID[v.VideoID] = v
+	}
+	curIDs := make(map[string]bool, len(cur.Videos))
+
+	var d Diff
+	for _, v := range cur.Videos {
+		curIDs[v.VideoID] = true
+		prev, ok := oldByID[v.VideoID]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, v)
+		case prev.Title != v.Title:
+			d.Retitled = append(d.Retitled, Retitle{VideoID: v.VideoID, OldTitle: prev.Title, NewTitle: v.Title})
+		}
+	}
+	for _, v := range old.Videos {
+		if !curIDs[v.VideoID] {
+			d.Removed = append(d.Removed, v)
+		}
+	}
+	return d
+}
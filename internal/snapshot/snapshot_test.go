@@ -0,0 +1,62 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/youtube"
+)
+
+func TestCompare(t *testing.T) {
+	old := &Snapshot{Videos: []youtube.VideoInfo{
+		{VideoID: "a", Title: "Keep"},
+		{VideoID: "b", Title: "Old title"},
+		{VideoID: "c", Title: "Gone"},
+	}}
+	cur := &Snapshot{Videos: []youtube.VideoInfo{
+		{VideoID: "d", Title: "Brand new"},
+		{VideoID: "a", Title: "Keep"},
+		{VideoID: "b", Title: "New title"},
+	}}
+
+	want := Diff{
+		Added:    []youtube.VideoInfo{{VideoID: "d", Title: "Brand new"}},
+		Removed:  []youtube.VideoInfo{{VideoID: "c", Title: "Gone"}},
+		Retitled: []Retitle{{VideoID: "b", OldTitle: "Old title", NewTitle: "New title"}},
+	}
+	if got := Compare(old, cur); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() = %+v, want %+v", got, want)
+	}
+	if !Compare(cur, cur).Empty() {
+		t.Error("Compare() of identical snapshots is not empty")
+	}
+}
+
+func TestSaveListLoad(t *testing.T) {
+	dir := t.TempDir()
+	first := &Snapshot{ChannelID: "UC1", TakenAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	second := &Snapshot{ChannelID: "UC1", TakenAt: time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC),
+		Videos: []youtube.VideoInfo{{VideoID: "a", Title: "A"}}}
+
+	for _, s := range []*Snapshot{second, first} {
+		if _, err := Save(dir, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := List(dir, "UC1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("List() returned %d paths, want 2", len(paths))
+	}
+	got, err := Load(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, second) {
+		t.Errorf("Load() = %+v, want %+v", got, second)
+	}
+}
@@ -0,0 +1,188 @@
+// Package stats records view, like, and comment counts at each sync so they
+// can be charted over time without the YouTube Analytics API.
+//
+// Samples are appended to one JSON Lines file per channel,
+// <dir>/<channelID>.jsonl.
+package stats
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Sample is one video's counts at a point in time.
+type Sample struct {
+	Time         time.Time `json:"time"`
+	VideoID      string    `json:"video_id"`
+	ViewCount    uint64    `json:"view_count"`
+	LikeCount    uint64    `json:"like_count"`
+	CommentCount uint64    `json:"comment_count"`
+}
+
+// Append adds samples to the channel's file in dir.
+func Append(dir, channelID string, samples []Sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating stats directory: %w", err)
+	}
+	f, err := os.OpenFile(channelPath(dir, channelID), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening stats for %s: %w", channelID, err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, s := range samples {
+		if err := enc.Encode(s); err != nil {
+			return fmt.Errorf("error writing stats: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("error writing stats: %w", err)
+	}
+	return nil
+}
+
+// HasChannel reports whether samples have been recorded for channelID.
+func HasChannel(dir, channelID string) bool {
+	_, err := os.Stat(channelPath(dir, channelID))
+	return err == nil
+}
+
+// LoadChannel returns every sample recorded for a channel, oldest first.
+func LoadChannel(dir, channelID string) ([]Sample, error) {
+	samples, err := loadFile(channelPath(dir, channelID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return samples, err
+}
+
+// LoadVideo returns every sample recorded for a video across all channels,
+// oldest first.
+func LoadVideo(dir, videoID string) ([]Sample, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Sample
+	for _, path := range paths {
+		samples, err := loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range samples {
+			if s.VideoID == videoID {
+				out = append(out, s)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
+
+// Total is a channel's summed counts at one sample time.
+type Total struct {
+	Time         time.Time `json:"time"`
+	Videos       int       `json:"videos"`
+	ViewCount    uint64    `json:"view_count"`
+	LikeCount    uint64    `json:"like_count"`
+	CommentCount uint64    `json:"comment_count"`
+}
+
+// Totals sums samples taken at the same time, oldest first.
+func Totals(samples []Sample) []Total {
+	byTime := map[time.Time]*Total{}
+	var times []time.Time
+	for _, s := range samples {
+		t, ok := byTime[s.Time]
+		if !ok {
+			t = &Total{Time: s.Time}
+			byTime[s.Time] = t
+			times = append(times, s.Time)
+		}
+		t.Videos++
+		t.ViewCount += s.ViewCount
+		t.LikeCount += s.LikeCount
+		t.CommentCount += s.CommentCount
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	totals := make([]Total, len(times))
+	for i, t := range times {
+		totals[i] = *byTime[t]
+	}
+	return totals
+}
+
+// WriteVideoCSV writes samples as CSV with a header row.
+func WriteVideoCSV(w io.Writer, samples []Sample) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "views", "likes", "comments"})
+	for _, s := range samples {
+		cw.Write([]string{
+			s.Time.UTC().Format(time.RFC3339),
+			strconv.FormatUint(s.ViewCount, 10),
+			strconv.FormatUint(s.LikeCount, 10),
+			strconv.FormatUint(s.CommentCount, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTotalsCSV writes channel totals as CSV with a header row.
+func WriteTotalsCSV(w io.Writer, totals []Total) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "videos", "views", "likes", "comments"})
+	for _, t := range totals {
+		cw.Write([]string{
+			t.Time.UTC().Format(time.RFC3339),
+			strconv.Itoa(t.Videos),
+			strconv.FormatUint(t.ViewCount, 10),
+			strconv.FormatUint(t.LikeCount, 10),
+			strconv.FormatUint(t.CommentCount, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func loadFile(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []Sample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s Sample
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		samples = append(samples, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return samples, nil
+}
+
+func channelPath(dir, channelID string) string {
+	return filepath.Join(dir, channelID+".jsonl")
+}
@@ -0,0 +1,52 @@
+package stats
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	t1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(24 * time.Hour)
+
+	if err := Append(dir, "UC1", []Sample{
+		{Time: t1, VideoID: "a", ViewCount: 10, LikeCount: 1},
+		{Time: t1, VideoID: "b", ViewCount: 5},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(dir, "UC1", []Sample{
+		{Time: t2, VideoID: "a", ViewCount: 25, LikeCount: 3},
+		{Time: t2, VideoID: "b", ViewCount: 7},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	video, err := LoadVideo(dir, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteVideoCSV(&buf, video); err != nil {
+		t.Fatal(err)
+	}
+	want := "time,views,likes,comments\n2025-01-01T00:00:00Z,10,1,0\n2025-01-02T00:00:00Z,25,3,0\n"
+	if buf.String() != want {
+		t.Errorf("video CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	channel, err := LoadChannel(dir, "UC1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := WriteTotalsCSV(&buf, Totals(channel)); err != nil {
+		t.Fatal(err)
+	}
+	want = "time,videos,views,likes,comments\n2025-01-01T00:00:00Z,2,15,1,0\n2025-01-02T00:00:00Z,2,32,3,0\n"
+	if buf.String() != want {
+		t.Errorf("channel CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,444 @@
+// Package telemetry exports traces and metrics to an OpenTelemetry
+// collector over OTLP/HTTP, encoded as JSON.
+//
+// It covers only what ytt reports, spans with attributes and monotonic
+// counters, so the CLI can be observed without an OpenTelemetry SDK. All
+// methods are safe on a nil *Exporter and nil *Span, which do nothing, so
+// callers needn't check whether telemetry is enabled.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBuffered is how many ended spans are held before they are sent.
+const maxBuffered = 512
+
+// exportTimeout bounds each export, so a slow or unreachable collector
+// can't hold up the process for long.
+const exportTimeout = 10 * time.Second
+
+// Config configures an Exporter.
+type Config struct {
+	// Endpoint is the collector's base URL, such as
+	// http://localhost:4318; traces are sent to /v1/traces under it and
+	// metrics to /v1/metrics.
+	Endpoint string
+	// Headers are sent with every export, such as for authentication.
+	Headers map[string]string
+	// ServiceName and ServiceVersion describe the process in the
+	// exported resource.
+	ServiceName    string
+	ServiceVersion string
+	// Client sends the exports. Defaults to a client that gives up after
+	// 10 seconds.
+	Client *http.Client
+}
+
+// ConfigFromEnv returns the configuration given by the standard
+// OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS, and
+// OTEL_SERVICE_NAME environment variables.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+	}
+	headers, err := ParseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+	}
+	cfg.Headers = headers
+	return cfg, nil
+}
+
+// ParseHeaders parses headers in the OTEL_EXPORTER_OTLP_HEADERS form: a
+// comma-separated list of key=value pairs with URL-encoded values.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for pair := range strings.SplitSeq(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("header %q is not key=value", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("header %q: %w", key, err)
+		}
+		headers[key] = value
+	}
+	return headers, nil
+}
+
+// Attr is an attribute of a span or counter. Values are strings, ints,
+// int64s, float64s, or bools.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key, value string) Attr { return Attr{key, value} }
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attr { return Attr{key, int64(value)} }
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attr { return Attr{key, value} }
+
+// Exporter collects spans and counters and sends them to a collector.
+type Exporter struct {
+	cfg     Config
+	started time.Time
+
+	mu       sync.Mutex
+	spans    []*Span
+	counters map[string]*counter
+	// err is why an export of a full buffer failed, kept for Flush to
+	// report.
+	err error
+	// sending tracks the exports of full buffers, which run in the
+	// background so a span's End doesn't wait on the collector.
+	sending sync.WaitGroup
+}
+
+type counter struct {
+	name, unit string
+	attrs      []Attr
+	value      int64
+}
+
+// New returns an Exporter sending to cfg.Endpoint.
+func New(cfg Config) (*Exporter, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: want an http:// or https:// URL", cfg.Endpoint)
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "ytt"
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: exportTimeout}
+	}
+	return &Exporter{cfg: cfg, started: time.Now(), counters: map[string]*counter{}}, nil
+}
+
+// Span is a timed operation. Spans started with the same root share a
+// trace.
+type Span struct {
+	exp      *Exporter
+	traceID  string
+	spanID   string
+	parentID string
+	name     string
+	client   bool
+	start    time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs []Attr
+	err   error
+}
+
+// Start starts a span named name, as a child of parent, or at the root of
+// a new trace if parent is nil.
+func (e *Exporter) Start(parent *Span, name string, attrs ...Attr) *Span {
+	return e.newSpan(parent, name, false, attrs)
+}
+
+// StartClient is Start for a span covering a request to another service.
+func (e *Exporter) StartClient(parent *Span, name string, attrs ...Attr) *Span {
+	return e.newSpan(parent, name, true, attrs)
+}
+
+func (e *Exporter) newSpan(parent *Span, name string, client bool, attrs []Attr) *Span {
+	if e == nil {
+		return nil
+	}
+	s := &Span{exp: e, spanID: randomID(8), name: name, client: client, start: time.Now(), attrs: attrs}
+	if parent != nil {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		s.traceID = randomID(16)
+	}
+	return s
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End ends the span, marking it failed if err isn't nil. Only the first
+// call counts.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end, s.err = time.Now(), err
+	s.mu.Unlock()
+
+	e := s.exp
+	e.mu.Lock()
+	e.spans = append(e.spans, s)
+	var spans []*Span
+	if len(e.spans) >= maxBuffered {
+		spans, e.spans = e.spans, nil
+	}
+	e.mu.Unlock()
+	if spans == nil {
+		return
+	}
+	// End is called on the API request path, so the full buffer is sent
+	// from another goroutine.
+	e.sending.Add(1)
+	go func() {
+		defer e.sending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := e.exportSpans(ctx, spans); err != nil {
+			e.mu.Lock()
+			e.err = errors.Join(e.err, err)
+			e.mu.Unlock()
+		}
+	}()
+}
+
+// Count adds n to the counter name with the given attributes. unit is the
+// counter's unit in UCUM notation, such as "{call}".
+func (e *Exporter) Count(name, unit string, n int64, attrs ...Attr) {
+	if e == nil {
+		return
+	}
+	key := name + "\x00" + attrKey(attrs)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c, ok := e.counters[key]
+	if !ok {
+		c = &counter{name: name, unit: unit, attrs: attrs}
+		e.counters[key] = c
+	}
+	c.value += n
+}
+
+// Flush waits for exports in the background to finish, then sends the
+// spans ended since the last export and the counters' current totals.
+func (e *Exporter) Flush(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+	e.sending.Wait()
+	e.mu.Lock()
+	earlier := e.err
+	e.err = nil
+	e.mu.Unlock()
+	return errors.Join(earlier, e.sendSpans(ctx), e.sendMetrics(ctx))
+}
+
+func (e *Exporter) sendSpans(ctx context.Context) error {
+	e.mu.Lock()
+	spans := e.spans
+	e.spans = nil
+	e.mu.Unlock()
+	return e.exportSpans(ctx, spans)
+}
+
+func (e *Exporter) exportSpans(ctx context.Context, spans []*Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	out := make([]map[string]any, len(spans))
+	for i, s := range spans {
+		s.mu.Lock()
+		span := map[string]any{
+			"traceId":           s.traceID,
+			"spanId":            s.spanID,
+			"name":              s.name,
+			"kind":              spanKind(s.client),
+			"startTimeUnixNano": unixNano(s.start),
+			"endTimeUnixNano":   unixNano(s.end),
+			"attributes":        encodeAttrs(s.attrs),
+		}
+		if s.parentID != "" {
+			span["parentSpanId"] = s.parentID
+		}
+		if s.err != nil {
+			span["status"] = map[string]any{"code": 2, "message": s.err.Error()}
+		}
+		s.mu.Unlock()
+		out[i] = span
+	}
+	return e.post(ctx, "/v1/traces", map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource":   e.resource(),
+			"scopeSpans": []any{map[string]any{"scope": e.scope(), "spans": out}},
+		}},
+	})
+}
+
+func (e *Exporter) sendMetrics(ctx context.Context) error {
+	e.mu.Lock()
+	counters := make([]counter, 0, len(e.counters))
+	for _, c := range e.counters {
+		counters = append(counters, *c)
+	}
+	e.mu.Unlock()
+	if len(counters) == 0 {
+		return nil
+	}
+	sort.Slice(counters, func(i, j int) bool { return counters[i].name < counters[j].name })
+
+	now := time.Now()
+	var metrics []any
+	byName := map[string]map[string]any{}
+	for _, c := range counters {
+		m, ok := byName[c.name]
+		if !ok {
+			m = map[string]any{
+				"name": c.name,
+				"unit": c.unit,
+				"sum": map[string]any{
+					// Cumulative, so each export carries the totals so far.
+					"aggregationTemporality": 2,
+					"isMonotonic":            true,
+					"dataPoints":             []any{},
+				},
+			}
+			byName[c.name] = m
+			metrics = append(metrics, m)
+		}
+		sum := m["sum"].(map[string]any)
+		sum["dataPoints"] = append(sum["dataPoints"].([]any), map[string]any{
+			"attributes":        encodeAttrs(c.attrs),
+			"startTimeUnixNano": unixNano(e.started),
+			"timeUnixNano":      unixNano(now),
+			"asInt":             strconv.FormatInt(c.value, 10),
+		})
+	}
+	return e.post(ctx, "/v1/metrics", map[string]any{
+		"resourceMetrics": []any{map[string]any{
+			"resource":     e.resource(),
+			"scopeMetrics": []any{map[string]any{"scope": e.scope(), "metrics": metrics}},
+		}},
+	})
+}
+
+func (e *Exporter) post(ctx context.Context, path string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.Endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error exporting telemetry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("error exporting telemetry to %s: %s: %s", req.URL, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (e *Exporter) resource() map[string]any {
+	attrs := []Attr{String("service.name", e.cfg.ServiceName)}
+	if e.cfg.ServiceVersion != "" {
+		attrs = append(attrs, String("service.version", e.cfg.ServiceVersion))
+	}
+	return map[string]any{"attributes": encodeAttrs(attrs)}
+}
+
+func (e *Exporter) scope() map[string]any {
+	return map[string]any{"name": "github.com/n2p5/ytt", "version": e.cfg.ServiceVersion}
+}
+
+// spanKind returns the OTLP span kind: client for requests to other
+// services, internal otherwise.
+func spanKind(client bool) int {
+	if client {
+		return 3
+	}
+	return 1
+}
+
+func encodeAttrs(attrs []Attr) []any {
+	out := make([]any, 0, len(attrs))
+	for _, a := range attrs {
+		var v map[string]any
+		switch x := a.Value.(type) {
+		case string:
+			v = map[string]any{"stringValue": x}
+		case int:
+			v = map[string]any{"intValue": strconv.Itoa(x)}
+		case int64:
+			v = map[string]any{"intValue": strconv.FormatInt(x, 10)}
+		case float64:
+			v = map[string]any{"doubleValue": x}
+		case bool:
+			v = map[string]any{"boolValue": x}
+		default:
+			v = map[string]any{"stringValue": fmt.Sprint(x)}
+		}
+		out = append(out, map[string]any{"key": a.Key, "value": v})
+	}
+	return out
+}
+
+// attrKey identifies a set of attributes, for telling counters apart.
+func attrKey(attrs []Attr) string {
+	parts := make([]string, len(attrs))
+	for i, a := range attrs {
+		parts[i] = fmt.Sprintf("%s=%v", a.Key, a.Value)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\x00")
+}
+
+// unixNano formats t as OTLP JSON encodes 64-bit times, a decimal string.
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
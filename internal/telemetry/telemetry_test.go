@@ -0,0 +1,184 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string]map[string]any{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("%s: Authorization = %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("%s: %v", r.URL.Path, err)
+		}
+		mu.Lock()
+		bodies[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	e, err := New(Config{Endpoint: srv.URL + "/", Headers: map[string]string{"Authorization": "Bearer secret"}, ServiceVersion: "1.2.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := e.Start(nil, "ytt sync")
+	call := e.StartClient(root, "youtube videos.list", String("ytt.api.method", "videos.list"), Int("ytt.quota.cost", 1))
+	call.End(errors.New("boom"))
+	root.End(nil)
+	e.Count("ytt.api.calls", "{call}", 1, String("ytt.api.method", "videos.list"))
+	e.Count("ytt.api.calls", "{call}", 2, String("ytt.api.method", "videos.list"))
+	e.Count("ytt.api.calls", "{call}", 1, String("ytt.api.method", "captions.list"))
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := dig(bodies["/v1/traces"], "resourceSpans", 0, "scopeSpans", 0, "spans").([]any)
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	child, parent := spans[0].(map[string]any), spans[1].(map[string]any)
+	if child["traceId"] != parent["traceId"] || child["parentSpanId"] != parent["spanId"] {
+		t.Errorf("child span %v isn't in parent %v", child, parent)
+	}
+	if child["kind"] != 3.0 || parent["kind"] != 1.0 {
+		t.Errorf("kinds = %v, %v, want client and internal", child["kind"], parent["kind"])
+	}
+	if got := dig(child, "status", "message"); got != "boom" {
+		t.Errorf("status message = %v, want boom", got)
+	}
+	wantAttrs := []any{
+		map[string]any{"key": "ytt.api.method", "value": map[string]any{"stringValue": "videos.list"}},
+		map[string]any{"key": "ytt.quota.cost", "value": map[string]any{"intValue": "1"}},
+	}
+	if !reflect.DeepEqual(child["attributes"], wantAttrs) {
+		t.Errorf("attributes = %v, want %v", child["attributes"], wantAttrs)
+	}
+	if got := dig(bodies["/v1/traces"], "resourceSpans", 0, "resource", "attributes", 1, "value", "stringValue"); got != "1.2.3" {
+		t.Errorf("service.version = %v, want 1.2.3", got)
+	}
+
+	points := dig(bodies["/v1/metrics"], "resourceMetrics", 0, "scopeMetrics", 0, "metrics", 0, "sum", "dataPoints").([]any)
+	totals := map[any]any{}
+	for _, p := range points {
+		totals[dig(p, "attributes", 0, "value", "stringValue")] = dig(p, "asInt")
+	}
+	if want := map[any]any{"videos.list": "3", "captions.list": "1"}; !reflect.DeepEqual(totals, want) {
+		t.Errorf("counter totals = %v, want %v", totals, want)
+	}
+
+	// Spans are sent once; counters are cumulative.
+	delete(bodies, "/v1/traces")
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bodies["/v1/traces"]; ok {
+		t.Error("spans were sent again")
+	}
+	if _, ok := bodies["/v1/metrics"]; !ok {
+		t.Error("counters weren't sent again")
+	}
+}
+
+func TestNilExporter(t *testing.T) {
+	var e *Exporter
+	span := e.Start(nil, "x")
+	span.SetAttributes(String("k", "v"))
+	span.End(nil)
+	e.Count("c", "1", 1)
+	if err := e.Flush(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEndDoesNotWaitForCollector(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	e, err := New(Config{Endpoint: srv.URL, Client: &http.Client{Timeout: 100 * time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ended := make(chan struct{})
+	go func() {
+		for range maxBuffered {
+			e.Start(nil, "call").End(nil)
+		}
+		close(ended)
+	}()
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the full buffer wasn't sent")
+	}
+	select {
+	case <-ended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("End() waited for the collector")
+	}
+	if err := e.Flush(context.Background()); err == nil {
+		t.Error("Flush() = nil, want the timed-out export's error")
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"", map[string]string{}, false},
+		{"api-key=abc, x-team = data%20eng", map[string]string{"api-key": "abc", "x-team": "data eng"}, false},
+		{"novalue", nil, true},
+		{"=abc", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHeaders(tt.in)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("ParseHeaders(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsBadEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"", "localhost:4318", "grpc://collector:4317"} {
+		if _, err := New(Config{Endpoint: endpoint}); err == nil {
+			t.Errorf("New(%q) succeeded, want an error", endpoint)
+		}
+	}
+}
+
+// dig follows keys and indexes into decoded JSON.
+func dig(v any, path ...any) any {
+	for _, p := range path {
+		switch k := p.(type) {
+		case string:
+			m, _ := v.(map[string]any)
+			v = m[k]
+		case int:
+			a, _ := v.([]any)
+			if k >= len(a) {
+				return nil
+			}
+			v = a[k]
+		}
+	}
+	return v
+}
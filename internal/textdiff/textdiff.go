@@ -0,0 +1,259 @@
+// Package textdiff compares and merges text line by line.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is the kind of an Edit.
+type Op int
+
+// Edit operations.
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Edit is one line of a diff: a line both sides share, or one only the old
+// (Delete) or new (Insert) side has.
+type Edit struct {
+	Op   Op
+	Line string
+}
+
+// maxEditDistance bounds the work Lines does. Beyond it the inputs are
+// treated as entirely different, which is accurate enough for text that
+// diverged that far. The saved frontiers grow with the square of the edit
+// distance, not with the input length, so this also caps memory at a few
+// tens of megabytes however long the inputs are.
+const maxEditDistance = 2000
+
+// Lines returns a shortest edit script turning a into b, using Myers'
+// algorithm.
+func Lines(a, b []string) []Edit {
+	// Common prefixes and suffixes are cheap to strip and common in
+	// transcripts, where edits tend to be local.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var edits []Edit
+	for _, l := range a[:pre] {
+		edits = append(edits, Edit{Equal, l})
+	}
+	edits = append(edits, myers(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		edits = append(edits, Edit{Equal, l})
+	}
+	return edits
+}
+
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	// v[k+max] is the furthest x reached on diagonal k. Round d only reads
+	// diagonals -d..d, so trace[d] keeps just that window of v as it stood
+	// before the round, indexed by k+d, to walk the path back.
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	found := false
+	for d := 0; d <= max && d <= maxEditDistance && !found; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[max-d:max+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+max] < v[k+1+max]) {
+				x = v[k+1+max]
+			} else {
+				x = v[k-1+max] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+max] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		edits := make([]Edit, 0, n+m)
+		for _, l := range a {
+			edits = append(edits, Edit{Delete, l})
+		}
+		for _, l := range b {
+			edits = append(edits, Edit{Insert, l})
+		}
+		return edits
+	}
+
+	// Walk back from (n, m) through the saved frontiers.
+	var rev []Edit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rev = append(rev, Edit{Equal, a[x]})
+		}
+		if x == prevX {
+			y--
+			rev = append(rev, Edit{Insert, b[y]})
+		} else {
+			x--
+			rev = append(rev, Edit{Delete, a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		rev = append(rev, Edit{Equal, a[x]})
+	}
+
+	edits := make([]Edit, len(rev))
+	for i, e := range rev {
+		edits[len(rev)-1-i] = e
+	}
+	return edits
+}
+
+// SplitLines splits text into lines without their line endings.
+func SplitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Changed reports how many lines were removed and added.
+func Changed(edits []Edit) (removed, added int) {
+	for _, e := range edits {
+		switch e.Op {
+		case Delete:
+			removed++
+		case Insert:
+			added++
+		}
+	}
+	return removed, added
+}
+
+// Merge combines two versions of a text line by line. Lines both share are
+// kept once; each run of differing lines becomes a conflict block marked
+// like Git's, with ours first. It returns the merged lines and the number
+// of conflict blocks.
+func Merge(ours, theirs []string, oursLabel, theirsLabel string) ([]string, int) {
+	var out, del, ins []string
+	conflicts := 0
+	flush := func() {
+		if len(del) == 0 && len(ins) == 0 {
+			return
+		}
+		conflicts++
+		out = append(out, "<<<<<<< "+oursLabel)
+		out = append(out, del...)
+		out = append(out, "=======")
+		out = append(out, ins...)
+		out = append(out, ">>>>>>> "+theirsLabel)
+		del, ins = nil, nil
+	}
+	for _, e := range Lines(ours, theirs) {
+		switch e.Op {
+		case Equal:
+			flush()
+			out = append(out, e.Line)
+		case Delete:
+			del = append(del, e.Line)
+		case Insert:
+			ins = append(ins, e.Line)
+		}
+	}
+	flush()
+	return out, conflicts
+}
+
+// Unified formats edits as a unified diff body with context lines around
+// each change, without file headers.
+func Unified(edits []Edit, context int) string {
+	var b strings.Builder
+	i := 0
+	for i < len(edits) {
+		// Find the next change.
+		for i < len(edits) && edits[i].Op == Equal {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+		start := max(i-context, 0)
+
+		// Extend the hunk while changes are within 2*context of each other.
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].Op != Equal {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		end = min(end+context, len(edits))
+
+		oldStart, newStart := lineNumbers(edits[:start])
+		oldLen, newLen := lineNumbers(edits[start:end])
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart+1, oldLen, newStart+1, newLen)
+		for _, e := range edits[start:end] {
+			switch e.Op {
+			case Equal:
+				b.WriteString(" ")
+			case Delete:
+				b.WriteString("-")
+			case Insert:
+				b.WriteString("+")
+			}
+			b.WriteString(e.Line)
+			b.WriteByte('\n')
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// lineNumbers counts the old and new lines covered by edits.
+func lineNumbers(edits []Edit) (old, new int) {
+	for _, e := range edits {
+		if e.Op != Insert {
+			old++
+		}
+		if e.Op != Delete {
+			new++
+		}
+	}
+	return old, new
+}
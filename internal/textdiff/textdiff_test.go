@@ -0,0 +1,127 @@
+package textdiff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// apply rebuilds both sides from an edit script.
+func apply(edits []Edit) (a, b []string) {
+	for _, e := range edits {
+		if e.Op != Insert {
+			a = append(a, e.Line)
+		}
+		if e.Op != Delete {
+			b = append(b, e.Line)
+		}
+	}
+	return a, b
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		a, b        string
+		wantChanges int
+	}{
+		{"", "", 0},
+		{"a\nb\nc", "a\nb\nc", 0},
+		{"a\nb\nc", "a\nx\nc", 2},
+		{"a\nb\nc\nd", "b\nc\nd\ne", 2},
+		{"", "a\nb", 2},
+		{"a\nb", "", 2},
+		{"a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc", 5},
+	}
+	for _, tt := range tests {
+		a, b := SplitLines(tt.a), SplitLines(tt.b)
+		edits := Lines(a, b)
+		gotA, gotB := apply(edits)
+		if !reflect.DeepEqual(gotA, a) || !reflect.DeepEqual(gotB, b) {
+			t.Errorf("Lines(%q, %q) = %v, which doesn't rebuild the inputs", tt.a, tt.b, edits)
+		}
+		removed, added := Changed(edits)
+		if removed+added != tt.wantChanges {
+			t.Errorf("Lines(%q, %q) has %d changes, want %d", tt.a, tt.b, removed+added, tt.wantChanges)
+		}
+	}
+}
+
+func TestLinesLong(t *testing.T) {
+	// A quarter of the lines changed keeps the edit distance under the limit;
+	// disjoint inputs push it past, falling back to delete-all/insert-all.
+	const n = 3000
+	tests := []struct {
+		name        string
+		b           func(i int) string
+		wantChanges int
+	}{
+		{"scattered", func(i int) string {
+			if i%4 == 0 {
+				return fmt.Sprintf("changed %d", i)
+			}
+			return fmt.Sprintf("line %d", i)
+		}, n / 2},
+		{"disjoint", func(i int) string { return fmt.Sprintf("other %d", i) }, 2 * n},
+	}
+	for _, tt := range tests {
+		a, b := make([]string, n), make([]string, n)
+		for i := range a {
+			a[i] = fmt.Sprintf("line %d", i)
+			b[i] = tt.b(i)
+		}
+		edits := Lines(a, b)
+		gotA, gotB := apply(edits)
+		if !reflect.DeepEqual(gotA, a) || !reflect.DeepEqual(gotB, b) {
+			t.Errorf("%s: edits don't rebuild the inputs", tt.name)
+		}
+		removed, added := Changed(edits)
+		if removed+added != tt.wantChanges {
+			t.Errorf("%s: %d changes, want %d", tt.name, removed+added, tt.wantChanges)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	ours := SplitLines("intro\nhand edited line\nmiddle\nend\n")
+	theirs := SplitLines("intro\nauto line\nmiddle\nend\nnew closing\n")
+
+	got, conflicts := Merge(ours, theirs, "local", "youtube")
+	want := `intro
+<<<<<<< local
+hand edited line
+=======
+auto line
+>>>>>>> youtube
+middle
+end
+<<<<<<< local
+=======
+new closing
+>>>>>>> youtube`
+	if strings.Join(got, "\n") != want {
+		t.Errorf("Merge() =\n%s\nwant\n%s", strings.Join(got, "\n"), want)
+	}
+	if conflicts != 2 {
+		t.Errorf("Merge() conflicts = %d, want 2", conflicts)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := SplitLines("1\n2\n3\n4\n5\n6\n7\n8\n9\n")
+	b := SplitLines("1\n2\nthree\n4\n5\n6\n7\n8\nnine\n")
+	got := Unified(Lines(a, b), 1)
+	want := `@@ -2,3 +2,3 @@
+ 2
+-3
++three
+ 4
+@@ -8,2 +8,2 @@
+ 8
+-9
++nine
+`
+	if got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
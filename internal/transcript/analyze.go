@@ -0,0 +1,160 @@
+package transcript
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ReadingRate is the words per minute an adult reads silently at, which
+// Stats.ReadingTime assumes.
+const ReadingRate = 238
+
+// richnessWindow is the number of words Stats.Richness averages the
+// type-token ratio over.
+const richnessWindow = 100
+
+// Fillers are the filler words and phrases Analyze counts. "Like" is
+// counted wherever it occurs, so it overstates the filler use of speakers
+// who say it a lot as a verb.
+var Fillers = []string{"um", "uh", "er", "ah", "hmm", "like", "you know", "i mean", "sort of", "kind of", "basically", "actually", "literally"}
+
+// soundTag matches the sound descriptions in captions, such as "[Music]"
+// and "(applause)", which aren't words anyone said.
+var soundTag = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)`)
+
+// Stats describes how a transcript was delivered.
+type Stats struct {
+	Words       int
+	UniqueWords int
+	// Richness is the vocabulary richness: the average share of distinct
+	// words in each run of 100 words, from 0 to 1. Unlike UniqueWords /
+	// Words, it doesn't fall as transcripts get longer, so episodes of
+	// different lengths can be compared. Shorter transcripts use their
+	// whole length.
+	Richness float64
+	// SpeakingTime is the time cues are on screen, and WordsPerMinute the
+	// speaking rate over it. Both are zero for untimed transcripts.
+	SpeakingTime   time.Duration
+	WordsPerMinute float64
+	// Fillers counts each filler word or phrase said, and FillersPer100
+	// how many are said per 100 words.
+	Fillers       map[string]int
+	FillersPer100 float64
+	// ReadingTime is how long the transcript takes to read at ReadingRate.
+	ReadingTime time.Duration
+}
+
+// Analyze works out a transcript's statistics. Repeated lines in
+// auto-generated captions should be removed with Dedupe first, or they're
+// counted twice.
+func Analyze(cues []Cue) Stats {
+	var words []string
+	for _, c := range cues {
+		words = append(words, Tokens(c.Text)...)
+	}
+	s := Stats{Words: len(words), Fillers: map[string]int{}}
+	if s.Words == 0 {
+		return s
+	}
+
+	seen := map[string]bool{}
+	for _, w := range words {
+		seen[w] = true
+	}
+	s.UniqueWords = len(seen)
+	s.Richness = richness(words, richnessWindow)
+
+	s.SpeakingTime = speakingTime(cues)
+	if s.SpeakingTime > 0 {
+		s.WordsPerMinute = float64(s.Words) / s.SpeakingTime.Minutes()
+	}
+
+	joined := " " + strings.Join(words, " ") + " "
+	total := 0
+	for _, f := range Fillers {
+		if n := strings.Count(joined, " "+f+" "); n > 0 {
+			s.Fillers[f] = n
+			total += n
+		}
+	}
+	s.FillersPer100 = float64(total) * 100 / float64(s.Words)
+
+	s.ReadingTime = time.Duration(float64(s.Words) / ReadingRate * float64(time.Minute)).Round(time.Second)
+	return s
+}
+
+// TopFillers returns the fillers said, most often first.
+func (s Stats) TopFillers() []string {
+	fillers := make([]string, 0, len(s.Fillers))
+	for f := range s.Fillers {
+		fillers = append(fillers, f)
+	}
+	slices.SortFunc(fillers, func(a, b string) int {
+		if s.Fillers[a] != s.Fillers[b] {
+			return s.Fillers[b] - s.Fillers[a]
+		}
+		return strings.Compare(a, b)
+	})
+	return fillers
+}
+
+// Tokens splits text into lowercase words, leaving out sound descriptions
+// such as "[Music]" and punctuation.
+func Tokens(text string) []string {
+	text = soundTag.ReplaceAllString(strings.ToLower(text), " ")
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+	})
+	out := words[:0]
+	for _, w := range words {
+		if w = strings.Trim(w, "'’"); w != "" {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// richness returns the moving-average type-token ratio of words over
+// windows of n words.
+func richness(words []string, n int) float64 {
+	if len(words) <= n {
+		seen := map[string]bool{}
+		for _, w := range words {
+			seen[w] = true
+		}
+		return float64(len(seen)) / float64(len(words))
+	}
+	counts := map[string]int{}
+	for _, w := range words[:n] {
+		counts[w]++
+	}
+	sum := float64(len(counts))
+	for i := n; i < len(words); i++ {
+		if counts[words[i-n]]--; counts[words[i-n]] == 0 {
+			delete(counts, words[i-n])
+		}
+		counts[words[i]]++
+		sum += float64(len(counts))
+	}
+	return sum / float64(len(words)-n+1) / float64(n)
+}
+
+// speakingTime returns the time any cue is on screen, counting overlapping
+// cues once.
+func speakingTime(cues []Cue) time.Duration {
+	spans := slices.Clone(cues)
+	slices.SortFunc(spans, func(a, b Cue) int { return cmp.Compare(a.Start, b.Start) })
+	var total, end time.Duration
+	for _, c := range spans {
+		start := max(c.Start, end)
+		if c.End > start {
+			total += c.End - start
+			end = c.End
+		}
+	}
+	return total
+}
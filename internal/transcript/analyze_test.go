@@ -0,0 +1,74 @@
+package transcript
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnalyze(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: sec(20), Text: "[Music] Um, so today, you know, we'll look at it."},
+		{Start: sec(10), End: sec(30), Text: "It's like, basically, um... done."},
+		{Start: sec(40), End: sec(60), Text: "Thanks!"},
+	}
+	got := Analyze(cues)
+	want := Stats{
+		Words:          15,
+		UniqueWords:    14,
+		Richness:       14.0 / 15,
+		SpeakingTime:   50 * time.Second,
+		WordsPerMinute: 15 / (50.0 / 60),
+		Fillers:        map[string]int{"um": 2, "you know": 1, "like": 1, "basically": 1},
+		FillersPer100:  5 * 100 / 15.0,
+		ReadingTime:    4 * time.Second,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Analyze() =\n%+v\nwant\n%+v", got, want)
+	}
+	if top := got.TopFillers(); !reflect.DeepEqual(top, []string{"um", "basically", "like", "you know"}) {
+		t.Errorf("TopFillers() = %q", top)
+	}
+
+	if got := Analyze([]Cue{{Text: "one two three"}}); got.SpeakingTime != 0 || got.WordsPerMinute != 0 || got.Words != 3 {
+		t.Errorf("Analyze() of untimed cues = %+v", got)
+	}
+	if got := Analyze(nil); got.Words != 0 {
+		t.Errorf("Analyze(nil) = %+v", got)
+	}
+}
+
+func TestRichness(t *testing.T) {
+	tests := []struct {
+		words []string
+		n     int
+		want  float64
+	}{
+		{[]string{"a", "b", "a", "b"}, 10, 0.5},
+		{[]string{"a", "b", "a", "c"}, 2, 1},
+		{[]string{"a", "a", "b", "b"}, 2, (1 + 2 + 1) / 3.0 / 2},
+	}
+	for _, tt := range tests {
+		if got := richness(tt.words, tt.n); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("richness(%q, %d) = %v, want %v", tt.words, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSpeakingTime(t *testing.T) {
+	tests := []struct {
+		cues []Cue
+		want time.Duration
+	}{
+		{nil, 0},
+		{[]Cue{{Start: sec(40), End: sec(60)}, {Start: 0, End: sec(20)}, {Start: sec(10), End: sec(30)}}, 50 * time.Second},
+		{[]Cue{{Start: sec(5), End: sec(10)}, {Start: 0, End: sec(20)}}, 20 * time.Second},
+		{[]Cue{{Start: math.MaxInt64 - sec(1), End: math.MaxInt64}, {Start: -sec(1), End: sec(1)}}, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := speakingTime(tt.cues); got != tt.want {
+			t.Errorf("speakingTime(%v) = %v, want %v", tt.cues, got, tt.want)
+		}
+	}
+}
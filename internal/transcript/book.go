@@ -0,0 +1,77 @@
+package transcript
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/book"
+)
+
+// The PDF, EPUB, and DOCX formats lay a transcript out as a book: a title page
+// listing the source's metadata, then the transcript as a chapter, each cue
+// a paragraph headed by its timestamp. Like HTML, they embed the cues in the
+// JSON format, so a book can be parsed back.
+
+func formatBook(f Format, meta Metadata, cues []Cue) ([]byte, error) {
+	embedded, err := formatJSON(meta, cues)
+	if err != nil {
+		return nil, err
+	}
+	b := &book.Book{
+		ID:         cmp.Or(meta.Get("video_id"), "transcript"),
+		Title:      cmp.Or(meta.Get("title"), "Transcript"),
+		Language:   meta.Get("language"),
+		Date:       meta.Get("published_at"),
+		Chapters:   []book.Chapter{BookChapter(meta, cues)},
+		Attachment: embedded,
+	}
+	for _, field := range meta {
+		b.Fields = append(b.Fields, book.Field{Key: field.Key, Value: field.Value})
+	}
+	switch f {
+	case FormatPDF:
+		return b.PDF()
+	case FormatDOCX:
+		return b.DOCX()
+	}
+	return b.EPUB()
+}
+
+// BookChapter lays out cues from a source as a chapter of a book, titled
+// with the source's title and subtitled with its date and URL. Timestamps
+// link to the video where the source is known, and are left out of untimed
+// transcripts.
+func BookChapter(meta Metadata, cues []Cue) book.Chapter {
+	ch := book.Chapter{Title: cmp.Or(meta.Get("title"), meta.Get("video_id"), "Transcript")}
+	var subtitle []string
+	if published, err := time.Parse(time.RFC3339, meta.Get("published_at")); err == nil {
+		subtitle = append(subtitle, published.Format(time.DateOnly))
+	}
+	if url := meta.Get("url"); url != "" {
+		subtitle = append(subtitle, url)
+	}
+	ch.Subtitle = strings.Join(subtitle, " · ")
+
+	withTimes := timed(cues)
+	videoID := meta.Get("video_id")
+	for _, c := range cues {
+		p := book.Paragraph{Speaker: c.Speaker, Text: c.Text}
+		if withTimes {
+			p.Time = FormatTimestamp(c.Start)
+			if videoID != "" {
+				p.URL = WatchURL(videoID, c.Start)
+			}
+		}
+		ch.Paragraphs = append(ch.Paragraphs, p)
+	}
+	return ch
+}
+
+func parseBook(data []byte) ([]Cue, error) {
+	embedded, err := book.ReadAttachment(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseJSON(embedded)
+}
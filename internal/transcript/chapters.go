@@ -0,0 +1,127 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Chapter is a chapter marker from a video's description.
+type Chapter struct {
+	Title string
+	Start time.Duration
+}
+
+// A chapter line starts or ends with its timestamp, which may be bracketed
+// and set off from the title by a dash, colon, or bar.
+var (
+	chapterLead  = regexp.MustCompile(`^[\s\-*•]*[(\[]?((?:\d+:)?\d{1,2}:\d{2})[)\]]?\s*[-–—:|.]?\s*(\S.*)$`)
+	chapterTrail = regexp.MustCompile(`^[\s\-*•]*(\S.*?)\s*[-–—:|]?\s*[(\[]?((?:\d+:)?\d{1,2}:\d{2})[)\]]?$`)
+)
+
+// ParseChapters returns the chapters listed in a video description, by the
+// rules YouTube shows them by: one timestamp per line, the first at 0:00,
+// in increasing order, and at least three of them. It returns nil if the
+// description lists no chapters YouTube would show.
+func ParseChapters(description string) []Chapter {
+	var chapters []Chapter
+	for line := range strings.Lines(description) {
+		line = strings.TrimSpace(line)
+		var stamp, title string
+		if m := chapterLead.FindStringSubmatch(line); m != nil {
+			stamp, title = m[1], m[2]
+		} else if m := chapterTrail.FindStringSubmatch(line); m != nil {
+			title, stamp = m[1], m[2]
+		} else {
+			continue
+		}
+		start, err := ParseTimestamp(stamp)
+		if err != nil {
+			continue
+		}
+		if len(chapters) == 0 && start != 0 {
+			// Timestamps before the list, such as "recorded at 10:30".
+			continue
+		}
+		if len(chapters) > 0 && start <= chapters[len(chapters)-1].Start {
+			break
+		}
+		chapters = append(chapters, Chapter{Title: title, Start: start})
+	}
+	if len(chapters) < 3 {
+		return nil
+	}
+	return chapters
+}
+
+// Section is a chapter with the transcript text spoken within it.
+type Section struct {
+	Title string
+	Start time.Duration
+	// End is the start of the next chapter, or for the last chapter, the
+	// end of its last cue.
+	End  time.Duration
+	Text string
+}
+
+// Outline joins chapters with the cues spoken in them. A cue belongs to the
+// chapter it starts in.
+func Outline(chapters []Chapter, cues []Cue) []Section {
+	sections := make([]Section, len(chapters))
+	texts := make([][]string, len(chapters))
+	for i, ch := range chapters {
+		sections[i] = Section{Title: ch.Title, Start: ch.Start, End: ch.Start}
+		if i+1 < len(chapters) {
+			sections[i].End = chapters[i+1].Start
+		}
+	}
+	if len(chapters) == 0 {
+		return sections
+	}
+	i := 0
+	for _, c := range cues {
+		for i+1 < len(chapters) && c.Start >= chapters[i+1].Start {
+			i++
+		}
+		texts[i] = append(texts[i], strings.Join(strings.Fields(c.Text), " "))
+		if i == len(chapters)-1 {
+			sections[i].End = max(sections[i].End, c.End)
+		}
+	}
+	for i := range sections {
+		sections[i].Text = strings.Join(texts[i], " ")
+	}
+	return sections
+}
+
+// OutlineMarkdown renders sections as Markdown show notes: the source's
+// metadata as front matter, the title as a heading, then a heading per
+// chapter, linked to that moment of the video when the video is known,
+// followed by its text.
+func OutlineMarkdown(meta Metadata, sections []Section) []byte {
+	var b bytes.Buffer
+	if fm := meta.FrontMatter(); fm != nil {
+		b.Write(fm)
+		b.WriteByte('\n')
+	}
+	if title := meta.Get("title"); title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", strings.Join(strings.Fields(title), " "))
+	}
+	videoID := meta.Get("video_id")
+	for i, s := range sections {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		stamp := "[" + FormatTimestamp(s.Start) + "]"
+		if videoID != "" {
+			stamp += "(" + WatchURL(videoID, s.Start) + ")"
+		}
+		fmt.Fprintf(&b, "## %s %s\n", stamp, s.Title)
+		if s.Text != "" {
+			fmt.Fprintf(&b, "\n%s\n", s.Text)
+		}
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,97 @@
+package transcript
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseChapters(t *testing.T) {
+	sec := func(n int) time.Duration { return time.Duration(n) * time.Second }
+	tests := []struct {
+		name        string
+		description string
+		want        []Chapter
+	}{
+		{
+			name:        "leading timestamps",
+			description: "Today we talk rates.\n\n0:00 Intro\n1:30 - Rates\n[12:05] Q&A\n\nSubscribe!",
+			want:        []Chapter{{"Intro", 0}, {"Rates", sec(90)}, {"Q&A", sec(725)}},
+		},
+		{
+			name:        "trailing timestamps and hours",
+			description: "Intro 00:00\nPart one (10:00)\nPart two - 1:02:03",
+			want:        []Chapter{{"Intro", 0}, {"Part one", sec(600)}, {"Part two", sec(3723)}},
+		},
+		{
+			name:        "timestamp before the list",
+			description: "Recorded live at 10:30\n0:00 Intro\n0:10 Middle\n0:20 End",
+			want:        []Chapter{{"Intro", 0}, {"Middle", sec(10)}, {"End", sec(20)}},
+		},
+		{
+			name:        "stops when out of order",
+			description: "0:00 Intro\n0:10 Middle\n0:20 End\n0:05 Bonus",
+			want:        []Chapter{{"Intro", 0}, {"Middle", sec(10)}, {"End", sec(20)}},
+		},
+		{
+			name:        "too few",
+			description: "0:00 Intro\n5:00 End",
+		},
+		{
+			name:        "not starting at zero",
+			description: "0:30 Intro\n1:00 Middle\n2:00 End",
+		},
+		{
+			name:        "none",
+			description: "Just a video.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseChapters(tt.description); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseChapters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutline(t *testing.T) {
+	sec := func(n int) time.Duration { return time.Duration(n) * time.Second }
+	chapters := []Chapter{{"Intro", 0}, {"Rates", sec(10)}, {"Wrap-up", sec(20)}}
+	cues := []Cue{
+		{Start: sec(0), End: sec(4), Text: "hello\nthere"},
+		{Start: sec(4), End: sec(11), Text: "first"},
+		{Start: sec(11), End: sec(15), Text: "rates rose"},
+		{Start: sec(25), End: sec(30), Text: "bye"},
+	}
+	want := []Section{
+		{Title: "Intro", Start: 0, End: sec(10), Text: "hello there first"},
+		{Title: "Rates", Start: sec(10), End: sec(20), Text: "rates rose"},
+		{Title: "Wrap-up", Start: sec(20), End: sec(30), Text: "bye"},
+	}
+	if got := Outline(chapters, cues); !reflect.DeepEqual(got, want) {
+		t.Errorf("Outline() = %v, want %v", got, want)
+	}
+
+	// A last chapter after the last cue ends where it starts.
+	if got := Outline(chapters, cues[:1]); got[2].End != sec(20) || got[2].Text != "" {
+		t.Errorf("Outline() last section = %+v, want empty at 0:20", got[2])
+	}
+}
+
+func TestOutlineMarkdown(t *testing.T) {
+	src := Source{VideoID: "abc123", Title: "Rates"}
+	sections := []Section{
+		{Title: "Intro", Start: 0, End: 10 * time.Second, Text: "hello"},
+		{Title: "Q&A", Start: 10 * time.Second, End: 20 * time.Second},
+	}
+	got := string(OutlineMarkdown(src.Metadata(), sections))
+	want := "# Rates\n\n## [0:00](https://youtu.be/abc123?t=0) Intro\n\nhello\n\n## [0:10](https://youtu.be/abc123?t=10) Q&A\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("OutlineMarkdown() = %q, want suffix %q", got, want)
+	}
+	if !strings.HasPrefix(got, "---\nvideo_id: \"abc123\"\n") {
+		t.Errorf("OutlineMarkdown() = %q, want front matter", got)
+	}
+}
@@ -0,0 +1,110 @@
+package transcript
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Chunk is a run of cues sized for an embedding model.
+type Chunk struct {
+	// Start is the start of the chunk's first cue, and End the end of its
+	// last.
+	Start  time.Duration
+	End    time.Duration
+	Text   string
+	Tokens int
+}
+
+// EstimateTokens estimates how many tokens a model's tokenizer splits s
+// into, counting a token for every four characters of each word, rounded
+// up. It runs a little high for English, so chunks sized by it fit the
+// model.
+func EstimateTokens(s string) int {
+	n := 0
+	for _, w := range strings.Fields(s) {
+		n += (utf8.RuneCountInString(w) + 3) / 4
+	}
+	return n
+}
+
+// Chunks splits cues into chunks of at most maxTokens estimated tokens,
+// keeping cues whole, so each chunk has the times it covers. Each chunk
+// after the first repeats up to overlap tokens of whole cues from the end
+// of the one before, so text split between chunks is also found whole in
+// one of them. A cue longer than maxTokens, such as a whole transcript read
+// from plain text, is first split into cues that fit, with its time shared
+// out among them.
+func Chunks(cues []Cue, maxTokens, overlap int) []Chunk {
+	var split []Cue
+	for _, c := range cues {
+		if maxTokens > 0 && EstimateTokens(c.Text) > maxTokens {
+			split = append(split, splitTokens(c, maxTokens)...)
+		} else {
+			split = append(split, c)
+		}
+	}
+	cues = split
+
+	tokens := make([]int, len(cues))
+	texts := make([]string, len(cues))
+	for i, c := range cues {
+		texts[i] = strings.Join(strings.Fields(c.Text), " ")
+		tokens[i] = EstimateTokens(texts[i])
+	}
+
+	var chunks []Chunk
+	for start := 0; start < len(cues); {
+		end, n := start, 0
+		for end < len(cues) && (end == start || n+tokens[end] <= maxTokens) {
+			n += tokens[end]
+			end++
+		}
+		chunks = append(chunks, Chunk{
+			Start:  cues[start].Start,
+			End:    cues[end-1].End,
+			Text:   strings.Join(texts[start:end], " "),
+			Tokens: n,
+		})
+		if end == len(cues) {
+			break
+		}
+
+		// Step back over the cues to repeat, always moving forward.
+		next, repeated := end, 0
+		for next-1 > start && repeated+tokens[next-1] <= overlap {
+			next--
+			repeated += tokens[next]
+		}
+		start = next
+	}
+	return chunks
+}
+
+// splitTokens splits c into cues of at most maxTokens estimated tokens,
+// breaking between words, as splitCue does. A word too long for a cue on
+// its own, such as a long URL, is cut into parts that fit.
+func splitTokens(c Cue, maxTokens int) []Cue {
+	var chunks [][]string
+	n := 0
+	for _, w := range strings.Fields(c.Text) {
+		for EstimateTokens(w) > maxTokens {
+			r := []rune(w)
+			chunks = append(chunks, []string{string(r[:4*maxTokens])})
+			w, n = string(r[4*maxTokens:]), maxTokens
+			// The parts no longer line up with the word timings.
+			c.Words = nil
+		}
+		if w == "" {
+			continue
+		}
+		t := EstimateTokens(w)
+		if len(chunks) == 0 || n+t > maxTokens {
+			chunks = append(chunks, nil)
+			n = 0
+		}
+		chunks[len(chunks)-1] = append(chunks[len(chunks)-1], w)
+		n += t
+	}
+	return splitCue(c, chunks)
+}
@@ -0,0 +1,98 @@
+package transcript
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"the cat", 2},
+		{"inflation", 3},
+		{"  a\nb  ", 2},
+		{"größer", 2},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.in); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestChunks(t *testing.T) {
+	sec := func(n int) time.Duration { return time.Duration(n) * time.Second }
+	cues := []Cue{
+		{Start: sec(0), End: sec(2), Text: "one two"},
+		{Start: sec(2), End: sec(4), Text: "six\nten"},
+		{Start: sec(4), End: sec(6), Text: "red blue"},
+		{Start: sec(6), End: sec(8), Text: "gold"},
+	}
+
+	tests := []struct {
+		name    string
+		max     int
+		overlap int
+		cues    []Cue
+		want    []Chunk
+	}{
+		{
+			"no overlap",
+			4, 0, cues,
+			[]Chunk{
+				{Start: sec(0), End: sec(4), Text: "one two six ten", Tokens: 4},
+				{Start: sec(4), End: sec(8), Text: "red blue gold", Tokens: 3},
+			},
+		},
+		{
+			"overlap",
+			4, 2, cues,
+			[]Chunk{
+				{Start: sec(0), End: sec(4), Text: "one two six ten", Tokens: 4},
+				{Start: sec(2), End: sec(6), Text: "six ten red blue", Tokens: 4},
+				{Start: sec(4), End: sec(8), Text: "red blue gold", Tokens: 3},
+			},
+		},
+		{
+			// Repeating a whole chunk would never move forward.
+			"overlap of a whole chunk",
+			2, 10, cues,
+			[]Chunk{
+				{Start: sec(0), End: sec(2), Text: "one two", Tokens: 2},
+				{Start: sec(2), End: sec(4), Text: "six ten", Tokens: 2},
+				{Start: sec(4), End: sec(6), Text: "red blue", Tokens: 2},
+				{Start: sec(6), End: sec(8), Text: "gold", Tokens: 1},
+			},
+		},
+		{"everything fits", 100, 10, cues, []Chunk{{Start: sec(0), End: sec(8), Text: "one two six ten red blue gold", Tokens: 7}}},
+		{"no cues", 100, 10, nil, nil},
+		{
+			// A transcript read from plain text is one long cue.
+			"cue longer than a chunk",
+			2, 0, []Cue{{Start: sec(0), End: sec(6), Text: "aa bb cc dd ee ff"}},
+			[]Chunk{
+				{Start: sec(0), End: sec(2), Text: "aa bb", Tokens: 2},
+				{Start: sec(2), End: sec(4), Text: "cc dd", Tokens: 2},
+				{Start: sec(4), End: sec(6), Text: "ee ff", Tokens: 2},
+			},
+		},
+		{
+			"word longer than a chunk",
+			1, 0, []Cue{{Start: sec(0), End: sec(2), Text: "abcdefgh"}},
+			[]Chunk{
+				{Start: sec(0), End: sec(1), Text: "abcd", Tokens: 1},
+				{Start: sec(1), End: sec(2), Text: "efgh", Tokens: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := Chunks(tt.cues, tt.max, tt.overlap)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Chunks() =\n%+v\nwant\n%+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Text encodings DetectEncoding tells apart.
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingWindows1252 = "windows-1252"
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// DetectEncoding guesses the text encoding of caption data: UTF-16 from
+// its byte order mark or, without one, from the zero bytes ASCII text has
+// in every other byte; UTF-8 if the data is valid UTF-8; and otherwise
+// Windows-1252, which older subtitle editors save in.
+func DetectEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return EncodingUTF8
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return EncodingUTF16BE
+	}
+	sample := data[:min(len(data), 512)&^1]
+	var even, odd int
+	for i := 0; i < len(sample); i += 2 {
+		if sample[i] == 0 {
+			even++
+		}
+		if sample[i+1] == 0 {
+			odd++
+		}
+	}
+	switch pairs := len(sample) / 2; {
+	case pairs > 0 && odd*10 >= pairs*4 && even*10 < pairs:
+		return EncodingUTF16LE
+	case pairs > 0 && even*10 >= pairs*4 && odd*10 < pairs:
+		return EncodingUTF16BE
+	case utf8.Valid(data):
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+// ToUTF8 decodes caption data in the encoding DetectEncoding finds into
+// UTF-8 without a byte order mark, returning the encoding it was in.
+func ToUTF8(data []byte) ([]byte, string) {
+	enc := DetectEncoding(data)
+	switch enc {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		data = bytes.TrimPrefix(bytes.TrimPrefix(data, bomUTF16LE), bomUTF16BE)
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if enc == EncodingUTF16LE {
+				units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+			} else {
+				units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+			}
+		}
+		return []byte(string(utf16.Decode(units))), enc
+	case EncodingWindows1252:
+		var b strings.Builder
+		for _, c := range data {
+			if c >= 0x80 && c < 0xa0 {
+				b.WriteRune(windows1252[c-0x80])
+			} else {
+				b.WriteRune(rune(c))
+			}
+		}
+		return []byte(b.String()), enc
+	}
+	return bytes.TrimPrefix(data, bomUTF8), enc
+}
+
+// windows1252 maps bytes 0x80 to 0x9f, where Windows-1252 differs from
+// Latin-1, to their characters.
+var windows1252 = [32]rune{
+	'€', utf8.RuneError, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', utf8.RuneError, 'Ž', utf8.RuneError,
+	utf8.RuneError, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', utf8.RuneError, 'ž', 'Ÿ',
+}
+
+// Newline is a line ending convention for saved captions.
+type Newline string
+
+// Supported line endings. The zero Newline is NewlineLF.
+const (
+	NewlineLF   Newline = "lf"
+	NewlineCRLF Newline = "crlf"
+)
+
+// ParseNewline parses a line ending name: lf or crlf, with "" for lf.
+func ParseNewline(s string) (Newline, error) {
+	switch nl := Newline(strings.ToLower(s)); nl {
+	case "", NewlineLF:
+		return NewlineLF, nil
+	case NewlineCRLF:
+		return nl, nil
+	}
+	return "", fmt.Errorf("unsupported newline %q (want lf or crlf)", s)
+}
+
+// Normalize decodes caption data to UTF-8 without a byte order mark, as
+// ToUTF8 does, and ends its lines with nl, whether they ended with CRLF,
+// LF, or a lone CR before.
+func Normalize(data []byte, nl Newline) []byte {
+	data, _ = ToUTF8(data)
+	text := normalizeNewlines(string(data))
+	if nl == NewlineCRLF {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	return []byte(text)
+}
+
+// normalizeNewlines ends every line of text with LF.
+func normalizeNewlines(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
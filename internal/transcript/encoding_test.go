@@ -0,0 +1,78 @@
+package transcript
+
+import (
+	"testing"
+	"unicode/utf16"
+)
+
+// utf16Bytes encodes s as UTF-16 in the given byte order, with bom first.
+func utf16Bytes(s string, bigEndian bool, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xfeff}, units...)
+	}
+	var b []byte
+	for _, u := range units {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return b
+}
+
+func TestToUTF8(t *testing.T) {
+	const text = "1\r\n00:00:01,000 --> 00:00:02,000\r\nCafé – ok\r\n"
+	tests := []struct {
+		name    string
+		data    []byte
+		wantEnc string
+	}{
+		{"utf-8", []byte(text), EncodingUTF8},
+		{"utf-8 bom", append([]byte("\xef\xbb\xbf"), text...), EncodingUTF8},
+		{"utf-16le bom", utf16Bytes(text, false, true), EncodingUTF16LE},
+		{"utf-16be bom", utf16Bytes(text, true, true), EncodingUTF16BE},
+		{"utf-16le", utf16Bytes(text, false, false), EncodingUTF16LE},
+		{"utf-16be", utf16Bytes(text, true, false), EncodingUTF16BE},
+		{"windows-1252", []byte("1\r\n00:00:01,000 --> 00:00:02,000\r\nCaf\xe9 \x96 ok\r\n"), EncodingWindows1252},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, enc := ToUTF8(tt.data)
+			if enc != tt.wantEnc {
+				t.Errorf("encoding = %q, want %q", enc, tt.wantEnc)
+			}
+			if string(got) != text {
+				t.Errorf("ToUTF8() = %q, want %q", got, text)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	data := []byte("\xef\xbb\xbfWEBVTT\r\n\r\n00:00.000 --> 00:01.000\rHi\n")
+	if got, want := string(Normalize(data, NewlineLF)), "WEBVTT\n\n00:00.000 --> 00:01.000\nHi\n"; got != want {
+		t.Errorf("Normalize(lf) = %q, want %q", got, want)
+	}
+	if got, want := string(Normalize(data, NewlineCRLF)), "WEBVTT\r\n\r\n00:00.000 --> 00:01.000\r\nHi\r\n"; got != want {
+		t.Errorf("Normalize(crlf) = %q, want %q", got, want)
+	}
+	if _, err := ParseNewline("cr"); err == nil {
+		t.Error("ParseNewline(cr) succeeded")
+	}
+}
+
+func TestParseUTF16(t *testing.T) {
+	data := utf16Bytes("WEBVTT\r\n\r\n00:00:01.000 --> 00:00:02.000\r\nHello\r\n", false, true)
+	if f := DetectFormat(data); f != FormatVTT {
+		t.Fatalf("DetectFormat() = %q, want vtt", f)
+	}
+	cues, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cues) != 1 || cues[0].Text != "Hello" {
+		t.Errorf("Parse() = %+v", cues)
+	}
+}
@@ -0,0 +1,132 @@
+package transcript
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Match is an occurrence of a phrase in a transcript, with the text around
+// it.
+type Match struct {
+	// Start is the start of the cue the match begins in, and End the end
+	// of the cue it ends in.
+	Start  time.Duration
+	End    time.Duration
+	Before string
+	Text   string
+	After  string
+}
+
+var sentenceBreak = regexp.MustCompile(`[.!?]["')\]]*\s`)
+
+// Sentence returns the sentence containing the match, as far as the
+// match's context reaches. Transcripts without punctuation, as
+// auto-generated ones often are, yield the whole context.
+func (m Match) Sentence() string {
+	before := m.Before
+	if locs := sentenceBreak.FindAllStringIndex(before, -1); locs != nil {
+		before = before[locs[len(locs)-1][1]:]
+	}
+	after := m.After
+	if sentenceEnd.MatchString(m.Text) {
+		after = ""
+	} else if loc := sentenceBreak.FindStringIndex(after + " "); loc != nil {
+		after = after[:loc[1]-1]
+	}
+	return strings.TrimSpace(before + m.Text + after)
+}
+
+// Find returns the occurrences of phrase in cues, ignoring case and
+// treating any run of whitespace as a single space, so phrases split across
+// cues are found too. Each match carries up to context characters of
+// surrounding text, cut back to whole words.
+func Find(cues []Cue, phrase string, context int) []Match {
+	words := strings.Fields(phrase)
+	if len(words) == 0 {
+		return nil
+	}
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	re := regexp.MustCompile(`(?i)` + strings.Join(words, `\s+`))
+
+	// Join the cues into one line, remembering where each begins.
+	var b strings.Builder
+	offsets := make([]int, len(cues))
+	for i, c := range cues {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		offsets[i] = b.Len()
+		b.WriteString(strings.Join(strings.Fields(c.Text), " "))
+	}
+	text := b.String()
+
+	var matches []Match
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		first := sort.Search(len(offsets), func(i int) bool { return offsets[i] > loc[0] }) - 1
+		last := sort.Search(len(offsets), func(i int) bool { return offsets[i] >= loc[1] }) - 1
+		matches = append(matches, Match{
+			Start:  cues[first].Start,
+			End:    cues[last].End,
+			Before: lastWords(text[:loc[0]], context),
+			Text:   text[loc[0]:loc[1]],
+			After:  firstWords(text[loc[1]:], context),
+		})
+	}
+	return matches
+}
+
+// firstWords returns the start of s, at most n characters, without a
+// partial word at the end.
+func firstWords(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	cut := 0
+	for i := range s {
+		if n == 0 {
+			cut = i
+			break
+		}
+		n--
+	}
+	if s[cut] != ' ' {
+		if i := strings.LastIndexByte(s[:cut], ' '); i >= 0 {
+			cut = i
+		}
+	}
+	return s[:cut]
+}
+
+// lastWords returns the end of s, at most n characters, without a partial
+// word at the start.
+func lastWords(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	count := utf8.RuneCountInString(s)
+	if count <= n {
+		return s
+	}
+	cut := 0
+	for i := range s {
+		if count == n {
+			cut = i
+			break
+		}
+		count--
+	}
+	if s[cut-1] != ' ' {
+		if i := strings.IndexByte(s[cut:], ' '); i >= 0 {
+			cut += i + 1
+		}
+	}
+	return s[cut:]
+}
@@ -0,0 +1,64 @@
+package transcript
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFind(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: 5 * time.Second, Text: "today we talk about"},
+		{Start: 5 * time.Second, End: 10 * time.Second, Text: "Inflation\nand why"},
+		{Start: 10 * time.Second, End: 15 * time.Second, Text: "inflation matters to everyone"},
+	}
+
+	tests := []struct {
+		name    string
+		phrase  string
+		context int
+		want    []Match
+	}{
+		{
+			"across cues",
+			"about  inflation",
+			10,
+			[]Match{{Start: 0, End: 10 * time.Second, Before: "we talk ", Text: "about Inflation", After: " and why"}},
+		},
+		{
+			"several matches",
+			"inflation",
+			8,
+			[]Match{
+				{Start: 5 * time.Second, End: 10 * time.Second, Before: "about ", Text: "Inflation", After: " and why"},
+				{Start: 10 * time.Second, End: 15 * time.Second, Before: "and why ", Text: "inflation", After: " matters"},
+			},
+		},
+		{"no context", "why", 0, []Match{{Start: 5 * time.Second, End: 10 * time.Second, Text: "why"}}},
+		{"no match", "deflation", 10, nil},
+		{"empty phrase", "  ", 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Find(cues, tt.phrase, tt.context); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchSentence(t *testing.T) {
+	tests := []struct {
+		m    Match
+		want string
+	}{
+		{Match{Before: "It rained. Then prices of ", Text: "eggs", After: " rose! Nobody knew why."}, "Then prices of eggs rose!"},
+		{Match{Before: "no punctuation here ", Text: "eggs", After: " and more"}, "no punctuation here eggs and more"},
+		{Match{Before: "Start. ", Text: "Eggs.", After: " Next."}, "Eggs."},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Sentence(); got != tt.want {
+			t.Errorf("Sentence(%+v) = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
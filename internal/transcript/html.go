@@ -0,0 +1,128 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// The HTML format is a standalone page for reading a transcript in a
+// browser: a header with the source's metadata, each cue with its timestamp
+// linked to that moment of the video unless the cues are untimed, and a
+// search box that filters the cues as you type. The cues are also embedded
+// in the page in the JSON format, so the page can be parsed back like any
+// other caption file.
+
+// htmlCuesStart begins the script element holding the embedded cues.
+const htmlCuesStart = `<script type="application/json" id="ytt-transcript">`
+
+func formatHTML(meta Metadata, cues []Cue) ([]byte, error) {
+	embedded, err := formatJSON(meta, cues)
+	if err != nil {
+		return nil, err
+	}
+
+	title := meta.Get("title")
+	if title == "" {
+		title = "Transcript"
+	}
+	videoID := meta.Get("video_id")
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n")
+	if lang := meta.Get("language"); lang != "" {
+		fmt.Fprintf(&b, "<html lang=\"%s\">\n", html.EscapeString(lang))
+	} else {
+		b.WriteString("<html>\n")
+	}
+	b.WriteString("<head>\n<meta charset=\"utf-8\">\n<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(title))
+	b.WriteString(htmlStyle)
+	b.WriteString("</head>\n<body>\n<header>\n")
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", html.EscapeString(title))
+	if len(meta) > 0 {
+		b.WriteString("<dl>\n")
+		for _, f := range meta {
+			value := html.EscapeString(f.Value)
+			if f.Key == "url" {
+				value = fmt.Sprintf("<a href=\"%s\">%s</a>", value, value)
+			}
+			fmt.Fprintf(&b, "<dt>%s</dt><dd>%s</dd>\n", html.EscapeString(f.Key), value)
+		}
+		b.WriteString("</dl>\n")
+	}
+	b.WriteString("<input type=\"search\" id=\"search\" placeholder=\"Search the transcript\" autofocus> <span id=\"count\"></span>\n")
+	b.WriteString("</header>\n<ol id=\"cues\">\n")
+	withTimes := timed(cues)
+	for _, c := range cues {
+		b.WriteString("<li>")
+		if withTimes {
+			stamp := FormatTimestamp(c.Start)
+			if videoID != "" {
+				stamp = fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(WatchURL(videoID, c.Start)), stamp)
+			}
+			b.WriteString("<span class=\"time\">" + stamp + "</span> ")
+		}
+		if c.Speaker != "" {
+			fmt.Fprintf(&b, "<span class=\"speaker\">%s:</span> ", html.EscapeString(c.Speaker))
+		}
+		text := strings.ReplaceAll(html.EscapeString(c.Text), "\n", "<br>")
+		fmt.Fprintf(&b, "<span class=\"text\">%s</span></li>\n", text)
+	}
+	b.WriteString("</ol>\n")
+	// JSON encoding escapes <, >, and &, so the cues can't end the script
+	// element early.
+	b.WriteString(htmlCuesStart + "\n")
+	b.Write(embedded)
+	b.WriteString("</script>\n")
+	b.WriteString(htmlScript)
+	b.WriteString("</body>\n</html>\n")
+	return []byte(b.String()), nil
+}
+
+// parseHTML reads back the cues embedded in a page written by formatHTML.
+func parseHTML(data []byte) ([]Cue, error) {
+	_, rest, ok := bytes.Cut(data, []byte(htmlCuesStart))
+	if !ok {
+		return nil, fmt.Errorf("HTML file has no embedded ytt transcript")
+	}
+	embedded, _, ok := bytes.Cut(rest, []byte("</script>"))
+	if !ok {
+		return nil, fmt.Errorf("HTML file has no embedded ytt transcript")
+	}
+	return parseJSON(embedded)
+}
+
+const htmlStyle = `<style>
+body { font: 16px/1.5 system-ui, sans-serif; max-width: 50em; margin: 2em auto; padding: 0 1em; color: #222; }
+h1 { font-size: 1.5em; }
+dl { display: grid; grid-template-columns: max-content 1fr; gap: 0 1em; font-size: 0.9em; color: #555; }
+dt { font-weight: 600; }
+dd { margin: 0; }
+header { position: sticky; top: 0; background: #fff; padding-bottom: 0.5em; border-bottom: 1px solid #ddd; }
+#search { width: 20em; max-width: 70%; font: inherit; padding: 0.25em 0.5em; }
+#count { color: #555; font-size: 0.9em; }
+ol { list-style: none; padding: 0; }
+li { margin: 0.25em 0; }
+.time { display: inline-block; min-width: 4.5em; font-variant-numeric: tabular-nums; color: #555; }
+.time a { color: inherit; }
+.speaker { font-weight: 600; }
+</style>
+`
+
+const htmlScript = `<script>
+const search = document.getElementById("search");
+const count = document.getElementById("count");
+const items = Array.from(document.querySelectorAll("#cues li"));
+search.addEventListener("input", () => {
+  const q = search.value.trim().toLowerCase();
+  let shown = 0;
+  for (const li of items) {
+    li.hidden = q !== "" && !li.textContent.toLowerCase().includes(q);
+    if (!li.hidden) shown++;
+  }
+  count.textContent = q === "" ? "" : shown + " of " + items.length + " cues";
+});
+</script>
+`
@@ -0,0 +1,83 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// The JSON format stores times in seconds, after the source's metadata if
+// it is known:
+//
+//	{"source": {"video_id": "abc123", "title": "..."},
+//	 "cues": [{"start": 1.5, "end": 3, "text": "so today",
+//	           "words": [{"start": 1.5, "text": "so"}, {"start": 2.1, "text": "today"}]}]}
+type jsonTranscript struct {
+	Source Metadata  `json:"source,omitempty"`
+	Cues   []jsonCue `json:"cues"`
+}
+
+type jsonCue struct {
+	Start    float64    `json:"start"`
+	End      float64    `json:"end"`
+	Speaker  string     `json:"speaker,omitempty"`
+	Text     string     `json:"text"`
+	Words    []jsonWord `json:"words,omitempty"`
+	Settings string     `json:"settings,omitempty"`
+	Markup   string     `json:"markup,omitempty"`
+}
+
+type jsonWord struct {
+	Start float64 `json:"start"`
+	Text  string  `json:"text"`
+}
+
+func formatJSON(meta Metadata, cues []Cue) ([]byte, error) {
+	out := jsonTranscript{Source: meta, Cues: make([]jsonCue, len(cues))}
+	for i, c := range cues {
+		jc := jsonCue{
+			Start:    c.Start.Seconds(),
+			End:      c.End.Seconds(),
+			Speaker:  c.Speaker,
+			Text:     c.Text,
+			Settings: c.Settings,
+			Markup:   c.markup(),
+		}
+		for _, w := range c.Words {
+			jc.Words = append(jc.Words, jsonWord{Start: w.Start.Seconds(), Text: w.Text})
+		}
+		out.Cues[i] = jc
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding transcript: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func parseJSON(data []byte) ([]Cue, error) {
+	var in jsonTranscript
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("error parsing JSON transcript: %w", err)
+	}
+	cues := make([]Cue, len(in.Cues))
+	for i, jc := range in.Cues {
+		c := Cue{
+			Start:    seconds(jc.Start),
+			End:      seconds(jc.End),
+			Text:     jc.Text,
+			Speaker:  jc.Speaker,
+			Settings: jc.Settings,
+			Markup:   jc.Markup,
+		}
+		for _, w := range jc.Words {
+			c.Words = append(c.Words, Word{Start: seconds(w.Start), Text: w.Text})
+		}
+		cues[i] = c
+	}
+	return cues, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+}
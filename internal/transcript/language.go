@@ -0,0 +1,153 @@
+package transcript
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Language detection is deliberately small: the writing system settles most
+// languages outside the Latin and Cyrillic alphabets, and the share of
+// common function words settles the rest. It is meant to catch a caption
+// track labeled with the wrong language, not to tell close relatives apart.
+
+// minDetectWords is how many words a transcript needs before its language
+// is guessed from them.
+const minDetectWords = 20
+
+// scripts maps writing systems used by a single common language to it.
+var scripts = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+	{unicode.Greek, "el"},
+}
+
+// stopwords are frequent short words of languages written in the Latin
+// alphabet. Words several languages share count partly towards each.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "that", "this", "with", "you", "it", "of", "to", "have", "what", "not", "we", "they"},
+	"es": {"el", "los", "las", "que", "y", "es", "una", "por", "con", "para", "pero", "muy", "como", "del", "está", "yo", "lo"},
+	"fr": {"le", "les", "et", "est", "une", "des", "que", "pas", "pour", "avec", "dans", "je", "vous", "nous", "ce", "c'est", "du"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "ich", "mit", "auf", "sie", "wir", "es", "zu", "auch", "den"},
+	"it": {"il", "che", "di", "è", "non", "sono", "una", "per", "con", "della", "gli", "anche", "questo", "ma", "mi", "ho", "lo"},
+	"pt": {"o", "os", "que", "não", "uma", "com", "para", "é", "são", "você", "isso", "mas", "muito", "do", "da", "eu", "em"},
+	"nl": {"de", "het", "een", "en", "is", "niet", "dat", "van", "ik", "je", "wij", "zijn", "ook", "met", "voor", "maar", "op"},
+	"sv": {"och", "är", "att", "det", "som", "en", "inte", "jag", "med", "för", "på", "vi", "har", "av", "till", "den", "om"},
+	"pl": {"i", "jest", "nie", "się", "to", "że", "na", "w", "z", "do", "jak", "ale", "tak", "co", "czy", "jestem", "bardzo"},
+	"tr": {"bir", "ve", "bu", "da", "de", "ne", "için", "çok", "ama", "ben", "sen", "var", "yok", "gibi", "daha", "olarak", "mi"},
+	"id": {"yang", "dan", "ini", "itu", "tidak", "saya", "kita", "ada", "dengan", "untuk", "dari", "akan", "juga", "kami", "bisa", "di", "ke"},
+}
+
+// stopwordLangs inverts stopwords.
+var stopwordLangs = func() map[string][]string {
+	m := map[string][]string{}
+	for lang, words := range stopwords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+// DetectLanguage guesses the language of cues' text, returning its ISO
+// 639-1 code, or "" if there is too little text or no language stands out.
+func DetectLanguage(cues []Cue) string {
+	text := Text(cues)
+
+	// A writing system of its own settles the language.
+	letters, cyrillic, counts := 0, 0, make([]int, len(scripts))
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Cyrillic, r) {
+			cyrillic++
+		}
+		for i, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[i]++
+				break
+			}
+		}
+	}
+	if letters < minDetectWords {
+		return ""
+	}
+	// Japanese mixes kana with Han characters, so any kana at all
+	// outweighs them.
+	kana := counts[1] + counts[2]
+	for i, s := range scripts {
+		n := counts[i]
+		if s.lang == "ja" {
+			n = kana
+		}
+		if s.lang == "zh" && kana > 0 {
+			continue
+		}
+		if n*2 > letters {
+			return s.lang
+		}
+	}
+	// Russian and Ukrainian each have letters the other lacks.
+	if cyrillic*2 > letters {
+		ru := strings.Count(text, "ы") + strings.Count(text, "э") + strings.Count(text, "ъ") + strings.Count(text, "ё")
+		uk := strings.Count(text, "і") + strings.Count(text, "ї") + strings.Count(text, "є") + strings.Count(text, "ґ")
+		switch {
+		case uk > ru:
+			return "uk"
+		case ru > uk:
+			return "ru"
+		}
+		return ""
+	}
+
+	// Otherwise count each language's common words.
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) < minDetectWords {
+		return ""
+	}
+	hits := map[string]float64{}
+	for _, w := range words {
+		for _, lang := range stopwordLangs[w] {
+			hits[lang] += 1 / float64(len(stopwordLangs[w]))
+		}
+	}
+	best, second := "", 0.0
+	for lang, n := range hits {
+		switch {
+		case best == "" || n > hits[best] || (n == hits[best] && lang < best):
+			second = max(second, hits[best])
+			best = lang
+		case n > second:
+			second = n
+		}
+	}
+	// The winner has to be common enough to be the language at all, and
+	// clearly ahead of the runner-up.
+	if best == "" || hits[best]*10 < float64(len(words)) || hits[best] < second*1.5 {
+		return ""
+	}
+	return best
+}
+
+// SameLanguage reports whether language codes a and b name the same
+// language, ignoring region and script subtags and case, so en-US and en
+// match.
+func SameLanguage(a, b string) bool {
+	base := func(code string) string {
+		code, _, _ = strings.Cut(strings.ReplaceAll(code, "_", "-"), "-")
+		return strings.ToLower(code)
+	}
+	return base(a) == base(b)
+}
@@ -0,0 +1,220 @@
+package transcript
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Finding severities. Errors are problems YouTube rejects files for or
+// shows wrongly; warnings are captions that are hard to read.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// A Finding is a problem Lint found in a caption file.
+type Finding struct {
+	Severity string `json:"severity"`
+	// Rule names the check, such as "overlap", for filtering findings.
+	Rule string `json:"rule"`
+	// Line is the 1-based line the problem is on, and Cue the 1-based
+	// number of the cue, when it's about one.
+	Line    int    `json:"line,omitempty"`
+	Cue     int    `json:"cue,omitempty"`
+	Message string `json:"message"`
+}
+
+// LintOptions are the limits Lint checks cues against.
+type LintOptions struct {
+	MaxLineChars int
+	MaxLines     int
+	MinDuration  time.Duration
+	MaxDuration  time.Duration
+}
+
+// DefaultLintOptions are common broadcast caption limits: two lines of 42
+// characters, on screen for between 2/3 of a second and 7 seconds.
+var DefaultLintOptions = LintOptions{
+	MaxLineChars: 42,
+	MaxLines:     2,
+	MinDuration:  667 * time.Millisecond,
+	MaxDuration:  7 * time.Second,
+}
+
+// captionTag matches a tag in cue text, and srtTags are those YouTube
+// supports in SRT and SBV captions.
+var (
+	captionTag = regexp.MustCompile(`</?([a-zA-Z]*)[^>]*>`)
+	srtTags    = map[string]bool{"b": true, "i": true, "u": true, "font": true}
+)
+
+// Lint checks caption data before it's uploaded to YouTube: that it's
+// UTF-8 SRT, VTT, or SBV, that every block parses, that cues have text,
+// last a positive time, and come in order, and, as warnings, that they
+// don't overlap and stay within opts' limits.
+func Lint(data []byte, opts LintOptions) []Finding {
+	var findings []Finding
+	add := func(severity, rule string, line, cue int, format string, args ...any) {
+		findings = append(findings, Finding{Severity: severity, Rule: rule, Line: line, Cue: cue, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case len(bytes.TrimSpace(data)) == 0:
+		add(SeverityError, "empty", 0, 0, "file is empty")
+		return findings
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}) || bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		add(SeverityError, "encoding", 0, 0, "file is UTF-16; save it as UTF-8")
+		return findings
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if !utf8.ValidString(line) {
+			add(SeverityError, "encoding", i+1, 0, "line isn't valid UTF-8; save the file as UTF-8")
+			break
+		}
+	}
+
+	format := DetectFormat(data)
+	var timing *regexp.Regexp
+	switch format {
+	case FormatSRT:
+		timing = srtTiming
+	case FormatVTT:
+		timing = vttTiming
+		if !strings.HasPrefix(lines[0], "WEBVTT") {
+			add(SeverityError, "header", 1, 0, "VTT files must start with WEBVTT on the first line")
+		}
+	case FormatSBV:
+		timing = sbvTiming
+	default:
+		add(SeverityError, "format", 0, 0, "not SRT, VTT, or SBV captions (read as %s)", format)
+		return findings
+	}
+
+	var prevStart, prevEnd time.Duration
+	cue, prevIndex := 0, 0
+	for start := 0; start < len(lines); {
+		if strings.TrimSpace(lines[start]) == "" {
+			start++
+			continue
+		}
+		end := start
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+			end++
+		}
+		block := lines[start:end]
+		first := start + 1
+		start = end
+
+		timingLine := -1
+		var m []string
+		for i, line := range block {
+			if m = timing.FindStringSubmatch(line); m != nil {
+				timingLine = i
+				break
+			}
+		}
+		if timingLine < 0 {
+			if format == FormatVTT && vttMetaBlock(block[0]) {
+				continue
+			}
+			malformed := slices.IndexFunc(block, func(line string) bool { return strings.Contains(line, "-->") })
+			if malformed >= 0 {
+				add(SeverityError, "timing", first+malformed, 0, "malformed timing line %q", strings.TrimSpace(block[malformed]))
+			} else {
+				add(SeverityError, "block", first, 0, "block has no timing line, so YouTube can't place it")
+			}
+			continue
+		}
+		line := first + timingLine
+		cueStart, err1 := ParseTimestamp(m[1])
+		cueEnd, err2 := ParseTimestamp(m[2])
+		text := block[timingLine+1:]
+		if cueStart == 0 && cueEnd == 0 && len(text) > 0 && text[0] == "NOTE" {
+			add(SeverityError, "header", line, 0, "ytt header block, as earlier versions wrote; YouTube would show it as a caption, so remove it")
+			continue
+		}
+		cue++
+
+		if format == FormatSRT {
+			switch index, err := strconv.Atoi(strings.TrimSpace(block[0])); {
+			case timingLine != 1 || err != nil:
+				add(SeverityWarning, "index", first, cue, "cue %d has no number before its timing line", cue)
+			case index != prevIndex+1:
+				add(SeverityWarning, "index", first, cue, "cue numbered %d follows cue %d", index, prevIndex)
+				prevIndex = index
+			default:
+				prevIndex = index
+			}
+			if strings.Contains(m[1], ".") || strings.Contains(m[2], ".") {
+				add(SeverityWarning, "timing", line, cue, "SRT times separate milliseconds with \",\", not \".\"")
+			}
+		}
+
+		if err := errors.Join(err1, err2); err != nil {
+			add(SeverityError, "timing", line, cue, "%v", err)
+			continue
+		}
+		switch {
+		case cueEnd <= cueStart:
+			add(SeverityError, "duration", line, cue, "cue %d ends at %s, not after it starts at %s", cue, FormatTimestamp(cueEnd), FormatTimestamp(cueStart))
+		case cueStart < prevStart:
+			add(SeverityError, "order", line, cue, "cue %d starts at %s, before the cue ahead of it", cue, FormatTimestamp(cueStart))
+		case cueStart < prevEnd:
+			add(SeverityWarning, "overlap", line, cue, "cue %d starts at %s, before the cue ahead of it ends at %s", cue, FormatTimestamp(cueStart), FormatTimestamp(prevEnd))
+		}
+		if d := cueEnd - cueStart; d > 0 && opts.MaxDuration > 0 && d > opts.MaxDuration {
+			add(SeverityWarning, "too_long", line, cue, "cue %d lasts %v, over %v", cue, d, opts.MaxDuration)
+		} else if d > 0 && d < opts.MinDuration {
+			add(SeverityWarning, "too_short", line, cue, "cue %d lasts %v, too briefly to read", cue, d)
+		}
+		prevStart, prevEnd = cueStart, max(prevEnd, cueEnd)
+
+		if len(text) == 0 {
+			add(SeverityWarning, "empty_cue", line, cue, "cue %d has no text", cue)
+			continue
+		}
+		if opts.MaxLines > 0 && len(text) > opts.MaxLines {
+			add(SeverityWarning, "lines", line+1, cue, "cue %d has %d lines, over %d", cue, len(text), opts.MaxLines)
+		}
+		for i, t := range text {
+			if n := utf8.RuneCountInString(vttTag.ReplaceAllString(t, "")); opts.MaxLineChars > 0 && n > opts.MaxLineChars {
+				add(SeverityWarning, "line_length", line+1+i, cue, "line is %d characters, over %d", n, opts.MaxLineChars)
+			}
+			if format == FormatVTT {
+				continue
+			}
+			seen := map[string]bool{}
+			for _, tag := range captionTag.FindAllStringSubmatch(t, -1) {
+				name := strings.ToLower(tag[1])
+				if !srtTags[name] && !seen[name] {
+					seen[name] = true
+					add(SeverityWarning, "markup", line+1+i, cue, "YouTube shows the unsupported tag %s as text", tag[0])
+				}
+			}
+		}
+	}
+	if cue == 0 {
+		add(SeverityError, "no_cues", 0, 0, "no cues found")
+	}
+	return findings
+}
+
+// vttMetaBlock reports whether a VTT block starting with line holds
+// something other than a cue.
+func vttMetaBlock(line string) bool {
+	for _, kw := range []string{"WEBVTT", "NOTE", "STYLE", "REGION"} {
+		if line == kw || strings.HasPrefix(line, kw+" ") || strings.HasPrefix(line, kw+"\t") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package transcript
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// want lists findings as "severity rule line".
+		want []string
+	}{
+		{"clean srt", "1\n00:00:01,000 --> 00:00:03,000\nHello there.\n\n2\n00:00:03,000 --> 00:00:05,000\nGeneral Kenobi.\n", nil},
+		{"clean vtt", "WEBVTT\nKind: captions\n\nNOTE a comment\n\n00:00:01.000 --> 00:00:03.000 align:start\nHello.\n", nil},
+		{"clean sbv", "0:00:01.000,0:00:03.000\nHello.\n\n0:00:03.000,0:00:05.000\nAgain.\n", nil},
+		{"empty", "\n\n", []string{"error empty 0"}},
+		{"utf-16", "\xff\xfe1\x00", []string{"error encoding 0"}},
+		{"invalid utf-8", "1\n00:00:01,000 --> 00:00:03,000\nbad \xff byte\n", []string{"error encoding 3"}},
+		{"not captions", "just some text\n", []string{"error format 0"}},
+		{"vtt header not first", "\nWEBVTT\n\n00:00:01.000 --> 00:00:03.000\nHi.\n", []string{"error header 1"}},
+		{
+			"timing problems",
+			"1\n00:00:05,000 --> 00:00:04,000\nBackwards.\n\n2\n00:00:03,000 --> 00:00:06,000\nEarlier.\n\n3\n00:00:05,500 --> 00:00:07,000\nOverlapping.\n",
+			[]string{"error duration 2", "error order 6", "warning overlap 10"},
+		},
+		{
+			"malformed blocks",
+			"1\n00:00:01,000 --> 00:00:03,000\nFine.\n\n2\n00:00:04 --> 00:00:05\nBad timing.\n\nStray text\n",
+			[]string{"error timing 6", "error block 9"},
+		},
+		{
+			"numbering and separators",
+			"1\n00:00:01.000 --> 00:00:03,000\nDots.\n\n3\n00:00:03,000 --> 00:00:05,000\nSkipped.\n",
+			[]string{"warning timing 2", "warning index 5"},
+		},
+		{
+			"limits",
+			"1\n00:00:01,000 --> 00:00:10,000\none\ntwo\nthis third line is much longer than forty-two characters\n\n2\n00:00:10,000 --> 00:00:10,200\n<span>short</span>\n",
+			[]string{"warning too_long 2", "warning lines 3", "warning line_length 5", "warning too_short 8", "warning markup 9"},
+		},
+		{
+			"ytt header block",
+			"0\n00:00:00,000 --> 00:00:00,000\nNOTE\nvideo_id: abc\n\n1\n00:00:01,000 --> 00:00:03,000\nHi.\n",
+			[]string{"error header 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range Lint([]byte(tt.data), DefaultLintOptions) {
+				got = append(got, fmt.Sprintf("%s %s %d", f.Severity, f.Rule, f.Line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The Markdown format is for notes and documentation sites: the source's
+// metadata as front matter, the title as a heading, and a paragraph per
+// cue starting with its timestamp, linked to that moment of the video when
+// the video is known. Lines within a cue stay on separate lines, which
+// Markdown renders as one paragraph. Cues end where the next one starts,
+// since only start times are written.
+
+// mdTimestamp matches the timestamp starting a paragraph, linked or not.
+var mdTimestamp = regexp.MustCompile(`^\[((?:\d+:)?\d+:\d{2})\](?:\([^)\s]*\))? `)
+
+// mdSpeaker matches the speaker label after the timestamp.
+var mdSpeaker = regexp.MustCompile(`^\*\*([^*]+):\*\* `)
+
+func formatMarkdown(meta Metadata, cues []Cue) []byte {
+	var b bytes.Buffer
+	if fm := meta.FrontMatter(); fm != nil {
+		b.Write(fm)
+		b.WriteByte('\n')
+	}
+	if title := meta.Get("title"); title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", strings.Join(strings.Fields(title), " "))
+	}
+	videoID := meta.Get("video_id")
+	withTimes := timed(cues)
+	for i, c := range cues {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if withTimes {
+			stamp := "[" + FormatTimestamp(c.Start) + "]"
+			if videoID != "" {
+				stamp += "(" + WatchURL(videoID, c.Start) + ")"
+			}
+			b.WriteString(stamp + " ")
+		}
+		if c.Speaker != "" {
+			fmt.Fprintf(&b, "**%s:** ", c.Speaker)
+		}
+		b.WriteString(c.Text + "\n")
+	}
+	return b.Bytes()
+}
+
+// isMarkdown reports whether text starts like a file written by
+// formatMarkdown, with front matter, a heading, or a timestamp.
+func isMarkdown(text []byte) bool {
+	return bytes.HasPrefix(text, []byte("---\n")) || bytes.HasPrefix(text, []byte("# ")) || mdTimestamp.Match(text)
+}
+
+// cutFrontMatter returns text after its YAML front matter, and whether it
+// had any.
+func cutFrontMatter(text []byte) ([]byte, bool) {
+	rest, ok := bytes.CutPrefix(text, []byte("---\n"))
+	if !ok {
+		return text, false
+	}
+	_, body, ok := bytes.Cut(rest, []byte("\n---\n"))
+	if !ok {
+		return text, false
+	}
+	return bytes.TrimLeft(body, "\n"), true
+}
+
+func parseMarkdown(text string) ([]Cue, error) {
+	if body, ok := cutFrontMatter([]byte(text)); ok {
+		text = string(body)
+	}
+
+	var cues []Cue
+	var para []string
+	flush := func() error {
+		if len(para) == 0 {
+			return nil
+		}
+		body := strings.Join(para, "\n")
+		para = nil
+		var c Cue
+		if m := mdTimestamp.FindStringSubmatch(body); m != nil {
+			start, err := ParseTimestamp(m[1])
+			if err != nil {
+				return err
+			}
+			c.Start = start
+			body = body[len(m[0]):]
+		}
+		if m := mdSpeaker.FindStringSubmatch(body); m != nil {
+			c.Speaker = m[1]
+			body = body[len(m[0]):]
+		}
+		if c.Text = strings.TrimSpace(body); c.Text != "" {
+			cues = append(cues, c)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		switch {
+		case line == "":
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case len(para) == 0 && strings.HasPrefix(line, "#"):
+			// Headings aren't part of the transcript.
+		default:
+			para = append(para, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading Markdown: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	// Each cue lasts until the next one starts.
+	for i := range cues {
+		if i+1 < len(cues) && cues[i+1].Start > cues[i].Start {
+			cues[i].End = cues[i+1].Start
+		} else {
+			cues[i].End = cues[i].Start
+		}
+	}
+	return cues, nil
+}
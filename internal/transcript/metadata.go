@@ -0,0 +1,150 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Field is one item of metadata describing a transcript's source.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Metadata is an ordered list of fields describing a transcript's source.
+// Every output format that can carry metadata embeds the same fields in the
+// same order, so any saved file identifies the video it came from.
+type Metadata []Field
+
+// Metadata returns the fields describing the source, leaving out those that
+// are unknown.
+func (s Source) Metadata() Metadata {
+	var m Metadata
+	add := func(key, value string) {
+		if value != "" {
+			m = append(m, Field{key, value})
+		}
+	}
+	add("video_id", s.VideoID)
+	add("title", s.Title)
+	add("channel_id", s.ChannelID)
+	if !s.PublishedAt.IsZero() {
+		add("published_at", s.PublishedAt.UTC().Format(time.RFC3339))
+	}
+	add("language", s.Language)
+	if s.VideoID != "" {
+		add("url", "https://youtu.be/"+s.VideoID)
+	}
+	return m
+}
+
+// Header returns the fields of a header block: the source's metadata, then
+// the channel's name, the caption track's kind, when the captions were
+// downloaded, and the program that wrote the file, leaving out those that
+// are unknown.
+func (s Source) Header(downloadedAt time.Time, generator string) Metadata {
+	m := s.Metadata()
+	add := func(key, value string) {
+		if value != "" {
+			m = append(m, Field{key, value})
+		}
+	}
+	add("channel", s.Channel)
+	add("track_kind", s.TrackKind)
+	if !downloadedAt.IsZero() {
+		add("downloaded_at", downloadedAt.UTC().Format(time.RFC3339))
+	}
+	add("generator", generator)
+	return m
+}
+
+// Get returns the value of the field named key, or "" if there is none.
+func (m Metadata) Get(key string) string {
+	for _, f := range m {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// FrontMatter renders the fields as a YAML front matter block, as used by
+// Markdown files.
+func (m Metadata) FrontMatter() []byte {
+	if len(m) == 0 {
+		return nil
+	}
+	var b bytes.Buffer
+	b.WriteString("---\n")
+	for _, f := range m {
+		// A JSON string is a valid YAML double-quoted scalar.
+		value, _ := json.Marshal(f.Value)
+		fmt.Fprintf(&b, "%s: %s\n", f.Key, value)
+	}
+	b.WriteString("---\n")
+	return b.Bytes()
+}
+
+// vttNote renders the fields as a VTT NOTE block, which players ignore.
+func (m Metadata) vttNote() string {
+	var b strings.Builder
+	b.WriteString("NOTE\n")
+	for _, f := range m {
+		// A NOTE block ends at a blank line and can't contain "-->".
+		value := strings.ReplaceAll(strings.Join(strings.Fields(f.Value), " "), "-->", "->")
+		fmt.Fprintf(&b, "%s: %s\n", f.Key, value)
+	}
+	return b.String()
+}
+
+// MarshalJSON encodes the fields as a JSON object, keeping their order.
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range m {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON object of strings, keeping the order of its
+// fields. null leaves m unset.
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("metadata is not a JSON object")
+	}
+	*m = nil
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value string
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		*m = append(*m, Field{tok.(string), value})
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package transcript
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatSource(t *testing.T) {
+	src := Source{
+		VideoID:     "abc123",
+		Title:       `A "quoted" --> title`,
+		PublishedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Language:    "en",
+	}
+	cues := []Cue{{Start: time.Second, End: 2 * time.Second, Text: "hi"}}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatVTT, "WEBVTT\n\nNOTE\nvideo_id: abc123\ntitle: A \"quoted\" -> title\npublished_at: 2024-03-01T12:00:00Z\nlanguage: en\nurl: https://youtu.be/abc123\n\n00:00:01.000 --> 00:00:02.000\nhi\n"},
+		{FormatSRT, "1\n00:00:01,000 --> 00:00:02,000\nhi\n"},
+	}
+	for _, tt := range tests {
+		got, err := tt.format.FormatSource(src, cues)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("FormatSource(%s) = %q, want %q", tt.format, got, tt.want)
+		}
+		// Metadata doesn't get in the way of reading the cues back.
+		parsed, err := Parse(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(parsed, cues) {
+			t.Errorf("Parse(FormatSource(%s)) = %+v, want %+v", tt.format, parsed, cues)
+		}
+	}
+}
+
+func TestFormatHeader(t *testing.T) {
+	src := Source{VideoID: "abc123", Title: "Talk", Language: "en", Channel: "Gophers", TrackKind: "asr"}
+	meta := src.Header(time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC), "ytt 1.2.3")
+	note := "NOTE\nvideo_id: abc123\ntitle: Talk\nlanguage: en\nurl: https://youtu.be/abc123\nchannel: Gophers\ntrack_kind: asr\ndownloaded_at: 2024-03-02T08:00:00Z\ngenerator: ytt 1.2.3\n"
+	cues := []Cue{{Start: time.Second, End: 2 * time.Second, Text: "hi"}}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatVTT, "WEBVTT\n\n" + note + "\n00:00:01.000 --> 00:00:02.000\nhi\n"},
+		// SRT and SBV have no room for a header.
+		{FormatSRT, "1\n00:00:01,000 --> 00:00:02,000\nhi\n"},
+		{FormatSBV, "0:00:01.000,0:00:02.000\nhi\n"},
+		{FormatPlain, "---\nvideo_id: \"abc123\"\ntitle: \"Talk\"\nlanguage: \"en\"\nurl: \"https://youtu.be/abc123\"\nchannel: \"Gophers\"\ntrack_kind: \"asr\"\ndownloaded_at: \"2024-03-02T08:00:00Z\"\ngenerator: \"ytt 1.2.3\"\n---\n\nhi\n"},
+	}
+	for _, tt := range tests {
+		got, err := tt.format.FormatHeader(meta, cues)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("FormatHeader(%s) = %q, want %q", tt.format, got, tt.want)
+		}
+		// The header is skipped when reading the cues back.
+		if f := DetectFormat(got); f != tt.format {
+			t.Errorf("DetectFormat(FormatHeader(%s)) = %s", tt.format, f)
+		}
+		parsed, err := Parse(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := cues
+		if tt.format == FormatPlain {
+			want = []Cue{{Text: "hi"}}
+		}
+		if !reflect.DeepEqual(parsed, want) {
+			t.Errorf("Parse(FormatHeader(%s)) = %+v, want %+v", tt.format, parsed, want)
+		}
+	}
+
+	// Without metadata there is no header.
+	if got, _ := FormatVTT.FormatHeader(nil, cues); string(got) != "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nhi\n" {
+		t.Errorf("FormatHeader(vtt) without metadata = %q", got)
+	}
+
+	// Header cues written into SRT and SBV by earlier versions are skipped.
+	for _, in := range []string{
+		"0\n00:00:00,000 --> 00:00:00,000\n" + note + "\n1\n00:00:01,000 --> 00:00:02,000\nhi\n",
+		"0:00:00.000,0:00:00.000\n" + note + "\n0:00:01.000,0:00:02.000\nhi\n",
+	} {
+		parsed, err := Parse([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(parsed, cues) {
+			t.Errorf("Parse(%q) = %+v, want %+v", in, parsed, cues)
+		}
+	}
+}
+
+func TestMetadataJSON(t *testing.T) {
+	src := Source{VideoID: "abc123", Title: "T", ChannelID: "UC1"}
+	data, err := FormatJSON.FormatSource(src, []Cue{{Start: time.Second, End: 2 * time.Second, Text: "hi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var in jsonTranscript
+	if err := json.Unmarshal(data, &in); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in.Source, src.Metadata()) {
+		t.Errorf("source = %+v, want %+v in order", in.Source, src.Metadata())
+	}
+
+	in = jsonTranscript{}
+	if err := json.Unmarshal([]byte(`{"source": null, "cues": [{"start": 1, "end": 2, "text": "hi"}]}`), &in); err != nil {
+		t.Fatalf("Unmarshal() with a null source: %v", err)
+	}
+	if in.Source != nil || len(in.Cues) != 1 {
+		t.Errorf("null source = %+v with %d cues, want no source and 1 cue", in.Source, len(in.Cues))
+	}
+}
+
+func TestFrontMatter(t *testing.T) {
+	got := string(Source{VideoID: "abc123", Title: `Say "hi"`}.Metadata().FrontMatter())
+	want := "---\nvideo_id: \"abc123\"\ntitle: \"Say \\\"hi\\\"\"\nurl: \"https://youtu.be/abc123\"\n---\n"
+	if got != want {
+		t.Errorf("FrontMatter() = %q, want %q", got, want)
+	}
+	if got := (Source{}).Metadata().FrontMatter(); got != nil {
+		t.Errorf("FrontMatter() of empty source = %q, want nothing", got)
+	}
+}
+
+func TestFormatHTML(t *testing.T) {
+	src := Source{VideoID: "abc123", Title: `Tips & <tricks>`, Language: "en"}
+	cues := []Cue{
+		{Start: 65 * time.Second, End: 67 * time.Second, Text: "a </script> tag\nand more", Speaker: "Ann"},
+		{Start: 70 * time.Second, End: 72 * time.Second, Text: "bye"},
+	}
+	got, err := FormatHTML.FormatSource(src, cues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(got)
+	for _, want := range []string{
+		`<html lang="en">`,
+		`<title>Tips &amp; &lt;tricks&gt;</title>`,
+		`<a href="https://youtu.be/abc123?t=65">1:05</a>`,
+		`<span class="speaker">Ann:</span> <span class="text">a &lt;/script&gt; tag<br>and more</span>`,
+		`<input type="search"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page is missing %q:\n%s", want, page)
+		}
+	}
+	if n := strings.Count(page, "</script>"); n != 2 {
+		t.Errorf("page has %d </script> tags, want 2 (cue text must be escaped)", n)
+	}
+
+	if f := DetectFormat(got); f != FormatHTML {
+		t.Errorf("DetectFormat = %q, want html", f)
+	}
+	parsed, err := Parse(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, cues) {
+		t.Errorf("Parse(FormatHTML) = %+v, want %+v", parsed, cues)
+	}
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	src := Source{VideoID: "abc123", Title: "Tips\nand tricks"}
+	cues := []Cue{
+		{Start: 65 * time.Second, End: 70 * time.Second, Text: "first line\nsecond line", Speaker: "Ann"},
+		{Start: 70 * time.Second, End: 72 * time.Second, Text: "bye"},
+	}
+	got, err := FormatMD.FormatSource(src, cues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `---
+video_id: "abc123"
+title: "Tips\nand tricks"
+url: "https://youtu.be/abc123"
+---
+
+# Tips and tricks
+
+[1:05](https://youtu.be/abc123?t=65) **Ann:** first line
+second line
+
+[1:10](https://youtu.be/abc123?t=70) bye
+`
+	if string(got) != want {
+		t.Errorf("FormatMD = %s\nwant %s", got, want)
+	}
+
+	if f := DetectFormat(got); f != FormatMD {
+		t.Errorf("DetectFormat = %q, want md", f)
+	}
+	parsed, err := Parse(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only start times are written, so the last cue has no duration.
+	cues[1].End = cues[1].Start
+	if !reflect.DeepEqual(parsed, cues) {
+		t.Errorf("Parse(FormatMD) = %+v, want %+v", parsed, cues)
+	}
+
+	// Without a video, timestamps aren't linked; untimed cues have none.
+	plain, err := FormatMD.Format([]Cue{{Start: 3 * time.Second, End: 4 * time.Second, Text: "hi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plain) != "[0:03] hi\n" {
+		t.Errorf("FormatMD without a source = %q", plain)
+	}
+	if f := DetectFormat(plain); f != FormatMD {
+		t.Errorf("DetectFormat(%q) = %q, want md", plain, f)
+	}
+	untimed, err := FormatMD.Format([]Cue{{Text: "hi"}, {Text: "there"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(untimed) != "hi\n\nthere\n" {
+		t.Errorf("FormatMD of untimed cues = %q", untimed)
+	}
+}
+
+func TestFormatBook(t *testing.T) {
+	src := Source{VideoID: "abc123", Title: "Talk", Language: "en"}
+	cues := []Cue{{Start: time.Second, End: 2 * time.Second, Text: "hi", Speaker: "Ann"}}
+	for _, f := range []Format{FormatPDF, FormatEPUB, FormatDOCX} {
+		got, err := f.FormatSource(src, cues)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := DetectFormat(got); d != f {
+			t.Errorf("DetectFormat(%s output) = %q", f, d)
+		}
+		parsed, err := Parse(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(parsed, cues) {
+			t.Errorf("Parse(%s) = %+v, want %+v", f, parsed, cues)
+		}
+	}
+}
+
+func TestUntimed(t *testing.T) {
+	cues := []Cue{{Start: 65 * time.Second, End: 67 * time.Second, Text: "hi", Words: []Word{{Start: 65 * time.Second, Text: "hi"}}}}
+	untimed, err := Untimed(Source{}, cues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Cue{{Text: "hi"}}; !reflect.DeepEqual(untimed, want) {
+		t.Errorf("Untimed = %+v, want %+v", untimed, want)
+	}
+	if cues[0].Start == 0 {
+		t.Error("Untimed modified its input")
+	}
+
+	// Formats that show timestamps leave them out.
+	src := Source{VideoID: "abc123"}
+	page, err := FormatHTML.FormatSource(src, untimed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(page), "?t=") {
+		t.Errorf("HTML of untimed cues links to timestamps:\n%s", page)
+	}
+	if p := BookChapter(src.Metadata(), untimed).Paragraphs[0]; p.Time != "" || p.URL != "" {
+		t.Errorf("book paragraph of an untimed cue = %+v, want no timestamp", p)
+	}
+}
@@ -0,0 +1,568 @@
+package transcript
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Source identifies the video a transcript belongs to, for processors that
+// need it.
+type Source struct {
+	VideoID     string
+	Title       string
+	ChannelID   string
+	PublishedAt time.Time
+	// Language is the caption track's language code.
+	Language string
+	// Channel is the channel's name, and TrackKind the caption track's
+	// kind, such as "standard" or "asr". Only header blocks include them.
+	Channel   string
+	TrackKind string
+}
+
+// A Processor transforms a transcript's cues. Processors must not modify
+// the slice they are given in place.
+type Processor interface {
+	Process(src Source, cues []Cue) ([]Cue, error)
+}
+
+// ProcessorFunc adapts a function to a Processor.
+type ProcessorFunc func(src Source, cues []Cue) ([]Cue, error)
+
+// Process implements Processor.
+func (f ProcessorFunc) Process(src Source, cues []Cue) ([]Cue, error) {
+	return f(src, cues)
+}
+
+// Pipeline runs processors in order, feeding each the previous one's output.
+type Pipeline []Processor
+
+// Process implements Processor.
+func (p Pipeline) Process(src Source, cues []Cue) ([]Cue, error) {
+	for _, proc := range p {
+		var err error
+		if cues, err = proc.Process(src, cues); err != nil {
+			return nil, err
+		}
+	}
+	return cues, nil
+}
+
+// A ProcessorFactory builds a processor from the argument given after "=" in
+// a pipeline spec, which is empty when there is none.
+type ProcessorFactory func(arg string) (Processor, error)
+
+var processors = map[string]ProcessorFactory{
+	"dedupe":           noArg(ProcessorFunc(Dedupe)),
+	"paragraphs":       paragraphsFactory,
+	"wrap":             wrapFactory,
+	"max-chars":        maxCharsFactory,
+	"max-duration":     maxDurationFactory,
+	"redact":           redactFactory,
+	"timestamps-links": noArg(ProcessorFunc(TimestampLinks)),
+	"profanity-mask":   noArg(ProcessorFunc(MaskProfanity)),
+	"untimed":          noArg(ProcessorFunc(Untimed)),
+}
+
+// RegisterProcessor makes a processor available to ParsePipeline by name.
+// It panics if the name is already registered.
+func RegisterProcessor(name string, factory ProcessorFactory) {
+	if _, ok := processors[name]; ok {
+		panic("transcript: processor " + name + " already registered")
+	}
+	processors[name] = factory
+}
+
+// ProcessorNames returns the registered processor names, sorted.
+func ProcessorNames() []string {
+	names := make([]string, 0, len(processors))
+	for name := range processors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParsePipeline builds a pipeline from a comma-separated list of processor
+// names, each optionally followed by "=arg", such as "dedupe,wrap=72".
+func ParsePipeline(spec string) (Pipeline, error) {
+	var p Pipeline
+	for _, step := range strings.Split(spec, ",") {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(step, "=")
+		factory, ok := processors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown processor %q (available: %s)", name, strings.Join(ProcessorNames(), ", "))
+		}
+		proc, err := factory(arg)
+		if err != nil {
+			return nil, fmt.Errorf("processor %s: %w", name, err)
+		}
+		p = append(p, proc)
+	}
+	return p, nil
+}
+
+func noArg(p Processor) ProcessorFactory {
+	return func(arg string) (Processor, error) {
+		if arg != "" {
+			return nil, fmt.Errorf("takes no argument")
+		}
+		return p, nil
+	}
+}
+
+// Dedupe removes the repetition in YouTube's auto-generated captions, where
+// each cue repeats the previous cue's last line before adding a new one and
+// identical cues are sometimes emitted back to back. Repeated lines are
+// dropped and cues left empty are merged into the previous cue's timing.
+func Dedupe(_ Source, cues []Cue) ([]Cue, error) {
+	var out []Cue
+	var prev []string
+	for _, c := range cues {
+		lines := strings.Split(c.Text, "\n")
+		n := overlap(prev, lines)
+		prev = lines
+
+		rest := strings.TrimSpace(strings.Join(lines[n:], "\n"))
+		if rest == "" {
+			if len(out) > 0 {
+				out[len(out)-1].End = max(out[len(out)-1].End, c.End)
+			}
+			continue
+		}
+		c.Text = rest
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// overlap returns the largest n such that the first n lines of cur equal the
+// last n lines of prev, ignoring surrounding whitespace.
+func overlap(prev, cur []string) int {
+	for n := min(len(prev), len(cur)); n > 0; n-- {
+		match := true
+		for i := range n {
+			if strings.TrimSpace(prev[len(prev)-n+i]) != strings.TrimSpace(cur[i]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return n
+		}
+	}
+	return 0
+}
+
+// DefaultParagraphGap is the pause between cues that starts a new paragraph.
+const DefaultParagraphGap = 2 * time.Second
+
+// paragraphMinLength is the length after which a sentence end also starts a
+// new paragraph, so pause-free speech doesn't become one huge paragraph.
+const paragraphMinLength = 400
+
+var sentenceEnd = regexp.MustCompile(`[.!?]["')\]]*$`)
+
+// Paragraphs returns a processor that reflows cues into paragraphs. A new
+// paragraph starts when the speaker changes, after a pause of at least gap,
+// or at the end of a sentence once the current paragraph is long.
+func Paragraphs(gap time.Duration) Processor {
+	return ProcessorFunc(func(_ Source, cues []Cue) ([]Cue, error) {
+		var out []Cue
+		for i, c := range cues {
+			text := strings.Join(strings.Fields(c.Text), " ")
+			if text == "" {
+				continue
+			}
+			if len(out) > 0 {
+				last := &out[len(out)-1]
+				long := len(last.Text) >= paragraphMinLength && sentenceEnd.MatchString(last.Text)
+				if c.Start-cues[i-1].End < gap && !long && c.Speaker == last.Speaker {
+					last.Text += " " + text
+					last.End = max(last.End, c.End)
+					last.Words = append(last.Words, c.Words...)
+					continue
+				}
+			}
+			out = append(out, Cue{Start: c.Start, End: c.End, Text: text, Words: slices.Clip(c.Words), Speaker: c.Speaker, Raw: c.Raw})
+		}
+		return out, nil
+	})
+}
+
+func paragraphsFactory(arg string) (Processor, error) {
+	if arg == "" {
+		return Paragraphs(DefaultParagraphGap), nil
+	}
+	gap, err := time.ParseDuration(arg)
+	if err != nil || gap < 0 {
+		return nil, fmt.Errorf("invalid gap %q", arg)
+	}
+	return Paragraphs(gap), nil
+}
+
+// DefaultWrapWidth is the column wrap wraps at when no width is given.
+const DefaultWrapWidth = 80
+
+// Wrap returns a processor that hard-wraps cue text at width columns,
+// breaking between words. Words longer than width are left whole.
+func Wrap(width int) Processor {
+	return ProcessorFunc(func(_ Source, cues []Cue) ([]Cue, error) {
+		out := make([]Cue, len(cues))
+		for i, c := range cues {
+			c.Text = wrapText(c.Text, width)
+			out[i] = c
+		}
+		return out, nil
+	})
+}
+
+func wrapFactory(arg string) (Processor, error) {
+	if arg == "" {
+		return Wrap(DefaultWrapWidth), nil
+	}
+	width, err := strconv.Atoi(arg)
+	if err != nil || width < 1 {
+		return nil, fmt.Errorf("invalid width %q", arg)
+	}
+	return Wrap(width), nil
+}
+
+func wrapText(text string, width int) string {
+	var b strings.Builder
+	col := 0
+	for _, word := range strings.Fields(text) {
+		n := len([]rune(word))
+		switch {
+		case col == 0:
+		case col+1+n > width:
+			b.WriteByte('\n')
+			col = 0
+		default:
+			b.WriteByte(' ')
+			col++
+		}
+		b.WriteString(word)
+		col += n
+	}
+	return b.String()
+}
+
+// DefaultMaxCueChars and DefaultMaxCueDuration are the limits max-chars
+// and max-duration split cues at when none is given: two 42-character
+// lines, and the longest a caption usually stays on screen.
+const (
+	DefaultMaxCueChars    = 84
+	DefaultMaxCueDuration = 7 * time.Second
+)
+
+// MaxChars returns a processor that splits cues longer than n characters
+// into consecutive cues, breaking between words and sharing out the cue's
+// time by length. Words longer than n are left whole.
+func MaxChars(n int) Processor {
+	return ProcessorFunc(func(_ Source, cues []Cue) ([]Cue, error) {
+		var out []Cue
+		for _, c := range cues {
+			var chunks [][]string
+			length := 0
+			for _, word := range strings.Fields(c.Text) {
+				w := len([]rune(word))
+				if len(chunks) == 0 || length+1+w > n {
+					chunks = append(chunks, nil)
+					length = -1
+				}
+				chunks[len(chunks)-1] = append(chunks[len(chunks)-1], word)
+				length += 1 + w
+			}
+			out = append(out, splitCue(c, chunks)...)
+		}
+		return out, nil
+	})
+}
+
+func maxCharsFactory(arg string) (Processor, error) {
+	if arg == "" {
+		return MaxChars(DefaultMaxCueChars), nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid length %q", arg)
+	}
+	return MaxChars(n), nil
+}
+
+// MaxDuration returns a processor that splits cues lasting longer than d
+// into as many consecutive cues as it takes, of about the same length,
+// breaking between words and sharing out the cue's time by length. A cue
+// with fewer words than that is split into one cue per word.
+func MaxDuration(d time.Duration) Processor {
+	return ProcessorFunc(func(_ Source, cues []Cue) ([]Cue, error) {
+		var out []Cue
+		for _, c := range cues {
+			words := strings.Fields(c.Text)
+			parts := int((c.End - c.Start + d - 1) / d)
+			if parts <= 1 || len(words) <= 1 {
+				out = append(out, c)
+				continue
+			}
+			parts = min(parts, len(words))
+			total := len([]rune(strings.Join(words, " ")))
+			chunks := make([][]string, 1, parts)
+			length := 0
+			for i, word := range words {
+				// Start the next chunk once this one has its share, as
+				// long as enough words are left to fill the rest.
+				if length >= total*len(chunks)/parts && len(chunks) < parts || len(words)-i == parts-len(chunks) {
+					chunks = append(chunks, nil)
+				}
+				chunks[len(chunks)-1] = append(chunks[len(chunks)-1], word)
+				length += len([]rune(word)) + 1
+			}
+			out = append(out, splitCue(c, chunks)...)
+		}
+		return out, nil
+	})
+}
+
+func maxDurationFactory(arg string) (Processor, error) {
+	if arg == "" {
+		return MaxDuration(DefaultMaxCueDuration), nil
+	}
+	d, err := time.ParseDuration(arg)
+	if err != nil || d <= 0 {
+		return nil, fmt.Errorf("invalid duration %q", arg)
+	}
+	return MaxDuration(d), nil
+}
+
+// splitCue splits a cue into one cue per chunk of its words. Each cue
+// starts when its first word does, if the cue has word timings for it, and
+// otherwise the cue's time is shared out by the chunks' lengths. Word
+// timings go to the cue they start in. A cue that stays whole is returned
+// unchanged.
+func splitCue(c Cue, chunks [][]string) []Cue {
+	if len(chunks) <= 1 {
+		return []Cue{c}
+	}
+	// Note when each word with a timing starts, by its index in the text.
+	starts := map[int]time.Duration{}
+	n := 0
+	for _, w := range c.Words {
+		starts[n] = w.Start
+		n += len(strings.Fields(w.Text))
+	}
+	if n != len(strings.Fields(c.Text)) {
+		starts = nil
+	}
+
+	texts := make([]string, len(chunks))
+	total := 0
+	for i, chunk := range chunks {
+		texts[i] = strings.Join(chunk, " ")
+		total += len([]rune(texts[i]))
+	}
+	out := make([]Cue, len(chunks))
+	start, done, words := c.Start, 0, 0
+	for i, text := range texts {
+		done += len([]rune(text))
+		words += len(chunks[i])
+		end := c.Start + (c.End-c.Start)*time.Duration(done)/time.Duration(total)
+		if t, ok := starts[words]; ok && t > start && t < c.End {
+			end = t
+		}
+		last := i == len(texts)-1
+		if last {
+			end = c.End
+		}
+		out[i] = Cue{Start: start, End: end, Text: text, Speaker: c.Speaker, Settings: c.Settings, Raw: c.Raw}
+		for _, w := range c.Words {
+			if (i == 0 || w.Start >= start) && (last || w.Start < end) {
+				out[i].Words = append(out[i].Words, w)
+			}
+		}
+		start = end
+	}
+	return out
+}
+
+// TimestampLinks prefixes each cue with a Markdown link to the video at the
+// cue's start time, such as "[1:02](https://youtu.be/abc?t=62) ".
+func TimestampLinks(src Source, cues []Cue) ([]Cue, error) {
+	if src.VideoID == "" {
+		return nil, fmt.Errorf("timestamp links need a video ID")
+	}
+	out := make([]Cue, len(cues))
+	for i, c := range cues {
+		c.Text = fmt.Sprintf("[%s](%s) %s", FormatTimestamp(c.Start), WatchURL(src.VideoID, c.Start), c.Text)
+		out[i] = c
+	}
+	return out, nil
+}
+
+// Untimed drops the cues' start and end times and word timings, so formats
+// that show timestamps beside the text, such as HTML and the book formats,
+// leave them out.
+func Untimed(_ Source, cues []Cue) ([]Cue, error) {
+	out := make([]Cue, len(cues))
+	for i, c := range cues {
+		c.Start, c.End, c.Words = 0, 0, nil
+		out[i] = c
+	}
+	return out, nil
+}
+
+// timed reports whether any cue has a time, as cues parsed from plain text
+// or processed by Untimed don't.
+func timed(cues []Cue) bool {
+	for _, c := range cues {
+		if c.Start != 0 || c.End != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+var profanity = regexp.MustCompile(`(?i)\b(fuck\w*|shit\w*|bitch\w*|cunt\w*|asshole\w*|motherfuck\w*)\b`)
+
+// MaskProfanity replaces all but the first letter of common profanities
+// with asterisks.
+func MaskProfanity(_ Source, cues []Cue) ([]Cue, error) {
+	return replaceText(cues, func(s string) string {
+		return profanity.ReplaceAllStringFunc(s, maskWord)
+	}), nil
+}
+
+// maskWord replaces all but the first letter of w with asterisks.
+func maskWord(w string) string {
+	r := []rune(w)
+	return string(r[0]) + strings.Repeat("*", len(r)-1)
+}
+
+// replaceText rewrites the text of cues and their words with replace.
+func replaceText(cues []Cue, replace func(string) string) []Cue {
+	out := make([]Cue, len(cues))
+	for i, c := range cues {
+		c.Text = replace(c.Text)
+		if c.Words != nil {
+			words := make([]Word, len(c.Words))
+			for j, w := range c.Words {
+				words[j] = Word{Start: w.Start, Text: replace(w.Text)}
+			}
+			c.Words = words
+		}
+		out[i] = c
+	}
+	return out
+}
+
+// isWordRune reports whether r is part of a word, in any script.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// A WordRule masks or replaces a word or phrase wherever it appears in a
+// transcript, ignoring case.
+type WordRule struct {
+	pattern *regexp.Regexp
+	// wordStart and wordEnd are set when the rule begins or ends with a
+	// word character, and so must not match within a longer word.
+	wordStart, wordEnd bool
+	// Replacement replaces the words matched; if empty, they are masked
+	// like MaskProfanity masks profanities.
+	Replacement string
+}
+
+// replace returns s with each whole-word match of the rule passed through
+// fn. \b only knows ASCII letters, so "хрен" would match within
+// "охренеть"; the boundaries are checked here instead.
+func (r WordRule) replace(s string, fn func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range r.pattern.FindAllStringIndex(s, -1) {
+		if r.wordStart {
+			if prev, _ := utf8.DecodeLastRuneInString(s[:m[0]]); isWordRune(prev) {
+				continue
+			}
+		}
+		if r.wordEnd {
+			if next, _ := utf8.DecodeRuneInString(s[m[1]:]); isWordRune(next) {
+				continue
+			}
+		}
+		b.WriteString(s[last:m[0]])
+		b.WriteString(fn(s[m[0]:m[1]]))
+		last = m[1]
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// ParseWordList parses a list of words to mask or replace, one rule per
+// line. A line holds a word or phrase, which a trailing "*" lets match any
+// ending, such as "darn*" for "darned" and "darnit", and "=" and a
+// replacement, such as "heck=[expletive]", replace instead of masking it.
+// Blank lines and lines starting with "#" are skipped.
+func ParseWordList(data []byte) ([]WordRule, error) {
+	var rules []WordRule
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words, replacement, _ := strings.Cut(line, "=")
+		words, prefix := strings.CutSuffix(strings.TrimSpace(words), "*")
+		fields := strings.Fields(words)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("line %d: no word to match", n+1)
+		}
+		words = strings.Join(fields, " ")
+		for i, f := range fields {
+			fields[i] = regexp.QuoteMeta(f)
+		}
+		// Words only match whole, but not beside symbols, as in "C++".
+		first, _ := utf8.DecodeRuneInString(words)
+		end, _ := utf8.DecodeLastRuneInString(words)
+		rule := WordRule{wordStart: isWordRune(first), Replacement: strings.TrimSpace(replacement)}
+		expr := `(?i)` + strings.Join(fields, `\s+`)
+		if prefix {
+			expr += `[\p{L}\p{N}_]*`
+			rule.wordEnd = true
+		} else {
+			rule.wordEnd = isWordRune(end)
+		}
+		rule.pattern = regexp.MustCompile(expr)
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// MaskWords returns a processor that applies word rules in order, masking
+// or replacing the words they match. Phrases are only matched within a
+// cue.
+func MaskWords(rules []WordRule) Processor {
+	return ProcessorFunc(func(_ Source, cues []Cue) ([]Cue, error) {
+		return replaceText(cues, func(s string) string {
+			for _, r := range rules {
+				if r.Replacement != "" {
+					s = r.replace(s, func(string) string { return r.Replacement })
+				} else {
+					s = r.replace(s, maskWord)
+				}
+			}
+			return s
+		}), nil
+	})
+}
@@ -0,0 +1,275 @@
+package transcript
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sec(n float64) time.Duration { return time.Duration(n * float64(time.Second)) }
+
+func TestDedupe(t *testing.T) {
+	in := []Cue{
+		{Start: sec(0), End: sec(2), Text: "so today we're"},
+		{Start: sec(2), End: sec(2.01), Text: "so today we're"},
+		{Start: sec(2), End: sec(4), Text: "so today we're\ngoing to talk"},
+		{Start: sec(4), End: sec(6), Text: "going to talk\nabout inflation"},
+		{Start: sec(6), End: sec(7), Text: "about inflation"},
+	}
+	want := []Cue{
+		{Start: sec(0), End: sec(2.01), Text: "so today we're"},
+		{Start: sec(2), End: sec(4), Text: "going to talk"},
+		{Start: sec(4), End: sec(7), Text: "about inflation"},
+	}
+	got, err := Dedupe(Source{}, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Dedupe() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParagraphs(t *testing.T) {
+	long := strings.Repeat("word ", 80) + "end."
+	tests := []struct {
+		name string
+		in   []Cue
+		want []string
+	}{
+		{
+			"merges until a pause",
+			[]Cue{
+				{Start: sec(0), End: sec(1), Text: "hello"},
+				{Start: sec(1), End: sec(2), Text: "there\nfriend"},
+				{Start: sec(5), End: sec(6), Text: "new topic"},
+			},
+			[]string{"hello there friend", "new topic"},
+		},
+		{
+			"breaks long paragraphs at sentence ends",
+			[]Cue{
+				{Start: sec(0), End: sec(1), Text: long},
+				{Start: sec(1), End: sec(2), Text: "next"},
+			},
+			[]string{strings.TrimSpace(long), "next"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Paragraphs(DefaultParagraphGap).Process(Source{}, tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var texts []string
+			for _, c := range got {
+				texts = append(texts, c.Text)
+			}
+			if !reflect.DeepEqual(texts, tt.want) {
+				t.Errorf("Paragraphs() = %q, want %q", texts, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"the quick brown fox jumps", 10, "the quick\nbrown fox\njumps"},
+		{"a verylongwordhere b", 5, "a\nverylongwordhere\nb"},
+		{"already\nwrapped  text", 80, "already wrapped text"},
+	}
+	for _, tt := range tests {
+		if got := wrapText(tt.text, tt.width); got != tt.want {
+			t.Errorf("wrapText(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestMaxChars(t *testing.T) {
+	in := []Cue{
+		{Start: sec(0), End: sec(6), Text: "the quick brown\nfox jumps", Speaker: "A", Words: []Word{{sec(0), "the quick brown"}, {sec(3), "fox"}, {sec(4), "jumps"}}},
+		{Start: sec(6), End: sec(7), Text: "over"},
+		{Start: sec(7), End: sec(15), Text: "the lazy dog and then runs"},
+	}
+	got, err := MaxChars(15).Process(Source{}, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{Start: sec(0), End: sec(3), Text: "the quick brown", Speaker: "A", Words: []Word{{sec(0), "the quick brown"}}},
+		{Start: sec(3), End: sec(6), Text: "fox jumps", Speaker: "A", Words: []Word{{sec(3), "fox"}, {sec(4), "jumps"}}},
+		{Start: sec(6), End: sec(7), Text: "over"},
+		{Start: sec(7), End: sec(10.84), Text: "the lazy dog"},
+		{Start: sec(10.84), End: sec(15), Text: "and then runs"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MaxChars() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestMaxDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Cue
+		want []Cue
+	}{
+		{"short enough", Cue{Start: sec(0), End: sec(4), Text: "one two"}, []Cue{{Start: sec(0), End: sec(4), Text: "one two"}}},
+		{
+			"halves",
+			Cue{Start: sec(0), End: sec(8), Text: "aaa bbb ccc ddd"},
+			[]Cue{{Start: sec(0), End: sec(4), Text: "aaa bbb"}, {Start: sec(4), End: sec(8), Text: "ccc ddd"}},
+		},
+		{
+			"fewer words than parts",
+			Cue{Start: sec(0), End: sec(20), Text: "one two"},
+			[]Cue{{Start: sec(0), End: sec(10), Text: "one"}, {Start: sec(10), End: sec(20), Text: "two"}},
+		},
+		{"one word", Cue{Start: sec(0), End: sec(20), Text: "hello"}, []Cue{{Start: sec(0), End: sec(20), Text: "hello"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MaxDuration(5*time.Second).Process(Source{}, []Cue{tt.in})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MaxDuration() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskWords(t *testing.T) {
+	rules, err := ParseWordList([]byte("# house rules\ndarn*\n\ngood  grief = [expletive]\nC++\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := []Cue{
+		{Text: "Darn it, darned thing. Good\ngrief!", Words: []Word{{0, "Darn"}}},
+		{Text: "Darnell writes c++ and undarned code"},
+	}
+	got, err := MaskWords(rules).Process(Source{}, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{Text: "D*** it, d***** thing. [expletive]!", Words: []Word{{0, "D***"}}},
+		{Text: "D****** writes c** and undarned code"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MaskWords() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if _, err := ParseWordList([]byte("ok\n=nothing\n")); err == nil {
+		t.Error("ParseWordList() with an empty rule succeeded, want error")
+	}
+}
+
+func TestMaskWordsUnicode(t *testing.T) {
+	tests := []struct {
+		rule string
+		in   string
+		want string
+	}{
+		{"хрен", "хрен и охренеть", "х*** и охренеть"},
+		{"хрен", "хренов", "хренов"},
+		{"хрен*", "хренов, охренеть", "х*****, охренеть"},
+		{"café", "café, cafés", "c***, cafés"},
+		{"café", "un café.", "un c***."},
+		{"naïve = [word]", "naïvely naïve", "naïvely [word]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rules, err := ParseWordList([]byte(tt.rule))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := MaskWords(rules).Process(Source{}, []Cue{{Text: tt.in}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got[0].Text != tt.want {
+				t.Errorf("MaskWords(%q) on %q = %q, want %q", tt.rule, tt.in, got[0].Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePipeline(t *testing.T) {
+	in := []Cue{
+		{Start: sec(0), End: sec(2), Text: "what the fuck"},
+		{Start: sec(2), End: sec(4), Text: "what the fuck\nis this"},
+		{Start: sec(62), End: sec(64), Text: "later"},
+	}
+	p, err := ParsePipeline("dedupe, profanity-mask,paragraphs=1s,wrap=20,timestamps-links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Process(Source{VideoID: "abc"}, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{Start: sec(0), End: sec(4), Text: "[0:00](https://youtu.be/abc?t=0) what the f*** is\nthis"},
+		{Start: sec(62), End: sec(64), Text: "[1:02](https://youtu.be/abc?t=62) later"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Process() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	for _, spec := range []string{"nope", "wrap=x", "dedupe=1", "paragraphs=-1s", "max-chars=0", "max-duration=x"} {
+		if _, err := ParsePipeline(spec); err == nil {
+			t.Errorf("ParsePipeline(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	cues := []Cue{
+		{Start: sec(1), End: sec(4), Text: "Hello there."},
+		{Start: sec(3725.5), End: sec(3727), Text: "Two\nlines."},
+	}
+	for _, f := range []Format{FormatVTT, FormatSRT, FormatSBV} {
+		t.Run(string(f), func(t *testing.T) {
+			data, err := f.Format(cues)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DetectFormat(data); got != f {
+				t.Errorf("DetectFormat() = %q, want %q", got, f)
+			}
+			got, err := Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, cues) {
+				t.Errorf("round trip =\n%+v\nwant\n%+v\n%s", got, cues, data)
+			}
+		})
+	}
+}
+
+func TestFormatPlain(t *testing.T) {
+	tests := []struct {
+		cues []Cue
+		want string
+	}{
+		{[]Cue{{Text: "one"}, {Text: "two"}}, "one\ntwo\n"},
+		{[]Cue{{Text: "one\nwrapped"}, {Text: "two"}}, "one\nwrapped\n\ntwo\n"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		got, err := FormatPlain.Format(tt.cues)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Format(%+v) = %q, want %q", tt.cues, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package transcript
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// spokenDigit matches a digit read out, as auto-generated captions often
+// transcribe phone numbers.
+const spokenDigit = `(?:zero|oh|one|two|three|four|five|six|seven|eight|nine)`
+
+// redactions are the kinds of personal information Redact masks, with the
+// patterns that find them and the placeholder they're replaced with.
+var redactions = map[string]struct {
+	patterns    []*regexp.Regexp
+	placeholder string
+}{
+	"email": {
+		[]*regexp.Regexp{
+			regexp.MustCompile(`(?i)\b[\w.+-]+@[\w-]+(?:\.[\w-]+)+\b`),
+			regexp.MustCompile(`(?i)\b[\w.+-]+\s+at\s+[\w-]+(?:\s+dot\s+[\w-]+)*\s+dot\s+(?:com|org|net|edu|gov|io|co|dev|me|uk|de)\b`),
+		},
+		"[email]",
+	},
+	"phone": {
+		[]*regexp.Regexp{
+			regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{3}\)|\b\d{3})[\s.-]?\d{3}[\s.-]?\d{4}\b`),
+			regexp.MustCompile(`\+\d{1,3}(?:[\s.-]?\d){7,12}\b`),
+			regexp.MustCompile(`(?i)\b(?:` + spokenDigit + `[\s,-]+){6,}` + spokenDigit + `\b`),
+		},
+		"[phone]",
+	},
+	"address": {
+		[]*regexp.Regexp{
+			regexp.MustCompile(`(?i)\b\d{1,6}\s+(?:[a-z][\w'-]*\s+){1,3}(?:street|st|avenue|ave|road|rd|boulevard|blvd|lane|ln|drive|dr|court|ct|place|terrace|circle|highway|hwy)\b\.?`),
+		},
+		"[address]",
+	},
+}
+
+// PIIKinds are the kinds of personal information Redact masks, in the
+// order it masks them.
+var PIIKinds = []string{"email", "phone", "address"}
+
+// Redact returns a processor that replaces personal information in cue
+// text with a placeholder such as "[email]": email addresses, phone
+// numbers, and street addresses, or only the kinds given. Spoken forms
+// are caught too, such as "jane at example dot com" and phone numbers read
+// out digit by digit. Only text within a cue is matched, so running
+// paragraphs first catches more. Cues that are redacted lose their word
+// timings, which would otherwise still spell out what was masked.
+func Redact(kinds ...string) (Processor, error) {
+	if len(kinds) == 0 {
+		kinds = PIIKinds
+	}
+	for _, kind := range kinds {
+		if _, ok := redactions[kind]; !ok {
+			return nil, fmt.Errorf("unknown kind %q (want %s)", kind, strings.Join(PIIKinds, ", "))
+		}
+	}
+	return ProcessorFunc(func(_ Source, cues []Cue) ([]Cue, error) {
+		out := make([]Cue, len(cues))
+		for i, c := range cues {
+			text := c.Text
+			for _, kind := range PIIKinds {
+				if !slices.Contains(kinds, kind) {
+					continue
+				}
+				r := redactions[kind]
+				for _, re := range r.patterns {
+					text = re.ReplaceAllLiteralString(text, r.placeholder)
+				}
+			}
+			if text != c.Text {
+				c.Text, c.Words = text, nil
+			}
+			out[i] = c
+		}
+		return out, nil
+	}), nil
+}
+
+// redactFactory builds Redact from "pii", meaning every kind, or kinds
+// joined with "+", such as "email+phone".
+func redactFactory(arg string) (Processor, error) {
+	if arg == "" || arg == "pii" {
+		return Redact()
+	}
+	return Redact(strings.Split(arg, "+")...)
+}
@@ -0,0 +1,46 @@
+package transcript
+
+import (
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name  string
+		kinds []string
+		text  string
+		want  string
+	}{
+		{"email", nil, "Write to jane.doe+calls@example.co.uk today", "Write to [email] today"},
+		{"spoken email", nil, "it's jane at example dot com", "it's [email]"},
+		{"not an email", nil, "look at this dot point", "look at this dot point"},
+		{"phone", nil, "Call (555) 123-4567 or 555.123.4567", "Call [phone] or [phone]"},
+		{"international phone", nil, "ring +44 20 7946 0958 now", "ring [phone] now"},
+		{"spoken phone", nil, "it's five five five one two three four", "it's [phone]"},
+		{"address", nil, "we're at 1600 Pennsylvania Avenue now", "we're at [address] now"},
+		{"year is not an address", nil, "in 2024 we moved on", "in 2024 we moved on"},
+		{"only the kinds given", []string{"phone"}, "jane@example.com, 555-123-4567", "jane@example.com, [phone]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Redact(tt.kinds...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Process(Source{}, []Cue{{Text: tt.text, Words: []Word{{0, tt.text}}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got[0].Text != tt.want {
+				t.Errorf("Redact() = %q, want %q", got[0].Text, tt.want)
+			}
+			if redacted := tt.text != tt.want; redacted != (got[0].Words == nil) {
+				t.Errorf("Redact() words = %v, want them dropped only when redacting", got[0].Words)
+			}
+		})
+	}
+
+	if _, err := ParsePipeline("redact=email+ssn"); err == nil {
+		t.Error("ParsePipeline(redact=email+ssn) succeeded, want error")
+	}
+}
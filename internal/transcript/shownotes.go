@@ -0,0 +1,87 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// descriptionLink matches a URL in a video description.
+var descriptionLink = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+// ExtractLinks returns the URLs in a video description, in order and
+// without repeats, leaving off punctuation that ends the sentence around
+// them.
+func ExtractLinks(description string) []string {
+	var links []string
+	for _, link := range descriptionLink.FindAllString(description, -1) {
+		link = strings.TrimRight(link, ".,;:!?")
+		if !slices.Contains(links, link) {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// ShowNotes is what a show-notes page for a video is made from.
+type ShowNotes struct {
+	Meta     Metadata
+	Chapters []Chapter
+	Links    []string
+	// Cues is the transcript, already cleaned up.
+	Cues []Cue
+}
+
+// Markdown renders the show notes as a Markdown document, ready to paste
+// into an episode page: the title, a link to the video, the chapters with
+// their timestamps, the links from the description, and the transcript.
+// Sections with nothing in them are left out.
+func (n ShowNotes) Markdown() []byte {
+	var b bytes.Buffer
+	videoID := n.Meta.Get("video_id")
+	stamp := func(c Cue) string {
+		s := "[" + FormatTimestamp(c.Start) + "]"
+		if videoID != "" {
+			s += "(" + WatchURL(videoID, c.Start) + ")"
+		}
+		return s
+	}
+
+	if title := n.Meta.Get("title"); title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", strings.Join(strings.Fields(title), " "))
+	}
+	if url := n.Meta.Get("url"); url != "" {
+		fmt.Fprintf(&b, "<%s>\n\n", url)
+	}
+	if len(n.Chapters) > 0 {
+		b.WriteString("## Chapters\n\n")
+		for _, ch := range n.Chapters {
+			fmt.Fprintf(&b, "- %s %s\n", stamp(Cue{Start: ch.Start}), ch.Title)
+		}
+		b.WriteByte('\n')
+	}
+	if len(n.Links) > 0 {
+		b.WriteString("## Links\n\n")
+		for _, link := range n.Links {
+			fmt.Fprintf(&b, "- <%s>\n", link)
+		}
+		b.WriteByte('\n')
+	}
+	if len(n.Cues) > 0 {
+		b.WriteString("## Transcript\n")
+		withTimes := timed(n.Cues)
+		for _, c := range n.Cues {
+			b.WriteByte('\n')
+			if withTimes {
+				b.WriteString(stamp(c) + " ")
+			}
+			if c.Speaker != "" {
+				fmt.Fprintf(&b, "**%s:** ", c.Speaker)
+			}
+			b.WriteString(strings.Join(strings.Fields(c.Text), " ") + "\n")
+		}
+	}
+	return append(bytes.TrimRight(b.Bytes(), "\n"), '\n')
+}
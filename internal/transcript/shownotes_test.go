@@ -0,0 +1,68 @@
+package transcript
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtractLinks(t *testing.T) {
+	tests := []struct {
+		description string
+		want        []string
+	}{
+		{"", nil},
+		{"No links here.", nil},
+		{
+			"Slides: https://example.com/slides.pdf. Code (https://github.com/x/y) and https://example.com/slides.pdf again!",
+			[]string{"https://example.com/slides.pdf", "https://github.com/x/y"},
+		},
+		{"Follow http://example.org/a?b=c&d=e, thanks", []string{"http://example.org/a?b=c&d=e"}},
+	}
+	for _, tt := range tests {
+		if got := ExtractLinks(tt.description); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractLinks(%q) = %v, want %v", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestShowNotesMarkdown(t *testing.T) {
+	src := Source{VideoID: "abc123", Title: "Episode  1"}
+	tests := []struct {
+		name  string
+		notes ShowNotes
+		want  string
+	}{
+		{
+			name: "full",
+			notes: ShowNotes{
+				Meta:     src.Metadata(),
+				Chapters: []Chapter{{"Intro", 0}, {"Q&A", 90 * time.Second}},
+				Links:    []string{"https://example.com"},
+				Cues: []Cue{
+					{Start: 0, End: 5 * time.Second, Text: "Welcome\nback."},
+					{Start: 5 * time.Second, End: 9 * time.Second, Text: "Hi.", Speaker: "Guest"},
+				},
+			},
+			want: "# Episode 1\n\n<https://youtu.be/abc123>\n\n" +
+				"## Chapters\n\n- [0:00](https://youtu.be/abc123?t=0) Intro\n- [1:30](https://youtu.be/abc123?t=90) Q&A\n\n" +
+				"## Links\n\n- <https://example.com>\n\n" +
+				"## Transcript\n\n[0:00](https://youtu.be/abc123?t=0) Welcome back.\n\n[0:05](https://youtu.be/abc123?t=5) **Guest:** Hi.\n",
+		},
+		{
+			name: "no chapters or links",
+			notes: ShowNotes{
+				Meta: src.Metadata(),
+				Cues: []Cue{{Start: 0, End: 5 * time.Second, Text: "Welcome."}},
+			},
+			want: "# Episode 1\n\n<https://youtu.be/abc123>\n\n## Transcript\n\n[0:00](https://youtu.be/abc123?t=0) Welcome.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.notes.Markdown()); got != tt.want {
+				t.Errorf("Markdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+// Package transcript parses caption files into timed cues.
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/book"
+)
+
+// Format is a caption file format.
+type Format string
+
+// Supported formats.
+const (
+	FormatVTT   Format = "vtt"
+	FormatSRT   Format = "srt"
+	FormatSBV   Format = "sbv"
+	FormatPlain Format = "txt"
+	FormatJSON  Format = "json"
+	FormatHTML  Format = "html"
+	FormatPDF   Format = "pdf"
+	FormatEPUB  Format = "epub"
+	FormatDOCX  Format = "docx"
+	FormatMD    Format = "md"
+)
+
+// Binary reports whether files in the format are binary rather than text,
+// so they can't be compared or merged line by line.
+func (f Format) Binary() bool {
+	return f == FormatPDF || f == FormatEPUB || f == FormatDOCX
+}
+
+// Cue is a piece of caption text shown between Start and End.
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+	// Words holds per-word start times when the source carries them, as
+	// YouTube's auto-generated VTT captions do.
+	Words []Word
+
+	// Speaker is the voice the cue is attributed to by a VTT <v> span.
+	Speaker string
+	// Settings are the VTT cue settings, such as "align:start line:0".
+	Settings string
+	// Markup is the VTT cue text with its tags, when it has any. It is
+	// only used while Text still matches it with the tags removed, so
+	// processors that rewrite Text don't need to maintain it.
+	Markup string
+	// Raw marks Text as read with ParseOptions.RawText, so it still holds
+	// the entities and tags of the source and is written as it is.
+	Raw bool
+}
+
+// Word is a word, or short run of words, within a cue.
+type Word struct {
+	Start time.Duration
+	Text  string
+}
+
+var (
+	srtTiming = regexp.MustCompile(`^\s*(\d+:\d{2}:\d{2}[,.]\d{1,3})\s*-->\s*(\d+:\d{2}:\d{2}[,.]\d{1,3})`)
+	vttTiming = regexp.MustCompile(`^\s*((?:\d+:)?\d{2}:\d{2}\.\d{3})\s*-->\s*((?:\d+:)?\d{2}:\d{2}\.\d{3})`)
+	sbvTiming = regexp.MustCompile(`^\s*(\d+:\d{2}:\d{2}\.\d{3}),(\d+:\d{2}:\d{2}\.\d{3})\s*$`)
+	vttTag    = regexp.MustCompile(`<[^>]*>`)
+	vttInline = regexp.MustCompile(`<((?:\d+:)?\d{2}:\d{2}\.\d{3})>`)
+	vttVoice  = regexp.MustCompile(`<v(?:\.[^\s>]*)*\s+([^>]+)>`)
+	// inlineTag matches the formatting tags found in caption text, such as
+	// <i> and <font color="red">, and ASS override tags, such as {\an8}.
+	// Other text in angle brackets is left alone.
+	inlineTag = regexp.MustCompile(`(?i)</?(?:b|i|u|s|em|strong|small|big|sub|sup|font|span|c|v|lang|ruby|rt|br|p|div)(?:[\s.][^<>]*)?/?>|\{\\[^{}]*\}`)
+	// encodedFontTag matches a <font> tag whose brackets were HTML-encoded,
+	// once or more, as some uploaded captions have them.
+	encodedFontTag = regexp.MustCompile(`(?i)&(?:amp;)*lt;/?font\b.*?&(?:amp;)*gt;`)
+)
+
+// DetectFormat guesses the format of caption data from its content, in
+// any encoding ToUTF8 decodes.
+func DetectFormat(data []byte) Format {
+	switch {
+	case book.IsPDF(data):
+		return FormatPDF
+	case book.IsEPUB(data):
+		return FormatEPUB
+	case book.IsDOCX(data):
+		return FormatDOCX
+	}
+	data, _ = ToUTF8(data)
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if bytes.HasPrefix(trimmed, []byte("WEBVTT")) {
+		return FormatVTT
+	}
+	if bytes.Contains(trimmed, []byte(htmlCuesStart)) {
+		return FormatHTML
+	}
+	if bytes.HasPrefix(trimmed, []byte("{")) && json.Valid(trimmed) {
+		return FormatJSON
+	}
+	if body, ok := cutFrontMatter(trimmed); ok && !isMarkdown(body) {
+		// Plain text with a header block.
+		return FormatPlain
+	}
+	if isMarkdown(trimmed) {
+		return FormatMD
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for i := 0; i < 10 && scanner.Scan(); i++ {
+		line := scanner.Text()
+		switch {
+		case srtTiming.MatchString(line):
+			return FormatSRT
+		case sbvTiming.MatchString(line):
+			return FormatSBV
+		}
+	}
+	return FormatPlain
+}
+
+// Parse parses caption data in any supported format, detecting which.
+// Plain text yields one untimed cue per non-empty line.
+func Parse(data []byte) ([]Cue, error) {
+	return ParseFormat(data, DetectFormat(data))
+}
+
+// ParseFormat parses caption data in the given format. Text formats are
+// decoded to UTF-8 first, whatever their encoding and line endings, and
+// HTML entities and inline tags in VTT, SRT, SBV, and plain text cues are
+// decoded and removed; see ParseOptions.
+func ParseFormat(data []byte, format Format) ([]Cue, error) {
+	return ParseFormatOptions(data, format, ParseOptions{})
+}
+
+// ParseOptions adjust how ParseFormatOptions reads cue text.
+type ParseOptions struct {
+	// RawText keeps cue text as the file has it, with HTML entities such
+	// as "&#39;" and tags such as "<font>" or "{\an8}" in SRT and SBV
+	// captions, instead of decoding and removing them. VTT markup is still
+	// removed from Text, and kept in Markup.
+	RawText bool
+}
+
+// ParseFormatOptions parses caption data in the given format like
+// ParseFormat, reading cue text as opts says.
+func ParseFormatOptions(data []byte, format Format, opts ParseOptions) ([]Cue, error) {
+	if !format.Binary() {
+		data, _ = ToUTF8(data)
+	}
+	text := normalizeNewlines(string(data))
+
+	switch format {
+	case FormatVTT:
+		return parseBlocks(text, vttTiming, true, opts.RawText)
+	case FormatSRT:
+		return parseBlocks(text, srtTiming, false, opts.RawText)
+	case FormatSBV:
+		return parseBlocks(text, sbvTiming, false, opts.RawText)
+	case FormatJSON:
+		return parseJSON(data)
+	case FormatHTML:
+		return parseHTML(data)
+	case FormatPDF, FormatEPUB, FormatDOCX:
+		return parseBook(data)
+	case FormatMD:
+		return parseMarkdown(text)
+	case FormatPlain:
+		if body, ok := cutFrontMatter([]byte(text)); ok {
+			text = string(body)
+		}
+		var cues []Cue
+		for _, line := range strings.Split(text, "\n") {
+			if !opts.RawText {
+				line = CleanText(line)
+			}
+			if line = strings.TrimSpace(line); line != "" {
+				cues = append(cues, Cue{Text: line})
+			}
+		}
+		return cues, nil
+	}
+	return nil, fmt.Errorf("unsupported caption format %q", format)
+}
+
+// parseBlocks parses blank-line separated blocks whose timing line matches
+// timing. Lines before the timing line (SRT indexes, VTT cue IDs) are
+// ignored, as are blocks without a timing line (VTT headers, NOTE, STYLE).
+// Unless raw is set, cue text is cleaned with CleanText.
+func parseBlocks(text string, timing *regexp.Regexp, stripTags, raw bool) ([]Cue, error) {
+	var cues []Cue
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+
+		timingLine := -1
+		var m []string
+		for i, line := range lines {
+			if m = timing.FindStringSubmatch(line); m != nil {
+				timingLine = i
+				break
+			}
+		}
+		if timingLine < 0 {
+			continue
+		}
+
+		start, err := ParseTimestamp(m[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := ParseTimestamp(m[2])
+		if err != nil {
+			return nil, err
+		}
+
+		body := strings.Join(lines[timingLine+1:], "\n")
+		cue := Cue{Start: start, End: end}
+		if stripTags {
+			if cue.Words, err = vttWords(lines[timingLine+1:], start, raw); err != nil {
+				return nil, err
+			}
+			cue.Settings = strings.TrimSpace(lines[timingLine][len(m[0]):])
+			if v := vttVoice.FindStringSubmatch(body); v != nil {
+				cue.Speaker = strings.TrimSpace(v[1])
+			}
+			if markup := strings.TrimSpace(body); vttTag.MatchString(markup) {
+				cue.Markup = markup
+			}
+			body = vttTag.ReplaceAllString(body, "")
+		}
+		if !raw {
+			body = CleanText(body)
+		}
+		cue.Raw = raw
+		if cue.Text = strings.TrimSpace(body); cue.Text == "" {
+			continue
+		}
+		if start == 0 && end == 0 && strings.HasPrefix(cue.Text, "NOTE\n") {
+			// A header block, as earlier versions of FormatHeader wrote in
+			// SRT and SBV.
+			continue
+		}
+		cues = append(cues, cue)
+	}
+	return cues, nil
+}
+
+// CleanText removes the inline tags from caption text, including <font>
+// tags whose brackets were encoded as entities, then decodes its HTML
+// entities, including those encoded twice over such as "&amp;#39;". So
+// "don&#39;t <i>stop</i>" reads "don't stop", while an escaped tag meant as
+// text, as in "the &lt;p&gt; tag", is kept as "the <p> tag".
+func CleanText(s string) string {
+	s = inlineTag.ReplaceAllString(s, "")
+	s = encodedFontTag.ReplaceAllString(s, "")
+	for range 3 {
+		if !strings.Contains(s, "&") {
+			break
+		}
+		decoded := html.UnescapeString(s)
+		if decoded == s {
+			break
+		}
+		s = decoded
+	}
+	return s
+}
+
+// vttWords extracts word timings from the lines of a VTT cue that contain
+// inline timestamps, such as "so<00:00:01.500><c> today</c>". Text before
+// the first timestamp starts with the cue. Lines without timestamps, like
+// the repeated line in rolling auto-generated captions, are skipped.
+func vttWords(lines []string, cueStart time.Duration, raw bool) ([]Word, error) {
+	var words []Word
+	for _, line := range lines {
+		locs := vttInline.FindAllStringSubmatchIndex(line, -1)
+		if locs == nil {
+			continue
+		}
+		add := func(start time.Duration, text string) {
+			text = vttTag.ReplaceAllString(text, "")
+			if !raw {
+				text = CleanText(text)
+			}
+			if text = strings.TrimSpace(text); text != "" {
+				words = append(words, Word{Start: start, Text: text})
+			}
+		}
+		add(cueStart, line[:locs[0][0]])
+		for i, loc := range locs {
+			start, err := ParseTimestamp(line[loc[2]:loc[3]])
+			if err != nil {
+				return nil, err
+			}
+			end := len(line)
+			if i+1 < len(locs) {
+				end = locs[i+1][0]
+			}
+			add(start, line[loc[1]:end])
+		}
+	}
+	return words, nil
+}
+
+// ParseTimestamp parses caption timestamps such as "01:02:03.456",
+// "1:02:03,456", "02:03.456", or "02:03".
+func ParseTimestamp(s string) (time.Duration, error) {
+	s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+
+	var frac time.Duration
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits := s[i+1:]
+		n, err := strconv.Atoi(digits)
+		if err != nil || len(digits) > 9 {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		for range 9 - len(digits) {
+			n *= 10
+		}
+		frac = time.Duration(n)
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	var total time.Duration
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total = total*60 + time.Duration(n)*time.Second
+	}
+	return total + frac, nil
+}
+
+// Between returns the cues shown at any point between from and to. A zero
+// to means the end of the transcript.
+func Between(cues []Cue, from, to time.Duration) []Cue {
+	var out []Cue
+	for _, c := range cues {
+		if c.End > from && (to == 0 || c.Start < to) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Shift retimes cues to t*scale + offset, to re-sync captions with a
+// re-edited or re-encoded copy of the video: offset moves every cue, and a
+// scale just off 1, such as 1.001, corrects captions that drift. Cues
+// moved wholly before the start of the video are dropped, and those moved
+// partly before it start at zero.
+func Shift(cues []Cue, offset time.Duration, scale float64) []Cue {
+	move := func(t time.Duration) time.Duration {
+		return time.Duration(float64(t)*scale) + offset
+	}
+	var out []Cue
+	for _, c := range cues {
+		c.Start, c.End = max(move(c.Start), 0), move(c.End)
+		if c.End <= 0 {
+			continue
+		}
+		words := make([]Word, len(c.Words))
+		for i, w := range c.Words {
+			words[i] = Word{Start: max(move(w.Start), 0), Text: w.Text}
+		}
+		if c.Words != nil {
+			c.Words = words
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// markup returns the cue's VTT markup if it still matches Text, read with
+// or without CleanText.
+func (c Cue) markup() string {
+	if c.Markup == "" {
+		return ""
+	}
+	text := strings.TrimSpace(vttTag.ReplaceAllString(c.Markup, ""))
+	if text != c.Text && strings.TrimSpace(CleanText(text)) != c.Text {
+		return ""
+	}
+	return c.Markup
+}
+
+// labeled returns the cue's text prefixed with its speaker, if any, for
+// formats without voice markup.
+func (c Cue) labeled() string {
+	if c.Speaker == "" {
+		return c.Text
+	}
+	return c.Speaker + ": " + c.Text
+}
+
+// Text joins the cues' text into a single string, one cue per line.
+func Text(cues []Cue) string {
+	var b strings.Builder
+	for _, c := range cues {
+		b.WriteString(strings.ReplaceAll(c.Text, "\n", " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,369 @@
+package transcript
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"00:00:01.000", time.Second, false},
+		{"01:02:03,456", time.Hour + 2*time.Minute + 3*time.Second + 456*time.Millisecond, false},
+		{"1:02:03.4", time.Hour + 2*time.Minute + 3*time.Second + 400*time.Millisecond, false},
+		{"02:03.500", 2*time.Minute + 3*time.Second + 500*time.Millisecond, false},
+		{"12:34", 12*time.Minute + 34*time.Second, false},
+		{"abc", 0, true},
+		{"1:2:3:4", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimestamp(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTimestamp(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	want := []Cue{
+		{Start: time.Second, End: 4 * time.Second, Text: "Hello there."},
+		{Start: 5 * time.Second, End: 7*time.Second + 500*time.Millisecond, Text: "Second line\nwraps."},
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		format Format
+	}{
+		{"srt", "1\r\n00:00:01,000 --> 00:00:04,000\r\nHello there.\r\n\r\n2\r\n00:00:05,000 --> 00:00:07,500\r\nSecond line\r\nwraps.\r\n", FormatSRT},
+		{"vtt", "\xef\xbb\xbfWEBVTT\nKind: captions\n\nNOTE a comment\n\n00:01.000 --> 00:04.000 align:start\nHello <c>there</c>.\n\nid2\n00:00:05.000 --> 00:00:07.500\nSecond line\nwraps.\n", FormatVTT},
+		{"sbv", "0:00:01.000,0:00:04.000\nHello there.\n\n0:00:05.000,0:00:07.500\nSecond line\nwraps.\n", FormatSBV},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat([]byte(tt.input)); got != tt.format {
+				t.Errorf("DetectFormat() = %q, want %q", got, tt.format)
+			}
+			got, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// VTT styling is covered by TestParseVTTStyles.
+			for i := range got {
+				got[i].Settings, got[i].Markup = "", ""
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Parse() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseVTTStyles(t *testing.T) {
+	input := "WEBVTT\n\n00:00:01.000 --> 00:00:04.000 align:start line:0\n<v.loud Roger Bingham>We are in <c.yellow>New York</c>\n\n00:00:05.000 --> 00:00:06.000\nplain\n"
+	got, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{
+			Start:    time.Second,
+			End:      4 * time.Second,
+			Text:     "We are in New York",
+			Speaker:  "Roger Bingham",
+			Settings: "align:start line:0",
+			Markup:   "<v.loud Roger Bingham>We are in <c.yellow>New York</c>",
+		},
+		{Start: 5 * time.Second, End: 6 * time.Second, Text: "plain"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parse() = %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatVTT, "WEBVTT\n\n00:00:01.000 --> 00:00:04.000 align:start line:0\n<v.loud Roger Bingham>We are in <c.yellow>New York</c>\n\n00:00:05.000 --> 00:00:06.000\nplain\n"},
+		{FormatSRT, "1\n00:00:01,000 --> 00:00:04,000\nRoger Bingham: We are in New York\n\n2\n00:00:05,000 --> 00:00:06,000\nplain\n"},
+		{FormatPlain, "Roger Bingham: We are in New York\nplain\n"},
+	}
+	for _, tt := range tests {
+		out, err := tt.format.Format(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tt.want {
+			t.Errorf("Format(%s) = %q, want %q", tt.format, out, tt.want)
+		}
+	}
+
+	// Once the text is rewritten, the markup is stale and the speaker is
+	// written as a plain voice span.
+	got[0].Text = "We are in NYC"
+	out, err := FormatVTT.Format(got[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "WEBVTT\n\n00:00:01.000 --> 00:00:04.000 align:start line:0\n<v Roger Bingham>We are in NYC\n"; string(out) != want {
+		t.Errorf("Format(vtt) after edit = %q, want %q", out, want)
+	}
+}
+
+func TestParsePlain(t *testing.T) {
+	got, err := Parse([]byte("first line\n\nsecond line\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{{Text: "first line"}, {Text: "second line"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"don&#39;t stop", "don't stop"},
+		{"don&amp;#39;t &amp;amp; more", "don't & more"},
+		{"<i>so</i> <font color=\"#E5E5E5\">today</font>", "so today"},
+		{"&lt;font color=&quot;#CCCCCC&quot;&gt;hi&lt;/font&gt;", "hi"},
+		{"{\\an8}on top", "on top"},
+		{"x &lt; y and <is> kept", "x < y and <is> kept"},
+		{"use the &lt;p&gt; tag", "use the <p> tag"},
+		{"&amp;lt;font color=&amp;quot;#CCCCCC&amp;quot;&amp;gt;hi&amp;lt;/font&amp;gt;", "hi"},
+	}
+	for _, tt := range tests {
+		if got := CleanText(tt.in); got != tt.want {
+			t.Errorf("CleanText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRawText(t *testing.T) {
+	data := []byte("1\n00:00:01,000 --> 00:00:02,000\n<i>don&#39;t</i>\n")
+	cues, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cues[0].Text != "don't" {
+		t.Errorf("Parse() text = %q, want %q", cues[0].Text, "don't")
+	}
+	cues, err = ParseFormatOptions(data, FormatSRT, ParseOptions{RawText: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cues[0].Text != "<i>don&#39;t</i>" {
+		t.Errorf("ParseFormatOptions(raw) text = %q", cues[0].Text)
+	}
+
+	// VTT text is unescaped when read and escaped again when written.
+	vtt := "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nQ&amp;A &lt;live&gt;\n"
+	if cues, err = Parse([]byte(vtt)); err != nil {
+		t.Fatal(err)
+	}
+	if cues[0].Text != "Q&A <live>" {
+		t.Errorf("Parse(vtt) text = %q", cues[0].Text)
+	}
+	out, err := FormatVTT.Format(cues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != vtt {
+		t.Errorf("Format(vtt) = %q, want %q", out, vtt)
+	}
+
+	// Raw text is written as read, not escaped a second time.
+	for _, tt := range []struct {
+		in     string
+		format Format
+		want   string
+	}{
+		{vtt, FormatVTT, "Q&amp;A &lt;live&gt;"},
+		{string(data), FormatSRT, "<i>don&#39;t</i>"},
+	} {
+		cues, err := ParseFormatOptions([]byte(tt.in), tt.format, ParseOptions{RawText: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := FormatVTT.Format(cues)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "00:00:01.000 --> 00:00:02.000\n" + tt.want + "\n"; !strings.HasSuffix(string(out), want) {
+			t.Errorf("Format(raw %s) = %q, want it to end %q", tt.format, out, want)
+		}
+	}
+}
+
+func TestParseVTTWords(t *testing.T) {
+	// Rolling auto-generated captions: the first line repeats the
+	// previous cue and has no word timings.
+	input := "WEBVTT\nKind: captions\n\n" +
+		"00:00:01.000 --> 00:00:03.000 align:start position:0%\n" +
+		"so today\n" +
+		"we're<00:00:01.500><c> going</c><00:00:02.250><c> to talk</c>\n"
+
+	got, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{{
+		Start:    time.Second,
+		End:      3 * time.Second,
+		Text:     "so today\nwe're going to talk",
+		Settings: "align:start position:0%",
+		Markup:   "so today\nwe're<00:00:01.500><c> going</c><00:00:02.250><c> to talk</c>",
+		Words: []Word{
+			{Start: time.Second, Text: "we're"},
+			{Start: 1500 * time.Millisecond, Text: "going"},
+			{Start: 2250 * time.Millisecond, Text: "to talk"},
+		},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	cues := []Cue{
+		{Start: 1500 * time.Millisecond, End: 3 * time.Second, Text: "so today", Words: []Word{
+			{Start: 1500 * time.Millisecond, Text: "so"},
+			{Start: 2100 * time.Millisecond, Text: "today"},
+		}},
+		{Start: 4 * time.Second, End: 5 * time.Second, Text: "no words"},
+	}
+	data, err := FormatJSON.Format(cues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DetectFormat(data); got != FormatJSON {
+		t.Errorf("DetectFormat() = %q, want json", got)
+	}
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cues) {
+		t.Errorf("round trip = %+v, want %+v", got, cues)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: 5 * time.Second, Text: "a"},
+		{Start: 5 * time.Second, End: 10 * time.Second, Text: "b"},
+		{Start: 10 * time.Second, End: 15 * time.Second, Text: "c"},
+	}
+	tests := []struct {
+		from, to time.Duration
+		want     string
+	}{
+		{0, 0, "a\nb\nc\n"},
+		{5 * time.Second, 10 * time.Second, "b\n"},
+		{4 * time.Second, 11 * time.Second, "a\nb\nc\n"},
+		{12 * time.Second, 0, "c\n"},
+		{20 * time.Second, 0, ""},
+	}
+	for _, tt := range tests {
+		if got := Text(Between(cues, tt.from, tt.to)); got != tt.want {
+			t.Errorf("Between(%v, %v) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestShift(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: sec(1), Text: "a"},
+		{Start: sec(1), End: sec(3), Text: "b", Words: []Word{{sec(1), "b"}}},
+		{Start: sec(10), End: sec(12), Text: "c"},
+	}
+	tests := []struct {
+		name   string
+		offset time.Duration
+		scale  float64
+		want   []Cue
+	}{
+		{"later", sec(1.5), 1, []Cue{
+			{Start: sec(1.5), End: sec(2.5), Text: "a"},
+			{Start: sec(2.5), End: sec(4.5), Text: "b", Words: []Word{{sec(2.5), "b"}}},
+			{Start: sec(11.5), End: sec(13.5), Text: "c"},
+		}},
+		{"earlier", -sec(1.5), 1, []Cue{
+			{Start: 0, End: sec(1.5), Text: "b", Words: []Word{{0, "b"}}},
+			{Start: sec(8.5), End: sec(10.5), Text: "c"},
+		}},
+		{"scaled", 0, 1.5, []Cue{
+			{Start: 0, End: sec(1.5), Text: "a"},
+			{Start: sec(1.5), End: sec(4.5), Text: "b", Words: []Word{{sec(1.5), "b"}}},
+			{Start: sec(15), End: sec(18), Text: "c"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Shift(cues, tt.offset, tt.scale); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Shift() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "So what we are going to do today is look at the way that this works, and you will see that it is not as hard as they say.", "en"},
+		{"spanish", "Hoy vamos a ver cómo funciona esto, y es muy fácil porque el sistema que usamos para los datos es lo mismo que antes.", "es"},
+		{"french", "Aujourd'hui nous allons voir comment ça marche, et vous verrez que ce n'est pas difficile pour les gens qui sont dans le métier.", "fr"},
+		{"german", "Heute schauen wir uns an, wie das funktioniert, und ich denke, es ist nicht so schwer, wie die Leute sagen, auch wenn wir mit den Daten arbeiten.", "de"},
+		{"japanese", "今日はこのシステムがどのように動くかを見ていきます。思ったより簡単です。", "ja"},
+		{"chinese", "今天我们来看看这个系统是如何工作的，其实比想象中简单得多。", "zh"},
+		{"korean", "오늘은 이 시스템이 어떻게 작동하는지 살펴보겠습니다 생각보다 쉽습니다", "ko"},
+		{"russian", "Сегодня мы посмотрим, как это работает, и вы увидите, что это не так сложно, как кажется.", "ru"},
+		{"ukrainian", "Сьогодні ми подивимося, як це працює, і ви побачите, що це не так складно, як здається.", "uk"},
+		{"too short", "the cat is here", ""},
+		{"no stopwords", "Lorem ipsum dolor sit amet consectetur adipiscing elit sed eiusmod tempor incididunt labore dolore magna aliqua enim minim veniam quis nostrud", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguage([]Cue{{Text: tt.text}}); got != tt.want {
+				t.Errorf("DetectLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameLanguage(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"en", "en", true},
+		{"en-US", "en", true},
+		{"en_GB", "EN-us", true},
+		{"zh-Hans", "zh-Hant", true},
+		{"en", "es", false},
+		{"", "en", false},
+	}
+	for _, tt := range tests {
+		if got := SameLanguage(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameLanguage(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
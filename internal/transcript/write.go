@@ -0,0 +1,140 @@
+package transcript
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format renders cues in the given caption format. Only JSON and the HTML
+// and book formats keep word timings. VTT, JSON, HTML, and books keep
+// speakers, cue settings, and markup; other
+// formats prefix each cue with its speaker. Plain text puts each cue
+// on its own line, with a blank line between cues when any cue spans several
+// lines (such as wrapped paragraphs) so their boundaries stay visible.
+func (f Format) Format(cues []Cue) ([]byte, error) {
+	return f.format(nil, cues, false)
+}
+
+// FormatSource renders cues like Format, embedding the source's metadata in
+// formats with room for it: as a NOTE block in VTT, a "source" object in
+// JSON, front matter in Markdown, the page header in HTML, and the title
+// page in PDF, EPUB, and DOCX.
+func (f Format) FormatSource(src Source, cues []Cue) ([]byte, error) {
+	return f.format(src.Metadata(), cues, false)
+}
+
+// FormatHeader renders cues like FormatSource with the fields of meta, such
+// as those from Source.Header, but also begins plain text with them as YAML
+// front matter. SRT and SBV have no comments to hold a header, so they are
+// rendered as FormatSource renders them.
+func (f Format) FormatHeader(meta Metadata, cues []Cue) ([]byte, error) {
+	return f.format(meta, cues, true)
+}
+
+// vttEscaper escapes the characters VTT cue text can't hold literally. Raw
+// cue text already holds them escaped, as the source had them.
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func (f Format) format(meta Metadata, cues []Cue, header bool) ([]byte, error) {
+	var b strings.Builder
+	header = header && len(meta) > 0
+	switch f {
+	case FormatVTT:
+		b.WriteString("WEBVTT\n")
+		if len(meta) > 0 {
+			b.WriteString("\n" + meta.vttNote())
+		}
+		for _, c := range cues {
+			timing := formatClock(c.Start, '.') + " --> " + formatClock(c.End, '.')
+			if c.Settings != "" {
+				timing += " " + c.Settings
+			}
+			payload := c.markup()
+			text := c.Text
+			if !c.Raw {
+				text = vttEscaper.Replace(text)
+			}
+			switch {
+			case payload != "":
+			case c.Speaker != "":
+				payload = "<v " + c.Speaker + ">" + text
+			default:
+				payload = text
+			}
+			fmt.Fprintf(&b, "\n%s\n%s\n", timing, payload)
+		}
+	case FormatSRT:
+		for i, c := range cues {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n", i+1, formatClock(c.Start, ','), formatClock(c.End, ','), c.labeled())
+		}
+	case FormatSBV:
+		for i, c := range cues {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(&b, "%s,%s\n%s\n", formatSBVClock(c.Start), formatSBVClock(c.End), c.labeled())
+		}
+	case FormatJSON:
+		return formatJSON(meta, cues)
+	case FormatHTML:
+		return formatHTML(meta, cues)
+	case FormatPDF, FormatEPUB, FormatDOCX:
+		return formatBook(f, meta, cues)
+	case FormatMD:
+		return formatMarkdown(meta, cues), nil
+	case FormatPlain:
+		if header {
+			b.Write(meta.FrontMatter())
+			b.WriteByte('\n')
+		}
+		sep := "\n"
+		for _, c := range cues {
+			if strings.Contains(c.Text, "\n") {
+				sep = "\n\n"
+				break
+			}
+		}
+		for i, c := range cues {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			b.WriteString(c.labeled())
+		}
+		if len(cues) > 0 {
+			b.WriteByte('\n')
+		}
+	default:
+		return nil, fmt.Errorf("unsupported caption format %q", f)
+	}
+	return []byte(b.String()), nil
+}
+
+// formatClock formats d as HH:MM:SS followed by sep and milliseconds.
+func formatClock(d time.Duration, sep byte) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// formatSBVClock formats d as H:MM:SS.mmm.
+func formatSBVClock(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// FormatTimestamp formats d for display as M:SS, or H:MM:SS past an hour.
+func FormatTimestamp(d time.Duration) string {
+	s := int(d.Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// WatchURL links to a video at a position, rounded down to the second.
+func WatchURL(videoID string, at time.Duration) string {
+	return fmt.Sprintf("https://youtu.be/%s?t=%d", videoID, int(at.Seconds()))
+}
@@ -0,0 +1,179 @@
+// Package trash keeps previous versions of output files that are about to be
+// replaced, so hand-edited transcripts can be recovered after an overwrite.
+//
+// Trashed files live under <root>/.trash/<timestamp>/<path relative to root>.
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dir is the name of the trash directory inside an output root.
+const Dir = ".trash"
+
+const stampLayout = "20060102T150405.000000000"
+
+// DefaultRetention is how long trashed files are kept before Purge removes them.
+const DefaultRetention = 30 * 24 * time.Hour
+
+// Entry is a single trashed file.
+type Entry struct {
+	// Path is the file's original path relative to the root.
+	Path string `json:"path"`
+	// TrashedAt is when the file was moved to the trash.
+	TrashedAt time.Time `json:"trashed_at"`
+	// TrashPath is where the trashed copy currently lives.
+	TrashPath string `json:"trash_path"`
+}
+
+// Move moves path, which must be inside root, into root's trash. It is a
+// no-op returning "" if path does not exist.
+func Move(root, path string) (string, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+
+	rel, err := relPath(root, path)
+	if err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(root, Dir, time.Now().UTC().Format(stampLayout), rel)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", fmt.Errorf("error creating trash directory: %w", err)
+	}
+	if err := os.Rename(path, dest); err != nil {
+		return "", fmt.Errorf("error moving %s to trash: %w", path, err)
+	}
+	return dest, nil
+}
+
+// List returns the entries in root's trash, newest first.
+func List(root string) ([]Entry, error) {
+	trashDir := filepath.Join(root, Dir)
+	stamps, err := os.ReadDir(trashDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading trash: %w", err)
+	}
+
+	var entries []Entry
+	for _, stamp := range stamps {
+		trashedAt, err := time.Parse(stampLayout, stamp.Name())
+		if err != nil || !stamp.IsDir() {
+			continue
+		}
+		base := filepath.Join(trashDir, stamp.Name())
+		err = filepath.WalkDir(base, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(base, path)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, Entry{Path: filepath.ToSlash(rel), TrashedAt: trashedAt, TrashPath: path})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error reading trash: %w", err)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].TrashedAt.After(entries[j].TrashedAt)
+	})
+	return entries, nil
+}
+
+// Latest returns the most recently trashed version of rel.
+func Latest(root, rel string) (Entry, error) {
+	entries, err := List(root)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	for _, e := range entries {
+		if e.Path == rel {
+			return e, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("no trashed version of %s found", rel)
+}
+
+// Restore moves the most recent trashed version of rel back into place. If a
+// file currently exists at that path it is trashed first, so a restore can
+// itself be undone.
+func Restore(root, rel string) (Entry, error) {
+	e, err := Latest(root, rel)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	dest := filepath.Join(root, filepath.FromSlash(e.Path))
+	if _, err := Move(root, dest); err != nil {
+		return Entry{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return Entry{}, fmt.Errorf("error creating directory: %w", err)
+	}
+	if err := os.Rename(e.TrashPath, dest); err != nil {
+		return Entry{}, fmt.Errorf("error restoring %s: %w", e.Path, err)
+	}
+	removeEmptyDirs(filepath.Join(root, Dir), filepath.Dir(e.TrashPath))
+	return e, nil
+}
+
+// Purge deletes trash batches older than retention and returns how many were removed.
+func Purge(root string, retention time.Duration) (int, error) {
+	trashDir := filepath.Join(root, Dir)
+	stamps, err := os.ReadDir(trashDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading trash: %w", err)
+	}
+
+	cutoff := time.Now().Add(-retention)
+	removed := 0
+	for _, stamp := range stamps {
+		trashedAt, err := time.Parse(stampLayout, stamp.Name())
+		if err != nil || !trashedAt.Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(trashDir, stamp.Name())); err != nil {
+			return removed, fmt.Errorf("error purging trash: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func relPath(root, path string) (string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not inside %s", path, root)
+	}
+	return rel, nil
+}
+
+// removeEmptyDirs removes dir and its parents up to, but not including, stop
+// for as long as they are empty.
+func removeEmptyDirs(stop, dir string) {
+	for dir != stop && strings.HasPrefix(dir, stop) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
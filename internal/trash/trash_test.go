@@ -0,0 +1,95 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMoveAndRestore(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "abc-Title.txt")
+
+	if err := os.WriteFile(path, []byte("hand edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Move(root, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("file still present after Move: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("redownloaded"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := List(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != "abc-Title.txt" {
+		t.Fatalf("List() = %+v, want one entry for abc-Title.txt", entries)
+	}
+
+	if _, err := Restore(root, "abc-Title.txt"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hand edited" {
+		t.Errorf("restored content = %q, want %q", got, "hand edited")
+	}
+
+	// The version replaced by the restore is itself in the trash.
+	entries, err = List(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("List() after restore returned %d entries, want 1", len(entries))
+	}
+}
+
+func TestMoveMissingFile(t *testing.T) {
+	root := t.TempDir()
+	dest, err := Move(root, filepath.Join(root, "missing.txt"))
+	if err != nil || dest != "" {
+		t.Errorf("Move() of missing file = %q, %v; want \"\", nil", dest, err)
+	}
+}
+
+func TestMoveOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	other := filepath.Join(t.TempDir(), "x.txt")
+	if err := os.WriteFile(other, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Move(root, other); err == nil {
+		t.Error("Move() of file outside root succeeded, want error")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	root := t.TempDir()
+	old := filepath.Join(root, Dir, time.Now().Add(-48*time.Hour).UTC().Format(stampLayout))
+	recent := filepath.Join(root, Dir, time.Now().UTC().Format(stampLayout))
+	for _, dir := range []string{old, recent} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := Purge(root, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("Purge() removed %d batches, want 1", removed)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("recent batch was purged: %v", err)
+	}
+}
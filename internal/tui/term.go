@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeyCode identifies a key press.
+type KeyCode int
+
+const (
+	KeyRune KeyCode = iota
+	KeyUp
+	KeyDown
+	KeyPageUp
+	KeyPageDown
+	KeyEnter
+	KeyEsc
+	KeyBackspace
+	KeyCtrlC
+	// KeyUnknown is an escape sequence or control key Update ignores.
+	KeyUnknown
+)
+
+// Key is a key press. Rune is set for KeyRune.
+type Key struct {
+	Code KeyCode
+	Rune rune
+}
+
+// ReadKey reads one key press from a terminal in raw mode.
+func ReadKey(r *bufio.Reader) (Key, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+	switch c {
+	case '\r', '\n':
+		return Key{Code: KeyEnter}, nil
+	case 0x7f, 0x08:
+		return Key{Code: KeyBackspace}, nil
+	case 0x03:
+		return Key{Code: KeyCtrlC}, nil
+	case 0x1b:
+		return readEscape(r)
+	}
+	if c < 0x20 {
+		return Key{Code: KeyUnknown}, nil
+	}
+	return Key{Code: KeyRune, Rune: c}, nil
+}
+
+// readEscape reads the rest of an escape sequence. A lone ESC, with nothing
+// buffered after it, is the escape key.
+func readEscape(r *bufio.Reader) (Key, error) {
+	if r.Buffered() == 0 {
+		return Key{Code: KeyEsc}, nil
+	}
+	if b, _ := r.Peek(1); b[0] != '[' && b[0] != 'O' {
+		return Key{Code: KeyEsc}, nil
+	}
+	r.ReadByte()
+	var seq strings.Builder
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return Key{}, err
+		}
+		seq.WriteByte(b)
+		if b >= 0x40 && b <= 0x7e {
+			break
+		}
+	}
+	switch seq.String() {
+	case "A":
+		return Key{Code: KeyUp}, nil
+	case "B":
+		return Key{Code: KeyDown}, nil
+	case "5~":
+		return Key{Code: KeyPageUp}, nil
+	case "6~":
+		return Key{Code: KeyPageDown}, nil
+	}
+	return Key{Code: KeyUnknown}, nil
+}
+
+// Run shows the browser on the terminal in and out until the user chooses
+// videos or quits, and returns the chosen IDs, or none if the user quit.
+// The terminal is put in raw mode, with stty on Unix and the console API
+// on Windows, and restored before Run returns.
+func Run(in, out *os.File, m *Model) ([]string, error) {
+	restore, err := makeRaw(in, out)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+
+	// Switch to the alternate screen and hide the cursor, and back again.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	r := bufio.NewReader(in)
+	for {
+		width, height := size(in, out)
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+strings.Join(m.Render(width, height), "\r\n"))
+
+		k, err := ReadKey(r)
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch m.Update(k) {
+		case ActionQuit:
+			return nil, nil
+		case ActionChoose:
+			return m.Selected(), nil
+		}
+	}
+}
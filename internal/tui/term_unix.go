@@ -0,0 +1,43 @@
+//go:build !windows
+
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// makeRaw puts the terminal in raw mode and returns a function restoring
+// its previous settings.
+func makeRaw(tty, _ *os.File) (func(), error) {
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("error reading terminal settings: %w", err)
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("error setting terminal to raw mode: %w", err)
+	}
+	return func() { stty(tty, saved) }, nil
+}
+
+// size returns the terminal's width and height, or 80x24 if it can't be
+// read.
+func size(tty, _ *os.File) (int, int) {
+	out, err := stty(tty, "size")
+	if err == nil {
+		var rows, cols int
+		if _, err := fmt.Sscan(out, &rows, &cols); err == nil && rows > 0 && cols > 0 {
+			return cols, rows
+		}
+	}
+	return 80, 24
+}
+
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleMode             = kernel32.NewProc("SetConsoleMode")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+)
+
+// Console modes makeRaw changes.
+const (
+	enableProcessedInput            = 0x0001
+	enableLineInput                 = 0x0002
+	enableEchoInput                 = 0x0004
+	enableVirtualTerminalInput      = 0x0200
+	enableVirtualTerminalProcessing = 0x0004
+)
+
+// consoleScreenBufferInfo is the console API's CONSOLE_SCREEN_BUFFER_INFO.
+type consoleScreenBufferInfo struct {
+	size, cursorPosition     [2]int16
+	attributes               uint16
+	left, top, right, bottom int16
+	maximumWindowSize        [2]int16
+}
+
+func setConsoleMode(f *os.File, mode uint32) error {
+	if r, _, err := procSetConsoleMode.Call(f.Fd(), uintptr(mode)); r == 0 {
+		return err
+	}
+	return nil
+}
+
+// makeRaw puts the console in raw mode, reading keys as the escape
+// sequences a Unix terminal sends and interpreting the escape sequences
+// written to out, and returns a function restoring its previous modes.
+func makeRaw(in, out *os.File) (func(), error) {
+	var inMode, outMode uint32
+	if err := syscall.GetConsoleMode(syscall.Handle(in.Fd()), &inMode); err != nil {
+		return nil, fmt.Errorf("error reading console mode: %w", err)
+	}
+	if err := syscall.GetConsoleMode(syscall.Handle(out.Fd()), &outMode); err != nil {
+		return nil, fmt.Errorf("error reading console mode: %w", err)
+	}
+	raw := inMode&^(enableProcessedInput|enableLineInput|enableEchoInput) | enableVirtualTerminalInput
+	if err := setConsoleMode(in, raw); err != nil {
+		return nil, fmt.Errorf("error setting console to raw mode: %w", err)
+	}
+	if err := setConsoleMode(out, outMode|enableVirtualTerminalProcessing); err != nil {
+		setConsoleMode(in, inMode)
+		return nil, fmt.Errorf("error enabling escape sequences in the console: %w", err)
+	}
+	return func() {
+		setConsoleMode(in, inMode)
+		setConsoleMode(out, outMode)
+	}, nil
+}
+
+// size returns the console window's width and height, or 80x24 if it
+// can't be read.
+func size(_, out *os.File) (int, int) {
+	var info consoleScreenBufferInfo
+	if r, _, _ := procGetConsoleScreenBufferInfo.Call(out.Fd(), uintptr(unsafe.Pointer(&info))); r != 0 {
+		if cols, rows := int(info.right-info.left)+1, int(info.bottom-info.top)+1; cols > 0 && rows > 0 {
+			return cols, rows
+		}
+	}
+	return 80, 24
+}
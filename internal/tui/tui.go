@@ -0,0 +1,296 @@
+// Package tui is a small terminal browser for picking videos from a list:
+// move with the arrow keys, search, sort, select several with space, and
+// press enter to choose them.
+//
+// Model holds the browser's state and is driven by Update, so it can be
+// tested without a terminal; Run connects a Model to one.
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Item is a video in the list.
+type Item struct {
+	VideoID   string
+	Title     string
+	Published time.Time
+	Views     uint64
+	// Local is set if the video's transcript is already downloaded.
+	Local bool
+}
+
+// SortKey orders the list.
+type SortKey int
+
+const (
+	SortDate SortKey = iota
+	SortViews
+	SortTitle
+)
+
+func (k SortKey) String() string {
+	switch k {
+	case SortViews:
+		return "views"
+	case SortTitle:
+		return "title"
+	default:
+		return "date"
+	}
+}
+
+// Action is what the caller should do after a key press.
+type Action int
+
+const (
+	ActionNone Action = iota
+	// ActionQuit ends the browser without choosing anything.
+	ActionQuit
+	// ActionChoose ends the browser with the selected videos chosen.
+	ActionChoose
+)
+
+// Model is the browser's state.
+type Model struct {
+	items     []Item
+	visible   []Item
+	selected  map[string]bool
+	sort      SortKey
+	filter    string
+	searching bool
+	cursor    int
+	offset    int
+}
+
+// NewModel returns a browser over items, newest first.
+func NewModel(items []Item) *Model {
+	m := &Model{items: items, selected: map[string]bool{}}
+	m.refresh()
+	return m
+}
+
+// Visible returns the items that match the search, in the current order.
+func (m *Model) Visible() []Item {
+	return m.visible
+}
+
+// Selected returns the IDs of the selected videos in the current order,
+// including those hidden by the search.
+func (m *Model) Selected() []string {
+	var ids []string
+	for _, it := range m.sorted(m.items) {
+		if m.selected[it.VideoID] {
+			ids = append(ids, it.VideoID)
+		}
+	}
+	return ids
+}
+
+// Update applies a key press.
+func (m *Model) Update(k Key) Action {
+	if m.searching {
+		switch k.Code {
+		case KeyEnter:
+			m.searching = false
+		case KeyEsc:
+			m.searching = false
+			m.setFilter("")
+		case KeyBackspace:
+			if m.filter != "" {
+				_, size := utf8.DecodeLastRuneInString(m.filter)
+				m.setFilter(m.filter[:len(m.filter)-size])
+			}
+		case KeyRune:
+			m.setFilter(m.filter + string(k.Rune))
+		case KeyCtrlC:
+			return ActionQuit
+		}
+		return ActionNone
+	}
+
+	switch k.Code {
+	case KeyUp:
+		m.move(-1)
+	case KeyDown:
+		m.move(1)
+	case KeyPageUp:
+		m.move(-10)
+	case KeyPageDown:
+		m.move(10)
+	case KeyEnter:
+		if len(m.selected) == 0 && len(m.visible) > 0 {
+			m.selected[m.visible[m.cursor].VideoID] = true
+		}
+		if len(m.selected) > 0 {
+			return ActionChoose
+		}
+	case KeyEsc:
+		m.setFilter("")
+	case KeyCtrlC:
+		return ActionQuit
+	case KeyRune:
+		switch k.Rune {
+		case 'k':
+			m.move(-1)
+		case 'j':
+			m.move(1)
+		case ' ':
+			if len(m.visible) > 0 {
+				id := m.visible[m.cursor].VideoID
+				if m.selected[id] {
+					delete(m.selected, id)
+				} else {
+					m.selected[id] = true
+				}
+				m.move(1)
+			}
+		case 'a':
+			m.toggleAll()
+		case '/':
+			m.searching = true
+		case 's':
+			m.sort = (m.sort + 1) % 3
+			m.refresh()
+		case 'q':
+			return ActionQuit
+		}
+	}
+	return ActionNone
+}
+
+// toggleAll selects every visible item, or clears them if all already are.
+func (m *Model) toggleAll() {
+	all := true
+	for _, it := range m.visible {
+		all = all && m.selected[it.VideoID]
+	}
+	for _, it := range m.visible {
+		if all {
+			delete(m.selected, it.VideoID)
+		} else {
+			m.selected[it.VideoID] = true
+		}
+	}
+}
+
+func (m *Model) move(n int) {
+	m.cursor = max(0, min(m.cursor+n, len(m.visible)-1))
+}
+
+func (m *Model) setFilter(f string) {
+	m.filter = f
+	m.offset = 0
+	m.refresh()
+}
+
+// refresh recomputes the visible items after the search or order changed,
+// keeping the cursor on the same video if it is still shown.
+func (m *Model) refresh() {
+	var current string
+	if m.cursor < len(m.visible) {
+		current = m.visible[m.cursor].VideoID
+	}
+	m.visible = m.visible[:0]
+	query := strings.ToLower(m.filter)
+	for _, it := range m.sorted(m.items) {
+		if query == "" || strings.Contains(strings.ToLower(it.Title), query) || strings.Contains(strings.ToLower(it.VideoID), query) {
+			m.visible = append(m.visible, it)
+		}
+	}
+	m.cursor = 0
+	for i, it := range m.visible {
+		if it.VideoID == current {
+			m.cursor = i
+		}
+	}
+}
+
+func (m *Model) sorted(items []Item) []Item {
+	out := slices.Clone(items)
+	slices.SortStableFunc(out, func(a, b Item) int {
+		switch m.sort {
+		case SortViews:
+			return cmp.Compare(b.Views, a.Views)
+		case SortTitle:
+			return cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		default:
+			return b.Published.Compare(a.Published)
+		}
+	})
+	return out
+}
+
+// Render draws the browser in a width by height terminal, as lines without
+// line endings.
+func (m *Model) Render(width, height int) []string {
+	header := fmt.Sprintf("%d videos, %d selected, sorted by %s", len(m.items), len(m.selected), m.sort)
+	if m.filter != "" || m.searching {
+		header += "  search: " + m.filter
+		if m.searching {
+			header += "_"
+		}
+	}
+	footer := "up/down move  space select  a all  / search  s sort  enter download  q quit"
+	lines := []string{truncate(header, width), ""}
+
+	rows := max(1, height-len(lines)-2)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+	for i := m.offset; i < len(m.visible) && i < m.offset+rows; i++ {
+		lines = append(lines, truncate(m.row(i), width))
+	}
+	if len(m.visible) == 0 {
+		lines = append(lines, "  No videos match.")
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	return append(lines, truncate(footer, width))
+}
+
+func (m *Model) row(i int) string {
+	it := m.visible[i]
+	cursor, check, local := " ", "[ ]", "     "
+	if i == m.cursor {
+		cursor = ">"
+	}
+	if m.selected[it.VideoID] {
+		check = "[x]"
+	}
+	if it.Local {
+		local = "saved"
+	}
+	date := "          "
+	if !it.Published.IsZero() {
+		date = it.Published.Local().Format(time.DateOnly)
+	}
+	return fmt.Sprintf("%s %s %s %s %12s  %s  %s", cursor, check, local, date, formatCount(it.Views), it.VideoID, it.Title)
+}
+
+// formatCount formats n with thousands separators.
+func formatCount(n uint64) string {
+	s := fmt.Sprint(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// truncate shortens s to at most width runes.
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:width])
+}
@@ -0,0 +1,190 @@
+package tui
+
+import (
+	"bufio"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testItems() []Item {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	return []Item{
+		{VideoID: "aaa", Title: "Intro to Go", Published: day(1), Views: 300},
+		{VideoID: "bbb", Title: "Generics deep dive", Published: day(3), Views: 100, Local: true},
+		{VideoID: "ccc", Title: "Go concurrency", Published: day(2), Views: 200},
+	}
+}
+
+func ids(items []Item) []string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.VideoID)
+	}
+	return out
+}
+
+func runes(s string) []Key {
+	var keys []Key
+	for _, r := range s {
+		keys = append(keys, Key{Code: KeyRune, Rune: r})
+	}
+	return keys
+}
+
+func TestModel(t *testing.T) {
+	down := Key{Code: KeyDown}
+	enter := Key{Code: KeyEnter}
+	esc := Key{Code: KeyEsc}
+
+	tests := []struct {
+		name        string
+		keys        []Key
+		wantVisible []string
+		wantAction  Action
+		wantChosen  []string
+	}{
+		{
+			name:        "newest first",
+			wantVisible: []string{"bbb", "ccc", "aaa"},
+		},
+		{
+			name:        "sort by views",
+			keys:        runes("s"),
+			wantVisible: []string{"aaa", "ccc", "bbb"},
+		},
+		{
+			name:        "sort by title",
+			keys:        runes("ss"),
+			wantVisible: []string{"bbb", "ccc", "aaa"},
+		},
+		{
+			name:        "search",
+			keys:        append(runes("/GO"), enter),
+			wantVisible: []string{"ccc", "aaa"},
+		},
+		{
+			name:        "search backspace",
+			keys:        append(runes("/gox"), Key{Code: KeyBackspace}),
+			wantVisible: []string{"ccc", "aaa"},
+		},
+		{
+			name:        "escape clears search",
+			keys:        append(runes("/go"), esc),
+			wantVisible: []string{"bbb", "ccc", "aaa"},
+		},
+		{
+			name:        "enter chooses the video under the cursor",
+			keys:        []Key{down, enter},
+			wantVisible: []string{"bbb", "ccc", "aaa"},
+			wantAction:  ActionChoose,
+			wantChosen:  []string{"ccc"},
+		},
+		{
+			name:        "multi-select",
+			keys:        append(runes(" j "), enter),
+			wantVisible: []string{"bbb", "ccc", "aaa"},
+			wantAction:  ActionChoose,
+			wantChosen:  []string{"bbb", "aaa"},
+		},
+		{
+			name:        "select all visible",
+			keys:        append(append(runes("/go"), enter), append(runes("a"), enter)...),
+			wantVisible: []string{"ccc", "aaa"},
+			wantAction:  ActionChoose,
+			wantChosen:  []string{"ccc", "aaa"},
+		},
+		{
+			name:        "quit",
+			keys:        runes(" q"),
+			wantVisible: []string{"bbb", "ccc", "aaa"},
+			wantAction:  ActionQuit,
+		},
+		{
+			name:        "cursor stops at the end",
+			keys:        []Key{down, down, down, down, enter},
+			wantVisible: []string{"bbb", "ccc", "aaa"},
+			wantAction:  ActionChoose,
+			wantChosen:  []string{"aaa"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(testItems())
+			var action Action
+			for _, k := range tt.keys {
+				if action = m.Update(k); action != ActionNone {
+					break
+				}
+			}
+			if got := ids(m.Visible()); !slices.Equal(got, tt.wantVisible) {
+				t.Errorf("visible = %v, want %v", got, tt.wantVisible)
+			}
+			if action != tt.wantAction {
+				t.Errorf("action = %v, want %v", action, tt.wantAction)
+			}
+			if action == ActionChoose {
+				if got := m.Selected(); !slices.Equal(got, tt.wantChosen) {
+					t.Errorf("chosen = %v, want %v", got, tt.wantChosen)
+				}
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	m := NewModel(testItems())
+	m.Update(Key{Code: KeyRune, Rune: ' '})
+	lines := m.Render(200, 8)
+	if len(lines) != 8 {
+		t.Fatalf("got %d lines, want 8", len(lines))
+	}
+	if want := "3 videos, 1 selected, sorted by date"; lines[0] != want {
+		t.Errorf("header = %q, want %q", lines[0], want)
+	}
+	if want := "  [x] saved 2024-01-03          100  bbb  Generics deep dive"; lines[2] != want {
+		t.Errorf("first row = %q, want %q", lines[2], want)
+	}
+	if !strings.HasPrefix(lines[3], "> [ ]       2024-01-02") {
+		t.Errorf("cursor row = %q", lines[3])
+	}
+
+	// A short terminal scrolls to keep the cursor in view.
+	m.Update(Key{Code: KeyDown})
+	lines = m.Render(40, 5)
+	if len(lines) != 5 || !strings.HasPrefix(lines[2], "> [ ]") || len([]rune(lines[2])) > 40 {
+		t.Errorf("scrolled render = %q", lines)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Key
+	}{
+		{"a/", []Key{{Code: KeyRune, Rune: 'a'}, {Code: KeyRune, Rune: '/'}}},
+		{"\x1b[A\x1b[B", []Key{{Code: KeyUp}, {Code: KeyDown}}},
+		{"\x1b[5~\x1b[6~", []Key{{Code: KeyPageUp}, {Code: KeyPageDown}}},
+		{"\x1bOA", []Key{{Code: KeyUp}}},
+		{"\x1b[1;5C", []Key{{Code: KeyUnknown}}},
+		{"\r\x7f\x03", []Key{{Code: KeyEnter}, {Code: KeyBackspace}, {Code: KeyCtrlC}}},
+		{"\x1b", []Key{{Code: KeyEsc}}},
+		{"é", []Key{{Code: KeyRune, Rune: 'é'}}},
+	}
+	for _, tt := range tests {
+		r := bufio.NewReader(strings.NewReader(tt.in))
+		var got []Key
+		for range tt.want {
+			k, err := ReadKey(r)
+			if err != nil {
+				t.Fatalf("ReadKey(%q): %v", tt.in, err)
+			}
+			got = append(got, k)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ReadKey(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
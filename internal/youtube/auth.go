@@ -0,0 +1,200 @@
+package youtube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/clock"
+	"golang.org/x/oauth2"
+)
+
+// Google's endpoints for inspecting and revoking tokens. Tests point them
+// at a local server.
+var (
+	tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+	revokeURL    = "https://oauth2.googleapis.com/revoke"
+)
+
+// TokenStatus describes the OAuth token saved at a path.
+type TokenStatus struct {
+	Path string `json:"path"`
+	// Storage describes where the token is kept, such as the file or the
+	// keyring.
+	Storage string `json:"storage"`
+	Present bool   `json:"present"`
+	// Expiry is when the access token expires. Expired tokens are
+	// refreshed on the next API call if Refreshable.
+	Expiry      time.Time `json:"expiry,omitzero"`
+	Expired     bool      `json:"expired"`
+	Refreshable bool      `json:"refreshable"`
+	// Scopes are the scopes Google says the access token grants. They are
+	// only looked up while the token is unexpired.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// InspectToken reports on the token saved at tokenPath without refreshing
+// it or starting an OAuth flow. Of opts, only WithTransport, WithClock,
+// WithTokenStorage, and WithCredentials apply.
+func InspectToken(tokenPath string, opts ...Option) (*TokenStatus, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	store, err := o.tokenStore(tokenPath)
+	if err != nil {
+		return nil, err
+	}
+	s := &TokenStatus{Path: tokenPath, Storage: store.String()}
+	tok, err := store.Load()
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read token: %w", err)
+	}
+	s.Present = true
+	s.Expiry = tok.Expiry
+	s.Expired = tokenExpired(tok, clock.Or(o.clock).Now())
+	s.Refreshable = tok.RefreshToken != ""
+	if s.Expired {
+		return s, nil
+	}
+	if s.Scopes, err = tokenScopes(o.httpClient(), tok.AccessToken); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// tokenScopes asks Google which scopes accessToken grants.
+func tokenScopes(client *http.Client, accessToken string) ([]string, error) {
+	resp, err := client.Get(tokenInfoURL + "?" + url.Values{"access_token": {accessToken}}.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error looking up token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error looking up token: %s", resp.Status)
+	}
+	var info struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("error decoding token info: %w", err)
+	}
+	return strings.Fields(info.Scope), nil
+}
+
+// RefreshToken exchanges the refresh token saved at tokenPath for a new
+// access token, whether or not the current one has expired, and saves it.
+// Of opts, only WithTransport, WithTokenStorage, and WithCredentials apply.
+func RefreshToken(oauthPath, tokenPath string, opts ...Option) (*oauth2.Token, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	config, err := o.oauthConfig(oauthPath)
+	if err != nil {
+		return nil, err
+	}
+	store, err := o.tokenStore(tokenPath)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read token: %w", err)
+	}
+	if tok.RefreshToken == "" {
+		return nil, fmt.Errorf("token in %s has no refresh token", tokenPath)
+	}
+
+	// Without an access token the token source has to refresh.
+	stale := &oauth2.Token{RefreshToken: tok.RefreshToken}
+	newTok, err := config.TokenSource(o.context(), stale).Token()
+	if err != nil {
+		return nil, fmt.Errorf("unable to refresh token: %w", err)
+	}
+	if err := store.Save(newTok); err != nil {
+		return nil, err
+	}
+	return newTok, nil
+}
+
+// MoveToken moves the token saved at fromPath to toPath, replacing any token
+// there. Of opts, only WithTokenStorage applies.
+func MoveToken(fromPath, toPath string, opts ...Option) error {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	from, err := o.tokenStore(fromPath)
+	if err != nil {
+		return err
+	}
+	to, err := o.tokenStore(toPath)
+	if err != nil {
+		return err
+	}
+	tok, err := from.Load()
+	if err != nil {
+		return fmt.Errorf("unable to read token: %w", err)
+	}
+	if err := to.Save(tok); err != nil {
+		return err
+	}
+	return from.Delete()
+}
+
+// DeleteToken deletes the token saved at tokenPath without revoking it, as
+// when Google can't be reached to revoke it. Of opts, only WithTokenStorage
+// applies.
+func DeleteToken(tokenPath string, opts ...Option) error {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	store, err := o.tokenStore(tokenPath)
+	if err != nil {
+		return err
+	}
+	return store.Delete()
+}
+
+// RevokeToken revokes the token saved at tokenPath at Google, so it can't
+// be used again anywhere, and deletes it. A token Google no longer
+// recognizes is deleted all the same. Of opts, only WithTransport,
+// WithTokenStorage, and WithCredentials apply.
+func RevokeToken(tokenPath string, opts ...Option) error {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	store, err := o.tokenStore(tokenPath)
+	if err != nil {
+		return err
+	}
+	tok, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("unable to read token: %w", err)
+	}
+	// Revoking the refresh token also revokes the access tokens it issued.
+	token := tok.RefreshToken
+	if token == "" {
+		token = tok.AccessToken
+	}
+	resp, err := o.httpClient().PostForm(revokeURL, url.Values{"token": {token}})
+	if err != nil {
+		return fmt.Errorf("error revoking token: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("error revoking token: %s", resp.Status)
+	}
+	return store.Delete()
+}
@@ -0,0 +1,170 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/n2p5/ytt/internal/clock"
+	"golang.org/x/oauth2"
+)
+
+// withGoogle points Google's token endpoints at a test server for the
+// duration of the test.
+func withGoogle(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	oldInfo, oldRevoke := tokenInfoURL, revokeURL
+	tokenInfoURL, revokeURL = srv.URL+"/tokeninfo", srv.URL+"/revoke"
+	t.Cleanup(func() { tokenInfoURL, revokeURL = oldInfo, oldRevoke })
+	return srv
+}
+
+func writeToken(t *testing.T, tok *oauth2.Token) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token.json")
+	if err := saveToken(path, tok); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInspectToken(t *testing.T) {
+	withGoogle(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tokeninfo" || r.URL.Query().Get("access_token") != "live" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"scope": "https://www.googleapis.com/auth/youtube.readonly https://www.googleapis.com/auth/youtube.force-ssl", "expires_in": "1200"}`)
+	})
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := WithClock(clock.NewFake(now))
+
+	s, err := InspectToken(filepath.Join(t.TempDir(), "missing.json"), clk)
+	if err != nil || s.Present {
+		t.Errorf("InspectToken(missing) = %+v, %v; want not present", s, err)
+	}
+
+	expired := writeToken(t, &oauth2.Token{AccessToken: "old", RefreshToken: "r", Expiry: now.Add(-time.Hour)})
+	s, err = InspectToken(expired, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Present || !s.Expired || !s.Refreshable || s.Scopes != nil {
+		t.Errorf("InspectToken(expired) = %+v, want present, expired, refreshable, without scopes", s)
+	}
+
+	live := writeToken(t, &oauth2.Token{AccessToken: "live", Expiry: now.Add(time.Hour)})
+	s, err = InspectToken(live, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://www.googleapis.com/auth/youtube.readonly", "https://www.googleapis.com/auth/youtube.force-ssl"}
+	if s.Expired || s.Refreshable || !slices.Equal(s.Scopes, want) {
+		t.Errorf("InspectToken(live) = %+v, want unexpired, not refreshable, scopes %q", s, want)
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	srv := withGoogle(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/token" || r.FormValue("refresh_token") != "r" {
+			http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "new", "token_type": "Bearer", "expires_in": 3600}`)
+	})
+	secret := filepath.Join(t.TempDir(), "oauth.json")
+	config := fmt.Sprintf(`{"installed": {"client_id": "id", "client_secret": "secret", "auth_uri": "%[1]s/auth", "token_uri": "%[1]s/token", "redirect_uris": ["http://localhost"]}}`, srv.URL)
+	if err := os.WriteFile(secret, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The access token is refreshed even though it hasn't expired.
+	path := writeToken(t, &oauth2.Token{AccessToken: "old", RefreshToken: "r", Expiry: time.Now().Add(time.Hour)})
+	tok, err := RefreshToken(secret, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := tokenFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "new" || saved.AccessToken != "new" || saved.RefreshToken != "r" {
+		t.Errorf("RefreshToken() = %+v, saved %+v; want access token new keeping refresh token r", tok, saved)
+	}
+
+	noRefresh := writeToken(t, &oauth2.Token{AccessToken: "old"})
+	if _, err := RefreshToken(secret, noRefresh); err == nil {
+		t.Error("RefreshToken() without a refresh token succeeded")
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	var revoked []string
+	withGoogle(t, func(w http.ResponseWriter, r *http.Request) {
+		revoked = append(revoked, r.FormValue("token"))
+		if r.FormValue("token") == "gone" {
+			http.Error(w, `{"error": "invalid_token"}`, http.StatusBadRequest)
+		}
+	})
+
+	path := writeToken(t, &oauth2.Token{AccessToken: "a", RefreshToken: "r"})
+	if err := RevokeToken(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("token file still there after RevokeToken: %v", err)
+	}
+
+	// A token Google has already forgotten is still deleted.
+	gone := writeToken(t, &oauth2.Token{AccessToken: "gone"})
+	if err := RevokeToken(gone); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(gone); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("token file still there after RevokeToken: %v", err)
+	}
+
+	if !slices.Equal(revoked, []string{"r", "gone"}) {
+		t.Errorf("revoked %q, want the refresh token, then the access token without one", revoked)
+	}
+}
+
+func TestMoveToken(t *testing.T) {
+	from := writeToken(t, &oauth2.Token{AccessToken: "a", RefreshToken: "r"})
+	to := filepath.Join(t.TempDir(), "token-UC1.json")
+	if err := MoveToken(from, to); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(from); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("token still at %s after MoveToken: %v", from, err)
+	}
+	tok, err := tokenFromFile(to)
+	if err != nil || tok.RefreshToken != "r" {
+		t.Errorf("moved token = %+v, %v; want the refresh token kept", tok, err)
+	}
+	if err := MoveToken(from, to); err == nil {
+		t.Error("MoveToken from a missing token succeeded")
+	}
+}
+
+func TestDeleteToken(t *testing.T) {
+	path := writeToken(t, &oauth2.Token{AccessToken: "a", RefreshToken: "r"})
+	if err := DeleteToken(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("token file still there after DeleteToken: %v", err)
+	}
+	if err := DeleteToken(path); err != nil {
+		t.Errorf("DeleteToken of a missing token = %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package youtube
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/youtube/v3"
+)
+
+// BackupManifestFile is the name of the manifest written at the root of a caption backup.
+const BackupManifestFile = "manifest.json"
+
+// BackupEntry describes one caption track saved in a backup.
+type BackupEntry struct {
+	VideoID      string `json:"video_id"`
+	CaptionID    string `json:"caption_id"`
+	Language     string `json:"language"`
+	Name         string `json:"name,omitempty"`
+	TrackKind    string `json:"track_kind"`
+	IsAutoSynced bool   `json:"is_auto_synced"`
+	LastUpdated  string `json:"last_updated"`
+	ContentType  string `json:"content_type,omitempty"`
+	File         string `json:"file"`
+}
+
+// BackupManifest lists every caption track saved in a backup directory.
+type BackupManifest struct {
+	ChannelID string        `json:"channel_id"`
+	CreatedAt string        `json:"created_at"`
+	Entries   []BackupEntry `json:"entries"`
+}
+
+// BackupVideoCaptions downloads every caption track of a video in its original
+// format into dir/<videoID>/ and returns the manifest entries for them.
+func (c *Client) BackupVideoCaptions(videoID, dir string) ([]BackupEntry, error) {
+	tracks, err := c.ListCaptions(videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []BackupEntry
+	for _, track := range tracks {
+		entry, err := c.backupCaption(track, dir)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (c *Client) backupCaption(track CaptionTrack, dir string) (BackupEntry, error) {
+	resp, err := c.Service.Captions.Download(track.CaptionID).Download()
+	if err != nil {
+		return BackupEntry{}, fmt.Errorf("error downloading caption %s: %w", track.CaptionID, err)
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	rel := filepath.Join(track.VideoID, track.CaptionID+captionExt(contentType))
+	path := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return BackupEntry{}, fmt.Errorf("error creating backup directory: %w", err)
+	}
+
+	data, err := c.readCaptions(resp.Body)
+	if err != nil {
+		return BackupEntry{}, fmt.Errorf("error downloading caption %s: %w", track.CaptionID, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return BackupEntry{}, fmt.Errorf("error writing caption %s: %w", track.CaptionID, err)
+	}
+
+	return BackupEntry{
+		VideoID:      track.VideoID,
+		CaptionID:    track.CaptionID,
+		Language:     track.Language,
+		Name:         track.Name,
+		TrackKind:    track.TrackKind,
+		IsAutoSynced: track.IsAutoSynced,
+		LastUpdated:  track.LastUpdated,
+		ContentType:  contentType,
+		File:         filepath.ToSlash(rel),
+	}, nil
+}
+
+// RestoreCaption uploads a backed-up caption track from dir to its video as a
+// new track and returns the new caption ID.
+func (c *Client) RestoreCaption(dir string, entry BackupEntry) (string, error) {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(entry.File)))
+	if err != nil {
+		return "", fmt.Errorf("error opening backup file: %w", err)
+	}
+	defer f.Close()
+
+	caption := &youtube.Caption{
+		Snippet: &youtube.CaptionSnippet{
+			VideoId:  entry.VideoID,
+			Language: entry.Language,
+			Name:     entry.Name,
+		},
+	}
+	var opts []googleapi.MediaOption
+	if entry.ContentType != "" {
+		opts = append(opts, googleapi.ContentType(entry.ContentType))
+	}
+
+	inserted, err := c.Service.Captions.Insert([]string{"snippet"}, caption).Media(f, opts...).Do()
+	if err != nil {
+		return "", fmt.Errorf("error uploading caption for video %s: %w", entry.VideoID, err)
+	}
+	return inserted.Id, nil
+}
+
+// WriteBackupManifest writes m to the manifest file in dir.
+func WriteBackupManifest(dir string, m *BackupManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding backup manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, BackupManifestFile), data, 0644); err != nil {
+		return fmt.Errorf("error writing backup manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadBackupManifest reads the manifest file in dir.
+func ReadBackupManifest(dir string) (*BackupManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, BackupManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup manifest: %w", err)
+	}
+	var m BackupManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error parsing backup manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// captionExt returns a file extension for a caption download's content type.
+func captionExt(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ".caption"
+	}
+	switch mediaType {
+	case "text/vtt":
+		return ".vtt"
+	case "application/x-subrip", "text/srt":
+		return ".srt"
+	case "application/ttml+xml", "text/xml", "application/xml":
+		return ".ttml"
+	case "text/plain":
+		return ".txt"
+	}
+	return ".caption"
+}
@@ -0,0 +1,51 @@
+package youtube
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCaptionExt(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"text/vtt", ".vtt"},
+		{"text/vtt; charset=utf-8", ".vtt"},
+		{"application/x-subrip", ".srt"},
+		{"application/ttml+xml", ".ttml"},
+		{"text/plain", ".txt"},
+		{"application/octet-stream", ".caption"},
+		{"", ".caption"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if got := captionExt(tt.contentType); got != tt.want {
+				t.Errorf("captionExt(%q) = %q, want %q", tt.contentType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackupManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	want := &BackupManifest{
+		ChannelID: "UC123",
+		CreatedAt: "2025-01-02T03:04:05Z",
+		Entries: []BackupEntry{
+			{VideoID: "abc", CaptionID: "cap1", Language: "en", TrackKind: "standard", File: "abc/cap1.vtt"},
+		},
+	}
+
+	if err := WriteBackupManifest(dir, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadBackupManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadBackupManifest() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package youtube
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+)
+
+// promptAuthorization asks the user to authorize ytt at authURL, opening
+// it in the default browser unless noBrowser is set. The URL is always
+// printed on a line of its own, so it can be copied into a browser when
+// none opens.
+func promptAuthorization(w io.Writer, authURL string, noBrowser bool) {
+	if !noBrowser {
+		err := openBrowser(authURL)
+		if err == nil {
+			fmt.Fprintf(w, "Opening your browser to authorize ytt. If it doesn't open, copy this URL into a browser on this machine:\n\n    %s\n\n", authURL)
+			return
+		}
+		fmt.Fprintf(w, "Unable to open a browser: %v\n", err)
+	}
+	fmt.Fprintf(w, "To authorize ytt, open this URL in a browser on this machine:\n\n    %s\n\n", authURL)
+}
+
+// openBrowser opens url in the default browser without waiting for it.
+func openBrowser(url string) error {
+	cmd := browserCommand(runtime.GOOS, url)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// browserCommand returns the command opening url on goos.
+func browserCommand(goos, url string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", url)
+	case "windows":
+		// rundll32 takes the URL verbatim, unlike "cmd /c start", which
+		// splits it at each &.
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	}
+	return exec.Command("xdg-open", url)
+}
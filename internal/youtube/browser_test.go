@@ -0,0 +1,36 @@
+package youtube
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBrowserCommand(t *testing.T) {
+	const url = "https://accounts.google.com/o/oauth2/auth?client_id=x&scope=y"
+	tests := []struct {
+		goos string
+		want []string
+	}{
+		{"linux", []string{"xdg-open", url}},
+		{"freebsd", []string{"xdg-open", url}},
+		{"darwin", []string{"open", url}},
+		{"windows", []string{"rundll32", "url.dll,FileProtocolHandler", url}},
+	}
+	for _, tt := range tests {
+		if got := browserCommand(tt.goos, url).Args; !slices.Equal(got, tt.want) {
+			t.Errorf("browserCommand(%q) = %q, want %q", tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestPromptAuthorizationWithoutBrowser(t *testing.T) {
+	var b strings.Builder
+	promptAuthorization(&b, "https://example.com/auth", true)
+	if !strings.Contains(b.String(), "\n    https://example.com/auth\n") {
+		t.Errorf("prompt doesn't show the URL on a line of its own:\n%s", b.String())
+	}
+	if strings.Contains(b.String(), "Opening") {
+		t.Errorf("prompt claims to open a browser:\n%s", b.String())
+	}
+}
@@ -0,0 +1,221 @@
+package youtube
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// cachedPaths are the list endpoints whose responses carry ETags worth
+// revalidating. Caption downloads are deliberately excluded.
+var cachedPaths = []string{
+	"/youtube/v3/videos",
+	"/youtube/v3/channels",
+	"/youtube/v3/playlistItems",
+}
+
+type cacheEntry struct {
+	ETag        string `json:"etag"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// cacheTransport stores ETagged GET responses on disk and revalidates them
+// with If-None-Match, turning a 304 back into the cached 200 response.
+type cacheTransport struct {
+	base      http.RoundTripper
+	dir       string
+	namespace string
+}
+
+func newCacheTransport(base http.RoundTripper, dir, namespace string) *cacheTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &cacheTransport{base: base, dir: dir, namespace: namespace}
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !isCachedPath(req.URL.Path) {
+		return t.base.RoundTrip(req)
+	}
+
+	key := t.key(req)
+	entry, cached := t.load(key)
+	if cached {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		resp.Body.Close()
+		if os.Getenv("YTT_DEBUG") != "" {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Cache hit (304) for %s\n", req.URL.Path)
+		}
+		return cachedResponse(req, entry), nil
+
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		t.store(key, cacheEntry{
+			ETag:        resp.Header.Get("ETag"),
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        body,
+		})
+	}
+	return resp, nil
+}
+
+// key identifies a request by namespace (the account) and full URL.
+func (t *cacheTransport) key(req *http.Request) string {
+	sum := sha256.Sum256([]byte(t.namespace + "\n" + req.URL.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *cacheTransport) load(key string) (cacheEntry, bool) {
+	data, err := os.ReadFile(filepath.Join(t.dir, key+".json"))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.ETag == "" {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store writes entry atomically. Failures only cost a cache miss next time,
+// so they are not reported.
+func (t *cacheTransport) store(key string, entry cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(t.dir, key+".tmp*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	os.Rename(tmp.Name(), filepath.Join(t.dir, key+".json"))
+}
+
+func cachedResponse(req *http.Request, entry cacheEntry) *http.Response {
+	header := http.Header{}
+	header.Set("ETag", entry.ETag)
+	if entry.ContentType != "" {
+		header.Set("Content-Type", entry.ContentType)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
+
+func isCachedPath(path string) bool {
+	for _, p := range cachedPaths {
+		if strings.HasSuffix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecentChannelIDs returns the IDs of the channels seen in the API responses
+// cached in dir, most recently cached first, for shell completion. Entries
+// that can't be read are skipped.
+func RecentChannelIDs(dir string) []string {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	type cached struct {
+		path    string
+		modTime time.Time
+	}
+	var entries []cached
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, cached{filepath.Join(dir, f.Name()), info.ModTime()})
+	}
+	slices.SortFunc(entries, func(a, b cached) int { return b.modTime.Compare(a.modTime) })
+
+	var ids []string
+	seen := map[string]bool{}
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, e := range entries {
+		data, err := os.ReadFile(e.path)
+		if err != nil {
+			continue
+		}
+		var entry cacheEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		var body struct {
+			Items []struct {
+				Kind    string `json:"kind"`
+				ID      any    `json:"id"`
+				Snippet struct {
+					ChannelID string `json:"channelId"`
+				} `json:"snippet"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(entry.Body, &body); err != nil {
+			continue
+		}
+		for _, item := range body.Items {
+			if id, ok := item.ID.(string); ok && item.Kind == "youtube#channel" {
+				add(id)
+			}
+			add(item.Snippet.ChannelID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,98 @@
+package youtube
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCacheTransport(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"items":[]}`)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newCacheTransport(nil, t.TempDir(), "token.json")}
+
+	for i := range 2 {
+		resp, err := client.Get(server.URL + "/youtube/v3/videos?id=abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != `{"items":[]}` {
+			t.Errorf("request %d: got %d %q, want 200 with cached body", i, resp.StatusCode, body)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Errorf("server saw %d requests (%d revalidated), want 2 (1 revalidated)", requests, notModified)
+	}
+}
+
+func TestCacheTransportSkipsUncachedPaths(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			t.Errorf("unexpected If-None-Match on %s", r.URL.Path)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, "caption body")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newCacheTransport(nil, t.TempDir(), "")}
+	for range 2 {
+		resp, err := client.Get(server.URL + "/youtube/v3/captions/abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestRecentChannelIDs(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string, age time.Duration) {
+		t.Helper()
+		data, err := json.Marshal(cacheEntry{ETag: `"v1"`, Body: []byte(body)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("old", `{"items":[{"kind":"youtube#channel","id":"UCold"}]}`, 2*time.Hour)
+	write("videos", `{"items":[{"kind":"youtube#video","id":"vid1","snippet":{"channelId":"UCnew"}},{"kind":"youtube#video","id":"vid2","snippet":{"channelId":"UCold"}}]}`, time.Hour)
+	write("items", `{"items":[{"kind":"youtube#playlistItem","id":"PLI1","snippet":{"channelId":"UCnewest"}}]}`, 0)
+	write("broken", `not json`, 0)
+
+	got := RecentChannelIDs(dir)
+	want := []string{"UCnewest", "UCnew", "UCold"}
+	if !slices.Equal(got, want) {
+		t.Errorf("RecentChannelIDs = %v, want %v", got, want)
+	}
+	if got := RecentChannelIDs(filepath.Join(dir, "missing")); got != nil {
+		t.Errorf("RecentChannelIDs of a missing dir = %v, want nil", got)
+	}
+}
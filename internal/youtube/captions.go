@@ -0,0 +1,277 @@
+package youtube
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/n2p5/ytt/internal/transcript"
+)
+
+// CaptionTrack represents metadata for a caption track on a video.
+type CaptionTrack struct {
+	CaptionID    string `json:"caption_id"`
+	VideoID      string `json:"video_id"`
+	Language     string `json:"language"`
+	Name         string `json:"name,omitempty"`
+	TrackKind    string `json:"track_kind"`
+	IsAutoSynced bool   `json:"is_auto_synced"`
+	LastUpdated  string `json:"last_updated"`
+}
+
+// UpdatedAt returns LastUpdated as a time, or the zero time if YouTube
+// didn't report one.
+func (t CaptionTrack) UpdatedAt() time.Time {
+	updated, err := time.Parse(time.RFC3339, t.LastUpdated)
+	if err != nil {
+		return time.Time{}
+	}
+	return updated
+}
+
+// ListCaptions retrieves the caption tracks available for a video.
+func (c *Client) ListCaptions(videoID string) ([]CaptionTrack, error) {
+	return c.listCaptions(context.Background(), videoID)
+}
+
+func (c *Client) listCaptions(ctx context.Context, videoID string) ([]CaptionTrack, error) {
+	captionsCall := c.Service.Captions.List([]string{"snippet"}, videoID).Context(ctx)
+	captionsResponse, err := captionsCall.Do()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving captions list: %w", err)
+	}
+
+	tracks := make([]CaptionTrack, 0, len(captionsResponse.Items))
+	for _, caption := range captionsResponse.Items {
+		tracks = append(tracks, CaptionTrack{
+			CaptionID:    caption.Id,
+			VideoID:      caption.Snippet.VideoId,
+			Language:     caption.Snippet.Language,
+			Name:         caption.Snippet.Name,
+			TrackKind:    caption.Snippet.TrackKind,
+			IsAutoSynced: caption.Snippet.IsAutoSynced,
+			LastUpdated:  caption.Snippet.LastUpdated,
+		})
+	}
+	return tracks, nil
+}
+
+// TrackPreference is which kind of caption track to pick when a language
+// has both captions uploaded by a person and YouTube's automatic speech
+// recognition (ASR) captions.
+type TrackPreference string
+
+const (
+	// PreferManual picks uploaded captions over ASR captions.
+	PreferManual TrackPreference = "manual"
+	// PreferASR picks ASR captions over uploaded captions.
+	PreferASR TrackPreference = "asr"
+	// PreferAny picks the first track YouTube lists.
+	PreferAny TrackPreference = "any"
+)
+
+// ParseTrackPreference parses a preference as named on the command line.
+// Empty is PreferManual.
+func ParseTrackPreference(s string) (TrackPreference, error) {
+	switch p := TrackPreference(s); p {
+	case "":
+		return PreferManual, nil
+	case PreferManual, PreferASR, PreferAny:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid track preference %q (want manual, asr, or any)", s)
+}
+
+// IsASR reports whether the track was made by automatic speech
+// recognition.
+func (t CaptionTrack) IsASR() bool {
+	return t.TrackKind == "asr"
+}
+
+// LangMatch is how closely a caption track's language must match the
+// language asked for.
+type LangMatch string
+
+const (
+	// MatchBase matches tracks in any variant of the language, so en
+	// matches en-US and en-GB, and zh-Hans matches zh-CN. The closest
+	// variant is picked first.
+	MatchBase LangMatch = "base"
+	// MatchExact only matches tracks labeled with the language asked for,
+	// ignoring case.
+	MatchExact LangMatch = "exact"
+)
+
+// ParseLangMatch parses a match as named on the command line. Empty is
+// MatchBase.
+func ParseLangMatch(s string) (LangMatch, error) {
+	switch m := LangMatch(s); m {
+	case "":
+		return MatchBase, nil
+	case MatchBase, MatchExact:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid language match %q (want exact or base)", s)
+}
+
+// CaptionChoice is how SelectCaption picks a track.
+type CaptionChoice struct {
+	// Lang is the language asked for, or empty for English.
+	Lang   string
+	Match  LangMatch
+	Prefer TrackPreference
+}
+
+// PreferredCaption returns the track FetchCaptions downloads from tracks:
+// an English or unlabeled track, or else the first track, choosing uploaded
+// captions over ASR captions. tracks must not be empty.
+func PreferredCaption(tracks []CaptionTrack) CaptionTrack {
+	t, _ := SelectCaption(tracks, CaptionChoice{})
+	return t
+}
+
+// SelectCaption returns the track to download from tracks: one in the
+// language asked for, or else an unlabeled one, or when no language is
+// asked for, an English or unlabeled track and else any track. Among
+// those, it picks by the track preference, then by how closely the
+// language matches, then by YouTube's order. It reports false if there is
+// no such track.
+func SelectCaption(tracks []CaptionTrack, choice CaptionChoice) (CaptionTrack, bool) {
+	lang, match := choice.Lang, cmp.Or(choice.Match, MatchBase)
+	unlabeled := func(t CaptionTrack) bool { return t.Language == "" }
+	matches := func(t CaptionTrack) bool { return languageScore(t.Language, lang, match) > 0 }
+	var groups [][]CaptionTrack
+	if lang == "" {
+		lang = "en"
+		groups = [][]CaptionTrack{filterTracks(tracks, func(t CaptionTrack) bool { return matches(t) || unlabeled(t) }), tracks}
+	} else {
+		groups = [][]CaptionTrack{filterTracks(tracks, matches), filterTracks(tracks, unlabeled)}
+	}
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		return slices.MinFunc(group, func(a, b CaptionTrack) int {
+			return cmp.Or(
+				cmp.Compare(kindRank(b, choice.Prefer), kindRank(a, choice.Prefer)),
+				cmp.Compare(languageScore(b.Language, lang, match), languageScore(a.Language, lang, match)),
+			)
+		}), true
+	}
+	return CaptionTrack{}, false
+}
+
+// LanguageCaption returns the track in tracks whose language is lang, or
+// else an unlabeled track, whose language is unknown, choosing uploaded
+// captions over ASR captions. It reports false if there is neither.
+func LanguageCaption(tracks []CaptionTrack, lang string) (CaptionTrack, bool) {
+	return SelectCaption(tracks, CaptionChoice{Lang: lang, Match: MatchExact})
+}
+
+// filterTracks returns the tracks keep reports true for.
+func filterTracks(tracks []CaptionTrack, keep func(CaptionTrack) bool) []CaptionTrack {
+	var out []CaptionTrack
+	for _, t := range tracks {
+		if keep(t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// kindRank returns 1 if t is the kind of track prefer asks for, and 0 if
+// not. Every track is the kind PreferAny asks for.
+func kindRank(t CaptionTrack, prefer TrackPreference) int {
+	if prefer == PreferAny || t.IsASR() == (prefer == PreferASR) {
+		return 1
+	}
+	return 0
+}
+
+// languageScore rates how well the track language code matches lang: 0 if
+// it doesn't, and higher the closer it does. Under MatchExact only equal
+// codes match. Chinese in one script never matches the other.
+func languageScore(code, lang string, match LangMatch) int {
+	code, lang = normalizeLang(code), normalizeLang(lang)
+	switch {
+	case code == "":
+		return 0
+	case code == lang:
+		return 3
+	case match == MatchExact || !transcript.SameLanguage(code, lang):
+		return 0
+	case chineseScript(code) != "" && chineseScript(lang) != "":
+		// Simplified and Traditional Chinese are read as different
+		// languages, however the rest of their codes compare.
+		if chineseScript(code) != chineseScript(lang) {
+			return 0
+		}
+		return 2
+	case !strings.Contains(code, "-") || !strings.Contains(lang, "-"):
+		// One is the language without a variant, as en is to en-US.
+		return 2
+	}
+	return 1
+}
+
+// normalizeLang lowercases a language code and separates its subtags with
+// hyphens.
+func normalizeLang(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
+
+// chineseScript returns "hans" for codes of Simplified Chinese, "hant" for
+// Traditional Chinese, and "" for anything else, going by the script
+// subtag or else the region. code must be normalized.
+func chineseScript(code string) string {
+	base, rest, _ := strings.Cut(code, "-")
+	if base != "zh" {
+		return ""
+	}
+	for _, sub := range strings.Split(rest, "-") {
+		switch sub {
+		case "hans", "cn", "sg", "my":
+			return "hans"
+		case "hant", "tw", "hk", "mo":
+			return "hant"
+		}
+	}
+	return ""
+}
+
+// DeleteCaption deletes a caption track. The authenticated user must own the video.
+func (c *Client) DeleteCaption(captionID string) error {
+	if err := c.Service.Captions.Delete(captionID).Do(); err != nil {
+		return fmt.Errorf("error deleting caption %s: %w", captionID, err)
+	}
+	return nil
+}
+
+// FindCaption returns the single track in tracks whose language is lang.
+// It fails when no track or more than one track matches, since deleting an
+// arbitrary one of several duplicates should be an explicit choice by ID.
+func FindCaption(tracks []CaptionTrack, lang string) (CaptionTrack, error) {
+	var matches []CaptionTrack
+	for _, track := range tracks {
+		if track.Language == lang {
+			matches = append(matches, track)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return CaptionTrack{}, fmt.Errorf("no caption track found for language %q", lang)
+	case 1:
+		return matches[0], nil
+	}
+
+	msg := fmt.Sprintf("%d caption tracks found for language %q, specify one by ID:", len(matches), lang)
+	for _, m := range matches {
+		msg += fmt.Sprintf("\n  %s  kind=%s name=%q updated=%s", m.CaptionID, m.TrackKind, m.Name, m.LastUpdated)
+	}
+	return CaptionTrack{}, errors.New(msg)
+}
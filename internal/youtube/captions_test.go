@@ -0,0 +1,213 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindCaption(t *testing.T) {
+	tracks := []CaptionTrack{
+		{CaptionID: "a", Language: "en", TrackKind: "standard"},
+		{CaptionID: "b", Language: "de", TrackKind: "standard"},
+		{CaptionID: "c", Language: "de", TrackKind: "asr"},
+	}
+
+	tests := []struct {
+		name    string
+		lang    string
+		wantID  string
+		wantErr bool
+	}{
+		{"single match", "en", "a", false},
+		{"duplicate tracks", "de", "", true},
+		{"no match", "fr", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindCaption(tracks, tt.lang)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindCaption(%q) error = %v, wantErr %v", tt.lang, err, tt.wantErr)
+			}
+			if got.CaptionID != tt.wantID {
+				t.Errorf("FindCaption(%q) = %q, want %q", tt.lang, got.CaptionID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCaptionTrackUpdatedAt(t *testing.T) {
+	tests := []struct {
+		lastUpdated string
+		want        time.Time
+	}{
+		{"2024-05-01T12:30:00.123Z", time.Date(2024, 5, 1, 12, 30, 0, 123000000, time.UTC)},
+		{"", time.Time{}},
+		{"not a time", time.Time{}},
+	}
+	for _, tt := range tests {
+		got := CaptionTrack{LastUpdated: tt.lastUpdated}.UpdatedAt()
+		if !got.Equal(tt.want) {
+			t.Errorf("UpdatedAt() for %q = %v, want %v", tt.lastUpdated, got, tt.want)
+		}
+	}
+}
+
+func TestPreferredCaption(t *testing.T) {
+	tests := []struct {
+		name   string
+		tracks []CaptionTrack
+		want   string
+	}{
+		{"english", []CaptionTrack{{CaptionID: "a", Language: "de"}, {CaptionID: "b", Language: "en"}}, "b"},
+		{"unlabeled", []CaptionTrack{{CaptionID: "a", Language: "de"}, {CaptionID: "b", Language: ""}}, "b"},
+		{"first", []CaptionTrack{{CaptionID: "a", Language: "de"}, {CaptionID: "b", Language: "fr"}}, "a"},
+		{"manual over asr", []CaptionTrack{{CaptionID: "a", Language: "en", TrackKind: "asr"}, {CaptionID: "b", Language: "en", TrackKind: "standard"}}, "b"},
+	}
+	for _, tt := range tests {
+		if got := PreferredCaption(tt.tracks); got.CaptionID != tt.want {
+			t.Errorf("%s: PreferredCaption() = %q, want %q", tt.name, got.CaptionID, tt.want)
+		}
+	}
+}
+
+func TestLanguageCaption(t *testing.T) {
+	tests := []struct {
+		name   string
+		tracks []CaptionTrack
+		want   string
+		ok     bool
+	}{
+		{"match", []CaptionTrack{{CaptionID: "a", Language: "en"}, {CaptionID: "b", Language: "de"}}, "b", true},
+		{"unlabeled", []CaptionTrack{{CaptionID: "a", Language: "en"}, {CaptionID: "b", Language: ""}}, "b", true},
+		{"match before unlabeled", []CaptionTrack{{CaptionID: "a", Language: ""}, {CaptionID: "b", Language: "de"}}, "b", true},
+		{"none", []CaptionTrack{{CaptionID: "a", Language: "en"}}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := LanguageCaption(tt.tracks, "de")
+		if got.CaptionID != tt.want || ok != tt.ok {
+			t.Errorf("%s: LanguageCaption() = %q, %v, want %q, %v", tt.name, got.CaptionID, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSelectCaption(t *testing.T) {
+	tracks := []CaptionTrack{
+		{CaptionID: "en-asr", Language: "en", TrackKind: "asr"},
+		{CaptionID: "de-asr", Language: "de", TrackKind: "asr"},
+		{CaptionID: "en", Language: "en", TrackKind: "standard"},
+		{CaptionID: "de", Language: "de", TrackKind: "forced"},
+		{CaptionID: "fr-asr", Language: "fr", TrackKind: "asr"},
+	}
+	tests := []struct {
+		lang   string
+		prefer TrackPreference
+		want   string
+		ok     bool
+	}{
+		{"", PreferManual, "en", true},
+		{"", PreferASR, "en-asr", true},
+		{"", PreferAny, "en-asr", true},
+		{"de", PreferManual, "de", true},
+		{"de", PreferASR, "de-asr", true},
+		// Only ASR captions in French, so they are used all the same.
+		{"fr", PreferManual, "fr-asr", true},
+		{"ja", PreferManual, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := SelectCaption(tracks, CaptionChoice{Lang: tt.lang, Prefer: tt.prefer})
+		if got.CaptionID != tt.want || ok != tt.ok {
+			t.Errorf("SelectCaption(%q, %s) = %q, %v, want %q, %v", tt.lang, tt.prefer, got.CaptionID, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSelectCaptionLangMatch(t *testing.T) {
+	tracks := []CaptionTrack{
+		{CaptionID: "en-GB", Language: "en-GB", TrackKind: "standard"},
+		{CaptionID: "en-US-asr", Language: "en-US", TrackKind: "asr"},
+		{CaptionID: "en-US", Language: "en_us", TrackKind: "standard"},
+		{CaptionID: "zh-TW", Language: "zh-TW", TrackKind: "standard"},
+		{CaptionID: "zh-CN", Language: "zh-CN", TrackKind: "standard"},
+		{CaptionID: "pt-BR", Language: "pt-BR", TrackKind: "standard"},
+	}
+	tests := []struct {
+		lang   string
+		match  LangMatch
+		prefer TrackPreference
+		want   string
+		ok     bool
+	}{
+		{"", MatchBase, PreferManual, "en-GB", true},
+		{"en", MatchBase, PreferManual, "en-GB", true},
+		{"en-US", MatchBase, PreferManual, "en-US", true},
+		{"EN-us", MatchExact, PreferManual, "en-US", true},
+		{"en-AU", MatchBase, PreferManual, "en-GB", true},
+		// The track preference comes before closeness of the language.
+		{"en-GB", MatchBase, PreferASR, "en-US-asr", true},
+		{"en", MatchExact, PreferManual, "", false},
+		{"zh-Hans", MatchBase, PreferManual, "zh-CN", true},
+		{"zh-Hant", MatchBase, PreferManual, "zh-TW", true},
+		{"zh-HK", MatchBase, PreferManual, "zh-TW", true},
+		{"zh-Hans", MatchExact, PreferManual, "", false},
+		{"pt", MatchBase, PreferManual, "pt-BR", true},
+		{"pt-PT", MatchBase, PreferManual, "pt-BR", true},
+		{"pt-PT", MatchExact, PreferManual, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := SelectCaption(tracks, CaptionChoice{Lang: tt.lang, Match: tt.match, Prefer: tt.prefer})
+		if got.CaptionID != tt.want || ok != tt.ok {
+			t.Errorf("SelectCaption(%q, %s, %s) = %q, %v, want %q, %v", tt.lang, tt.match, tt.prefer, got.CaptionID, ok, tt.want, tt.ok)
+		}
+	}
+
+	// Chinese in the other script is no match at all.
+	traditional := []CaptionTrack{{CaptionID: "zh-Hant", Language: "zh-Hant", TrackKind: "standard"}}
+	for _, lang := range []string{"zh-Hans", "zh-CN", "zh-Hans-HK"} {
+		if got, ok := SelectCaption(traditional, CaptionChoice{Lang: lang, Match: MatchBase}); ok {
+			t.Errorf("SelectCaption(%q) of Traditional Chinese = %q, want no match", lang, got.CaptionID)
+		}
+	}
+	if got, ok := SelectCaption(traditional, CaptionChoice{Lang: "zh", Match: MatchBase}); !ok {
+		t.Errorf("SelectCaption(zh) of Traditional Chinese = %q, no match", got.CaptionID)
+	}
+}
+
+func TestParseLangMatch(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LangMatch
+		wantErr bool
+	}{
+		{"", MatchBase, false},
+		{"base", MatchBase, false},
+		{"exact", MatchExact, false},
+		{"region", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLangMatch(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseLangMatch(%q) = %q, %v, want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseTrackPreference(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    TrackPreference
+		wantErr bool
+	}{
+		{"", PreferManual, false},
+		{"manual", PreferManual, false},
+		{"asr", PreferASR, false},
+		{"any", PreferAny, false},
+		{"human", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTrackPreference(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseTrackPreference(%q) = %q, %v, want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
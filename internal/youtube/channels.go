@@ -0,0 +1,323 @@
+package youtube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/n2p5/ytt/internal/filelock"
+	"google.golang.org/api/youtube/v3"
+)
+
+// ChannelDetails represents a channel's public metadata and statistics.
+type ChannelDetails struct {
+	ChannelID   string `json:"channel_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Handle is the channel's @handle, or its legacy custom URL.
+	Handle      string `json:"handle,omitempty"`
+	PublishedAt string `json:"published_at"`
+	Country     string `json:"country,omitempty"`
+
+	SubscriberCount uint64 `json:"subscriber_count"`
+	// SubscribersHidden is set if the channel hides its subscriber count,
+	// leaving SubscriberCount zero.
+	SubscribersHidden bool   `json:"subscribers_hidden,omitempty"`
+	ViewCount         uint64 `json:"view_count"`
+	VideoCount        uint64 `json:"video_count"`
+
+	// TopicCategories are Wikipedia URLs describing the channel's content.
+	TopicCategories []string `json:"topic_categories,omitempty"`
+	Keywords        string   `json:"keywords,omitempty"`
+	ThumbnailURL    string   `json:"thumbnail_url,omitempty"`
+	BannerURL       string   `json:"banner_url,omitempty"`
+	// TrailerVideoID is the video shown to visitors who aren't subscribed.
+	TrailerVideoID  string `json:"trailer_video_id,omitempty"`
+	UploadsPlaylist string `json:"uploads_playlist,omitempty"`
+}
+
+// GetChannelDetails retrieves metadata for a channel, given by ID or by
+// @handle.
+func (c *Client) GetChannelDetails(channel string) (*ChannelDetails, error) {
+	call := c.Service.Channels.List([]string{"snippet", "statistics", "topicDetails", "brandingSettings", "contentDetails"})
+	if strings.HasPrefix(channel, "@") {
+		call = call.ForHandle(channel)
+	} else {
+		call = call.Id(channel)
+	}
+	response, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving channel details: %w", err)
+	}
+	if len(response.Items) == 0 {
+		return nil, fmt.Errorf("channel %s %w", channel, ErrNotFound)
+	}
+
+	ch := response.Items[0]
+	d := &ChannelDetails{ChannelID: ch.Id}
+	if s := ch.Snippet; s != nil {
+		d.Title = s.Title
+		d.Description = s.Description
+		d.Handle = s.CustomUrl
+		d.PublishedAt = s.PublishedAt
+		d.Country = s.Country
+		if t := s.Thumbnails; t != nil && t.High != nil {
+			d.ThumbnailURL = t.High.Url
+		}
+	}
+	if s := ch.Statistics; s != nil {
+		d.SubscriberCount = s.SubscriberCount
+		d.SubscribersHidden = s.HiddenSubscriberCount
+		d.ViewCount = s.ViewCount
+		d.VideoCount = s.VideoCount
+	}
+	if t := ch.TopicDetails; t != nil {
+		d.TopicCategories = t.TopicCategories
+	}
+	if b := ch.BrandingSettings; b != nil {
+		if b.Channel != nil {
+			d.Keywords = b.Channel.Keywords
+			d.TrailerVideoID = b.Channel.UnsubscribedTrailer
+		}
+		if b.Image != nil {
+			d.BannerURL = b.Image.BannerExternalUrl
+		}
+	}
+	if cd := ch.ContentDetails; cd != nil && cd.RelatedPlaylists != nil {
+		d.UploadsPlaylist = cd.RelatedPlaylists.Uploads
+	}
+	return d, nil
+}
+
+// ChannelSummary is a channel listed by MyChannels, ManagedChannels, or
+// Subscriptions.
+type ChannelSummary struct {
+	ChannelID string `json:"channel_id"`
+	Title     string `json:"title"`
+	// Handle is the channel's @handle, or its legacy custom URL.
+	Handle string `json:"handle,omitempty"`
+}
+
+// MyChannels returns the channel the client is signed in as. A token
+// belongs to a single channel, chosen when signing in, so a Google account
+// managing Brand Account channels needs a token for each of them.
+func (c *Client) MyChannels() ([]ChannelSummary, error) {
+	response, err := c.Service.Channels.List([]string{"snippet"}).Mine(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving user's channels: %w", err)
+	}
+	return channelSummaries(response.Items), nil
+}
+
+// ManagedChannels returns the channels a YouTube content owner (CMS)
+// manages, for a client signed in as a user of that content owner.
+func (c *Client) ManagedChannels(contentOwner string) ([]ChannelSummary, error) {
+	var out []ChannelSummary
+	call := c.Service.Channels.List([]string{"snippet"}).ManagedByMe(true).OnBehalfOfContentOwner(contentOwner).MaxResults(50)
+	for pageToken := ""; ; {
+		response, err := call.PageToken(pageToken).Do()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving content owner's channels: %w", err)
+		}
+		out = append(out, channelSummaries(response.Items)...)
+		if pageToken = response.NextPageToken; pageToken == "" {
+			return out, nil
+		}
+	}
+}
+
+// Subscriptions returns the channels the signed-in user subscribes to. The
+// API doesn't give their handles.
+func (c *Client) Subscriptions() ([]ChannelSummary, error) {
+	var out []ChannelSummary
+	call := c.Service.Subscriptions.List([]string{"snippet"}).Mine(true).MaxResults(50)
+	for pageToken := ""; ; {
+		response, err := call.PageToken(pageToken).Do()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving subscriptions: %w", err)
+		}
+		for _, s := range response.Items {
+			if s.Snippet == nil || s.Snippet.ResourceId == nil {
+				continue
+			}
+			out = append(out, ChannelSummary{ChannelID: s.Snippet.ResourceId.ChannelId, Title: s.Snippet.Title})
+		}
+		if pageToken = response.NextPageToken; pageToken == "" {
+			return out, nil
+		}
+	}
+}
+
+func channelSummaries(items []*youtube.Channel) []ChannelSummary {
+	out := make([]ChannelSummary, 0, len(items))
+	for _, ch := range items {
+		s := ChannelSummary{ChannelID: ch.Id}
+		if ch.Snippet != nil {
+			s.Title = ch.Snippet.Title
+			s.Handle = ch.Snippet.CustomUrl
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// ChannelRef is a channel as a user might give it: by ID, by @handle, or by
+// the legacy username of a /user/ URL. Exactly one field is set.
+type ChannelRef struct {
+	ID       string
+	Handle   string
+	Username string
+}
+
+// ParseChannelRef parses a channel ID, an @handle, or a channel URL such as
+// https://www.youtube.com/@handle, https://www.youtube.com/channel/UC...,
+// https://www.youtube.com/user/name, or the channel's feed URL. Custom /c/
+// URLs have no API lookup of their own; most now redirect to the handle of
+// the same name, so they are resolved as that handle.
+func ParseChannelRef(s string) (ChannelRef, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return ChannelRef{}, fmt.Errorf("empty channel")
+	case strings.HasPrefix(s, "@"):
+		return ChannelRef{Handle: s}, nil
+	case !strings.Contains(s, "/"):
+		return ChannelRef{ID: s}, nil
+	}
+
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return ChannelRef{}, fmt.Errorf("invalid channel URL %q: %w", s, err)
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(u.Hostname(), "www."), "m.")
+	if host != "youtube.com" {
+		return ChannelRef{}, fmt.Errorf("not a YouTube channel URL: %s", s)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case strings.HasPrefix(parts[0], "@"):
+		handle, err := url.PathUnescape(parts[0])
+		if err != nil {
+			return ChannelRef{}, fmt.Errorf("invalid channel URL %q: %w", s, err)
+		}
+		return ChannelRef{Handle: handle}, nil
+	case len(parts) >= 2 && parts[0] == "channel":
+		return ChannelRef{ID: parts[1]}, nil
+	case len(parts) >= 2 && parts[0] == "user":
+		return ChannelRef{Username: parts[1]}, nil
+	case len(parts) >= 2 && parts[0] == "c":
+		return ChannelRef{Handle: "@" + parts[1]}, nil
+	case len(parts) == 2 && parts[0] == "feeds" && parts[1] == "videos.xml" && u.Query().Get("channel_id") != "":
+		return ChannelRef{ID: u.Query().Get("channel_id")}, nil
+	}
+	return ChannelRef{}, fmt.Errorf("not a YouTube channel URL: %s", s)
+}
+
+// ResolveChannel returns the ID of a channel given in any form
+// ParseChannelRef accepts. Channel IDs are returned as they are; handles and
+// usernames are looked up with channels.list, through the handle cache if
+// the client has one.
+func (c *Client) ResolveChannel(channel string) (string, error) {
+	ref, err := ParseChannelRef(channel)
+	if err != nil {
+		return "", err
+	}
+	if ref.ID != "" {
+		return ref.ID, nil
+	}
+
+	key := strings.ToLower(ref.Handle)
+	if ref.Username != "" {
+		key = "user:" + strings.ToLower(ref.Username)
+	}
+	if id, ok := c.handles.get(key); ok {
+		return id, nil
+	}
+
+	call := c.Service.Channels.List([]string{"id"})
+	if ref.Handle != "" {
+		call = call.ForHandle(ref.Handle)
+	} else {
+		call = call.ForUsername(ref.Username)
+	}
+	response, err := call.Do()
+	if err != nil {
+		return "", fmt.Errorf("error resolving channel %s: %w", channel, err)
+	}
+	if len(response.Items) == 0 {
+		return "", fmt.Errorf("channel %s %w", channel, ErrNotFound)
+	}
+	id := response.Items[0].Id
+	c.handles.put(key, id)
+	return id, nil
+}
+
+// handleCache is a JSON file mapping lowercased handles, and usernames
+// prefixed with "user:", to channel IDs. A nil cache stores nothing, and
+// failures to write it only cost another lookup next time.
+type handleCache struct {
+	path string
+
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+func (h *handleCache) get(key string) (string, bool) {
+	if h == nil {
+		return "", false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.load()
+	id, ok := h.ids[key]
+	return id, ok
+}
+
+func (h *handleCache) put(key, id string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.load()
+	h.ids[key] = id
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return
+	}
+	// Reread the file under its lock, to keep handles other processes
+	// have added since it was loaded.
+	filelock.With(h.path, func() error {
+		h.ids = nil
+		h.load()
+		h.ids[key] = id
+		data, err := json.MarshalIndent(h.ids, "", "  ")
+		if err != nil {
+			return err
+		}
+		tmp := h.path + ".tmp"
+		if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+		return os.Rename(tmp, h.path)
+	})
+}
+
+// load reads the file the first time the cache is used.
+func (h *handleCache) load() {
+	if h.ids != nil {
+		return
+	}
+	h.ids = map[string]string{}
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &h.ids)
+}
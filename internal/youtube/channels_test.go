@@ -0,0 +1,207 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetChannelDetails(t *testing.T) {
+	var query map[string][]string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		if r.URL.Query().Get("id") == "UCmissing" {
+			fmt.Fprint(w, `{"items":[]}`)
+			return
+		}
+		fmt.Fprint(w, `{"items":[{
+			"id": "UC1",
+			"snippet": {"title": "Gophers", "description": "Go talks", "customUrl": "@gophers",
+				"publishedAt": "2015-01-02T03:04:05Z", "country": "US",
+				"thumbnails": {"high": {"url": "https://yt3.example/high.jpg"}}},
+			"statistics": {"subscriberCount": "1200", "viewCount": "345678", "videoCount": "42"},
+			"topicDetails": {"topicCategories": ["https://en.wikipedia.org/wiki/Technology"]},
+			"brandingSettings": {"channel": {"keywords": "go golang", "unsubscribedTrailer": "vid1"},
+				"image": {"bannerExternalUrl": "https://yt3.example/banner"}},
+			"contentDetails": {"relatedPlaylists": {"uploads": "UU1"}}
+		}]}`)
+	}))
+
+	got, err := client.GetChannelDetails("@gophers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := query["forHandle"]; len(h) != 1 || h[0] != "@gophers" {
+		t.Errorf("forHandle = %v, want @gophers", h)
+	}
+	want := &ChannelDetails{
+		ChannelID:       "UC1",
+		Title:           "Gophers",
+		Description:     "Go talks",
+		Handle:          "@gophers",
+		PublishedAt:     "2015-01-02T03:04:05Z",
+		Country:         "US",
+		SubscriberCount: 1200,
+		ViewCount:       345678,
+		VideoCount:      42,
+		TopicCategories: []string{"https://en.wikipedia.org/wiki/Technology"},
+		Keywords:        "go golang",
+		ThumbnailURL:    "https://yt3.example/high.jpg",
+		BannerURL:       "https://yt3.example/banner",
+		TrailerVideoID:  "vid1",
+		UploadsPlaylist: "UU1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetChannelDetails = %+v\nwant %+v", got, want)
+	}
+
+	if _, err := client.GetChannelDetails("UC1"); err != nil {
+		t.Fatal(err)
+	}
+	if id := query["id"]; len(id) != 1 || id[0] != "UC1" {
+		t.Errorf("id = %v, want UC1", id)
+	}
+
+	if _, err := client.GetChannelDetails("UCmissing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing channel: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestParseChannelRef(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ChannelRef
+		wantErr bool
+	}{
+		{in: "UCabc", want: ChannelRef{ID: "UCabc"}},
+		{in: " @GoogleDevelopers ", want: ChannelRef{Handle: "@GoogleDevelopers"}},
+		{in: "https://www.youtube.com/@GoogleDevelopers/videos", want: ChannelRef{Handle: "@GoogleDevelopers"}},
+		{in: "youtube.com/@caf%C3%A9", want: ChannelRef{Handle: "@café"}},
+		{in: "https://m.youtube.com/channel/UCabc", want: ChannelRef{ID: "UCabc"}},
+		{in: "http://youtube.com/user/LegacyName", want: ChannelRef{Username: "LegacyName"}},
+		{in: "https://www.youtube.com/c/CustomName", want: ChannelRef{Handle: "@CustomName"}},
+		{in: "https://www.youtube.com/feeds/videos.xml?channel_id=UCabc", want: ChannelRef{ID: "UCabc"}},
+		{in: "https://www.youtube.com/feeds/videos.xml?playlist_id=PLabc", wantErr: true},
+		{in: "https://example.com/@someone", wantErr: true},
+		{in: "https://www.youtube.com/watch?v=abc", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseChannelRef(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseChannelRef(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseChannelRef(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestResolveChannel(t *testing.T) {
+	var lookups []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		lookups = append(lookups, q.Get("forHandle")+q.Get("forUsername"))
+		switch {
+		case q.Get("forHandle") == "@gophers":
+			fmt.Fprint(w, `{"items":[{"id":"UCgo"}]}`)
+		case q.Get("forUsername") == "legacy":
+			fmt.Fprint(w, `{"items":[{"id":"UClegacy"}]}`)
+		default:
+			fmt.Fprint(w, `{"items":[]}`)
+		}
+	}))
+	path := filepath.Join(t.TempDir(), "handles.json")
+	client.handles = &handleCache{path: path}
+
+	for _, tt := range []struct{ in, want string }{
+		{"UCdirect", "UCdirect"},
+		{"@gophers", "UCgo"},
+		{"https://www.youtube.com/@Gophers", "UCgo"},
+		{"https://www.youtube.com/user/legacy", "UClegacy"},
+	} {
+		got, err := client.ResolveChannel(tt.in)
+		if err != nil {
+			t.Fatalf("ResolveChannel(%q): %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("ResolveChannel(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if want := []string{"@gophers", "legacy"}; !reflect.DeepEqual(lookups, want) {
+		t.Errorf("looked up %v, want %v (IDs and cached handles need no lookup)", lookups, want)
+	}
+
+	// A new client reads the mapping back from the file.
+	lookups = nil
+	client.handles = &handleCache{path: path}
+	if got, err := client.ResolveChannel("@GOPHERS"); err != nil || got != "UCgo" {
+		t.Errorf("ResolveChannel from file = %q, %v; want UCgo", got, err)
+	}
+	if len(lookups) != 0 {
+		t.Errorf("looked up %v, want the cached mapping used", lookups)
+	}
+
+	if _, err := client.ResolveChannel("@nobody"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown handle: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestManagedChannels(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("mine") == "true":
+			fmt.Fprint(w, `{"items":[{"id":"UCme","snippet":{"title":"Me","customUrl":"@me"}}]}`)
+		case q.Get("managedByMe") != "true" || q.Get("onBehalfOfContentOwner") != "owner1":
+			http.Error(w, "bad query", http.StatusBadRequest)
+		case q.Get("pageToken") == "":
+			fmt.Fprint(w, `{"items":[{"id":"UC1","snippet":{"title":"One"}}],"nextPageToken":"p2"}`)
+		default:
+			fmt.Fprint(w, `{"items":[{"id":"UC2","snippet":{"title":"Two","customUrl":"@two"}}]}`)
+		}
+	}))
+
+	mine, err := client.MyChannels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []ChannelSummary{{ChannelID: "UCme", Title: "Me", Handle: "@me"}}; !reflect.DeepEqual(mine, want) {
+		t.Errorf("MyChannels() = %+v, want %+v", mine, want)
+	}
+	managed, err := client.ManagedChannels("owner1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ChannelSummary{{ChannelID: "UC1", Title: "One"}, {ChannelID: "UC2", Title: "Two", Handle: "@two"}}
+	if !reflect.DeepEqual(managed, want) {
+		t.Errorf("ManagedChannels() = %+v, want %+v", managed, want)
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path != "/youtube/v3/subscriptions" || q.Get("mine") != "true":
+			http.Error(w, "bad query", http.StatusBadRequest)
+		case q.Get("pageToken") == "":
+			fmt.Fprint(w, `{"items":[{"snippet":{"title":"One","resourceId":{"kind":"youtube#channel","channelId":"UC1"}}}],"nextPageToken":"p2"}`)
+		default:
+			fmt.Fprint(w, `{"items":[{"snippet":{"title":"Two","resourceId":{"kind":"youtube#channel","channelId":"UC2"}}}]}`)
+		}
+	}))
+
+	got, err := client.Subscriptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ChannelSummary{{ChannelID: "UC1", Title: "One"}, {ChannelID: "UC2", Title: "Two"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Subscriptions() = %+v, want %+v", got, want)
+	}
+}
@@ -1,17 +1,23 @@
 package youtube
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"time"
 
+	"github.com/n2p5/ytt/internal/clock"
+	"github.com/n2p5/ytt/internal/filelock"
+	"github.com/n2p5/ytt/internal/ratelimit"
+	"github.com/n2p5/ytt/internal/telemetry"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
 	"google.golang.org/api/youtube/v3"
 )
@@ -19,94 +25,486 @@ import (
 // Client wraps the YouTube API service.
 type Client struct {
 	Service *youtube.Service
+
+	usage   *CostEstimator
+	handles *handleCache
+	// language is the caption language to download, or empty for the
+	// PreferredCaption track.
+	language string
+	// langMatch is how closely a track's language must match language.
+	langMatch LangMatch
+	// prefer picks between uploaded and ASR captions in a language.
+	prefer TrackPreference
+	// maxCaptionSize is the largest caption download accepted, in bytes,
+	// or 0 for no limit.
+	maxCaptionSize int64
+	// feed fetches channel feeds, which aren't part of the API.
+	feed *http.Client
+}
+
+// Usage returns the API calls the client has made and their quota cost, or
+// nil if the client wasn't created by NewClient.
+func (c *Client) Usage() *CostEstimator {
+	return c.usage
+}
+
+// Option configures optional Client behavior.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	cacheDir   string
+	userAgent  string
+	quotaUser  string
+	owner      string
+	ownerChan  string
+	clock      clock.Clock
+	qps        float64
+	burst      int
+	handles    string
+	language   string
+	langMatch  LangMatch
+	prefer     TrackPreference
+	timeout    time.Duration
+	maxSize    int64
+	transport  http.RoundTripper
+	tel        *telemetry.Exporter
+	telParent  *telemetry.Span
+	usage      *CostEstimator
+	noBrowser  bool
+	storage    TokenStorage
+	passphrase string
+	// captionAccess asks for CaptionScope when signing in.
+	captionAccess bool
+	creds         *Credentials
+	// noSignIn fails instead of running the OAuth flow.
+	noSignIn bool
+}
+
+// WithCache stores Videos, Channels, and PlaylistItems list responses in dir
+// and revalidates them by ETag, so unchanged results cost almost no quota.
+func WithCache(dir string) Option {
+	return func(o *clientOptions) {
+		o.cacheDir = dir
+	}
+}
+
+// WithUserAgent sends ua as the User-Agent of every API request, so the
+// requests can be told apart in the Cloud Console and in proxy logs.
+func WithUserAgent(ua string) Option {
+	return func(o *clientOptions) {
+		o.userAgent = ua
+	}
+}
+
+// WithQuotaUser adds user as the quotaUser parameter of every API request,
+// which attributes usage to that user or team within the project's quota.
+func WithQuotaUser(user string) Option {
+	return func(o *clientOptions) {
+		o.quotaUser = user
+	}
+}
+
+// WithContentOwner makes API requests on behalf of a YouTube content owner
+// (CMS), so a user of the content owner's account can work with every
+// channel it manages. Requests about the signed-in user's own channel
+// (mine=true) are sent as they are.
+func WithContentOwner(id string) Option {
+	return func(o *clientOptions) {
+		o.owner = id
+	}
+}
+
+// WithContentOwnerChannel names the channel that requests made
+// WithContentOwner act for, where the API takes one: the channel whose
+// playlists and subscriptions are listed, and that new playlists and
+// videos are added to.
+func WithContentOwnerChannel(channelID string) Option {
+	return func(o *clientOptions) {
+		o.ownerChan = channelID
+	}
+}
+
+// WithRateLimit sends at most qps API requests per second on average, and
+// up to burst at once. When the API reports rateLimitExceeded the client
+// slows down and retries, recovering its rate over the following minutes.
+// Responses served from the cache don't count.
+func WithRateLimit(qps float64, burst int) Option {
+	return func(o *clientOptions) {
+		o.qps, o.burst = qps, burst
+	}
+}
+
+// WithHandleCache remembers the channel IDs that @handles and channel URLs
+// resolve to in the JSON file at path, so each is looked up only once.
+func WithHandleCache(path string) Option {
+	return func(o *clientOptions) {
+		o.handles = path
+	}
+}
+
+// WithLanguage makes FindCaptions and the downloads built on it pick the
+// caption track in lang, or else an unlabeled track, instead of the
+// PreferredCaption track.
+func WithLanguage(lang string) Option {
+	return func(o *clientOptions) {
+		o.language = lang
+	}
+}
+
+// WithTrackPreference makes FindCaptions and the downloads built on it
+// pick between uploaded and ASR captions in a language by p instead of
+// picking uploaded captions.
+func WithTrackPreference(p TrackPreference) Option {
+	return func(o *clientOptions) {
+		o.prefer = p
+	}
+}
+
+// WithLangMatch makes FindCaptions and the downloads built on it match
+// track languages by m instead of by base language.
+func WithLangMatch(m LangMatch) Option {
+	return func(o *clientOptions) {
+		o.langMatch = m
+	}
+}
+
+// WithTimeout fails any API request, including the download of its
+// response, that takes longer than d.
+func WithTimeout(d time.Duration) Option {
+	return func(o *clientOptions) {
+		o.timeout = d
+	}
+}
+
+// WithMaxCaptionSize fails caption downloads larger than n bytes.
+func WithMaxCaptionSize(n int64) Option {
+	return func(o *clientOptions) {
+		o.maxSize = n
+	}
+}
+
+// WithTransport sends every request, including those for OAuth tokens,
+// through rt instead of http.DefaultTransport, such as to go through a
+// proxy or add instrumentation. The client's own layers, such as the cache
+// and rate limit, sit on top of it.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *clientOptions) {
+		o.transport = rt
+	}
+}
+
+// WithTelemetry exports a span for each API call the client sends, under
+// parent, with its quota cost, and counts the calls, their cost, and their
+// errors. Responses served from the cache aren't calls.
+func WithTelemetry(e *telemetry.Exporter, parent *telemetry.Span) Option {
+	return func(o *clientOptions) {
+		o.tel, o.telParent = e, parent
+	}
+}
+
+// WithUsage tallies the client's API calls in e instead of a tally of its
+// own, so several clients can share one.
+func WithUsage(e *CostEstimator) Option {
+	return func(o *clientOptions) {
+		o.usage = e
+	}
+}
+
+// WithoutBrowser keeps the OAuth flow from opening a browser; the
+// authorization URL is only printed, to be opened by hand.
+func WithoutBrowser() Option {
+	return func(o *clientOptions) {
+		o.noBrowser = true
+	}
+}
+
+// WithoutSignIn never runs the OAuth flow, for running where no one can
+// sign in. When there is no usable token, or it lacks a scope a request
+// needs, the client fails with an error wrapping ErrAuthorizationRequired
+// instead.
+func WithoutSignIn() Option {
+	return func(o *clientOptions) {
+		o.noSignIn = true
+	}
+}
+
+// WithClock makes the client read the time from c, such as when checking
+// whether the saved token has expired.
+func WithClock(c clock.Clock) Option {
+	return func(o *clientOptions) {
+		o.clock = c
+	}
 }
 
 // NewClient creates a new YouTube API client using OAuth2 credentials.
-func NewClient(oauthPath, tokenPath string) (*Client, error) {
-	ctx := context.Background()
+func NewClient(oauthPath, tokenPath string, opts ...Option) (*Client, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ctx := o.context()
 
-	b, err := os.ReadFile(oauthPath)
+	config, err := o.oauthConfig(oauthPath)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read client secret file: %w", err)
+		return nil, err
 	}
 
-	config, err := google.ConfigFromJSON(b, youtube.YoutubeReadonlyScope, youtube.YoutubeForceSslScope)
+	store, err := o.tokenStore(tokenPath)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse client secret file: %w", err)
+		return nil, err
 	}
-
-	httpClient, err := getHTTPClient(config, tokenPath)
+	tok, err := getToken(ctx, config, store, tokenPath, &o)
 	if err != nil {
 		return nil, err
 	}
+	grants := newGrantSource(ctx, config, store, tok, &o)
+	httpClient := oauth2.NewClient(ctx, grants)
+	usage := o.usage
+	if usage == nil {
+		usage = &CostEstimator{}
+	}
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if o.userAgent != "" || o.quotaUser != "" {
+		base = &tagTransport{base: base, userAgent: o.userAgent, quotaUser: o.quotaUser}
+	}
+	if o.timeout > 0 {
+		base = &timeoutTransport{base: base, timeout: o.timeout}
+	}
+	httpClient.Transport = &meterTransport{base: base, usage: usage}
+	if o.qps > 0 {
+		httpClient.Transport = &limitTransport{base: httpClient.Transport, limiter: ratelimit.New(o.qps, o.burst, o.clock)}
+	}
+	if o.tel != nil {
+		httpClient.Transport = &traceTransport{base: httpClient.Transport, tel: o.tel, parent: o.telParent}
+	}
+	if o.cacheDir != "" {
+		// Cached responses belong to the account whose token fetched them.
+		namespace, _ := filepath.Abs(tokenPath)
+		httpClient.Transport = newCacheTransport(httpClient.Transport, o.cacheDir, namespace)
+	}
+	if o.owner != "" {
+		// Above the cache, as the content owner changes what is returned.
+		httpClient.Transport = &ownerTransport{base: httpClient.Transport, owner: o.owner, channel: o.ownerChan}
+	}
+	httpClient.Transport = &scopeTransport{base: httpClient.Transport, grants: grants}
 
 	service, err := youtube.NewService(ctx, option.WithHTTPClient(httpClient))
 	if err != nil {
 		return nil, fmt.Errorf("unable to create YouTube service: %w", err)
 	}
 
-	return &Client{Service: service}, nil
+	client := &Client{Service: service, usage: usage, language: o.language, langMatch: cmp.Or(o.langMatch, MatchBase), prefer: cmp.Or(o.prefer, PreferManual), maxCaptionSize: o.maxSize, feed: o.httpClient()}
+	if o.handles != "" {
+		client.handles = &handleCache{path: o.handles}
+	}
+	return client, nil
 }
 
-// Authenticate forces a new OAuth flow and saves the token.
-func Authenticate(oauthPath, tokenPath string) error {
-	b, err := os.ReadFile(oauthPath)
-	if err != nil {
-		return fmt.Errorf("unable to read client secret file: %w", err)
+// context returns the context OAuth requests are made with, which carries
+// the transport set with WithTransport.
+func (o *clientOptions) context() context.Context {
+	ctx := context.Background()
+	if o.transport != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: o.transport})
+	}
+	return ctx
+}
+
+// httpClient returns the client for requests to Google's OAuth endpoints
+// outside the oauth2 package, and for channel feeds, which uses the
+// transport set with WithTransport.
+func (o *clientOptions) httpClient() *http.Client {
+	return &http.Client{Transport: o.transport, Timeout: time.Minute}
+}
+
+// tagTransport sets the User-Agent and quotaUser of the requests sent
+// through it. It sits below the cache so tags don't split cache entries.
+type tagTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	quotaUser string
+}
+
+func (t *tagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	if t.quotaUser != "" {
+		q := req.URL.Query()
+		q.Set("quotaUser", t.quotaUser)
+		req.URL.RawQuery = q.Encode()
+	}
+	return t.base.RoundTrip(req)
+}
+
+// ownerTransport adds the onBehalfOfContentOwner parameter to requests, and
+// onBehalfOfContentOwnerChannel to those of ownerChannelMethods if channel
+// is set.
+type ownerTransport struct {
+	base    http.RoundTripper
+	owner   string
+	channel string
+}
+
+// ownerChannelMethods are the API methods ytt calls that take
+// onBehalfOfContentOwnerChannel, by HTTP method and resource. The API
+// rejects it elsewhere.
+var ownerChannelMethods = map[string]bool{
+	"GET playlists":     true,
+	"POST playlists":    true,
+	"GET subscriptions": true,
+	"POST videos":       true,
+}
+
+func (t *ownerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if q := req.URL.Query(); q.Get("mine") != "true" {
+		req = req.Clone(req.Context())
+		q.Set("onBehalfOfContentOwner", t.owner)
+		if t.channel != "" && ownerChannelMethods[req.Method+" "+path.Base(req.URL.Path)] {
+			q.Set("onBehalfOfContentOwnerChannel", t.channel)
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Authenticate forces a new OAuth flow and saves the token. Of opts, only
+// WithTransport, WithoutBrowser, WithTokenStorage, WithCaptionAccess, and
+// WithCredentials apply.
+func Authenticate(oauthPath, tokenPath string, opts ...Option) error {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
 	}
 
-	config, err := google.ConfigFromJSON(b, youtube.YoutubeReadonlyScope, youtube.YoutubeForceSslScope)
+	config, err := o.oauthConfig(oauthPath)
 	if err != nil {
-		return fmt.Errorf("unable to parse client secret file: %w", err)
+		return err
 	}
 
-	tok, err := getTokenFromWeb(config)
+	store, err := o.tokenStore(tokenPath)
+	if err != nil {
+		return err
+	}
+	if o.noSignIn {
+		return signInRequired("signing in needs a browser")
+	}
+	tok, err := getTokenFromWeb(o.context(), config, o.noBrowser)
 	if err != nil {
 		return err
 	}
 
-	return saveToken(tokenPath, tok)
+	return store.Save(tok)
 }
 
-func getHTTPClient(config *oauth2.Config, tokenPath string) (*http.Client, error) {
-	tok, err := tokenFromFile(tokenPath)
+// getToken returns the saved token, refreshing it if it has expired, or
+// runs the OAuth flow if there is none, it is corrupt, or it can't be
+// refreshed. Processes sharing the token at tokenPath take turns
+// refreshing it.
+func getToken(ctx context.Context, config *oauth2.Config, store tokenStore, tokenPath string, o *clientOptions) (*oauth2.Token, error) {
+	tok, err := store.Load()
+	switch {
+	case errors.Is(err, os.ErrNotExist) && o.noSignIn:
+		return nil, signInRequired(fmt.Sprintf("no token in %s", store))
+	case errors.Is(err, errCorruptToken) && o.noSignIn:
+		return nil, signInRequired(err.Error())
+	case errors.Is(err, os.ErrNotExist):
+		return authenticateAndSave(ctx, config, store, o.noBrowser)
+	case errors.Is(err, errCorruptToken):
+		log.Printf("Ignoring the saved token: %v", err)
+		log.Println("Re-authenticating...")
+		return authenticateAndSave(ctx, config, store, o.noBrowser)
+	}
 	if err != nil {
-		return authenticateAndSave(config, tokenPath)
+		return nil, err
 	}
 
-	if !tok.Expiry.Before(time.Now()) {
-		return config.Client(context.Background(), tok), nil
+	if !tokenExpired(tok, clock.Or(o.clock).Now()) {
+		return tok, nil
 	}
 
-	tokenSource := config.TokenSource(context.Background(), tok)
+	// Another process, such as a cron job, may be refreshing the same
+	// token. Wait for it and use the token it saved rather than refreshing
+	// again. The refresh lock is separate from the token file's own lock,
+	// which saving the token takes. It is released before falling back to
+	// signing in, which waits on the user for longer than a lock may be
+	// held.
+	release := func() {}
+	defer func() { release() }()
+	if _, ok := store.(*memoryStore); !ok {
+		if err := os.MkdirAll(filepath.Dir(tokenPath), 0700); err != nil {
+			return nil, fmt.Errorf("unable to create token directory: %w", err)
+		}
+		lock, err := filelock.Acquire(tokenPath + ".refresh")
+		if err != nil {
+			return nil, err
+		}
+		release = func() { lock.Release() }
+		if tok, err = store.Load(); err != nil {
+			return nil, err
+		}
+		if !tokenExpired(tok, clock.Or(o.clock).Now()) {
+			return tok, nil
+		}
+	}
+
+	tokenSource := config.TokenSource(ctx, tok)
 	newTok, err := tokenSource.Token()
 	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		switch {
+		case o.noSignIn && errors.As(err, &retrieveErr):
+			return nil, signInRequired(fmt.Sprintf("unable to refresh the token: %v", err))
+		case o.noSignIn:
+			return nil, fmt.Errorf("unable to refresh the token: %w", err)
+		}
 		log.Printf("Token refresh failed: %v", err)
 		log.Println("Re-authenticating...")
-		return authenticateAndSave(config, tokenPath)
+		release()
+		release = func() {}
+		return authenticateAndSave(ctx, config, store, o.noBrowser)
 	}
 
 	if newTok.AccessToken != tok.AccessToken {
-		if err := saveToken(tokenPath, newTok); err != nil {
+		if err := store.Save(newTok); err != nil {
 			return nil, err
 		}
 	}
 
-	return config.Client(context.Background(), newTok), nil
+	return newTok, nil
 }
 
-func authenticateAndSave(config *oauth2.Config, tokenPath string) (*http.Client, error) {
-	tok, err := getTokenFromWeb(config)
+// signInRequired returns the error for a client that needs the user to sign
+// in but was made WithoutSignIn.
+func signInRequired(reason string) error {
+	return fmt.Errorf("%w: %s", ErrAuthorizationRequired, reason)
+}
+
+// tokenExpired reports whether tok has expired at now. A token without an
+// expiry counts as expired, so it is refreshed.
+func tokenExpired(tok *oauth2.Token, now time.Time) bool {
+	return tok.Expiry.Before(now)
+}
+
+func authenticateAndSave(ctx context.Context, config *oauth2.Config, store tokenStore, noBrowser bool) (*oauth2.Token, error) {
+	tok, err := getTokenFromWeb(ctx, config, noBrowser)
 	if err != nil {
 		return nil, err
 	}
-	if err := saveToken(tokenPath, tok); err != nil {
+	if err := store.Save(tok); err != nil {
 		return nil, err
 	}
-	return config.Client(context.Background(), tok), nil
+	return tok, nil
 }
 
-func getTokenFromWeb(config *oauth2.Config) (*oauth2.Token, error) {
+// getTokenFromWeb runs the OAuth flow in a browser, opening it unless
+// noBrowser is set, and receives the authorization code on localhost. opts
+// are added to the authorization URL.
+func getTokenFromWeb(ctx context.Context, config *oauth2.Config, noBrowser bool, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
 	codeChan := make(chan string)
 
 	server := &http.Server{Addr: ":8080"}
@@ -130,20 +528,20 @@ func getTokenFromWeb(config *oauth2.Config) (*oauth2.Token, error) {
 	}()
 
 	config.RedirectURL = "http://localhost:8080"
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Fprintf(os.Stderr, "Opening browser for authorization...\nIf it doesn't open automatically, go to: %v\n", authURL)
+	authURL := config.AuthCodeURL("state-token", append([]oauth2.AuthCodeOption{oauth2.AccessTypeOffline}, opts...)...)
+	promptAuthorization(os.Stderr, authURL, noBrowser)
 
 	authCode := <-codeChan
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	server.Shutdown(ctx)
+	server.Shutdown(shutdownCtx)
 
 	if authCode == "" {
 		return nil, fmt.Errorf("authorization failed")
 	}
 
-	tok, err := config.Exchange(context.TODO(), authCode)
+	tok, err := config.Exchange(ctx, authCode)
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve token from web: %w", err)
 	}
@@ -157,21 +555,45 @@ func tokenFromFile(file string) (*oauth2.Token, error) {
 	}
 	defer f.Close()
 	tok := &oauth2.Token{}
-	err = json.NewDecoder(f).Decode(tok)
-	return tok, err
+	if err := json.NewDecoder(f).Decode(tok); err != nil {
+		return nil, fmt.Errorf("unable to parse token in %s: %w: %w", file, errCorruptToken, err)
+	}
+	return tok, nil
 }
 
 func saveToken(path string, token *oauth2.Token) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("unable to create token directory: %w", err)
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("unable to cache oauth token: %w", err)
 	}
-
 	fmt.Fprintf(os.Stderr, "Saving credential file to: %s\n", path)
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
+	if err := writeTokenFile(path, append(data, '\n')); err != nil {
 		return fmt.Errorf("unable to cache oauth token: %w", err)
 	}
-	defer f.Close()
-	return json.NewEncoder(f).Encode(token)
+	return nil
+}
+
+// writeTokenFile replaces the token file at path with data, readable only
+// by the user, holding its lock so processes saving it at once can't
+// interleave their writes.
+func writeTokenFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("unable to create token directory: %w", err)
+	}
+	return filelock.With(path, func() error {
+		tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := tmp.Write(data); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		return os.Rename(tmp.Name(), path)
+	})
 }
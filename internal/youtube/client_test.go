@@ -0,0 +1,100 @@
+package youtube
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type recordTransport struct {
+	req *http.Request
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.req = req
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestTagTransport(t *testing.T) {
+	tests := []struct {
+		name, userAgent, quotaUser string
+		wantUA, wantQuery          string
+	}{
+		{"both", "acme-ingest/1.0", "team-search", "acme-ingest/1.0", "id=a&quotaUser=team-search"},
+		{"user agent only", "acme-ingest/1.0", "", "acme-ingest/1.0", "id=a"},
+		{"quota user only", "", "team-search", "google-api-go-client/0.5", "id=a&quotaUser=team-search"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordTransport{}
+			tr := &tagTransport{base: rec, userAgent: tt.userAgent, quotaUser: tt.quotaUser}
+			req, err := http.NewRequest("GET", "https://youtube.googleapis.com/youtube/v3/videos?id=a", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("User-Agent", "google-api-go-client/0.5")
+			if _, err := tr.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+			if got := rec.req.Header.Get("User-Agent"); got != tt.wantUA {
+				t.Errorf("User-Agent = %q, want %q", got, tt.wantUA)
+			}
+			if got := rec.req.URL.RawQuery; got != tt.wantQuery {
+				t.Errorf("query = %q, want %q", got, tt.wantQuery)
+			}
+			if req.URL.RawQuery != "id=a" || req.Header.Get("User-Agent") != "google-api-go-client/0.5" {
+				t.Errorf("original request was modified")
+			}
+		})
+	}
+}
+
+func TestOwnerTransport(t *testing.T) {
+	tests := []struct {
+		method, channel, url, wantQuery string
+	}{
+		{"GET", "", "https://youtube.googleapis.com/youtube/v3/captions?videoId=a", "onBehalfOfContentOwner=cms1&videoId=a"},
+		{"GET", "", "https://youtube.googleapis.com/youtube/v3/channels?managedByMe=true&onBehalfOfContentOwner=cms1", "managedByMe=true&onBehalfOfContentOwner=cms1"},
+		{"GET", "", "https://youtube.googleapis.com/youtube/v3/channels?mine=true", "mine=true"},
+		{"GET", "UC1", "https://youtube.googleapis.com/youtube/v3/playlists?channelId=UC1", "channelId=UC1&onBehalfOfContentOwner=cms1&onBehalfOfContentOwnerChannel=UC1"},
+		{"POST", "UC1", "https://youtube.googleapis.com/upload/youtube/v3/videos?part=snippet", "onBehalfOfContentOwner=cms1&onBehalfOfContentOwnerChannel=UC1&part=snippet"},
+		// Methods that don't take the channel don't get it.
+		{"GET", "UC1", "https://youtube.googleapis.com/youtube/v3/videos?id=a", "id=a&onBehalfOfContentOwner=cms1"},
+		{"POST", "UC1", "https://youtube.googleapis.com/upload/youtube/v3/captions?part=snippet", "onBehalfOfContentOwner=cms1&part=snippet"},
+	}
+	for _, tt := range tests {
+		rec := &recordTransport{}
+		tr := &ownerTransport{base: rec, owner: "cms1", channel: tt.channel}
+		req, err := http.NewRequest(tt.method, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tr.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.req.URL.RawQuery; got != tt.wantQuery {
+			t.Errorf("query for %s = %q, want %q", tt.url, got, tt.wantQuery)
+		}
+	}
+}
+
+func TestTokenExpired(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		expiry time.Time
+		want   bool
+	}{
+		{"valid", now.Add(time.Hour), false},
+		{"expires now", now, false},
+		{"expired", now.Add(-time.Second), true},
+		{"no expiry", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		if got := tokenExpired(&oauth2.Token{Expiry: tt.expiry}, now); got != tt.want {
+			t.Errorf("%s: tokenExpired() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
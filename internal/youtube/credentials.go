@@ -0,0 +1,149 @@
+package youtube
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// Credentials are the OAuth client and, optionally, the refresh token to
+// use instead of the client secret file and the saved token, such as when
+// they are passed in the environment of a container.
+type Credentials struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// ParseCredentials decodes credentials from blob, base64-encoded JSON as
+// returned by Encode. Standard and URL-safe base64, padded or not, are
+// accepted.
+func ParseCredentials(blob string) (*Credentials, error) {
+	blob = strings.TrimSpace(blob)
+	var data []byte
+	var err error
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if data, err = enc.DecodeString(blob); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode credentials: %w", err)
+	}
+	c := &Credentials{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("unable to parse credentials: %w", err)
+	}
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return nil, fmt.Errorf("credentials need client_id and client_secret")
+	}
+	return c, nil
+}
+
+// Encode returns the credentials as base64-encoded JSON, for ParseCredentials.
+func (c *Credentials) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// WithCredentials uses the OAuth client in c instead of the client secret
+// file. If c has a refresh token, it is used instead of the saved token,
+// and refreshed access tokens are kept in memory only.
+func WithCredentials(c *Credentials) Option {
+	return func(o *clientOptions) {
+		o.creds = c
+	}
+}
+
+// oauthConfig returns the OAuth client from the credentials given with
+// WithCredentials, or else from the client secret file at oauthPath.
+func (o *clientOptions) oauthConfig(oauthPath string) (*oauth2.Config, error) {
+	if o.creds != nil {
+		return &oauth2.Config{
+			ClientID:     o.creds.ClientID,
+			ClientSecret: o.creds.ClientSecret,
+			Endpoint:     google.Endpoint,
+			Scopes:       o.scopes(),
+		}, nil
+	}
+	b, err := os.ReadFile(oauthPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read it: %w", ErrClientSecret, err)
+	}
+	config, err := google.ConfigFromJSON(b, o.scopes()...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to parse it: %w", ErrClientSecret, err)
+	}
+	return config, nil
+}
+
+// ClientID returns the ID of the OAuth client that WithCredentials or the
+// client secret file at oauthPath names, checking that it can be used.
+func ClientID(oauthPath string, opts ...Option) (string, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	config, err := o.oauthConfig(oauthPath)
+	if err != nil {
+		return "", err
+	}
+	return config.ClientID, nil
+}
+
+// memoryStore keeps the token in memory, starting from a refresh token
+// given with WithCredentials.
+type memoryStore struct {
+	tok *oauth2.Token
+}
+
+func (s *memoryStore) Load() (*oauth2.Token, error) {
+	if s.tok == nil {
+		return nil, fmt.Errorf("no token in the credentials: %w", os.ErrNotExist)
+	}
+	return s.tok, nil
+}
+
+func (s *memoryStore) Save(tok *oauth2.Token) error {
+	s.tok = tok
+	return nil
+}
+
+func (s *memoryStore) Delete() error {
+	s.tok = nil
+	return nil
+}
+
+func (s *memoryStore) String() string { return "credentials (refresh token)" }
+
+// ExportCredentials returns the OAuth client in the client secret file at
+// oauthPath and the refresh token saved at tokenPath, to pass to another
+// machine with WithCredentials. Of opts, only WithTokenStorage and
+// WithCredentials apply.
+func ExportCredentials(oauthPath, tokenPath string, opts ...Option) (*Credentials, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	config, err := o.oauthConfig(oauthPath)
+	if err != nil {
+		return nil, err
+	}
+	store, err := o.tokenStore(tokenPath)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read token: %w", err)
+	}
+	if tok.RefreshToken == "" {
+		return nil, fmt.Errorf("token in %s has no refresh token", tokenPath)
+	}
+	return &Credentials{ClientID: config.ClientID, ClientSecret: config.ClientSecret, RefreshToken: tok.RefreshToken}, nil
+}
@@ -0,0 +1,67 @@
+package youtube
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestParseCredentials(t *testing.T) {
+	want := Credentials{ClientID: "id.apps.googleusercontent.com", ClientSecret: "secret", RefreshToken: "1//refresh"}
+	json := `{"client_id": "id.apps.googleusercontent.com", "client_secret": "secret", "refresh_token": "1//refresh"}`
+	tests := []struct {
+		name    string
+		blob    string
+		wantErr bool
+	}{
+		{"encoded", want.Encode(), false},
+		{"url-safe unpadded", base64.RawURLEncoding.EncodeToString([]byte(json)), false},
+		{"trailing newline", base64.StdEncoding.EncodeToString([]byte(json)) + "\n", false},
+		{"not base64", "{not base64}", true},
+		{"not json", base64.StdEncoding.EncodeToString([]byte("client_id=id")), true},
+		{"no secret", base64.StdEncoding.EncodeToString([]byte(`{"client_id": "id"}`)), true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCredentials(tt.blob)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ParseCredentials() error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && *got != want {
+			t.Errorf("%s: ParseCredentials() = %+v, want %+v", tt.name, got, want)
+		}
+	}
+}
+
+func TestCredentialsOptions(t *testing.T) {
+	o := &clientOptions{creds: &Credentials{ClientID: "id", ClientSecret: "secret", RefreshToken: "r"}}
+	config, err := o.oauthConfig("missing/oauth.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ClientID != "id" || config.ClientSecret != "secret" || config.Endpoint.TokenURL == "" {
+		t.Errorf("oauthConfig() = %+v, want the client from the credentials", config)
+	}
+
+	store, err := o.tokenStore("missing/token.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := store.Load()
+	if err != nil || tok.RefreshToken != "r" || tok.AccessToken != "" {
+		t.Errorf("Load() = %+v, %v; want only the refresh token", tok, err)
+	}
+	if err := store.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() after Delete = %v, want os.ErrNotExist", err)
+	}
+
+	// Without a refresh token, the saved token is used.
+	o.creds.RefreshToken = ""
+	if store, err = o.tokenStore("token.json"); err != nil || store.String() != "token.json" {
+		t.Errorf("tokenStore() = %v, %v; want the token file", store, err)
+	}
+}
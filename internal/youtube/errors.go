@@ -0,0 +1,86 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+var (
+	// ErrNotFound is wrapped by errors for videos and channels that don't
+	// exist or aren't visible to the authenticated user.
+	ErrNotFound = errors.New("not found")
+	// ErrNoCaptions is wrapped by errors for videos without caption tracks.
+	ErrNoCaptions = errors.New("no captions found")
+	// ErrTooLarge is wrapped by errors for captions over the client's
+	// maximum caption size.
+	ErrTooLarge = errors.New("captions too large")
+	// ErrAuthorizationRequired is wrapped by errors from clients made
+	// WithoutSignIn that would otherwise have asked the user to sign in.
+	ErrAuthorizationRequired = errors.New("authorization required")
+	// ErrClientSecret is wrapped by errors for a client secret file that
+	// can't be read or parsed.
+	ErrClientSecret = errors.New("unusable client secret file")
+)
+
+// IsNotFound reports whether err means a video, channel, or caption track
+// doesn't exist.
+func IsNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.Is(err, ErrNotFound) || errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
+
+// IsTransient reports whether err may go away if the request is tried
+// again: a server error, rate limiting, or a network failure or timeout. A
+// missing video or captions, an exhausted quota, and a canceled request are
+// not transient.
+func IsTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || IsQuotaExceeded(err) {
+		return false
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		if apiErr.Code >= http.StatusInternalServerError || apiErr.Code == http.StatusTooManyRequests {
+			return true
+		}
+		for _, item := range apiErr.Errors {
+			if item.Reason == "rateLimitExceeded" || item.Reason == "userRateLimitExceeded" {
+				return true
+			}
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsAuthError reports whether err means the credentials were rejected, the
+// token could not be refreshed, signing in is required, or the client
+// secret file can't be used.
+func IsAuthError(err error) bool {
+	var apiErr *googleapi.Error
+	var retrieveErr *oauth2.RetrieveError
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusUnauthorized || errors.As(err, &retrieveErr) ||
+		errors.Is(err, ErrAuthorizationRequired) || errors.Is(err, ErrClientSecret)
+}
+
+// IsAPIDisabled reports whether err means the YouTube Data API isn't
+// enabled for the OAuth client's Cloud project.
+func IsAPIDisabled(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "accessNotConfigured" {
+			return true
+		}
+	}
+	return strings.Contains(apiErr.Message, "SERVICE_DISABLED") || strings.Contains(apiErr.Message, "has not been used in project")
+}
@@ -0,0 +1,85 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+func TestErrorClasses(t *testing.T) {
+	_, secretErr := (&clientOptions{}).oauthConfig(filepath.Join(t.TempDir(), "missing.json"))
+	tests := []struct {
+		name               string
+		err                error
+		notFound, authFail bool
+	}{
+		{"missing client secret", secretErr, false, true},
+		{"missing video", fmt.Errorf("video %s %w", "abc", ErrNotFound), true, false},
+		{"404", fmt.Errorf("error downloading captions: %w", &googleapi.Error{Code: 404}), true, false},
+		{"401", &googleapi.Error{Code: 401}, false, true},
+		{"refresh failed", fmt.Errorf("Get: %w", &oauth2.RetrieveError{}), false, true},
+		{"sign in required", signInRequired("no token in token.json"), false, true},
+		{"forbidden", &googleapi.Error{Code: 403}, false, false},
+		{"other", errors.New("boom"), false, false},
+	}
+	for _, tt := range tests {
+		if got := IsNotFound(tt.err); got != tt.notFound {
+			t.Errorf("%s: IsNotFound() = %v, want %v", tt.name, got, tt.notFound)
+		}
+		if got := IsAuthError(tt.err); got != tt.authFail {
+			t.Errorf("%s: IsAuthError() = %v, want %v", tt.name, got, tt.authFail)
+		}
+	}
+}
+
+func TestIsAPIDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"access not configured", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "accessNotConfigured"}}}, true},
+		{"service disabled", fmt.Errorf("error listing channels: %w", &googleapi.Error{Code: 403, Message: "YouTube Data API v3 has not been used in project 123 before or it is disabled."}), true},
+		{"quota", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}, false},
+		{"unauthorized", &googleapi.Error{Code: 401}, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsAPIDisabled(tt.err); got != tt.want {
+			t.Errorf("%s: IsAPIDisabled() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", fmt.Errorf("error downloading captions: %w", &googleapi.Error{Code: 503}), true},
+		{"too many requests", &googleapi.Error{Code: 429}, true},
+		{"rate limited", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}, true},
+		{"network", &url.Error{Op: "Get", URL: "https://youtube.googleapis.com", Err: errors.New("connection reset by peer")}, true},
+		{"timeout", fmt.Errorf("error retrieving video details: %w", context.DeadlineExceeded), true},
+		{"cut short", io.ErrUnexpectedEOF, true},
+		{"quota", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}, false},
+		{"not found", &googleapi.Error{Code: 404}, false},
+		{"missing video", fmt.Errorf("video %s %w", "abc", ErrNotFound), false},
+		{"no captions", fmt.Errorf("%w for video %s", ErrNoCaptions, "abc"), false},
+		{"canceled", &url.Error{Op: "Get", URL: "https://youtube.googleapis.com", Err: context.Canceled}, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("%s: IsTransient() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package youtube
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// feedURL is where YouTube serves channels' Atom feeds. Feeds list a
+// channel's 15 latest uploads and, unlike the Data API, cost no quota.
+var feedURL = "https://www.youtube.com/feeds/videos.xml"
+
+// FeedURL returns the URL of a channel's Atom feed.
+func FeedURL(channelID string) string {
+	return feedURL + "?" + url.Values{"channel_id": {channelID}}.Encode()
+}
+
+// IsFeedURL reports whether s is the URL of a channel's feed, such as
+// https://www.youtube.com/feeds/videos.xml?channel_id=UC...
+func IsFeedURL(s string) bool {
+	ref, err := ParseChannelRef(s)
+	return err == nil && ref.ID != "" && strings.Contains(s, "/feeds/videos.xml")
+}
+
+// FeedEntry is a video listed in a channel's feed.
+type FeedEntry struct {
+	VideoID     string
+	ChannelID   string
+	Title       string
+	Description string
+	Published   time.Time
+	Updated     time.Time
+	Views       uint64
+}
+
+// ParseFeed parses a channel's Atom feed, with times in UTC.
+func ParseFeed(data []byte) ([]FeedEntry, error) {
+	var feed struct {
+		Entries []struct {
+			VideoID   string    `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+			ChannelID string    `xml:"http://www.youtube.com/xml/schemas/2015 channelId"`
+			Title     string    `xml:"title"`
+			Published time.Time `xml:"published"`
+			Updated   time.Time `xml:"updated"`
+			Group     struct {
+				Description string `xml:"description"`
+				Community   struct {
+					Statistics struct {
+						Views uint64 `xml:"views,attr"`
+					} `xml:"statistics"`
+				} `xml:"community"`
+			} `xml:"http://search.yahoo.com/mrss/ group"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("error parsing channel feed: %w", err)
+	}
+	entries := make([]FeedEntry, 0, len(feed.Entries))
+	for _, e := range feed.Entries {
+		if e.VideoID == "" {
+			continue
+		}
+		entries = append(entries, FeedEntry{
+			VideoID:     e.VideoID,
+			ChannelID:   e.ChannelID,
+			Title:       e.Title,
+			Description: e.Group.Description,
+			Published:   e.Published.UTC(),
+			Updated:     e.Updated.UTC(),
+			Views:       e.Group.Community.Statistics.Views,
+		})
+	}
+	return entries, nil
+}
+
+// Feed fetches a channel's feed: its 15 latest uploads, newest first,
+// without spending any quota. Feeds don't say which videos are shorts or
+// live streams, or have captions; look the videos up with LookupVideos for
+// that.
+func (c *Client) Feed(ctx context.Context, channelID string) ([]FeedEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, FeedURL(channelID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating feed request: %w", err)
+	}
+	client := c.feed
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching channel feed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("channel %s %w", channelID, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching channel feed: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading channel feed: %w", err)
+	}
+	return ParseFeed(data)
+}
+
+// LookupVideos looks videos up by ID, 50 per videos.list request, and
+// returns those that pass the shorts and live filters in opts, in the order
+// given. Videos that don't exist are left out.
+func (c *Client) LookupVideos(ctx context.Context, videoIDs []string, opts ListOptions) ([]VideoInfo, error) {
+	var videos []VideoInfo
+	for start := 0; start < len(videoIDs); start += 50 {
+		end := min(start+50, len(videoIDs))
+		items, err := c.fetchVideoBatch(ctx, videoIDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]VideoInfo, len(items))
+		for _, video := range items {
+			if c.keepVideo(video, opts) {
+				byID[video.Id] = newVideoInfo(video, opts)
+			}
+		}
+		for _, id := range videoIDs[start:end] {
+			if v, ok := byID[id]; ok {
+				videos = append(videos, v)
+			}
+		}
+	}
+	return videos, nil
+}
+
+// FeedVideos lists a channel's latest uploads from its feed instead of its
+// uploads playlist, looking up with the API only the videos skip reports
+// false for. With nothing new on the channel, it costs no quota. Only the
+// 15 latest uploads are listed, so a channel that uploads more than that
+// between runs needs listing in full with Videos.
+func (c *Client) FeedVideos(ctx context.Context, channelID string, skip func(videoID string) bool, opts ListOptions) iter.Seq2[VideoInfo, error] {
+	return func(yield func(VideoInfo, error) bool) {
+		entries, err := c.Feed(ctx, channelID)
+		if err != nil {
+			yield(VideoInfo{}, err)
+			return
+		}
+		var ids []string
+		for _, e := range entries {
+			if skip == nil || !skip(e.VideoID) {
+				ids = append(ids, e.VideoID)
+			}
+		}
+		videos, err := c.LookupVideos(ctx, ids, opts)
+		if err != nil {
+			yield(VideoInfo{}, err)
+			return
+		}
+		for _, v := range videos {
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
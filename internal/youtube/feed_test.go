@@ -0,0 +1,145 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns:media="http://search.yahoo.com/mrss/" xmlns="http://www.w3.org/2005/Atom">
+ <title>Gophers</title>
+ <entry>
+  <id>yt:video:v2</id>
+  <yt:videoId>v2</yt:videoId>
+  <yt:channelId>UC1</yt:channelId>
+  <title>Second &amp; newest</title>
+  <published>2024-05-02T10:00:00+00:00</published>
+  <updated>2024-05-03T10:00:00+00:00</updated>
+  <media:group>
+   <media:title>Second &amp; newest</media:title>
+   <media:description>Links: https://example.com</media:description>
+   <media:community><media:statistics views="1234"/></media:community>
+  </media:group>
+ </entry>
+ <entry>
+  <id>yt:video:v1</id>
+  <yt:videoId>v1</yt:videoId>
+  <yt:channelId>UC1</yt:channelId>
+  <title>First</title>
+  <published>2024-05-01T10:00:00+00:00</published>
+  <updated>2024-05-01T10:00:00+00:00</updated>
+ </entry>
+</feed>`
+
+func TestParseFeed(t *testing.T) {
+	got, err := ParseFeed([]byte(testFeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FeedEntry{
+		{
+			VideoID:     "v2",
+			ChannelID:   "UC1",
+			Title:       "Second & newest",
+			Description: "Links: https://example.com",
+			Published:   time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
+			Updated:     time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC),
+			Views:       1234,
+		},
+		{
+			VideoID:   "v1",
+			ChannelID: "UC1",
+			Title:     "First",
+			Published: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			Updated:   time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseFeed() = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseFeed([]byte("not xml")); err == nil {
+		t.Error("ParseFeed() of garbage succeeded")
+	}
+}
+
+func TestIsFeedURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"https://www.youtube.com/feeds/videos.xml?channel_id=UC1", true},
+		{"youtube.com/feeds/videos.xml?channel_id=UC1", true},
+		{"https://www.youtube.com/channel/UC1", false},
+		{"UC1", false},
+	}
+	for _, tt := range tests {
+		if got := IsFeedURL(tt.in); got != tt.want {
+			t.Errorf("IsFeedURL(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFeedVideos(t *testing.T) {
+	feeds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("channel_id") != "UC1" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns="http://www.w3.org/2005/Atom">
+<entry><yt:videoId>v4</yt:videoId></entry>
+<entry><yt:videoId>v3</yt:videoId></entry>
+<entry><yt:videoId>v2</yt:videoId></entry>
+<entry><yt:videoId>v1</yt:videoId></entry>
+</feed>`)
+	}))
+	t.Cleanup(feeds.Close)
+	old := feedURL
+	feedURL = feeds.URL + "/feeds/videos.xml"
+	t.Cleanup(func() { feedURL = old })
+
+	var lookups []string
+	fake := fakeChannel(10)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/youtube/v3/videos" {
+			lookups = append(lookups, r.URL.Query().Get("id"))
+		}
+		fake.ServeHTTP(w, r)
+	}))
+
+	var got []string
+	skip := func(id string) bool { return id == "v1" }
+	for v, err := range client.FeedVideos(context.Background(), "UC1", skip, ListOptions{MinDurationSeconds: 60}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v.VideoID)
+	}
+	// v3 is a short; v1 is skipped without a lookup.
+	if want := []string{"v4", "v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FeedVideos() = %v, want %v", got, want)
+	}
+	if want := []string{"v4,v3,v2"}; !reflect.DeepEqual(lookups, want) {
+		t.Errorf("FeedVideos() looked up %v, want %v", lookups, want)
+	}
+
+	// Nothing new costs no lookups.
+	lookups = nil
+	for _, err := range client.FeedVideos(context.Background(), "UC1", func(string) bool { return true }, ListOptions{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lookups != nil {
+		t.Errorf("FeedVideos() with nothing new looked up %v", lookups)
+	}
+
+	if _, err := client.Feed(context.Background(), "UCmissing"); err == nil {
+		t.Error("Feed() of a missing channel succeeded")
+	}
+}
@@ -0,0 +1,59 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// WatchLaterPlaylist is the ID of the signed-in user's Watch Later playlist.
+const WatchLaterPlaylist = "WL"
+
+// ErrWatchLater is returned for the Watch Later playlist, which the API has
+// listed as empty since 2016.
+var ErrWatchLater = errors.New("the YouTube API doesn't list the Watch Later playlist; save the videos to another playlist and use that instead")
+
+// PlaylistVideoIDs lists the videos of a playlist, in playlist order, 50
+// per playlistItems.list request. The signed-in user's private playlists
+// are listed too.
+func (c *Client) PlaylistVideoIDs(ctx context.Context, playlistID string) ([]string, error) {
+	var ids []string
+	call := c.Service.PlaylistItems.List([]string{"contentDetails"}).PlaylistId(playlistID).MaxResults(50).Context(ctx)
+	for pageToken := ""; ; {
+		response, err := call.PageToken(pageToken).Do()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving playlist items: %w", err)
+		}
+		for _, item := range response.Items {
+			if item.ContentDetails != nil && item.ContentDetails.VideoId != "" {
+				ids = append(ids, item.ContentDetails.VideoId)
+			}
+		}
+		if pageToken = response.NextPageToken; pageToken == "" {
+			break
+		}
+	}
+	if playlistID == WatchLaterPlaylist && len(ids) == 0 {
+		return nil, ErrWatchLater
+	}
+	return ids, nil
+}
+
+// LikedVideoIDs lists the videos the signed-in user has liked, most
+// recently liked first, 50 per videos.list request.
+func (c *Client) LikedVideoIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	call := c.Service.Videos.List([]string{"id"}).MyRating("like").MaxResults(50).Context(ctx)
+	for pageToken := ""; ; {
+		response, err := call.PageToken(pageToken).Do()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving liked videos: %w", err)
+		}
+		for _, video := range response.Items {
+			ids = append(ids, video.Id)
+		}
+		if pageToken = response.NextPageToken; pageToken == "" {
+			return ids, nil
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestPlaylistVideoIDs(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("playlistId") == WatchLaterPlaylist:
+			fmt.Fprint(w, `{"items":[]}`)
+		case q.Get("playlistId") != "PL1":
+			http.Error(w, "bad query", http.StatusBadRequest)
+		case q.Get("pageToken") == "":
+			fmt.Fprint(w, `{"items":[{"contentDetails":{"videoId":"v1"}},{"contentDetails":{}}],"nextPageToken":"p2"}`)
+		default:
+			fmt.Fprint(w, `{"items":[{"contentDetails":{"videoId":"v2"}}]}`)
+		}
+	}))
+
+	got, err := client.PlaylistVideoIDs(context.Background(), "PL1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v1", "v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PlaylistVideoIDs() = %v, want %v", got, want)
+	}
+	if _, err := client.PlaylistVideoIDs(context.Background(), WatchLaterPlaylist); !errors.Is(err, ErrWatchLater) {
+		t.Errorf("PlaylistVideoIDs(WL) error = %v, want ErrWatchLater", err)
+	}
+}
+
+func TestLikedVideoIDs(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("myRating") != "like":
+			http.Error(w, "bad query", http.StatusBadRequest)
+		case q.Get("pageToken") == "":
+			fmt.Fprint(w, `{"items":[{"id":"v9"}],"nextPageToken":"p2"}`)
+		default:
+			fmt.Fprint(w, `{"items":[{"id":"v3"}]}`)
+		}
+	}))
+
+	got, err := client.LikedVideoIDs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v9", "v3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LikedVideoIDs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,27 @@
+package youtube
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// proxySchemes are the proxy URL schemes net/http can use.
+var proxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// ProxyTransport returns a transport that sends requests through the proxy
+// at rawURL, an http://, https://, or socks5:// URL with optional user
+// info. It is otherwise http.DefaultTransport, for use with WithTransport.
+func ProxyTransport(rawURL string) (http.RoundTripper, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", rawURL, err)
+	}
+	if !slices.Contains(proxySchemes, u.Scheme) || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: want http://, https://, or socks5://host:port", rawURL)
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyURL(u)
+	return t, nil
+}
@@ -0,0 +1,253 @@
+package youtube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/n2p5/ytt/internal/filelock"
+	"google.golang.org/api/googleapi"
+)
+
+// Quota cost, in units, of each YouTube Data API method ytt calls. The
+// default daily allowance for a project is 10,000 units.
+const (
+	CostVideosList        = 1
+	CostChannelsList      = 1
+	CostPlaylistItemsList = 1
+	CostSubscriptionsList = 1
+	CostCaptionsList      = 50
+	CostCaptionsDownload  = 200
+	CostCaptionsInsert    = 400
+	CostCaptionsDelete    = 50
+)
+
+// CostDownloadTranscript is the quota used by one DownloadTranscript call.
+const CostDownloadTranscript = CostVideosList + CostCaptionsList + CostCaptionsDownload
+
+// Method is a YouTube Data API method, named as in the API reference.
+type Method string
+
+// The API methods ytt calls.
+const (
+	MethodVideosList        Method = "videos.list"
+	MethodChannelsList      Method = "channels.list"
+	MethodPlaylistItemsList Method = "playlistItems.list"
+	MethodSubscriptionsList Method = "subscriptions.list"
+	MethodCaptionsList      Method = "captions.list"
+	MethodCaptionsDownload  Method = "captions.download"
+	MethodCaptionsInsert    Method = "captions.insert"
+	MethodCaptionsDelete    Method = "captions.delete"
+)
+
+var methodCosts = map[Method]int{
+	MethodVideosList:        CostVideosList,
+	MethodChannelsList:      CostChannelsList,
+	MethodPlaylistItemsList: CostPlaylistItemsList,
+	MethodSubscriptionsList: CostSubscriptionsList,
+	MethodCaptionsList:      CostCaptionsList,
+	MethodCaptionsDownload:  CostCaptionsDownload,
+	MethodCaptionsInsert:    CostCaptionsInsert,
+	MethodCaptionsDelete:    CostCaptionsDelete,
+}
+
+// Cost returns the method's quota cost in units.
+func (m Method) Cost() int {
+	return methodCosts[m]
+}
+
+// IsQuotaExceeded reports whether err is the API refusing a request because
+// the project's daily quota is used up.
+func IsQuotaExceeded(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "quotaExceeded" || item.Reason == "dailyLimitExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
+// QuotaReset returns when the daily quota next resets after now. Quotas
+// reset at midnight Pacific time.
+func QuotaReset(now time.Time) time.Time {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		loc = time.FixedZone("PST", -8*60*60)
+	}
+	t := now.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+}
+
+// DefaultDailyQuota is the quota, in units, a Cloud project gets each day
+// unless it has been granted more.
+const DefaultDailyQuota = 10000
+
+// QuotaDay returns the day, in Pacific time, whose quota calls made at now
+// count against, as a date such as "2025-01-31".
+func QuotaDay(now time.Time) string {
+	return QuotaReset(now).AddDate(0, 0, -1).Format(time.DateOnly)
+}
+
+// QuotaLog is the quota used on one quota day, as logged by LogQuota.
+type QuotaLog struct {
+	Day   string `json:"day"`
+	Units int    `json:"units"`
+}
+
+// LoadQuotaLog returns the quota logged in the file at path for the quota
+// day of now. Quota logged on an earlier day, or no log at all, counts as
+// none used.
+func LoadQuotaLog(path string, now time.Time) (QuotaLog, error) {
+	log := QuotaLog{Day: QuotaDay(now)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return log, nil
+	}
+	if err != nil {
+		return log, fmt.Errorf("unable to read quota log: %w", err)
+	}
+	var saved QuotaLog
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return log, fmt.Errorf("unable to parse quota log %s: %w", path, err)
+	}
+	if saved.Day == log.Day {
+		log.Units = saved.Units
+	}
+	return log, nil
+}
+
+// LogQuota adds units to the quota logged in the file at path for the
+// quota day of now, so the quota used by separate runs adds up, even runs
+// finishing at once.
+func LogQuota(path string, units int, now time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("unable to create quota log directory: %w", err)
+	}
+	return filelock.With(path, func() error {
+		log, err := LoadQuotaLog(path, now)
+		if err != nil {
+			return err
+		}
+		log.Units += units
+		data, err := json.Marshal(log)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("unable to save quota log: %w", err)
+		}
+		return nil
+	})
+}
+
+// DownloadTranscriptCalls are the API calls DownloadTranscript makes.
+var DownloadTranscriptCalls = []Method{MethodVideosList, MethodCaptionsList, MethodCaptionsDownload}
+
+// CostEstimator tallies API calls and their quota cost. The same tally
+// serves for calls planned under --dry-run and for calls a Client has made.
+// It is safe for concurrent use, and the zero value is ready to use.
+type CostEstimator struct {
+	mu    sync.Mutex
+	calls map[Method]int
+}
+
+// Add tallies n calls to each of the given methods.
+func (e *CostEstimator) Add(n int, methods ...Method) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.calls == nil {
+		e.calls = map[Method]int{}
+	}
+	for _, m := range methods {
+		e.calls[m] += n
+	}
+}
+
+// Total returns the quota cost of the calls tallied so far.
+func (e *CostEstimator) Total() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	total := 0
+	for m, n := range e.calls {
+		total += m.Cost() * n
+	}
+	return total
+}
+
+// MethodUsage is the tally for one API method.
+type MethodUsage struct {
+	Method Method
+	Calls  int
+	Cost   int
+}
+
+// Breakdown returns the tally per method, costliest first.
+func (e *CostEstimator) Breakdown() []MethodUsage {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	usage := make([]MethodUsage, 0, len(e.calls))
+	for m, n := range e.calls {
+		usage = append(usage, MethodUsage{Method: m, Calls: n, Cost: m.Cost() * n})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Cost != usage[j].Cost {
+			return usage[i].Cost > usage[j].Cost
+		}
+		return usage[i].Method < usage[j].Method
+	})
+	return usage
+}
+
+// methodOf identifies the API method an HTTP request calls.
+func methodOf(req *http.Request) (Method, bool) {
+	path := req.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/upload/youtube/v3/captions"):
+		return MethodCaptionsInsert, req.Method == http.MethodPost
+	case strings.HasPrefix(path, "/youtube/v3/captions/"):
+		return MethodCaptionsDownload, req.Method == http.MethodGet
+	case path == "/youtube/v3/captions" && req.Method == http.MethodDelete:
+		return MethodCaptionsDelete, true
+	}
+
+	if req.Method != http.MethodGet {
+		return "", false
+	}
+	switch path {
+	case "/youtube/v3/videos":
+		return MethodVideosList, true
+	case "/youtube/v3/channels":
+		return MethodChannelsList, true
+	case "/youtube/v3/playlistItems":
+		return MethodPlaylistItemsList, true
+	case "/youtube/v3/subscriptions":
+		return MethodSubscriptionsList, true
+	case "/youtube/v3/captions":
+		return MethodCaptionsList, true
+	}
+	return "", false
+}
+
+// meterTransport tallies the API calls sent through it.
+type meterTransport struct {
+	base  http.RoundTripper
+	usage *CostEstimator
+}
+
+func (t *meterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if m, ok := methodOf(req); ok {
+		t.usage.Add(1, m)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,130 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestMethodOf(t *testing.T) {
+	tests := []struct {
+		method, url string
+		want        Method
+		ok          bool
+	}{
+		{"GET", "https://youtube.googleapis.com/youtube/v3/videos?id=a", MethodVideosList, true},
+		{"GET", "https://youtube.googleapis.com/youtube/v3/channels?mine=true", MethodChannelsList, true},
+		{"GET", "https://youtube.googleapis.com/youtube/v3/playlistItems", MethodPlaylistItemsList, true},
+		{"GET", "https://youtube.googleapis.com/youtube/v3/subscriptions", MethodSubscriptionsList, true},
+		{"GET", "https://youtube.googleapis.com/youtube/v3/captions?videoId=a", MethodCaptionsList, true},
+		{"GET", "https://youtube.googleapis.com/youtube/v3/captions/abc?tfmt=vtt", MethodCaptionsDownload, true},
+		{"POST", "https://youtube.googleapis.com/upload/youtube/v3/captions?uploadType=multipart", MethodCaptionsInsert, true},
+		{"DELETE", "https://youtube.googleapis.com/youtube/v3/captions?id=abc", MethodCaptionsDelete, true},
+		{"POST", "https://oauth2.googleapis.com/token", "", false},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := methodOf(req)
+		if got != tt.want && tt.ok || ok != tt.ok {
+			t.Errorf("methodOf(%s %s) = %q, %v, want %q, %v", tt.method, tt.url, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCostEstimator(t *testing.T) {
+	var e CostEstimator
+	e.Add(2, DownloadTranscriptCalls...)
+	e.Add(3, MethodChannelsList)
+
+	if got, want := e.Total(), 2*CostDownloadTranscript+3*CostChannelsList; got != want {
+		t.Errorf("Total() = %d, want %d", got, want)
+	}
+	want := []MethodUsage{
+		{MethodCaptionsDownload, 2, 400},
+		{MethodCaptionsList, 2, 100},
+		{MethodChannelsList, 3, 3},
+		{MethodVideosList, 2, 2},
+	}
+	if got := e.Breakdown(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Breakdown() = %+v, want %+v", got, want)
+	}
+}
+
+func TestIsQuotaExceeded(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"quota", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}, true},
+		{"wrapped", fmt.Errorf("error downloading captions: %w", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "dailyLimitExceeded"}}}), true},
+		{"rate limit", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}, false},
+		{"forbidden", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "forbidden"}}}, false},
+		{"other", errors.New("quotaExceeded"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsQuotaExceeded(tt.err); got != tt.want {
+			t.Errorf("%s: IsQuotaExceeded() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQuotaReset(t *testing.T) {
+	pacific, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2025, 3, 1, 15, 0, 0, 0, time.UTC), time.Date(2025, 3, 2, 0, 0, 0, 0, pacific)},
+		// Still the previous day in Pacific time.
+		{time.Date(2025, 3, 2, 7, 0, 0, 0, time.UTC), time.Date(2025, 3, 2, 0, 0, 0, 0, pacific)},
+		// Daylight saving time.
+		{time.Date(2025, 7, 1, 6, 59, 0, 0, time.UTC), time.Date(2025, 7, 1, 0, 0, 0, 0, pacific)},
+		{time.Date(2025, 7, 1, 7, 0, 0, 0, time.UTC), time.Date(2025, 7, 2, 0, 0, 0, 0, pacific)},
+	}
+	for _, tt := range tests {
+		if got := QuotaReset(tt.now); !got.Equal(tt.want) {
+			t.Errorf("QuotaReset(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestQuotaLog(t *testing.T) {
+	if _, err := time.LoadLocation("America/Los_Angeles"); err != nil {
+		t.Skip("no time zone database")
+	}
+	path := filepath.Join(t.TempDir(), "quota.json")
+	morning := time.Date(2025, 3, 1, 17, 0, 0, 0, time.UTC)
+	if err := LogQuota(path, 251, morning); err != nil {
+		t.Fatal(err)
+	}
+	if err := LogQuota(path, 50, morning.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	log, err := LoadQuotaLog(path, morning.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (QuotaLog{Day: "2025-03-01", Units: 301}); log != want {
+		t.Errorf("LoadQuotaLog() = %+v, want %+v", log, want)
+	}
+
+	// After midnight Pacific time the quota starts over.
+	nextDay := time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC)
+	if log, err = LoadQuotaLog(path, nextDay); err != nil || log.Units != 0 || log.Day != "2025-03-02" {
+		t.Errorf("LoadQuotaLog() the next day = %+v, %v; want none used on 2025-03-02", log, err)
+	}
+}
@@ -0,0 +1,75 @@
+package youtube
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/n2p5/ytt/internal/ratelimit"
+)
+
+// rateLimitRetries is how many times a request rejected for going too fast
+// is sent again after slowing down.
+const rateLimitRetries = 3
+
+// limitTransport paces the requests sent through it, slowing down and
+// retrying when the API reports rateLimitExceeded.
+type limitTransport struct {
+	base    http.RoundTripper
+	limiter *ratelimit.Limiter
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || !rateLimited(resp) {
+			return resp, err
+		}
+		t.limiter.SlowDown()
+		if attempt == rateLimitRetries || !rewindable(req) {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rateLimited reports whether resp rejects a request for exceeding a rate
+// limit, as opposed to the daily quota. It leaves resp's body readable.
+func rateLimited(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		bytes.Contains(body, []byte(`"rateLimitExceeded"`)) ||
+		bytes.Contains(body, []byte(`"userRateLimitExceeded"`))
+}
+
+// rewindable reports whether req can be sent again.
+func rewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind returns a copy of req with a fresh body, to send it again.
+func rewind(req *http.Request) (*http.Request, error) {
+	req = req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	return req, nil
+}
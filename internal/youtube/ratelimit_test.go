@@ -0,0 +1,63 @@
+package youtube
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/n2p5/ytt/internal/ratelimit"
+)
+
+func TestLimitTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		failures  int32
+		wantCalls int32
+		wantCode  int
+		wantRate  float64
+	}{
+		{"ok", 200, `{}`, 0, 1, 200, 1000},
+		{"rate limited once", 403, `{"error":{"errors":[{"reason":"rateLimitExceeded"}]}}`, 1, 2, 200, 500},
+		{"too many requests", 429, `{}`, 1, 2, 200, 500},
+		{"gives up", 403, `{"error":{"errors":[{"reason":"userRateLimitExceeded"}]}}`, 10, rateLimitRetries + 1, 403, 62.5},
+		{"quota exceeded", 403, `{"error":{"errors":[{"reason":"quotaExceeded"}]}}`, 10, 1, 403, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(tt.status)
+					io.WriteString(w, tt.body)
+					return
+				}
+				io.WriteString(w, `{}`)
+			}))
+			defer server.Close()
+
+			// A high rate keeps the waits after each slowdown short.
+			limiter := ratelimit.New(1000, 10, nil)
+			client := &http.Client{Transport: &limitTransport{base: http.DefaultTransport, limiter: limiter}}
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status = %d (%s), want %d", resp.StatusCode, body, tt.wantCode)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", got, tt.wantCalls)
+			}
+			if got := limiter.Rate(); got != tt.wantRate {
+				t.Errorf("rate = %v, want %v", got, tt.wantRate)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/youtube/v3"
+)
+
+// The scopes ytt asks for. Reading channels, playlists, and videos only
+// needs ReadScope; listing, downloading, and uploading captions needs
+// CaptionScope as well, which also allows changing the account's videos.
+const (
+	ReadScope    = youtube.YoutubeReadonlyScope
+	CaptionScope = youtube.YoutubeForceSslScope
+)
+
+// WithCaptionAccess asks for CaptionScope when signing in, instead of
+// waiting for the first caption request to ask for it.
+func WithCaptionAccess() Option {
+	return func(o *clientOptions) {
+		o.captionAccess = true
+	}
+}
+
+// scopes returns the scopes to ask for when signing in.
+func (o *clientOptions) scopes() []string {
+	if o.captionAccess {
+		return []string{ReadScope, CaptionScope}
+	}
+	return []string{ReadScope}
+}
+
+// needsCaptionScope reports whether req calls the captions API.
+func needsCaptionScope(req *http.Request) bool {
+	return strings.Contains(req.URL.Path, "/youtube/v3/captions")
+}
+
+// grantSource is the token source of a client. When a request needs a scope
+// the token doesn't grant, it asks the user for that scope too, with
+// incremental authorization, and switches to the new token.
+type grantSource struct {
+	ctx    context.Context
+	config *oauth2.Config
+	store  tokenStore
+	// scopesOf looks up the scopes an access token grants.
+	scopesOf func(accessToken string) ([]string, error)
+	// authorize runs the OAuth flow for config's scopes.
+	authorize func(config *oauth2.Config) (*oauth2.Token, error)
+	w         io.Writer
+
+	mu  sync.Mutex
+	src oauth2.TokenSource
+	// granted are the token's scopes, or nil until they are looked up.
+	granted []string
+	// refused is the error that a client made WithoutSignIn failed to get
+	// a scope with, so later requests fail the same way without asking.
+	refused error
+}
+
+func newGrantSource(ctx context.Context, config *oauth2.Config, store tokenStore, tok *oauth2.Token, o *clientOptions) *grantSource {
+	client := o.httpClient()
+	return &grantSource{
+		ctx:    ctx,
+		config: config,
+		store:  store,
+		scopesOf: func(accessToken string) ([]string, error) {
+			return tokenScopes(client, accessToken)
+		},
+		authorize: func(config *oauth2.Config) (*oauth2.Token, error) {
+			if o.noSignIn {
+				return nil, signInRequired("the token doesn't grant " + config.Scopes[len(config.Scopes)-1])
+			}
+			return getTokenFromWeb(ctx, config, o.noBrowser, oauth2.SetAuthURLParam("include_granted_scopes", "true"))
+		},
+		w:   os.Stderr,
+		src: oauth2.ReuseTokenSource(tok, config.TokenSource(ctx, tok)),
+	}
+}
+
+func (g *grantSource) Token() (*oauth2.Token, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.src.Token()
+}
+
+// require makes sure the token grants scope, asking the user for it if it
+// doesn't. Concurrent callers wait for a single authorization.
+func (g *grantSource) require(scope string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.granted == nil {
+		tok, err := g.src.Token()
+		if err != nil {
+			return err
+		}
+		if g.granted, err = g.scopesOf(tok.AccessToken); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(g.granted, scope) {
+		return nil
+	}
+	if g.refused != nil {
+		return g.refused
+	}
+
+	fmt.Fprintf(g.w, "Downloading and uploading captions needs permission to manage your YouTube account (%s).\n", scope)
+	config := *g.config
+	config.Scopes = append(slices.Clone(g.config.Scopes), scope)
+	tok, err := g.authorize(&config)
+	if errors.Is(err, ErrAuthorizationRequired) {
+		g.refused = err
+	}
+	if err != nil {
+		return err
+	}
+	if err := g.store.Save(tok); err != nil {
+		return err
+	}
+	g.config = &config
+	g.src = oauth2.ReuseTokenSource(tok, config.TokenSource(g.ctx, tok))
+	g.granted = append(g.granted, scope)
+	return nil
+}
+
+// scopeTransport asks for CaptionScope before the first caption request.
+// It sits above the other layers, so the time spent authorizing doesn't
+// count toward the request's timeout.
+type scopeTransport struct {
+	base   http.RoundTripper
+	grants *grantSource
+}
+
+func (t *scopeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if needsCaptionScope(req) {
+		if err := t.grants.require(CaptionScope); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, fmt.Errorf("unable to get permission to manage captions: %w", err)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
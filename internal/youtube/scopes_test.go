@@ -0,0 +1,140 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestNeedsCaptionScope(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://youtube.googleapis.com/youtube/v3/captions?videoId=a", true},
+		{"https://youtube.googleapis.com/youtube/v3/captions/abc", true},
+		{"https://youtube.googleapis.com/upload/youtube/v3/captions?uploadType=multipart", true},
+		{"https://youtube.googleapis.com/youtube/v3/videos?id=a", false},
+		{"https://youtube.googleapis.com/youtube/v3/channels?mine=true", false},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := needsCaptionScope(req); got != tt.want {
+			t.Errorf("needsCaptionScope(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestGrantSourceRequire(t *testing.T) {
+	tests := []struct {
+		name          string
+		granted       []string
+		wantAuthorize bool
+	}{
+		{"already granted", []string{ReadScope, CaptionScope}, false},
+		{"read only", []string{ReadScope}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := fileStore(filepath.Join(t.TempDir(), "token.json"))
+			config := &oauth2.Config{Scopes: []string{ReadScope}}
+			tok := &oauth2.Token{AccessToken: "old", Expiry: time.Now().Add(time.Hour)}
+			var asked []string
+			lookups := 0
+			g := &grantSource{
+				ctx:    context.Background(),
+				config: config,
+				store:  store,
+				scopesOf: func(string) ([]string, error) {
+					lookups++
+					return tt.granted, nil
+				},
+				authorize: func(c *oauth2.Config) (*oauth2.Token, error) {
+					asked = c.Scopes
+					return &oauth2.Token{AccessToken: "new", Expiry: time.Now().Add(time.Hour)}, nil
+				},
+				w:   io.Discard,
+				src: oauth2.StaticTokenSource(tok),
+			}
+
+			for range 2 {
+				if err := g.require(CaptionScope); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if lookups != 1 {
+				t.Errorf("looked up the scopes %d times, want once", lookups)
+			}
+			got, err := g.Token()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.wantAuthorize {
+				if asked != nil || got.AccessToken != "old" {
+					t.Errorf("authorized %q and switched to %q, want the old token kept", asked, got.AccessToken)
+				}
+				return
+			}
+			if !slices.Equal(asked, []string{ReadScope, CaptionScope}) {
+				t.Errorf("authorized scopes %q, want %q", asked, []string{ReadScope, CaptionScope})
+			}
+			if got.AccessToken != "new" {
+				t.Errorf("Token() = %q after authorizing, want the new token", got.AccessToken)
+			}
+			if saved, err := store.Load(); err != nil || saved.AccessToken != "new" {
+				t.Errorf("saved token = %+v, %v; want the new token", saved, err)
+			}
+			if !slices.Equal(config.Scopes, []string{ReadScope}) {
+				t.Errorf("original config scopes changed to %q", config.Scopes)
+			}
+		})
+	}
+}
+
+func TestGrantSourceWithoutSignIn(t *testing.T) {
+	asked := 0
+	g := &grantSource{
+		config:   &oauth2.Config{Scopes: []string{ReadScope}},
+		scopesOf: func(string) ([]string, error) { return []string{ReadScope}, nil },
+		authorize: func(*oauth2.Config) (*oauth2.Token, error) {
+			asked++
+			return nil, signInRequired("the token doesn't grant " + CaptionScope)
+		},
+		w:   io.Discard,
+		src: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "a"}),
+	}
+	for range 2 {
+		if err := g.require(CaptionScope); !errors.Is(err, ErrAuthorizationRequired) {
+			t.Errorf("require() = %v, want ErrAuthorizationRequired", err)
+		}
+	}
+	if asked != 1 {
+		t.Errorf("asked to sign in %d times, want once", asked)
+	}
+}
+
+func TestGetTokenWithoutSignIn(t *testing.T) {
+	o := &clientOptions{noSignIn: true}
+	store := fileStore(filepath.Join(t.TempDir(), "token.json"))
+	if _, err := getToken(context.Background(), &oauth2.Config{}, store, string(store), o); !errors.Is(err, ErrAuthorizationRequired) {
+		t.Errorf("getToken() without a token = %v, want ErrAuthorizationRequired", err)
+	}
+
+	if err := os.WriteFile(string(store), []byte("{\"access_tok"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getToken(context.Background(), &oauth2.Config{}, store, string(store), o); !errors.Is(err, ErrAuthorizationRequired) {
+		t.Errorf("getToken() with a corrupt token = %v, want ErrAuthorizationRequired", err)
+	}
+}
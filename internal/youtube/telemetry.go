@@ -0,0 +1,68 @@
+package youtube
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/n2p5/ytt/internal/telemetry"
+)
+
+// traceTransport records a span for each API call sent through it, under
+// parent, and counts the calls and their quota cost.
+type traceTransport struct {
+	base   http.RoundTripper
+	tel    *telemetry.Exporter
+	parent *telemetry.Span
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	m, ok := methodOf(req)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	method := telemetry.String("ytt.api.method", string(m))
+	t.tel.Count("ytt.api.calls", "{call}", 1, method)
+	t.tel.Count("ytt.quota.units", "{unit}", int64(m.Cost()), method)
+
+	span := t.tel.StartClient(t.parent, "youtube "+string(m), method,
+		telemetry.Int("ytt.quota.cost", m.Cost()),
+		telemetry.String("http.request.method", req.Method),
+		telemetry.String("url.path", req.URL.Path))
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+	span.SetAttributes(telemetry.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		t.tel.Count("ytt.api.errors", "{call}", 1, method, telemetry.Int("http.response.status_code", resp.StatusCode))
+		span.End(fmt.Errorf("%s", resp.Status))
+		return resp, nil
+	}
+	// The span lasts until the response has been read, which for caption
+	// downloads is most of the call.
+	resp.Body = &spanBody{ReadCloser: resp.Body, span: span}
+	return resp, nil
+}
+
+// spanBody ends a span when the response body it belongs to is closed.
+type spanBody struct {
+	io.ReadCloser
+	span *telemetry.Span
+	err  error
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.span.End(b.err)
+	return err
+}
@@ -0,0 +1,62 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/n2p5/ytt/internal/telemetry"
+)
+
+func TestTraceTransport(t *testing.T) {
+	exports := map[string]string{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body any
+		json.NewDecoder(r.Body).Decode(&body)
+		data, _ := json.Marshal(body)
+		exports[r.URL.Path] = string(data)
+	}))
+	defer collector.Close()
+
+	tel, err := telemetry.New(telemetry.Config{Endpoint: collector.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &traceTransport{base: &recordTransport{}, tel: tel, parent: tel.Start(nil, "ytt transcript")}
+	for _, url := range []string{
+		"https://youtube.googleapis.com/youtube/v3/captions/cap-1",
+		"https://oauth2.googleapis.com/token",
+	} {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err := tel.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	traces := exports["/v1/traces"]
+	if strings.Count(traces, `"spanId"`) != 1 || !strings.Contains(traces, `"name":"youtube captions.download"`) {
+		t.Errorf("traces = %s, want one span for captions.download", traces)
+	}
+	if !strings.Contains(traces, `{"key":"ytt.quota.cost","value":{"intValue":"200"}}`) {
+		t.Errorf("traces = %s, want the quota cost", traces)
+	}
+	metrics := exports["/v1/metrics"]
+	for _, want := range []string{`"name":"ytt.api.calls"`, `"name":"ytt.quota.units"`, `"asInt":"200"`} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics = %s, want %s", metrics, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// timeoutTransport limits each request sent through it, including reading
+// its response body, to a fixed time, so a stalled connection fails the
+// request instead of hanging the batch it belongs to.
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) && req.Context().Err() == nil {
+			return nil, fmt.Errorf("request timed out after %s: %w", t.timeout, err)
+		}
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel, timeout: t.timeout}
+	return resp, nil
+}
+
+// cancelBody releases a request's deadline when its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel  context.CancelFunc
+	timeout time.Duration
+}
+
+func (b *cancelBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("response timed out after %s: %w", b.timeout, err)
+	}
+	return n, err
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// readCaptions reads downloaded captions from r, failing if they are larger
+// than the client's maximum caption size.
+func (c *Client) readCaptions(r io.Reader) ([]byte, error) {
+	if c.maxCaptionSize <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, c.maxCaptionSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.maxCaptionSize {
+		return nil, fmt.Errorf("%w (over %d bytes)", ErrTooLarge, c.maxCaptionSize)
+	}
+	return data, nil
+}
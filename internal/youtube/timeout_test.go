@@ -0,0 +1,79 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stallTransport responds at once but never finishes sending the body,
+// unless the request is canceled.
+type stallTransport struct {
+	stallHeaders bool
+}
+
+func (t stallTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.stallHeaders {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: stallBody{req.Context()}, Request: req}, nil
+}
+
+type stallBody struct {
+	ctx context.Context
+}
+
+func (b stallBody) Read(p []byte) (int, error) {
+	<-b.ctx.Done()
+	return 0, b.ctx.Err()
+}
+
+func (b stallBody) Close() error { return nil }
+
+func TestTimeoutTransport(t *testing.T) {
+	for _, stallHeaders := range []bool{true, false} {
+		tr := &timeoutTransport{base: stallTransport{stallHeaders: stallHeaders}, timeout: 10 * time.Millisecond}
+		req, err := http.NewRequest("GET", "https://youtube.googleapis.com/youtube/v3/captions/a", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := tr.RoundTrip(req)
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "timed out after 10ms") {
+			t.Errorf("stalled headers %v: error = %v, want a timeout", stallHeaders, err)
+		}
+	}
+}
+
+func TestReadCaptions(t *testing.T) {
+	tests := []struct {
+		max     int64
+		data    string
+		wantErr bool
+	}{
+		{0, "WEBVTT\n", false},
+		{7, "WEBVTT\n", false},
+		{6, "WEBVTT\n", true},
+	}
+	for _, tt := range tests {
+		c := &Client{maxCaptionSize: tt.max}
+		got, err := c.readCaptions(strings.NewReader(tt.data))
+		if tt.wantErr {
+			if !errors.Is(err, ErrTooLarge) {
+				t.Errorf("max %d: error = %v, want ErrTooLarge", tt.max, err)
+			}
+			continue
+		}
+		if err != nil || string(got) != tt.data {
+			t.Errorf("max %d: readCaptions() = %q, %v, want %q", tt.max, got, err, tt.data)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package youtube
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/n2p5/ytt/internal/keyring"
+	"golang.org/x/oauth2"
+)
+
+// TokenStorage is where the OAuth token is kept.
+type TokenStorage string
+
+const (
+	// StorageFile keeps the token as plain JSON in the token file.
+	StorageFile TokenStorage = "file"
+	// StorageKeyring keeps the token in the system keyring, under the
+	// token file's absolute path.
+	StorageKeyring TokenStorage = "keyring"
+	// StorageEncrypted keeps the token in the token file with ".enc"
+	// appended, encrypted with a passphrase.
+	StorageEncrypted TokenStorage = "encrypted"
+	// StorageAuto uses the keyring when there is one, and otherwise an
+	// encrypted file if a passphrase is given, or else a plain file.
+	StorageAuto TokenStorage = "auto"
+)
+
+// errCorruptToken is wrapped by the error loading a saved token that
+// can't be parsed, which is replaced by signing in again.
+var errCorruptToken = errors.New("corrupt token")
+
+// keyringService is the service the token is stored under in the keyring.
+const keyringService = "ytt"
+
+// tokenKDFIterations is the PBKDF2-SHA256 work factor for encrypted
+// tokens, as OWASP recommends.
+const tokenKDFIterations = 600_000
+
+// WithTokenStorage keeps the OAuth token in storage instead of a plain
+// file. passphrase encrypts the token for StorageEncrypted and
+// StorageAuto. A token already saved in the plain token file is moved into
+// the new storage the first time it is needed.
+func WithTokenStorage(storage TokenStorage, passphrase string) Option {
+	return func(o *clientOptions) {
+		o.storage, o.passphrase = storage, passphrase
+	}
+}
+
+// tokenStore loads and saves the OAuth token.
+type tokenStore interface {
+	// Load returns the saved token, or an error wrapping os.ErrNotExist if
+	// there is none.
+	Load() (*oauth2.Token, error)
+	Save(tok *oauth2.Token) error
+	// Delete removes the saved token, if there is one.
+	Delete() error
+	// String describes where the token is kept.
+	String() string
+}
+
+// tokenStore returns the store for the token at tokenPath, or for the
+// refresh token given with WithCredentials.
+func (o *clientOptions) tokenStore(tokenPath string) (tokenStore, error) {
+	if o.creds != nil && o.creds.RefreshToken != "" {
+		return &memoryStore{tok: &oauth2.Token{RefreshToken: o.creds.RefreshToken}}, nil
+	}
+	storage := o.storage
+	if storage == StorageAuto {
+		switch {
+		case keyring.Available():
+			storage = StorageKeyring
+		case o.passphrase != "":
+			storage = StorageEncrypted
+		default:
+			storage = StorageFile
+		}
+	}
+	plain := fileStore(tokenPath)
+	switch storage {
+	case "", StorageFile:
+		return plain, nil
+	case StorageKeyring:
+		account, err := filepath.Abs(tokenPath)
+		if err != nil {
+			return nil, err
+		}
+		return migratingStore{keyringStore(account), plain}, nil
+	case StorageEncrypted:
+		if o.passphrase == "" {
+			return nil, fmt.Errorf("encrypted token storage needs a passphrase")
+		}
+		return migratingStore{encryptedStore{path: tokenPath + ".enc", passphrase: o.passphrase}, plain}, nil
+	}
+	return nil, fmt.Errorf("unknown token storage %q (want file, keyring, encrypted, or auto)", storage)
+}
+
+// fileStore keeps the token as plain JSON in a file.
+type fileStore string
+
+func (s fileStore) Load() (*oauth2.Token, error) { return tokenFromFile(string(s)) }
+func (s fileStore) Save(tok *oauth2.Token) error { return saveToken(string(s), tok) }
+func (s fileStore) String() string               { return string(s) }
+
+func (s fileStore) Delete() error {
+	if err := os.Remove(string(s)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to delete token file: %w", err)
+	}
+	return nil
+}
+
+// keyringStore keeps the token in the system keyring under an account
+// name.
+type keyringStore string
+
+func (s keyringStore) Load() (*oauth2.Token, error) {
+	data, err := keyring.Get(keyringService, string(s))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("no token in the keyring for %s: %w", string(s), os.ErrNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read token from keyring: %w", err)
+	}
+	tok := &oauth2.Token{}
+	if err := json.Unmarshal([]byte(data), tok); err != nil {
+		return nil, fmt.Errorf("unable to parse token from keyring: %w: %w", errCorruptToken, err)
+	}
+	return tok, nil
+}
+
+func (s keyringStore) Save(tok *oauth2.Token) error {
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	if err := keyring.Set(keyringService, string(s), string(data)); err != nil {
+		return fmt.Errorf("unable to save token to keyring: %w", err)
+	}
+	return nil
+}
+
+func (s keyringStore) Delete() error {
+	if err := keyring.Delete(keyringService, string(s)); err != nil {
+		return fmt.Errorf("unable to delete token from keyring: %w", err)
+	}
+	return nil
+}
+
+func (s keyringStore) String() string { return "keyring (" + string(s) + ")" }
+
+// encryptedStore keeps the token in a file, encrypted with AES-256-GCM
+// under a key derived from a passphrase.
+type encryptedStore struct {
+	path       string
+	passphrase string
+}
+
+// encryptedToken is the file format of an encryptedStore.
+type encryptedToken struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// aead returns the cipher for the passphrase and salt.
+func (s encryptedStore) aead(salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, s.passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s encryptedStore) Load() (*oauth2.Token, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var enc encryptedToken
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return nil, fmt.Errorf("unable to parse encrypted token: %w: %w", errCorruptToken, err)
+	}
+	if enc.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unable to decrypt token: unknown key derivation %q", enc.KDF)
+	}
+	aead, err := s.aead(enc.Salt, enc.Iterations)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt token: %w", err)
+	}
+	plain, err := aead.Open(nil, enc.Nonce, enc.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt token in %s: wrong passphrase or corrupted file", s.path)
+	}
+	tok := &oauth2.Token{}
+	if err := json.Unmarshal(plain, tok); err != nil {
+		return nil, fmt.Errorf("unable to parse decrypted token: %w: %w", errCorruptToken, err)
+	}
+	return tok, nil
+}
+
+func (s encryptedStore) Save(tok *oauth2.Token) error {
+	plain, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	enc := encryptedToken{KDF: "pbkdf2-sha256", Iterations: tokenKDFIterations, Salt: make([]byte, 16)}
+	rand.Read(enc.Salt)
+	aead, err := s.aead(enc.Salt, enc.Iterations)
+	if err != nil {
+		return fmt.Errorf("unable to encrypt token: %w", err)
+	}
+	enc.Nonce = make([]byte, aead.NonceSize())
+	rand.Read(enc.Nonce)
+	enc.Ciphertext = aead.Seal(nil, enc.Nonce, plain, nil)
+
+	data, err := json.MarshalIndent(enc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTokenFile(s.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("unable to save encrypted token: %w", err)
+	}
+	return nil
+}
+
+func (s encryptedStore) Delete() error  { return fileStore(s.path).Delete() }
+func (s encryptedStore) String() string { return s.path + " (encrypted)" }
+
+// migratingStore is a store that takes over a token left in the plain
+// token file: loading it moves it into the store and deletes the file.
+type migratingStore struct {
+	tokenStore
+	plain fileStore
+}
+
+func (s migratingStore) Load() (*oauth2.Token, error) {
+	tok, err := s.tokenStore.Load()
+	if !errors.Is(err, os.ErrNotExist) {
+		return tok, err
+	}
+	tok, perr := s.plain.Load()
+	if perr != nil {
+		return nil, err
+	}
+	if err := s.tokenStore.Save(tok); err != nil {
+		return nil, err
+	}
+	if err := s.plain.Delete(); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Moved the token from %s to %s\n", s.plain, s.tokenStore)
+	return tok, nil
+}
+
+func (s migratingStore) Delete() error {
+	return errors.Join(s.tokenStore.Delete(), s.plain.Delete())
+}
@@ -0,0 +1,103 @@
+package youtube
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/n2p5/ytt/internal/keyring"
+	"golang.org/x/oauth2"
+)
+
+func TestEncryptedStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json.enc")
+	s := encryptedStore{path: path, passphrase: "correct horse"}
+	if _, err := s.Load(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() before Save = %v, want os.ErrNotExist", err)
+	}
+
+	want := &oauth2.Token{AccessToken: "a", RefreshToken: "secret-refresh"}
+	if err := s.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-refresh") {
+		t.Errorf("saved file holds the token in the clear:\n%s", data)
+	}
+	got, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AccessToken != want.AccessToken || got.RefreshToken != want.RefreshToken {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	wrong := encryptedStore{path: path, passphrase: "battery staple"}
+	if _, err := wrong.Load(); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Load() with the wrong passphrase = %v, want a wrong passphrase error", err)
+	}
+}
+
+func TestTokenStoreMigrates(t *testing.T) {
+	tokenPath := writeToken(t, &oauth2.Token{AccessToken: "a", RefreshToken: "r"})
+	o := &clientOptions{storage: StorageEncrypted, passphrase: "pw"}
+	store, err := o.tokenStore(tokenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.RefreshToken != "r" {
+		t.Errorf("Load() = %+v, want the token from the plain file", tok)
+	}
+	if _, err := os.Stat(tokenPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("plain token file still there after migrating: %v", err)
+	}
+	if _, err := (encryptedStore{path: tokenPath + ".enc", passphrase: "pw"}).Load(); err != nil {
+		t.Errorf("token not saved encrypted: %v", err)
+	}
+
+	if err := store.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() after Delete = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestTokenStoreChoice(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    clientOptions
+		want    string
+		wantErr bool
+	}{
+		{"default", clientOptions{}, "token.json", false},
+		{"file", clientOptions{storage: StorageFile}, "token.json", false},
+		{"encrypted", clientOptions{storage: StorageEncrypted, passphrase: "pw"}, "token.json.enc (encrypted)", false},
+		{"encrypted without passphrase", clientOptions{storage: StorageEncrypted}, "", true},
+		{"auto without keyring", clientOptions{storage: StorageAuto, passphrase: "pw"}, "token.json.enc (encrypted)", false},
+		{"unknown", clientOptions{storage: "vault"}, "", true},
+	}
+	for _, tt := range tests {
+		if tt.opts.storage == StorageAuto && keyring.Available() {
+			continue
+		}
+		store, err := tt.opts.tokenStore("token.json")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: tokenStore() error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && store.String() != tt.want {
+			t.Errorf("%s: tokenStore() = %s, want %s", tt.name, store, tt.want)
+		}
+	}
+}
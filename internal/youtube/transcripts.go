@@ -1,92 +1,428 @@
 package youtube
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"regexp"
-	"strings"
+	"slices"
+	"time"
+
+	"github.com/n2p5/ytt/internal/filename"
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/transcript"
+	"github.com/n2p5/ytt/internal/trash"
 )
 
+// DownloadResult describes a transcript saved by DownloadTranscript.
+type DownloadResult struct {
+	VideoID     string
+	Title       string
+	ChannelID   string
+	PublishedAt time.Time
+	Language    string
+	// Path is the saved file's path.
+	Path string
+	// RawPath is the path of the captions as downloaded, if kept.
+	RawPath string
+	// Hash is the SHA-256 of the saved file, and SourceHash that of the
+	// captions it was made from, as recorded in the manifest.
+	Hash       string
+	SourceHash string
+	// CaptionID is the caption track the transcript was made from, and
+	// CaptionUpdatedAt when it was last updated on YouTube, if known.
+	CaptionID        string
+	CaptionUpdatedAt time.Time
+	// Imported is the file the transcript was imported from, if it wasn't
+	// downloaded from YouTube.
+	Imported string
+	// DetectedLanguage is the language the captions' text reads as, if it
+	// could be told. Language is set to it when the track has none.
+	DetectedLanguage string
+	// Unchanged reports that the saved files were left as they were, and
+	// only what the manifest records about them changed.
+	Unchanged bool
+	// Warnings describes anything about the saved transcript that may
+	// need attention, such as a title truncated to fit the file name.
+	Warnings []string
+}
+
+// RawDir is the directory inside the output directory where captions are
+// kept exactly as downloaded.
+const RawDir = "raw"
+
+// DownloadOptions configures DownloadTranscript.
+type DownloadOptions struct {
+	// Format converts captions to the given format before they are saved.
+	// Empty keeps the format YouTube provides.
+	Format transcript.Format
+	// Process, if set, transforms the parsed captions before they are saved.
+	Process transcript.Processor
+	// KeepRaw saves the captions exactly as downloaded under RawDir, so
+	// they can be processed again later without another download.
+	KeepRaw bool
+	// Lang is the caption language asked for, if any. Captions whose text
+	// reads as another language are saved with a warning.
+	Lang string
+	// KeepStyles keeps VTT speaker labels, cue settings, and markup when
+	// converting or processing captions, instead of reducing cues to text.
+	KeepStyles bool
+	// RawText keeps HTML entities and inline tags in caption text when
+	// converting or processing captions, instead of decoding and removing
+	// them; see transcript.ParseOptions.
+	RawText bool
+	// WordTimings downloads captions as VTT, which carries per-word start
+	// times for auto-generated tracks, and keeps those times in JSON output.
+	WordTimings bool
+	// Header begins each transcript with a header block describing the
+	// video, the caption track, and the download, in a form suited to the
+	// format; see transcript.Format.FormatHeader. SRT and SBV have no room
+	// for one. Generator names the program in it.
+	Header    bool
+	Generator string
+	// Newline is the line ending transcripts are saved with. Captions are
+	// always saved as UTF-8 without a byte order mark.
+	Newline transcript.Newline
+	// Log receives a line for each step of a download, such as where the
+	// transcript was saved. If nil, the lines are discarded.
+	Log io.Writer
+}
+
+// CaptionsAsVTT reports whether captions should be downloaded as VTT rather
+// than in their original format: for the word timings only VTT carries, or
+// for a header block when the captions are saved in the format they come
+// in, which may be SBV, with no room for one.
+func (opts DownloadOptions) CaptionsAsVTT() bool {
+	return opts.WordTimings || opts.Header && opts.Format == ""
+}
+
+// logf writes a line to opts.Log, if set.
+func (opts DownloadOptions) logf(format string, args ...any) {
+	if opts.Log != nil {
+		fmt.Fprintf(opts.Log, format+"\n", args...)
+	}
+}
+
 // DownloadTranscript downloads the transcript for a video and saves it to the output directory.
-func (c *Client) DownloadTranscript(videoID, outputDir string) error {
-	videoCall := c.Service.Videos.List([]string{"snippet"}).Id(videoID)
-	videoResponse, err := videoCall.Do()
+func (c *Client) DownloadTranscript(videoID, outputDir string, opts DownloadOptions) (*DownloadResult, error) {
+	src, track, err := c.FindCaptions(videoID)
 	if err != nil {
-		return fmt.Errorf("error retrieving video details: %w", err)
+		return nil, err
+	}
+	data, err := c.DownloadCaptions(track, opts.CaptionsAsVTT())
+	if err != nil {
+		return nil, err
 	}
 
-	if len(videoResponse.Items) == 0 {
-		return fmt.Errorf("video %s not found", videoID)
+	opts.logf("Downloading transcript for video: %s", src.Title)
+	res, err := SaveCaptions(outputDir, src, data, opts)
+	if err != nil {
+		return nil, err
+	}
+	res.CaptionID, res.CaptionUpdatedAt = track.CaptionID, track.UpdatedAt()
+	return res, nil
+}
+
+// SaveCaptions saves captions returned by FetchCaptions: the transcript, as
+// SaveTranscript does, and the captions themselves if opts.KeepRaw is set.
+func SaveCaptions(outputDir string, src transcript.Source, data []byte, opts DownloadOptions) (*DownloadResult, error) {
+	var rawPath string
+	if opts.KeepRaw {
+		var err error
+		if rawPath, err = saveRaw(outputDir, src.VideoID, data); err != nil {
+			return nil, err
+		}
+	}
+
+	detected, warning := CheckLanguage(&src, data, opts.Lang)
+	res, err := SaveTranscript(outputDir, src, data, opts)
+	if err != nil {
+		return nil, err
 	}
+	res.RawPath = rawPath
+	res.SourceHash = manifest.Hash(data)
+	res.DetectedLanguage = detected
+	if warning != "" {
+		res.Warnings = append(res.Warnings, warning)
+	}
+	return res, nil
+}
 
-	videoTitle := videoResponse.Items[0].Snippet.Title
-	sanitizedTitle := SanitizeFilename(videoTitle)
+// CheckLanguage detects the language of captions, taking it as src's
+// language if the track has none. It returns the detected language, or ""
+// if it can't be told, and a warning if the captions read as a language
+// other than the one requested or the track's.
+func CheckLanguage(src *transcript.Source, data []byte, requested string) (string, string) {
+	cues, err := transcript.Parse(data)
+	if err != nil {
+		// Rendering the captions reports the error.
+		return "", ""
+	}
+	detected := transcript.DetectLanguage(cues)
+	if detected == "" {
+		return "", ""
+	}
+	var warning string
+	switch {
+	case requested != "" && !transcript.SameLanguage(detected, requested):
+		warning = fmt.Sprintf("captions of %s were requested in %s but read as %s", src.VideoID, requested, detected)
+	case src.Language != "" && !transcript.SameLanguage(detected, src.Language):
+		warning = fmt.Sprintf("captions of %s are labeled %s but read as %s", src.VideoID, src.Language, detected)
+	}
+	if src.Language == "" {
+		src.Language = detected
+	}
+	return detected, warning
+}
 
-	captionsCall := c.Service.Captions.List([]string{"snippet"}, videoID)
-	captionsResponse, err := captionsCall.Do()
+// FetchCaptions downloads a video's captions without saving them, returning
+// the video and track they came from and the caption data. With vtt set,
+// captions are requested as VTT rather than in their original format.
+// Canceling ctx stops the requests in progress.
+func (c *Client) FetchCaptions(ctx context.Context, videoID string, vtt bool) (transcript.Source, []byte, error) {
+	src, track, err := c.findCaptions(ctx, videoID, c.pickCaption(videoID))
+	if err != nil {
+		return transcript.Source{}, nil, err
+	}
+	data, err := c.downloadCaptions(ctx, track, vtt)
 	if err != nil {
-		return fmt.Errorf("error retrieving captions list: %w", err)
+		return transcript.Source{}, nil, err
 	}
+	return src, data, nil
+}
 
-	if len(captionsResponse.Items) == 0 {
-		return fmt.Errorf("no captions found for video %s", videoID)
+// FindCaptions looks up a video and the caption track FetchCaptions would
+// download for it, preferring English or the client's language, without
+// downloading the captions.
+func (c *Client) FindCaptions(videoID string) (transcript.Source, CaptionTrack, error) {
+	return c.findCaptions(context.Background(), videoID, c.pickCaption(videoID))
+}
+
+// pickCaption returns the pick for findCaptions that chooses the track by
+// the client's language and preference.
+func (c *Client) pickCaption(videoID string) func(tracks []CaptionTrack) (CaptionTrack, error) {
+	return func(tracks []CaptionTrack) (CaptionTrack, error) {
+		track, ok := SelectCaption(tracks, CaptionChoice{Lang: c.language, Match: c.langMatch, Prefer: c.prefer})
+		if !ok {
+			return CaptionTrack{}, fmt.Errorf("%w in %s for video %s", ErrNoCaptions, c.language, videoID)
+		}
+		return track, nil
 	}
+}
 
-	var captionID string
-	for _, caption := range captionsResponse.Items {
-		if caption.Snippet.Language == "en" || caption.Snippet.Language == "" {
-			captionID = caption.Id
-			break
+// FindCaptionTrack is FindCaptions for a track picked before: the one
+// with captionID, or without one, the one labeled lang, or unlabeled. It
+// fails with ErrNoCaptions if the video no longer has that track.
+func (c *Client) FindCaptionTrack(videoID, captionID, lang string) (transcript.Source, CaptionTrack, error) {
+	return c.findCaptions(context.Background(), videoID, func(tracks []CaptionTrack) (CaptionTrack, error) {
+		if captionID != "" {
+			if i := slices.IndexFunc(tracks, func(t CaptionTrack) bool { return t.CaptionID == captionID }); i >= 0 {
+				return tracks[i], nil
+			}
+			return CaptionTrack{}, fmt.Errorf("%w: caption track %s is no longer on video %s", ErrNoCaptions, captionID, videoID)
 		}
+		track, ok := LanguageCaption(tracks, lang)
+		if !ok {
+			return CaptionTrack{}, fmt.Errorf("%w in %s for video %s", ErrNoCaptions, lang, videoID)
+		}
+		return track, nil
+	})
+}
+
+// findCaptions looks up a video and the caption track pick chooses from
+// its tracks.
+func (c *Client) findCaptions(ctx context.Context, videoID string, pick func(tracks []CaptionTrack) (CaptionTrack, error)) (transcript.Source, CaptionTrack, error) {
+	videoCall := c.Service.Videos.List([]string{"snippet"}).Id(videoID).Context(ctx)
+	videoResponse, err := videoCall.Do()
+	if err != nil {
+		return transcript.Source{}, CaptionTrack{}, fmt.Errorf("error retrieving video details: %w", err)
+	}
+
+	if len(videoResponse.Items) == 0 {
+		return transcript.Source{}, CaptionTrack{}, fmt.Errorf("video %s %w", videoID, ErrNotFound)
 	}
 
-	if captionID == "" {
-		captionID = captionsResponse.Items[0].Id
+	snippet := videoResponse.Items[0].Snippet
+	src := transcript.Source{VideoID: videoID, Title: snippet.Title, ChannelID: snippet.ChannelId, Channel: snippet.ChannelTitle}
+	if published, err := time.Parse(time.RFC3339, snippet.PublishedAt); err == nil {
+		src.PublishedAt = published
 	}
 
-	downloadCall := c.Service.Captions.Download(captionID)
+	tracks, err := c.listCaptions(ctx, videoID)
+	if err != nil {
+		return transcript.Source{}, CaptionTrack{}, err
+	}
+
+	if len(tracks) == 0 {
+		return transcript.Source{}, CaptionTrack{}, fmt.Errorf("%w for video %s", ErrNoCaptions, videoID)
+	}
+
+	track, err := pick(tracks)
+	if err != nil {
+		return transcript.Source{}, CaptionTrack{}, err
+	}
+	src.Language, src.TrackKind = track.Language, track.TrackKind
+	return src, track, nil
+}
+
+// DownloadCaptions downloads a caption track found by FindCaptions. With vtt
+// set, captions are requested as VTT rather than in their original format.
+func (c *Client) DownloadCaptions(track CaptionTrack, vtt bool) ([]byte, error) {
+	return c.downloadCaptions(context.Background(), track, vtt)
+}
+
+func (c *Client) downloadCaptions(ctx context.Context, track CaptionTrack, vtt bool) ([]byte, error) {
+	downloadCall := c.Service.Captions.Download(track.CaptionID).Context(ctx)
+	if vtt {
+		downloadCall = downloadCall.Tfmt("vtt")
+	}
 	resp, err := downloadCall.Download()
 	if err != nil {
-		return fmt.Errorf("error downloading captions: %w", err)
+		return nil, fmt.Errorf("error downloading captions: %w", err)
 	}
 	defer resp.Body.Close()
 
+	data, err := c.readCaptions(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading captions: %w", err)
+	}
+	return data, nil
+}
+
+// saveRaw saves downloaded captions unmodified as RawDir/<videoID>.<format>.
+func saveRaw(outputDir, videoID string, data []byte) (string, error) {
+	dir := filepath.Join(outputDir, RawDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creating raw captions directory: %w", err)
+	}
+
+	path := filepath.Join(dir, videoID+"."+string(transcript.DetectFormat(data)))
+	if _, err := trash.Move(outputDir, path); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("error writing raw captions: %w", err)
+	}
+	return path, nil
+}
+
+// SaveTranscript converts and processes caption data as opts asks and saves
+// it to the output directory under TranscriptFilename, moving any file it
+// replaces to the trash.
+func SaveTranscript(outputDir string, src transcript.Source, data []byte, opts DownloadOptions) (*DownloadResult, error) {
+	data, err := RenderTranscript(data, src, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("error creating output directory: %w", err)
+		return nil, fmt.Errorf("error creating output directory: %w", err)
 	}
 
-	filename := fmt.Sprintf("%s-%s.txt", videoID, sanitizedTitle)
-	outputPath := filepath.Join(outputDir, filename)
+	outputPath := filepath.Join(outputDir, TranscriptFilename(src.VideoID, src.Title, opts.Format))
 
-	outputFile, err := os.Create(outputPath)
+	trashed, err := trash.Move(outputDir, outputPath)
 	if err != nil {
-		return fmt.Errorf("error creating output file: %w", err)
+		return nil, err
+	}
+	if trashed != "" {
+		opts.logf("Moved previous version to: %s", trashed)
 	}
-	defer outputFile.Close()
 
-	fmt.Fprintf(os.Stderr, "Downloading transcript for video: %s\n", videoTitle)
-	fmt.Fprintf(os.Stderr, "Saving to: %s\n", outputPath)
+	opts.logf("Saving to: %s", outputPath)
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("error writing transcript: %w", err)
+	}
 
-	if _, err := io.Copy(outputFile, resp.Body); err != nil {
-		return fmt.Errorf("error writing transcript: %w", err)
+	opts.logf("Transcript saved successfully!")
+	res := &DownloadResult{
+		VideoID:     src.VideoID,
+		Title:       src.Title,
+		ChannelID:   src.ChannelID,
+		PublishedAt: src.PublishedAt,
+		Language:    src.Language,
+		Path:        outputPath,
+		Hash:        manifest.Hash(data),
+	}
+	if _, truncated := sanitizeFilename(src.Title); truncated {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("title of %s truncated in file name %s", src.VideoID, filepath.Base(outputPath)))
 	}
+	return res, nil
+}
 
-	fmt.Fprintf(os.Stderr, "Transcript saved successfully!\n")
-	return nil
+// RenderTranscript returns caption data as SaveTranscript would save it.
+func RenderTranscript(data []byte, src transcript.Source, opts DownloadOptions) ([]byte, error) {
+	if opts.Format == "" && opts.Process == nil && !opts.Header {
+		return transcript.Normalize(data, opts.Newline), nil
+	}
+	return renderCaptions(data, src, opts)
+}
+
+// renderCaptions parses caption data, runs it through opts.Process, and
+// renders the result in opts.Format, or the format it arrived in.
+func renderCaptions(data []byte, src transcript.Source, opts DownloadOptions) ([]byte, error) {
+	format := transcript.DetectFormat(data)
+	cues, err := transcript.ParseFormatOptions(data, format, transcript.ParseOptions{RawText: opts.RawText})
+	if err != nil {
+		return nil, fmt.Errorf("error parsing captions: %w", err)
+	}
+	for i := range cues {
+		if !opts.WordTimings {
+			cues[i].Words = nil
+		}
+		if !opts.KeepStyles {
+			cues[i].Speaker, cues[i].Settings, cues[i].Markup = "", "", ""
+		}
+	}
+	if opts.Process != nil {
+		if cues, err = opts.Process.Process(src, cues); err != nil {
+			return nil, fmt.Errorf("error processing captions: %w", err)
+		}
+	}
+	if opts.Format != "" {
+		format = opts.Format
+	}
+	var out []byte
+	if opts.Header {
+		out, err = format.FormatHeader(src.Header(time.Now(), opts.Generator), cues)
+	} else {
+		out, err = format.FormatSource(src, cues)
+	}
+	if err != nil || format.Binary() {
+		return out, err
+	}
+	return transcript.Normalize(out, opts.Newline), nil
 }
 
-// SanitizeFilename removes or replaces characters that are invalid in filenames.
-func SanitizeFilename(filename string) string {
-	reg := regexp.MustCompile(`[<>:"/\\|?*]`)
-	sanitized := reg.ReplaceAllString(filename, "_")
+// MaxTranscriptFilename is the longest, in bytes, TranscriptFilename's
+// names can be: an 11-character video ID, a title of up to 100 bytes, and
+// the longest extension.
+const MaxTranscriptFilename = 11 + 1 + maxTitleBytes + len(".docx")
 
-	if len(sanitized) > 100 {
-		sanitized = sanitized[:100]
+// TranscriptFilename returns the file name a video's transcript is saved
+// under in the given format. Captions kept in YouTube's format use .txt.
+func TranscriptFilename(videoID, title string, format transcript.Format) string {
+	ext := "txt"
+	if format != "" {
+		ext = string(format)
 	}
+	return fmt.Sprintf("%s-%s.%s", videoID, SanitizeFilename(title), ext)
+}
 
-	sanitized = strings.Trim(sanitized, " .")
+// maxTitleBytes is the longest a title may be in a file name.
+const maxTitleBytes = 100
 
+// SanitizeFilename removes or replaces characters that are invalid in
+// filenames on any platform, so an archive can move between systems, and
+// shortens it to 100 bytes.
+func SanitizeFilename(name string) string {
+	sanitized, _ := sanitizeFilename(name)
 	return sanitized
 }
+
+// sanitizeFilename is SanitizeFilename, also reporting whether the name was
+// truncated.
+func sanitizeFilename(name string) (string, bool) {
+	return filename.Portable.Sanitize(name, maxTitleBytes)
+}
@@ -1,6 +1,18 @@
 package youtube
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/transcript"
+)
 
 func TestSanitizeFilename(t *testing.T) {
 	tests := []struct {
@@ -17,6 +29,9 @@ func TestSanitizeFilename(t *testing.T) {
 		{"long filename truncated", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
 		{"empty after sanitize", "...", ""},
 		{"mixed invalid chars", "My Video: Part 1/2 | Q&A?", "My Video_ Part 1_2 _ Q&A_"},
+		{"control characters", "line one\nline\ttwo", "line one_line_two"},
+		{"long multibyte title not split", strings.Repeat("é", 60), strings.Repeat("é", 50)},
+		{"reserved device name", "con", "con_"},
 	}
 
 	for _, tt := range tests {
@@ -28,3 +43,248 @@ func TestSanitizeFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestTranscriptFilename(t *testing.T) {
+	tests := []struct {
+		format transcript.Format
+		want   string
+	}{
+		{"", "abc123-My Video_ Part 1_2.txt"},
+		{transcript.FormatVTT, "abc123-My Video_ Part 1_2.vtt"},
+		{transcript.FormatPlain, "abc123-My Video_ Part 1_2.txt"},
+	}
+	for _, tt := range tests {
+		if got := TranscriptFilename("abc123", "My Video: Part 1/2", tt.format); got != tt.want {
+			t.Errorf("TranscriptFilename(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestRenderCaptions(t *testing.T) {
+	in := "0:00:01.000,0:00:02.000\nhello\n\n0:00:02.000,0:00:03.000\nhello\nworld\n"
+	p, err := transcript.ParsePipeline("dedupe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := renderCaptions([]byte(in), transcript.Source{VideoID: "abc"}, DownloadOptions{Process: p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0:00:01.000,0:00:02.000\nhello\n\n0:00:02.000,0:00:03.000\nworld\n"
+	if string(got) != want {
+		t.Errorf("renderCaptions() = %q, want %q", got, want)
+	}
+
+	got, err = renderCaptions([]byte(in), transcript.Source{}, DownloadOptions{Format: transcript.FormatSRT})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "1\n00:00:01,000 --> 00:00:02,000\nhello\n\n2\n00:00:02,000 --> 00:00:03,000\nhello\nworld\n"
+	if string(got) != want {
+		t.Errorf("renderCaptions() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderCaptionsWordTimings(t *testing.T) {
+	in := "WEBVTT\n\n00:00:01.000 --> 00:00:03.000\nso<00:00:01.500><c> today</c>\n"
+	tests := []struct {
+		name string
+		opts DownloadOptions
+		want string
+	}{
+		{"dropped by default", DownloadOptions{Format: transcript.FormatJSON}, `"text": "so today"
+    }`},
+		{"kept", DownloadOptions{Format: transcript.FormatJSON, WordTimings: true}, `"words": [
+        {
+          "start": 1,
+          "text": "so"
+        },
+        {
+          "start": 1.5,
+          "text": "today"
+        }
+      ]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderCaptions([]byte(in), transcript.Source{}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(got), tt.want) {
+				t.Errorf("renderCaptions() = %s, want it to contain %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderCaptionsHeader(t *testing.T) {
+	in := "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nhello\n"
+	src := transcript.Source{VideoID: "abc", Channel: "Chan", TrackKind: "asr"}
+	got, err := renderCaptions([]byte(in), src, DownloadOptions{Header: true, Generator: "ytt v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"WEBVTT\n\nNOTE\nvideo_id: abc\n", "channel: Chan\n", "track_kind: asr\n", "generator: ytt v1\n", "00:00:01.000 --> 00:00:02.000\nhello\n"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("renderCaptions() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestCaptionsAsVTT(t *testing.T) {
+	tests := []struct {
+		opts DownloadOptions
+		want bool
+	}{
+		{DownloadOptions{}, false},
+		{DownloadOptions{Format: transcript.FormatJSON, WordTimings: true}, true},
+		// The original format may be SBV, which can't hold a header.
+		{DownloadOptions{Header: true}, true},
+		{DownloadOptions{Header: true, Format: transcript.FormatPlain}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.CaptionsAsVTT(); got != tt.want {
+			t.Errorf("%+v.CaptionsAsVTT() = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestSaveRaw(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("\xef\xbb\xbfWEBVTT\r\n\r\n00:00:01.000 --> 00:00:02.000\r\nhi\r\n")
+
+	path, err := saveRaw(dir, "abc", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, RawDir, "abc.vtt"); path != want {
+		t.Errorf("saveRaw() path = %q, want %q", path, want)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("raw file = %q, want the downloaded bytes %q", got, data)
+	}
+}
+
+func TestSaveCaptionsHashes(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nhi\n")
+	src := transcript.Source{VideoID: "abc", Title: "T"}
+
+	res, err := SaveCaptions(dir, src, data, DownloadOptions{Format: transcript.FormatPlain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Hash != manifest.Hash(saved) {
+		t.Errorf("Hash = %s, want the hash of the saved file %s", res.Hash, manifest.Hash(saved))
+	}
+	if res.SourceHash != manifest.Hash(data) {
+		t.Errorf("SourceHash = %s, want the hash of the downloaded captions %s", res.SourceHash, manifest.Hash(data))
+	}
+}
+
+func TestRenderCaptionsKeepStyles(t *testing.T) {
+	in := "WEBVTT\n\n00:00:01.000 --> 00:00:03.000\n<v Ann>hello\n"
+	tests := []struct {
+		opts DownloadOptions
+		want string
+	}{
+		{DownloadOptions{Format: transcript.FormatPlain}, "hello\n"},
+		{DownloadOptions{Format: transcript.FormatPlain, KeepStyles: true}, "Ann: hello\n"},
+	}
+	for _, tt := range tests {
+		got, err := renderCaptions([]byte(in), transcript.Source{}, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("renderCaptions(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeFilenameTruncated(t *testing.T) {
+	if _, truncated := sanitizeFilename(strings.Repeat("a", 100)); truncated {
+		t.Error("100-character name reported as truncated")
+	}
+	if _, truncated := sanitizeFilename(strings.Repeat("a", 101)); !truncated {
+		t.Error("101-character name not reported as truncated")
+	}
+}
+
+func TestCheckLanguage(t *testing.T) {
+	english := []byte("WEBVTT\n\n00:00:01.000 --> 00:00:05.000\nSo what we are going to do today is look at the way that this works, and you will see that it is not as hard as they say.\n")
+	tests := []struct {
+		name      string
+		label     string
+		requested string
+		wantLang  string
+		warns     bool
+	}{
+		{"unlabeled", "", "", "en", false},
+		{"labeled", "en-US", "", "en-US", false},
+		{"mislabeled", "de", "", "de", true},
+		{"requested", "", "fr", "en", true},
+	}
+	for _, tt := range tests {
+		src := transcript.Source{VideoID: "abc", Language: tt.label}
+		detected, warning := CheckLanguage(&src, english, tt.requested)
+		if detected != "en" {
+			t.Errorf("%s: detected %q, want en", tt.name, detected)
+		}
+		if src.Language != tt.wantLang {
+			t.Errorf("%s: Language = %q, want %q", tt.name, src.Language, tt.wantLang)
+		}
+		if (warning != "") != tt.warns {
+			t.Errorf("%s: warning = %q, want a warning: %v", tt.name, warning, tt.warns)
+		}
+	}
+}
+
+func TestFindCaptionTrack(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/youtube/v3/videos":
+			fmt.Fprint(w, `{"items":[{"id":"abc","snippet":{"title":"T","channelId":"UC1"}}]}`)
+		case "/youtube/v3/captions":
+			fmt.Fprint(w, `{"items":[
+				{"id":"en1","snippet":{"language":"en","trackKind":"standard"}},
+				{"id":"fr1","snippet":{"language":"fr","trackKind":"standard"}},
+				{"id":"fr2","snippet":{"language":"fr-CA","trackKind":"asr"}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	tests := []struct {
+		captionID, lang string
+		want            string
+		wantErr         bool
+	}{
+		{"fr2", "fr", "fr2", false},
+		{"", "fr", "fr1", false},
+		{"", "fr-CA", "fr2", false},
+		{"gone", "fr", "", true},
+		{"", "de", "", true},
+	}
+	for _, tt := range tests {
+		src, track, err := client.FindCaptionTrack("abc", tt.captionID, tt.lang)
+		if tt.wantErr {
+			if !errors.Is(err, ErrNoCaptions) {
+				t.Errorf("FindCaptionTrack(%q, %q) error = %v, want ErrNoCaptions", tt.captionID, tt.lang, err)
+			}
+			continue
+		}
+		if err != nil || track.CaptionID != tt.want || src.Language != track.Language {
+			t.Errorf("FindCaptionTrack(%q, %q) = %+v, %v; want %s", tt.captionID, tt.lang, track, err, tt.want)
+		}
+	}
+}
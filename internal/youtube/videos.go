@@ -1,18 +1,31 @@
 package youtube
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"os"
 	"strings"
+	"sync"
+
+	"google.golang.org/api/youtube/v3"
 )
 
 // VideoInfo represents metadata for a YouTube video.
 type VideoInfo struct {
-	VideoID     string `json:"video_id"`
-	Title       string `json:"title"`
-	Description string `json:"description,omitempty"`
-	ViewCount   uint64 `json:"view_count"`
-	Date        string `json:"published_at"`
+	VideoID      string   `json:"video_id"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	ViewCount    uint64   `json:"view_count"`
+	LikeCount    uint64   `json:"like_count"`
+	CommentCount uint64   `json:"comment_count"`
+	HasCaptions  bool     `json:"has_captions"`
+	IsLive       bool     `json:"is_live"`
+	Date         string   `json:"published_at"`
+	// DurationSeconds is the video's length; zero for live streams still
+	// going on.
+	DurationSeconds int `json:"duration_seconds"`
 }
 
 // VideoDetails represents detailed metadata for a YouTube video.
@@ -30,15 +43,208 @@ type VideoDetails struct {
 	Tags         []string `json:"tags,omitempty"`
 }
 
+// ShortsFilter selects how videos shorter than ListOptions.MinDurationSeconds are treated.
+type ShortsFilter int
+
+const (
+	// ShortsExclude drops shorts. It is the default.
+	ShortsExclude ShortsFilter = iota
+	// ShortsInclude keeps every video regardless of duration.
+	ShortsInclude
+	// ShortsOnly keeps only shorts.
+	ShortsOnly
+)
+
+// LiveFilter selects how live streams, current or past, are treated.
+type LiveFilter int
+
+const (
+	// LiveInclude keeps live streams. It is the default.
+	LiveInclude LiveFilter = iota
+	// LiveExclude drops live streams.
+	LiveExclude
+	// LiveOnly keeps only live streams.
+	LiveOnly
+)
+
+// ListOptions configures which videos StreamVideos returns and how it fetches them.
+type ListOptions struct {
+	// MinDurationSeconds is the duration below which a video counts as a short.
+	MinDurationSeconds int
+	// Shorts filters videos by the MinDurationSeconds threshold.
+	Shorts ShortsFilter
+	// Live filters videos that are or were live streams.
+	Live LiveFilter
+	// IncludeDescription populates VideoInfo.Description.
+	IncludeDescription bool
+	// IncludeTags populates VideoInfo.Tags.
+	IncludeTags bool
+	// Workers is the number of concurrent Videos.List calls. Defaults to 4.
+	Workers int
+}
+
+// VideoResult is one item from StreamVideos: a video or the error that ended the stream.
+type VideoResult struct {
+	Video VideoInfo
+	Err   error
+}
+
 // ListVideos retrieves all videos from a channel, filtering out shorts.
 func (c *Client) ListVideos(channelID string, minDurationSeconds int, includeDescription bool) ([]VideoInfo, error) {
+	opts := ListOptions{MinDurationSeconds: minDurationSeconds, IncludeDescription: includeDescription}
+
+	videos := []VideoInfo{}
+	for video, err := range c.Videos(context.Background(), channelID, opts) {
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+	if os.Getenv("YTT_DEBUG") != "" {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Returning %d videos after filtering\n", len(videos))
+	}
+	return videos, nil
+}
+
+// Videos returns an iterator over a channel's uploads, in playlist order, for
+// use with range. Breaking out of the loop stops the underlying listing, so
+// callers can apply their own filters and stop early without fetching or
+// holding the rest of the channel. An error is yielded once, as the last
+// item.
+func (c *Client) Videos(ctx context.Context, channelID string, opts ListOptions) iter.Seq2[VideoInfo, error] {
+	return func(yield func(VideoInfo, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		for r := range c.StreamVideos(ctx, channelID, opts) {
+			if !yield(r.Video, r.Err) || r.Err != nil {
+				return
+			}
+		}
+	}
+}
+
+// videoBatch is one uploads playlist page worth of video IDs, at most 50.
+type videoBatch struct {
+	seq    int
+	ids    []string
+	videos []*youtube.Video
+	err    error
+}
+
+// StreamVideos lists a channel's uploads as a pipeline: playlist pages are
+// read in sequence, each page's video IDs are looked up by a pool of workers
+// calling Videos.List concurrently, and videos are sent on the returned
+// channel in upload-playlist order as soon as their page is ready. Memory use
+// is bounded by the number of workers rather than the size of the channel:
+// at most twice as many pages as workers are read ahead of the one being
+// sent.
+//
+// The channel is closed when the listing is complete or fails; a failure is
+// delivered as a final VideoResult with Err set. Cancel ctx to stop early.
+func (c *Client) StreamVideos(ctx context.Context, channelID string, opts ListOptions) <-chan VideoResult {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 4
+	}
+
+	out := make(chan VideoResult)
+	go func() {
+		defer close(out)
+
+		send := func(r VideoResult) bool {
+			select {
+			case out <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		uploadsPlaylistID, err := c.resolveUploadsPlaylist(channelID)
+		if err != nil {
+			send(VideoResult{Err: err})
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		pages := make(chan videoBatch, workers)
+		fetched := make(chan videoBatch, workers)
+		// A page takes a slot before it is read and gives it back once its
+		// videos are sent, so a slow batch can't leave an ever-growing run
+		// of later ones waiting in pending.
+		slots := make(chan struct{}, 2*workers)
+
+		go func() {
+			defer close(pages)
+			c.pageUploads(ctx, uploadsPlaylistID, slots, pages)
+		}()
+
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for b := range pages {
+					b.videos, b.err = c.fetchVideoBatch(ctx, b.ids)
+					select {
+					case fetched <- b:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(fetched)
+		}()
+
+		// Workers finish out of order; hold batches until their turn so
+		// results keep playlist order.
+		pending := map[int]videoBatch{}
+		next := 0
+		for b := range fetched {
+			pending[b.seq] = b
+			for {
+				ready, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				<-slots
+				next++
+				if ready.err != nil {
+					send(VideoResult{Err: ready.err})
+					return
+				}
+				for _, video := range ready.videos {
+					if !c.keepVideo(video, opts) {
+						continue
+					}
+					if !send(VideoResult{Video: newVideoInfo(video, opts)}) {
+						return
+					}
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			send(VideoResult{Err: ctx.Err()})
+		}
+	}()
+	return out
+}
+
+func (c *Client) resolveUploadsPlaylist(channelID string) (string, error) {
 	debug := os.Getenv("YTT_DEBUG") != ""
 
 	if channelID == "" {
 		var err error
-		channelID, err = c.getAuthenticatedChannelID()
+		channelID, err = c.AuthenticatedChannelID()
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 	}
 	if debug {
@@ -47,82 +253,143 @@ func (c *Client) ListVideos(channelID string, minDurationSeconds int, includeDes
 
 	uploadsPlaylistID, err := c.getUploadsPlaylistID(channelID)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	if debug {
 		fmt.Fprintf(os.Stderr, "[DEBUG] Uploads playlist ID: %s\n", uploadsPlaylistID)
 	}
+	return uploadsPlaylistID, nil
+}
 
-	videos := []VideoInfo{}
+// pageUploads sends each page of the uploads playlist to pages, taking a
+// slot in slots before reading each one. Pages have to be read in order
+// because each one carries the next page's token. A failure is sent as a
+// batch with err set.
+func (c *Client) pageUploads(ctx context.Context, playlistID string, slots chan<- struct{}, pages chan<- videoBatch) {
 	nextPageToken := ""
-
-	for {
+	for seq := 0; ; seq++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
 		playlistCall := c.Service.PlaylistItems.List([]string{"snippet"}).
-			PlaylistId(uploadsPlaylistID).
-			MaxResults(50)
+			PlaylistId(playlistID).
+			MaxResults(50).
+			Context(ctx)
 		if nextPageToken != "" {
 			playlistCall = playlistCall.PageToken(nextPageToken)
 		}
 
+		b := videoBatch{seq: seq}
 		playlistResponse, err := playlistCall.Do()
 		if err != nil {
-			return nil, fmt.Errorf("error retrieving playlist items: %w", err)
-		}
-		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Playlist returned %d items\n", len(playlistResponse.Items))
-		}
-
-		var videoIDs []string
-		for _, item := range playlistResponse.Items {
-			videoIDs = append(videoIDs, item.Snippet.ResourceId.VideoId)
-		}
-
-		if len(videoIDs) > 0 {
-			if debug {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Fetching details for video IDs: %v\n", videoIDs)
-			}
-			videosCall := c.Service.Videos.List([]string{"snippet", "statistics", "contentDetails"}).
-				Id(strings.Join(videoIDs, ","))
-			videosResponse, err := videosCall.Do()
-			if err != nil {
-				return nil, fmt.Errorf("error retrieving video statistics: %w", err)
+			b.err = fmt.Errorf("error retrieving playlist items: %w", err)
+		} else {
+			if os.Getenv("YTT_DEBUG") != "" {
+				fmt.Fprintf(os.Stderr, "[DEBUG] Playlist returned %d items\n", len(playlistResponse.Items))
 			}
-			if debug {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Videos.List returned %d items\n", len(videosResponse.Items))
+			for _, item := range playlistResponse.Items {
+				b.ids = append(b.ids, item.Snippet.ResourceId.VideoId)
 			}
+		}
 
-			for _, video := range videosResponse.Items {
-				duration := ParseDuration(video.ContentDetails.Duration)
-				if debug {
-					fmt.Fprintf(os.Stderr, "[DEBUG] Video %s: duration=%s (%ds), minDuration=%d, isShort=%v\n",
-						video.Id, video.ContentDetails.Duration, duration, minDurationSeconds, duration < minDurationSeconds)
-				}
-				if isShort(video.ContentDetails.Duration, minDurationSeconds) {
-					continue
-				}
-				info := VideoInfo{
-					VideoID:   video.Id,
-					Title:     video.Snippet.Title,
-					ViewCount: video.Statistics.ViewCount,
-					Date:      video.Snippet.PublishedAt,
-				}
-				if includeDescription {
-					info.Description = video.Snippet.Description
-				}
-				videos = append(videos, info)
-			}
+		select {
+		case pages <- b:
+		case <-ctx.Done():
+			return
+		}
+		if b.err != nil {
+			return
 		}
 
 		nextPageToken = playlistResponse.NextPageToken
 		if nextPageToken == "" {
-			break
+			return
 		}
 	}
+}
 
-	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Returning %d videos after filtering\n", len(videos))
+func (c *Client) fetchVideoBatch(ctx context.Context, videoIDs []string) ([]*youtube.Video, error) {
+	if len(videoIDs) == 0 {
+		return nil, nil
 	}
-	return videos, nil
+	if os.Getenv("YTT_DEBUG") != "" {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Fetching details for video IDs: %v\n", videoIDs)
+	}
+	videosCall := c.Service.Videos.List([]string{"snippet", "statistics", "contentDetails", "liveStreamingDetails"}).
+		Id(strings.Join(videoIDs, ",")).
+		Context(ctx)
+	videosResponse, err := videosCall.Do()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving video statistics: %w", err)
+	}
+	if os.Getenv("YTT_DEBUG") != "" {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Videos.List returned %d items\n", len(videosResponse.Items))
+	}
+	return videosResponse.Items, nil
+}
+
+func (c *Client) keepVideo(video *youtube.Video, opts ListOptions) bool {
+	live := isLive(video)
+	if os.Getenv("YTT_DEBUG") != "" {
+		duration := ParseDuration(video.ContentDetails.Duration)
+		fmt.Fprintf(os.Stderr, "[DEBUG] Video %s: duration=%s (%ds), minDuration=%d, isShort=%v, isLive=%v\n",
+			video.Id, video.ContentDetails.Duration, duration, opts.MinDurationSeconds, duration < opts.MinDurationSeconds, live)
+	}
+	return matchesFilters(video.ContentDetails.Duration, live, opts)
+}
+
+// matchesFilters applies the shorts and live filters in opts to a video.
+func matchesFilters(duration string, live bool, opts ListOptions) bool {
+	switch opts.Live {
+	case LiveExclude:
+		if live {
+			return false
+		}
+	case LiveOnly:
+		if !live {
+			return false
+		}
+	}
+
+	// Live streams report a zero duration while they're on air, so they
+	// are never treated as shorts.
+	short := !live && isShort(duration, opts.MinDurationSeconds)
+	switch opts.Shorts {
+	case ShortsInclude:
+		return true
+	case ShortsOnly:
+		return short
+	default:
+		return !short
+	}
+}
+
+// isLive reports whether a video is, was, or will be a live broadcast.
+func isLive(video *youtube.Video) bool {
+	return video.LiveStreamingDetails != nil || video.Snippet.LiveBroadcastContent == "live" || video.Snippet.LiveBroadcastContent == "upcoming"
+}
+
+func newVideoInfo(video *youtube.Video, opts ListOptions) VideoInfo {
+	info := VideoInfo{
+		VideoID:         video.Id,
+		Title:           video.Snippet.Title,
+		ViewCount:       video.Statistics.ViewCount,
+		LikeCount:       video.Statistics.LikeCount,
+		CommentCount:    video.Statistics.CommentCount,
+		HasCaptions:     video.ContentDetails.Caption == "true",
+		IsLive:          isLive(video),
+		Date:            video.Snippet.PublishedAt,
+		DurationSeconds: ParseDuration(video.ContentDetails.Duration),
+	}
+	if opts.IncludeDescription {
+		info.Description = video.Snippet.Description
+	}
+	if opts.IncludeTags {
+		info.Tags = video.Snippet.Tags
+	}
+	return info
 }
 
 // GetVideoDetails retrieves detailed metadata for a single video.
@@ -134,7 +401,7 @@ func (c *Client) GetVideoDetails(videoID string) (*VideoDetails, error) {
 	}
 
 	if len(response.Items) == 0 {
-		return nil, fmt.Errorf("video %s not found", videoID)
+		return nil, fmt.Errorf("video %s %w", videoID, ErrNotFound)
 	}
 
 	video := response.Items[0]
@@ -153,7 +420,26 @@ func (c *Client) GetVideoDetails(videoID string) (*VideoDetails, error) {
 	}, nil
 }
 
-func (c *Client) getAuthenticatedChannelID() (string, error) {
+// VideoTitles looks up the titles of videos, 50 IDs per request. Videos that
+// don't exist are absent from the result.
+func (c *Client) VideoTitles(videoIDs []string) (map[string]string, error) {
+	titles := make(map[string]string, len(videoIDs))
+	for start := 0; start < len(videoIDs); start += 50 {
+		end := min(start+50, len(videoIDs))
+		call := c.Service.Videos.List([]string{"snippet"}).Id(strings.Join(videoIDs[start:end], ","))
+		response, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving video details: %w", err)
+		}
+		for _, video := range response.Items {
+			titles[video.Id] = video.Snippet.Title
+		}
+	}
+	return titles, nil
+}
+
+// AuthenticatedChannelID returns the ID of the authenticated user's channel.
+func (c *Client) AuthenticatedChannelID() (string, error) {
 	channelsCall := c.Service.Channels.List([]string{"id", "statistics"}).Mine(true)
 	channelsResponse, err := channelsCall.Do()
 	if err != nil {
@@ -177,7 +463,7 @@ func (c *Client) getUploadsPlaylistID(channelID string) (string, error) {
 		return "", fmt.Errorf("error retrieving channel details: %w", err)
 	}
 	if len(channelResponse.Items) == 0 {
-		return "", fmt.Errorf("channel %s not found", channelID)
+		return "", fmt.Errorf("channel %s %w", channelID, ErrNotFound)
 	}
 	return channelResponse.Items[0].ContentDetails.RelatedPlaylists.Uploads, nil
 }
@@ -1,6 +1,20 @@
 package youtube
 
-import "testing"
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
 
 func TestParseDuration(t *testing.T) {
 	tests := []struct {
@@ -52,3 +66,177 @@ func TestIsShort(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchesFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration string
+		live     bool
+		opts     ListOptions
+		want     bool
+	}{
+		{"default drops shorts", "PT30S", false, ListOptions{MinDurationSeconds: 60}, false},
+		{"default keeps long videos", "PT10M", false, ListOptions{MinDurationSeconds: 60}, true},
+		{"default keeps live", "PT0S", true, ListOptions{MinDurationSeconds: 60}, true},
+		{"include shorts", "PT30S", false, ListOptions{MinDurationSeconds: 60, Shorts: ShortsInclude}, true},
+		{"only shorts keeps short", "PT30S", false, ListOptions{MinDurationSeconds: 60, Shorts: ShortsOnly}, true},
+		{"only shorts drops long", "PT10M", false, ListOptions{MinDurationSeconds: 60, Shorts: ShortsOnly}, false},
+		{"only shorts drops live", "PT0S", true, ListOptions{MinDurationSeconds: 60, Shorts: ShortsOnly}, false},
+		{"exclude live", "PT2H", true, ListOptions{Live: LiveExclude}, false},
+		{"only live keeps live", "PT2H", true, ListOptions{Live: LiveOnly}, true},
+		{"only live drops uploads", "PT10M", false, ListOptions{Live: LiveOnly}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesFilters(tt.duration, tt.live, tt.opts); got != tt.want {
+				t.Errorf("matchesFilters(%q, %v, %+v) = %v, want %v", tt.duration, tt.live, tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestClient returns a Client whose API calls go to handler.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	service, err := youtube.NewService(context.Background(),
+		option.WithEndpoint(server.URL),
+		option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{Service: service}
+}
+
+// fakeChannel serves an uploads playlist of n videos split into pages of
+// 50. Every third video is a 30-second short.
+func fakeChannel(n int) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/youtube/v3/channels", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items":[{"id":"UC1","contentDetails":{"relatedPlaylists":{"uploads":"UU1"}}}]}`)
+	})
+	mux.HandleFunc("/youtube/v3/playlistItems", func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		end := min(start+50, n)
+		var items []string
+		for i := start; i < end; i++ {
+			items = append(items, fmt.Sprintf(`{"snippet":{"resourceId":{"videoId":"v%d"}}}`, i))
+		}
+		next := ""
+		if end < n {
+			next = strconv.Itoa(end)
+		}
+		fmt.Fprintf(w, `{"items":[%s],"nextPageToken":%q}`, strings.Join(items, ","), next)
+	})
+	mux.HandleFunc("/youtube/v3/videos", func(w http.ResponseWriter, r *http.Request) {
+		var items []string
+		for _, id := range strings.Split(r.URL.Query().Get("id"), ",") {
+			i, _ := strconv.Atoi(strings.TrimPrefix(id, "v"))
+			duration := "PT10M"
+			if i%3 == 0 {
+				duration = "PT30S"
+			}
+			items = append(items, fmt.Sprintf(`{"id":%q,"snippet":{"title":"Video %d"},"contentDetails":{"duration":%q},"statistics":{"viewCount":"%d"}}`, id, i, duration, i))
+		}
+		fmt.Fprintf(w, `{"items":[%s]}`, strings.Join(items, ","))
+	})
+	return mux
+}
+
+func TestStreamVideosOrderAndFilter(t *testing.T) {
+	client := newTestClient(t, fakeChannel(175))
+
+	var got []string
+	for r := range client.StreamVideos(context.Background(), "UC1", ListOptions{MinDurationSeconds: 60, Workers: 3}) {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		got = append(got, r.Video.VideoID)
+	}
+
+	var want []string
+	for i := range 175 {
+		if i%3 != 0 {
+			want = append(want, fmt.Sprintf("v%d", i))
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StreamVideos() returned %d videos out of order or unfiltered:\n got %v\nwant %v", len(got), got, want)
+	}
+}
+
+func TestStreamVideosStopEarly(t *testing.T) {
+	client := newTestClient(t, fakeChannel(500))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := client.StreamVideos(ctx, "UC1", ListOptions{Workers: 2})
+	<-stream
+	cancel()
+	for range stream {
+		// Drain until the producer notices the cancellation and closes.
+	}
+}
+
+func TestStreamVideosReadAhead(t *testing.T) {
+	var pages atomic.Int32
+	release := make(chan struct{})
+	fake := fakeChannel(1000)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/youtube/v3/playlistItems":
+			pages.Add(1)
+		case strings.HasPrefix(r.URL.Query().Get("id"), "v0,"):
+			<-release
+		}
+		fake.ServeHTTP(w, r)
+	}))
+
+	stream := client.StreamVideos(context.Background(), "UC1", ListOptions{Workers: 2})
+	time.Sleep(200 * time.Millisecond)
+	if n := pages.Load(); n > 4 {
+		t.Errorf("read %d pages while the first was still being looked up, want at most 4", n)
+	}
+	close(release)
+	var got int
+	for r := range stream {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		got++
+	}
+	if got != 1000 {
+		t.Errorf("StreamVideos() returned %d videos, want 1000", got)
+	}
+}
+
+func TestVideosBreak(t *testing.T) {
+	var pages atomic.Int32
+	fake := fakeChannel(500)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/youtube/v3/playlistItems" {
+			pages.Add(1)
+		}
+		fake.ServeHTTP(w, r)
+	}))
+
+	var got []string
+	for video, err := range client.Videos(context.Background(), "UC1", ListOptions{Workers: 1}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, video.VideoID)
+		if len(got) == 3 {
+			break
+		}
+	}
+
+	if want := []string{"v0", "v1", "v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Videos() yielded %v, want %v", got, want)
+	}
+	if n := pages.Load(); n >= 10 {
+		t.Errorf("listing fetched all %d playlist pages after the loop stopped", n)
+	}
+}
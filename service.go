@@ -0,0 +1,27 @@
+package ytt
+
+import (
+	"net/http"
+
+	ytclient "github.com/n2p5/ytt/internal/youtube"
+	"google.golang.org/api/youtube/v3"
+)
+
+// NewService returns a YouTube Data API service for a BatchDownloader,
+// authorized with the OAuth client secret at oauthPath and the token the
+// ytt command saved at tokenPath. Without a usable token it starts the same
+// browser authorization as the command.
+//
+// If transport isn't nil, every request, including token refreshes, is sent
+// through it, such as to use a proxy or instrument the requests.
+func NewService(oauthPath, tokenPath string, transport http.RoundTripper) (*youtube.Service, error) {
+	var opts []ytclient.Option
+	if transport != nil {
+		opts = append(opts, ytclient.WithTransport(transport))
+	}
+	client, err := ytclient.NewClient(oauthPath, tokenPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return client.Service, nil
+}
@@ -0,0 +1,91 @@
+package ytt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/n2p5/ytt/internal/manifest"
+	"github.com/n2p5/ytt/internal/trash"
+)
+
+// Transcript is a downloaded transcript, ready to be saved.
+type Transcript struct {
+	VideoID     string
+	Title       string
+	ChannelID   string
+	PublishedAt time.Time
+	// Language is the caption track's language code.
+	Language string
+	// Format is the format Data was converted to, or empty if it holds
+	// captions as YouTube provided them.
+	Format string
+	// Process is the processing pipeline applied to Data, if any.
+	Process string
+	// Filename is the name the ytt command saves the transcript under.
+	Filename string
+	Data     []byte
+}
+
+// Sink saves transcripts downloaded by a BatchDownloader. Save is called
+// concurrently when the downloader's Concurrency is above 1.
+type Sink interface {
+	Save(ctx context.Context, t Transcript) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, t Transcript) error
+
+// Save calls f.
+func (f SinkFunc) Save(ctx context.Context, t Transcript) error {
+	return f(ctx, t)
+}
+
+// DirSink saves transcripts to a directory laid out as the ytt command lays
+// out its output directory, with a manifest and a trash for replaced files,
+// so the ytt command can work with it too.
+type DirSink struct {
+	dir string
+	mw  *manifest.Writer
+}
+
+// NewDirSink opens the output directory dir, creating it if needed. Close
+// must be called to finish writing the manifest.
+func NewDirSink(dir string) (*DirSink, error) {
+	mw, err := manifest.OpenWriter(dir, manifest.WriterOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &DirSink{dir: dir, mw: mw}, nil
+}
+
+// Save writes the transcript and records it in the manifest.
+func (s *DirSink) Save(ctx context.Context, t Transcript) error {
+	path := filepath.Join(s.dir, t.Filename)
+	if _, err := trash.Move(s.dir, path); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, t.Data, 0644); err != nil {
+		return fmt.Errorf("error writing transcript: %w", err)
+	}
+	s.mw.Record(manifest.Entry{
+		VideoID:     t.VideoID,
+		Title:       t.Title,
+		ChannelID:   t.ChannelID,
+		PublishedAt: t.PublishedAt,
+		Language:    t.Language,
+		File:        t.Filename,
+		Format:      t.Format,
+		Process:     t.Process,
+		Hash:        manifest.Hash(t.Data),
+		Status:      manifest.StatusOK,
+	})
+	return nil
+}
+
+// Close flushes the manifest.
+func (s *DirSink) Close() error {
+	return s.mw.Close()
+}
@@ -0,0 +1,44 @@
+package ytt
+
+import (
+	"context"
+
+	ytclient "github.com/n2p5/ytt/internal/youtube"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Source lists videos for a BatchDownloader to download.
+type Source interface {
+	VideoIDs(ctx context.Context, yt *youtube.Service) ([]string, error)
+}
+
+// SourceFunc adapts a function to a Source.
+type SourceFunc func(ctx context.Context, yt *youtube.Service) ([]string, error)
+
+// VideoIDs calls f.
+func (f SourceFunc) VideoIDs(ctx context.Context, yt *youtube.Service) ([]string, error) {
+	return f(ctx, yt)
+}
+
+// Videos returns a Source listing the given video IDs.
+func Videos(videoIDs ...string) Source {
+	return SourceFunc(func(context.Context, *youtube.Service) ([]string, error) {
+		return videoIDs, nil
+	})
+}
+
+// Channel returns a Source listing a channel's uploads, leaving out shorts
+// under a minute long as the ytt command does by default.
+func Channel(channelID string) Source {
+	return SourceFunc(func(ctx context.Context, yt *youtube.Service) ([]string, error) {
+		client := &ytclient.Client{Service: yt}
+		var ids []string
+		for v, err := range client.Videos(ctx, channelID, ytclient.ListOptions{MinDurationSeconds: 60}) {
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, v.VideoID)
+		}
+		return ids, nil
+	})
+}